@@ -0,0 +1,168 @@
+// Package analysis computes expected coverage of the message over time
+// from analytical models of rumor spreading, so simulated propagation
+// can be checked against theory: big gaps point either to the network
+// topology being far from the random graph models assume, or to bugs.
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/stats"
+)
+
+// maxRounds bounds the number of rounds of the expected curve, for models
+// which never reach all nodes, i.e. with zero fanout.
+const maxRounds = 1000
+
+// Model represents analytical rumor spreading model.
+type Model int
+
+// Known models. In each round of the push model, every informed node
+// sends the message to fanout random nodes. In push-pull one, every
+// uninformed node also asks a random node for the message.
+const (
+	Push Model = iota
+	PushPull
+)
+
+// ParseModel parses model name, as returned by Model.String.
+func ParseModel(s string) (Model, error) {
+	switch s {
+	case "push":
+		return Push, nil
+	case "pushpull":
+		return PushPull, nil
+	}
+	return 0, fmt.Errorf("unknown model '%s'", s)
+}
+
+// String implements Stringer interface for Model.
+func (m Model) String() string {
+	if m == PushPull {
+		return "pushpull"
+	}
+	return "push"
+}
+
+// Params describes network the model is applied to.
+type Params struct {
+	Nodes  int
+	Fanout int           // nodes each informed node pushes the message to per round
+	Round  time.Duration // duration of a single round, i.e. per hop latency
+}
+
+// Point is a single point of the coverage curve.
+type Point struct {
+	Time     time.Duration
+	Coverage float64 // fraction of nodes having the message
+}
+
+// Curve represents coverage over time, in order of time.
+type Curve []Point
+
+// Expected returns mean-field expected coverage after each round of the
+// model, starting from a single informed node, until all but half a node
+// are expected to be informed. Push model informs uninformed node in a
+// round unless none of pushes hits it:
+//
+//	x' = 1 - (1-x) * (1 - f/(n-1))^(n*x)
+//
+// and push-pull one additionally requires its own pull to miss, which
+// happens with probability 1-x.
+func Expected(model Model, p Params) Curve {
+	if p.Nodes < 2 {
+		return Curve{{Coverage: 1}}
+	}
+	n := float64(p.Nodes)
+	miss := 1 - math.Min(float64(p.Fanout)/(n-1), 1)
+	x := 1 / n
+	curve := Curve{{Coverage: x}}
+	for round := 1; round <= maxRounds && x < 1-0.5/n; round++ {
+		uninformed := (1 - x) * math.Pow(miss, n*x)
+		if model == PushPull {
+			uninformed *= 1 - x
+		}
+		x = 1 - uninformed
+		curve = append(curve, Point{Time: time.Duration(round) * p.Round, Coverage: x})
+	}
+	return curve
+}
+
+// Rounds returns asymptotic number of rounds the model needs to inform
+// all nodes with high probability: log_{1+f}(n) + ln(n)/f for push
+// (Frieze and Grimmett, generalized to fanout f), and log_{2+f}(n) +
+// ln(ln(n)) for push-pull (Karp et al.). Both omit constant terms, so
+// they are meaningful for large networks only.
+func Rounds(model Model, p Params) float64 {
+	n, f := float64(p.Nodes), float64(p.Fanout)
+	if n < 2 || f <= 0 {
+		return 0
+	}
+	if model == PushPull {
+		return math.Log(n)/math.Log(2+f) + math.Log(math.Log(n))
+	}
+	return math.Log(n)/math.Log(1+f) + math.Log(n)/f
+}
+
+// At returns coverage of the curve at the given time, holding the
+// previous point's value between points.
+func (c Curve) At(t time.Duration) float64 {
+	i := sort.Search(len(c), func(i int) bool { return c[i].Time > t })
+	if i == 0 {
+		return 0
+	}
+	return c[i-1].Coverage
+}
+
+// Simulated returns coverage curve of the propagation log, for network
+// of the given number of nodes, with a point for each node reached.
+func Simulated(plog *propagation.Log, nodes int) Curve {
+	tree := stats.NewTree(plog)
+	times := make([]int, 0, len(tree.Time))
+	for _, ts := range tree.Time {
+		times = append(times, ts)
+	}
+	sort.Ints(times)
+	curve := make(Curve, len(times))
+	for i, ts := range times {
+		curve[i] = Point{
+			Time:     time.Duration(ts) * time.Millisecond,
+			Coverage: float64(i+1) / float64(nodes),
+		}
+	}
+	return curve
+}
+
+// Deviation returns the largest absolute difference of coverage between
+// two curves, checked at time of each point of both of them.
+func Deviation(a, b Curve) float64 {
+	var max float64
+	for _, c := range []Curve{a, b} {
+		for _, p := range c {
+			if d := math.Abs(a.At(p.Time) - b.At(p.Time)); d > max {
+				max = d
+			}
+		}
+	}
+	return max
+}
+
+// EstimateRound estimates duration of the model round from propagation
+// log, as the median delay between node and its parent in the first
+// arrival propagation tree (see stats.Tree), i.e. per hop latency.
+func EstimateRound(plog *propagation.Log) time.Duration {
+	tree := stats.NewTree(plog)
+	delays := make([]int, 0, len(tree.Parent))
+	for node, parent := range tree.Parent {
+		delays = append(delays, tree.Time[node]-tree.Time[parent])
+	}
+	if len(delays) == 0 {
+		return 0
+	}
+	sort.Ints(delays)
+	return time.Duration(delays[len(delays)/2]) * time.Millisecond
+}
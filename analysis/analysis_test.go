@@ -0,0 +1,52 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestExpected(t *testing.T) {
+	p := Params{Nodes: 1000, Fanout: 1, Round: 10 * time.Millisecond}
+	push, pushPull := Expected(Push, p), Expected(PushPull, p)
+	for _, c := range []Curve{push, pushPull} {
+		if c[0].Coverage != 0.001 || c[len(c)-1].Coverage < 0.999 {
+			t.Fatalf("Expected curve from 0.1%% to full coverage, got %v", c)
+		}
+		for i := 1; i < len(c); i++ {
+			if c[i].Coverage < c[i-1].Coverage || c[i].Time != time.Duration(i)*p.Round {
+				t.Fatalf("Expected coverage to grow every round, got %v", c)
+			}
+		}
+	}
+	if len(pushPull) >= len(push) {
+		t.Fatalf("Expected push-pull to take fewer rounds than push, got %d and %d", len(pushPull), len(push))
+	}
+	if r := Rounds(Push, p); math.Abs(r-(math.Log2(1000)+math.Log(1000))) > 1e-9 {
+		t.Fatalf("Expected log2(n)+ln(n) rounds for push, got %v", r)
+	}
+	if c := Expected(Push, Params{Nodes: 10}); len(c) != maxRounds+1 {
+		t.Fatalf("Expected curve without fanout to be bounded, got %d points", len(c))
+	}
+}
+
+func TestDeviation(t *testing.T) {
+	plog := propagation.NewLog(2)
+	plog.AddStep(10, []int{0, 1}, []int{0})
+	plog.AddStep(20, []int{1, 2, 1, 3}, []int{1, 2})
+
+	sim := Simulated(plog, 4)
+	if len(sim) != 4 || sim.At(15*time.Millisecond) != 0.5 || sim.At(20*time.Millisecond) != 1 {
+		t.Fatalf("Unexpected simulated curve: %v", sim)
+	}
+	if round := EstimateRound(plog); round != 10*time.Millisecond {
+		t.Fatalf("Expected 10ms round, got %v", round)
+	}
+
+	model := Curve{{0, 0.25}, {10 * time.Millisecond, 0.5}, {20 * time.Millisecond, 0.75}}
+	if d := Deviation(sim, model); d != 0.25 {
+		t.Fatalf("Expected deviation 0.25, got %v", d)
+	}
+}
@@ -0,0 +1,114 @@
+// Package bench provides reproducible workloads for benchmarking
+// simulators, so performance regressions and protocol overhead can be
+// tracked over time. Workload graphs are generated from fixed seeds, so
+// each run of the benchmark simulates propagation over the same network.
+//
+// Benchmarks for each simulator live in this package tests:
+//
+//	go test -run XXX -bench . -benchmem ./bench/
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/stats"
+)
+
+// Workload describes network and message of the benchmark.
+type Workload struct {
+	Name   string
+	Nodes  int
+	Degree int   // average number of peers of each node
+	Seed   int64 // seed of the graph and simulator random sources
+	TTL    int
+	Size   int // message payload size
+}
+
+// Workloads holds standard workloads with 1k, 10k and 100k nodes.
+var Workloads = []Workload{
+	{Name: "1k", Nodes: 1000, Degree: 6, Seed: 1, TTL: 10, Size: 400},
+	{Name: "10k", Nodes: 10000, Degree: 6, Seed: 2, TTL: 10, Size: 400},
+	{Name: "100k", Nodes: 100000, Degree: 6, Seed: 3, TTL: 10, Size: 400},
+}
+
+// String implements Stringer interface for Workload.
+func (w Workload) String() string {
+	return fmt.Sprintf("%s (%d nodes, degree %d, seed %d)", w.Name, w.Nodes, w.Degree, w.Seed)
+}
+
+// Graph generates workload network: nodes form a ring, so the network is
+// connected, and the rest of links connect random pairs of nodes. The same
+// workload always yields the same graph.
+func (w Workload) Graph() *graph.Graph {
+	rnd := rand.New(rand.NewSource(w.Seed))
+	g := graph.NewGraph()
+	for i := 0; i < w.Nodes; i++ {
+		g.AddNode(node(strconv.Itoa(i)))
+	}
+
+	linked := make(map[[2]int]bool)
+	link := func(a, b int) {
+		if b < a {
+			a, b = b, a
+		}
+		if a == b || linked[[2]int{a, b}] {
+			return
+		}
+		linked[[2]int{a, b}] = true
+		g.AddLink(strconv.Itoa(a), strconv.Itoa(b))
+	}
+	for i := 0; i < w.Nodes; i++ {
+		link(i, (i+1)%w.Nodes)
+	}
+	// each link adds a peer to two nodes
+	for len(linked) < w.Nodes*w.Degree/2 {
+		link(rnd.Intn(w.Nodes), rnd.Intn(w.Nodes))
+	}
+	return g
+}
+
+// node implements string-only graph.Node.
+type node string
+
+// ID implements graph.Node interface.
+func (n node) ID() string { return string(n) }
+
+// Result holds outcome of the single workload run.
+type Result struct {
+	Duration   time.Duration // real time of the simulation
+	Coverage   stats.Coverage
+	Time       time.Duration // propagation time reported by simulator
+	Duplicates int
+	Traffic    *propagation.Traffic // nil if not tracked by simulator
+}
+
+// String implements Stringer interface for Result.
+func (r Result) String() string {
+	s := fmt.Sprintf("took %v, coverage %v, time %v, duplicates %d", r.Duration, r.Coverage, r.Time, r.Duplicates)
+	if r.Traffic != nil {
+		s += fmt.Sprintf(", traffic %d bytes", r.Traffic.TotalBytes())
+	}
+	return s
+}
+
+// Run sends workload message from node 0 with simulator sim running on
+// graph g, which should be the workload graph.
+func Run(w Workload, g *graph.Graph, sim propagation.Simulator) Result {
+	start := time.Now()
+	plog := sim.SendMessage(0, w.TTL, w.Size)
+	took := time.Since(start)
+
+	ss := stats.Analyze(plog, g.NumNodes(), g.NumLinks())
+	return Result{
+		Duration:   took,
+		Coverage:   ss.NodeCoverage,
+		Time:       ss.Time,
+		Duplicates: ss.Duplicates,
+		Traffic:    ss.Traffic,
+	}
+}
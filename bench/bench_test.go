@@ -0,0 +1,90 @@
+package bench
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/propagation/bitswap"
+	"github.com/divan/simulation/propagation/gossip"
+	"github.com/divan/simulation/propagation/whisperv6"
+)
+
+func TestGraph(t *testing.T) {
+	w := Workload{Nodes: 100, Degree: 6, Seed: 42}
+	a, b := w.Graph(), w.Graph()
+	if a.NumNodes() != 100 || a.NumLinks() != 300 {
+		t.Fatalf("Expected 100 nodes and 300 links, got %d and %d", a.NumNodes(), a.NumLinks())
+	}
+	if !reflect.DeepEqual(linkPairs(a), linkPairs(b)) {
+		t.Fatal("Expected the same graph for the same workload")
+	}
+	w.Seed++
+	if reflect.DeepEqual(linkPairs(a), linkPairs(w.Graph())) {
+		t.Fatal("Expected different graphs for different seeds")
+	}
+}
+
+func linkPairs(g *graph.Graph) [][2]int {
+	var ret [][2]int
+	for _, link := range g.Links() {
+		ret = append(ret, [2]int{link.FromIdx(), link.ToIdx()})
+	}
+	return ret
+}
+
+// benchmark runs simulators created with newSim on each of the given
+// workloads, reporting coverage and protocol overhead along with timings.
+func benchmark(b *testing.B, workloads []Workload, newSim func(w Workload, g *graph.Graph) propagation.Simulator) {
+	for _, w := range workloads {
+		b.Run(w.Name, func(b *testing.B) {
+			g := w.Graph()
+			b.ReportAllocs()
+			b.ResetTimer()
+			var res Result
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				sim := newSim(w, g)
+				b.StartTimer()
+				res = Run(w, g, sim)
+				b.StopTimer()
+				sim.Stop()
+				b.StartTimer()
+			}
+			b.ReportMetric(res.Coverage.Percentage, "coverage%")
+			b.ReportMetric(float64(res.Time)/float64(time.Millisecond), "propagation-ms")
+			b.ReportMetric(float64(res.Duplicates)/float64(w.Nodes), "dups/node")
+			if res.Traffic != nil {
+				b.ReportMetric(float64(res.Traffic.TotalBytes())/float64(w.Nodes), "traffic-bytes/node")
+			}
+		})
+	}
+}
+
+func BenchmarkGossip(b *testing.B) {
+	benchmark(b, Workloads, func(w Workload, g *graph.Graph) propagation.Simulator {
+		return gossip.NewSimulator(g, 4, time.Millisecond, gossip.WithSeed(w.Seed))
+	})
+}
+
+func BenchmarkGossipLazyPush(b *testing.B) {
+	benchmark(b, Workloads, func(w Workload, g *graph.Graph) propagation.Simulator {
+		return gossip.NewSimulator(g, 4, time.Millisecond, gossip.WithSeed(w.Seed), gossip.WithMode(gossip.LazyPush))
+	})
+}
+
+func BenchmarkBitswap(b *testing.B) {
+	benchmark(b, Workloads, func(w Workload, g *graph.Graph) propagation.Simulator {
+		return bitswap.NewSimulator(g, bitswap.WithLatency(func(int, int) time.Duration { return time.Millisecond }))
+	})
+}
+
+// BenchmarkWhisper runs the smallest workload only, as each whisper node
+// is a full in-process p2p node.
+func BenchmarkWhisper(b *testing.B) {
+	benchmark(b, Workloads[:1], func(w Workload, g *graph.Graph) propagation.Simulator {
+		return whisperv6.NewSimulator(g)
+	})
+}
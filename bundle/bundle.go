@@ -0,0 +1,187 @@
+// Package bundle implements simulation bundles: single zip archives
+// holding the network graph, simulation parameters, propagation log and
+// stats of a run, so logs are never analyzed against the wrong graph.
+//
+// Bundle contains following files:
+//
+//	meta.json       simulation parameters, see Meta
+//	network.json    network graph in D3 JSON format
+//	propagation.pb  propagation log in protobuf format
+//	stats.txt       stats printed after simulation (optional)
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+)
+
+// Names of bundle files.
+const (
+	metaFile    = "meta.json"
+	networkFile = "network.json"
+	logFile     = "propagation.pb"
+	statsFile   = "stats.txt"
+)
+
+// Meta describes simulation run of the bundle.
+type Meta struct {
+	Created   time.Time
+	Algorithm string
+	Seed      int64 // zero if simulator was not seeded
+	TTL       int
+	Size      int
+	Params    map[string]string `json:",omitempty"` // other parameters, i.e. command line flags
+}
+
+// Bundle holds results of a single simulation run along with its inputs.
+type Bundle struct {
+	Meta    Meta
+	Network []byte // network graph in D3 JSON format
+	Log     *propagation.Log
+	Stats   string // optional
+}
+
+// Write writes bundle as zip archive to w.
+func (b *Bundle) Write(w io.Writer) error {
+	meta, err := json.MarshalIndent(b.Meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	var plog bytes.Buffer
+	if err := b.Log.Encode(&plog, propagation.FormatProto); err != nil {
+		return fmt.Errorf("encode propagation log: %v", err)
+	}
+
+	zw := zip.NewWriter(w)
+	add := func(name string, data []byte) error {
+		fw, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return fmt.Errorf("write %s: %v", name, err)
+		}
+		return nil
+	}
+	if err := add(metaFile, meta); err != nil {
+		return err
+	}
+	if err := add(networkFile, b.Network); err != nil {
+		return err
+	}
+	if err := add(logFile, plog.Bytes()); err != nil {
+		return err
+	}
+	if b.Stats != "" {
+		if err := add(statsFile, []byte(b.Stats)); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// WriteFile writes bundle into the file at path.
+func (b *Bundle) WriteFile(path string) error {
+	fd, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create bundle file: %v", err)
+	}
+	defer fd.Close()
+
+	if err := b.Write(fd); err != nil {
+		return err
+	}
+	return fd.Close()
+}
+
+// Read reads bundle from zip archive of the given size.
+func Read(r io.ReaderAt, size int64) (*Bundle, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("open bundle: %v", err)
+	}
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %v", f.Name, err)
+		}
+		files[f.Name] = data
+	}
+	for _, name := range []string{metaFile, networkFile, logFile} {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("no %s in bundle", name)
+		}
+	}
+
+	b := &Bundle{
+		Network: files[networkFile],
+		Stats:   string(files[statsFile]),
+	}
+	if err := json.Unmarshal(files[metaFile], &b.Meta); err != nil {
+		return nil, fmt.Errorf("parse %s: %v", metaFile, err)
+	}
+	b.Log, err = propagation.DecodeLog(bytes.NewReader(files[logFile]), propagation.FormatProto)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %v", logFile, err)
+	}
+	return b, nil
+}
+
+// ReadFile reads bundle from the file at path.
+func ReadFile(path string) (*Bundle, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	fi, err := fd.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return Read(fd, fi.Size())
+}
+
+// node implements string-only graph.Node.
+type node string
+
+func (n node) ID() string { return string(n) }
+
+// Graph parses the network graph of the bundle.
+func (b *Bundle) Graph() (*graph.Graph, error) {
+	var data struct {
+		Nodes []struct {
+			ID string `json:"id"`
+		} `json:"nodes"`
+		Links []struct {
+			Source string `json:"source"`
+			Target string `json:"target"`
+		} `json:"links"`
+	}
+	if err := json.Unmarshal(b.Network, &data); err != nil {
+		return nil, fmt.Errorf("parse network: %v", err)
+	}
+
+	g := graph.NewGraph()
+	for _, n := range data.Nodes {
+		g.AddNode(node(n.ID))
+	}
+	for _, l := range data.Links {
+		g.AddLink(l.Source, l.Target)
+	}
+	return g, nil
+}
@@ -0,0 +1,69 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestWriteRead(t *testing.T) {
+	plog := propagation.NewLog(2)
+	plog.AddStep(10, []int{0, 1}, []int{0})
+	plog.AddStep(20, []int{1, 2}, []int{1})
+	b := &Bundle{
+		Meta: Meta{
+			Created:   time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC),
+			Algorithm: "gossip",
+			Seed:      42,
+			TTL:       10,
+			Size:      400,
+			Params:    map[string]string{"gossipmode": "lazy"},
+		},
+		Network: []byte(`{"nodes":[{"id":"a"},{"id":"b"},{"id":"c"}],"links":[{"source":"a","target":"b"},{"source":"b","target":"c"}]}`),
+		Log:     plog,
+		Stats:   "Stats:\n",
+	}
+
+	var buf bytes.Buffer
+	if err := b.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got.Meta, b.Meta) {
+		t.Fatalf("Expected meta %+v, got %+v", b.Meta, got.Meta)
+	}
+	if got.Stats != b.Stats {
+		t.Fatalf("Expected stats %q, got %q", b.Stats, got.Stats)
+	}
+	if !reflect.DeepEqual(got.Log.Timestamps, plog.Timestamps) || !reflect.DeepEqual(got.Log.Nodes, plog.Nodes) || !reflect.DeepEqual(got.Log.Links, plog.Links) {
+		t.Fatalf("Expected log %+v, got %+v", plog, got.Log)
+	}
+	g, err := got.Graph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.NumNodes() != 3 || g.NumLinks() != 2 {
+		t.Fatalf("Expected graph of 3 nodes and 2 links, got %d and %d", g.NumNodes(), g.NumLinks())
+	}
+}
+
+func TestReadMissingFile(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if _, err := zw.Create(metaFile); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+
+	if _, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len())); err == nil {
+		t.Fatal("Expected error for bundle without network and log")
+	}
+}
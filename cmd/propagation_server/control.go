@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// running holds the currently running simulation, so it can be
+// controlled by separate requests.
+var running struct {
+	sync.Mutex
+	sim *Simulation
+}
+
+func setRunning(sim *Simulation) {
+	running.Lock()
+	running.sim = sim
+	running.Unlock()
+}
+
+func getRunning() *Simulation {
+	running.Lock()
+	defer running.Unlock()
+	return running.sim
+}
+
+// pauseHandler pauses currently running simulation.
+func pauseHandler(w http.ResponseWriter, r *http.Request) {
+	controlHandler(w, r, (*Simulation).Pause)
+}
+
+// resumeHandler resumes currently running simulation.
+func resumeHandler(w http.ResponseWriter, r *http.Request) {
+	controlHandler(w, r, (*Simulation).Resume)
+}
+
+func controlHandler(w http.ResponseWriter, r *http.Request, fn func(*Simulation) error) {
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	sim := getRunning()
+	if sim == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err := fn(sim); err != nil {
+		slog.Error("Control request failed", "err", err)
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// checkpointHandler sends back state of the currently running simulation,
+// which can be passed later in the 'checkpoint' field of simulation request.
+func checkpointHandler(w http.ResponseWriter, r *http.Request) {
+	sim := getRunning()
+	if sim == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := sim.Checkpoint(w); err != nil {
+		slog.Error("Checkpoint failed", "err", err)
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+}
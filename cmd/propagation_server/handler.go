@@ -3,19 +3,22 @@ package main
 import (
 	"bytes"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/divan/graphx/formats"
+	"github.com/divan/simulation/propagation"
 )
 
 // SimulationRequests defines a POST request payload for simulation backend.
 type SimulationRequest struct {
-	Algorithm string          `json:"algorithm"`
-	SenderIdx int             `json:"senderIdx"` // index of the sender node (index of data.Nodes, in fact)
-	TTL       int             `json:"ttl"`       // ttl in seconds
-	MsgSize   int             `json:"msg_size"`  // msg size in bytes
-	Network   json.RawMessage `json:"network"`   // current network graph
+	Algorithm  string          `json:"algorithm"`
+	SenderIdx  int             `json:"senderIdx"`  // index of the sender node (index of data.Nodes, in fact)
+	TTL        int             `json:"ttl"`        // ttl in seconds
+	MsgSize    int             `json:"msg_size"`   // msg size in bytes
+	Network    json.RawMessage `json:"network"`    // current network graph
+	Checkpoint json.RawMessage `json:"checkpoint"` // optional simulator state to restore
 }
 
 // simulationHandler serves request to start simulation. It expectes network graph
@@ -33,7 +36,7 @@ func simulationHandler(w http.ResponseWriter, r *http.Request) {
 	var req SimulationRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		log.Println("[ERROR] Bad payload:", err)
+		slog.Error("Bad payload", "err", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -42,7 +45,7 @@ func simulationHandler(w http.ResponseWriter, r *http.Request) {
 	buf := bytes.NewBuffer(req.Network)
 	network, err := formats.FromD3JSONReader(buf)
 	if err != nil {
-		log.Println("[ERROR] Bad payload:", err)
+		slog.Error("Bad payload", "err", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -51,13 +54,31 @@ func simulationHandler(w http.ResponseWriter, r *http.Request) {
 	if req.Algorithm == "gossip" {
 		algo = "gossip"
 	} // TODO: add proper validation for algorithm
-	log.Printf("Using %s propagation algorithm", algo)
+	slog.Info("Using propagation algorithm", "algorithm", algo)
 
-	log.Printf("Loaded graph with %d nodes", network.NumNodes())
+	slog.Info("Loaded graph", "nodes", network.NumNodes())
 	sim := NewSimulation(algo, network)
-	sim.Start(req.SenderIdx, req.TTL, req.MsgSize)
 	defer sim.Stop()
 
-	log.Println("Sending propagation log")
+	if len(req.Checkpoint) > 0 {
+		if err := sim.Restore(bytes.NewReader(req.Checkpoint)); err != nil {
+			slog.Error("Bad checkpoint", "err", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		slog.Info("Restored simulator state from checkpoint")
+	}
+
+	setRunning(sim)
+	sim.Start(req.SenderIdx, req.TTL, req.MsgSize)
+	setRunning(nil)
+	sim.plog.Meta = propagation.NewMeta(network, map[string]string{
+		"algorithm": algo,
+		"sender":    strconv.Itoa(req.SenderIdx),
+		"ttl":       strconv.Itoa(req.TTL),
+		"msgSize":   strconv.Itoa(req.MsgSize),
+	})
+
+	slog.Info("Sending propagation log")
 	sim.WriteOutput(w)
 }
@@ -3,9 +3,12 @@ package main
 import (
 	"flag"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 
+	"github.com/divan/simulation/control"
 	gethlog "github.com/ethereum/go-ethereum/log"
 )
 
@@ -13,13 +16,30 @@ func main() {
 	var (
 		gethlogLevel = flag.String("loglevel", "crit", "Geth log level for whisper simulator (crti, error, warn, info, debug, trace)")
 		serverAddr   = flag.String("h", "localhost:8084", "Address to bind to in server mode")
+		grpcAddr     = flag.String("grpc", "", "Address to serve gRPC control API on, i.e. localhost:8085 (optional)")
 	)
+	setupLog := logFlags(flag.CommandLine)
 	flag.Parse()
+	setupLog()
 
 	setGethLogLevel(*gethlogLevel)
 
-	log.Println("Starting simulator server on", *serverAddr)
+	if *grpcAddr != "" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			log.Fatal("Listening for gRPC failed: ", err)
+		}
+		slog.Info("Starting gRPC control server", "addr", *grpcAddr)
+		go func() {
+			log.Fatal(control.NewGRPCServer(control.NewServer()).Serve(lis))
+		}()
+	}
+
+	slog.Info("Starting simulator server", "addr", *serverAddr)
 	http.HandleFunc("/", allowCORS(simulationHandler))
+	http.HandleFunc("/pause", allowCORS(pauseHandler))
+	http.HandleFunc("/resume", allowCORS(resumeHandler))
+	http.HandleFunc("/checkpoint", allowCORS(checkpointHandler))
 	log.Fatal(http.ListenAndServe(*serverAddr, nil))
 }
 
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/divan/graphx/graph"
@@ -50,13 +53,66 @@ func (s *Simulation) WriteOutput(w io.Writer) error {
 	return json.NewEncoder(w).Encode(s.plog)
 }
 
-// WriteOutputToFile writes propagation log to the given io.Writer.
+// WriteOutputToFile writes propagation log to the given file. Path "-"
+// means stdout, and files with ".gz" extension are gzip-compressed.
 func (s *Simulation) WriteOutputToFile(path string) error {
+	if path == "-" {
+		return s.WriteOutput(os.Stdout)
+	}
+
 	fd, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("create output file: %v", err)
 	}
 	defer fd.Close()
 
+	if strings.HasSuffix(path, ".gz") {
+		gz := gzip.NewWriter(fd)
+		if err := s.WriteOutput(gz); err != nil {
+			return err
+		}
+		return gz.Close()
+	}
 	return s.WriteOutput(fd)
 }
+
+// ErrNotSupported is returned when simulator doesn't support requested operation.
+var ErrNotSupported = errors.New("not supported by simulator")
+
+// Pause pauses running simulation, if simulator supports it.
+func (s *Simulation) Pause() error {
+	p, ok := s.sim.(propagation.Pauser)
+	if !ok {
+		return ErrNotSupported
+	}
+	p.Pause()
+	return nil
+}
+
+// Resume resumes paused simulation, if simulator supports it.
+func (s *Simulation) Resume() error {
+	p, ok := s.sim.(propagation.Pauser)
+	if !ok {
+		return ErrNotSupported
+	}
+	p.Resume()
+	return nil
+}
+
+// Checkpoint writes simulator state to the given io.Writer, if simulator supports it.
+func (s *Simulation) Checkpoint(w io.Writer) error {
+	c, ok := s.sim.(propagation.Checkpointer)
+	if !ok {
+		return ErrNotSupported
+	}
+	return c.Checkpoint(w)
+}
+
+// Restore restores simulator state from the given io.Reader, if simulator supports it.
+func (s *Simulation) Restore(r io.Reader) error {
+	c, ok := s.sim.(propagation.Checkpointer)
+	if !ok {
+		return ErrNotSupported
+	}
+	return c.Restore(r)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/stats"
+)
+
+// Artifacts of a single run in its directory.
+const (
+	logArtifact    = "log.json"
+	statsArtifact  = "stats.json"
+	metaArtifact   = "meta.json"
+	outputArtifact = "output.txt"
+)
+
+// runSummary is stats of a single run, written into its stats.json.
+type runSummary struct {
+	Nodes        int
+	Links        int
+	NodeCoverage stats.Coverage
+	LinkCoverage stats.Coverage
+	TimeMs       int64
+	P50Ms        int64
+	P90Ms        int64
+	P99Ms        int64
+	Peak         float64 // nodes reached per second, see stats.Velocity
+	AUC          float64
+	Duplicates   int
+	Traffic      *propagation.Traffic     `json:",omitempty"`
+	Offline      *propagation.Offline     `json:",omitempty"`
+	Reliability  *propagation.Reliability `json:",omitempty"`
+	Mesh         *propagation.Mesh        `json:",omitempty"`
+}
+
+// newRunsDir creates directory for the batch of runs in dir, named
+// after current time, i.e. runs/20060102-150405. Number suffix is added
+// if batch with the same name already exists.
+func newRunsDir(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	name := filepath.Join(dir, time.Now().Format("20060102-150405"))
+	path := name
+	for i := 1; ; i++ {
+		err := os.Mkdir(path, 0755)
+		if err == nil {
+			return path, nil
+		}
+		if !os.IsExist(err) {
+			return "", err
+		}
+		path = fmt.Sprintf("%s-%d", name, i)
+	}
+}
+
+// writeArtifacts writes stats and metadata of the run into its dir,
+// next to its log.json.
+func writeArtifacts(dir string, plog *propagation.Log, nodeCount, linkCount int, params stats.VelocityParams) error {
+	ss := stats.Analyze(plog, nodeCount, linkCount)
+	latency := stats.LatencyPercentiles(plog, 0.5, 0.9, 0.99)
+	v := stats.AnalyzeVelocity(plog, nodeCount, params)
+	summary := runSummary{
+		Nodes:        nodeCount,
+		Links:        linkCount,
+		NodeCoverage: ss.NodeCoverage,
+		LinkCoverage: ss.LinkCoverage,
+		TimeMs:       ss.Time.Milliseconds(),
+		P50Ms:        latency[0].Milliseconds(),
+		P90Ms:        latency[1].Milliseconds(),
+		P99Ms:        latency[2].Milliseconds(),
+		Peak:         v.Peak,
+		AUC:          v.AUC,
+		Duplicates:   ss.Duplicates,
+		Traffic:      ss.Traffic,
+		Offline:      ss.Offline,
+		Reliability:  ss.Reliability,
+		Mesh:         ss.Mesh,
+	}
+	if err := writeJSONFile(filepath.Join(dir, statsArtifact), summary); err != nil {
+		return err
+	}
+	if plog.Meta == nil {
+		return nil
+	}
+	return writeJSONFile(filepath.Join(dir, metaArtifact), plog.Meta)
+}
+
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
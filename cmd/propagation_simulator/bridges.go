@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/divan/graphx/graph"
+)
+
+// bridgeField is the node field of the input file marking bridge nodes,
+// which join networks given with -bridges.
+const bridgeField = "bridge"
+
+// networkBridges reads network names of nodes from the given field of the
+// network file, and bridge nodes marked with bridgeField. It checks bridge
+// nodes are the only ones joining networks: every other node belongs to
+// some network and links only nodes of its own network or bridges.
+func networkBridges(path string, data *graph.Graph, field string) ([]string, []bool, error) {
+	networks, err := nodeGroups(path, data, field)
+	if err != nil {
+		return nil, nil, err
+	}
+	marks, err := nodeGroups(path, data, bridgeField)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bridges := make([]bool, len(marks))
+	names := make(map[string]bool)
+	for i, mark := range marks {
+		bridges[i] = mark == "true"
+		switch {
+		case bridges[i]:
+			networks[i] = ""
+		case networks[i] == "":
+			return nil, nil, fmt.Errorf("node %s has no '%s' field and isn't a bridge", data.Nodes()[i].ID(), field)
+		default:
+			names[networks[i]] = true
+		}
+	}
+	if len(names) < 2 {
+		return nil, nil, fmt.Errorf("expected at least two networks, got %d", len(names))
+	}
+
+	for _, link := range data.Links() {
+		from, to := link.FromIdx(), link.ToIdx()
+		if bridges[from] || bridges[to] || networks[from] == networks[to] {
+			continue
+		}
+		nodes := data.Nodes()
+		return nil, nil, fmt.Errorf("link %s-%s joins networks '%s' and '%s' bypassing bridges", nodes[from].ID(), nodes[to].ID(), networks[from], networks[to])
+	}
+	return networks, bridges, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/bundle"
+	"github.com/divan/simulation/discovery"
+	"github.com/divan/simulation/propagation"
+)
+
+// writeBundle writes simulation bundle into the file at path. Network is
+// taken from the input file as is, unless the graph was changed or formed
+// without it, so node fields like coordinates are kept. Flags set on the
+// command line are saved as parameters.
+func writeBundle(path, input string, data *graph.Graph, changed bool, b *bundle.Bundle) error {
+	if changed {
+		var buf bytes.Buffer
+		if err := discovery.WriteJSON(&buf, data); err != nil {
+			return err
+		}
+		b.Network = buf.Bytes()
+	} else {
+		network, err := os.ReadFile(input)
+		if err != nil {
+			return err
+		}
+		b.Network = network
+	}
+
+	b.Meta.Params = setFlags(flag.CommandLine)
+	return b.WriteFile(path)
+}
+
+// readInputs reads network graph and propagation log from the bundle file,
+// if given, or from separate network and log files otherwise.
+func readInputs(bundlePath, network, plogFile string) (*graph.Graph, *propagation.Log, error) {
+	if bundlePath != "" {
+		b, err := bundle.ReadFile(bundlePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := b.Graph()
+		if err != nil {
+			return nil, nil, err
+		}
+		slog.Info("Loaded simulation bundle", "file", bundlePath, "algorithm", b.Meta.Algorithm)
+		return data, b.Log, nil
+	}
+
+	data, err := formats.FromD3JSON(network)
+	if err != nil {
+		return nil, nil, err
+	}
+	slog.Info("Loaded network graph", "file", network)
+	plog, err := readLog(plogFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	slog.Info("Loaded propagation log", "file", plogFile)
+	return data, plog, nil
+}
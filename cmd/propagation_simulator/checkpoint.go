@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/divan/simulation/simulation"
+)
+
+// saveCheckpoint writes simulator state into file, if simulator supports it.
+func saveCheckpoint(sim *simulation.Simulation, path string) error {
+	fd, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create checkpoint file: %v", err)
+	}
+	defer fd.Close()
+
+	return sim.SaveCheckpoint(fd)
+}
+
+// restoreCheckpoint restores simulator state from file, if simulator supports it.
+func restoreCheckpoint(sim *simulation.Simulation, path string) error {
+	fd, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open checkpoint file: %v", err)
+	}
+	defer fd.Close()
+
+	return sim.RestoreCheckpoint(fd)
+}
+
+// checkpointOnInterrupt pauses simulation and saves checkpoint into
+// the file when process is interrupted, and exits. Deliveries in flight are
+// not part of the checkpoint, so the interrupted run is not resumed after
+// restore, but started over on the restored network state.
+func checkpointOnInterrupt(sim *simulation.Simulation, path string) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ch
+		sim.Pause()
+		if err := saveCheckpoint(sim, path); err != nil {
+			log.Fatal("Saving checkpoint failed: ", err)
+		}
+		slog.Warn("Interrupted, saved checkpoint", "file", path)
+		os.Exit(1)
+	}()
+}
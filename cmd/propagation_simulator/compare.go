@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/simulation/stats"
+)
+
+// compareCmd implements 'compare' subcommand, which reports the difference
+// between two propagation logs (for example, whisperv6 vs gossip run on the
+// same network).
+func compareCmd(args []string) {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: propagation_simulator compare [-energy] propagation_a.json propagation_b.json")
+		fs.PrintDefaults()
+	}
+	var (
+		energy  = fs.Bool("energy", false, "Compare energy spent by nodes on propagation")
+		size    = fs.Int("msgSize", 400, "Payload size of propagated messages, for energy comparison")
+		network = fs.String("n", "network.json", "Input filename for network graph data, for energy comparison")
+	)
+	setupLog := logFlags(fs)
+	fs.Parse(args)
+	setupLog()
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	a, err := readLog(fs.Arg(0))
+	if err != nil {
+		log.Fatal("Opening propagation file failed: ", err)
+	}
+	b, err := readLog(fs.Arg(1))
+	if err != nil {
+		log.Fatal("Opening propagation file failed: ", err)
+	}
+
+	cmp := stats.Compare(a, b)
+	if *energy {
+		data, err := formats.FromD3JSON(*network)
+		if err != nil {
+			log.Fatal("Opening input file failed: ", err)
+		}
+		model := stats.DefaultEnergyModel()
+		cmp.EnergyA = stats.AnalyzeEnergy(a, data.NumNodes(), *size, model)
+		cmp.EnergyB = stats.AnalyzeEnergy(b, data.NumNodes(), *size, model)
+	}
+	cmp.PrintVerbose()
+}
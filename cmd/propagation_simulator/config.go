@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// applyConfig sets flags from the YAML config file at path, which maps
+// flag names to values, i.e.:
+//
+//	i: network.json
+//	algorithm: gossip
+//	ttl: 10
+//	loss: 0.1
+//
+// Flags set on the command line take precedence over the config.
+func applyConfig(fs *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("parse config: %v", err)
+	}
+
+	set := setFlags(fs)
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("unknown parameter '%s'", name)
+		}
+		if _, ok := set[name]; ok {
+			continue
+		}
+		switch v := values[name].(type) {
+		case map[interface{}]interface{}, []interface{}:
+			return fmt.Errorf("parameter '%s' should be a scalar value", name)
+		default:
+			if err := fs.Set(name, fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("parameter '%s': %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// flagRange defines allowed range of numeric flag values.
+type flagRange struct {
+	min, max float64
+	positive bool // min is exclusive
+	desc     string
+}
+
+var (
+	fractionRange    = flagRange{min: 0, max: 1, desc: "between 0 and 1"}
+	nonNegativeRange = flagRange{min: 0, max: 1e300, desc: "non-negative"}
+	positiveRange    = flagRange{min: 0, max: 1e300, positive: true, desc: "positive"}
+)
+
+// flagRanges defines allowed ranges of the main command numeric flags.
+var flagRanges = map[string]flagRange{
+	"nat":            fractionRange,
+	"relayed":        fractionRange,
+	"loss":           fractionRange,
+	"freeriders":     fractionRange,
+	"malicious":      fractionRange,
+	"meshchurn":      fractionRange,
+	"subscribe":      fractionRange,
+	"stopcoverage":   fractionRange,
+	"ttl":            nonNegativeRange,
+	"msgSize":        nonNegativeRange,
+	"relays":         nonNegativeRange,
+	"bootstrap":      nonNegativeRange,
+	"topics":         nonNegativeRange,
+	"toplinks":       nonNegativeRange,
+	"ratelimit":      nonNegativeRange,
+	"join":           nonNegativeRange,
+	"max-duration":   nonNegativeRange,
+	"quiescence":     nonNegativeRange,
+	"clockskew":      nonNegativeRange,
+	"powtarget":      nonNegativeRange,
+	"powtime":        positiveRange,
+	"padding":        nonNegativeRange,
+	"horizon":        nonNegativeRange,
+	"nodes":          positiveRange,
+	"maxpeers":       positiveRange,
+	"walkers":        positiveRange,
+	"syncinterval":   positiveRange,
+	"storepoll":      positiveRange,
+	"timescale":      positiveRange,
+	"chunkSize":      positiveRange,
+	"wantlist":       positiveRange,
+	"velocitywindow": positiveRange,
+}
+
+// checkFlagRanges checks that numeric flags values are within their
+// allowed ranges, see flagRanges.
+func checkFlagRanges(fs *flag.FlagSet) error {
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		r, ok := flagRanges[f.Name]
+		if !ok {
+			return
+		}
+		getter, ok := f.Value.(flag.Getter)
+		if !ok {
+			return
+		}
+		var v float64
+		switch x := getter.Get().(type) {
+		case int:
+			v = float64(x)
+		case int64:
+			v = float64(x)
+		case float64:
+			v = x
+		case time.Duration:
+			v = float64(x)
+		default:
+			return
+		}
+		if v < r.min || v > r.max || r.positive && v == r.min {
+			errs = append(errs, fmt.Errorf("-%s should be %s, got %s", f.Name, r.desc, f.Value))
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// checkNodes checks that node counts given by flag names don't exceed
+// the network size.
+func checkNodes(data *graph.Graph, counts map[string]int) error {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs []error
+	for _, name := range names {
+		if counts[name] > data.NumNodes() {
+			errs = append(errs, fmt.Errorf("-%s is %d, but network has only %d nodes", name, counts[name], data.NumNodes()))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/simulation/distributed"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/stats"
+)
+
+// partitionCmd implements 'partition' subcommand, which splits network
+// graph into partition files for distributed simulation workers.
+func partitionCmd(args []string) {
+	fs := flag.NewFlagSet("partition", flag.ExitOnError)
+	var (
+		input  = fs.String("i", "network.json", "Input filename for pregenerated data to be partitioned")
+		parts  = fs.Int("k", 2, "Number of partitions")
+		output = fs.String("o", "part%d.json", "Output filename pattern for partitions, %d is replaced by partition index")
+	)
+	setupLog := logFlags(fs)
+	fs.Parse(args)
+	setupLog()
+
+	if *parts < 1 {
+		log.Fatal("Number of partitions should be positive")
+	}
+	if !strings.Contains(*output, "%d") {
+		log.Fatalf("Output filename should contain %%d for partition index")
+	}
+	data, err := formats.FromD3JSON(*input)
+	if err != nil {
+		log.Fatal("Opening input file failed: ", err)
+	}
+	slog.Info("Loaded network graph", "file", *input)
+
+	owners := distributed.Partition(data, *parts)
+	for _, part := range distributed.Split(data, owners, *parts) {
+		path := fmt.Sprintf(*output, part.Index)
+		if err := writePart(path, part); err != nil {
+			log.Fatal("Writing partition failed: ", err)
+		}
+		slog.Info("Written partition", "file", path, "nodes", len(part.Owned), "links", len(part.Edges))
+	}
+}
+
+func writePart(path string, part *distributed.Part) error {
+	fd, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	return part.WriteJSON(fd)
+}
+
+// workerCmd implements 'worker' subcommand, which serves simulation of
+// a single partition to the coordinator.
+func workerCmd(args []string) {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	var (
+		partFile = fs.String("part", "part0.json", "Partition file created by 'partition' subcommand")
+		addr     = fs.String("addr", "localhost:9100", "Address to serve worker gRPC API on")
+	)
+	setupLog := logFlags(fs)
+	fs.Parse(args)
+	setupLog()
+
+	part, err := distributed.ReadPart(*partFile)
+	if err != nil {
+		log.Fatal("Opening partition file failed: ", err)
+	}
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal("Listening failed: ", err)
+	}
+	slog.Info("Starting simulation worker", "addr", *addr, "partition", part.Index, "nodes", len(part.Owned))
+	log.Fatal(distributed.NewGRPCServer(distributed.NewWorker(part)).Serve(lis))
+}
+
+// coordinatorCmd implements 'coordinator' subcommand, which runs
+// distributed simulation on workers.
+func coordinatorCmd(args []string) {
+	fs := flag.NewFlagSet("coordinator", flag.ExitOnError)
+	var (
+		workers = fs.String("workers", "localhost:9100", "Comma-separated addresses of workers, ordered by their partition index")
+		output  = fs.String("o", "propagation.json", "Output filename for p2p sending data ('-' for stdout, s3:// or gs:// URL for object storage, '.gz' extension for gzip compression)")
+		format  = fs.String("format", "", "Output format for propagation data (json, pb, msgpack), guessed by output file extension if empty")
+		sender  = fs.Int("sender", 0, "Index of the sender node")
+		ttl     = fs.Int("ttl", 10, "TTL for generated messages")
+		latency = fs.Duration("latency", distributed.DefaultLatency, "Per link latency, also the window workers advance by in lockstep")
+	)
+	setupLog := logFlags(fs)
+	fs.Parse(args)
+	setupLog()
+
+	var clients []distributed.WorkerClient
+	for _, addr := range strings.Split(*workers, ",") {
+		c, err := distributed.Dial(strings.TrimSpace(addr))
+		if err != nil {
+			log.Fatal("Connecting to worker failed: ", err)
+		}
+		defer c.Close()
+		clients = append(clients, c)
+	}
+
+	slog.Info("Starting distributed simulation", "workers", len(clients))
+	coord := distributed.NewCoordinator(clients, *latency)
+	started := time.Now()
+	plog, err := coord.Run(context.Background(), *sender, *ttl)
+	if err != nil {
+		log.Fatal("Distributed simulation failed: ", err)
+	}
+	slog.Info("Finished distributed simulation", "steps", coord.Steps, "elapsed", time.Since(started))
+
+	version, commit := propagation.BuildInfo()
+	plog.Meta = &propagation.Meta{
+		Version:       version,
+		Commit:        commit,
+		Params:        setFlags(fs),
+		GraphChecksum: coord.Checksum,
+	}
+	if *format == "" {
+		*format = propagation.FormatFromPath(*output)
+	}
+	if err := writeLogFile(plog, *output, *format); err != nil {
+		log.Fatal("Writing output failed: ", err)
+	}
+
+	statsOut := os.Stdout
+	if *output == "-" {
+		statsOut = os.Stderr
+	}
+	stats.Analyze(plog, coord.Nodes, coord.Links).FprintVerbose(statsOut)
+	slog.Info("Written propagation data", "file", *output)
+}
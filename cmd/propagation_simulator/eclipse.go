@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/eclipse"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/propagation/gossip"
+)
+
+// eclipseCmd implements 'eclipse' subcommand, which runs eclipse attack
+// scenario with gossip algorithm and reports target's time-to-delivery
+// for each number of peers replaced by attackers.
+func eclipseCmd(args []string) {
+	fs := flag.NewFlagSet("eclipse", flag.ExitOnError)
+	var (
+		input    = fs.String("i", "network.json", "Input filename for pregenerated data to be used with simulation")
+		target   = fs.Int("target", 1, "Index of the eclipsed node")
+		source   = fs.Int("source", 0, "Index of the node sending message")
+		policies = fs.String("policies", "none,anchors,rotate", "Comma-separated peer rotation policies of the target (none, anchors, rotate)")
+		anchors  = fs.Int("anchors", 2, "Number of protected peers for anchors policy")
+		rotate   = fs.Int("rotate", 1, "Number of peers rotated for rotate policy")
+		ttl      = fs.Int("ttl", 10, "TTL for generated messages")
+		size     = fs.Int("msgSize", 400, "Payload size for generated messages")
+	)
+	setupLog := logFlags(fs)
+	fs.Parse(args)
+	setupLog()
+
+	var list []eclipse.Policy
+	for _, name := range strings.Split(*policies, ",") {
+		p, err := eclipse.ParsePolicy(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		list = append(list, p)
+	}
+
+	data, err := formats.FromD3JSON(*input)
+	if err != nil {
+		log.Fatal("Opening input file failed: ", err)
+	}
+	slog.Info("Loaded network graph", "file", *input)
+
+	params := eclipse.DefaultParams(*target)
+	params.Source = *source
+	params.Anchors = *anchors
+	params.Rotate = *rotate
+	params.TTL = *ttl
+	params.Size = *size
+
+	newSim := func(network *graph.Graph, attackers []int) propagation.Simulator {
+		return gossip.NewSimulator(network, 4, 10, gossip.WithWithholdingNodes(attackers...))
+	}
+	results, err := eclipse.Run(data, params, list, newSim)
+	if err != nil {
+		log.Fatal("Running eclipse attack failed: ", err)
+	}
+
+	fmt.Printf("%-10s %-10s %s\n", "Policy", "Replaced", "Time to target")
+	for _, res := range results {
+		delivery := "not delivered"
+		if res.Delivered {
+			delivery = res.Time.String()
+		}
+		fmt.Printf("%-10s %-10s %s\n", res.Policy, fmt.Sprintf("%d/%d", res.Replaced, res.Peers), delivery)
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/simulation"
+)
+
+// countEvents sets up counting of typed events of the run, reported in
+// stats with -eventstats flag. Observer already set in config, if any,
+// keeps receiving events as well.
+func countEvents(cfg *simulation.Config) *propagation.EventCounts {
+	counts := &propagation.EventCounts{}
+	if cfg.Observer != nil {
+		cfg.Observer = propagation.Chain(cfg.Observer, propagation.Tee(counts.Observe))
+	} else {
+		cfg.Observer = counts.Observe
+	}
+	return counts
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/simulation/stats"
+	"github.com/divan/simulation/viz"
+)
+
+// exportCmd implements 'export' subcommand, which converts propagation
+// log into formats consumed by other tools.
+func exportCmd(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	var (
+		network  = fs.String("n", "network.json", "Input filename for network graph data")
+		plogFile = fs.String("p", "propagation.json", "Input filename for propagation log data")
+		output   = fs.String("o", "frames.json", "Output filename")
+		format   = fs.String("format", "frames", "Export format (frames, influx)")
+		tags     = fs.String("tags", "", "Comma-separated key=value tags for influx format, i.e. algorithm=gossip,network=grid")
+		start    = fs.String("start", "", "Start time for influx format points in RFC3339 format (default now)")
+	)
+	setupLog := logFlags(fs)
+	fs.Parse(args)
+	setupLog()
+
+	if *format != "frames" && *format != "influx" {
+		log.Fatalf("Unknown export format: %s", *format)
+	}
+
+	data, err := formats.FromD3JSON(*network)
+	if err != nil {
+		log.Fatal("Opening network file failed: ", err)
+	}
+
+	plog, err := readLog(*plogFile)
+	if err != nil {
+		log.Fatal("Opening propagation file failed: ", err)
+	}
+
+	fd, err := os.Create(*output)
+	if err != nil {
+		log.Fatal("Creating output file failed: ", err)
+	}
+	defer fd.Close()
+
+	switch *format {
+	case "frames":
+		slog.Info("Calculating layout", "nodes", data.NumNodes())
+		var frames *viz.Frames
+		frames, err = viz.NewFrames(data, plog, nil)
+		if err == nil {
+			err = frames.WriteJSON(fd)
+		}
+	case "influx":
+		t0 := time.Now()
+		if *start != "" {
+			t0, err = time.Parse(time.RFC3339, *start)
+			if err != nil {
+				log.Fatal("Parsing start time failed: ", err)
+			}
+		}
+		err = stats.WriteInflux(fd, plog, data.NumNodes(), t0, parseTags(*tags))
+	}
+	if err != nil {
+		log.Fatal("Export failed: ", err)
+	}
+	slog.Info("Written export data", "format", *format, "file", *output)
+}
+
+// parseTags parses comma-separated list of key=value pairs.
+func parseTags(s string) map[string]string {
+	tags := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			continue
+		}
+		tags[kv[0]] = kv[1]
+	}
+	return tags
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/simulation"
+)
+
+// freeridersCmd implements 'freeriders' subcommand, which searches for the
+// minimum fraction of altruistic (relaying) nodes needed for full coverage
+// with gossip algorithm, while the rest of nodes are free-riders receiving
+// messages but never relaying them.
+func freeridersCmd(args []string) {
+	fs := flag.NewFlagSet("freeriders", flag.ExitOnError)
+	var (
+		input     = fs.String("i", "network.json", "Input filename for pregenerated data to be used with simulation")
+		ttl       = fs.Int("ttl", 10, "TTL for generated messages")
+		size      = fs.Int("msgSize", 400, "Payload size for generated messages")
+		trials    = fs.Int("trials", 5, "Number of runs with random free-riders for each fraction, all of them should reach full coverage")
+		precision = fs.Float64("precision", 0.01, "Precision of the altruistic fraction search")
+	)
+	setupLog := logFlags(fs)
+	fs.Parse(args)
+	setupLog()
+
+	data, err := formats.FromD3JSON(*input)
+	if err != nil {
+		log.Fatal("Opening input file failed: ", err)
+	}
+	slog.Info("Loaded network graph", "file", *input)
+
+	// binary search needs full coverage at the upper bound, which may be
+	// unreachable regardless of free-riders, i.e. with low TTL
+	full := fullCoverage(data, 0, *trials, *ttl, *size)
+	fmt.Printf("Altruistic fraction %.3f: full coverage %v\n", 1.0, full)
+	if !full {
+		fmt.Println("Full coverage is unreachable on this topology with gossip fanout, even without free-riders")
+		return
+	}
+
+	// binary search, assuming more altruistic nodes never hurt coverage
+	lo, hi := 0.0, 1.0
+	for hi-lo > *precision {
+		mid := (lo + hi) / 2
+		full := fullCoverage(data, 1-mid, *trials, *ttl, *size)
+		fmt.Printf("Altruistic fraction %.3f: full coverage %v\n", mid, full)
+		if full {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	fmt.Printf("Minimum altruistic fraction for full coverage: %.3f\n", hi)
+}
+
+// fullCoverage reports whether all trials with the given fraction of
+// free-riders reach all nodes.
+func fullCoverage(data *graph.Graph, freeriders float64, trials, ttl, size int) bool {
+	for i := 0; i < trials; i++ {
+		cfg := simulation.Config{GossipWithholding: randomNodes(data.NumNodes(), freeriders)}
+		sim := newSimulation("gossip", data, cfg)
+		res := sim.Run(ttl, size)
+		sim.Stop()
+
+		if res.Stats.NodeCoverage.Actual < data.NumNodes() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/divan/simulation/crawl"
+)
+
+// importCmd implements 'import' subcommand, which converts network crawler
+// snapshot into network file with nodes metadata.
+func importCmd(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	var (
+		input  = fs.String("i", "", "Input filename of the crawler snapshot")
+		output = fs.String("o", "network.json", "Output filename for network graph data")
+		format = fs.String("format", crawl.FormatEthereumCSV, "Snapshot format (ethcsv, libp2p)")
+	)
+	setupLog := logFlags(fs)
+	fs.Parse(args)
+	setupLog()
+
+	if *input == "" {
+		log.Fatal("Snapshot file is required, use -i flag")
+	}
+	in, err := os.Open(*input)
+	if err != nil {
+		log.Fatal("Opening snapshot file failed: ", err)
+	}
+	defer in.Close()
+
+	data, err := crawl.Read(in, *format)
+	if err != nil {
+		log.Fatal("Importing snapshot failed: ", err)
+	}
+	slog.Info("Imported crawl snapshot", "format", *format,
+		"nodes", data.NumNodes(), "links", data.NumLinks())
+
+	fd, err := os.Create(*output)
+	if err != nil {
+		log.Fatal("Creating output file failed: ", err)
+	}
+	defer fd.Close()
+	if err := crawl.WriteJSON(fd, data); err != nil {
+		log.Fatal("Writing network failed: ", err)
+	}
+	slog.Info("Saved network graph", "file", *output)
+}
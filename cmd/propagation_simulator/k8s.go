@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// k8sParams defines how sweep runs are executed as Kubernetes Jobs.
+type k8sParams struct {
+	Image     string        // image with propagation_simulator and storage CLI
+	Namespace string        // namespace of Jobs
+	Store     string        // object storage prefix, s3://bucket/path or gs://bucket/path
+	Timeout   time.Duration // max time to wait for all Jobs
+}
+
+// k8sFlags registers Kubernetes Jobs flags in fs and returns function
+// returning their values.
+func k8sFlags(fs *flag.FlagSet) func() k8sParams {
+	var (
+		image     = fs.String("image", "divan/propagation_simulator", "Container image for Kubernetes Jobs, should have propagation_simulator and aws or gsutil CLI")
+		namespace = fs.String("namespace", "default", "Kubernetes namespace for Jobs")
+		store     = fs.String("store", "", "Object storage prefix for Jobs input and outputs, s3://bucket/path or gs://bucket/path")
+		timeout   = fs.Duration("timeout", time.Hour, "Max time to wait for Kubernetes Jobs to complete")
+	)
+	return func() k8sParams {
+		return k8sParams{
+			Image:     *image,
+			Namespace: *namespace,
+			Store:     strings.TrimSuffix(*store, "/"),
+			Timeout:   *timeout,
+		}
+	}
+}
+
+// runK8sSweep runs each combination as Kubernetes Job, using kubectl
+// from PATH. Input network is uploaded into object storage, Jobs write
+// propagation data there, and it's downloaded into dir once all Jobs
+// are complete, marking runs completed in the sweep state.
+func runK8sSweep(params k8sParams, state *sweepState, dir string, combos []Combination) error {
+	if len(combos) == 0 {
+		return nil
+	}
+	if _, err := storageCopyCmd(params.Store); err != nil {
+		return err
+	}
+	// keep files of each sweep under its own prefix
+	prefix := fmt.Sprintf("sweep-%d", time.Now().Unix())
+	store := params.Store + "/" + prefix
+	network := store + "/network.json"
+	if err := storageCopy(state.Input, network); err != nil {
+		return fmt.Errorf("uploading network: %v", err)
+	}
+
+	jobs := make([]string, len(combos))
+	for i, combo := range combos {
+		jobs[i] = jobName(prefix, combo)
+		manifest, err := jobManifest(params, jobs[i], store, state.Args, combo)
+		if err != nil {
+			return err
+		}
+		if err := kubectl(manifest, "apply", "-n", params.Namespace, "-f", "-"); err != nil {
+			return fmt.Errorf("creating job for %s: %v", combo, err)
+		}
+		slog.Info("Created Kubernetes job", "job", jobs[i], "params", combo.String())
+	}
+
+	args := []string{"wait", "-n", params.Namespace, "--for=condition=complete", "--timeout", params.Timeout.String()}
+	for _, job := range jobs {
+		args = append(args, "job/"+job)
+	}
+	if err := kubectl(nil, args...); err != nil {
+		return fmt.Errorf("waiting for jobs: %v", err)
+	}
+
+	for _, combo := range combos {
+		out := filepath.Join(dir, combo.Name())
+		if err := os.MkdirAll(out, 0755); err != nil {
+			return err
+		}
+		if err := storageCopy(store+"/"+combo.Name()+".json", filepath.Join(out, logArtifact)); err != nil {
+			return fmt.Errorf("downloading output of %s: %v", combo, err)
+		}
+		if err := state.done(combo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jobName returns valid Kubernetes name of the combination Job, which
+// should be lowercase alphanumeric or '-', and at most 63 characters.
+func jobName(prefix string, combo Combination) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(prefix+"-"+combo.Name()))
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return strings.Trim(name, "-")
+}
+
+// jobManifest returns JSON manifest of the Job, which downloads network
+// from store, runs simulation for the combination and uploads its
+// propagation data back.
+func jobManifest(params k8sParams, name, store string, base []string, combo Combination) ([]byte, error) {
+	cp, err := storageCopyCmd(store)
+	if err != nil {
+		return nil, err
+	}
+	network := store + "/network.json"
+	output := store + "/" + combo.Name() + ".json"
+
+	args := append([]string{"propagation_simulator", "-i", "/tmp/network.json"}, base...)
+	args = append(args, combo.Args()...)
+	args = append(args, "-o", "/tmp/propagation.json")
+	script := strings.Join([]string{
+		strings.Join(append(cp, shellQuote(network), "/tmp/network.json"), " "),
+		shellQuoteAll(args),
+		strings.Join(append(cp, "/tmp/propagation.json", shellQuote(output)), " "),
+	}, " && ")
+
+	job := map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": params.Namespace,
+			"labels":    map[string]string{"app": "propagation-simulator-sweep"},
+		},
+		"spec": map[string]interface{}{
+			"backoffLimit": 0,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"restartPolicy": "Never",
+					"containers": []map[string]interface{}{
+						{
+							"name":    "simulator",
+							"image":   params.Image,
+							"command": []string{"sh", "-c", script},
+						},
+					},
+				},
+			},
+		},
+	}
+	return json.MarshalIndent(job, "", "  ")
+}
+
+// kubectl runs kubectl with given args and stdin.
+func kubectl(stdin []byte, args ...string) error {
+	cmd := exec.Command("kubectl", args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// shellQuote quotes s for use in sh command line.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func shellQuoteAll(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
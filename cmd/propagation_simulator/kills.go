@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// killsFlag collects node failures from repeated -kill flags.
+type killsFlag []propagation.Kill
+
+// String implements flag.Value.
+func (k *killsFlag) String() string {
+	items := make([]string, len(*k))
+	for i, kill := range *k {
+		items[i] = kill.String()
+	}
+	return strings.Join(items, "; ")
+}
+
+// Set implements flag.Value.
+func (k *killsFlag) Set(s string) error {
+	kill, err := propagation.ParseKill(s)
+	if err != nil {
+		return err
+	}
+	*k = append(*k, kill)
+	return nil
+}
+
+// killFlags registers -kill flag in the flag set and returns node failures
+// to inject, filled once flags are parsed.
+func killFlags(fs *flag.FlagSet) *killsFlag {
+	kills := &killsFlag{}
+	fs.Var(kills, "kill", "Kill node during simulation, as 'node=idx at=offset [recover=downtime fetch=bool]', i.e. 'node=5 at=3s recover=2s', may be repeated (optional)")
+	return kills
+}
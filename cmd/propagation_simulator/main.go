@@ -1,51 +1,950 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"math/rand"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/divan/graphx/formats"
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/bundle"
+	"github.com/divan/simulation/community"
+	"github.com/divan/simulation/discovery"
+	"github.com/divan/simulation/geo"
+	"github.com/divan/simulation/preflight"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/propagation/antientropy"
+	"github.com/divan/simulation/propagation/bitswap"
+	"github.com/divan/simulation/propagation/gossip"
+	"github.com/divan/simulation/propagation/randomwalk"
+	"github.com/divan/simulation/propagation/statusgo"
+	"github.com/divan/simulation/propagation/whisperv6"
+	"github.com/divan/simulation/simulation"
+	"github.com/divan/simulation/sink"
 	"github.com/divan/simulation/stats"
+	"github.com/divan/simulation/store"
 	gethlog "github.com/ethereum/go-ethereum/log"
 )
 
+// commands defines available subcommands. Running without
+// subcommand starts the propagation simulation, and "validate"
+// subcommand checks its parameters and network without running it.
+var commands = map[string]func(args []string){
+	"compare":     compareCmd,
+	"coordinator": coordinatorCmd,
+	"eclipse":     eclipseCmd,
+	"export":      exportCmd,
+	"freeriders":  freeridersCmd,
+	"import":      importCmd,
+	"nat":         natCmd,
+	"relayed":     relayedCmd,
+	"partition":   partitionCmd,
+	"priority":    priorityCmd,
+	"report":      reportCmd,
+	"runs":        runsCmd,
+	"scenario":    scenarioCmd,
+	"sweep":       sweepCmd,
+	"version":     versionCmd,
+	"viz":         vizCmd,
+	"walkers":     walkersCmd,
+	"worker":      workerCmd,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			cmd(os.Args[2:])
+			return
+		}
+	}
+	args := os.Args[1:]
+	validate := len(args) > 0 && args[0] == "validate"
+	if validate {
+		args = args[1:]
+	}
+
 	var (
 		input        = flag.String("i", "network.json", "Input filename for pregenerated data to be used with simulation")
-		output       = flag.String("o", "propagation.json", "Output filename for p2p sending data")
+		output       = flag.String("o", "propagation.json", "Output filename for p2p sending data ('-' for stdout, s3:// or gs:// URL for object storage, '.gz' extension for gzip compression)")
+		format       = flag.String("format", "", "Output format for propagation data (json, pb, msgpack), guessed by output file extension if empty")
 		gethlogLevel = flag.String("loglevel", "crit", "Geth log level for whisper simulator (crti, error, warn, info, debug, trace)")
 		ttl          = flag.Int("ttl", 10, "TTL for generated messages")
 		size         = flag.Int("msgSize", 400, "Payload size for generated messages")
-		algorithm    = flag.String("algorithm", "whisperv6", "Propagation algorithm to use (whisperv6, gossip)")
+		algorithm    = flag.String("algorithm", "whisperv6", "Propagation algorithm to use (whisperv6, gossip, bitswap, eth, randomwalk, antientropy, statusgo, core)")
+		gossipMode   = flag.String("gossipmode", "eager", "Push mode for gossip algorithm (eager, lazy)")
+		handshake    = flag.String("handshake", "", "Establish gossip links lazily on first send, paying handshake of the given stack (tcp, tls, tls12, devp2p), instead of pre-established connections (optional)")
+		hybrid       = flag.String("hybrid", "", "Push-pull hybrid gossip, pushing message for first rounds and pulling it from fanout peers every interval after that, as rounds:interval:fanout, i.e. 3:200ms:2 (optional)")
+		gossipScore  = flag.Bool("gossipscore", false, "Enable GossipSub-style peer scoring and mesh pruning for gossip algorithm")
+		meshChurn    = flag.Float64("meshchurn", 0, "Probability of each mesh peer being pruned on heartbeat regardless of score, simulating mesh instability with -gossipscore (0..1)")
+		gossipChoke  = flag.Bool("gossipchoke", false, "Enable Episub-style choking of peers pushing duplicates for gossip algorithm")
+		transports   = flag.String("transports", "", "Transports mix for gossip algorithm, i.e. tcp=0.7,quic=0.2,websocket=0.1 (transports: tcp, quic, websocket)")
+		bandwidth    = flag.String("bandwidth", "", "Bandwidth classes mix for gossip algorithm, i.e. mobile=0.3,dsl=0.5,fiber=0.2 (classes: mobile, dsl, cable, fiber, dc)")
+		queue        = flag.String("queue", "", "Transmission queueing model for gossip algorithm: node (queue per node uplink) or link (queue per link)")
+		geoLatency   = flag.Bool("geo", false, "Derive link latencies from nodes coordinates (lat/lon fields in the input file) for gossip algorithm")
+		linkLatency  = flag.Bool("linklatency", false, "Use per link latencies (latency field in ms of the input file links) for gossip algorithm")
+		linkTypes    = flag.String("linktypes", "", "Link type profiles for gossip algorithm, as comma-separated type=latency:loss items, i.e. wifi=50ms:0.05,backbone=5ms:0, applied to links by type field of the input file (optional)")
+		preferLinks  = flag.String("preferlinks", "", "Comma-separated link types gossip nodes push payload over, announcing messages over other links, see -linktypes (optional)")
+		dutyCycle    = flag.String("dutycycle", "", "Duty cycle of intermittently connected nodes for gossip algorithm, as fraction:online:offline, i.e. 0.3:1s:500ms (optional)")
+		natFraction  = flag.Float64("nat", 0, "Fraction of gossip nodes behind NAT, which can't accept inbound connections (0..1)")
+		relays       = flag.Int("relays", 0, "Number of relay nodes forwarding messages between gossip nodes behind NAT or over relayed links")
+		relayed      = flag.Float64("relayed", 0, "Fraction of gossip links going through circuit relays, picked among -relays nodes (0..1)")
+		linkRelays   = flag.Bool("linkrelays", false, "Make gossip links with relay field (relay node id) of the input file links go through that relay")
+		wakuRoles    = flag.String("waku", "", "Waku roles mix for gossip algorithm, as comma-separated roles=share items, i.e. relay=0.6,relay+filter+lightpush+store=0.1,light=0.3 (roles: relay, store, filter, lightpush, light)")
+		storePoll    = flag.Duration("storepoll", gossip.DefaultStorePoll, "Interval of light clients querying Waku store nodes, if they have no filter node")
+		joinInterval = flag.Duration("join", 0, "Interval between nodes joining the network through bootstrap nodes for gossip algorithm, so message is sent while network is forming (optional)")
+		bootstrap    = flag.Int("bootstrap", 3, "Number of bootstrap nodes, joined from the start, see -join")
+		directed     = flag.Bool("directed", false, "Treat network links as directed (source -> target only) for gossip algorithm")
+		groupBy      = flag.String("groupby", "", "Node field of the input file (i.e. country) to break down coverage and latency stats by (optional)")
+		bridgesBy    = flag.String("bridges", "", "Node field of the input file with network name of each node, for networks joined by nodes with bridge field set to true, to report cross-network propagation (optional)")
+		communities  = flag.Bool("communities", false, "Detect communities of the network graph and report propagation across them in stats")
+		topLinks     = flag.Int("toplinks", 0, "Number of the most used links to report in stats (optional)")
+		linksCSV     = flag.String("linkscsv", "", "Output filename for per link hit counts in CSV format (optional)")
+		topics       = flag.Int("topics", 0, "Number of topics nodes subscribe to, messages are published on the first one (optional)")
+		subscribe    = flag.Float64("subscribe", 0.1, "Probability of node subscribing to each topic")
+		relay        = flag.String("relay", "all", "Relay policy for messages on topics with gossip algorithm (all, bloom, mesh)")
+		stopCoverage = flag.Float64("stopcoverage", 0, "Stop whisperv6 simulation once that fraction of nodes is reached, i.e. 1.0 for all nodes (optional)")
+		timeScale    = flag.Float64("timescale", 1, "Run gossip simulation that many times faster than real time, keeping log timestamps (optional)")
+		maxDuration  = flag.Duration("max-duration", 0, "Bound propagation of each message by that duration, for all algorithms, i.e. 10s (optional)")
+		quiescence   = flag.Duration("quiescence", 0, "Stop whisperv6 simulation after that long without events, i.e. 500ms (optional)")
+		powTarget    = flag.Float64("powtarget", whisperv6.DefaultMessageParams().PowTarget, "PoW target of whisperv6 messages, nodes drop envelopes below their min PoW (0.001 by default, see -whispernodes)")
+		powTime      = flag.Int("powtime", int(whisperv6.DefaultMessageParams().PowTime), "Max seconds spent on PoW computation of whisperv6 messages")
+		msgTopic     = flag.String("msgtopic", "", "Topic name of whisperv6 messages, random if empty; -topics takes precedence (optional)")
+		whisperNodes = flag.String("whispernodes", "", "Whisper config classes mix, as comma-separated minpow[/maxsize]=share items, i.e. 0.001=0.7,0.2/65536=0.3 (optional)")
+		peerLimit    = flag.String("peerlimit", "", "Per-peer rate limit of whisperv6 nodes, as limit[:tolerance] envelopes per second, disconnecting peers exceeding it more than tolerance times, i.e. 20:5 (optional, see -spam)")
+		codes        = flag.String("codes", "", "Devp2p packets tracked by whisperv6 simulation, as comma-separated protocol/code items, i.e. shh/1,les/2; packets other than whisper envelopes are streamed to -sink and -tui only (optional)")
+		statusProto  = flag.String("statusprotocol", "waku", "Service of statusgo algorithm, status-go Waku or Whisper fork (waku, whisper)")
+		mailServers  = flag.Float64("mailservers", 0, "Fraction of nodes running status-go mail server with statusgo algorithm (0..1)")
+		watch        = flag.Bool("watch", false, "Keep whisperv6 network running after simulation, applying links of the edited input file to it and sending new message after each edit, until interrupted")
+		sampling     = flag.String("sampling", "", "Record only first arrivals ('first') or first arrivals and deliveries over 1-in-N links (N) of whisperv6 simulation, for huge networks (optional)")
+		eventStats   = flag.Bool("eventstats", false, "Count typed events of the simulation, i.e. messages dropped by reason and nodes going down, and report them in stats (whisperv6 and gossip report drops and network events, others deliveries only)")
+		padding      = flag.Int("padding", 0, "Padding bytes of whisperv6 messages, whisper pads to 256 bytes blocks if 0 (optional)")
+		clockSkew    = flag.Duration("clockskew", 0, "Max clock offset of whisperv6 nodes, drawn uniformly from [-clockskew, clockskew] using -seed, i.e. 15s (optional)")
+		senders      = flag.String("senders", "", "Send distinct messages concurrently, as comma-separated node@offset pairs, i.e. 0@0s,15@100ms (optional)")
+		targets      = flag.String("targets", "", "Comma-separated target nodes to report delivery time and success for, when it matters rather than full coverage (optional)")
+		recipient    = flag.Int("to", -1, "Send message to the recipient node, encrypted to its asymmetric key, and report delivery latency (whisperv6 only, optional)")
+		coding       = flag.String("coding", "", "Erasure-coded broadcast for gossip algorithm, as k:n fragments, i.e. 4:6 (optional)")
+		loss         = flag.Float64("loss", 0, "Probability of losing each message sent by gossip node (0..1)")
+		retries      = flag.String("retries", "", "ACK and retransmission of lost gossip messages, as timeout:backoff:retries, i.e. 50ms:2:5 (optional)")
+		chunkSize    = flag.Int("chunkSize", bitswap.DefaultChunkSize, "Size of chunks messages are split into with bitswap algorithm")
+		walkers      = flag.Int("walkers", randomwalk.DefaultWalkers, "Message copies sprayed by the sender with randomwalk algorithm, each carried by a walker for TTL hops")
+		syncInterval = flag.Duration("syncinterval", antientropy.DefaultInterval, "Interval of each node syncing digests with a random peer with antientropy algorithm")
+		pushPull     = flag.Bool("pushpull", false, "Push messages peer lacks on sync, not only pull missing ones, with antientropy algorithm")
+		ethBlocks    = flag.Bool("ethblocks", false, "Propagate blocks instead of transactions with eth algorithm, announcing them only after import")
+		wantList     = flag.Int("wantlist", bitswap.DefaultWantListSize, "Number of chunks bitswap node requests from a single peer at once")
+		rateLimit    = flag.Float64("ratelimit", 0, "Messages per second each gossip node sends to each of its peers, unlimited if 0")
+		freeriders   = flag.Float64("freeriders", 0, "Fraction of gossip nodes receiving messages but never relaying them (0..1)")
+		spam         = flag.String("spam", "", "Background spam during propagation, as fraction:rate[:size] of spamming nodes, i.e. 0.1:50 for 10% of nodes sending 50 msgs/s (optional)")
+		malicious    = flag.Float64("malicious", 0, "Fraction of gossip nodes propagating invalid messages (0..1)")
+		energy       = flag.Bool("energy", false, "Report energy spent by nodes on sending and receiving messages")
+		checkpoint   = flag.String("checkpoint", "", "Filename to save simulator state into after simulation or on interrupt (optional)")
+		restore      = flag.String("restore", "", "Filename to restore simulator state from (optional)")
+		progress     = flag.Bool("progress", false, "Show progress bars instead of progress logging")
+		tui          = flag.Bool("tui", false, "Show live dashboard with coverage, events rate and velocity during the run, logging only warnings")
+		db           = flag.String("db", "", "SQLite database filename to store run results into (optional)")
+		sinkURL      = flag.String("sink", "", "URL of event sink to publish propagation events to, i.e. nats://localhost:4222/propagation or kafka://localhost:9092/propagation (optional)")
+		discoveryBy  = flag.String("discovery", "", "Form network with peer discovery (kademlia, randomwalk) instead of reading input file (optional)")
+		nodes        = flag.Int("nodes", 100, "Number of nodes for network formed with peer discovery")
+		maxPeers     = flag.Int("maxpeers", 4, "Number of peers dialed by each node for network formed with peer discovery")
+		networkOut   = flag.String("networkout", "", "Filename to save network formed with peer discovery into (optional)")
+		fix          = flag.Bool("fix", false, "Fix network graph problems found before simulation, instead of failing: drop duplicate links and self-loops, and connect components")
+		snapshot     = flag.String("snapshot", "", "Filename of whisperv6 network snapshot to reuse, created if doesn't exist (optional)")
+		bundleOut    = flag.String("bundle", "", "Output filename for simulation bundle with network, parameters, propagation data and stats (optional)")
+		dryRun       = flag.Bool("dry-run", false, "Print plan of the run with estimated memory and duration, and exit without running it")
+		seed         = flag.Int64("seed", 0, "Random source seed for gossip simulation, random if 0")
+		configFile   = flag.String("config", "", "YAML file with parameters, as flag name to value map, overridden by flags set on the command line (optional)")
 	)
-	flag.Parse()
+	setupLog := logFlags(flag.CommandLine)
+	startProfile := profileFlags(flag.CommandLine)
+	velocityParams := velocityFlags(flag.CommandLine)
+	kills := killFlags(flag.CommandLine)
+	flag.CommandLine.Parse(args)
+	setupLog()
+	defer startProfile()()
+
+	if *configFile != "" {
+		if err := applyConfig(flag.CommandLine, *configFile); err != nil {
+			log.Fatal("Reading config failed: ", err)
+		}
+		slog.Info("Loaded config", "file", *configFile)
+	}
+	if err := checkFlagRanges(flag.CommandLine); err != nil {
+		log.Fatalf("Invalid parameters:\n%v", err)
+	}
+	if !validAlgorithm(*algorithm) {
+		log.Fatalf("Unknown algorithm '%s'", *algorithm)
+	}
+	if *restore != "" {
+		if _, err := os.Stat(*restore); err != nil {
+			log.Fatal("Opening checkpoint failed: ", err)
+		}
+	}
 
 	setGethLogLevel(*gethlogLevel)
 
-	data, err := formats.FromD3JSON(*input)
+	var data *graph.Graph
+	var err error
+	if validate && *discoveryBy != "" {
+		fmt.Printf("Config is valid, network is formed with %s peer discovery (%d nodes)\n", *discoveryBy, *nodes)
+		return
+	}
+	if *discoveryBy != "" {
+		data, err = discoverNetwork(*discoveryBy, *nodes, *maxPeers, *networkOut)
+		if err != nil {
+			log.Fatal("Peer discovery failed: ", err)
+		}
+	} else {
+		data, err = formats.FromD3JSON(*input)
+		if err != nil {
+			log.Fatal("Opening input file failed: ", err)
+		}
+		slog.Info("Loaded network graph", "file", *input)
+	}
+
+	var fixed bool
+	report := preflight.Check(data, *directed)
+	if !report.OK() {
+		if !*fix {
+			log.Fatalf("Network graph check failed:\n%s\nUse -fix to fix it automatically", report)
+		}
+		data = preflight.Fix(data, *directed)
+		fixed = true
+		slog.Warn("Fixed network graph problems", "links", data.NumLinks(), "unreachable", report.Unreachable())
+	}
+
+	algo := algorithmName(*algorithm)
+	slog.Info("Using propagation algorithm", "algorithm", algo)
+	if *watch && (algo != "whisperv6" || *discoveryBy != "") {
+		log.Fatal("-watch requires whisperv6 algorithm and network graph input file")
+	}
+
+	counts := make(map[string]int)
+	if *joinInterval > 0 {
+		counts["bootstrap"] = *bootstrap
+	}
+	if *natFraction > 0 || *relayed > 0 {
+		counts["relays"] = *relays
+	}
+	if err := checkNodes(data, counts); err != nil {
+		log.Fatalf("Invalid parameters:\n%v", err)
+	}
+	var sends []propagation.Send
+	if *senders != "" {
+		sends, err = propagation.ParseSends(*senders)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, send := range sends {
+			if send.Node >= data.NumNodes() {
+				log.Fatalf("Sender node %d not found", send.Node)
+			}
+		}
+	}
+	if *recipient >= 0 {
+		switch {
+		case algo != "whisperv6":
+			log.Fatal("Direct messages are supported only by whisperv6")
+		case sends != nil:
+			log.Fatal("Direct message can't be combined with -senders")
+		case *recipient >= data.NumNodes():
+			log.Fatalf("Recipient node %d not found", *recipient)
+		}
+	}
+	for _, kill := range *kills {
+		if kill.Node >= data.NumNodes() {
+			log.Fatalf("Killed node %d not found", kill.Node)
+		}
+	}
+	var targetNodes []int
+	if *targets != "" {
+		targetNodes, err = parseTargets(*targets, data.NumNodes())
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *groupBy != "" {
+		if _, err := nodeGroups(*input, data, *groupBy); err != nil {
+			log.Fatal("Reading node groups failed: ", err)
+		}
+	}
+	var networks []string
+	var bridges []bool
+	if *bridgesBy != "" {
+		networks, bridges, err = networkBridges(*input, data, *bridgesBy)
+		if err != nil {
+			log.Fatal("Reading bridged networks failed: ", err)
+		}
+	}
+
+	var cfg simulation.Config
+	cfg.GossipMode, err = gossip.ParseMode(*gossipMode)
 	if err != nil {
-		log.Fatal("Opening input file failed: ", err)
+		log.Fatal(err)
+	}
+	if *gossipScore {
+		params := gossip.DefaultScoreParams()
+		params.Churn = *meshChurn
+		cfg.GossipScoring = &params
+	}
+	if *bandwidth != "" {
+		cfg.Bandwidth, err = gossip.ParseBandwidthClasses(*bandwidth)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *transports != "" {
+		cfg.Transports, err = gossip.ParseTransportClasses(*transports)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *whisperNodes != "" {
+		cfg.WhisperNodes, err = whisperv6.ParseNodeClasses(*whisperNodes)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *peerLimit != "" {
+		params, err := whisperv6.ParseRateLimit(*peerLimit)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.WhisperLimit = &params
+	}
+	if *codes != "" {
+		cfg.WhisperCodes, err = whisperv6.ParseMessageCodes(*codes)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if algo == "statusgo" {
+		cfg.StatusGo.Protocol, err = statusgo.ParseProtocol(*statusProto)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.StatusGo.MailServers = randomNodes(data.NumNodes(), *mailServers)
+	}
+	if *sampling != "" {
+		cfg.Sampling, err = propagation.ParseSampling(*sampling)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *queue != "" {
+		params, err := gossip.ParseQueueModel(*queue)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.Queue = &params
+	}
+	if *geoLatency && *linkLatency {
+		log.Fatal("Only one of -geo and -linklatency can be used")
+	}
+	if *geoLatency {
+		cfg.Latency, err = geoLatencies(*input, data)
+		if err != nil {
+			log.Fatal("Calculating geo latencies failed: ", err)
+		}
+	}
+	if *linkLatency {
+		cfg.LinkLatencies, err = linkLatencies(*input, data)
+		if err != nil {
+			log.Fatal("Reading link latencies failed: ", err)
+		}
+	}
+	if *preferLinks != "" && *linkTypes == "" {
+		log.Fatal("-preferlinks requires -linktypes")
+	}
+	if *linkTypes != "" {
+		params := &gossip.LinkTypesParams{}
+		params.Profiles, err = gossip.ParseLinkProfiles(*linkTypes)
+		if err != nil {
+			log.Fatal(err)
+		}
+		params.Types, err = linkTypeNames(*input, data)
+		if err != nil {
+			log.Fatal("Reading link types failed: ", err)
+		}
+		if *preferLinks != "" {
+			params.Prefer = strings.Split(*preferLinks, ",")
+		}
+		cfg.LinkTypes = params
+	}
+	cfg.Directed = *directed
+	if *joinInterval > 0 {
+		cfg.Join = &gossip.JoinParams{Bootstrap: *bootstrap, Interval: *joinInterval}
+	}
+	if *natFraction > 0 {
+		cfg.NAT = &gossip.NATParams{Fraction: *natFraction, Relays: *relays}
+	}
+	if *relayed > 0 && *relays == 0 {
+		log.Fatal("-relayed requires -relays nodes to relay links through")
+	}
+	if *relayed > 0 || *linkRelays {
+		cfg.Relayed = &gossip.RelayedParams{Fraction: *relayed, Relays: *relays}
+	}
+	if *linkRelays {
+		cfg.Relayed.Links, err = linkRelayNodes(*input, data)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *wakuRoles != "" {
+		classes, err := gossip.ParseRoleClasses(*wakuRoles)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.Waku = &gossip.WakuParams{Classes: classes, StorePoll: *storePoll}
+	}
+	if *handshake != "" {
+		params, err := gossip.ParseHandshake(*handshake)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.Handshake = &params
+	}
+	if *hybrid != "" {
+		params, err := gossip.ParseHybrid(*hybrid)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.Hybrid = &params
+	}
+	if *dutyCycle != "" {
+		params, err := gossip.ParseDutyCycle(*dutyCycle)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.DutyCycle = &params
+	}
+	if *topics > 0 {
+		cfg.Subscriptions = propagation.RandomSubscriptions(data.NumNodes(), *topics, *subscribe)
+		cfg.Topic = propagation.TopicName(0)
+		cfg.Relay, err = gossip.ParseRelayPolicy(*relay)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *coding != "" {
+		params, err := gossip.ParseCoding(*coding)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.Coding = &params
+	}
+	cfg.Loss = *loss
+	cfg.StopCoverage = *stopCoverage
+	cfg.Quiescence = *quiescence
+	cfg.ClockSkew = *clockSkew
+	cfg.WhisperMessage = &whisperv6.MessageParams{
+		PowTarget: *powTarget,
+		PowTime:   uint32(*powTime),
+		Topic:     *msgTopic,
+		Padding:   *padding,
+	}
+	cfg.MaxDuration = *maxDuration
+	cfg.Kills = *kills
+	cfg.TimeScale = *timeScale
+	cfg.ChunkSize = *chunkSize
+	cfg.WantListSize = *wantList
+	cfg.EthBlocks = *ethBlocks
+	cfg.Walkers = *walkers
+	cfg.SyncInterval = *syncInterval
+	cfg.SyncPushPull = *pushPull
+	if *retries != "" {
+		params, err := gossip.ParseRetries(*retries)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.Retries = &params
+	}
+	if *gossipChoke {
+		params := gossip.DefaultChokeParams()
+		cfg.GossipChoking = &params
+	}
+	cfg.RateLimit = *rateLimit
+	cfg.GossipWithholding = randomNodes(data.NumNodes(), *freeriders)
+	if len(cfg.GossipWithholding) > 0 {
+		slog.Info("Using free-rider nodes", "count", len(cfg.GossipWithholding))
+	}
+	if *spam != "" {
+		fraction, params, err := propagation.ParseSpam(*spam)
+		if err != nil {
+			log.Fatal(err)
+		}
+		params.Nodes = randomNodes(data.NumNodes(), fraction)
+		params.TTL = *ttl
+		cfg.Spam = &params
+		slog.Info("Using spamming nodes", "count", len(params.Nodes), "rate", params.Rate)
+	}
+	cfg.GossipMalicious = randomNodes(data.NumNodes(), *malicious)
+	if len(cfg.GossipMalicious) > 0 {
+		slog.Info("Using malicious nodes", "count", len(cfg.GossipMalicious))
+	}
+	cfg.Seed = *seed
+	if validate {
+		fmt.Printf("Config is valid: %s algorithm, %d nodes, %d links\n", algo, data.NumNodes(), data.NumLinks())
+		return
+	}
+	starts := []int{0}
+	if sends != nil {
+		starts = starts[:0]
+		for _, send := range sends {
+			starts = append(starts, send.Node)
+		}
+	}
+	if *dryRun {
+		messages := len(starts)
+		if cfg.Coding != nil {
+			messages *= cfg.Coding.N
+		}
+		newPlan(algo, data, cfg, messages, *ttl, *size, starts).Fprint(os.Stdout)
+		return
+	}
+	if *progress {
+		cfg.Progress = progressBar(os.Stderr)
+	}
+	if *sinkURL != "" {
+		s, err := sink.New(*sinkURL)
+		if err != nil {
+			log.Fatal("Creating event sink failed: ", err)
+		}
+		defer s.Close()
+		runID := time.Now().UTC().Format("20060102T150405.000")
+		cfg.Events = sink.EventFunc(s, runID)
+		slog.Info("Publishing events to sink", "url", *sinkURL, "run", runID)
 	}
-	log.Printf("Loaded network graph from %s file", *input)
 
-	algo := "whisperv6"
-	if *algorithm == "gossip" {
-		algo = "gossip"
-	} // TODO: add proper validation for algorithm
-	log.Printf("Using %s propagation algorithm", algo)
+	var dash *dashboard
+	if *tui {
+		// keep dashboard readable
+		setLogger(false, true, false)
+		dash = newDashboard(os.Stderr, data.NumNodes())
+		cfg.Progress = dash.Progress
+		if events := cfg.Events; events != nil {
+			cfg.Events = func(e propagation.LogEntry) {
+				events(e)
+				dash.Event(e)
+			}
+		} else {
+			cfg.Events = dash.Event
+		}
+	}
+
+	var eventCounts *propagation.EventCounts
+	if *eventStats {
+		eventCounts = countEvents(&cfg)
+	}
+
+	cfg.Velocity = velocityParams()
+	var sim *simulation.Simulation
+	if algo == "whisperv6" && *snapshot != "" {
+		sim, err = simulation.NewWhisperSimulationWithSnapshot(data, *snapshot, cfg)
+		if err != nil {
+			log.Fatal("Using network snapshot failed: ", err)
+		}
+	} else {
+		sim = newSimulation(algo, data, cfg)
+	}
+	if *checkpoint != "" || *restore != "" {
+		if _, ok := sim.Simulator().(propagation.Checkpointer); !ok {
+			log.Fatalf("Checkpoints are not supported by %s algorithm", algo)
+		}
+	}
+	if *restore != "" {
+		if err := restoreCheckpoint(sim, *restore); err != nil {
+			log.Fatal("Restoring checkpoint failed: ", err)
+		}
+		slog.Info("Restored simulator state", "file", *restore)
+	}
+	if *checkpoint != "" {
+		checkpointOnInterrupt(sim, *checkpoint)
+	}
 
-	sim := NewSimulation(algo, data)
-	log.Printf("Starting message sending simulation for graph with %d nodes...", len(data.Nodes()))
-	sim.Start(*ttl, *size)
+	slog.Info("Starting message sending simulation", "nodes", data.NumNodes())
+	started := time.Now()
+	if dash != nil {
+		dash.Start()
+	}
+	var res *simulation.Results
+	if *recipient >= 0 {
+		res, err = sim.RunDirect(0, *recipient, *ttl, *size)
+		if err != nil {
+			log.Fatal("Sending direct message failed: ", err)
+		}
+	} else {
+		res = sim.Run(*ttl, *size, sends...)
+	}
+	if dash != nil {
+		dash.Stop()
+	}
 	defer sim.Stop()
-	sim.WriteOutputToFile(*output)
+	plog := res.Log
+	plog.Meta = propagation.NewMeta(data, setFlags(flag.CommandLine))
+	if *format == "" {
+		*format = propagation.FormatFromPath(*output)
+	}
+	if err := writeLogFile(plog, *output, *format); err != nil {
+		log.Fatal("Writing output failed: ", err)
+	}
+
+	if *checkpoint != "" {
+		if err := saveCheckpoint(sim, *checkpoint); err != nil {
+			log.Fatal("Saving checkpoint failed: ", err)
+		}
+		slog.Info("Saved simulator state", "file", *checkpoint)
+	}
 
 	// stats
-	ss := stats.Analyze(sim.plog, data.NumNodes(), data.NumLinks())
-	ss.PrintVerbose()
+	ss := res.Stats
+	ss.Events = eventCounts
+	if *groupBy != "" {
+		groups, err := nodeGroups(*input, data, *groupBy)
+		if err != nil {
+			log.Fatal("Reading node groups failed: ", err)
+		}
+		ss.Groups = stats.AnalyzeGroups(plog, groups)
+	}
+	if targetNodes != nil {
+		ss.Targets = stats.AnalyzeTargets([]*propagation.Log{plog}, targetNodes...)
+	}
+	if networks != nil {
+		ss.Bridges = stats.AnalyzeBridges(plog, networks, bridges, networks[starts[0]])
+	}
+	if *communities {
+		ss.Communities = stats.AnalyzeCommunities(plog, data, community.Detect(data))
+	}
+	if *topLinks > 0 || *linksCSV != "" {
+		usage := stats.AnalyzeLinkUsage(plog, data)
+		if *topLinks > 0 {
+			ss.Links = usage.Top(*topLinks)
+		}
+		if *linksCSV != "" {
+			if err := writeLinksCSV(*linksCSV, usage); err != nil {
+				log.Fatal("Writing links CSV failed: ", err)
+			}
+			slog.Info("Written links usage", "file", *linksCSV)
+		}
+	}
+	if *energy {
+		ss.Energy = stats.AnalyzeEnergy(plog, data.NumNodes(), *size, stats.DefaultEnergyModel())
+	}
+	// keep stdout clean if it's used for propagation data
+	statsOut := os.Stdout
+	if *output == "-" {
+		statsOut = os.Stderr
+	}
+	var statsText bytes.Buffer
+	ss.FprintVerbose(io.MultiWriter(statsOut, &statsText))
+	if sends != nil {
+		printSends(statsOut, sends, res.Logs, data.NumNodes(), data.NumLinks())
+	}
+
+	if *db != "" {
+		run := &store.Run{
+			Started:   started,
+			Algorithm: algo,
+			Network:   *input,
+			Nodes:     data.NumNodes(),
+			Links:     data.NumLinks(),
+			TTL:       *ttl,
+			Size:      *size,
+			Log:       plog,
+			Stats:     ss,
+		}
+		if err := saveRun(*db, run); err != nil {
+			log.Fatal("Saving results failed: ", err)
+		}
+		slog.Info("Saved run results", "db", *db)
+	}
+
+	if *bundleOut != "" {
+		b := &bundle.Bundle{
+			Meta: bundle.Meta{
+				Created:   started,
+				Algorithm: algo,
+				Seed:      cfg.Seed,
+				TTL:       *ttl,
+				Size:      *size,
+			},
+			Log:   plog,
+			Stats: statsText.String(),
+		}
+		if err := writeBundle(*bundleOut, *input, data, fixed || *discoveryBy != "", b); err != nil {
+			log.Fatal("Writing bundle failed: ", err)
+		}
+		slog.Info("Written simulation bundle", "file", *bundleOut)
+	}
+
+	slog.Info("Written propagation data", "file", *output)
+
+	if *watch {
+		watchTopology(sim, *input, *output, *format, *ttl, *size, statsOut)
+	}
+}
+
+// randomNodes returns random fraction of nodes indices, excluding
+// node 0, which is used as a message sender.
+func randomNodes(count int, fraction float64) []int {
+	n := int(fraction * float64(count))
+	if n <= 0 || count < 2 {
+		return nil
+	}
+	if n > count-1 {
+		n = count - 1
+	}
+	perm := rand.Perm(count - 1)[:n]
+	for i := range perm {
+		perm[i]++
+	}
+	return perm
+}
+
+// discoverNetwork forms network with the given peer discovery protocol,
+// optionally saving it to the file at path.
+func discoverNetwork(name string, nodes, maxPeers int, path string) (*graph.Graph, error) {
+	protocol, err := discovery.ParseProtocol(name)
+	if err != nil {
+		return nil, err
+	}
+	params := discovery.DefaultParams(protocol, nodes)
+	params.MaxPeers = maxPeers
+	res, err := discovery.Run(params)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Formed network with peer discovery", "protocol", name,
+		"nodes", res.Graph.NumNodes(), "links", res.Graph.NumLinks(), "messages", res.Messages)
 
-	log.Printf("Written propagation data into %s", *output)
+	if path != "" {
+		fd, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		defer fd.Close()
+		if err := discovery.WriteJSON(fd, res.Graph); err != nil {
+			return nil, err
+		}
+		slog.Info("Saved network graph", "file", path)
+	}
+	return res.Graph, nil
+}
+
+// geoLatencies reads nodes coordinates from the network file and returns
+// latency function based on distances between nodes.
+func geoLatencies(path string, data *graph.Graph) (func(from, to int) time.Duration, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	coords, err := geo.ReadCoords(fd)
+	if err != nil {
+		return nil, err
+	}
+	nodesCoords, err := geo.NodesCoords(data, coords)
+	if err != nil {
+		return nil, err
+	}
+	return geo.Latency(nodesCoords, geo.DefaultLatencyParams()), nil
+}
+
+// linkLatencies reads per link latencies from the "latency" field (in
+// milliseconds) of the network file links. Links without latency are skipped.
+func linkLatencies(path string, data *graph.Graph) (map[gossip.LinkIndex]time.Duration, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var network struct {
+		Links []struct {
+			Source  string   `json:"source"`
+			Target  string   `json:"target"`
+			Latency *float64 `json:"latency"`
+		} `json:"links"`
+	}
+	if err := json.NewDecoder(fd).Decode(&network); err != nil {
+		return nil, fmt.Errorf("parse network: %v", err)
+	}
+
+	idx := make(map[string]int, data.NumNodes())
+	for i, node := range data.Nodes() {
+		idx[node.ID()] = i
+	}
+	ret := make(map[gossip.LinkIndex]time.Duration)
+	for _, link := range network.Links {
+		if link.Latency == nil {
+			continue
+		}
+		from, ok := idx[link.Source]
+		if !ok {
+			return nil, fmt.Errorf("unknown node '%s'", link.Source)
+		}
+		to, ok := idx[link.Target]
+		if !ok {
+			return nil, fmt.Errorf("unknown node '%s'", link.Target)
+		}
+		ret[gossip.LinkIndex{From: from, To: to}] = time.Duration(*link.Latency * float64(time.Millisecond))
+	}
+	return ret, nil
+}
+
+// linkRelayNodes reads relay node ids from the "relay" field of the network
+// file links. Links without relay are direct ones.
+func linkRelayNodes(path string, data *graph.Graph) (map[gossip.LinkIndex]int, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var network struct {
+		Links []struct {
+			Source string `json:"source"`
+			Target string `json:"target"`
+			Relay  string `json:"relay"`
+		} `json:"links"`
+	}
+	if err := json.NewDecoder(fd).Decode(&network); err != nil {
+		return nil, fmt.Errorf("parse network: %v", err)
+	}
+
+	idx := make(map[string]int, data.NumNodes())
+	for i, node := range data.Nodes() {
+		idx[node.ID()] = i
+	}
+	ret := make(map[gossip.LinkIndex]int)
+	for _, link := range network.Links {
+		if link.Relay == "" {
+			continue
+		}
+		from, ok := idx[link.Source]
+		if !ok {
+			return nil, fmt.Errorf("unknown node '%s'", link.Source)
+		}
+		to, ok := idx[link.Target]
+		if !ok {
+			return nil, fmt.Errorf("unknown node '%s'", link.Target)
+		}
+		relay, ok := idx[link.Relay]
+		if !ok {
+			return nil, fmt.Errorf("unknown relay node '%s' of link %s-%s", link.Relay, link.Source, link.Target)
+		}
+		ret[gossip.LinkIndex{From: from, To: to}] = relay
+	}
+	return ret, nil
+}
+
+// linkTypeNames reads link types from the "type" field of the network file
+// links. Links without type are untyped ones.
+func linkTypeNames(path string, data *graph.Graph) (map[gossip.LinkIndex]string, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var network struct {
+		Links []struct {
+			Source string `json:"source"`
+			Target string `json:"target"`
+			Type   string `json:"type"`
+		} `json:"links"`
+	}
+	if err := json.NewDecoder(fd).Decode(&network); err != nil {
+		return nil, fmt.Errorf("parse network: %v", err)
+	}
+
+	idx := make(map[string]int, data.NumNodes())
+	for i, node := range data.Nodes() {
+		idx[node.ID()] = i
+	}
+	ret := make(map[gossip.LinkIndex]string)
+	for _, link := range network.Links {
+		if link.Type == "" {
+			continue
+		}
+		from, ok := idx[link.Source]
+		if !ok {
+			return nil, fmt.Errorf("unknown node '%s'", link.Source)
+		}
+		to, ok := idx[link.Target]
+		if !ok {
+			return nil, fmt.Errorf("unknown node '%s'", link.Target)
+		}
+		ret[gossip.LinkIndex{From: from, To: to}] = link.Type
+	}
+	return ret, nil
+}
+
+// nodeGroups reads group names of nodes from the given field of the network
+// file nodes, ordered by node index. Nodes without the field get empty name.
+func nodeGroups(path string, data *graph.Graph, field string) ([]string, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var network struct {
+		Nodes []map[string]interface{} `json:"nodes"`
+	}
+	if err := json.NewDecoder(fd).Decode(&network); err != nil {
+		return nil, fmt.Errorf("parse network: %v", err)
+	}
+
+	byID := make(map[string]string, len(network.Nodes))
+	for _, node := range network.Nodes {
+		id, ok := node["id"]
+		if !ok {
+			continue
+		}
+		if v, ok := node[field]; ok && v != nil {
+			byID[fmt.Sprint(id)] = fmt.Sprint(v)
+		}
+	}
+	ret := make([]string, data.NumNodes())
+	for i, node := range data.Nodes() {
+		ret[i] = byID[node.ID()]
+	}
+	return ret, nil
+}
+
+// writeLinksCSV writes links usage into the CSV file at path.
+func writeLinksCSV(path string, usage stats.LinkUsages) error {
+	fd, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create output file: %v", err)
+	}
+	defer fd.Close()
+
+	return usage.WriteCSV(fd)
+}
+
+// saveRun saves run results into the SQLite database at path.
+func saveRun(path string, run *store.Run) error {
+	db, err := store.Open(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	id, err := db.SaveRun(run)
+	if err != nil {
+		return err
+	}
+	slog.Debug("Stored run", "id", id)
+	return nil
 }
 
 func setGethLogLevel(level string) {
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/simulation/propagation/gossip"
+	"github.com/divan/simulation/simulation"
+	"github.com/divan/simulation/stats"
+)
+
+// natCmd implements 'nat' subcommand, which runs gossip simulation with
+// increasing fraction of nodes behind NAT and reports how it affects coverage.
+func natCmd(args []string) {
+	fs := flag.NewFlagSet("nat", flag.ExitOnError)
+	var (
+		input     = fs.String("i", "network.json", "Input filename for pregenerated data to be used with simulation")
+		ttl       = fs.Int("ttl", 10, "TTL for generated messages")
+		size      = fs.Int("msgSize", 400, "Payload size for generated messages")
+		fractions = fs.String("fractions", "0,0.2,0.4,0.6,0.8", "Comma-separated fractions of nodes behind NAT to simulate")
+		relays    = fs.Int("relays", 0, "Number of relay nodes forwarding messages between nodes behind NAT")
+	)
+	setupLog := logFlags(fs)
+	fs.Parse(args)
+	setupLog()
+
+	values, err := parseFractions(*fractions)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	data, err := formats.FromD3JSON(*input)
+	if err != nil {
+		log.Fatal("Opening input file failed: ", err)
+	}
+	slog.Info("Loaded network graph", "file", *input)
+
+	fmt.Printf("%-8s %-8s %-16s %-16s %s\n", "NAT", "Relays", "Nodes coverage", "Links coverage", "Time")
+	for _, fraction := range values {
+		cfg := simulation.Config{NAT: &gossip.NATParams{Fraction: fraction, Relays: *relays}}
+		sim := newSimulation("gossip", data, cfg)
+		ss := sim.Run(*ttl, *size).Stats
+		sim.Stop()
+
+		fmt.Printf("%-8.2f %-8d %-16v %-16v %v\n", fraction, *relays,
+			ss.NodeCoverage, ss.LinkCoverage, ss.Time)
+	}
+}
+
+// relayedCmd implements 'relayed' subcommand, which runs gossip simulation
+// with increasing fraction of links going through circuit relays and
+// reports how it slows dissemination.
+func relayedCmd(args []string) {
+	fs := flag.NewFlagSet("relayed", flag.ExitOnError)
+	var (
+		input     = fs.String("i", "network.json", "Input filename for pregenerated data to be used with simulation")
+		ttl       = fs.Int("ttl", 10, "TTL for generated messages")
+		size      = fs.Int("msgSize", 400, "Payload size for generated messages")
+		fractions = fs.String("fractions", "0,0.2,0.4,0.6,0.8", "Comma-separated fractions of relayed links to simulate")
+		relays    = fs.Int("relays", 3, "Number of relay nodes relayed links go through")
+		queue     = fs.Bool("queue", true, "Queue transmissions at node uplinks, so relays get loaded by links they relay")
+		geoLat    = fs.Bool("geo", false, "Derive link latencies from nodes coordinates (lat/lon fields in the input file)")
+	)
+	setupLog := logFlags(fs)
+	fs.Parse(args)
+	setupLog()
+
+	values, err := parseFractions(*fractions)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *relays <= 0 {
+		log.Fatal("-relays should be positive")
+	}
+
+	data, err := formats.FromD3JSON(*input)
+	if err != nil {
+		log.Fatal("Opening input file failed: ", err)
+	}
+	slog.Info("Loaded network graph", "file", *input)
+
+	var base simulation.Config
+	if *queue {
+		params := gossip.DefaultQueueParams()
+		base.Queue = &params
+	}
+	if *geoLat {
+		base.Latency, err = geoLatencies(*input, data)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	fmt.Printf("%-8s %-8s %-16s %-16s %-10s %s\n", "Relayed", "Relays", "Nodes coverage", "Links coverage", "Median", "Time")
+	for _, fraction := range values {
+		cfg := base
+		cfg.Relayed = &gossip.RelayedParams{Fraction: fraction, Relays: *relays}
+		sim := newSimulation("gossip", data, cfg)
+		res := sim.Run(*ttl, *size)
+		sim.Stop()
+
+		ss := res.Stats
+		fmt.Printf("%-8.2f %-8d %-16v %-16v %-10v %v\n", fraction, *relays,
+			ss.NodeCoverage, ss.LinkCoverage, stats.LatencyPercentiles(res.Log, 0.5)[0], ss.Time)
+	}
+}
+
+// parseFractions parses comma-separated list of fractions in 0..1 range.
+func parseFractions(s string) ([]float64, error) {
+	var ret []float64
+	for _, v := range strings.Split(s, ",") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || f < 0 || f > 1 {
+			return nil, fmt.Errorf("wrong fraction '%s'", v)
+		}
+		ret = append(ret, f)
+	}
+	return ret, nil
+}
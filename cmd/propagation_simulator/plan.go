@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation/bitswap"
+	"github.com/divan/simulation/propagation/eth"
+	"github.com/divan/simulation/propagation/gossip"
+	"github.com/divan/simulation/simulation"
+)
+
+// Rough per node and per link memory costs of simulators, used for
+// estimating plan of the run. Whisper runs full in-process nodes, so it's
+// way heavier than other simulators, as does statusgo.
+const (
+	whisperNodeMemory = 8 << 20
+	nodeMemory        = 16 << 10
+	linkMemory        = 256
+	logEntryMemory    = 16 // single node or link index in propagation log
+
+	gossipHopDelay = time.Millisecond // rough scheduling overhead of gossip hop without latencies
+)
+
+// Plan describes resolved parameters of the simulation run, with
+// estimated resources it needs.
+type Plan struct {
+	Algorithm string
+	Nodes     int
+	Links     int
+	Messages  int
+	Hops      int // hops needed to reach all reachable nodes
+
+	Memory      int64         // estimated, in bytes
+	Duration    time.Duration // estimated
+	MaxDuration time.Duration // upper bound, zero if unbounded
+	Seed        int64         // zero if random
+}
+
+// newPlan resolves plan of the simulation run with the given config.
+func newPlan(algo string, data *graph.Graph, cfg simulation.Config, messages, ttl, size int, senders []int) *Plan {
+	p := &Plan{
+		Algorithm: algo,
+		Nodes:     data.NumNodes(),
+		Links:     data.NumLinks(),
+		Messages:  messages,
+		Hops:      hops(data, cfg.Directed, senders),
+		Seed:      cfg.Seed,
+	}
+	if p.Hops > ttl {
+		p.Hops = ttl
+	}
+
+	perNode := int64(nodeMemory)
+	if algo == "whisperv6" || algo == "statusgo" {
+		perNode = whisperNodeMemory
+	}
+	if algo == "bitswap" {
+		perNode += int64(size)
+	}
+	// each message may activate every link in both directions
+	logSize := int64(messages) * int64(2*p.Links+p.Nodes) * logEntryMemory
+	p.Memory = int64(p.Nodes)*perNode + int64(p.Links)*linkMemory + logSize
+
+	switch algo {
+	case "whisperv6", "statusgo":
+		p.MaxDuration = time.Duration(ttl)*time.Second + 200*time.Millisecond
+		p.Duration = p.MaxDuration
+	case "bitswap":
+		chunkSize := cfg.ChunkSize
+		if chunkSize <= 0 {
+			chunkSize = bitswap.DefaultChunkSize
+		}
+		wantList := cfg.WantListSize
+		if wantList <= 0 {
+			wantList = bitswap.DefaultWantListSize
+		}
+		chunks := (size + chunkSize - 1) / chunkSize
+		rounds := (chunks+wantList-1)/wantList + 1 // want-have round trip
+		p.Duration = time.Duration(p.Hops*rounds) * 2 * bitswap.DefaultLatency
+	case "eth":
+		latency := eth.DefaultLatency
+		if cfg.Latency != nil {
+			latency = maxLatency(data, cfg)
+		}
+		// most nodes get pushed message, the rest fetch it after timeout
+		p.Duration = time.Duration(p.Hops)*latency + eth.DefaultArriveTimeout + 2*latency
+		if cfg.EthBlocks {
+			p.Duration += eth.DefaultImportTime
+		}
+	case "randomwalk", "antientropy", "core":
+		// walks, syncs and core events run on virtual clock, taking no real time
+		p.Duration = 0
+	default:
+		p.Duration = time.Duration(p.Hops) * (gossipHopDelay + maxLatency(data, cfg))
+		if cfg.Join != nil && p.Nodes > cfg.Join.Bootstrap {
+			p.Duration += time.Duration(p.Nodes-cfg.Join.Bootstrap) * cfg.Join.Interval
+		}
+		if cfg.TimeScale > 0 {
+			p.Duration = time.Duration(float64(p.Duration) / cfg.TimeScale)
+		}
+	}
+	if cfg.MaxDuration > 0 && (p.MaxDuration == 0 || cfg.MaxDuration < p.MaxDuration) {
+		p.MaxDuration = cfg.MaxDuration
+	}
+	if p.MaxDuration > 0 && p.Duration > p.MaxDuration {
+		p.Duration = p.MaxDuration
+	}
+	return p
+}
+
+// maxLatency returns the largest gossip link latency of the config.
+func maxLatency(data *graph.Graph, cfg simulation.Config) time.Duration {
+	var max time.Duration
+	for _, link := range data.Links() {
+		from, to := link.FromIdx(), link.ToIdx()
+		l := gossip.LinkIndex{From: from, To: to}
+		d, ok := cfg.LinkLatencies[l]
+		if !ok && cfg.Latency != nil {
+			d = cfg.Latency(from, to)
+		}
+		if cfg.LinkTypes != nil {
+			if p, typed := cfg.LinkTypes.Profiles[cfg.LinkTypes.Types[l]]; typed {
+				d = p.Latency
+			}
+		}
+		if d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+// hops returns number of hops from senders to the farthest reachable node.
+func hops(data *graph.Graph, directed bool, senders []int) int {
+	n := data.NumNodes()
+	adj := make([][]int, n)
+	for _, link := range data.Links() {
+		from, to := link.FromIdx(), link.ToIdx()
+		adj[from] = append(adj[from], to)
+		if !directed {
+			adj[to] = append(adj[to], from)
+		}
+	}
+
+	dist := make([]int, n)
+	for i := range dist {
+		dist[i] = -1
+	}
+	var queue []int
+	for _, s := range senders {
+		if s < n && dist[s] < 0 {
+			dist[s] = 0
+			queue = append(queue, s)
+		}
+	}
+	var max int
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, peer := range adj[node] {
+			if dist[peer] < 0 {
+				dist[peer] = dist[node] + 1
+				if dist[peer] > max {
+					max = dist[peer]
+				}
+				queue = append(queue, peer)
+			}
+		}
+	}
+	return max
+}
+
+// Fprint prints plan to w.
+func (p *Plan) Fprint(w io.Writer) {
+	fmt.Fprintln(w, "Plan:")
+	fmt.Fprintln(w, "Algorithm:", p.Algorithm)
+	fmt.Fprintln(w, "Nodes:", p.Nodes)
+	fmt.Fprintln(w, "Links:", p.Links)
+	fmt.Fprintln(w, "Messages:", p.Messages)
+	fmt.Fprintln(w, "Hops:", p.Hops)
+	fmt.Fprintln(w, "Estimated memory:", formatBytes(p.Memory))
+	if p.MaxDuration > p.Duration {
+		fmt.Fprintf(w, "Estimated duration: %v (at most %v)\n", p.Duration, p.MaxDuration)
+	} else {
+		fmt.Fprintln(w, "Estimated duration:", p.Duration)
+	}
+	if p.Seed != 0 {
+		fmt.Fprintln(w, "Seed:", p.Seed)
+	} else {
+		fmt.Fprintln(w, "Seed: random")
+	}
+}
+
+// formatBytes formats n bytes in human readable form, i.e. 1.5 MiB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// readLog reads propagation log from the file. Format is detected by the file
+// extension (see propagation.FormatFromPath). Path "-" means JSON from stdin,
+// and files with ".gz" extension are expected to be gzip-compressed.
+func readLog(path string) (*propagation.Log, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		fd, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer fd.Close()
+		r = fd
+	}
+
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("open gzip: %v", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	plog, err := propagation.DecodeLog(r, propagation.FormatFromPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("parse propagation log: %v", err)
+	}
+	return plog, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/propagation/gossip"
+	"github.com/divan/simulation/simulation"
+	"github.com/divan/simulation/stats"
+)
+
+// priorityCmd implements 'priority' subcommand, which sends messages of
+// all priority classes at once with rate limited gossip algorithm, and
+// compares delivery latency per class.
+func priorityCmd(args []string) {
+	fs := flag.NewFlagSet("priority", flag.ExitOnError)
+	var (
+		input    = fs.String("i", "network.json", "Input filename for pregenerated data to be used with simulation")
+		ttl      = fs.Int("ttl", 10, "TTL for generated messages")
+		size     = fs.Int("msgSize", 400, "Payload size for generated messages")
+		rate     = fs.Float64("ratelimit", 100, "Messages per second each node sends to each of its peers")
+		messages = fs.Int("messages", 10, "Number of messages of each class, sent from random nodes")
+	)
+	setupLog := logFlags(fs)
+	fs.Parse(args)
+	setupLog()
+
+	data, err := formats.FromD3JSON(*input)
+	if err != nil {
+		log.Fatal("Opening input file failed: ", err)
+	}
+	slog.Info("Loaded network graph", "file", *input)
+
+	cfg := simulation.Config{RateLimit: *rate}
+	sim := gossip.NewSimulator(data, 4, 10, cfg.GossipOptions()...)
+	defer sim.Stop()
+
+	classes := []gossip.Priority{gossip.PriorityHigh, gossip.PriorityNormal, gossip.PriorityBulk}
+	logs := make(map[gossip.Priority][]*propagation.Log)
+	var (
+		wg sync.WaitGroup
+		mx sync.Mutex
+	)
+	for _, priority := range classes {
+		for i := 0; i < *messages; i++ {
+			wg.Add(1)
+			go func(priority gossip.Priority, node int) {
+				defer wg.Done()
+				plog := sim.SendMessageWithPriority(node, *ttl, *size, priority)
+				mx.Lock()
+				logs[priority] = append(logs[priority], plog)
+				mx.Unlock()
+			}(priority, rand.Intn(data.NumNodes()))
+		}
+	}
+	wg.Wait()
+
+	percentiles := []float64{0.5, 0.9, 0.99, 1.0}
+	fmt.Printf("%-8s %-10s %-10s %-10s %-10s %s\n", "Class", "Coverage", "p50", "p90", "p99", "max")
+	for _, priority := range classes {
+		// average percentiles over all messages of the class
+		sums := make([]float64, len(percentiles))
+		var coverage float64
+		for _, plog := range logs[priority] {
+			for i, l := range stats.LatencyPercentiles(plog, percentiles...) {
+				sums[i] += float64(l)
+			}
+			coverage += stats.Analyze(plog, data.NumNodes(), data.NumLinks()).NodeCoverage.Percentage
+		}
+		n := float64(len(logs[priority]))
+		fmt.Printf("%-8s %-10s", priority, fmt.Sprintf("%.0f%%", coverage/n))
+		for _, sum := range sums {
+			fmt.Printf(" %-10v", time.Duration(sum/n).Round(time.Millisecond))
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"log/slog"
+	"net/http"
+	_ "net/http/pprof" // registers pprof handlers
+	"os"
+	"runtime/trace"
+)
+
+// profileFlags registers profiling flags in the flag set and returns a
+// function starting profiling, to be called after flags are parsed. That
+// function returns another one, stopping profiling, to be deferred.
+func profileFlags(fs *flag.FlagSet) func() func() {
+	var (
+		pprofAddr = fs.String("pprof", "", "Address to serve net/http/pprof on during the run, i.e. :6060 (optional)")
+		traceFile = fs.String("trace", "", "Filename to write runtime execution trace of the run into (optional)")
+	)
+	return func() func() {
+		return startProfiling(*pprofAddr, *traceFile)
+	}
+}
+
+// startProfiling starts pprof server and execution tracing, if enabled,
+// and returns a function stopping tracing. pprof server keeps running
+// until the program exits.
+func startProfiling(pprofAddr, traceFile string) func() {
+	if pprofAddr != "" {
+		go func() {
+			if err := http.ListenAndServe(pprofAddr, nil); err != nil {
+				slog.Error("Serving pprof failed", "error", err)
+			}
+		}()
+		slog.Info("Serving pprof", "url", "http://"+pprofAddr+"/debug/pprof/")
+	}
+	if traceFile == "" {
+		return func() {}
+	}
+
+	fd, err := os.Create(traceFile)
+	if err != nil {
+		log.Fatal("Creating trace file failed: ", err)
+	}
+	if err := trace.Start(fd); err != nil {
+		log.Fatal("Starting execution trace failed: ", err)
+	}
+	slog.Info("Writing execution trace", "file", traceFile)
+	return func() {
+		trace.Stop()
+		if err := fd.Close(); err != nil {
+			slog.Error("Writing execution trace failed", "error", err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// progressBarWidth is the width of progress bar in characters.
+const progressBarWidth = 40
+
+// progressBar returns ProgressFunc that draws terminal progress bar for
+// each simulation phase into w.
+func progressBar(w io.Writer) propagation.ProgressFunc {
+	var (
+		mx      sync.Mutex
+		phase   string
+		percent = -1
+	)
+	return func(p propagation.Progress) {
+		pct := int(p.Percentage())
+		if pct > 100 {
+			pct = 100
+		}
+
+		mx.Lock()
+		defer mx.Unlock()
+		if p.Phase == phase && pct == percent {
+			return
+		}
+		if p.Phase != phase && phase != "" {
+			fmt.Fprintln(w)
+		}
+		phase, percent = p.Phase, pct
+
+		filled := progressBarWidth * pct / 100
+		bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+		fmt.Fprintf(w, "\r%-18s [%s] %3d%% (%d/%d)", p.Phase, bar, pct, p.Done, p.Total)
+		if pct == 100 {
+			fmt.Fprintln(w)
+			phase = ""
+		}
+	}
+}
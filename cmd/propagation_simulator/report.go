@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/divan/simulation/analysis"
+	"github.com/divan/simulation/report"
+)
+
+// reportCmd implements 'report' subcommand, which renders a self-contained
+// HTML report for the given network and propagation log.
+func reportCmd(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	var (
+		network  = fs.String("n", "network.json", "Input filename for network graph data")
+		plogFile = fs.String("p", "propagation.json", "Input filename for propagation log data")
+		bundleIn = fs.String("b", "", "Input filename of simulation bundle, used instead of -n and -p (optional)")
+		output   = fs.String("o", "report.html", "Output filename for HTML report")
+		title    = fs.String("title", "Propagation simulation report", "Report title")
+		models   = fs.String("models", "", "Comma-separated analytical models (push, pushpull) to overlay expected coverage of with the simulated one (optional)")
+		fanout   = fs.Int("fanout", 4, "Fanout of analytical models, nodes each informed node pushes the message to per round")
+		round    = fs.Duration("round", 0, "Round duration of analytical models, estimated as median per hop latency of the log if 0")
+	)
+	setupLog := logFlags(fs)
+	fs.Parse(args)
+	setupLog()
+
+	data, plog, err := readInputs(*bundleIn, *network, *plogFile)
+	if err != nil {
+		log.Fatal("Opening input files failed: ", err)
+	}
+
+	r := report.New(*title, data, plog)
+	if *bundleIn != "" {
+		r.AddParam("Bundle file", *bundleIn)
+	} else {
+		r.AddParam("Network file", *network)
+		r.AddParam("Propagation log file", *plogFile)
+	}
+	if *models != "" {
+		params := analysis.Params{Nodes: data.NumNodes(), Fanout: *fanout, Round: *round}
+		if params.Round == 0 {
+			params.Round = analysis.EstimateRound(plog)
+		}
+		for _, name := range strings.Split(*models, ",") {
+			model, err := analysis.ParseModel(strings.TrimSpace(name))
+			if err != nil {
+				log.Fatal(err)
+			}
+			r.AddModel(model.String(), analysis.Expected(model, params))
+		}
+		r.AddParam("Model fanout", fmt.Sprint(params.Fanout))
+		r.AddParam("Model round", params.Round.String())
+	}
+
+	fd, err := os.Create(*output)
+	if err != nil {
+		log.Fatal("Creating report file failed: ", err)
+	}
+	defer fd.Close()
+
+	if err := r.WriteHTML(fd); err != nil {
+		log.Fatal("Rendering report failed: ", err)
+	}
+	slog.Info("Written report", "file", *output)
+}
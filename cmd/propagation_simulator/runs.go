@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/simulation"
+	"github.com/divan/simulation/stats"
+)
+
+// runsCmd implements 'runs' subcommand, which executes multiple independent
+// gossip simulations in parallel, each with its own random source, and
+// prints stats of each run.
+func runsCmd(args []string) {
+	fs := flag.NewFlagSet("runs", flag.ExitOnError)
+	var (
+		input   = fs.String("i", "network.json", "Input filename for pregenerated data to be used with simulation")
+		output  = fs.String("o", "", "Output filename for each run propagation data, with %d replaced by run number (optional)")
+		dir     = fs.String("dir", "", "Output directory, writes artifacts of each run into <dir>/<timestamp>/run-<n>/ (optional)")
+		ttl     = fs.Int("ttl", 10, "TTL for generated messages")
+		size    = fs.Int("msgSize", 400, "Payload size for generated messages")
+		n       = fs.Int("n", 10, "Number of runs")
+		workers = fs.Int("workers", 0, "Number of runs executed in parallel, GOMAXPROCS if 0")
+		seed    = fs.Int64("seed", 1, "Random seed of the first run, incremented for each next one")
+		targets = fs.String("targets", "", "Comma-separated target nodes to report delivery time and success across runs for (optional)")
+		starts  = fs.Bool("starts", false, "Send message of each run from start node sampled with -seed instead of node 0, and report coverage and latency distribution across start nodes")
+	)
+	setupLog := logFlags(fs)
+	startProfile := profileFlags(fs)
+	velocityParams := velocityFlags(fs)
+	fs.Parse(args)
+	setupLog()
+	defer startProfile()()
+
+	if *output != "" && !strings.Contains(*output, "%d") {
+		log.Fatalf("Output filename should contain %%d for run number")
+	}
+	data, err := formats.FromD3JSON(*input)
+	if err != nil {
+		log.Fatal("Opening input file failed: ", err)
+	}
+	slog.Info("Loaded network graph", "file", *input)
+	var targetNodes []int
+	if *targets != "" {
+		targetNodes, err = parseTargets(*targets, data.NumNodes())
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	runs := propagation.SeededRuns(*n, *seed, 0, *ttl, *size)
+	if *starts {
+		runs = propagation.SampledRuns(*n, *seed, data.NumNodes(), *ttl, *size)
+	}
+	logs, err := propagation.RunMany(runs, *workers, func(seed int64) propagation.Simulator {
+		return newSimulation("gossip", data, simulation.Config{Seed: seed}).Simulator()
+	})
+	if err != nil {
+		log.Fatal("Running simulations failed: ", err)
+	}
+
+	for i, plog := range logs {
+		params := setFlags(fs)
+		params["seed"] = strconv.FormatInt(runs[i].Seed, 10)
+		if *starts {
+			params["start"] = strconv.Itoa(runs[i].Node)
+		}
+		plog.Meta = propagation.NewMeta(data, params)
+	}
+	if *output != "" {
+		for i, plog := range logs {
+			path := fmt.Sprintf(*output, i)
+			if err := writeLogFile(plog, path, propagation.FormatFromPath(path)); err != nil {
+				log.Fatal("Writing output failed: ", err)
+			}
+		}
+		slog.Info("Written propagation data", "runs", len(logs))
+	}
+	if *dir != "" {
+		runsDir, err := writeRunsArtifacts(*dir, logs, data.NumNodes(), data.NumLinks(), velocityParams())
+		if err != nil {
+			log.Fatal("Writing artifacts failed: ", err)
+		}
+		slog.Info("Written runs artifacts", "dir", runsDir)
+	}
+	printRuns(os.Stdout, runs, logs, data.NumNodes(), data.NumLinks(), velocityParams())
+	if targetNodes != nil {
+		fmt.Fprintln(os.Stdout, "Targets:", stats.AnalyzeTargets(logs, targetNodes...))
+	}
+	if *starts {
+		nodes := make([]int, len(runs))
+		for i, run := range runs {
+			nodes[i] = run.Node
+		}
+		fmt.Fprintln(os.Stdout, "Start nodes:", stats.AnalyzeStarts(logs, nodes, data.NumNodes()))
+	}
+}
+
+// writeRunsArtifacts writes log, stats and metadata of each run into
+// run-<n> directory of the new batch directory in dir, and returns the
+// latter.
+func writeRunsArtifacts(dir string, logs []*propagation.Log, nodeCount, linkCount int, params stats.VelocityParams) (string, error) {
+	runsDir, err := newRunsDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for i, plog := range logs {
+		runDir := filepath.Join(runsDir, fmt.Sprintf("run-%d", i))
+		if err := os.MkdirAll(runDir, 0755); err != nil {
+			return "", err
+		}
+		if err := writeLogFile(plog, filepath.Join(runDir, logArtifact), "json"); err != nil {
+			return "", err
+		}
+		if err := writeArtifacts(runDir, plog, nodeCount, linkCount, params); err != nil {
+			return "", err
+		}
+	}
+	return runsDir, nil
+}
+
+// printRuns prints stats of each independent run.
+func printRuns(w io.Writer, runs []propagation.Run, logs []*propagation.Log, nodeCount, linkCount int, params stats.VelocityParams) {
+	fmt.Fprintln(w, "Runs:")
+	fmt.Fprintf(w, "%-6s %-8s %-8s %-16s %-10s %-12s %-8s %s\n", "Run", "Seed", "Start", "Coverage", "p50", "Peak, n/s", "AUC", "Time")
+	for i, plog := range logs {
+		ss := stats.Analyze(plog, nodeCount, linkCount)
+		p50 := stats.LatencyPercentiles(plog, 0.5)[0]
+		v := stats.AnalyzeVelocity(plog, nodeCount, params)
+		fmt.Fprintf(w, "%-6d %-8d %-8d %-16v %-10v %-12.1f %-8.3f %v\n", i, runs[i].Seed, runs[i].Node, ss.NodeCoverage, p50, v.Peak, v.AUC, ss.Time)
+	}
+}
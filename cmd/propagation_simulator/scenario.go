@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/scenario"
+	"github.com/divan/simulation/simulation"
+	"github.com/divan/simulation/stats"
+)
+
+// scenarioCmd implements 'scenario' subcommand, which runs scripted
+// timeline of events from the YAML file.
+func scenarioCmd(args []string) {
+	fs := flag.NewFlagSet("scenario", flag.ExitOnError)
+	var (
+		input     = fs.String("i", "network.json", "Input filename for pregenerated data to be used with simulation")
+		output    = fs.String("o", "propagation.json", "Output filename for p2p sending data ('-' for stdout, s3:// or gs:// URL for object storage, '.gz' extension for gzip compression)")
+		format    = fs.String("format", "", "Output format for propagation data (json, pb, msgpack), guessed by output file extension if empty")
+		file      = fs.String("s", "scenario.yaml", "Scenario filename")
+		algorithm = fs.String("algorithm", "whisperv6", "Propagation algorithm to use (whisperv6, gossip, bitswap, eth, randomwalk, antientropy, core)")
+	)
+	setupLog := logFlags(fs)
+	fs.Parse(args)
+	setupLog()
+
+	s, err := scenario.Load(*file)
+	if err != nil {
+		log.Fatal("Loading scenario failed: ", err)
+	}
+	slog.Info("Loaded scenario", "events", len(s.Events), "file", *file)
+
+	data, err := formats.FromD3JSON(*input)
+	if err != nil {
+		log.Fatal("Opening input file failed: ", err)
+	}
+	slog.Info("Loaded network graph", "file", *input)
+
+	algo := algorithmName(*algorithm)
+	slog.Info("Using propagation algorithm", "algorithm", algo)
+
+	sim := newSimulation(algo, data, simulation.Config{})
+	defer sim.Stop()
+
+	runner := scenario.NewRunner(data, sim.Simulator())
+	plog, err := runner.Run(s)
+	if err != nil {
+		log.Fatal("Running scenario failed: ", err)
+	}
+	plog.Meta = propagation.NewMeta(data, setFlags(fs))
+	if *format == "" {
+		*format = propagation.FormatFromPath(*output)
+	}
+	if err := writeLogFile(plog, *output, *format); err != nil {
+		log.Fatal("Writing output failed: ", err)
+	}
+
+	ss := stats.Analyze(plog, data.NumNodes(), data.NumLinks())
+	// keep stdout clean if it's used for propagation data
+	statsOut := os.Stdout
+	if *output == "-" {
+		statsOut = os.Stderr
+	}
+	ss.FprintVerbose(statsOut)
+
+	slog.Info("Written propagation data", "file", *output)
+}
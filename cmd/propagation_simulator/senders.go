@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/stats"
+)
+
+// printSends prints stats of each message sent with multiple senders,
+// so propagation of concurrent messages can be compared.
+func printSends(w io.Writer, sends []propagation.Send, logs map[string]*propagation.Log, nodeCount, linkCount int) {
+	fmt.Fprintln(w, "Messages:")
+	fmt.Fprintf(w, "%-8s %-6s %-8s %-16s %-10s %s\n", "ID", "Node", "Offset", "Coverage", "p50", "Time")
+	for _, send := range sends {
+		plog, ok := logs[send.ID]
+		if !ok {
+			continue
+		}
+		ss := stats.Analyze(plog, nodeCount, linkCount)
+		p50 := stats.LatencyPercentiles(plog, 0.5)[0]
+		fmt.Fprintf(w, "%-8s %-6d %-8v %-16v %-10v %v\n", send.ID, send.Node, send.Offset, ss.NodeCoverage, p50, ss.Time)
+	}
+}
@@ -1,61 +1,73 @@
 package main
 
 import (
-	"encoding/json"
+	"compress/gzip"
 	"fmt"
-	"io"
+	"log"
 	"os"
+	"strings"
 
 	"github.com/divan/graphx/graph"
 	"github.com/divan/simulation/propagation"
-	"github.com/divan/simulation/propagation/gossip"
-	"github.com/divan/simulation/propagation/whisperv6"
+	"github.com/divan/simulation/simulation"
 )
 
-// Simulation represents single simulation.
-type Simulation struct {
-	network *graph.Graph
-	sim     propagation.Simulator
-	plog    *propagation.Log
-}
-
-// NewSimulation creates Simulation for the given network.
-func NewSimulation(algo string, network *graph.Graph) *Simulation {
-	var sim propagation.Simulator
-	if algo == "whisperv6" {
-		sim = whisperv6.NewSimulator(network)
-	} else {
-		sim = gossip.NewSimulator(network, 4, 10)
-	}
-
-	return &Simulation{
-		network: network,
-		sim:     sim,
+// newSimulation creates simulation of the algorithm for the given network,
+// failing on unknown algorithm.
+func newSimulation(algo string, network *graph.Graph, cfg simulation.Config) *simulation.Simulation {
+	sim, err := simulation.NewSimulation(algo, network, cfg)
+	if err != nil {
+		log.Fatal(err)
 	}
+	return sim
 }
 
-// Start starts simulation.
-func (s *Simulation) Start(ttl, size int) {
-	s.plog = s.sim.SendMessage(0, ttl, size)
+// validAlgorithm reports whether name is a known propagation algorithm.
+func validAlgorithm(name string) bool {
+	switch name {
+	case "whisperv6", "gossip", "bitswap", "eth", "randomwalk", "antientropy", "statusgo", "core":
+		return true
+	}
+	return false
 }
 
-// Stop stops simulation and shuts down network.
-func (s *Simulation) Stop() error {
-	return s.sim.Stop()
+// algorithmName returns name of the known propagation algorithm,
+// falling back to whisperv6.
+func algorithmName(name string) string {
+	switch name {
+	case "gossip", "bitswap", "eth", "randomwalk", "antientropy", "statusgo", "core":
+		return name
+	default:
+		return "whisperv6"
+	}
 }
 
-// WriteOutput writes propagation log to the given io.Writer.
-func (s *Simulation) WriteOutput(w io.Writer) error {
-	return json.NewEncoder(w).Encode(s.plog)
-}
+// writeLogFile writes propagation log to the given file in the given
+// format (see propagation.Encode). Path "-" means stdout, "s3://" and "gs://"
+// URLs are uploaded to object storage, and files with ".gz" extension are
+// gzip-compressed.
+func writeLogFile(plog *propagation.Log, path, format string) error {
+	if path == "-" {
+		return plog.Encode(os.Stdout, format)
+	}
+	if isStorageURL(path) {
+		return uploadFile(path, func(path string) error {
+			return writeLogFile(plog, path, format)
+		})
+	}
 
-// WriteOutputToFile writes propagation log to the given io.Writer.
-func (s *Simulation) WriteOutputToFile(path string) error {
 	fd, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("create output file: %v", err)
 	}
 	defer fd.Close()
 
-	return s.WriteOutput(fd)
+	if strings.HasSuffix(path, ".gz") {
+		gz := gzip.NewWriter(fd)
+		if err := plog.Encode(gz, format); err != nil {
+			return err
+		}
+		return gz.Close()
+	}
+	return plog.Encode(fd, format)
 }
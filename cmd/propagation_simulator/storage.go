@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// isStorageURL reports whether path is object storage URL, i.e.
+// s3://bucket/path or gs://bucket/path.
+func isStorageURL(path string) bool {
+	return strings.HasPrefix(path, "s3://") || strings.HasPrefix(path, "gs://")
+}
+
+// storageCopyCmd returns CLI command copying files to and from object
+// storage with the given prefix.
+func storageCopyCmd(store string) ([]string, error) {
+	switch {
+	case store == "":
+		return nil, fmt.Errorf("object storage prefix is required for Kubernetes jobs, see -store")
+	case strings.HasPrefix(store, "s3://"):
+		return []string{"aws", "s3", "cp"}, nil
+	case strings.HasPrefix(store, "gs://"):
+		return []string{"gsutil", "cp"}, nil
+	}
+	return nil, fmt.Errorf("unsupported object storage '%s', should be s3:// or gs://", store)
+}
+
+// storageCopy copies file between local filesystem and object storage,
+// using its CLI from PATH.
+func storageCopy(from, to string) error {
+	store := from
+	if isStorageURL(to) {
+		store = to
+	}
+	cp, err := storageCopyCmd(store)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(cp[0], append(cp[1:], from, to)...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	slog.Debug("Copying file", "from", from, "to", to)
+	return cmd.Run()
+}
+
+// uploadFile writes file to object storage URL by calling write with
+// local temporary file of the same name, so extension-based encoding
+// keeps working, and copying it to url afterwards.
+func uploadFile(url string, write func(path string) error) error {
+	dir, err := os.MkdirTemp("", "propagation_simulator")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, filepath.Base(url))
+	if err := write(path); err != nil {
+		return err
+	}
+	if err := storageCopy(path, url); err != nil {
+		return fmt.Errorf("upload to %s: %v", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/stats"
+)
+
+// sweepCmd implements 'sweep' subcommand, which runs simulation for each
+// combination of parameters values, either locally or as Kubernetes Jobs,
+// and prints stats of all of them.
+func sweepCmd(args []string) {
+	fs := flag.NewFlagSet("sweep", flag.ExitOnError)
+	var (
+		input    = fs.String("i", "network.json", "Input filename for pregenerated data to be used with simulation")
+		grid     = fs.String("grid", "", "Parameters values to sweep, as semicolon-separated name=v1,v2 lists of simulator flags, i.e. ttl=5,10;loss=0,0.1")
+		dir      = fs.String("dir", "runs", "Output directory, each sweep writes artifacts of its runs into <dir>/<timestamp>/<params>/")
+		parallel = fs.Int("parallel", 1, "Number of local runs executed in parallel")
+		k8s      = fs.Bool("k8s", false, "Run each combination as Kubernetes Job instead of locally, see -image and -store")
+		resume   = fs.String("resume", "", "Directory of interrupted sweep to resume, skipping its completed runs")
+	)
+	jobParams := k8sFlags(fs)
+	setupLog := logFlags(fs)
+	velocityParams := velocityFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: propagation_simulator sweep [flags] [-- simulator flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	setupLog()
+
+	var (
+		state   *sweepState
+		runsDir string
+		err     error
+	)
+	if *resume != "" {
+		set := setFlags(fs)
+		if set["i"] != "" || set["grid"] != "" || fs.NArg() > 0 {
+			log.Fatal("Input, grid and simulator flags of the resumed sweep can't be changed")
+		}
+		runsDir = *resume
+		state, err = readSweepState(runsDir)
+		if err != nil {
+			log.Fatal("Reading sweep state failed: ", err)
+		}
+	} else {
+		// keep input usable if sweep is resumed from other directory
+		path, err := filepath.Abs(*input)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// common flags passed to simulator after the sweep flags
+		state = &sweepState{Input: path, Grid: *grid, Args: fs.Args()}
+	}
+
+	combos, err := parseGrid(state.Grid)
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err := formats.FromD3JSON(state.Input)
+	if err != nil {
+		log.Fatal("Opening input file failed: ", err)
+	}
+	slog.Info("Loaded network graph", "file", state.Input)
+	if *resume == "" {
+		runsDir, err = newRunsDir(*dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	state.path = filepath.Join(runsDir, sweepStateFile)
+	if err := state.save(); err != nil {
+		log.Fatal("Writing sweep state failed: ", err)
+	}
+
+	pending := state.pending(combos)
+	slog.Info("Starting sweep", "runs", len(combos), "completed", len(combos)-len(pending), "dir", runsDir)
+	if *k8s {
+		err = runK8sSweep(jobParams(), state, runsDir, pending)
+	} else {
+		err = runLocalSweep(state, runsDir, pending, *parallel)
+	}
+	if err != nil {
+		log.Fatalf("Sweep failed: %v, resume with -resume %s", err, runsDir)
+	}
+
+	logs := make([]*propagation.Log, len(combos))
+	for i, combo := range combos {
+		dir := filepath.Join(runsDir, combo.Name())
+		logs[i], err = readLog(filepath.Join(dir, logArtifact))
+		if err != nil {
+			log.Fatalf("Reading output of %s failed: %v", combo.Name(), err)
+		}
+		if err := writeArtifacts(dir, logs[i], data.NumNodes(), data.NumLinks(), velocityParams()); err != nil {
+			log.Fatalf("Writing stats of %s failed: %v", combo.Name(), err)
+		}
+	}
+	slog.Info("Written sweep artifacts", "dir", runsDir)
+	printSweep(os.Stdout, combos, logs, data.NumNodes(), data.NumLinks(), velocityParams())
+}
+
+// Combination holds values of swept parameters of a single run.
+type Combination struct {
+	Names  []string
+	Values []string
+}
+
+// Name returns combination name, usable as a file or Job name, i.e.
+// "ttl-5.loss-0.1".
+func (c Combination) Name() string {
+	parts := make([]string, len(c.Names))
+	for i := range c.Names {
+		v := strings.NewReplacer("/", "-", ":", "-", "=", "-").Replace(c.Values[i])
+		parts[i] = strings.ToLower(c.Names[i]) + "-" + v
+	}
+	return strings.Join(parts, ".")
+}
+
+// Args returns simulator flags of the combination.
+func (c Combination) Args() []string {
+	args := make([]string, len(c.Names))
+	for i := range c.Names {
+		args[i] = "-" + c.Names[i] + "=" + c.Values[i]
+	}
+	return args
+}
+
+// String implements Stringer interface for Combination.
+func (c Combination) String() string {
+	parts := make([]string, len(c.Names))
+	for i := range c.Names {
+		parts[i] = c.Names[i] + "=" + c.Values[i]
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseGrid parses parameters values in form of semicolon-separated
+// name=v1,v2 lists, and returns all their combinations. The last
+// parameter changes the fastest.
+func parseGrid(s string) ([]Combination, error) {
+	if s == "" {
+		return nil, fmt.Errorf("no parameters to sweep, see -grid")
+	}
+	combos := []Combination{{}}
+	seen := make(map[string]bool)
+	for _, param := range strings.Split(s, ";") {
+		kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("wrong parameter '%s', should be name=v1,v2", param)
+		}
+		name := strings.TrimLeft(kv[0], "-")
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate parameter '%s'", name)
+		}
+		seen[name] = true
+
+		var next []Combination
+		for _, c := range combos {
+			for _, v := range strings.Split(kv[1], ",") {
+				next = append(next, Combination{
+					Names:  append(append([]string(nil), c.Names...), name),
+					Values: append(append([]string(nil), c.Values...), strings.TrimSpace(v)),
+				})
+			}
+		}
+		combos = next
+	}
+	return combos, nil
+}
+
+// sweepStateFile is the name of sweep state file in its directory.
+const sweepStateFile = "sweep.json"
+
+// sweepState holds parameters of the sweep and its completed runs, so
+// interrupted sweep can be resumed.
+type sweepState struct {
+	Input     string
+	Grid      string
+	Args      []string // simulator flags common for all runs
+	Completed []string // names of completed combinations
+
+	mx   sync.Mutex
+	path string
+}
+
+// readSweepState reads state of the sweep from its directory.
+func readSweepState(dir string) (*sweepState, error) {
+	data, err := os.ReadFile(filepath.Join(dir, sweepStateFile))
+	if err != nil {
+		return nil, err
+	}
+	var state sweepState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// completed reports whether run of the combination is completed.
+func (s *sweepState) completed(combo Combination) bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	for _, name := range s.Completed {
+		if name == combo.Name() {
+			return true
+		}
+	}
+	return false
+}
+
+// pending returns combinations whose runs are not completed yet.
+func (s *sweepState) pending(combos []Combination) []Combination {
+	var ret []Combination
+	for _, combo := range combos {
+		if !s.completed(combo) {
+			ret = append(ret, combo)
+		}
+	}
+	return ret
+}
+
+// done marks run of the combination as completed and saves the state.
+func (s *sweepState) done(combo Combination) error {
+	s.mx.Lock()
+	s.Completed = append(s.Completed, combo.Name())
+	s.mx.Unlock()
+	return s.save()
+}
+
+// save writes the state into its file, replacing it atomically, so
+// interrupted write doesn't corrupt the state.
+func (s *sweepState) save() error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	tmp := s.path + ".tmp"
+	if err := writeJSONFile(tmp, s); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// runLocalSweep runs simulator binary for each combination, parallel
+// runs at once, writing propagation data and output of each run into
+// its directory in dir, and marking it completed in the sweep state.
+func runLocalSweep(state *sweepState, dir string, combos []Combination, parallel int) error {
+	bin, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	sem := make(chan struct{}, parallel)
+	errs := make([]error, len(combos))
+	var wg sync.WaitGroup
+	for i, combo := range combos {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, combo Combination) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if errs[i] = runLocal(bin, state.Input, dir, state.Args, combo); errs[i] == nil {
+				errs[i] = state.done(combo)
+			}
+		}(i, combo)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("%s: %v", combos[i], err)
+		}
+	}
+	return nil
+}
+
+// runLocal runs simulator binary for the single combination, writing
+// its stats output next to propagation data.
+func runLocal(bin, input, dir string, base []string, combo Combination) error {
+	out := filepath.Join(dir, combo.Name())
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return err
+	}
+	args := append([]string{"-i", input}, base...)
+	args = append(args, combo.Args()...)
+	args = append(args, "-o", filepath.Join(out, logArtifact))
+
+	fd, err := os.Create(filepath.Join(out, outputArtifact))
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	cmd := exec.Command(bin, args...)
+	cmd.Stdout = fd
+	cmd.Stderr = fd
+	slog.Info("Running simulation", "params", combo.String())
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%v, see %s", err, fd.Name())
+	}
+	return nil
+}
+
+// printSweep prints stats of each combination.
+func printSweep(w io.Writer, combos []Combination, logs []*propagation.Log, nodeCount, linkCount int, params stats.VelocityParams) {
+	width := len("Params")
+	for _, c := range combos {
+		if n := len(c.String()); n > width {
+			width = n
+		}
+	}
+	fmt.Fprintln(w, "Sweep:")
+	fmt.Fprintf(w, "%-*s %-16s %-10s %-12s %-8s %s\n", width, "Params", "Coverage", "p50", "Peak, n/s", "AUC", "Time")
+	for i, plog := range logs {
+		ss := stats.Analyze(plog, nodeCount, linkCount)
+		p50 := stats.LatencyPercentiles(plog, 0.5)[0]
+		v := stats.AnalyzeVelocity(plog, nodeCount, params)
+		fmt.Fprintf(w, "%-*s %-16v %-10v %-12.1f %-8.3f %v\n", width, combos[i], ss.NodeCoverage, p50, v.Peak, v.AUC, ss.Time)
+	}
+}
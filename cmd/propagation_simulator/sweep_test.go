@@ -0,0 +1,128 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseGrid(t *testing.T) {
+	var tests = []struct {
+		name     string
+		grid     string
+		expected []string // combinations, in order
+		err      bool
+	}{
+		{"single", "ttl=5,10", []string{"ttl=5", "ttl=10"}, false},
+		{"product", "ttl=5,10;loss=0,0.1", []string{"ttl=5 loss=0", "ttl=5 loss=0.1", "ttl=10 loss=0", "ttl=10 loss=0.1"}, false},
+		{"spaces and dashes", " -ttl=5, 10 ; --loss=0", []string{"ttl=5 loss=0", "ttl=10 loss=0"}, false},
+		{"empty", "", nil, true},
+		{"no values", "ttl", nil, true},
+		{"empty values", "ttl=", nil, true},
+		{"no name", "=5", nil, true},
+		{"duplicate", "ttl=5;ttl=10", nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			combos, err := parseGrid(test.grid)
+			if test.err {
+				if err == nil {
+					t.Fatalf("Expected error for grid %q", test.grid)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range combos {
+				got = append(got, c.String())
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Fatalf("Expected combinations %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestCombinationName(t *testing.T) {
+	var tests = []struct {
+		combo Combination
+		name  string
+		args  []string
+	}{
+		{
+			Combination{Names: []string{"ttl", "loss"}, Values: []string{"5", "0.1"}},
+			"ttl-5.loss-0.1",
+			[]string{"-ttl=5", "-loss=0.1"},
+		},
+		{
+			// names are lowercased and separators in values replaced, so
+			// names are usable as Job names and directories
+			Combination{Names: []string{"msgSize", "mix"}, Values: []string{"400", "a=1/b:2"}},
+			"msgsize-400.mix-a-1-b-2",
+			[]string{"-msgSize=400", "-mix=a=1/b:2"},
+		},
+	}
+	for _, test := range tests {
+		if got := test.combo.Name(); got != test.name {
+			t.Fatalf("Expected name %q, got %q", test.name, got)
+		}
+		if got := test.combo.Args(); !reflect.DeepEqual(got, test.args) {
+			t.Fatalf("Expected args %v, got %v", test.args, got)
+		}
+	}
+}
+
+func TestSweepResume(t *testing.T) {
+	dir := t.TempDir()
+	combos, err := parseGrid("ttl=5,10;loss=0,0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := &sweepState{
+		Input: "network.json",
+		Grid:  "ttl=5,10;loss=0,0.1",
+		Args:  []string{"-fanout=4"},
+		path:  filepath.Join(dir, sweepStateFile),
+	}
+	if err := state.save(); err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range []int{1, 2} {
+		if err := state.done(combos[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resumed, err := readSweepState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Input != state.Input || resumed.Grid != state.Grid || !reflect.DeepEqual(resumed.Args, state.Args) {
+		t.Fatalf("Expected sweep parameters %+v, got %+v", state, resumed)
+	}
+	var tests = []struct {
+		combo     Combination
+		completed bool
+	}{
+		{combos[0], false},
+		{combos[1], true},
+		{combos[2], true},
+		{combos[3], false},
+	}
+	for _, test := range tests {
+		if got := resumed.completed(test.combo); got != test.completed {
+			t.Fatalf("Expected %s completed %v, got %v", test.combo, test.completed, got)
+		}
+	}
+	if got, expected := resumed.pending(combos), []Combination{combos[0], combos[3]}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected pending runs %v, got %v", expected, got)
+	}
+}
+
+func TestReadSweepStateMissing(t *testing.T) {
+	if _, err := readSweepState(t.TempDir()); err == nil {
+		t.Fatal("Expected error for directory without sweep state")
+	}
+}
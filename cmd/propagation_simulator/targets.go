@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTargets parses comma-separated indices of target nodes, checking
+// they exist in the network of nodeCount nodes.
+func parseTargets(s string, nodeCount int) ([]int, error) {
+	var targets []int
+	for _, part := range strings.Split(s, ",") {
+		node, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("wrong target node '%s'", part)
+		}
+		if node < 0 || node >= nodeCount {
+			return nil, fmt.Errorf("target node %d not found", node)
+		}
+		targets = append(targets, node)
+	}
+	return targets, nil
+}
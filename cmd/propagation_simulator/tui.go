@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+const (
+	// dashboardInterval is the interval of dashboard redraws.
+	dashboardInterval = 250 * time.Millisecond
+	// sparklineWidth is the number of velocity samples in sparkline.
+	sparklineWidth = 40
+)
+
+// sparkTicks are sparkline characters, from the lowest to the highest.
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// dashboard draws live terminal dashboard of the running simulation:
+// current phase, coverage, events rate, elapsed time and sparkline of
+// propagation velocity. It's redrawn in place using ANSI escape codes.
+type dashboard struct {
+	w     io.Writer
+	nodes int
+
+	mx       sync.Mutex
+	start    time.Time
+	phase    propagation.Progress
+	reached  []bool
+	covered  int
+	events   int
+	velocity []float64 // nodes reached per second, by redraw interval
+	rate     float64   // events per second during the last interval
+	last     struct{ covered, events int }
+	lines    int // number of lines drawn by the last redraw
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+func newDashboard(w io.Writer, nodes int) *dashboard {
+	return &dashboard{
+		w:       w,
+		nodes:   nodes,
+		reached: make([]bool, nodes),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Event implements propagation.EventFunc.
+func (d *dashboard) Event(e propagation.LogEntry) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.events++
+	for _, node := range []int{e.From, e.To} {
+		if node >= 0 && node < d.nodes && !d.reached[node] {
+			d.reached[node] = true
+			d.covered++
+		}
+	}
+}
+
+// Progress implements propagation.ProgressFunc.
+func (d *dashboard) Progress(p propagation.Progress) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.phase = p
+}
+
+// Start starts redrawing dashboard until Stop is called.
+func (d *dashboard) Start() {
+	d.start = time.Now()
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(dashboardInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				d.tick(dashboardInterval)
+			case <-d.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops redrawing and draws the final state.
+func (d *dashboard) Stop() {
+	close(d.quit)
+	<-d.done
+	d.mx.Lock()
+	d.draw()
+	d.mx.Unlock()
+}
+
+// tick samples rates over the interval and redraws dashboard.
+func (d *dashboard) tick(interval time.Duration) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.rate = float64(d.events-d.last.events) / interval.Seconds()
+	d.velocity = append(d.velocity, float64(d.covered-d.last.covered)/interval.Seconds())
+	if len(d.velocity) > sparklineWidth {
+		d.velocity = d.velocity[len(d.velocity)-sparklineWidth:]
+	}
+	d.last.covered, d.last.events = d.covered, d.events
+	d.draw()
+}
+
+// draw redraws dashboard over the previous one. Caller should hold the lock.
+func (d *dashboard) draw() {
+	var b strings.Builder
+	if d.lines > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", d.lines)
+	}
+	line := func(format string, args ...interface{}) {
+		b.WriteString("\r\x1b[K")
+		fmt.Fprintf(&b, format, args...)
+		b.WriteByte('\n')
+	}
+
+	if d.phase.Phase == "" {
+		line("Phase:    running")
+	} else {
+		line("Phase:    %s (%.0f%%)", d.phase.Phase, d.phase.Percentage())
+	}
+	var pct float64
+	if d.nodes > 0 {
+		pct = 100 * float64(d.covered) / float64(d.nodes)
+	}
+	filled := int(progressBarWidth * pct / 100)
+	line("Coverage: [%s%s] %.1f%% (%d/%d)", strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled), pct, d.covered, d.nodes)
+	line("Events:   %d (%.0f/s)", d.events, d.rate)
+	line("Elapsed:  %v", time.Since(d.start).Round(100*time.Millisecond))
+	line("Velocity: %s %.0f nodes/s", sparkline(d.velocity), lastValue(d.velocity))
+
+	d.lines = 5
+	io.WriteString(d.w, b.String())
+}
+
+// sparkline returns sparkline of values, scaled to their maximum.
+func sparkline(values []float64) string {
+	var max float64
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	ret := make([]rune, len(values))
+	for i, v := range values {
+		idx := 0
+		if max > 0 {
+			idx = int(v / max * float64(len(sparkTicks)-1))
+		}
+		ret[i] = sparkTicks[idx]
+	}
+	return string(ret)
+}
+
+func lastValue(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	return values[len(values)-1]
+}
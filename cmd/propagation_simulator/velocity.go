@@ -0,0 +1,21 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/divan/simulation/stats"
+)
+
+// velocityFlags registers flags of propagation velocity measuring in the
+// flag set and returns a function returning parameters, to be called after
+// flags are parsed.
+func velocityFlags(fs *flag.FlagSet) func() stats.VelocityParams {
+	defaults := stats.DefaultVelocityParams()
+	var (
+		window  = fs.Duration("velocitywindow", defaults.Window, "Sliding window for measuring peak propagation velocity")
+		horizon = fs.Duration("horizon", defaults.Horizon, "Time the coverage curve is integrated up to for AUC, set the same value to compare runs (time of the run if 0)")
+	)
+	return func() stats.VelocityParams {
+		return stats.VelocityParams{Window: *window, Horizon: *horizon}
+	}
+}
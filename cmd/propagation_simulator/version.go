@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// versionCmd implements 'version' subcommand, which prints simulator
+// version and build info.
+func versionCmd(args []string) {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	fs.Parse(args)
+
+	version, commit := propagation.BuildInfo()
+	fmt.Println("propagation_simulator", version)
+	if commit != "" {
+		fmt.Println("Commit:", commit)
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.time" {
+				fmt.Println("Commit time:", s.Value)
+			}
+		}
+		fmt.Println("Go version:", info.GoVersion)
+	}
+	fmt.Printf("Platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+}
+
+// setFlags returns values of flags set on the command line, by name.
+func setFlags(fs *flag.FlagSet) map[string]string {
+	ret := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		ret[f.Name] = f.Value.String()
+	})
+	return ret
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"log/slog"
+	"net/http"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/simulation"
+	"github.com/divan/simulation/viz"
+)
+
+// vizCmd implements 'viz' subcommand, which serves web UI animating
+// message propagation over the network graph.
+func vizCmd(args []string) {
+	fs := flag.NewFlagSet("viz", flag.ExitOnError)
+	var (
+		network   = fs.String("n", "network.json", "Input filename for network graph data")
+		plogFile  = fs.String("p", "propagation.json", "Input filename for propagation log data")
+		bundleIn  = fs.String("b", "", "Input filename of simulation bundle, used instead of -n and -p (optional)")
+		addr      = fs.String("h", "localhost:8085", "Address to bind to")
+		run       = fs.Bool("run", false, "Run new simulation instead of reading propagation log from file")
+		algorithm = fs.String("algorithm", "whisperv6", "Propagation algorithm to use with -run (whisperv6, gossip, bitswap, eth, randomwalk, antientropy, core)")
+		ttl       = fs.Int("ttl", 10, "TTL for generated messages, used with -run")
+		size      = fs.Int("msgSize", 400, "Payload size for generated messages, used with -run")
+	)
+	setupLog := logFlags(fs)
+	fs.Parse(args)
+	setupLog()
+
+	var (
+		data *graph.Graph
+		plog *propagation.Log
+		err  error
+	)
+	if *run {
+		data, err = formats.FromD3JSON(*network)
+		if err != nil {
+			log.Fatal("Opening network file failed: ", err)
+		}
+		slog.Info("Loaded network graph", "file", *network)
+
+		algo := algorithmName(*algorithm)
+		sim := newSimulation(algo, data, simulation.Config{})
+		slog.Info("Starting message sending simulation", "nodes", data.NumNodes())
+		plog = sim.Run(*ttl, *size).Log
+		sim.Stop()
+	} else {
+		data, plog, err = readInputs(*bundleIn, *network, *plogFile)
+		if err != nil {
+			log.Fatal("Opening input files failed: ", err)
+		}
+	}
+
+	slog.Info("Starting visualization server", "url", "http://"+*addr)
+	log.Fatal(http.ListenAndServe(*addr, viz.NewServer(data, plog)))
+}
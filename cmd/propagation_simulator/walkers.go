@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/simulation/simulation"
+)
+
+// walkersCmd implements 'walkers' subcommand, which runs random walk
+// simulation with increasing number of walkers and reports coverage vs
+// transmissions trade-off.
+func walkersCmd(args []string) {
+	fs := flag.NewFlagSet("walkers", flag.ExitOnError)
+	var (
+		input   = fs.String("i", "network.json", "Input filename for pregenerated data to be used with simulation")
+		ttl     = fs.Int("ttl", 100, "Hops each walker makes")
+		size    = fs.Int("msgSize", 400, "Payload size for generated messages")
+		walkers = fs.String("k", "1,2,4,8,16", "Comma-separated numbers of walkers to simulate")
+		seed    = fs.Int64("seed", 1, "Random walks seed, random if 0")
+	)
+	setupLog := logFlags(fs)
+	fs.Parse(args)
+	setupLog()
+
+	values, err := parseCounts(*walkers)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	data, err := formats.FromD3JSON(*input)
+	if err != nil {
+		log.Fatal("Opening input file failed: ", err)
+	}
+	slog.Info("Loaded network graph", "file", *input)
+
+	fmt.Printf("%-8s %-16s %-14s %-10s %-8s %s\n", "Walkers", "Nodes coverage", "Transmissions", "Per node", "To 90%", "Time")
+	for _, k := range values {
+		sim := newSimulation("randomwalk", data, simulation.Config{Walkers: k, Seed: *seed})
+		ss := sim.Run(*ttl, *size).Stats
+		sim.Stop()
+
+		to90 := "never"
+		if ss.Efficiency.To90 >= 0 {
+			to90 = strconv.Itoa(ss.Efficiency.To90)
+		}
+		fmt.Printf("%-8d %-16v %-14d %-10.2f %-8s %v\n", k, ss.NodeCoverage,
+			ss.Efficiency.Transmissions, ss.Efficiency.PerNode, to90, ss.Time)
+	}
+}
+
+// parseCounts parses comma-separated list of positive numbers.
+func parseCounts(s string) ([]int, error) {
+	var ret []int
+	for _, v := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("wrong number '%s'", v)
+		}
+		ret = append(ret, n)
+	}
+	return ret, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/simulation/simulation"
+)
+
+// watchInterval defines how often network graph file is checked for edits.
+const watchInterval = time.Second
+
+// watchTopology keeps simulation network running, polling network graph
+// file for edits. Each edit is applied to the network (see
+// simulation.UpdateTopology), followed by sending new message, writing
+// its log to output and printing stats to w. It runs until the process
+// is interrupted.
+func watchTopology(sim *simulation.Simulation, path, output, format string, ttl, size int, w io.Writer) {
+	info, err := os.Stat(path)
+	if err != nil {
+		slog.Error("Watching network graph failed", "file", path, "err", err)
+		return
+	}
+	modified := info.ModTime()
+	slog.Info("Watching network graph for edits, interrupt to stop", "file", path)
+	for range time.Tick(watchInterval) {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().After(modified) {
+			continue
+		}
+		modified = info.ModTime()
+
+		data, err := formats.FromD3JSON(path)
+		if err != nil {
+			// file may be saved partially, wait for the next edit
+			slog.Warn("Reading edited network graph failed", "err", err)
+			continue
+		}
+		if err := sim.UpdateTopology(data); err != nil {
+			slog.Warn("Updating network topology failed", "err", err)
+			continue
+		}
+		res := sim.Run(ttl, size)
+		if err := writeLogFile(res.Log, output, format); err != nil {
+			slog.Error("Writing output failed", "err", err)
+			continue
+		}
+		res.Stats.FprintVerbose(w)
+		slog.Info("Written propagation data", "file", output)
+	}
+}
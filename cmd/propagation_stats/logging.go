@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"os"
+)
+
+// logFlags registers logging flags in the flag set and returns a function
+// configuring default logger, to be called after flags are parsed.
+func logFlags(fs *flag.FlagSet) func() {
+	var (
+		verbose = fs.Bool("verbose", false, "Enable debug logging")
+		quiet   = fs.Bool("quiet", false, "Log only warnings and errors")
+		jsonLog = fs.Bool("logjson", false, "Write logs in JSON format")
+	)
+	return func() {
+		setLogger(*verbose, *quiet, *jsonLog)
+	}
+}
+
+// setLogger configures default structured logger. Logs are always written
+// to stderr, so they don't mix with the output on stdout.
+func setLogger(verbose, quiet, jsonLog bool) {
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	} else if quiet {
+		level = slog.LevelWarn
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	if jsonLog {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+
+	// messages from the standard log package are fatal errors
+	slog.SetLogLoggerLevel(slog.LevelError)
+}
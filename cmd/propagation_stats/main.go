@@ -1,12 +1,18 @@
 package main
 
 import (
-	"encoding/json"
+	"compress/gzip"
 	"flag"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
+	"strings"
 
 	"github.com/divan/graphx/formats"
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/bundle"
 	"github.com/divan/simulation/propagation"
 	"github.com/divan/simulation/stats"
 )
@@ -15,28 +21,147 @@ func main() {
 	var (
 		network  = flag.String("n", "network.json", "Input filename for network graph data")
 		plogFile = flag.String("p", "propagation.json", "Input filename for propagation log data")
+		bundleIn = flag.String("b", "", "Input filename of simulation bundle, used instead of -n and -p (optional)")
+		csvFile  = flag.String("csv", "", "Output filename for TimeToNode histogram in CSV format (optional)")
+		hdrFile  = flag.String("hdr", "", "Output filename for TimeToNode histogram in HdrHistogram format (optional)")
+		msg      = flag.String("msg", "", "Analyze only the message with the given identifier, for logs of multiple messages (optional)")
+		topLinks = flag.Int("toplinks", 10, "Number of the most used links to print")
+		linksCSV = flag.String("linkscsv", "", "Output filename for per link hit counts in CSV format (optional)")
 	)
+	setupLog := logFlags(flag.CommandLine)
 	flag.Parse()
+	setupLog()
 
-	data, err := formats.FromD3JSON(*network)
-	if err != nil {
-		log.Fatal("Opening network file failed: ", err)
+	var (
+		data *graph.Graph
+		plog *propagation.Log
+		err  error
+	)
+	if *bundleIn != "" {
+		data, plog, err = readBundle(*bundleIn)
+		if err != nil {
+			log.Fatal("Opening bundle file failed: ", err)
+		}
+		slog.Info("Loaded simulation bundle", "file", *bundleIn)
+	} else {
+		data, err = formats.FromD3JSON(*network)
+		if err != nil {
+			log.Fatal("Opening network file failed: ", err)
+		}
+		slog.Info("Loaded network graph", "file", *network)
+
+		plog, err = readLog(*plogFile)
+		if err != nil {
+			log.Fatal("Opening propagation file failed: ", err)
+		}
+		slog.Info("Loaded propagation log", "file", *plogFile)
+	}
+
+	if plog.Meta != nil {
+		slog.Info("Propagation log produced by simulator", "version", plog.Meta.Version, "commit", plog.Meta.Commit)
+	}
+	if !plog.MatchesGraph(data) {
+		slog.Warn("Propagation log was produced on a different network graph", "checksum", plog.Meta.GraphChecksum)
 	}
-	log.Printf("Loaded network graph from %s file", *network)
 
-	fd, err := os.Open(*plogFile)
+	if *msg != "" {
+		if plog.Messages == nil {
+			log.Fatal("Propagation log holds a single message, can't filter it")
+		}
+		plog = plog.Filter(*msg)
+		slog.Info("Filtered propagation log", "message", *msg)
+	}
+
+	ss := stats.Analyze(plog, data.NumNodes(), data.NumLinks())
+	usage := stats.AnalyzeLinkUsage(plog, data)
+	if *topLinks > 0 {
+		ss.Links = usage.Top(*topLinks)
+	}
+	ss.PrintVerbose()
+	printMessages(plog, data.NumNodes(), data.NumLinks())
+
+	if *csvFile != "" {
+		if err := writeHistogram(*csvFile, ss.TimeToNodeHistogram.WriteCSV); err != nil {
+			log.Fatalf("Writing CSV histogram failed: %v", err)
+		}
+		slog.Info("Written TimeToNode histogram", "file", *csvFile)
+	}
+	if *linksCSV != "" {
+		if err := writeHistogram(*linksCSV, usage.WriteCSV); err != nil {
+			log.Fatalf("Writing links CSV failed: %v", err)
+		}
+		slog.Info("Written links usage", "file", *linksCSV)
+	}
+	if *hdrFile != "" {
+		if err := writeHistogram(*hdrFile, ss.TimeToNodeHistogram.WriteHDR); err != nil {
+			log.Fatalf("Writing HDR histogram failed: %v", err)
+		}
+		slog.Info("Written TimeToNode histogram", "file", *hdrFile)
+	}
+}
+
+// printMessages prints stats of each message, for logs of multiple messages.
+func printMessages(plog *propagation.Log, nodeCount, linkCount int) {
+	messages := stats.AnalyzeMessages(plog, nodeCount, linkCount)
+	if messages == nil {
+		return
+	}
+	fmt.Println("Messages:")
+	fmt.Printf("%-18s %-16s %s\n", "ID", "Coverage", "Time")
+	for _, id := range plog.MessageIDs() {
+		ss := messages[id]
+		fmt.Printf("%-18s %-16v %v\n", id, ss.NodeCoverage, ss.Time)
+	}
+}
+
+// writeHistogram creates file at path and writes histogram, or other data,
+// into it using given write function.
+func writeHistogram(path string, write func(io.Writer) error) error {
+	fd, err := os.Create(path)
 	if err != nil {
-		log.Fatal("Opening propagation file failed: ", err)
+		return fmt.Errorf("create output file: %v", err)
 	}
 	defer fd.Close()
-	log.Printf("Loaded propagation log from %s file", *plogFile)
 
-	plog := &propagation.Log{}
-	err = json.NewDecoder(fd).Decode(&plog)
+	return write(fd)
+}
+
+// readBundle reads network graph and propagation log from the simulation
+// bundle file.
+func readBundle(path string) (*graph.Graph, *propagation.Log, error) {
+	b, err := bundle.ReadFile(path)
 	if err != nil {
-		log.Fatalf("Parsing propagation log failed: %v", err)
+		return nil, nil, err
 	}
+	data, err := b.Graph()
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, b.Log, nil
+}
 
-	ss := stats.Analyze(plog, data.NumNodes(), data.NumLinks())
-	ss.PrintVerbose()
+// readLog reads propagation log from the file, detecting format by the file
+// extension. Files with ".gz" extension are expected to be gzip-compressed.
+func readLog(path string) (*propagation.Log, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var r io.Reader = fd
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(fd)
+		if err != nil {
+			return nil, fmt.Errorf("open gzip: %v", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	plog, err := propagation.DecodeLog(r, propagation.FormatFromPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("parse propagation log: %v", err)
+	}
+	return plog, nil
 }
@@ -0,0 +1,208 @@
+// Package community detects communities of densely connected nodes in
+// network graphs with the Louvain method. Links between communities are
+// structural bottlenecks for propagation, so it's useful to see how
+// message crosses them.
+package community
+
+import (
+	"sort"
+
+	"github.com/divan/graphx/graph"
+)
+
+// minGain is the minimal modularity gain for node to change community,
+// so rounding errors don't cause endless moves.
+const minGain = 1e-12
+
+// weighted is undirected weighted graph, with communities of the previous
+// level aggregated into single nodes.
+type weighted struct {
+	adj    []map[int]float64 // symmetric, self-loops hold internal weight
+	degree []float64
+	total  float64 // sum of all degrees, twice the weight of links
+}
+
+// newWeighted converts graph into weighted one, with links treated as
+// undirected. Self-loops are skipped and duplicate links add up.
+func newWeighted(g *graph.Graph) *weighted {
+	w := &weighted{
+		adj:    make([]map[int]float64, g.NumNodes()),
+		degree: make([]float64, g.NumNodes()),
+	}
+	for i := range w.adj {
+		w.adj[i] = make(map[int]float64)
+	}
+	for _, link := range g.Links() {
+		from, to := link.FromIdx(), link.ToIdx()
+		if from == to {
+			continue
+		}
+		w.adj[from][to]++
+		w.adj[to][from]++
+		w.degree[from]++
+		w.degree[to]++
+		w.total += 2
+	}
+	return w
+}
+
+// Detect returns community of each node, ordered by node index, found by
+// Louvain modularity optimization. Links are treated as undirected.
+// Communities are numbered by size, largest first.
+func Detect(g *graph.Graph) []int {
+	labels := make([]int, g.NumNodes())
+	for i := range labels {
+		labels[i] = i
+	}
+	w := newWeighted(g)
+	for {
+		comm, n, moved := w.localMoves()
+		if !moved {
+			break
+		}
+		for i := range labels {
+			labels[i] = comm[labels[i]]
+		}
+		w = w.aggregate(comm, n)
+	}
+	return bySize(labels)
+}
+
+// localMoves repeatedly moves each node into the neighbouring community
+// with the best modularity gain, until no node moves. It returns community
+// of each node numbered from zero, number of communities, and whether any
+// node has moved.
+func (w *weighted) localMoves() ([]int, int, bool) {
+	n := len(w.adj)
+	comm := make([]int, n)
+	tot := make([]float64, n) // sum of degrees of community nodes
+	for i := range comm {
+		comm[i] = i
+		tot[i] = w.degree[i]
+	}
+	if w.total == 0 {
+		return comm, n, false
+	}
+
+	var moved bool
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < n; i++ {
+			links := make(map[int]float64) // community -> weight of links to it
+			for j, a := range w.adj[i] {
+				if j != i {
+					links[comm[j]] += a
+				}
+			}
+			candidates := make([]int, 0, len(links))
+			for c := range links {
+				candidates = append(candidates, c)
+			}
+			sort.Ints(candidates)
+
+			own := comm[i]
+			tot[own] -= w.degree[i]
+			gain := func(c int) float64 {
+				return links[c] - tot[c]*w.degree[i]/w.total
+			}
+			best, bestGain := own, gain(own)
+			for _, c := range candidates {
+				if g := gain(c); g > bestGain+minGain {
+					best, bestGain = c, g
+				}
+			}
+			tot[best] += w.degree[i]
+			if best != own {
+				comm[i] = best
+				improved, moved = true, true
+			}
+		}
+	}
+
+	// renumber communities from zero
+	ids := make(map[int]int)
+	for i, c := range comm {
+		id, ok := ids[c]
+		if !ok {
+			id = len(ids)
+			ids[c] = id
+		}
+		comm[i] = id
+	}
+	return comm, len(ids), moved
+}
+
+// aggregate returns graph with communities turned into nodes.
+func (w *weighted) aggregate(comm []int, n int) *weighted {
+	ret := &weighted{
+		adj:    make([]map[int]float64, n),
+		degree: make([]float64, n),
+		total:  w.total,
+	}
+	for i := range ret.adj {
+		ret.adj[i] = make(map[int]float64)
+	}
+	for i, peers := range w.adj {
+		for j, a := range peers {
+			ret.adj[comm[i]][comm[j]] += a
+		}
+		ret.degree[comm[i]] += w.degree[i]
+	}
+	return ret
+}
+
+// bySize renumbers communities by size, largest first, with ties broken
+// by the smallest node index.
+func bySize(labels []int) []int {
+	size := make(map[int]int)
+	first := make(map[int]int)
+	var order []int
+	for i, c := range labels {
+		if _, ok := size[c]; !ok {
+			first[c] = i
+			order = append(order, c)
+		}
+		size[c]++
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if size[a] != size[b] {
+			return size[a] > size[b]
+		}
+		return first[a] < first[b]
+	})
+	ids := make(map[int]int, len(order))
+	for id, c := range order {
+		ids[c] = id
+	}
+	ret := make([]int, len(labels))
+	for i, c := range labels {
+		ret[i] = ids[c]
+	}
+	return ret
+}
+
+// Modularity returns modularity of the graph split into given communities,
+// ordered by node index. Links are treated as undirected.
+func Modularity(g *graph.Graph, communities []int) float64 {
+	w := newWeighted(g)
+	if w.total == 0 {
+		return 0
+	}
+	in := make(map[int]float64)  // weight of links inside community, counted twice
+	tot := make(map[int]float64) // sum of degrees of community nodes
+	for i, peers := range w.adj {
+		c := communities[i]
+		tot[c] += w.degree[i]
+		for j, a := range peers {
+			if communities[j] == c {
+				in[c] += a
+			}
+		}
+	}
+	var q float64
+	for c, t := range tot {
+		q += in[c]/w.total - (t/w.total)*(t/w.total)
+	}
+	return q
+}
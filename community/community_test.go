@@ -0,0 +1,62 @@
+package community
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/divan/graphx/graph"
+)
+
+// node implements string-only graph.Node
+type node string
+
+func (n node) ID() string { return string(n) }
+
+func newGraph(n int, links ...[2]int) *graph.Graph {
+	g := graph.NewGraph()
+	for i := 0; i < n; i++ {
+		g.AddNode(node(strconv.Itoa(i)))
+	}
+	for _, l := range links {
+		g.AddLink(strconv.Itoa(l[0]), strconv.Itoa(l[1]))
+	}
+	return g
+}
+
+// clique adds links between all nodes in [from, to).
+func clique(from, to int) [][2]int {
+	var links [][2]int
+	for i := from; i < to; i++ {
+		for j := i + 1; j < to; j++ {
+			links = append(links, [2]int{i, j})
+		}
+	}
+	return links
+}
+
+func TestDetect(t *testing.T) {
+	// cliques of 5 and 4 nodes, joined by 4-5 link
+	links := append(clique(0, 5), clique(5, 9)...)
+	links = append(links, [2]int{4, 5})
+	g := newGraph(9, links...)
+
+	got := Detect(g)
+	expected := []int{0, 0, 0, 0, 0, 1, 1, 1, 1}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected communities %v, got %v", expected, got)
+	}
+	if q := Modularity(g, got); q < 0.4 {
+		t.Fatalf("Expected modularity above 0.4, got %v", q)
+	}
+	if q := Modularity(g, make([]int, 9)); q != 0 {
+		t.Fatalf("Expected zero modularity of single community, got %v", q)
+	}
+}
+
+func TestDetectNoLinks(t *testing.T) {
+	got := Detect(newGraph(3))
+	if !reflect.DeepEqual(got, []int{0, 1, 2}) {
+		t.Fatalf("Expected each node in own community, got %v", got)
+	}
+}
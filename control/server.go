@@ -0,0 +1,238 @@
+// Package control implements gRPC control API of simulation servers
+// (see control.proto, Go code is generated from it), so simulations can be started, watched and
+// stopped programmatically from any language:
+//
+//	lis, err := net.Listen("tcp", ":8085")
+//	if err != nil {
+//		return err
+//	}
+//	return control.NewGRPCServer(control.NewServer()).Serve(lis)
+package control
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/simulation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative control.proto
+
+// States of simulations.
+const (
+	StateRunning  = "running"
+	StateFinished = "finished"
+	StateStopped  = "stopped"
+)
+
+// Server implements Control service, running simulations in background.
+type Server struct {
+	UnimplementedControlServer
+
+	mx   sync.Mutex
+	runs map[string]*run
+	next int
+}
+
+// NewServer creates new Server.
+func NewServer() *Server {
+	return &Server{
+		runs: make(map[string]*run),
+	}
+}
+
+// NewGRPCServer creates gRPC server with the Control service of s registered.
+func NewGRPCServer(s *Server, opts ...grpc.ServerOption) *grpc.Server {
+	g := grpc.NewServer(opts...)
+	RegisterControlServer(g, s)
+	return g
+}
+
+// run holds state of the single simulation.
+type run struct {
+	sim          *simulation.Simulation
+	nodes, links int
+	stop         sync.Once
+
+	mx      sync.Mutex
+	state   string
+	events  []propagation.LogEntry
+	changed chan struct{} // closed and replaced on each change
+	res     *simulation.Results
+}
+
+// addEvent records event and wakes up streams waiting for it.
+func (r *run) addEvent(e propagation.LogEntry) {
+	r.mx.Lock()
+	r.events = append(r.events, e)
+	close(r.changed)
+	r.changed = make(chan struct{})
+	r.mx.Unlock()
+}
+
+// finish sets final state of the run and wakes up streams.
+func (r *run) finish(state string, res *simulation.Results) {
+	r.mx.Lock()
+	if r.state == StateRunning {
+		r.state = state
+	}
+	r.res = res
+	close(r.changed)
+	r.changed = make(chan struct{})
+	r.mx.Unlock()
+}
+
+// eventsFrom returns events starting from i, channel closed on the next
+// change, and whether the run is over.
+func (r *run) eventsFrom(i int) ([]propagation.LogEntry, <-chan struct{}, bool) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return r.events[i:], r.changed, r.state != StateRunning
+}
+
+// shutdown stops the simulator, once.
+func (r *run) shutdown() {
+	r.stop.Do(func() { r.sim.Stop() })
+}
+
+// StartSimulation starts simulation in background and returns its id.
+func (s *Server) StartSimulation(ctx context.Context, req *StartRequest) (*StartResponse, error) {
+	network, err := formats.FromD3JSONReader(bytes.NewReader(req.Network))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "bad network: %v", err)
+	}
+	if req.Sender < 0 || req.Sender >= int64(network.NumNodes()) {
+		return nil, status.Errorf(codes.InvalidArgument, "sender node %d not found", req.Sender)
+	}
+
+	r := &run{
+		nodes:   network.NumNodes(),
+		links:   network.NumLinks(),
+		state:   StateRunning,
+		changed: make(chan struct{}),
+	}
+	cfg := simulation.Config{
+		Seed:   req.Seed,
+		Events: r.addEvent,
+	}
+	r.sim, err = simulation.NewSimulation(req.Algorithm, network, cfg)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	s.mx.Lock()
+	id := strconv.Itoa(s.next)
+	s.next++
+	s.runs[id] = r
+	s.mx.Unlock()
+
+	go func() {
+		var sends []propagation.Send
+		if req.Sender != 0 {
+			sends = []propagation.Send{{ID: "msg0", Node: int(req.Sender)}}
+		}
+		res := r.sim.Run(int(req.Ttl), int(req.MsgSize), sends...)
+		r.shutdown()
+		r.finish(StateFinished, res)
+	}()
+	return &StartResponse{Id: id}, nil
+}
+
+// StreamEvents streams events of the simulation, starting from the first
+// one, until simulation is over.
+func (s *Server) StreamEvents(req *StreamRequest, stream grpc.ServerStreamingServer[Event]) error {
+	r, err := s.run(req.Id)
+	if err != nil {
+		return err
+	}
+
+	var sent int
+	for {
+		events, changed, over := r.eventsFrom(sent)
+		for _, e := range events {
+			ev := &Event{Timestamp: e.Ts, From: int64(e.From), To: int64(e.To), Message: e.Msg}
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		}
+		sent += len(events)
+		if len(events) > 0 {
+			continue
+		}
+		if over {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// GetStats returns state of the simulation, and its stats once finished.
+func (s *Server) GetStats(ctx context.Context, req *StatsRequest) (*StatsResponse, error) {
+	r, err := s.run(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	resp := &StatsResponse{
+		State:  r.state,
+		Events: int64(len(r.events)),
+		Nodes:  int64(r.nodes),
+		Links:  int64(r.links),
+	}
+	if r.res != nil {
+		ss := r.res.Stats
+		resp.CoveredNodes = int64(ss.NodeCoverage.Actual)
+		resp.CoveredLinks = int64(ss.LinkCoverage.Actual)
+		resp.TimeMs = ss.Time.Milliseconds()
+		var buf bytes.Buffer
+		ss.FprintVerbose(&buf)
+		resp.Text = buf.String()
+	}
+	return resp, nil
+}
+
+// StopSimulation stops running simulation, or forgets finished one, so
+// its results are freed.
+func (s *Server) StopSimulation(ctx context.Context, req *StopRequest) (*StopResponse, error) {
+	r, err := s.run(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mx.Lock()
+	running := r.state == StateRunning
+	r.mx.Unlock()
+	if running {
+		r.shutdown()
+		r.finish(StateStopped, nil)
+		return &StopResponse{}, nil
+	}
+
+	s.mx.Lock()
+	delete(s.runs, req.Id)
+	s.mx.Unlock()
+	return &StopResponse{}, nil
+}
+
+func (s *Server) run(id string) (*run, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	r, ok := s.runs[id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "simulation %s not found", id)
+	}
+	return r, nil
+}
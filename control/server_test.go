@@ -0,0 +1,149 @@
+package control
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testNetwork = `{
+  "nodes": [{"id": "0"}, {"id": "1"}, {"id": "2"}, {"id": "3"}],
+  "links": [
+    {"source": "0", "target": "1"},
+    {"source": "1", "target": "2"},
+    {"source": "2", "target": "3"}
+  ]
+}`
+
+// testStream collects events sent to the stream.
+type testStream struct {
+	ctx    context.Context
+	events []*Event
+}
+
+func (s *testStream) SetHeader(metadata.MD) error  { return nil }
+func (s *testStream) SendHeader(metadata.MD) error { return nil }
+func (s *testStream) SetTrailer(metadata.MD)       {}
+func (s *testStream) Context() context.Context     { return s.ctx }
+func (s *testStream) RecvMsg(m interface{}) error  { return nil }
+func (s *testStream) SendMsg(m interface{}) error  { return nil }
+
+func (s *testStream) Send(e *Event) error {
+	s.events = append(s.events, e)
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	s := NewServer()
+	ctx := context.Background()
+	req := &StartRequest{Algorithm: "gossip", Network: []byte(testNetwork), Ttl: 10, MsgSize: 400, Seed: 1}
+	started, err := s.StartSimulation(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	stream := &testStream{ctx: ctx}
+	if err := s.StreamEvents(&StreamRequest{Id: started.Id}, stream); err != nil {
+		t.Fatal(err)
+	}
+	// each of 3 links is used at least once
+	if len(stream.events) < 3 {
+		t.Fatalf("Expected at least 3 events, got %d", len(stream.events))
+	}
+
+	resp, err := s.GetStats(ctx, &StatsRequest{Id: started.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.State != StateFinished {
+		t.Fatalf("Expected state %s, got %s", StateFinished, resp.State)
+	}
+	if resp.Events != int64(len(stream.events)) {
+		t.Fatalf("Expected %d events in stats, got %d", len(stream.events), resp.Events)
+	}
+	if resp.CoveredNodes != 4 || resp.Text == "" {
+		t.Fatalf("Expected stats of all 4 nodes covered, got %+v", resp)
+	}
+
+	if _, err := s.StopSimulation(ctx, &StopRequest{Id: started.Id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetStats(ctx, &StatsRequest{Id: started.Id}); status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected NotFound for stopped simulation, got %v", err)
+	}
+}
+
+func TestStartSimulationErrors(t *testing.T) {
+	s := NewServer()
+	tests := []*StartRequest{
+		{Algorithm: "gossip", Network: []byte("{")},
+		{Algorithm: "unknown", Network: []byte(testNetwork)},
+		{Algorithm: "gossip", Network: []byte(testNetwork), Sender: 4},
+	}
+	for _, req := range tests {
+		if _, err := s.StartSimulation(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("Expected InvalidArgument for %+v, got %v", req, err)
+		}
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	g := NewGRPCServer(NewServer())
+	go g.Serve(lis)
+	defer g.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := NewControlClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req := &StartRequest{Algorithm: "gossip", Network: []byte(testNetwork), Ttl: 10, MsgSize: 400, Seed: 1}
+	started, err := client.StartSimulation(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := client.StreamEvents(ctx, &StreamRequest{Id: started.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events int64
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		events++
+	}
+
+	resp, err := client.GetStats(ctx, &StatsRequest{Id: started.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.State != StateFinished || resp.Events != events {
+		t.Fatalf("Expected finished simulation with %d events, got %+v", events, resp)
+	}
+}
@@ -0,0 +1,147 @@
+// Package crawl imports network snapshots made by P2P network crawlers,
+// so simulations run on real measured topologies rather than synthetic
+// ones. Crawled nodes keep their metadata (client, country, coordinates),
+// which is written into the network file along with the graph:
+//
+//	g, err := crawl.ReadEthereumCSV(fd)
+//	if err != nil {
+//		return err
+//	}
+//	return crawl.WriteJSON(w, g)
+package crawl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/divan/graphx/graph"
+)
+
+// Supported snapshot formats.
+const (
+	FormatEthereumCSV = "ethcsv" // Ethereum node crawler CSV, see ReadEthereumCSV
+	FormatLibp2pJSON  = "libp2p" // libp2p crawler JSON, see ReadLibp2pJSON
+)
+
+// Node represents crawled node with its metadata. Fields unknown to the
+// crawler are left empty.
+type Node struct {
+	NodeID    string
+	IP        string
+	Client    string // client name and version, i.e. "Geth/v1.13.5"
+	Country   string
+	Lat, Lon  float64
+	HasCoord  bool     // Lat and Lon are set
+	Protocols []string // supported protocols, if reported by crawler
+	Crawled   bool     // node was reached by crawler, rather than only seen as neighbor
+}
+
+// ID implements graph.Node interface.
+func (n *Node) ID() string { return n.NodeID }
+
+// Read reads snapshot in the given format (see FormatEthereumCSV and
+// FormatLibp2pJSON).
+func Read(r io.Reader, format string) (*graph.Graph, error) {
+	switch format {
+	case FormatEthereumCSV:
+		return ReadEthereumCSV(r)
+	case FormatLibp2pJSON:
+		return ReadLibp2pJSON(r)
+	default:
+		return nil, fmt.Errorf("unknown snapshot format '%s'", format)
+	}
+}
+
+// builder builds undirected graph out of crawled nodes and their
+// neighbors, in order of their appearance in snapshot.
+type builder struct {
+	g     *graph.Graph
+	nodes map[string]*Node
+	links map[[2]string]bool
+}
+
+func newBuilder() *builder {
+	return &builder{
+		g:     graph.NewGraph(),
+		nodes: make(map[string]*Node),
+		links: make(map[[2]string]bool),
+	}
+}
+
+// node returns node with the given ID, adding it to the graph if needed.
+func (b *builder) node(id string) *Node {
+	if n, ok := b.nodes[id]; ok {
+		return n
+	}
+	n := &Node{NodeID: id}
+	b.nodes[id] = n
+	b.g.AddNode(n)
+	return n
+}
+
+// link adds link between nodes, unless it's added already in either
+// direction. Self links are skipped.
+func (b *builder) link(from, to string) {
+	if from == to {
+		return
+	}
+	key := [2]string{from, to}
+	if to < from {
+		key = [2]string{to, from}
+	}
+	if b.links[key] {
+		return
+	}
+	b.links[key] = true
+	b.node(to)
+	b.g.AddLink(from, to)
+}
+
+// graph returns built graph, or error if snapshot has no crawled nodes.
+func (b *builder) graph() (*graph.Graph, error) {
+	if b.g.NumNodes() == 0 {
+		return nil, fmt.Errorf("no nodes in snapshot")
+	}
+	return b.g, nil
+}
+
+// WriteJSON writes graph in D3 JSON format, as used for network files,
+// with metadata of crawled nodes as node fields: "lat" and "lon" for
+// geo latencies, "country" and "client" for grouping stats by them.
+func WriteJSON(w io.Writer, g *graph.Graph) error {
+	type jsonNode struct {
+		ID        string   `json:"id"`
+		IP        string   `json:"ip,omitempty"`
+		Client    string   `json:"client,omitempty"`
+		Country   string   `json:"country,omitempty"`
+		Lat       *float64 `json:"lat,omitempty"`
+		Lon       *float64 `json:"lon,omitempty"`
+		Protocols []string `json:"protocols,omitempty"`
+		Crawled   bool     `json:"crawled"`
+	}
+	type jsonLink struct {
+		Source string `json:"source"`
+		Target string `json:"target"`
+	}
+	var data struct {
+		Nodes []jsonNode `json:"nodes"`
+		Links []jsonLink `json:"links"`
+	}
+	for _, n := range g.Nodes() {
+		jn := jsonNode{ID: n.ID()}
+		if cn, ok := n.(*Node); ok {
+			jn.IP, jn.Client, jn.Country = cn.IP, cn.Client, cn.Country
+			jn.Protocols, jn.Crawled = cn.Protocols, cn.Crawled
+			if cn.HasCoord {
+				lat, lon := cn.Lat, cn.Lon
+				jn.Lat, jn.Lon = &lat, &lon
+			}
+		}
+		data.Nodes = append(data.Nodes, jn)
+	}
+	for _, l := range g.Links() {
+		data.Links = append(data.Links, jsonLink{Source: l.From(), Target: l.To()})
+	}
+	return json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,107 @@
+package crawl
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestReadEthereumCSV(t *testing.T) {
+	data := `node_id,ip,client_name,country_name,latitude,longitude,neighbors
+enode://aa@10.0.0.1:30303,,Geth/v1.13.5,Germany,52.5,13.4,bb cc
+bb,10.0.0.2,Nethermind/v1.25.0,France,48.9,2.35,aa
+`
+	g, err := ReadEthereumCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.NumNodes() != 3 {
+		t.Fatalf("expected 3 nodes, got %d", g.NumNodes())
+	}
+	if g.NumLinks() != 2 {
+		t.Fatalf("expected 2 links, got %d", g.NumLinks())
+	}
+
+	nodes := g.Nodes()
+	aa := nodes[0].(*Node)
+	if aa.ID() != "aa" || aa.IP != "10.0.0.1" || aa.Client != "Geth/v1.13.5" || aa.Country != "Germany" {
+		t.Fatalf("unexpected node metadata: %+v", aa)
+	}
+	if !aa.HasCoord || aa.Lat != 52.5 || aa.Lon != 13.4 {
+		t.Fatalf("unexpected node coordinates: %+v", aa)
+	}
+	if cc := nodes[2].(*Node); cc.ID() != "cc" || cc.Crawled {
+		t.Fatalf("expected not crawled neighbor cc, got %+v", cc)
+	}
+}
+
+func TestReadEthereumCSVWrong(t *testing.T) {
+	for _, data := range []string{
+		"ip,client\n10.0.0.1,Geth\n",
+		"id,lat,lon\naa,north,13.4\n",
+		"id\n",
+	} {
+		if _, err := ReadEthereumCSV(strings.NewReader(data)); err == nil {
+			t.Fatalf("expected error for %q", data)
+		}
+	}
+}
+
+func TestReadLibp2pJSON(t *testing.T) {
+	array := `[
+  {"PeerID": "QmA", "NeighborIDs": ["QmB", "QmC"], "AgentVersion": "kubo/0.24.0", "Maddrs": ["/ip4/10.0.0.1/tcp/4001"]},
+  {"PeerID": "QmB", "NeighborIDs": ["QmA"], "Protocols": ["/ipfs/kad/1.0.0"]}
+]`
+	stream := `{"PeerID": "QmA", "NeighborIDs": ["QmB", "QmC"], "AgentVersion": "kubo/0.24.0", "MultiAddrs": ["/ip4/10.0.0.1/tcp/4001"]}
+{"PeerID": "QmB", "NeighborIDs": ["QmA"], "Protocols": ["/ipfs/kad/1.0.0"]}
+`
+	for _, data := range []string{array, stream} {
+		g, err := ReadLibp2pJSON(strings.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.NumNodes() != 3 || g.NumLinks() != 2 {
+			t.Fatalf("expected 3 nodes and 2 links, got %d and %d", g.NumNodes(), g.NumLinks())
+		}
+		a := g.Nodes()[0].(*Node)
+		if a.Client != "kubo/0.24.0" || a.IP != "10.0.0.1" || !a.Crawled {
+			t.Fatalf("unexpected node metadata: %+v", a)
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	data := "id,country,lat,lon,peers\naa,Germany,52.5,13.4,bb\n"
+	g, err := Read(strings.NewReader(data), FormatEthereumCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	var network struct {
+		Nodes []struct {
+			ID      string   `json:"id"`
+			Country string   `json:"country"`
+			Lat     *float64 `json:"lat"`
+		} `json:"nodes"`
+		Links []struct {
+			Source string `json:"source"`
+			Target string `json:"target"`
+		} `json:"links"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &network); err != nil {
+		t.Fatal(err)
+	}
+	if len(network.Nodes) != 2 || len(network.Links) != 1 {
+		t.Fatalf("expected 2 nodes and 1 link, got %s", buf.String())
+	}
+	if n := network.Nodes[0]; n.Country != "Germany" || n.Lat == nil || *n.Lat != 52.5 {
+		t.Fatalf("expected node metadata, got %s", buf.String())
+	}
+	if network.Nodes[1].Lat != nil {
+		t.Fatalf("expected no coordinates for neighbor, got %s", buf.String())
+	}
+}
@@ -0,0 +1,114 @@
+package crawl
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/divan/graphx/graph"
+)
+
+// ethColumns maps known Ethereum node crawler CSV columns to node fields.
+// Crawlers name columns differently, so aliases are accepted.
+var ethColumns = map[string][]string{
+	"id":        {"id", "node_id", "enode"},
+	"ip":        {"ip", "ip_address"},
+	"client":    {"client", "client_name", "client_identifier"},
+	"country":   {"country", "country_name"},
+	"lat":       {"lat", "latitude"},
+	"lon":       {"lon", "lng", "longitude"},
+	"neighbors": {"neighbors", "peers"},
+}
+
+// ReadEthereumCSV reads Ethereum node crawler snapshot in CSV format with
+// header row. Column "id" (or "node_id", "enode") is required and may hold
+// enode URL, in which case node ID is the public key part of it. Optional
+// columns are "ip", "client" (or "client_name"), "country" (or
+// "country_name"), "lat" and "lon" (or "latitude" and "longitude"), and
+// "neighbors" (or "peers") with space-separated IDs of nodes found in the
+// node's routing table. Neighbors missing from the snapshot are added as
+// not crawled nodes.
+func ReadEthereumCSV(r io.Reader) (*graph.Graph, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %v", err)
+	}
+	cols := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for field, aliases := range ethColumns {
+			for _, alias := range aliases {
+				if _, ok := cols[field]; !ok && name == alias {
+					cols[field] = i
+				}
+			}
+		}
+	}
+	if _, ok := cols["id"]; !ok {
+		return nil, fmt.Errorf("no node id column in header")
+	}
+
+	b := newBuilder()
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		get := func(field string) string {
+			i, ok := cols[field]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		id, ip := parseEnode(get("id"))
+		if id == "" {
+			return nil, fmt.Errorf("line %d: empty node id", line)
+		}
+		n := b.node(id)
+		n.Crawled = true
+		n.IP = ip
+		if v := get("ip"); v != "" {
+			n.IP = v
+		}
+		n.Client, n.Country = get("client"), get("country")
+		if lat, lon := get("lat"), get("lon"); lat != "" && lon != "" {
+			n.Lat, err = strconv.ParseFloat(lat, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: wrong latitude '%s'", line, lat)
+			}
+			n.Lon, err = strconv.ParseFloat(lon, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: wrong longitude '%s'", line, lon)
+			}
+			n.HasCoord = true
+		}
+		for _, peer := range strings.Fields(get("neighbors")) {
+			peerID, _ := parseEnode(peer)
+			b.link(id, peerID)
+		}
+	}
+	return b.graph()
+}
+
+// parseEnode returns node ID and IP out of enode URL, i.e.
+// "enode://<pubkey>@10.3.58.6:30303". Strings other than enode URL are
+// returned as ID as is.
+func parseEnode(s string) (id, ip string) {
+	if !strings.HasPrefix(s, "enode://") {
+		return s, ""
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s, ""
+	}
+	return u.User.Username(), u.Hostname()
+}
@@ -0,0 +1,109 @@
+package crawl
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/divan/graphx/graph"
+)
+
+// libp2pPeer represents single crawled peer record of libp2p crawler
+// (i.e. Nebula) JSON output.
+type libp2pPeer struct {
+	PeerID       string   `json:"PeerID"`
+	NeighborIDs  []string `json:"NeighborIDs"`
+	AgentVersion string   `json:"AgentVersion"`
+	Protocols    []string `json:"Protocols"`
+	Maddrs       []string `json:"Maddrs"`
+	MultiAddrs   []string `json:"MultiAddrs"`
+}
+
+// ReadLibp2pJSON reads libp2p crawler snapshot in JSON format, either as
+// array of peer records or as newline-delimited records. Each record has
+// "PeerID", "NeighborIDs" found in the peer's routing table, and optional
+// "AgentVersion", "Protocols" and "Maddrs" (or "MultiAddrs") fields. Node
+// IP is taken from the first IP multiaddress. Neighbors missing from the
+// snapshot are added as not crawled nodes.
+func ReadLibp2pJSON(r io.Reader) (*graph.Graph, error) {
+	br := bufio.NewReader(r)
+	peers, err := decodePeers(br)
+	if err != nil {
+		return nil, err
+	}
+
+	b := newBuilder()
+	for i, p := range peers {
+		if p.PeerID == "" {
+			return nil, fmt.Errorf("record %d: empty peer id", i)
+		}
+		n := b.node(p.PeerID)
+		n.Crawled = true
+		n.Client = p.AgentVersion
+		n.Protocols = p.Protocols
+		addrs := p.Maddrs
+		if len(addrs) == 0 {
+			addrs = p.MultiAddrs
+		}
+		n.IP = multiaddrIP(addrs)
+		for _, neighbor := range p.NeighborIDs {
+			b.link(p.PeerID, neighbor)
+		}
+	}
+	return b.graph()
+}
+
+// decodePeers decodes peer records out of JSON array or stream.
+func decodePeers(br *bufio.Reader) ([]libp2pPeer, error) {
+	var peers []libp2pPeer
+	first, err := peekNonSpace(br)
+	if err != nil {
+		return nil, fmt.Errorf("parse snapshot: %v", err)
+	}
+	dec := json.NewDecoder(br)
+	if first == '[' {
+		if err := dec.Decode(&peers); err != nil {
+			return nil, fmt.Errorf("parse snapshot: %v", err)
+		}
+		return peers, nil
+	}
+	for {
+		var p libp2pPeer
+		err := dec.Decode(&p)
+		if err == io.EOF {
+			return peers, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse record %d: %v", len(peers), err)
+		}
+		peers = append(peers, p)
+	}
+}
+
+// peekNonSpace returns the first non-whitespace byte of the reader,
+// without consuming it.
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if strings.IndexByte(" \t\r\n", c) < 0 {
+			return c, br.UnreadByte()
+		}
+	}
+}
+
+// multiaddrIP returns IP of the first /ip4 or /ip6 multiaddress, i.e.
+// "/ip4/10.3.58.6/tcp/4001".
+func multiaddrIP(addrs []string) string {
+	for _, addr := range addrs {
+		parts := strings.Split(addr, "/")
+		if len(parts) > 2 && (parts[1] == "ip4" || parts[1] == "ip6") {
+			return parts[2]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,165 @@
+// Package discovery simulates peer discovery phase, forming network
+// topology with the discovery protocol instead of taking it as given.
+// Nodes join one by one through bootstrap nodes, discover other nodes
+// with either Kademlia lookups or random walks, and then dial random
+// peers out of discovered ones, as Ethereum nodes do with discv5.
+//
+// Resulting graph can be used by any simulator, so the whole lifecycle
+// from bootstrap to broadcast can be simulated.
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+
+	"github.com/divan/graphx/graph"
+)
+
+// Protocol defines discovery protocol used by nodes.
+type Protocol int
+
+const (
+	// Kademlia discovers nodes with iterative lookups of own and random
+	// IDs in Kademlia routing table, like discv4/discv5.
+	Kademlia Protocol = iota
+	// RandomWalk discovers nodes with random walks over the overlay,
+	// starting at bootstrap nodes.
+	RandomWalk
+)
+
+// ParseProtocol parses protocol name ("kademlia" or "randomwalk").
+func ParseProtocol(name string) (Protocol, error) {
+	switch name {
+	case "kademlia":
+		return Kademlia, nil
+	case "randomwalk":
+		return RandomWalk, nil
+	default:
+		return Kademlia, fmt.Errorf("unknown discovery protocol '%s'", name)
+	}
+}
+
+// Params defines discovery simulation parameters.
+type Params struct {
+	Protocol  Protocol
+	Nodes     int // total number of nodes
+	Bootstrap int // number of bootstrap nodes, known to every node
+	MaxPeers  int // number of peers dialed by each node
+
+	BucketSize int // Kademlia bucket size (k)
+	Alpha      int // Kademlia lookup concurrency
+	Lookups    int // Kademlia random lookups after joining
+
+	Walks      int // number of random walks on joining
+	WalkLength int // number of hops of each random walk
+}
+
+// DefaultParams returns default discovery parameters for the given
+// protocol and number of nodes.
+func DefaultParams(protocol Protocol, nodes int) Params {
+	return Params{
+		Protocol:   protocol,
+		Nodes:      nodes,
+		Bootstrap:  3,
+		MaxPeers:   4,
+		BucketSize: 16,
+		Alpha:      3,
+		Lookups:    3,
+		Walks:      3,
+		WalkLength: 5,
+	}
+}
+
+// Result holds the outcome of discovery phase.
+type Result struct {
+	Graph    *graph.Graph
+	Messages int // discovery messages exchanged, requests and responses
+}
+
+// Run simulates the discovery phase and returns formed network.
+func Run(params Params) (*Result, error) {
+	if params.Nodes < 2 {
+		return nil, fmt.Errorf("at least 2 nodes needed, got %d", params.Nodes)
+	}
+	if params.Bootstrap < 1 || params.Bootstrap > params.Nodes {
+		return nil, fmt.Errorf("wrong number of bootstrap nodes %d", params.Bootstrap)
+	}
+
+	var d discoverer
+	switch params.Protocol {
+	case Kademlia:
+		d = newKademlia(params)
+	case RandomWalk:
+		d = newRandomWalk(params)
+	default:
+		return nil, fmt.Errorf("unknown discovery protocol %d", params.Protocol)
+	}
+	for i := params.Bootstrap; i < params.Nodes; i++ {
+		d.join(i)
+	}
+
+	g := graph.NewGraph()
+	for i := 0; i < params.Nodes; i++ {
+		g.AddNode(node(strconv.Itoa(i)))
+	}
+	connected := make(map[[2]int]bool)
+	for i := 0; i < params.Nodes; i++ {
+		known := d.known(i)
+		rand.Shuffle(len(known), func(a, b int) { known[a], known[b] = known[b], known[a] })
+		var dialed int
+		for _, peer := range known {
+			if dialed == params.MaxPeers {
+				break
+			}
+			key := [2]int{i, peer}
+			if peer < i {
+				key = [2]int{peer, i}
+			}
+			if peer == i || connected[key] {
+				continue
+			}
+			connected[key] = true
+			g.AddLink(strconv.Itoa(i), strconv.Itoa(peer))
+			dialed++
+		}
+	}
+	return &Result{Graph: g, Messages: d.messages()}, nil
+}
+
+// discoverer is implemented by discovery protocols.
+type discoverer interface {
+	join(node int)        // joins the node to the network
+	known(node int) []int // nodes discovered by node
+	messages() int        // messages exchanged so far
+}
+
+// node implements string-only graph.Node.
+type node string
+
+// ID implements graph.Node interface.
+func (n node) ID() string { return string(n) }
+
+// WriteJSON writes graph in D3 JSON format, as used for network files.
+func WriteJSON(w io.Writer, g *graph.Graph) error {
+	type jsonNode struct {
+		ID string `json:"id"`
+	}
+	type jsonLink struct {
+		Source string `json:"source"`
+		Target string `json:"target"`
+	}
+	var data struct {
+		Nodes []jsonNode `json:"nodes"`
+		Links []jsonLink `json:"links"`
+	}
+	for _, n := range g.Nodes() {
+		data.Nodes = append(data.Nodes, jsonNode{ID: n.ID()})
+	}
+	for _, l := range g.Links() {
+		data.Links = append(data.Links, jsonLink{Source: l.From(), Target: l.To()})
+	}
+	return json.NewEncoder(w).Encode(data)
+}
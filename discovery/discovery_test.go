@@ -0,0 +1,81 @@
+package discovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	for _, protocol := range []Protocol{Kademlia, RandomWalk} {
+		res, err := Run(DefaultParams(protocol, 100))
+		if err != nil {
+			t.Fatalf("protocol %d: %v", protocol, err)
+		}
+		g := res.Graph
+		if g.NumNodes() != 100 {
+			t.Fatalf("protocol %d: expected 100 nodes, got %d", protocol, g.NumNodes())
+		}
+		if res.Messages == 0 {
+			t.Fatalf("protocol %d: expected discovery messages", protocol)
+		}
+
+		// network should be connected
+		peers := make(map[int][]int)
+		for _, l := range g.Links() {
+			if l.FromIdx() == l.ToIdx() {
+				t.Fatalf("protocol %d: unexpected self link %d", protocol, l.FromIdx())
+			}
+			peers[l.FromIdx()] = append(peers[l.FromIdx()], l.ToIdx())
+			peers[l.ToIdx()] = append(peers[l.ToIdx()], l.FromIdx())
+		}
+		visited := map[int]bool{0: true}
+		queue := []int{0}
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			for _, p := range peers[n] {
+				if !visited[p] {
+					visited[p] = true
+					queue = append(queue, p)
+				}
+			}
+		}
+		if len(visited) != 100 {
+			t.Fatalf("protocol %d: expected connected network, reached %d nodes", protocol, len(visited))
+		}
+	}
+}
+
+func TestRunWrongParams(t *testing.T) {
+	params := DefaultParams(Kademlia, 1)
+	if _, err := Run(params); err == nil {
+		t.Fatal("expected error for single node")
+	}
+	params = DefaultParams(Kademlia, 10)
+	params.Bootstrap = 0
+	if _, err := Run(params); err == nil {
+		t.Fatal("expected error without bootstrap nodes")
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	res, err := Run(DefaultParams(RandomWalk, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, res.Graph); err != nil {
+		t.Fatal(err)
+	}
+	var data struct {
+		Nodes []struct{ ID string }
+		Links []struct{ Source, Target string }
+	}
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Nodes) != 10 || len(data.Links) != res.Graph.NumLinks() {
+		t.Fatalf("expected 10 nodes and %d links, got %d and %d", res.Graph.NumLinks(), len(data.Nodes), len(data.Links))
+	}
+}
@@ -0,0 +1,135 @@
+package discovery
+
+import (
+	"math/bits"
+	"math/rand"
+	"sort"
+)
+
+// idBits is the size of node IDs. Real discv5 uses 256-bit IDs, but
+// 64 bits is enough to get the same routing table structure.
+const idBits = 64
+
+// kademlia simulates Kademlia routing tables and iterative lookups.
+type kademlia struct {
+	params Params
+	ids    []uint64
+	tables [][idBits][]int // node -> bucket -> nodes
+	msgs   int
+}
+
+func newKademlia(params Params) *kademlia {
+	k := &kademlia{
+		params: params,
+		ids:    make([]uint64, params.Nodes),
+		tables: make([][idBits][]int, params.Nodes),
+	}
+	for i := range k.ids {
+		k.ids[i] = rand.Uint64()
+	}
+	// bootstrap nodes know each other
+	for i := 0; i < params.Bootstrap; i++ {
+		for j := 0; j < params.Bootstrap; j++ {
+			k.add(i, j)
+		}
+	}
+	return k
+}
+
+// add adds peer to node's routing table, if its bucket is not full.
+func (k *kademlia) add(node, peer int) {
+	if node == peer {
+		return
+	}
+	b := bits.Len64(k.ids[node]^k.ids[peer]) - 1
+	bucket := k.tables[node][b]
+	if len(bucket) >= k.params.BucketSize {
+		return
+	}
+	for _, n := range bucket {
+		if n == peer {
+			return
+		}
+	}
+	k.tables[node][b] = append(bucket, peer)
+}
+
+func (k *kademlia) join(node int) {
+	for i := 0; i < k.params.Bootstrap; i++ {
+		k.add(node, i)
+	}
+	k.lookup(node, k.ids[node])
+	for i := 0; i < k.params.Lookups; i++ {
+		k.lookup(node, rand.Uint64())
+	}
+}
+
+// lookup performs iterative lookup of target by node, asking alpha
+// closest not yet queried nodes for their closest nodes, until the
+// closest ones are queried. Queried nodes learn about node as well.
+func (k *kademlia) lookup(node int, target uint64) {
+	queried := map[int]bool{node: true}
+	seen := map[int]bool{node: true}
+	shortlist := k.closest(node, target)
+	for _, n := range shortlist {
+		seen[n] = true
+	}
+	for {
+		var ask []int
+		for _, n := range shortlist {
+			if len(ask) == k.params.Alpha {
+				break
+			}
+			if !queried[n] {
+				ask = append(ask, n)
+			}
+		}
+		if len(ask) == 0 {
+			return
+		}
+		for _, n := range ask {
+			queried[n] = true
+			k.msgs += 2 // FINDNODE request and NODES response
+			k.add(n, node)
+			k.add(node, n)
+			for _, found := range k.closest(n, target) {
+				if !seen[found] {
+					seen[found] = true
+					shortlist = append(shortlist, found)
+				}
+			}
+		}
+		k.sortByDistance(shortlist, target)
+		if len(shortlist) > k.params.BucketSize {
+			shortlist = shortlist[:k.params.BucketSize]
+		}
+	}
+}
+
+// closest returns up to bucket size nodes from node's table closest to target.
+func (k *kademlia) closest(node int, target uint64) []int {
+	ret := k.known(node)
+	k.sortByDistance(ret, target)
+	if len(ret) > k.params.BucketSize {
+		ret = ret[:k.params.BucketSize]
+	}
+	return ret
+}
+
+func (k *kademlia) sortByDistance(nodes []int, target uint64) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return k.ids[nodes[i]]^target < k.ids[nodes[j]]^target
+	})
+}
+
+func (k *kademlia) known(node int) []int {
+	var ret []int
+	for _, bucket := range k.tables[node] {
+		ret = append(ret, bucket...)
+	}
+	return ret
+}
+
+func (k *kademlia) messages() int {
+	return k.msgs
+}
@@ -0,0 +1,76 @@
+package discovery
+
+import "math/rand"
+
+// randomWalk simulates discovery with random walks over the overlay
+// of known nodes.
+type randomWalk struct {
+	params Params
+	peers  []map[int]bool // node -> nodes it knows about
+	msgs   int
+}
+
+func newRandomWalk(params Params) *randomWalk {
+	r := &randomWalk{
+		params: params,
+		peers:  make([]map[int]bool, params.Nodes),
+	}
+	for i := range r.peers {
+		r.peers[i] = make(map[int]bool)
+	}
+	// bootstrap nodes know each other
+	for i := 0; i < params.Bootstrap; i++ {
+		for j := 0; j < params.Bootstrap; j++ {
+			if i != j {
+				r.peers[i][j] = true
+			}
+		}
+	}
+	return r
+}
+
+// join runs random walks from random bootstrap nodes. Each visited node
+// returns random known node as the next hop and learns about the joining node.
+func (r *randomWalk) join(node int) {
+	for w := 0; w < r.params.Walks; w++ {
+		current := rand.Intn(r.params.Bootstrap)
+		for hop := 0; hop < r.params.WalkLength; hop++ {
+			r.msgs += 2 // request and response
+			r.peers[node][current] = true
+			r.peers[current][node] = true
+
+			next := r.randomPeer(current, node)
+			if next < 0 {
+				break
+			}
+			current = next
+		}
+	}
+}
+
+// randomPeer returns random node known by node, except the given one,
+// or -1 if there is no such node.
+func (r *randomWalk) randomPeer(node, except int) int {
+	candidates := make([]int, 0, len(r.peers[node]))
+	for peer := range r.peers[node] {
+		if peer != except {
+			candidates = append(candidates, peer)
+		}
+	}
+	if len(candidates) == 0 {
+		return -1
+	}
+	return candidates[rand.Intn(len(candidates))]
+}
+
+func (r *randomWalk) known(node int) []int {
+	ret := make([]int, 0, len(r.peers[node]))
+	for peer := range r.peers[node] {
+		ret = append(ret, peer)
+	}
+	return ret
+}
+
+func (r *randomWalk) messages() int {
+	return r.msgs
+}
@@ -0,0 +1,200 @@
+// Package distributed implements simulation of huge graphs across multiple
+// machines. Graph is split into partitions (see Partition and Split), each
+// simulated by a Worker, and Coordinator advances workers in lockstep by
+// windows of simulated time, routing messages crossing partitions. As
+// each message takes at least one link latency to arrive, deliveries
+// within a window never depend on other workers, so results are the same
+// as for the single partition.
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// WorkerClient is implemented by Worker and its gRPC Client.
+type WorkerClient interface {
+	Start(ctx context.Context, req *StartRequest) (*StartResponse, error)
+	Step(ctx context.Context, req *StepRequest) (*StepResponse, error)
+	Collect(ctx context.Context, req *CollectRequest) (*CollectResponse, error)
+}
+
+// DefaultLatency is the default per link latency.
+const DefaultLatency = 10 * time.Millisecond
+
+// Coordinator runs simulation on workers. Workers should be ordered by
+// their partition index.
+type Coordinator struct {
+	workers []WorkerClient
+	latency time.Duration
+
+	Nodes, Links int    // size of the whole graph, set by Run
+	Checksum     string // checksum of the whole graph, set by Run
+	Steps        int    // number of lockstep windows of the last run
+}
+
+// NewCoordinator creates coordinator of the given workers, with per
+// link latency, which is also the size of lockstep window.
+func NewCoordinator(workers []WorkerClient, latency time.Duration) *Coordinator {
+	if latency < time.Millisecond {
+		latency = time.Millisecond
+	}
+	return &Coordinator{
+		workers: workers,
+		latency: latency,
+	}
+}
+
+// Run simulates propagation of the message sent from the sender with the
+// given ttl, and returns its propagation log.
+func (c *Coordinator) Run(ctx context.Context, sender, ttl int) (*propagation.Log, error) {
+	latency := int64(c.latency / time.Millisecond)
+	next := make([]int64, len(c.workers))
+	inbox := make([][]Message, len(c.workers))
+	var (
+		mx        sync.Mutex
+		owners    int
+		checksums = make(map[string]bool)
+	)
+	err := c.each(func(i int, w WorkerClient) error {
+		resp, err := w.Start(ctx, &StartRequest{Part: i, Sender: sender, TTL: ttl, Latency: latency})
+		if err != nil {
+			return err
+		}
+		next[i] = resp.Next
+		mx.Lock()
+		defer mx.Unlock()
+		if resp.Owned {
+			owners++
+		}
+		c.Nodes, c.Links, c.Checksum = resp.Nodes, resp.Links, resp.Checksum
+		checksums[resp.Checksum] = true
+		return route(inbox, resp.Messages)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(checksums) > 1 {
+		return nil, fmt.Errorf("workers simulate partitions of different graphs")
+	}
+	if owners != 1 {
+		return nil, fmt.Errorf("sender node %d is owned by %d workers", sender, owners)
+	}
+
+	// advance all workers by windows of latency, skipping idle time
+	c.Steps = 0
+	for {
+		start := int64(-1)
+		for i := range c.workers {
+			if next[i] >= 0 && (start < 0 || next[i] < start) {
+				start = next[i]
+			}
+			for _, m := range inbox[i] {
+				if start < 0 || m.Ts < start {
+					start = m.Ts
+				}
+			}
+		}
+		if start < 0 {
+			break
+		}
+
+		outbox := make([][]Message, len(c.workers))
+		err := c.each(func(i int, w WorkerClient) error {
+			resp, err := w.Step(ctx, &StepRequest{Until: start + latency, Messages: inbox[i]})
+			if err != nil {
+				return err
+			}
+			next[i] = resp.Next
+			mx.Lock()
+			defer mx.Unlock()
+			return route(outbox, resp.Messages)
+		})
+		if err != nil {
+			return nil, err
+		}
+		inbox = outbox
+		c.Steps++
+	}
+
+	var entries []Entry
+	err = c.each(func(i int, w WorkerClient) error {
+		resp, err := w.Collect(ctx, &CollectRequest{Part: i})
+		if err != nil {
+			return err
+		}
+		mx.Lock()
+		entries = append(entries, resp.Entries...)
+		mx.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entriesLog(entries), nil
+}
+
+// route appends messages to inboxes of their partitions.
+func route(inbox [][]Message, msgs []Message) error {
+	for _, m := range msgs {
+		if m.Part < 0 || m.Part >= len(inbox) {
+			return fmt.Errorf("message to unknown partition %d", m.Part)
+		}
+		inbox[m.Part] = append(inbox[m.Part], m)
+	}
+	return nil
+}
+
+// each calls fn for all workers concurrently, returning the first error.
+func (c *Coordinator) each(fn func(i int, w WorkerClient) error) error {
+	errs := make([]error, len(c.workers))
+	var wg sync.WaitGroup
+	for i, w := range c.workers {
+		wg.Add(1)
+		go func(i int, w WorkerClient) {
+			defer wg.Done()
+			if err := fn(i, w); err != nil {
+				errs[i] = fmt.Errorf("worker %d: %v", i, err)
+			}
+		}(i, w)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entriesLog converts entries into propagation log, with steps of all
+// entries of the same timestamp.
+func entriesLog(entries []Entry) *propagation.Log {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Ts != b.Ts {
+			return a.Ts < b.Ts
+		}
+		if a.Link != b.Link {
+			return a.Link < b.Link
+		}
+		return a.From < b.From
+	})
+	plog := propagation.NewLog(0)
+	for i := 0; i < len(entries); {
+		j := i
+		var nodes, links []int
+		for ; j < len(entries) && entries[j].Ts == entries[i].Ts; j++ {
+			links = append(links, entries[j].Link)
+			nodes = append(nodes, entries[j].From, entries[j].To)
+		}
+		plog.AddStep(int(entries[i].Ts), nodes, links)
+		i = j
+	}
+	return plog
+}
@@ -0,0 +1,181 @@
+package distributed
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/distributed/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// node implements string-only graph.Node
+type node string
+
+func (n node) ID() string { return string(n) }
+
+func newGraph(n int, links ...[2]int) *graph.Graph {
+	g := graph.NewGraph()
+	for i := 0; i < n; i++ {
+		g.AddNode(node(strconv.Itoa(i)))
+	}
+	for _, l := range links {
+		g.AddLink(strconv.Itoa(l[0]), strconv.Itoa(l[1]))
+	}
+	return g
+}
+
+// testGraph returns ring of 10 nodes with a few chords.
+func testGraph() *graph.Graph {
+	var links [][2]int
+	for i := 0; i < 10; i++ {
+		links = append(links, [2]int{i, (i + 1) % 10})
+	}
+	links = append(links, [2]int{0, 5}, [2]int{2, 7}, [2]int{3, 8})
+	return newGraph(10, links...)
+}
+
+func TestPartition(t *testing.T) {
+	g := testGraph()
+	owners := Partition(g, 3)
+	sizes := make([]int, 3)
+	for _, owner := range owners {
+		sizes[owner]++
+	}
+	if !reflect.DeepEqual(sizes, []int{4, 4, 2}) {
+		t.Fatalf("Expected partitions of 4, 4 and 2 nodes, got %v", sizes)
+	}
+
+	parts := Split(g, owners, 3)
+	var edges int
+	for _, p := range parts {
+		for _, e := range p.Edges {
+			if owners[e.From] != p.Index && owners[e.To] != p.Index {
+				t.Fatalf("Partition %d holds foreign edge %v", p.Index, e)
+			}
+			if owners[e.From] != p.Index && p.Owners[e.From] != owners[e.From] {
+				t.Fatalf("Partition %d has wrong owner of node %d", p.Index, e.From)
+			}
+			if owners[e.From] == p.Index {
+				edges++
+			}
+		}
+	}
+	if edges != g.NumLinks() {
+		t.Fatalf("Expected %d edges in total, got %d", g.NumLinks(), edges)
+	}
+}
+
+// newTestCoordinator creates coordinator of in-process workers of k partitions.
+func newTestCoordinator(g *graph.Graph, k int) *Coordinator {
+	parts := Split(g, Partition(g, k), k)
+	workers := make([]WorkerClient, k)
+	for i, p := range parts {
+		workers[i] = NewWorker(p)
+	}
+	return NewCoordinator(workers, DefaultLatency)
+}
+
+func TestCoordinator(t *testing.T) {
+	g := testGraph()
+	single, err := newTestCoordinator(g, 1).Run(context.Background(), 4, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTestCoordinator(g, 3)
+	plog, err := c.Run(context.Background(), 4, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(plog, single) {
+		t.Fatalf("Expected the same log as for single partition:\n%v\ngot:\n%v", single, plog)
+	}
+	if c.Nodes != 10 || c.Links != 13 {
+		t.Fatalf("Expected 10 nodes and 13 links, got %d and %d", c.Nodes, c.Links)
+	}
+
+	// each link is used at least once, in both directions at most
+	used := make(map[int]int)
+	for _, links := range plog.Links {
+		for _, link := range links {
+			used[link]++
+		}
+	}
+	for i := 0; i < g.NumLinks(); i++ {
+		if used[i] < 1 || used[i] > 2 {
+			t.Fatalf("Expected link %d used once or twice, got %d", i, used[i])
+		}
+	}
+	if plog.Timestamps[0] != 10 {
+		t.Fatalf("Expected first step after one latency, got %d", plog.Timestamps[0])
+	}
+}
+
+func TestCoordinatorTTL(t *testing.T) {
+	g := newGraph(4, [2]int{0, 1}, [2]int{1, 2}, [2]int{2, 3})
+	plog, err := newTestCoordinator(g, 2).Run(context.Background(), 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(plog.Links, [][]int{{0}, {1}}) {
+		t.Fatalf("Expected message to travel 2 hops, got %v", plog.Links)
+	}
+}
+
+func TestCoordinatorUnknownSender(t *testing.T) {
+	g := testGraph()
+	if _, err := newTestCoordinator(g, 2).Run(context.Background(), 42, 10); err == nil {
+		t.Fatal("Expected error for unknown sender")
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	g := testGraph()
+	single, err := newTestCoordinator(g, 1).Run(context.Background(), 4, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := Split(g, Partition(g, 2), 2)
+	workers := make([]WorkerClient, len(parts))
+	for i, p := range parts {
+		lis := bufconn.Listen(1 << 20)
+		srv := NewGRPCServer(NewWorker(p))
+		go srv.Serve(lis)
+		defer srv.Stop()
+
+		conn, err := grpc.NewClient("passthrough:///bufconn",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return lis.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		workers[i] = &Client{conn: conn, client: pb.NewWorkerClient(conn)}
+	}
+	plog, err := NewCoordinator(workers, DefaultLatency).Run(context.Background(), 4, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(plog, single) {
+		t.Fatalf("Expected the same log as for in-process workers:\n%v\ngot:\n%v", single, plog)
+	}
+}
+
+func TestCoordinatorDifferentGraphs(t *testing.T) {
+	a := Split(testGraph(), Partition(testGraph(), 2), 2)
+	other := newGraph(10, [2]int{0, 1})
+	b := Split(other, Partition(other, 2), 2)
+	c := NewCoordinator([]WorkerClient{NewWorker(a[0]), NewWorker(b[1])}, DefaultLatency)
+	if _, err := c.Run(context.Background(), 0, 10); err == nil {
+		t.Fatal("Expected error for partitions of different graphs")
+	}
+}
@@ -0,0 +1,170 @@
+package distributed
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative pb/worker.proto
+
+import (
+	"context"
+
+	"github.com/divan/simulation/distributed/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// NewGRPCServer creates gRPC server serving the worker to coordinator.
+func NewGRPCServer(w *Worker, opts ...grpc.ServerOption) *grpc.Server {
+	g := grpc.NewServer(opts...)
+	pb.RegisterWorkerServer(g, &grpcWorker{w: w})
+	return g
+}
+
+// grpcWorker implements Worker service of pb/worker.proto by converting
+// messages for the worker.
+type grpcWorker struct {
+	pb.UnimplementedWorkerServer
+	w *Worker
+}
+
+func (g *grpcWorker) Start(ctx context.Context, req *pb.StartRequest) (*pb.StartResponse, error) {
+	resp, err := g.w.Start(ctx, &StartRequest{
+		Part:    int(req.Part),
+		Sender:  int(req.Sender),
+		TTL:     int(req.Ttl),
+		Latency: req.Latency,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.StartResponse{
+		Owned:    resp.Owned,
+		Nodes:    int64(resp.Nodes),
+		Links:    int64(resp.Links),
+		Checksum: resp.Checksum,
+		Messages: toPbMessages(resp.Messages),
+		Next:     resp.Next,
+	}, nil
+}
+
+func (g *grpcWorker) Step(ctx context.Context, req *pb.StepRequest) (*pb.StepResponse, error) {
+	resp, err := g.w.Step(ctx, &StepRequest{
+		Until:    req.Until,
+		Messages: fromPbMessages(req.Messages),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.StepResponse{
+		Messages: toPbMessages(resp.Messages),
+		Next:     resp.Next,
+	}, nil
+}
+
+func (g *grpcWorker) Collect(ctx context.Context, req *pb.CollectRequest) (*pb.CollectResponse, error) {
+	resp, err := g.w.Collect(ctx, &CollectRequest{Part: int(req.Part)})
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*pb.Entry, len(resp.Entries))
+	for i, e := range resp.Entries {
+		entries[i] = &pb.Entry{Ts: e.Ts, Link: int64(e.Link), From: int64(e.From), To: int64(e.To)}
+	}
+	return &pb.CollectResponse{Entries: entries}, nil
+}
+
+// Client is a gRPC client of the remote worker.
+type Client struct {
+	conn   *grpc.ClientConn
+	client pb.WorkerClient
+}
+
+// Dial creates client of the worker served at addr.
+func Dial(addr string) (*Client, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, client: pb.NewWorkerClient(conn)}, nil
+}
+
+// Start implements WorkerClient.
+func (c *Client) Start(ctx context.Context, req *StartRequest) (*StartResponse, error) {
+	resp, err := c.client.Start(ctx, &pb.StartRequest{
+		Part:    int64(req.Part),
+		Sender:  int64(req.Sender),
+		Ttl:     int64(req.TTL),
+		Latency: req.Latency,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &StartResponse{
+		Owned:    resp.Owned,
+		Nodes:    int(resp.Nodes),
+		Links:    int(resp.Links),
+		Checksum: resp.Checksum,
+		Messages: fromPbMessages(resp.Messages),
+		Next:     resp.Next,
+	}, nil
+}
+
+// Step implements WorkerClient.
+func (c *Client) Step(ctx context.Context, req *StepRequest) (*StepResponse, error) {
+	resp, err := c.client.Step(ctx, &pb.StepRequest{
+		Until:    req.Until,
+		Messages: toPbMessages(req.Messages),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &StepResponse{
+		Messages: fromPbMessages(resp.Messages),
+		Next:     resp.Next,
+	}, nil
+}
+
+// Collect implements WorkerClient.
+func (c *Client) Collect(ctx context.Context, req *CollectRequest) (*CollectResponse, error) {
+	resp, err := c.client.Collect(ctx, &pb.CollectRequest{Part: int64(req.Part)})
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, e := range resp.Entries {
+		entries = append(entries, Entry{Ts: e.Ts, Link: int(e.Link), From: int(e.From), To: int(e.To)})
+	}
+	return &CollectResponse{Entries: entries}, nil
+}
+
+// Close closes connection to the worker.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func toPbMessages(msgs []Message) []*pb.Message {
+	ret := make([]*pb.Message, len(msgs))
+	for i, m := range msgs {
+		ret[i] = &pb.Message{
+			Part: int64(m.Part),
+			Link: int64(m.Link),
+			From: int64(m.From),
+			To:   int64(m.To),
+			Ts:   m.Ts,
+			Ttl:  int64(m.TTL),
+		}
+	}
+	return ret
+}
+
+func fromPbMessages(msgs []*pb.Message) []Message {
+	var ret []Message
+	for _, m := range msgs {
+		ret = append(ret, Message{
+			Part: int(m.Part),
+			Link: int(m.Link),
+			From: int(m.From),
+			To:   int(m.To),
+			Ts:   m.Ts,
+			TTL:  int(m.Ttl),
+		})
+	}
+	return ret
+}
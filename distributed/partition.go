@@ -0,0 +1,134 @@
+package distributed
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+)
+
+// Part describes partition of the network graph simulated by a single
+// worker. It holds only nodes owned by the partition and links incident
+// to them, so workers never load the whole graph.
+type Part struct {
+	Index int // partition index
+	Parts int // total number of partitions
+	Nodes int // total number of nodes in the graph
+	Links int // total number of links in the graph
+
+	// Checksum is the checksum of the whole graph, see propagation.GraphChecksum.
+	Checksum string
+
+	Owned  []int       // indices of nodes owned by the partition
+	Edges  []Edge      // links with at least one owned endpoint
+	Owners map[int]int // partitions of foreign endpoints of edges
+}
+
+// Edge is a link of the graph, identified by its index in the whole graph.
+type Edge struct {
+	Link     int
+	From, To int // nodes indices
+}
+
+// Partition assigns each node of the graph to one of k partitions of
+// roughly equal size, growing partitions with breadth-first search, so
+// neighbouring nodes tend to end up in the same partition, and fewer
+// messages cross partitions. It returns partition index of each node.
+func Partition(g *graph.Graph, k int) []int {
+	n := g.NumNodes()
+	adj := make([][]int, n)
+	for _, link := range g.Links() {
+		from, to := link.FromIdx(), link.ToIdx()
+		adj[from] = append(adj[from], to)
+		adj[to] = append(adj[to], from)
+	}
+
+	owners := make([]int, n)
+	for i := range owners {
+		owners[i] = -1
+	}
+	if k < 1 {
+		k = 1
+	}
+	size := (n + k - 1) / k
+	part, filled := 0, 0
+	assign := func(node int) {
+		owners[node] = part
+		filled++
+		if filled == size && part < k-1 {
+			part++
+			filled = 0
+		}
+	}
+	for start := range owners {
+		if owners[start] >= 0 {
+			continue
+		}
+		queue := []int{start}
+		assign(start)
+		for len(queue) > 0 {
+			node := queue[0]
+			queue = queue[1:]
+			for _, peer := range adj[node] {
+				if owners[peer] < 0 {
+					assign(peer)
+					queue = append(queue, peer)
+				}
+			}
+		}
+	}
+	return owners
+}
+
+// Split splits the graph into k parts by nodes partitions, see Partition.
+func Split(g *graph.Graph, owners []int, k int) []*Part {
+	checksum := propagation.GraphChecksum(g)
+	parts := make([]*Part, k)
+	for i := range parts {
+		parts[i] = &Part{
+			Index:    i,
+			Parts:    k,
+			Nodes:    g.NumNodes(),
+			Links:    g.NumLinks(),
+			Checksum: checksum,
+			Owners:   make(map[int]int),
+		}
+	}
+	for node, owner := range owners {
+		parts[owner].Owned = append(parts[owner].Owned, node)
+	}
+	for i, link := range g.Links() {
+		e := Edge{Link: i, From: link.FromIdx(), To: link.ToIdx()}
+		a, b := owners[e.From], owners[e.To]
+		parts[a].Edges = append(parts[a].Edges, e)
+		if a == b {
+			continue
+		}
+		parts[b].Edges = append(parts[b].Edges, e)
+		parts[a].Owners[e.To] = b
+		parts[b].Owners[e.From] = a
+	}
+	return parts
+}
+
+// WriteJSON writes partition to w in JSON format.
+func (p *Part) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(p)
+}
+
+// ReadPart reads partition in JSON format from the file at path.
+func ReadPart(path string) (*Part, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var p Part
+	if err := json.NewDecoder(fd).Decode(&p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
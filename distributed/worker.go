@@ -0,0 +1,228 @@
+package distributed
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Message is a delivery of the propagated message to the node over the
+// link, sent from one worker to another if nodes are in different
+// partitions.
+type Message struct {
+	Part     int // partition of the receiver
+	Link     int
+	From, To int   // nodes indices
+	Ts       int64 // arrival time, in milliseconds
+	TTL      int   // hops left, including this one
+}
+
+// Entry is a single message sending recorded by worker, see propagation.LogEntry.
+type Entry struct {
+	Ts       int64
+	Link     int
+	From, To int
+}
+
+// StartRequest starts propagation of the new message from the sender.
+type StartRequest struct {
+	Part    int // expected partition of the worker
+	Sender  int
+	TTL     int
+	Latency int64 // per link latency, in milliseconds
+}
+
+// StartResponse tells whether worker owns the sender, and holds its
+// first messages to other partitions, and the time of the earliest
+// pending delivery, see StepResponse.
+type StartResponse struct {
+	Owned    bool
+	Nodes    int    // total number of nodes in the graph
+	Links    int    // total number of links in the graph
+	Checksum string // checksum of the whole graph
+	Messages []Message
+	Next     int64
+}
+
+// StepRequest delivers messages to the worker's nodes, and asks it to
+// process deliveries happening before Until.
+type StepRequest struct {
+	Until    int64
+	Messages []Message
+}
+
+// StepResponse holds messages sent to nodes of other partitions during
+// the step, and the time of the earliest pending delivery, -1 if none.
+type StepResponse struct {
+	Messages []Message
+	Next     int64
+}
+
+// CollectRequest asks worker for the recorded propagation.
+type CollectRequest struct {
+	Part int // expected partition of the worker
+}
+
+// CollectResponse holds all message sendings recorded by the worker.
+type CollectResponse struct {
+	Entries []Entry
+}
+
+// Worker simulates naive gossip propagation (each node floods message to
+// all its peers once) in its partition of the graph. Time is simulated,
+// so workers advance in lockstep, driven by Coordinator.
+type Worker struct {
+	part *Part
+	own  map[int]bool
+	adj  map[int][]Edge // owned node -> incident edges
+
+	mx      sync.Mutex
+	latency int64
+	seen    map[int]bool
+	queue   deliveries
+	entries []Entry
+}
+
+// NewWorker creates worker simulating the given partition.
+func NewWorker(part *Part) *Worker {
+	w := &Worker{
+		part: part,
+		own:  make(map[int]bool, len(part.Owned)),
+		adj:  make(map[int][]Edge, len(part.Owned)),
+	}
+	for _, node := range part.Owned {
+		w.own[node] = true
+	}
+	for _, e := range part.Edges {
+		if w.own[e.From] {
+			w.adj[e.From] = append(w.adj[e.From], e)
+		}
+		if w.own[e.To] {
+			w.adj[e.To] = append(w.adj[e.To], e)
+		}
+	}
+	return w
+}
+
+// Start resets worker state and starts propagation from the sender, if
+// worker owns it.
+func (w *Worker) Start(ctx context.Context, req *StartRequest) (*StartResponse, error) {
+	if req.Part != w.part.Index {
+		return nil, fmt.Errorf("worker simulates partition %d, not %d", w.part.Index, req.Part)
+	}
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	w.latency = req.Latency
+	w.seen = make(map[int]bool)
+	w.queue = nil
+	w.entries = nil
+	var out []Message
+	owned := w.own[req.Sender]
+	if owned {
+		w.seen[req.Sender] = true
+		out = w.forward(req.Sender, -1, 0, req.TTL)
+	}
+	return &StartResponse{
+		Owned:    owned,
+		Nodes:    w.part.Nodes,
+		Links:    w.part.Links,
+		Checksum: w.part.Checksum,
+		Messages: out,
+		Next:     w.next(),
+	}, nil
+}
+
+// Step delivers incoming messages and processes deliveries happening
+// before req.Until. Deliveries to other partitions are returned, as they
+// happen at least one link latency later, so never before req.Until.
+func (w *Worker) Step(ctx context.Context, req *StepRequest) (*StepResponse, error) {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	for _, m := range req.Messages {
+		heap.Push(&w.queue, m)
+	}
+	var out []Message
+	for len(w.queue) > 0 && w.queue[0].Ts < req.Until {
+		m := heap.Pop(&w.queue).(Message)
+		w.entries = append(w.entries, Entry{Ts: m.Ts, Link: m.Link, From: m.From, To: m.To})
+		if w.seen[m.To] {
+			continue
+		}
+		w.seen[m.To] = true
+		out = append(out, w.forward(m.To, m.From, m.Ts, m.TTL-1)...)
+	}
+	return &StepResponse{Messages: out, Next: w.next()}, nil
+}
+
+// Collect returns message sendings recorded since the start.
+func (w *Worker) Collect(ctx context.Context, req *CollectRequest) (*CollectResponse, error) {
+	if req.Part != w.part.Index {
+		return nil, fmt.Errorf("worker simulates partition %d, not %d", w.part.Index, req.Part)
+	}
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	return &CollectResponse{Entries: w.entries}, nil
+}
+
+// forward sends message received by node from peer at ts to the rest of
+// its peers, queueing local deliveries and returning the foreign ones.
+// Caller should hold the lock.
+func (w *Worker) forward(node, from int, ts int64, ttl int) []Message {
+	if ttl <= 0 {
+		return nil
+	}
+	var out []Message
+	for _, e := range w.adj[node] {
+		peer := e.To
+		if peer == node {
+			peer = e.From
+		}
+		if peer == from {
+			continue
+		}
+		m := Message{Link: e.Link, From: node, To: peer, Ts: ts + w.latency, TTL: ttl}
+		if w.own[peer] {
+			m.Part = w.part.Index
+			heap.Push(&w.queue, m)
+			continue
+		}
+		m.Part = w.part.Owners[peer]
+		out = append(out, m)
+	}
+	return out
+}
+
+// next returns time of the earliest pending local delivery, -1 if none.
+// Caller should hold the lock.
+func (w *Worker) next() int64 {
+	if len(w.queue) == 0 {
+		return -1
+	}
+	return w.queue[0].Ts
+}
+
+// deliveries implements heap.Interface for messages, ordered by arrival
+// time, so propagation doesn't depend on partitioning.
+type deliveries []Message
+
+func (d deliveries) Len() int { return len(d) }
+func (d deliveries) Less(i, j int) bool {
+	if d[i].Ts != d[j].Ts {
+		return d[i].Ts < d[j].Ts
+	}
+	if d[i].To != d[j].To {
+		return d[i].To < d[j].To
+	}
+	return d[i].From < d[j].From
+}
+func (d deliveries) Swap(i, j int)       { d[i], d[j] = d[j], d[i] }
+func (d *deliveries) Push(x interface{}) { *d = append(*d, x.(Message)) }
+func (d *deliveries) Pop() interface{} {
+	old := *d
+	m := old[len(old)-1]
+	*d = old[:len(old)-1]
+	return m
+}
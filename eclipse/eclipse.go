@@ -0,0 +1,267 @@
+// Package eclipse implements eclipse attack scenario, where target node's
+// peers are progressively replaced by attacker nodes withholding messages.
+// For each number of replaced peers and each peer rotation policy, message
+// is sent from the honest source node, and time it takes to reach the
+// target (or failure to reach it) is reported.
+package eclipse
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/stats"
+)
+
+// Policy defines peer rotation policy of the target node, which may help
+// it to escape the eclipse.
+type Policy int
+
+const (
+	// PolicyNone keeps peers as is, so replaced peers stay replaced.
+	PolicyNone Policy = iota
+	// PolicyAnchors protects the oldest peers (anchor connections), so
+	// they can't be replaced by attackers.
+	PolicyAnchors
+	// PolicyRotate periodically drops random peers and connects to random
+	// nodes instead.
+	PolicyRotate
+)
+
+// Policies lists all policies.
+var Policies = []Policy{PolicyNone, PolicyAnchors, PolicyRotate}
+
+// String implements Stringer interface for Policy.
+func (p Policy) String() string {
+	switch p {
+	case PolicyNone:
+		return "none"
+	case PolicyAnchors:
+		return "anchors"
+	case PolicyRotate:
+		return "rotate"
+	default:
+		return fmt.Sprintf("policy(%d)", int(p))
+	}
+}
+
+// ParsePolicy parses policy name ("none", "anchors" or "rotate").
+func ParsePolicy(name string) (Policy, error) {
+	for _, p := range Policies {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return PolicyNone, fmt.Errorf("unknown peer rotation policy '%s'", name)
+}
+
+// Params defines eclipse attack parameters.
+type Params struct {
+	Target  int // index of the eclipsed node
+	Source  int // index of the node sending message
+	Anchors int // number of protected peers for PolicyAnchors
+	Rotate  int // number of peers rotated for PolicyRotate
+	TTL     int
+	Size    int
+}
+
+// DefaultParams returns default attack parameters for the given target.
+func DefaultParams(target int) Params {
+	source := 0
+	if target == 0 {
+		source = 1
+	}
+	return Params{
+		Target:  target,
+		Source:  source,
+		Anchors: 2,
+		Rotate:  1,
+		TTL:     10,
+		Size:    400,
+	}
+}
+
+// Result holds the outcome of the single attack step.
+type Result struct {
+	Policy    Policy
+	Replaced  int // number of target's peers attackers tried to replace
+	Peers     int // number of target's peers
+	Delivered bool
+	Time      time.Duration // time-to-delivery for the target, if delivered
+}
+
+// String implements Stringer interface for Result.
+func (r Result) String() string {
+	if !r.Delivered {
+		return fmt.Sprintf("%s, %d/%d replaced: not delivered", r.Policy, r.Replaced, r.Peers)
+	}
+	return fmt.Sprintf("%s, %d/%d replaced: %v", r.Policy, r.Replaced, r.Peers, r.Time)
+}
+
+// SimulatorFunc creates simulator for the network, where attackers are the
+// indices of nodes withholding messages.
+type SimulatorFunc func(data *graph.Graph, attackers []int) propagation.Simulator
+
+// Run runs attack for each policy, replacing target's peers one by one,
+// and returns results of all steps.
+func Run(data *graph.Graph, params Params, policies []Policy, newSim SimulatorFunc) ([]Result, error) {
+	if err := params.validate(data); err != nil {
+		return nil, err
+	}
+	peers := len(peersOf(data, params.Target))
+
+	var results []Result
+	for _, policy := range policies {
+		for replaced := 0; replaced <= peers; replaced++ {
+			network, attackers := Network(data, params, policy, replaced)
+			sim := newSim(network, attackers)
+			plog := sim.SendMessage(params.Source, params.TTL, params.Size)
+			sim.Stop()
+
+			res := Result{
+				Policy:   policy,
+				Replaced: replaced,
+				Peers:    peers,
+			}
+			if ts, ok := stats.NewTree(plog).Time[params.Target]; ok {
+				res.Delivered = true
+				res.Time = time.Duration(ts) * time.Millisecond
+			}
+			results = append(results, res)
+		}
+	}
+	return results, nil
+}
+
+// Network returns copy of the network, where up to replaced target's peers
+// are replaced by attacker nodes, according to the policy, and indices of
+// attacker nodes. Attackers are connected to random honest nodes as well,
+// so they receive messages, but never relay them.
+func Network(data *graph.Graph, params Params, policy Policy, replaced int) (*graph.Graph, []int) {
+	nodes := data.Nodes()
+	target := nodes[params.Target].ID()
+	peers := peersOf(data, params.Target)
+
+	protected := 0
+	if policy == PolicyAnchors {
+		protected = params.Anchors
+	}
+	if replaced > len(peers)-protected {
+		replaced = len(peers) - protected
+	}
+	if replaced < 0 {
+		replaced = 0
+	}
+
+	// the oldest peers (anchors) go first, so replace the newest ones
+	dropped := make(map[string]bool)
+	var current []string // target's peers after replacement
+	for i, peer := range peers {
+		id := nodes[peer].ID()
+		if i >= len(peers)-replaced {
+			dropped[id] = true
+			continue
+		}
+		current = append(current, id)
+	}
+	attackerIDs := make([]string, replaced)
+	for i := range attackerIDs {
+		attackerIDs[i] = "attacker-" + strconv.Itoa(i)
+		current = append(current, attackerIDs[i])
+	}
+
+	// rotated peers are replaced by random nodes of the original network
+	var rotated []string
+	if policy == PolicyRotate {
+		connected := make(map[string]bool, len(current))
+		for _, id := range current {
+			connected[id] = true
+		}
+		rand.Shuffle(len(current), func(i, j int) { current[i], current[j] = current[j], current[i] })
+		n := params.Rotate
+		if n > len(current) {
+			n = len(current)
+		}
+		for _, id := range current[:n] {
+			dropped[id] = true
+		}
+		for tries := 0; len(rotated) < n && tries < 10*len(nodes); tries++ {
+			id := nodes[rand.Intn(len(nodes))].ID()
+			if id == target || connected[id] {
+				continue
+			}
+			connected[id] = true
+			rotated = append(rotated, id)
+		}
+	}
+
+	g := graph.NewGraph()
+	for _, n := range nodes {
+		g.AddNode(n)
+	}
+	for _, link := range data.Links() {
+		if (link.From() == target && dropped[link.To()]) || (link.To() == target && dropped[link.From()]) {
+			continue
+		}
+		g.AddLink(link.From(), link.To())
+	}
+	attackers := make([]int, 0, replaced)
+	for _, id := range attackerIDs {
+		g.AddNode(attacker(id))
+		attackers = append(attackers, g.NumNodes()-1)
+		if !dropped[id] {
+			g.AddLink(target, id)
+		}
+		if honest := rand.Intn(len(nodes)); honest != params.Target {
+			g.AddLink(id, nodes[honest].ID())
+		}
+	}
+	for _, id := range rotated {
+		g.AddLink(target, id)
+	}
+	return g, attackers
+}
+
+func (p Params) validate(data *graph.Graph) error {
+	n := data.NumNodes()
+	if p.Target < 0 || p.Target >= n {
+		return fmt.Errorf("target node %d not found", p.Target)
+	}
+	if p.Source < 0 || p.Source >= n {
+		return fmt.Errorf("source node %d not found", p.Source)
+	}
+	if p.Source == p.Target {
+		return fmt.Errorf("source and target should be different nodes")
+	}
+	return nil
+}
+
+// peersOf returns node's peers in order of links, i.e. the oldest first.
+func peersOf(data *graph.Graph, idx int) []int {
+	var peers []int
+	seen := make(map[int]bool)
+	for _, link := range data.Links() {
+		from, to := link.FromIdx(), link.ToIdx()
+		peer := -1
+		switch {
+		case from == idx && to != idx:
+			peer = to
+		case to == idx && from != idx:
+			peer = from
+		}
+		if peer >= 0 && !seen[peer] {
+			seen[peer] = true
+			peers = append(peers, peer)
+		}
+	}
+	return peers
+}
+
+// attacker implements string-only graph.Node for attacker nodes.
+type attacker string
+
+// ID implements graph.Node interface.
+func (a attacker) ID() string { return string(a) }
@@ -0,0 +1,73 @@
+package eclipse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/internal/testgraph"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/propagation/gossip"
+)
+
+func targetPeers(g *graph.Graph, target int) (honest, attackers int) {
+	for _, peer := range peersOf(g, target) {
+		if _, ok := g.Nodes()[peer].(attacker); ok {
+			attackers++
+		} else {
+			honest++
+		}
+	}
+	return honest, attackers
+}
+
+func TestNetwork(t *testing.T) {
+	data := testgraph.Ring(20)
+	params := DefaultParams(10)
+
+	g, attackers := Network(data, params, PolicyNone, 4)
+	if len(attackers) != 4 {
+		t.Fatalf("Expected 4 attackers, got %d", len(attackers))
+	}
+	if honest, evil := targetPeers(g, 10); honest != 0 || evil != 4 {
+		t.Fatalf("Expected target to be fully eclipsed, got %d honest and %d attacker peers", honest, evil)
+	}
+
+	g, attackers = Network(data, params, PolicyAnchors, 4)
+	if len(attackers) != 2 {
+		t.Fatalf("Expected 2 attackers with 2 anchors, got %d", len(attackers))
+	}
+	if honest, evil := targetPeers(g, 10); honest != 2 || evil != 2 {
+		t.Fatalf("Expected 2 honest and 2 attacker peers, got %d and %d", honest, evil)
+	}
+
+	g, _ = Network(data, params, PolicyRotate, 4)
+	if honest, evil := targetPeers(g, 10); honest != 1 || evil != 3 {
+		t.Fatalf("Expected 1 honest and 3 attacker peers after rotation, got %d and %d", honest, evil)
+	}
+}
+
+func TestRun(t *testing.T) {
+	newSim := func(data *graph.Graph, attackers []int) propagation.Simulator {
+		return gossip.NewSimulator(data, 4, time.Millisecond, gossip.WithWithholdingNodes(attackers...))
+	}
+	params := DefaultParams(10)
+	results, err := Run(testgraph.Ring(20), params, []Policy{PolicyNone, PolicyAnchors}, newSim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 10 {
+		t.Fatalf("Expected 10 results, got %d", len(results))
+	}
+	for _, res := range results {
+		eclipsed := res.Policy == PolicyNone && res.Replaced == res.Peers
+		if res.Delivered == eclipsed {
+			t.Fatalf("Unexpected result: %v", res)
+		}
+	}
+
+	params.Source = params.Target
+	if _, err := Run(testgraph.Ring(20), params, Policies, newSim); err == nil {
+		t.Fatal("Expected error for the same source and target")
+	}
+}
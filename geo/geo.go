@@ -0,0 +1,135 @@
+// Package geo derives link latencies from geographical coordinates of
+// nodes, so globe-scale networks get realistic propagation timings
+// without manual link weights.
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/divan/graphx/graph"
+)
+
+// earthRadius is the mean Earth radius, in km.
+const earthRadius = 6371.0
+
+// Coord represents geographical coordinates, in degrees.
+type Coord struct {
+	Lat, Lon float64
+}
+
+// Node is implemented by graph nodes carrying coordinates.
+type Node interface {
+	graph.Node
+	Coord() Coord
+}
+
+// Distance returns great-circle distance between two points, in km.
+func Distance(a, b Coord) float64 {
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	lat1, lat2 := rad(a.Lat), rad(b.Lat)
+	dLat, dLon := lat2-lat1, rad(b.Lon-a.Lon)
+
+	// haversine formula
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// LatencyParams defines how distance is converted into latency.
+type LatencyParams struct {
+	Base        time.Duration // constant part, i.e. last mile and processing
+	Speed       float64       // signal propagation speed, in km/s
+	RouteFactor float64       // ratio of the real route length to great-circle distance
+	Jitter      time.Duration // max random deviation, applied uniformly
+}
+
+// DefaultLatencyParams returns parameters for light in fiber, with routes
+// being 1.5 times longer than great-circle distance.
+func DefaultLatencyParams() LatencyParams {
+	return LatencyParams{
+		Base:        2 * time.Millisecond,
+		Speed:       200000,
+		RouteFactor: 1.5,
+		Jitter:      5 * time.Millisecond,
+	}
+}
+
+// Latency returns function calculating one-way latency between nodes
+// with given indices, out of coordinates ordered by node index.
+func Latency(coords []Coord, params LatencyParams) func(from, to int) time.Duration {
+	return func(from, to int) time.Duration {
+		d := Distance(coords[from], coords[to]) * params.RouteFactor
+		latency := params.Base + time.Duration(d/params.Speed*float64(time.Second))
+		if params.Jitter > 0 {
+			latency += time.Duration((rand.Float64()*2 - 1) * float64(params.Jitter))
+		}
+		if latency < 0 {
+			latency = 0
+		}
+		return latency
+	}
+}
+
+// NodesCoords returns coordinates of graph nodes ordered by node index.
+// Coordinates are taken from nodes implementing Node interface, or from
+// coords map by node ID.
+func NodesCoords(data *graph.Graph, coords map[string]Coord) ([]Coord, error) {
+	nodes := data.Nodes()
+	ret := make([]Coord, len(nodes))
+	for i, node := range nodes {
+		if n, ok := node.(Node); ok {
+			ret[i] = n.Coord()
+			continue
+		}
+		c, ok := coords[node.ID()]
+		if !ok {
+			return nil, fmt.Errorf("no coordinates for node '%s'", node.ID())
+		}
+		ret[i] = c
+	}
+	return ret, nil
+}
+
+// ReadCoords reads nodes coordinates from the network graph in D3 JSON
+// format, where nodes have "lat" and "lon" (or "lng", "latitude" and
+// "longitude") fields.
+func ReadCoords(r io.Reader) (map[string]Coord, error) {
+	var data struct {
+		Nodes []struct {
+			ID        string   `json:"id"`
+			Lat       *float64 `json:"lat"`
+			Lon       *float64 `json:"lon"`
+			Lng       *float64 `json:"lng"`
+			Latitude  *float64 `json:"latitude"`
+			Longitude *float64 `json:"longitude"`
+		} `json:"nodes"`
+	}
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, fmt.Errorf("parse network: %v", err)
+	}
+
+	first := func(values ...*float64) (float64, bool) {
+		for _, v := range values {
+			if v != nil {
+				return *v, true
+			}
+		}
+		return 0, false
+	}
+
+	coords := make(map[string]Coord, len(data.Nodes))
+	for _, node := range data.Nodes {
+		lat, okLat := first(node.Lat, node.Latitude)
+		lon, okLon := first(node.Lon, node.Lng, node.Longitude)
+		if !okLat || !okLon {
+			continue
+		}
+		coords[node.ID] = Coord{Lat: lat, Lon: lon}
+	}
+	return coords, nil
+}
@@ -0,0 +1,53 @@
+package geo
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDistance(t *testing.T) {
+	london := Coord{Lat: 51.5074, Lon: -0.1278}
+	paris := Coord{Lat: 48.8566, Lon: 2.3522}
+	if d := Distance(london, paris); math.Abs(d-343.5) > 1 {
+		t.Fatalf("Expected London-Paris distance ~343.5km, got %.1f", d)
+	}
+	if d := Distance(paris, paris); d != 0 {
+		t.Fatalf("Expected zero distance, got %v", d)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	coords := []Coord{{0, 0}, {0, 180}}
+	params := DefaultLatencyParams()
+	params.Jitter = 0
+	latency := Latency(coords, params)
+
+	// half of equator, ~20015km * 1.5 at 200000km/s
+	expected := params.Base + 150*time.Millisecond
+	if got := latency(0, 1); got < expected-time.Millisecond || got > expected+time.Millisecond {
+		t.Fatalf("Expected latency ~%v, got %v", expected, got)
+	}
+	if got := latency(0, 0); got != params.Base {
+		t.Fatalf("Expected latency %v, got %v", params.Base, got)
+	}
+}
+
+func TestReadCoords(t *testing.T) {
+	network := `{"nodes": [
+		{"id": "a", "lat": 1, "lon": 2},
+		{"id": "b", "latitude": 3, "lng": 4},
+		{"id": "c"}
+	], "links": []}`
+	coords, err := ReadCoords(strings.NewReader(network))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(coords) != 2 {
+		t.Fatalf("Expected 2 nodes with coordinates, got %d", len(coords))
+	}
+	if coords["a"] != (Coord{1, 2}) || coords["b"] != (Coord{3, 4}) {
+		t.Fatalf("Wrong coordinates: %v", coords)
+	}
+}
@@ -0,0 +1,19 @@
+module github.com/divan/simulation
+
+go 1.26.0
+
+require (
+	github.com/ethereum/go-ethereum v1.9.15
+	github.com/gonum/floats v0.0.0-20181209220543-c233463c7e82
+	github.com/gonum/stat v0.0.0-20181125101827-41a0da705a5b
+	github.com/mattn/go-sqlite3 v1.14.52
+	github.com/nats-io/nats.go v1.54.0
+	github.com/segmentio/kafka-go v0.4.51
+	github.com/status-im/status-go v0.62.0
+	github.com/status-im/whisper v1.6.6
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	go.uber.org/zap v1.28.0
+	google.golang.org/grpc v1.84.0
+	google.golang.org/protobuf v1.36.11
+	gopkg.in/yaml.v2 v2.2.2
+)
@@ -0,0 +1,41 @@
+// Package testgraph provides small network graphs for tests of simulators
+// and scenarios.
+package testgraph
+
+import (
+	"strconv"
+
+	"github.com/divan/graphx/graph"
+)
+
+// Node implements string-only graph.Node.
+type Node string
+
+// ID implements graph.Node.
+func (n Node) ID() string { return string(n) }
+
+// Ring returns ring network where each node is connected to two
+// neighbours on each side.
+func Ring(n int) *graph.Graph {
+	g := graph.NewGraph()
+	for i := 0; i < n; i++ {
+		g.AddNode(Node(strconv.Itoa(i)))
+	}
+	for i := 0; i < n; i++ {
+		g.AddLink(strconv.Itoa(i), strconv.Itoa((i+1)%n))
+		g.AddLink(strconv.Itoa(i), strconv.Itoa((i+2)%n))
+	}
+	return g
+}
+
+// Line returns network of n nodes connected one after another.
+func Line(n int) *graph.Graph {
+	g := graph.NewGraph()
+	for i := 0; i < n; i++ {
+		g.AddNode(Node(strconv.Itoa(i)))
+	}
+	for i := 1; i < n; i++ {
+		g.AddLink(strconv.Itoa(i-1), strconv.Itoa(i))
+	}
+	return g
+}
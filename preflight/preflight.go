@@ -0,0 +1,204 @@
+// Package preflight validates network graphs before simulation. Graphs
+// with problems like isolated nodes or disconnected components are still
+// simulated fine, but unreachable nodes silently show up as low coverage,
+// so it's better to catch them upfront.
+package preflight
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/divan/graphx/graph"
+)
+
+// maxListed is the number of problematic nodes or links listed in report.
+const maxListed = 10
+
+// Report describes problems found in the graph.
+type Report struct {
+	Nodes          int
+	DuplicateLinks [][2]int // node indices of links met more than once
+	SelfLoops      []int    // nodes linked to themselves
+	Isolated       []int    // nodes without links
+	Components     [][]int  // connected components, largest first
+}
+
+// Check validates graph and returns report of found problems. For
+// directed graphs links in opposite directions are not duplicates.
+// Components are found with links treated as undirected.
+func Check(g *graph.Graph, directed bool) *Report {
+	r := &Report{Nodes: g.NumNodes()}
+
+	seen := make(map[[2]int]bool, g.NumLinks())
+	degree := make([]int, g.NumNodes())
+	for _, link := range g.Links() {
+		from, to := link.FromIdx(), link.ToIdx()
+		if from == to {
+			r.SelfLoops = append(r.SelfLoops, from)
+			continue
+		}
+		key := linkKey(from, to, directed)
+		if seen[key] {
+			r.DuplicateLinks = append(r.DuplicateLinks, [2]int{from, to})
+			continue
+		}
+		seen[key] = true
+		degree[from]++
+		degree[to]++
+	}
+	for i, d := range degree {
+		if d == 0 {
+			r.Isolated = append(r.Isolated, i)
+		}
+	}
+	r.Components = Components(g)
+	return r
+}
+
+// OK reports whether graph has no problems.
+func (r *Report) OK() bool {
+	return len(r.DuplicateLinks) == 0 && len(r.SelfLoops) == 0 &&
+		len(r.Isolated) == 0 && len(r.Components) <= 1
+}
+
+// Unreachable returns the number of nodes outside of the largest component.
+func (r *Report) Unreachable() int {
+	if len(r.Components) == 0 {
+		return 0
+	}
+	return r.Nodes - len(r.Components[0])
+}
+
+// String implements Stringer interface for Report.
+func (r *Report) String() string {
+	if r.OK() {
+		return "no problems found"
+	}
+	var lines []string
+	if n := len(r.DuplicateLinks); n > 0 {
+		var links []string
+		for _, l := range r.DuplicateLinks[:min(n, maxListed)] {
+			links = append(links, fmt.Sprintf("%d-%d", l[0], l[1]))
+		}
+		lines = append(lines, fmt.Sprintf("duplicate links: %d (%s)", n, listed(links, n)))
+	}
+	if n := len(r.SelfLoops); n > 0 {
+		lines = append(lines, fmt.Sprintf("self-loops: %d (nodes %s)", n, listed(ints(r.SelfLoops), n)))
+	}
+	if n := len(r.Isolated); n > 0 {
+		lines = append(lines, fmt.Sprintf("isolated nodes: %d (%s)", n, listed(ints(r.Isolated), n)))
+	}
+	if n := len(r.Components); n > 1 {
+		var sizes []string
+		for _, c := range r.Components[:min(n, maxListed)] {
+			sizes = append(sizes, fmt.Sprint(len(c)))
+		}
+		lines = append(lines, fmt.Sprintf("disconnected components: %d (sizes %s), %d nodes unreachable from the largest one",
+			n, listed(sizes, n), r.Unreachable()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Fix returns the copy of graph with self-loops and duplicate links removed,
+// and components connected to the largest one. Each smaller component gets
+// a single link from its first node to one of the largest component nodes,
+// picked in turn, so added links don't create a hub.
+func Fix(g *graph.Graph, directed bool) *graph.Graph {
+	nodes := g.Nodes()
+	ret := graph.NewGraph()
+	for _, node := range nodes {
+		ret.AddNode(node)
+	}
+	seen := make(map[[2]int]bool, g.NumLinks())
+	for _, link := range g.Links() {
+		from, to := link.FromIdx(), link.ToIdx()
+		key := linkKey(from, to, directed)
+		if from == to || seen[key] {
+			continue
+		}
+		seen[key] = true
+		ret.AddLink(nodes[from].ID(), nodes[to].ID())
+	}
+
+	comps := Components(ret)
+	if len(comps) <= 1 {
+		return ret
+	}
+	largest := comps[0]
+	for i, comp := range comps[1:] {
+		peer := largest[i%len(largest)]
+		ret.AddLink(nodes[comp[0]].ID(), nodes[peer].ID())
+		if directed {
+			ret.AddLink(nodes[peer].ID(), nodes[comp[0]].ID())
+		}
+	}
+	return ret
+}
+
+// Components returns connected components of graph, treating links as
+// undirected, sorted by size with the largest first. Nodes of each
+// component are sorted by index.
+func Components(g *graph.Graph) [][]int {
+	n := g.NumNodes()
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	for _, link := range g.Links() {
+		a, b := find(link.FromIdx()), find(link.ToIdx())
+		if a != b {
+			parent[a] = b
+		}
+	}
+
+	byRoot := make(map[int][]int)
+	var roots []int
+	for i := 0; i < n; i++ {
+		root := find(i)
+		if _, ok := byRoot[root]; !ok {
+			roots = append(roots, root)
+		}
+		byRoot[root] = append(byRoot[root], i)
+	}
+	ret := make([][]int, 0, len(roots))
+	for _, root := range roots {
+		ret = append(ret, byRoot[root])
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return len(ret[i]) > len(ret[j]) })
+	return ret
+}
+
+// linkKey returns key identifying link between nodes, regardless of its
+// direction for undirected graphs.
+func linkKey(from, to int, directed bool) [2]int {
+	if !directed && to < from {
+		from, to = to, from
+	}
+	return [2]int{from, to}
+}
+
+func ints(values []int) []string {
+	var ret []string
+	for _, v := range values[:min(len(values), maxListed)] {
+		ret = append(ret, fmt.Sprint(v))
+	}
+	return ret
+}
+
+// listed joins first listed items of n, marking the rest as omitted.
+func listed(items []string, n int) string {
+	s := strings.Join(items, ", ")
+	if n > len(items) {
+		s += ", ..."
+	}
+	return s
+}
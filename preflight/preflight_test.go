@@ -0,0 +1,74 @@
+package preflight
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/divan/graphx/graph"
+)
+
+// node implements string-only graph.Node
+type node string
+
+func (n node) ID() string { return string(n) }
+
+func newGraph(n int, links ...[2]int) *graph.Graph {
+	g := graph.NewGraph()
+	for i := 0; i < n; i++ {
+		g.AddNode(node(strconv.Itoa(i)))
+	}
+	for _, l := range links {
+		g.AddLink(strconv.Itoa(l[0]), strconv.Itoa(l[1]))
+	}
+	return g
+}
+
+func TestCheck(t *testing.T) {
+	// 0-1-2 triangle, 3-4 pair, 5 isolated, 6 with self-loop
+	g := newGraph(7, [2]int{0, 1}, [2]int{1, 2}, [2]int{2, 0}, [2]int{1, 0},
+		[2]int{3, 4}, [2]int{6, 6})
+
+	r := Check(g, false)
+	if r.OK() {
+		t.Fatal("Expected problems to be found")
+	}
+	if !reflect.DeepEqual(r.DuplicateLinks, [][2]int{{1, 0}}) {
+		t.Fatalf("Expected duplicate link 1-0, got %v", r.DuplicateLinks)
+	}
+	if !reflect.DeepEqual(r.SelfLoops, []int{6}) {
+		t.Fatalf("Expected self-loop of node 6, got %v", r.SelfLoops)
+	}
+	if !reflect.DeepEqual(r.Isolated, []int{5, 6}) {
+		t.Fatalf("Expected isolated nodes 5 and 6, got %v", r.Isolated)
+	}
+	expected := [][]int{{0, 1, 2}, {3, 4}, {5}, {6}}
+	if !reflect.DeepEqual(r.Components, expected) {
+		t.Fatalf("Expected components %v, got %v", expected, r.Components)
+	}
+	if r.Unreachable() != 4 {
+		t.Fatalf("Expected 4 unreachable nodes, got %d", r.Unreachable())
+	}
+
+	// opposite directions are different links of directed graph
+	if r := Check(g, true); len(r.DuplicateLinks) != 0 {
+		t.Fatalf("Expected no duplicates in directed graph, got %v", r.DuplicateLinks)
+	}
+	if r := Check(newGraph(2, [2]int{0, 1}), false); !r.OK() {
+		t.Fatalf("Expected no problems, got %s", r)
+	}
+}
+
+func TestFix(t *testing.T) {
+	g := newGraph(7, [2]int{0, 1}, [2]int{1, 2}, [2]int{2, 0}, [2]int{1, 0},
+		[2]int{3, 4}, [2]int{6, 6})
+
+	fixed := Fix(g, false)
+	if r := Check(fixed, false); !r.OK() {
+		t.Fatalf("Expected fixed graph to have no problems, got %s", r)
+	}
+	// 3 unique links plus 3-4, and one link for each of 3 components
+	if fixed.NumNodes() != 7 || fixed.NumLinks() != 7 {
+		t.Fatalf("Expected 7 nodes and 7 links, got %d and %d", fixed.NumNodes(), fixed.NumLinks())
+	}
+}
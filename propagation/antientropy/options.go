@@ -0,0 +1,78 @@
+package antientropy
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// Option represents simulator option.
+type Option func(*Simulator)
+
+// Defaults for anti-entropy sync.
+const (
+	DefaultInterval = time.Second
+	DefaultLatency  = 10 * time.Millisecond
+)
+
+// WithInterval sets how often each node syncs with a random peer.
+func WithInterval(d time.Duration) Option {
+	return func(s *Simulator) {
+		if d > 0 {
+			s.interval = d
+		}
+	}
+}
+
+// WithPushPull makes nodes exchange messages both ways on sync: node
+// having the message pushes it to the peer lacking it, in addition to
+// pulling messages it lacks.
+func WithPushPull() Option {
+	return func(s *Simulator) {
+		s.pushPull = true
+	}
+}
+
+// WithLatency sets per link latency function, replacing DefaultLatency.
+func WithLatency(fn func(from, to int) time.Duration) Option {
+	return func(s *Simulator) {
+		s.latency = fn
+	}
+}
+
+// WithMaxDuration bounds propagation of each message sent with SendMessage
+// by the given duration, so syncs stop once it's reached.
+func WithMaxDuration(d time.Duration) Option {
+	return func(s *Simulator) {
+		s.maxDuration = d
+	}
+}
+
+// WithSeed seeds simulator's own random source used for picking peers and
+// sync phases, so runs are reproducible.
+func WithSeed(seed int64) Option {
+	return func(s *Simulator) {
+		s.rand = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithProgress sets the function to report simulation progress to.
+func WithProgress(fn propagation.ProgressFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.Progress) {}
+		}
+		s.progress = fn
+	}
+}
+
+// WithEvents sets the function to report each message delivery to.
+func WithEvents(fn propagation.EventFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.LogEntry) {}
+		}
+		s.events = fn
+	}
+}
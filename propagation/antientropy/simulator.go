@@ -0,0 +1,228 @@
+// Package antientropy implements pull-based anti-entropy simulator, where
+// nodes don't forward messages as they get them, but periodically sync
+// with a random peer instead: node sends digest of messages it has, and
+// the peer replies with messages missing from the digest. With push-pull
+// sync, node also pushes messages the peer lacks. Dissemination is
+// eventually consistent: it's slower than push gossip, but costs only
+// a digest per sync in addition to a single delivery per node.
+//
+// Syncs don't need real time, so simulation runs on virtual clock, and
+// results depend only on the seed.
+package antientropy
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/propagation/gossip"
+)
+
+// digestSize is the size of digest of a single message, its hash.
+const digestSize = 32
+
+// Simulator is responsible for running anti-entropy simulation.
+type Simulator struct {
+	data        *graph.Graph
+	peers       map[int][]int
+	interval    time.Duration
+	pushPull    bool
+	latency     func(from, to int) time.Duration
+	maxDuration time.Duration // zero if syncs are bounded by ttl rounds only
+	progress    propagation.ProgressFunc
+	events      propagation.EventFunc
+
+	randMx sync.Mutex
+	rand   *rand.Rand
+}
+
+// NewSimulator initializes new simulator for the given graph data.
+func NewSimulator(data *graph.Graph, opts ...Option) *Simulator {
+	sim := &Simulator{
+		data:     data,
+		peers:    gossip.PrecalculatePeers(data),
+		interval: DefaultInterval,
+		latency:  func(int, int) time.Duration { return DefaultLatency },
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		progress: func(propagation.Progress) {},
+		events:   func(propagation.LogEntry) {},
+	}
+	for _, opt := range opts {
+		opt(sim)
+	}
+	return sim
+}
+
+// Stop stops simulator. Implements propagation.Simulator.
+func (s *Simulator) Stop() error {
+	return nil
+}
+
+// SendMessage puts message of the given size on node and tracks its
+// dissemination by anti-entropy syncs. Implements propagation.Simulator.
+// Each node syncs at random phase within the interval, for up to ttl
+// rounds. Simulation stops once all nodes reachable from the sender have
+// the message. Every payload delivery is reported to the log, and digests
+// count as control traffic. It's safe to call SendMessage multiple times,
+// including concurrently.
+func (s *Simulator) SendMessage(startNodeIdx, ttl, size int) *propagation.Log {
+	s.randMx.Lock()
+	seed := s.rand.Int63()
+	s.randMx.Unlock()
+	r := &run{
+		sim:       s,
+		id:        fmt.Sprintf("%016x", uint64(seed)),
+		start:     time.Now(),
+		size:      size,
+		rand:      rand.New(rand.NewSource(seed)),
+		have:      map[int]bool{startNodeIdx: true},
+		reachable: s.reachable(startNodeIdx),
+		until:     time.Duration(ttl) * s.interval,
+	}
+	if s.maxDuration > 0 && s.maxDuration < r.until {
+		r.until = s.maxDuration
+	}
+	for node := 0; node < s.data.NumNodes(); node++ {
+		phase := time.Duration(r.rand.Int63n(int64(s.interval)))
+		r.schedule(event{at: phase, kind: kindSync, node: node})
+	}
+	for r.queue.Len() > 0 && len(r.have) < r.reachable {
+		r.handle(heap.Pop(&r.queue).(event))
+	}
+
+	plog := r.entries.Log(s.data)
+	r.entries.Release()
+	plog.Traffic = &r.traffic
+	return plog
+}
+
+// reachable returns the number of nodes reachable from node, including it.
+func (s *Simulator) reachable(node int) int {
+	seen := map[int]bool{node: true}
+	queue := []int{node}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, peer := range s.peers[n] {
+			if !seen[peer] {
+				seen[peer] = true
+				queue = append(queue, peer)
+			}
+		}
+	}
+	return len(seen)
+}
+
+// eventKind defines the type of simulation event.
+type eventKind int
+
+const (
+	kindSync    eventKind = iota // node starts sync with random peer
+	kindDigest                   // node got digest of the peer
+	kindReply                    // node got digest reply of the peer, push-pull only
+	kindDeliver                  // node got message from the peer
+)
+
+// event is the single step of the simulation, happening at virtual time.
+type event struct {
+	at   time.Duration
+	kind eventKind
+	node int
+	peer int
+	has  bool // peer had the message when it sent digest
+}
+
+// run holds state of the single message dissemination.
+type run struct {
+	sim       *Simulator
+	id        string // message identifier reported in log entries
+	start     time.Time
+	size      int
+	rand      *rand.Rand
+	queue     events
+	have      map[int]bool
+	reachable int           // nodes to reach before stopping
+	until     time.Duration // syncs stop after that
+	traffic   propagation.Traffic
+	entries   propagation.LogEntries
+}
+
+// schedule adds event to the queue, unless it's beyond the time bound.
+func (r *run) schedule(e event) {
+	if e.at > r.until {
+		return
+	}
+	heap.Push(&r.queue, e)
+}
+
+// send schedules event of the given kind on peer, sent by node at time at.
+func (r *run) send(at time.Duration, kind eventKind, node, peer int) {
+	if kind == kindDeliver {
+		r.traffic.AddPayload(r.size)
+	} else {
+		r.traffic.AddControl(digestSize)
+	}
+	r.schedule(event{
+		at:   at + r.sim.latency(node, peer),
+		kind: kind,
+		node: peer,
+		peer: node,
+		has:  r.have[node],
+	})
+}
+
+// handle processes single event.
+func (r *run) handle(e event) {
+	switch e.kind {
+	case kindSync:
+		r.schedule(event{at: e.at + r.sim.interval, kind: kindSync, node: e.node})
+		peers := r.sim.peers[e.node]
+		if len(peers) == 0 {
+			return
+		}
+		r.send(e.at, kindDigest, e.node, peers[r.rand.Intn(len(peers))])
+	case kindDigest:
+		switch {
+		case r.have[e.node] && !e.has:
+			r.send(e.at, kindDeliver, e.node, e.peer)
+		case r.sim.pushPull && !r.have[e.node] && e.has:
+			r.send(e.at, kindReply, e.node, e.peer)
+		}
+	case kindReply:
+		if r.have[e.node] {
+			r.send(e.at, kindDeliver, e.node, e.peer)
+		}
+	case kindDeliver:
+		entry := propagation.NewLogEntry(r.start.Add(e.at), r.start, e.peer, e.node)
+		entry.Msg = r.id
+		r.entries.Add(*entry)
+		r.sim.events(*entry)
+		if r.have[e.node] {
+			return
+		}
+		r.have[e.node] = true
+		r.sim.progress(propagation.Progress{
+			Phase: propagation.PhaseCollect,
+			Done:  len(r.have),
+			Total: r.sim.data.NumNodes(),
+		})
+	}
+}
+
+// events implements heap.Interface for events, ordered by virtual time.
+type events []event
+
+func (e events) Len() int            { return len(e) }
+func (e events) Less(i, j int) bool  { return e[i].at < e[j].at }
+func (e events) Swap(i, j int)       { e[i], e[j] = e[j], e[i] }
+func (e *events) Push(x interface{}) { *e = append(*e, x.(event)) }
+func (e *events) Pop() interface{} {
+	old := *e
+	last := old[len(old)-1]
+	*e = old[:len(old)-1]
+	return last
+}
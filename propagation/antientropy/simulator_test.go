@@ -0,0 +1,83 @@
+package antientropy
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/divan/graphx/graph"
+)
+
+// node implements string-only graph.Node
+type node string
+
+func (n node) ID() string { return string(n) }
+
+// line returns network of n nodes connected one after another.
+func line(n int) *graph.Graph {
+	g := graph.NewGraph()
+	for i := 0; i < n; i++ {
+		g.AddNode(node(strconv.Itoa(i)))
+	}
+	for i := 1; i < n; i++ {
+		g.AddLink(strconv.Itoa(i-1), strconv.Itoa(i))
+	}
+	return g
+}
+
+// reached returns the number of nodes in log, including the sender.
+func reached(nodes [][]int) int {
+	ret := make(map[int]bool)
+	for _, n := range nodes {
+		for _, idx := range n {
+			ret[idx] = true
+		}
+	}
+	return len(ret)
+}
+
+func TestSendMessage(t *testing.T) {
+	data := line(5)
+	send := func() ([]int, int64, int64) {
+		sim := NewSimulator(data,
+			WithInterval(100*time.Millisecond),
+			WithLatency(func(int, int) time.Duration { return time.Millisecond }),
+			WithSeed(1),
+		)
+		defer sim.Stop()
+		plog := sim.SendMessage(0, 100, 400)
+		if got := reached(plog.Nodes); got != 5 {
+			t.Fatalf("Expected all 5 nodes reached, got %d", got)
+		}
+		return plog.Timestamps, plog.Traffic.PayloadMessages, plog.Traffic.ControlMessages
+	}
+
+	// each node pulls the message once, as syncs don't overlap
+	timestamps, payload, control := send()
+	if payload != 4 {
+		t.Fatalf("Expected 4 payload messages, got %d", payload)
+	}
+	if control == 0 {
+		t.Fatal("Expected digests in control traffic")
+	}
+	if again, _, _ := send(); !reflect.DeepEqual(again, timestamps) {
+		t.Fatalf("Expected the same syncs with the same seed, got %v vs %v", again, timestamps)
+	}
+}
+
+func TestRounds(t *testing.T) {
+	sim := NewSimulator(line(10), WithPushPull(), WithSeed(1))
+	defer sim.Stop()
+
+	// message can't get further than one hop per round
+	plog := sim.SendMessage(0, 2, 400)
+	if got := reached(plog.Nodes); got > 3 {
+		t.Fatalf("Expected at most 3 nodes reached in 2 rounds, got %d", got)
+	}
+	for _, ts := range plog.Timestamps {
+		if ts > 2000 {
+			t.Fatalf("Expected syncs to stop after 2 rounds, got delivery at %dms", ts)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package bitswap
+
+import (
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// Option represents simulator option.
+type Option func(*Simulator)
+
+// Defaults for chunked propagation, close to IPFS Bitswap ones.
+const (
+	DefaultChunkSize    = 256 * 1024 // bytes
+	DefaultWantListSize = 32         // outstanding requests per peer
+	DefaultUplink       = 1250000    // bytes per second, 10 Mbit/s
+	DefaultLatency      = 10 * time.Millisecond
+)
+
+// WithChunkSize sets the size of chunks messages are split into.
+func WithChunkSize(size int) Option {
+	return func(s *Simulator) {
+		if size > 0 {
+			s.chunkSize = size
+		}
+	}
+}
+
+// WithWantListSize sets the maximum number of chunks node requests from
+// a single peer at once. Node spreads the rest of its want-list over other
+// peers having the chunks, or waits for outstanding requests to complete.
+func WithWantListSize(n int) Option {
+	return func(s *Simulator) {
+		if n > 0 {
+			s.wantListSize = n
+		}
+	}
+}
+
+// WithUplink sets uplink bandwidth of each node, in bytes per second, so
+// sending a chunk takes chunk size / bandwidth, and chunks sent by the
+// same node wait for each other. Zero value means unlimited bandwidth.
+func WithUplink(fn func(node int) float64) Option {
+	return func(s *Simulator) {
+		for i := range s.uplink {
+			s.uplink[i] = fn(i)
+		}
+	}
+}
+
+// WithLatency sets per link latency function, replacing DefaultLatency.
+func WithLatency(fn func(from, to int) time.Duration) Option {
+	return func(s *Simulator) {
+		s.latency = fn
+	}
+}
+
+// WithMaxDuration bounds propagation of each message sent with SendMessage
+// by the given duration, so log contains nodes completed so far.
+func WithMaxDuration(d time.Duration) Option {
+	return func(s *Simulator) {
+		s.maxDuration = d
+	}
+}
+
+// WithProgress sets the function to report simulation progress to.
+func WithProgress(fn propagation.ProgressFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.Progress) {}
+		}
+		s.progress = fn
+	}
+}
+
+// WithEvents sets the function to report each node completing the
+// message to, as it happens during simulation.
+func WithEvents(fn propagation.EventFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.LogEntry) {}
+		}
+		s.events = fn
+	}
+}
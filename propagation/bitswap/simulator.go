@@ -0,0 +1,318 @@
+// Package bitswap implements chunked propagation simulator, modeling
+// dissemination of large payloads in IPFS Bitswap or Swarm style. Message
+// is split into chunks, nodes announce chunks they have to their peers
+// (HAVE), and request missing chunks with want-lists (WANT), spreading
+// requests over multiple peers having them. Node is reached by the message
+// once it has all the chunks.
+package bitswap
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/propagation/gossip"
+)
+
+// chunkIDSize is the size of chunk ID (hash) in HAVE and WANT messages.
+const chunkIDSize = 32
+
+// Simulator is responsible for running chunked propagation simulation.
+type Simulator struct {
+	data         *graph.Graph
+	peers        map[int][]int
+	chunkSize    int
+	wantListSize int
+	uplink       []float64 // bytes per second, zero if unlimited
+	latency      func(from, to int) time.Duration
+	maxDuration  time.Duration // zero if propagation is unbounded
+	progress     propagation.ProgressFunc
+	events       propagation.EventFunc
+}
+
+// NewSimulator initializes new simulator for the given graph data.
+func NewSimulator(data *graph.Graph, opts ...Option) *Simulator {
+	sim := &Simulator{
+		data:         data,
+		peers:        gossip.PrecalculatePeers(data),
+		chunkSize:    DefaultChunkSize,
+		wantListSize: DefaultWantListSize,
+		uplink:       make([]float64, data.NumNodes()),
+		latency:      func(int, int) time.Duration { return DefaultLatency },
+		progress:     func(propagation.Progress) {},
+		events:       func(propagation.LogEntry) {},
+	}
+	for i := range sim.uplink {
+		sim.uplink[i] = DefaultUplink
+	}
+	for _, opt := range opts {
+		opt(sim)
+	}
+	return sim
+}
+
+// Stop stops simulator. Implements propagation.Simulator.
+func (s *Simulator) Stop() error {
+	return nil
+}
+
+// SendMessage sends single message of the given size from node and tracks
+// its propagation. Implements propagation.Simulator. Chunks are pulled on
+// demand by all reachable nodes, so ttl is ignored. Log has one entry per
+// node, with the link delivering its last chunk. It's safe to call
+// SendMessage multiple times, including concurrently.
+func (s *Simulator) SendMessage(startNodeIdx, ttl, size int) *propagation.Log {
+	r := s.newRun(size)
+	r.mx.Lock()
+	for chunk := range r.have[startNodeIdx] {
+		r.have[startNodeIdx][chunk] = true
+	}
+	r.count[startNodeIdx] = r.chunks
+	r.announce(startNodeIdx, allChunks(r.chunks))
+	r.mx.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	var deadline <-chan time.Time
+	if s.maxDuration > 0 {
+		timer := time.NewTimer(s.maxDuration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	select {
+	case <-done:
+	case <-deadline:
+	}
+
+	// in-flight messages are dropped once run is expired
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.expired = true
+	plog := r.entries.Log(s.data)
+	r.entries.Release()
+	traffic := r.traffic
+	plog.Traffic = &traffic
+	return plog
+}
+
+// run holds state of the single message propagation.
+type run struct {
+	sim       *Simulator
+	id        string // message identifier reported in log entries
+	start     time.Time
+	size      int
+	chunks    int
+	wg        sync.WaitGroup // in-flight messages
+	traffic   propagation.Traffic
+	mx        sync.Mutex
+	have      [][]bool                    // node -> chunk -> has it
+	count     []int                       // number of chunks node has
+	requested [][]bool                    // node -> chunk -> requested it
+	knows     map[gossip.LinkIndex][]bool // node -> peer -> chunks peer announced
+	pending   map[gossip.LinkIndex]int    // node -> peer -> outstanding requests
+	uplinkAt  []time.Time                 // time node's uplink gets free
+	entries   propagation.LogEntries
+	expired   bool // max duration is reached
+}
+
+func (s *Simulator) newRun(size int) *run {
+	n := s.data.NumNodes()
+	chunks := (size + s.chunkSize - 1) / s.chunkSize
+	if chunks == 0 {
+		chunks = 1
+	}
+	r := &run{
+		sim:       s,
+		id:        fmt.Sprintf("%016x", rand.Uint64()),
+		start:     time.Now(),
+		size:      size,
+		chunks:    chunks,
+		have:      make([][]bool, n),
+		count:     make([]int, n),
+		requested: make([][]bool, n),
+		knows:     make(map[gossip.LinkIndex][]bool),
+		pending:   make(map[gossip.LinkIndex]int),
+		uplinkAt:  make([]time.Time, n),
+	}
+	for i := 0; i < n; i++ {
+		r.have[i] = make([]bool, chunks)
+		r.requested[i] = make([]bool, chunks)
+	}
+	return r
+}
+
+// chunkBytes returns the size of the given chunk, the last one may be smaller.
+func (r *run) chunkBytes(chunk int) int {
+	if chunk < r.chunks-1 {
+		return r.sim.chunkSize
+	}
+	return r.size - (r.chunks-1)*r.sim.chunkSize
+}
+
+// announce sends HAVE message with chunks to node's peers which didn't
+// announce them. Caller should hold the lock.
+func (r *run) announce(node int, chunks []int) {
+	for _, peer := range r.sim.peers[node] {
+		var news []int
+		known := r.knows[gossip.LinkIndex{From: peer, To: node}]
+		for _, chunk := range chunks {
+			if known == nil || !known[chunk] {
+				news = append(news, chunk)
+			}
+		}
+		if len(news) == 0 {
+			continue
+		}
+		r.traffic.AddControl(chunkIDSize * len(news))
+		r.wg.Add(1)
+		go func(peer int) {
+			defer r.wg.Done()
+			time.Sleep(r.sim.latency(node, peer))
+			r.mx.Lock()
+			defer r.mx.Unlock()
+			if r.expired {
+				return
+			}
+			r.receiveHave(peer, node, news)
+		}(peer)
+	}
+}
+
+// receiveHave handles HAVE message received by node from peer.
+// Caller should hold the lock.
+func (r *run) receiveHave(node, peer int, chunks []int) {
+	l := gossip.LinkIndex{From: node, To: peer}
+	if r.knows[l] == nil {
+		r.knows[l] = make([]bool, r.chunks)
+	}
+	for _, chunk := range chunks {
+		r.knows[l][chunk] = true
+	}
+	r.request(node)
+}
+
+// request sends WANT messages for missing chunks to peers having them,
+// preferring peers with fewer outstanding requests, so chunks are pulled
+// from multiple peers in parallel. Caller should hold the lock.
+func (r *run) request(node int) {
+	if r.count[node] == r.chunks {
+		return
+	}
+	peers := append([]int(nil), r.sim.peers[node]...)
+	rand.Shuffle(len(peers), func(i, j int) { peers[i], peers[j] = peers[j], peers[i] })
+
+	wants := make(map[int][]int) // peer -> chunks
+	for chunk := 0; chunk < r.chunks; chunk++ {
+		if r.have[node][chunk] || r.requested[node][chunk] {
+			continue
+		}
+		best := -1
+		for _, peer := range peers {
+			l := gossip.LinkIndex{From: node, To: peer}
+			if known := r.knows[l]; known == nil || !known[chunk] || r.pending[l] >= r.sim.wantListSize {
+				continue
+			}
+			if best == -1 || r.pending[l] < r.pending[gossip.LinkIndex{From: node, To: best}] {
+				best = peer
+			}
+		}
+		if best == -1 {
+			continue
+		}
+		r.requested[node][chunk] = true
+		r.pending[gossip.LinkIndex{From: node, To: best}]++
+		wants[best] = append(wants[best], chunk)
+	}
+
+	for peer, chunks := range wants {
+		r.traffic.AddControl(chunkIDSize * len(chunks))
+		r.wg.Add(1)
+		go func(peer int, chunks []int) {
+			defer r.wg.Done()
+			time.Sleep(r.sim.latency(node, peer))
+			r.mx.Lock()
+			defer r.mx.Unlock()
+			if r.expired {
+				return
+			}
+			for _, chunk := range chunks {
+				r.sendChunk(peer, node, chunk)
+			}
+		}(peer, chunks)
+	}
+}
+
+// sendChunk starts sending chunk from node to its peer. Chunks sent by the
+// same node share its uplink and wait for each other. Caller should hold
+// the lock.
+func (r *run) sendChunk(from, to, chunk int) {
+	size := r.chunkBytes(chunk)
+	r.traffic.AddPayload(size)
+
+	var transfer time.Duration
+	if rate := r.sim.uplink[from]; rate > 0 {
+		transfer = time.Duration(float64(size) / rate * float64(time.Second))
+	}
+	start := time.Now()
+	if r.uplinkAt[from].After(start) {
+		start = r.uplinkAt[from]
+	}
+	r.uplinkAt[from] = start.Add(transfer)
+	arrival := r.uplinkAt[from].Add(r.sim.latency(from, to))
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		time.Sleep(time.Until(arrival))
+		r.mx.Lock()
+		defer r.mx.Unlock()
+		if r.expired {
+			return
+		}
+		r.receiveChunk(to, from, chunk)
+	}()
+}
+
+// receiveChunk handles chunk received by node from peer. Caller should
+// hold the lock.
+func (r *run) receiveChunk(node, peer, chunk int) {
+	r.pending[gossip.LinkIndex{From: node, To: peer}]--
+	if !r.have[node][chunk] {
+		r.have[node][chunk] = true
+		r.count[node]++
+		if r.count[node] == r.chunks {
+			r.complete(node, peer)
+		}
+		r.announce(node, []int{chunk})
+	}
+	r.request(node)
+}
+
+// complete reports node having all the chunks, with the last one
+// received from peer. Caller should hold the lock.
+func (r *run) complete(node, peer int) {
+	entry := propagation.NewLogEntry(time.Now(), r.start, peer, node)
+	entry.Msg = r.id
+	r.entries.Add(*entry)
+	r.sim.events(*entry)
+	r.sim.progress(propagation.Progress{
+		Phase: propagation.PhaseCollect,
+		Done:  r.entries.Len() + 1, // including sender
+		Total: r.sim.data.NumNodes(),
+	})
+}
+
+// allChunks returns indices of all n chunks.
+func allChunks(n int) []int {
+	ret := make([]int, n)
+	for i := range ret {
+		ret[i] = i
+	}
+	return ret
+}
@@ -0,0 +1,91 @@
+package bitswap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/internal/testgraph"
+	"github.com/divan/simulation/propagation"
+)
+
+func lastTimestamp(plog *propagation.Log) time.Duration {
+	var max int
+	for _, ts := range plog.Timestamps {
+		if ts > max {
+			max = ts
+		}
+	}
+	return time.Duration(max) * time.Millisecond
+}
+
+func TestSendMessage(t *testing.T) {
+	data := testgraph.Ring(20)
+	size := 1000000
+	sim := NewSimulator(data,
+		WithChunkSize(64*1024),
+		WithUplink(func(int) float64 { return 100000000 }),
+		WithLatency(func(int, int) time.Duration { return time.Millisecond }),
+	)
+	defer sim.Stop()
+
+	plog := sim.SendMessage(0, 10, size)
+	reached := make(map[int]bool)
+	for _, nodes := range plog.Nodes {
+		for _, n := range nodes {
+			reached[n] = true
+		}
+	}
+	if len(reached) != 20 {
+		t.Fatalf("Expected all 20 nodes to be reached, got %d", len(reached))
+	}
+	// chunks are requested once, so each node receives the message once
+	if got, want := plog.Traffic.PayloadBytes, int64(19*size); got != want {
+		t.Fatalf("Expected %d payload bytes, got %d", want, got)
+	}
+}
+
+func TestChunksPipelining(t *testing.T) {
+	data := testgraph.Line(5)
+	size := 100000
+	send := func(chunkSize int) time.Duration {
+		sim := NewSimulator(data,
+			WithChunkSize(chunkSize),
+			WithUplink(func(int) float64 { return 1000000 }),
+			WithLatency(func(int, int) time.Duration { return time.Millisecond }),
+		)
+		defer sim.Stop()
+		return lastTimestamp(sim.SendMessage(0, 10, size))
+	}
+
+	// single chunk is stored and forwarded on each hop, taking ~400ms,
+	// while small chunks are pipelined along the line
+	whole, chunked := send(size), send(size/10)
+	if chunked > whole/2 {
+		t.Fatalf("Expected chunked propagation to be faster, got %v vs %v", chunked, whole)
+	}
+}
+
+func TestMaxDuration(t *testing.T) {
+	data := testgraph.Line(10)
+	sim := NewSimulator(data,
+		WithLatency(func(int, int) time.Duration { return 20 * time.Millisecond }),
+		WithMaxDuration(100*time.Millisecond),
+	)
+	defer sim.Stop()
+
+	// each hop takes ~40ms (HAVE and WANT) plus chunk transfer
+	start := time.Now()
+	plog := sim.SendMessage(0, 10, 1000)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected propagation to be bounded, took %v", elapsed)
+	}
+	reached := make(map[int]bool)
+	for _, nodes := range plog.Nodes {
+		for _, n := range nodes {
+			reached[n] = true
+		}
+	}
+	if len(reached) == 0 || len(reached) >= 10 {
+		t.Fatalf("Expected some of nodes to be reached, got %d", len(reached))
+	}
+}
@@ -0,0 +1,255 @@
+// Package core implements pure propagation core: discrete event
+// scheduler, push gossip node behavior and log collection. Unlike
+// simulators of other packages, it runs in the caller's goroutine on
+// virtual clock, so its results depend only on the network, parameters
+// and seed, and reuses its buffers across runs, so the only allocations
+// of a run are the resulting log. It makes core suitable for fuzzing over
+// random graphs and parameters to find panics and non-termination:
+//
+//	go test -run XXX -fuzz FuzzRun ./propagation/core/
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// Default parameters.
+const (
+	DefaultLatency   = 10 // ms
+	DefaultMaxEvents = 1 << 24
+)
+
+// ErrEventLimit is returned when run processes more events than allowed
+// by Params.MaxEvents, which means propagation doesn't terminate.
+var ErrEventLimit = errors.New("event limit exceeded")
+
+// Network describes network graph as plain data.
+type Network struct {
+	Nodes int
+	Links [][2]int // from and to node indices of each link
+}
+
+// Params describes propagation parameters.
+type Params struct {
+	Fanout    int     // peers each node relays the message to, all if 0
+	TTL       int     // hops the message makes, counting from the sender
+	Latency   []int   // latency of each link in ms, DefaultLatency for links without it
+	Loss      float64 // probability of losing each message
+	Seed      int64
+	MaxEvents int // bound of events processed by a single run, DefaultMaxEvents if 0
+}
+
+// peer is the node's neighbour, along with the link to it.
+type peer struct {
+	node, link int
+}
+
+// event is a single message delivery.
+type event struct {
+	at       int // virtual time, in ms
+	seq      int // order of scheduling, so events at the same time are ordered
+	from, to int
+	link     int
+	ttl      int // hops left after delivery
+}
+
+// Core runs propagation of messages over the network. It's not safe for
+// concurrent use.
+type Core struct {
+	params Params
+	peers  [][]peer
+	rand   *rand.Rand
+
+	// buffers reused across runs
+	queue   []event
+	seen    []bool
+	targets []peer
+	entries []event
+	seq     int
+}
+
+// New creates Core for the network, checking that links and latencies
+// are valid.
+func New(network Network, params Params) (*Core, error) {
+	if network.Nodes < 0 {
+		return nil, fmt.Errorf("negative number of nodes %d", network.Nodes)
+	}
+	if params.Loss < 0 || params.Loss > 1 {
+		return nil, fmt.Errorf("loss %v is out of [0, 1] range", params.Loss)
+	}
+	if params.MaxEvents <= 0 {
+		params.MaxEvents = DefaultMaxEvents
+	}
+	peers := make([][]peer, network.Nodes)
+	for i, link := range network.Links {
+		from, to := link[0], link[1]
+		if from < 0 || from >= network.Nodes || to < 0 || to >= network.Nodes {
+			return nil, fmt.Errorf("link %d (%d -> %d) is out of nodes range", i, from, to)
+		}
+		if i < len(params.Latency) && params.Latency[i] < 0 {
+			return nil, fmt.Errorf("negative latency of link %d", i)
+		}
+		peers[from] = append(peers[from], peer{node: to, link: i})
+		peers[to] = append(peers[to], peer{node: from, link: i})
+	}
+	return &Core{
+		params: params,
+		peers:  peers,
+		rand:   rand.New(rand.NewSource(params.Seed)),
+		seen:   make([]bool, network.Nodes),
+	}, nil
+}
+
+// Run propagates message from the start node, and returns its log, with
+// every delivery reported, so duplicates show up in it. Random source
+// is shared across runs, so runs of the same Core differ, but sequence
+// of them is the same for the same seed. On ErrEventLimit, log of the
+// events processed so far is returned.
+func (c *Core) Run(start int) (*propagation.Log, error) {
+	if start < 0 || start >= len(c.seen) {
+		return nil, fmt.Errorf("start node %d not found", start)
+	}
+	for i := range c.seen {
+		c.seen[i] = false
+	}
+	c.queue, c.entries, c.seq = c.queue[:0], c.entries[:0], 0
+
+	var err error
+	c.seen[start] = true
+	c.relay(0, start, -1, c.params.TTL)
+	for len(c.queue) > 0 {
+		if len(c.entries) == c.params.MaxEvents {
+			err = ErrEventLimit
+			break
+		}
+		e := c.pop()
+		c.entries = append(c.entries, e)
+		if c.seen[e.to] {
+			continue
+		}
+		c.seen[e.to] = true
+		c.relay(e.at, e.to, e.from, e.ttl)
+	}
+	return c.log(), err
+}
+
+// relay sends message from node to fanout of its peers, except the one
+// it got the message from, unless message has no hops left.
+func (c *Core) relay(at, node, from, ttl int) {
+	if ttl <= 0 {
+		return
+	}
+	c.targets = c.targets[:0]
+	for _, p := range c.peers[node] {
+		if p.node != from {
+			c.targets = append(c.targets, p)
+		}
+	}
+	n := len(c.targets)
+	if c.params.Fanout > 0 && c.params.Fanout < n {
+		// partial Fisher-Yates shuffle picks fanout random peers
+		for i := 0; i < c.params.Fanout; i++ {
+			j := i + c.rand.Intn(n-i)
+			c.targets[i], c.targets[j] = c.targets[j], c.targets[i]
+		}
+		n = c.params.Fanout
+	}
+	for _, p := range c.targets[:n] {
+		if c.params.Loss > 0 && c.rand.Float64() < c.params.Loss {
+			continue
+		}
+		c.push(event{
+			at:   at + c.latency(p.link),
+			from: node,
+			to:   p.node,
+			link: p.link,
+			ttl:  ttl - 1,
+		})
+	}
+}
+
+// latency returns latency of the link.
+func (c *Core) latency(link int) int {
+	if link < len(c.params.Latency) {
+		return c.params.Latency[link]
+	}
+	return DefaultLatency
+}
+
+// log converts processed events into propagation log. Events are processed
+// in order of time, so they are grouped into steps as they go, and all
+// steps share the same backing arrays.
+func (c *Core) log() *propagation.Log {
+	var steps int
+	for i, e := range c.entries {
+		if i == 0 || e.at != c.entries[i-1].at {
+			steps++
+		}
+	}
+	plog := propagation.NewLog(steps)
+	links := make([]int, len(c.entries))
+	nodes := make([]int, 2*len(c.entries))
+	var start int
+	for i, e := range c.entries {
+		links[i] = e.link
+		nodes[2*i], nodes[2*i+1] = e.from, e.to
+		if i+1 == len(c.entries) || c.entries[i+1].at != e.at {
+			// limit capacity, so appending to the step doesn't overwrite the next one
+			plog.AddStep(e.at, nodes[2*start:2*(i+1):2*(i+1)], links[start:i+1:i+1])
+			start = i + 1
+		}
+	}
+	return plog
+}
+
+// push adds event to the queue, which is binary heap ordered by time and
+// sequence number. It's implemented without container/heap, so events
+// are not boxed into interfaces.
+func (c *Core) push(e event) {
+	e.seq = c.seq
+	c.seq++
+	c.queue = append(c.queue, e)
+	for i := len(c.queue) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if !c.less(i, parent) {
+			break
+		}
+		c.queue[i], c.queue[parent] = c.queue[parent], c.queue[i]
+		i = parent
+	}
+}
+
+// pop removes the earliest event from the queue.
+func (c *Core) pop() event {
+	top := c.queue[0]
+	last := len(c.queue) - 1
+	c.queue[0] = c.queue[last]
+	c.queue = c.queue[:last]
+	for i := 0; ; {
+		min, left, right := i, 2*i+1, 2*i+2
+		if left < last && c.less(left, min) {
+			min = left
+		}
+		if right < last && c.less(right, min) {
+			min = right
+		}
+		if min == i {
+			break
+		}
+		c.queue[i], c.queue[min] = c.queue[min], c.queue[i]
+		i = min
+	}
+	return top
+}
+
+func (c *Core) less(i, j int) bool {
+	a, b := c.queue[i], c.queue[j]
+	if a.at != b.at {
+		return a.at < b.at
+	}
+	return a.seq < b.seq
+}
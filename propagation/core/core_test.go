@@ -0,0 +1,137 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ring returns network of n nodes connected into a ring.
+func ring(n int) Network {
+	network := Network{Nodes: n}
+	for i := 0; i < n; i++ {
+		network.Links = append(network.Links, [2]int{i, (i + 1) % n})
+	}
+	return network
+}
+
+func TestRun(t *testing.T) {
+	c, err := New(ring(4), Params{TTL: 10, Latency: []int{10, 20, 10, 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plog, err := c.Run(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 0 -> 1 and 0 -> 3 at 10ms, 3 -> 2 at 20ms, then duplicates
+	// 1 -> 2 at 30ms and 2 -> 1 at 40ms over the slow link
+	if want := []int{10, 20, 30, 40}; !reflect.DeepEqual(plog.Timestamps, want) {
+		t.Fatalf("Expected timestamps %v, got %v", want, plog.Timestamps)
+	}
+	if want := [][]int{{0, 1, 0, 3}, {3, 2}, {1, 2}, {2, 1}}; !reflect.DeepEqual(plog.Nodes, want) {
+		t.Fatalf("Expected nodes %v, got %v", want, plog.Nodes)
+	}
+	if want := [][]int{{0, 3}, {2}, {1}, {1}}; !reflect.DeepEqual(plog.Links, want) {
+		t.Fatalf("Expected links %v, got %v", want, plog.Links)
+	}
+
+	// only the sender's peers get the message with TTL 1
+	c.params.TTL = 1
+	if plog, _ = c.Run(0); len(plog.Timestamps) != 1 {
+		t.Fatalf("Expected single hop with TTL 1, got %v", plog.Timestamps)
+	}
+}
+
+func TestRunDeterministic(t *testing.T) {
+	params := Params{Fanout: 2, TTL: 10, Loss: 0.2, Seed: 7}
+	a, _ := New(ring(50), params)
+	b, _ := New(ring(50), params)
+	for i := 0; i < 3; i++ {
+		la, _ := a.Run(i)
+		lb, _ := b.Run(i)
+		if !reflect.DeepEqual(la, lb) {
+			t.Fatalf("Expected the same logs of run %d for the same seed", i)
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	for _, network := range []Network{
+		{Nodes: -1},
+		{Nodes: 2, Links: [][2]int{{0, 2}}},
+		{Nodes: 2, Links: [][2]int{{-1, 0}}},
+	} {
+		if _, err := New(network, Params{}); err == nil {
+			t.Fatalf("Expected error for network %v", network)
+		}
+	}
+	if _, err := New(ring(2), Params{Latency: []int{-1}}); err == nil {
+		t.Fatal("Expected error for negative latency")
+	}
+	if _, err := New(ring(2), Params{Loss: 2}); err == nil {
+		t.Fatal("Expected error for loss out of range")
+	}
+	c, _ := New(ring(2), Params{})
+	if _, err := c.Run(2); err == nil {
+		t.Fatal("Expected error for unknown start node")
+	}
+	c, _ = New(ring(10), Params{TTL: 10, MaxEvents: 3})
+	if _, err := c.Run(0); err != ErrEventLimit {
+		t.Fatalf("Expected event limit error, got %v", err)
+	}
+}
+
+// FuzzRun builds random network out of fuzzer data, with a link for each
+// pair of bytes, and checks that propagation terminates and its log is
+// consistent.
+func FuzzRun(f *testing.F) {
+	f.Add(uint8(4), []byte{0, 1, 1, 2, 2, 3}, uint8(0), uint8(10), uint8(0), int64(1))
+	f.Add(uint8(10), []byte{0, 0, 1, 1, 0, 9, 9, 0}, uint8(2), uint8(3), uint8(128), int64(2))
+	f.Fuzz(func(t *testing.T, nodes uint8, links []byte, fanout, ttl, loss uint8, seed int64) {
+		if nodes == 0 {
+			return
+		}
+		network := Network{Nodes: int(nodes)}
+		var latency []int
+		for i := 0; i+1 < len(links); i += 2 {
+			network.Links = append(network.Links, [2]int{int(links[i]) % network.Nodes, int(links[i+1]) % network.Nodes})
+			latency = append(latency, int(links[i]^links[i+1])%4)
+		}
+		c, err := New(network, Params{
+			Fanout:  int(fanout),
+			TTL:     int(ttl),
+			Latency: latency,
+			Loss:    float64(loss) / 255,
+			Seed:    seed,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		plog, err := c.Run(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// each node relays the message once, at most to all its peers
+		reached := map[int]bool{0: true}
+		var deliveries int
+		for i, ts := range plog.Timestamps {
+			if i > 0 && ts <= plog.Timestamps[i-1] {
+				t.Fatalf("Expected increasing timestamps, got %v", plog.Timestamps)
+			}
+			// deliveries of each step are in order they happened
+			for k := 0; k+1 < len(plog.Nodes[i]); k += 2 {
+				from, to := plog.Nodes[i][k], plog.Nodes[i][k+1]
+				if !reached[from] {
+					t.Fatalf("Node %d relayed message before receiving it", from)
+				}
+				reached[to] = true
+				deliveries++
+			}
+		}
+		if deliveries > 2*len(network.Links) {
+			t.Fatalf("Expected at most %d deliveries, got %d", 2*len(network.Links), deliveries)
+		}
+	})
+}
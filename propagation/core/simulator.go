@@ -0,0 +1,53 @@
+package core
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+)
+
+// Simulator adapts Core to propagation.Simulator interface for the
+// network graph.
+type Simulator struct {
+	mx   sync.Mutex
+	core *Core
+}
+
+// NewSimulator initializes new simulator for the given graph data.
+func NewSimulator(data *graph.Graph, params Params) (*Simulator, error) {
+	network := Network{Nodes: data.NumNodes()}
+	for _, link := range data.Links() {
+		network.Links = append(network.Links, [2]int{link.FromIdx(), link.ToIdx()})
+	}
+	core, err := New(network, params)
+	if err != nil {
+		return nil, err
+	}
+	return &Simulator{core: core}, nil
+}
+
+// SendMessage propagates message from node. Implements
+// propagation.Simulator. Message size doesn't affect propagation, and its
+// TTL overrides one of params, if positive.
+func (s *Simulator) SendMessage(startNodeIdx, ttl, size int) *propagation.Log {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if ttl > 0 {
+		s.core.params.TTL = ttl
+	}
+	plog, err := s.core.Run(startNodeIdx)
+	if err != nil {
+		slog.Warn("Propagation failed", "node", startNodeIdx, "err", err)
+	}
+	if plog == nil {
+		plog = propagation.NewLog(0)
+	}
+	return plog
+}
+
+// Stop stops simulator. Implements propagation.Simulator.
+func (s *Simulator) Stop() error {
+	return nil
+}
@@ -0,0 +1,122 @@
+package devp2p
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+	"github.com/ethereum/go-ethereum/p2p/simulations"
+)
+
+// connectAll connects nodes for all given links using bounded pool of
+// workers, and returns the number of connections requested. Duplicated
+// links (including reversed ones) are connected only once.
+func (s *Simulator) connectAll(links []*graph.Link) (int64, error) {
+	type pair struct{ from, to int }
+
+	unique := make([]pair, 0, len(links))
+	seen := make(map[pair]bool, len(links))
+	for _, link := range links {
+		p := pair{link.FromIdx(), link.ToIdx()}
+		if p.from > p.to {
+			p.from, p.to = p.to, p.from
+		}
+		if p.from == p.to || seen[p] {
+			continue
+		}
+		seen[p] = true
+		unique = append(unique, p)
+	}
+
+	jobs := make(chan pair)
+	go func() {
+		for _, p := range unique {
+			jobs <- p
+		}
+		close(jobs)
+	}()
+
+	var (
+		wg        sync.WaitGroup
+		errOnce   sync.Once
+		err       error
+		count     int64
+		processed int64
+	)
+	wg.Add(s.connectWorkers)
+	for i := 0; i < s.connectWorkers; i++ {
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				e := s.connectNodes(p.from, p.to)
+				if e != nil && e != ErrLinkExists {
+					errOnce.Do(func() { err = fmt.Errorf("connect nodes %d and %d: %v", p.from, p.to, e) })
+				} else if e == nil {
+					atomic.AddInt64(&count, 1)
+				}
+				s.progress(propagation.Progress{
+					Phase: propagation.PhaseConnect,
+					Done:  int(atomic.AddInt64(&processed, 1)),
+					Total: len(unique),
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	return atomic.LoadInt64(&count), err
+}
+
+// connect connects nodes according to graph links and waits for all
+// connections to be established.
+func (s *Simulator) connect() error {
+	events := make(chan *simulations.Event)
+	sub := s.network.Events().Subscribe(events)
+	defer sub.Unsubscribe()
+
+	var (
+		count int64
+		err   error
+	)
+	connectingDone := make(chan struct{})
+	go func() {
+		slog.Info("Connecting nodes", "workers", s.connectWorkers)
+		count, err = s.connectAll(s.Graph().Links())
+		close(connectingDone)
+	}()
+
+	var (
+		connected   int64
+		allStarted  bool
+		waitStarted = connectingDone
+	)
+	for !allStarted || connected < count {
+		select {
+		case event := <-events:
+			if event.Type == simulations.EventTypeConn && event.Conn.Up {
+				connected++
+			}
+		case <-waitStarted:
+			if err != nil {
+				return err
+			}
+			allStarted = true
+			waitStarted = nil // closed channel, don't select it anymore
+		case e := <-sub.Err():
+			return fmt.Errorf("wait for connections: %v", e)
+		}
+	}
+	return nil
+}
+
+// connectNodes connects two nodes, unless they're connected already.
+func (s *Simulator) connectNodes(from, to int) error {
+	one, other := s.network.Nodes[from].ID(), s.network.Nodes[to].ID()
+	// network.Connect fails for existing connections
+	if s.network.GetConn(one, other) != nil {
+		return ErrLinkExists
+	}
+	return s.network.Connect(one, other)
+}
@@ -0,0 +1,11 @@
+// Package devp2p implements message propagation simulator for arbitrary
+// devp2p services of go-ethereum, i.e. les, eth or custom protocols.
+//
+// Simulator starts in-memory service of the given Service on each node
+// with the given network topology, sends message with Service.Send
+// through the start node and logs packets Service.Match reports as
+// carrying the message, using geth's simulations
+// (https://github.com/ethereum/go-ethereum/tree/master/p2p/simulations)
+// package. Whisperv6 simulator is built on it, adding whisper specific
+// accounting on top.
+package devp2p
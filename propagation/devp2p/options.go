@@ -0,0 +1,66 @@
+package devp2p
+
+import (
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// DefaultConnectWorkers is the default number of workers establishing
+// connections between nodes in parallel.
+const DefaultConnectWorkers = 16
+
+// Option represents simulator option.
+type Option func(*Simulator)
+
+// WithConnectWorkers sets the number of workers establishing connections
+// between nodes in parallel during network setup.
+func WithConnectWorkers(n int) Option {
+	return func(s *Simulator) {
+		if n > 0 {
+			s.connectWorkers = n
+		}
+	}
+}
+
+// WithProgress sets the function to report setup and simulation progress to.
+// Passing nil disables progress reporting.
+func WithProgress(fn propagation.ProgressFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.Progress) {}
+		}
+		s.progress = fn
+	}
+}
+
+// WithEvents sets the function to report each message sending to,
+// as it happens during simulation.
+func WithEvents(fn propagation.EventFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.LogEntry) {}
+		}
+		s.events = fn
+	}
+}
+
+// WithObserver sets the function to report typed events to, as they
+// happen during message propagation: packets carrying the message sent,
+// and connections and nodes going up and down.
+func WithObserver(fn propagation.ObserverFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.Event) {}
+		}
+		s.observer = fn
+	}
+}
+
+// WithQuiescence stops collecting events of the message after d without
+// matching packets, instead of waiting for the message TTL to pass.
+func WithQuiescence(d time.Duration) Option {
+	return func(s *Simulator) {
+		s.quiescence = d
+	}
+}
@@ -0,0 +1,361 @@
+package devp2p
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/simulations"
+	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+)
+
+// Service describes devp2p service under test.
+type Service struct {
+	// Name of the service, i.e. "les".
+	Name string
+	// New creates service of the node with the given index.
+	New func(idx int, ctx *adapters.ServiceContext) (node.Service, error)
+	// Send sends message of the given TTL (in seconds) and size through
+	// the service of the start node, returning message identifier.
+	Send func(svc node.Service, ttl, size int) (string, error)
+	// Match reports whether packet sent by the node with the given
+	// service carries the message with the given identifier. Packets
+	// are reported by the network on both ends, only sent ones are
+	// passed to Match.
+	Match func(msg string, sender node.Service, packet *simulations.Msg) bool
+}
+
+// Simulator simulates message propagation of the devp2p service through
+// the given p2p network. Implements propagation.Simulator.
+type Simulator struct {
+	data     *graph.Graph
+	service  Service
+	network  *simulations.Network
+	services map[enode.ID]node.Service
+	indices  map[enode.ID]int // node indices, by node ID
+	mx       sync.RWMutex     // guards data, services and indices, changed for restarted and added nodes
+
+	connectWorkers int
+	quiescence     time.Duration // stop after that long without events, if set
+	progress       propagation.ProgressFunc
+	events         propagation.EventFunc
+	observer       propagation.ObserverFunc
+}
+
+// ErrLinkExists is returned when nodes are connected already.
+var ErrLinkExists = errors.New("link exists")
+
+// progressInterval defines how often events collection progress is reported.
+const progressInterval = 500 * time.Millisecond
+
+// NewSimulator creates simulator for the given graph data, starting the
+// service on each node and connecting nodes according to graph links.
+func NewSimulator(data *graph.Graph, service Service, opts ...Option) (*Simulator, error) {
+	sim, err := New(data, service, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := sim.CreateNetwork(); err != nil {
+		sim.network.Shutdown()
+		return nil, err
+	}
+	return sim, nil
+}
+
+// NewSimulatorFromSnapshot creates simulator for the given graph data,
+// loading nodes and connections from the network snapshot instead of
+// creating them (see LoadSnapshot).
+func NewSimulatorFromSnapshot(data *graph.Graph, service Service, snap *simulations.Snapshot, opts ...Option) (*Simulator, error) {
+	sim, err := New(data, service, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := sim.LoadSnapshot(snap); err != nil {
+		sim.network.Shutdown()
+		return nil, err
+	}
+	return sim, nil
+}
+
+// New creates simulator for the given graph data with empty network. It's
+// for simulators wrapping this one, whose services need the simulator
+// before nodes start: create nodes with CreateNetwork or LoadSnapshot then.
+func New(data *graph.Graph, service Service, opts ...Option) (*Simulator, error) {
+	if service.Name == "" || service.New == nil || service.Send == nil || service.Match == nil {
+		return nil, errors.New("service should have name, New, Send and Match set")
+	}
+	sim := &Simulator{
+		data:           data,
+		service:        service,
+		services:       make(map[enode.ID]node.Service, data.NumNodes()),
+		indices:        make(map[enode.ID]int, data.NumNodes()),
+		connectWorkers: DefaultConnectWorkers,
+		progress:       propagation.LogProgress(),
+		events:         func(propagation.LogEntry) {},
+		observer:       func(propagation.Event) {},
+	}
+	for _, opt := range opts {
+		opt(sim)
+	}
+
+	adapter := adapters.NewSimAdapter(map[string]adapters.ServiceFunc{
+		service.Name: func(ctx *adapters.ServiceContext) (node.Service, error) {
+			svc, err := service.New(sim.Index(ctx.Config.ID), ctx)
+			if err != nil {
+				return nil, err
+			}
+			sim.mx.Lock()
+			sim.services[ctx.Config.ID] = svc
+			sim.mx.Unlock()
+			return svc, nil
+		},
+	})
+	sim.network = simulations.NewNetwork(adapter, &simulations.NetworkConfig{
+		DefaultService: service.Name,
+	})
+	return sim, nil
+}
+
+// CreateNetwork creates and starts node for each graph node, and connects
+// nodes according to graph links.
+func (s *Simulator) CreateNetwork() error {
+	data := s.Graph()
+	slog.Info("Creating nodes", "count", data.NumNodes(), "service", s.service.Name)
+	for i := 0; i < data.NumNodes(); i++ {
+		cfg, err := nodeConfig(i)
+		if err != nil {
+			return err
+		}
+		s.setIndex(cfg.ID, i)
+		if _, err := s.network.NewNodeWithConfig(cfg); err != nil {
+			return fmt.Errorf("create node %d: %v", i, err)
+		}
+		s.progress(propagation.Progress{
+			Phase: propagation.PhaseCreateNodes,
+			Done:  i + 1,
+			Total: data.NumNodes(),
+		})
+	}
+
+	slog.Info("Starting nodes")
+	if err := s.network.StartAll(); err != nil {
+		return fmt.Errorf("start nodes: %v", err)
+	}
+	if err := s.connect(); err != nil {
+		return err
+	}
+	slog.Info("All connections established")
+	return nil
+}
+
+// simulatedPort is TCP port of simulated nodes.
+const simulatedPort = 30303
+
+// nodeConfig generates config for simulated node with random key.
+func nodeConfig(idx int) (*adapters.NodeConfig, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, fmt.Errorf("generate key: %v", err)
+	}
+	return &adapters.NodeConfig{
+		ID:              enode.PubkeyToIDV4(&key.PublicKey),
+		PrivateKey:      key,
+		Name:            fmt.Sprintf("Node %d", idx),
+		EnableMsgEvents: true,
+		// in-memory connections ignore port, but geth dials only nodes
+		// which have one
+		Port: simulatedPort,
+	}, nil
+}
+
+// Network returns the underlying simulation network, i.e. for RPC clients
+// of its nodes.
+func (s *Simulator) Network() *simulations.Network {
+	return s.network
+}
+
+// Service returns service of the node with the given index, i.e. for
+// inspecting its state after propagation, or nil if node is not running.
+func (s *Simulator) Service(idx int) node.Service {
+	if idx < 0 || idx >= len(s.network.Nodes) {
+		return nil
+	}
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.services[s.network.Nodes[idx].ID()]
+}
+
+// Stop stops simulator and frees all resources if any.
+func (s *Simulator) Stop() error {
+	slog.Info("Shutting down simulation nodes")
+	s.network.Shutdown()
+	return nil
+}
+
+// StopNode stops the node with the given index. Implements propagation.NodeStopper.
+func (s *Simulator) StopNode(idx int) error {
+	if idx < 0 || idx >= len(s.network.Nodes) {
+		return fmt.Errorf("node with index %d not found", idx)
+	}
+	return s.network.Stop(s.network.Nodes[idx].ID())
+}
+
+// DisconnectNodes breaks the connection between two nodes. Implements propagation.NodesDisconnector.
+func (s *Simulator) DisconnectNodes(from, to int) error {
+	if from < 0 || from >= len(s.network.Nodes) || to < 0 || to >= len(s.network.Nodes) {
+		return fmt.Errorf("nodes %d and %d not found", from, to)
+	}
+	return s.network.Disconnect(s.network.Nodes[from].ID(), s.network.Nodes[to].ID())
+}
+
+// RestartNode starts stopped node again, with fresh service, and
+// reconnects it to its peers from the network graph that are up. Services
+// keeping their state in memory only lose it, and get what they missed
+// from peers on reconnection, if protocol supports it. Implements
+// propagation.NodeRestarter.
+func (s *Simulator) RestartNode(idx int, fetch bool) error {
+	if idx < 0 || idx >= len(s.network.Nodes) {
+		return fmt.Errorf("node with index %d not found", idx)
+	}
+	if err := s.network.Start(s.network.Nodes[idx].ID()); err != nil {
+		return err
+	}
+
+	for _, link := range s.Graph().Links() {
+		peer := link.ToIdx()
+		if peer == idx {
+			peer = link.FromIdx()
+		} else if link.FromIdx() != idx {
+			continue
+		}
+		if !s.network.Nodes[peer].Up() {
+			continue
+		}
+		if err := s.connectNodes(idx, peer); err != nil && err != ErrLinkExists {
+			return fmt.Errorf("reconnect to %d: %v", peer, err)
+		}
+	}
+	return nil
+}
+
+// SendMessage sends single message with the service of the start node and
+// tracks propagation until message TTL passes. Implements
+// propagation.Simulator. It's safe to call SendMessage concurrently, as
+// long as Match tells messages apart.
+func (s *Simulator) SendMessage(startNodeIdx, ttl, size int) *propagation.Log {
+	// subscribing to network events before sending, not to miss any
+	events := make(chan *simulations.Event)
+	sub := s.network.Events().Subscribe(events)
+	defer sub.Unsubscribe()
+
+	slog.Info("Sending message", "service", s.service.Name, "ttl", ttl, "size", size, "from", startNodeIdx)
+	msg, err := s.service.Send(s.Service(startNodeIdx), ttl, size)
+	if err != nil {
+		log.Fatal("Failed sending message: ", err)
+	}
+	start := time.Now() // mark simulation start
+
+	timeout := time.Duration(ttl)*time.Second + 200*time.Millisecond // add a bit in the end
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	// quiet fires after period of events silence, if enabled
+	var quiet <-chan time.Time
+	var quietTimer *time.Timer
+	if s.quiescence > 0 {
+		quietTimer = time.NewTimer(s.quiescence)
+		defer quietTimer.Stop()
+		quiet = quietTimer.C
+	}
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	var (
+		entries propagation.LogEntries
+		subErr  error
+		done    bool
+	)
+	for subErr == nil && !done {
+		select {
+		case event := <-events:
+			e := s.TypedEvent(event, start)
+			send, ok := e.(propagation.SendEvent)
+			if !ok {
+				if e != nil {
+					s.observer(e)
+				}
+				continue
+			}
+			if !s.service.Match(msg, s.Service(send.From), event.Msg) {
+				continue
+			}
+			send.Msg = msg
+			s.observer(send)
+			entry := propagation.NewLogEntry(event.Time, start, send.From, send.To)
+			entry.Msg = msg
+			entries.Add(*entry)
+			s.events(*entry)
+			if quietTimer != nil {
+				if !quietTimer.Stop() {
+					<-quietTimer.C
+				}
+				quietTimer.Reset(s.quiescence)
+			}
+		case <-ticker.C:
+			s.progress(propagation.Progress{
+				Phase: propagation.PhaseCollect,
+				Done:  int(time.Since(start) / time.Millisecond),
+				Total: int(timeout / time.Millisecond),
+			})
+		case <-timer.C:
+			done = true
+		case <-quiet:
+			slog.Debug("No events, stopping", "silence", s.quiescence)
+			done = true
+		case e := <-sub.Err():
+			subErr = e
+		}
+	}
+	if subErr != nil {
+		log.Fatal("[ERROR] Failed to collect propagation info", subErr)
+	}
+
+	plog := entries.Log(s.Graph())
+	entries.Release()
+	return plog
+}
+
+// TypedEvent converts event of the simulation network into typed one,
+// timestamped relative to start, or returns nil for events which are not
+// tracked. Only packets sent are converted into SendEvents, and it's up
+// to the caller to match them against the message.
+func (s *Simulator) TypedEvent(event *simulations.Event, start time.Time) propagation.Event {
+	ts := int64(event.Time.Sub(start) / time.Millisecond)
+	switch event.Type {
+	case simulations.EventTypeMsg:
+		if event.Msg.Received {
+			return nil
+		}
+		return propagation.SendEvent{Ts: ts, From: s.Index(event.Msg.One), To: s.Index(event.Msg.Other)}
+	case simulations.EventTypeConn:
+		from, to := s.Index(event.Conn.One), s.Index(event.Conn.Other)
+		if event.Conn.Up {
+			return propagation.ConnUpEvent{Ts: ts, From: from, To: to}
+		}
+		return propagation.ConnDownEvent{Ts: ts, From: from, To: to}
+	case simulations.EventTypeNode:
+		idx := s.Index(event.Node.ID())
+		if event.Node.Up() {
+			return propagation.NodeUpEvent{Ts: ts, Node: idx}
+		}
+		return propagation.NodeDownEvent{Ts: ts, Node: idx}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package devp2p
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/internal/testgraph"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p/simulations"
+	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv6"
+)
+
+// whisperService returns geth whisper service, the simplest devp2p
+// service flooding messages.
+func whisperService() Service {
+	return Service{
+		Name: "shh",
+		New: func(idx int, ctx *adapters.ServiceContext) (node.Service, error) {
+			cfg := whisper.DefaultConfig
+			cfg.MinimumAcceptedPOW = 0
+			return whisper.New(&cfg), nil
+		},
+		Send: func(svc node.Service, ttl, size int) (string, error) {
+			params := &whisper.MessageParams{
+				TTL:      uint32(ttl),
+				KeySym:   make([]byte, 32),
+				Topic:    whisper.TopicType{1},
+				Payload:  make([]byte, size),
+				WorkTime: 1,
+			}
+			rand.Read(params.KeySym)
+			msg, err := whisper.NewSentMessage(params)
+			if err != nil {
+				return "", err
+			}
+			env, err := msg.Wrap(params)
+			if err != nil {
+				return "", err
+			}
+			return env.Hash().Hex(), svc.(*whisper.Whisper).Send(env)
+		},
+		Match: func(msg string, sender node.Service, packet *simulations.Msg) bool {
+			return packet.Protocol == "shh" && packet.Code == 1 && sender != nil &&
+				sender.(*whisper.Whisper).GetEnvelope(common.HexToHash(msg)) != nil
+		},
+	}
+}
+
+// reached returns nodes message has been delivered to, including sender.
+func reached(sender int, nodes [][]int) map[int]bool {
+	ret := map[int]bool{sender: true}
+	for _, to := range nodes {
+		for _, n := range to {
+			ret[n] = true
+		}
+	}
+	return ret
+}
+
+func TestSendMessage(t *testing.T) {
+	sim, err := NewSimulator(testgraph.Line(4), whisperService(), WithQuiescence(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Stop()
+
+	plog := sim.SendMessage(0, 10, 100)
+	if got := reached(0, plog.Nodes); len(got) != 4 {
+		t.Fatalf("expected message to reach all 4 nodes, got %v", got)
+	}
+}
+
+func TestNewSimulatorErrors(t *testing.T) {
+	if _, err := NewSimulator(testgraph.Line(2), Service{Name: "shh"}); err == nil {
+		t.Fatal("expected error for service without functions")
+	}
+}
+
+func TestAddNode(t *testing.T) {
+	sim, err := NewSimulator(testgraph.Line(3), whisperService(), WithQuiescence(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Stop()
+
+	idx, err := sim.AddNode("3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.AddLink(2, idx); err != nil {
+		t.Fatal(err)
+	}
+	// connection is established asynchronously
+	time.Sleep(100 * time.Millisecond)
+	if got := reached(0, sim.SendMessage(0, 10, 100).Nodes); !got[idx] {
+		t.Fatalf("expected message to reach added node %d, got %v", idx, got)
+	}
+}
@@ -0,0 +1,38 @@
+package devp2p
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/ethereum/go-ethereum/p2p/simulations"
+)
+
+// Snapshot returns snapshot of the simulated network: nodes with their
+// keys and established connections. Loading it with LoadSnapshot skips
+// the expensive nodes creation and connection phase.
+func (s *Simulator) Snapshot() (*simulations.Snapshot, error) {
+	snap, err := s.network.Snapshot()
+	if err != nil {
+		return nil, fmt.Errorf("create snapshot: %v", err)
+	}
+	return snap, nil
+}
+
+// LoadSnapshot creates and connects nodes from the network snapshot.
+// Snapshot should be taken from the simulator created for the same graph,
+// as nodes are matched with graph nodes by index.
+func (s *Simulator) LoadSnapshot(snap *simulations.Snapshot) error {
+	if n := s.Graph().NumNodes(); len(snap.Nodes) != n {
+		return fmt.Errorf("snapshot has %d nodes, but graph has %d", len(snap.Nodes), n)
+	}
+	for i, n := range snap.Nodes {
+		s.setIndex(n.Node.Config.ID, i)
+	}
+
+	slog.Info("Loading network snapshot", "nodes", len(snap.Nodes), "connections", len(snap.Conns))
+	if err := s.network.Load(snap); err != nil {
+		return fmt.Errorf("load snapshot: %v", err)
+	}
+	slog.Info("Network snapshot loaded")
+	return nil
+}
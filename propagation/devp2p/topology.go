@@ -0,0 +1,143 @@
+package devp2p
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// UpdateTopology applies links of the network graph to the running
+// network, connecting nodes of new links and disconnecting nodes of
+// removed ones, and uses the graph for logs of messages sent after that.
+// Graph should have the same nodes in the same order (see
+// propagation.LinkChanges). It should not be called while messages
+// propagate, see NetworkMutator methods for changes during propagation.
+// Implements propagation.TopologyUpdater.
+func (s *Simulator) UpdateTopology(data *graph.Graph) error {
+	added, removed, err := propagation.LinkChanges(s.Graph(), data)
+	if err != nil {
+		return err
+	}
+	for _, p := range added {
+		if err := s.connectNodes(p[0], p[1]); err != nil && err != ErrLinkExists {
+			return fmt.Errorf("connect nodes %d and %d: %v", p[0], p[1], err)
+		}
+	}
+	for _, p := range removed {
+		// links of stopped nodes are down already
+		if s.network.GetConn(s.network.Nodes[p[0]].ID(), s.network.Nodes[p[1]].ID()) == nil {
+			continue
+		}
+		if err := s.DisconnectNodes(p[0], p[1]); err != nil {
+			return fmt.Errorf("disconnect nodes %d and %d: %v", p[0], p[1], err)
+		}
+	}
+	s.setGraph(data)
+	slog.Info("Updated network topology", "added", len(added), "removed", len(removed))
+	return nil
+}
+
+// AddNode adds new node with the given ID to the network, running the
+// service, and returns its index. Node has no links, connect it with
+// AddLink. Implements propagation.NetworkMutator.
+func (s *Simulator) AddNode(id string) (int, error) {
+	data, idx, err := propagation.GraphWithNode(s.Graph(), id)
+	if err != nil {
+		return 0, err
+	}
+	cfg, err := nodeConfig(idx)
+	if err != nil {
+		return 0, err
+	}
+	s.mx.Lock()
+	s.indices[cfg.ID] = idx
+	s.data = data
+	s.mx.Unlock()
+
+	node, err := s.network.NewNodeWithConfig(cfg)
+	if err != nil {
+		return 0, fmt.Errorf("create node: %v", err)
+	}
+	if err := s.network.Start(node.ID()); err != nil {
+		return 0, fmt.Errorf("start node: %v", err)
+	}
+	return idx, nil
+}
+
+// RemoveNode stops the node and removes its links. Node keeps its index,
+// so indices of other nodes don't change. Implements
+// propagation.NetworkMutator.
+func (s *Simulator) RemoveNode(idx int) error {
+	if idx < 0 || idx >= len(s.network.Nodes) {
+		return fmt.Errorf("node with index %d not found", idx)
+	}
+	s.setGraph(propagation.CopyGraph(s.Graph(), func(from, to int) bool {
+		return from == idx || to == idx
+	}))
+	if !s.network.Nodes[idx].Up() {
+		return nil
+	}
+	return s.StopNode(idx)
+}
+
+// AddLink adds link between nodes and connects them, if both are up.
+// Implements propagation.NetworkMutator.
+func (s *Simulator) AddLink(from, to int) error {
+	data, err := propagation.GraphWithLink(s.Graph(), from, to)
+	if err != nil {
+		return err
+	}
+	s.setGraph(data)
+	if !s.network.Nodes[from].Up() || !s.network.Nodes[to].Up() {
+		return nil
+	}
+	if err := s.connectNodes(from, to); err != nil && err != ErrLinkExists {
+		return err
+	}
+	return nil
+}
+
+// RemoveLink removes links between nodes, in both directions, and
+// disconnects them. Implements propagation.NetworkMutator.
+func (s *Simulator) RemoveLink(from, to int) error {
+	if from < 0 || from >= len(s.network.Nodes) || to < 0 || to >= len(s.network.Nodes) {
+		return fmt.Errorf("wrong link %d -> %d", from, to)
+	}
+	s.setGraph(propagation.CopyGraph(s.Graph(), func(a, b int) bool {
+		return (a == from && b == to) || (a == to && b == from)
+	}))
+	if s.network.GetConn(s.network.Nodes[from].ID(), s.network.Nodes[to].ID()) == nil {
+		return nil
+	}
+	return s.DisconnectNodes(from, to)
+}
+
+// Index returns index of the node with the given ID.
+func (s *Simulator) Index(id enode.ID) int {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.indices[id]
+}
+
+func (s *Simulator) setIndex(id enode.ID, idx int) {
+	s.mx.Lock()
+	s.indices[id] = idx
+	s.mx.Unlock()
+}
+
+// Graph returns network graph of the simulator.
+func (s *Simulator) Graph() *graph.Graph {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.data
+}
+
+// setGraph replaces network graph of the simulator.
+func (s *Simulator) setGraph(data *graph.Graph) {
+	s.mx.Lock()
+	s.data = data
+	s.mx.Unlock()
+}
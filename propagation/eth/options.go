@@ -0,0 +1,81 @@
+package eth
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// Option represents simulator option.
+type Option func(*Simulator)
+
+// Defaults close to go-ethereum ones.
+const (
+	DefaultLatency       = 10 * time.Millisecond
+	DefaultArriveTimeout = 500 * time.Millisecond // wait for push before fetching announced hash
+	DefaultImportTime    = 100 * time.Millisecond // block import before announcing it
+)
+
+// WithBlocks makes simulator propagate blocks rather than transactions.
+// Block is pushed to sqrt(peers) once it's received, but announced to the
+// rest of peers only after it's imported, which takes importTime.
+func WithBlocks(importTime time.Duration) Option {
+	return func(s *Simulator) {
+		s.blocks = true
+		s.importTime = importTime
+	}
+}
+
+// WithArriveTimeout sets how long node waits for announced message to be
+// pushed by other peers, before fetching it from the announcer.
+func WithArriveTimeout(d time.Duration) Option {
+	return func(s *Simulator) {
+		s.arriveTimeout = d
+	}
+}
+
+// WithLatency sets per link latency function, replacing DefaultLatency.
+func WithLatency(fn func(from, to int) time.Duration) Option {
+	return func(s *Simulator) {
+		s.latency = fn
+	}
+}
+
+// WithMaxDuration bounds propagation of each message sent with SendMessage
+// by the given duration, so log contains nodes reached so far.
+func WithMaxDuration(d time.Duration) Option {
+	return func(s *Simulator) {
+		s.maxDuration = d
+	}
+}
+
+// WithSeed seeds simulator's own random source used for picking peers to
+// push messages to, so runs are reproducible, as far as goroutines
+// scheduling allows.
+func WithSeed(seed int64) Option {
+	return func(s *Simulator) {
+		s.rand = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithProgress sets the function to report simulation progress to.
+func WithProgress(fn propagation.ProgressFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.Progress) {}
+		}
+		s.progress = fn
+	}
+}
+
+// WithEvents sets the function to report each message delivery to,
+// as it happens during simulation.
+func WithEvents(fn propagation.EventFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.LogEntry) {}
+		}
+		s.events = fn
+	}
+}
@@ -0,0 +1,268 @@
+// Package eth implements Ethereum transactions and blocks propagation
+// simulator, modeling eth/66 gossip heuristics of go-ethereum. Node
+// receiving new transaction pushes it to square root of its peers which
+// don't know it yet, and announces its hash to the rest of them
+// (NewPooledTransactionHashes). Node receiving announcement waits for the
+// transaction to be pushed by other peers, and fetches it from the
+// announcer otherwise (GetPooledTransactions). Blocks are pushed the same
+// way, but announced (NewBlockHashes) only once they're imported.
+package eth
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/propagation/gossip"
+)
+
+// hashSize is the size of message hash in announcements and requests.
+const hashSize = 32
+
+// Simulator is responsible for running eth propagation simulation.
+type Simulator struct {
+	data          *graph.Graph
+	peers         map[int][]int
+	blocks        bool          // propagate blocks rather than transactions
+	importTime    time.Duration // block import time, before announcing it
+	arriveTimeout time.Duration
+	latency       func(from, to int) time.Duration
+	maxDuration   time.Duration // zero if propagation is unbounded
+	progress      propagation.ProgressFunc
+	events        propagation.EventFunc
+
+	randMx sync.Mutex
+	rand   *rand.Rand
+}
+
+// NewSimulator initializes new simulator for the given graph data.
+func NewSimulator(data *graph.Graph, opts ...Option) *Simulator {
+	sim := &Simulator{
+		data:          data,
+		peers:         gossip.PrecalculatePeers(data),
+		arriveTimeout: DefaultArriveTimeout,
+		latency:       func(int, int) time.Duration { return DefaultLatency },
+		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
+		progress:      func(propagation.Progress) {},
+		events:        func(propagation.LogEntry) {},
+	}
+	for _, opt := range opts {
+		opt(sim)
+	}
+	return sim
+}
+
+// Stop stops simulator. Implements propagation.Simulator.
+func (s *Simulator) Stop() error {
+	return nil
+}
+
+// SendMessage sends single transaction (or block, see WithBlocks) of the
+// given size from node and tracks its propagation. Implements
+// propagation.Simulator. Messages propagate until all reachable nodes
+// have them, so ttl is ignored. Log has one entry per node, with the link
+// delivering the message first. It's safe to call SendMessage multiple
+// times, including concurrently.
+func (s *Simulator) SendMessage(startNodeIdx, ttl, size int) *propagation.Log {
+	r := s.newRun(size)
+	r.mx.Lock()
+	r.have[startNodeIdx] = true
+	r.relay(startNodeIdx)
+	r.mx.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	var deadline <-chan time.Time
+	if s.maxDuration > 0 {
+		timer := time.NewTimer(s.maxDuration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	select {
+	case <-done:
+	case <-deadline:
+	}
+
+	// in-flight messages are dropped once run is expired
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.expired = true
+	plog := r.entries.Log(s.data)
+	r.entries.Release()
+	traffic := r.traffic
+	plog.Traffic = &traffic
+	return plog
+}
+
+// messageKind defines the type of message exchanged between nodes.
+type messageKind int
+
+const (
+	kindPush     messageKind = iota // full message pushed to peer
+	kindAnnounce                    // message hash announcement
+	kindRequest                     // request of the announced message
+	kindResponse                    // full message sent on request
+)
+
+// run holds state of the single message propagation.
+type run struct {
+	sim        *Simulator
+	id         string // message identifier reported in log entries
+	start      time.Time
+	size       int
+	rand       *rand.Rand
+	wg         sync.WaitGroup // in-flight messages and timers
+	traffic    propagation.Traffic
+	mx         sync.Mutex
+	have       []bool
+	announcers [][]int                   // node -> peers announced the message to it
+	fetching   []bool                    // node waits to fetch announced message
+	known      map[gossip.LinkIndex]bool // node -> peer known to have the message
+	entries    propagation.LogEntries
+	expired    bool // max duration is reached
+}
+
+func (s *Simulator) newRun(size int) *run {
+	n := s.data.NumNodes()
+	s.randMx.Lock()
+	seed := s.rand.Int63()
+	s.randMx.Unlock()
+	return &run{
+		sim:        s,
+		id:         fmt.Sprintf("%016x", uint64(seed)),
+		start:      time.Now(),
+		size:       size,
+		rand:       rand.New(rand.NewSource(seed)),
+		have:       make([]bool, n),
+		announcers: make([][]int, n),
+		fetching:   make([]bool, n),
+		known:      make(map[gossip.LinkIndex]bool),
+	}
+}
+
+// relay propagates the message node just got: pushes it to square root of
+// peers not knowing it, and announces it to the rest of them, right away
+// for transactions or after import for blocks. Caller should hold the lock.
+func (r *run) relay(node int) {
+	peers := r.unaware(node)
+	push := int(math.Sqrt(float64(len(peers))))
+	for _, peer := range peers[:push] {
+		r.send(node, peer, kindPush)
+	}
+	if r.sim.blocks {
+		r.after(r.sim.importTime, func() {
+			for _, peer := range r.unaware(node) {
+				r.send(node, peer, kindAnnounce)
+			}
+		})
+		return
+	}
+	for _, peer := range peers[push:] {
+		r.send(node, peer, kindAnnounce)
+	}
+}
+
+// unaware returns node's peers in random order, except ones known to have
+// the message. Caller should hold the lock.
+func (r *run) unaware(node int) []int {
+	var ret []int
+	for _, peer := range r.sim.peers[node] {
+		if !r.known[gossip.LinkIndex{From: node, To: peer}] {
+			ret = append(ret, peer)
+		}
+	}
+	r.rand.Shuffle(len(ret), func(i, j int) { ret[i], ret[j] = ret[j], ret[i] })
+	return ret
+}
+
+// send starts sending message of the given kind from node to its peer.
+// Caller should hold the lock.
+func (r *run) send(from, to int, kind messageKind) {
+	switch kind {
+	case kindPush, kindResponse:
+		r.traffic.AddPayload(r.size)
+	default:
+		r.traffic.AddControl(hashSize)
+	}
+	if kind != kindRequest {
+		r.known[gossip.LinkIndex{From: from, To: to}] = true
+	}
+	r.after(r.sim.latency(from, to), func() {
+		r.receive(to, from, kind)
+	})
+}
+
+// after calls fn with the lock held once d passes, unless run is expired
+// by then.
+func (r *run) after(d time.Duration, fn func()) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		time.Sleep(d)
+		r.mx.Lock()
+		defer r.mx.Unlock()
+		if r.expired {
+			return
+		}
+		fn()
+	}()
+}
+
+// receive handles message of the given kind received by node from peer.
+// Caller should hold the lock.
+func (r *run) receive(node, peer int, kind messageKind) {
+	switch kind {
+	case kindRequest:
+		r.send(node, peer, kindResponse)
+		return
+	case kindAnnounce:
+		r.known[gossip.LinkIndex{From: node, To: peer}] = true
+		if r.have[node] {
+			return
+		}
+		r.announcers[node] = append(r.announcers[node], peer)
+		if !r.fetching[node] {
+			r.fetching[node] = true
+			r.after(r.sim.arriveTimeout, func() { r.fetch(node) })
+		}
+		return
+	}
+
+	r.known[gossip.LinkIndex{From: node, To: peer}] = true
+	if r.have[node] {
+		return
+	}
+	r.have[node] = true
+	r.complete(node, peer)
+	r.relay(node)
+}
+
+// fetch requests announced message from the first announcer, unless it
+// has been pushed meanwhile. Caller should hold the lock.
+func (r *run) fetch(node int) {
+	if r.have[node] {
+		return
+	}
+	r.send(node, r.announcers[node][0], kindRequest)
+}
+
+// complete reports node receiving the message from peer. Caller should
+// hold the lock.
+func (r *run) complete(node, peer int) {
+	entry := propagation.NewLogEntry(time.Now(), r.start, peer, node)
+	entry.Msg = r.id
+	r.entries.Add(*entry)
+	r.sim.events(*entry)
+	r.sim.progress(propagation.Progress{
+		Phase: propagation.PhaseCollect,
+		Done:  r.entries.Len() + 1, // including sender
+		Total: r.sim.data.NumNodes(),
+	})
+}
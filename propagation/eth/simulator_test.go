@@ -0,0 +1,124 @@
+package eth
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+)
+
+// node implements string-only graph.Node
+type node string
+
+func (n node) ID() string { return string(n) }
+
+// star returns network of n leaves connected to the node 0.
+func star(n int) *graph.Graph {
+	g := graph.NewGraph()
+	for i := 0; i <= n; i++ {
+		g.AddNode(node(strconv.Itoa(i)))
+	}
+	for i := 1; i <= n; i++ {
+		g.AddLink("0", strconv.Itoa(i))
+	}
+	return g
+}
+
+// line returns network of n nodes connected one after another.
+func line(n int) *graph.Graph {
+	g := graph.NewGraph()
+	for i := 0; i < n; i++ {
+		g.AddNode(node(strconv.Itoa(i)))
+	}
+	for i := 1; i < n; i++ {
+		g.AddLink(strconv.Itoa(i-1), strconv.Itoa(i))
+	}
+	return g
+}
+
+// timestamps returns time each node is reached at, except the sender 0.
+func timestamps(plog *propagation.Log) map[int]time.Duration {
+	ret := make(map[int]time.Duration)
+	for i, nodes := range plog.Nodes {
+		for _, n := range nodes {
+			ret[n] = time.Duration(plog.Timestamps[i]) * time.Millisecond
+		}
+	}
+	delete(ret, 0)
+	return ret
+}
+
+func TestSendMessage(t *testing.T) {
+	latency, timeout := 5*time.Millisecond, 50*time.Millisecond
+	sim := NewSimulator(star(16),
+		WithLatency(func(int, int) time.Duration { return latency }),
+		WithArriveTimeout(timeout),
+		WithSeed(1),
+	)
+	defer sim.Stop()
+
+	// center pushes to sqrt(16) leaves, and the rest of them fetch
+	// announced message after timeout
+	plog := sim.SendMessage(0, 10, 1000)
+	reached := timestamps(plog)
+	if len(reached) != 16 {
+		t.Fatalf("Expected all 16 leaves to be reached, got %d", len(reached))
+	}
+	var pushed int
+	for _, ts := range reached {
+		if ts < timeout {
+			pushed++
+		} else if ts < timeout+2*latency {
+			t.Fatalf("Expected fetched message to take timeout and round trip, got %v", ts)
+		}
+	}
+	if pushed != 4 {
+		t.Fatalf("Expected 4 leaves to get pushed message, got %d", pushed)
+	}
+	if got := plog.Traffic.PayloadMessages; got != 16 {
+		t.Fatalf("Expected 16 payload messages, got %d", got)
+	}
+	if got := plog.Traffic.ControlMessages; got != 24 {
+		t.Fatalf("Expected 12 announcements and 12 requests, got %d", got)
+	}
+}
+
+func TestSendBlock(t *testing.T) {
+	latency, timeout, importTime := 5*time.Millisecond, 20*time.Millisecond, 50*time.Millisecond
+	sim := NewSimulator(star(9),
+		WithLatency(func(int, int) time.Duration { return latency }),
+		WithArriveTimeout(timeout),
+		WithBlocks(importTime),
+	)
+	defer sim.Stop()
+
+	// block is announced only after import
+	reached := timestamps(sim.SendMessage(0, 10, 1000))
+	if len(reached) != 9 {
+		t.Fatalf("Expected all 9 leaves to be reached, got %d", len(reached))
+	}
+	for _, ts := range reached {
+		if ts > latency+timeout && ts < importTime+timeout {
+			t.Fatalf("Expected announced block to be fetched after import, got %v", ts)
+		}
+	}
+}
+
+func TestMaxDuration(t *testing.T) {
+	sim := NewSimulator(line(10),
+		WithLatency(func(int, int) time.Duration { return 20 * time.Millisecond }),
+		WithMaxDuration(100*time.Millisecond),
+	)
+	defer sim.Stop()
+
+	start := time.Now()
+	reached := timestamps(sim.SendMessage(0, 10, 1000))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected propagation to be bounded, took %v", elapsed)
+	}
+	if len(reached) == 0 || len(reached) >= 9 {
+		t.Fatalf("Expected some of nodes to be reached, got %d", len(reached))
+	}
+}
@@ -0,0 +1,178 @@
+package propagation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EventFunc is called by simulators for each message sending as soon
+// as it's observed, so events can be streamed while simulation is
+// running. It may be called concurrently and should not block for long.
+type EventFunc func(LogEntry)
+
+// Event is a typed simulation event, one of SendEvent, ReceiveEvent,
+// DropEvent, ConnUpEvent, ConnDownEvent, NodeDownEvent or NodeUpEvent.
+// Unlike log entries, which hold deliveries only, events describe what
+// happened to messages and the network during simulation, so drops and
+// reconnects can be analyzed as well. Timestamps are in milliseconds
+// since the start of message propagation, like LogEntry ones.
+type Event interface {
+	Timestamp() int64
+}
+
+// ObserverFunc is called by simulators for each typed event as soon as
+// it happens. It may be called concurrently and should not block for long.
+type ObserverFunc func(Event)
+
+// SendEvent is reported when node sends the message to its peer.
+type SendEvent struct {
+	Ts       int64
+	From, To int
+	Msg      string // message identifier, optional
+}
+
+// ReceiveEvent is reported when node accepts the message sent by its peer,
+// either for the first time or as duplicate. It corresponds to LogEntry.
+type ReceiveEvent struct {
+	Ts       int64
+	From, To int
+	Msg      string // message identifier, optional
+}
+
+// DropReason describes why message was dropped.
+type DropReason string
+
+// Known drop reasons.
+const (
+	DropLoss      DropReason = "loss"      // lost by link
+	DropNodeDown  DropReason = "down"      // receiver is down
+	DropRateLimit DropReason = "ratelimit" // receiver's rate limit exceeded
+	DropClockSkew DropReason = "clockskew" // expired or from the future by receiver's clock
+	DropRefused   DropReason = "refused"   // refused by receiver's config
+)
+
+// DropEvent is reported when message sent by node never reaches its peer.
+type DropEvent struct {
+	Ts       int64
+	From, To int
+	Msg      string // message identifier, optional
+	Reason   DropReason
+}
+
+// ConnUpEvent is reported when connection between nodes is established.
+type ConnUpEvent struct {
+	Ts       int64
+	From, To int
+}
+
+// ConnDownEvent is reported when connection between nodes is broken.
+type ConnDownEvent struct {
+	Ts       int64
+	From, To int
+}
+
+// NodeDownEvent is reported when node is stopped.
+type NodeDownEvent struct {
+	Ts   int64
+	Node int
+}
+
+// NodeUpEvent is reported when node is started, i.e. restarted after
+// failure.
+type NodeUpEvent struct {
+	Ts   int64
+	Node int
+}
+
+// Timestamp implements Event interface.
+func (e SendEvent) Timestamp() int64 { return e.Ts }
+
+// Timestamp implements Event interface.
+func (e ReceiveEvent) Timestamp() int64 { return e.Ts }
+
+// Timestamp implements Event interface.
+func (e DropEvent) Timestamp() int64 { return e.Ts }
+
+// Timestamp implements Event interface.
+func (e ConnUpEvent) Timestamp() int64 { return e.Ts }
+
+// Timestamp implements Event interface.
+func (e ConnDownEvent) Timestamp() int64 { return e.Ts }
+
+// Timestamp implements Event interface.
+func (e NodeDownEvent) Timestamp() int64 { return e.Ts }
+
+// Timestamp implements Event interface.
+func (e NodeUpEvent) Timestamp() int64 { return e.Ts }
+
+// ReceiveEvents returns EventFunc reporting log entries to fn as
+// ReceiveEvents, for simulators reporting deliveries only.
+func ReceiveEvents(fn ObserverFunc) EventFunc {
+	return func(entry LogEntry) {
+		fn(ReceiveEvent{Ts: entry.Ts, From: entry.From, To: entry.To, Msg: entry.Msg})
+	}
+}
+
+// EventCounts counts typed events of each kind, and drops by reason.
+// Zero value is ready to use, and Observe is safe for concurrent use.
+type EventCounts struct {
+	mx        sync.Mutex
+	Sent      int64
+	Received  int64
+	Drops     map[DropReason]int64
+	ConnUps   int64
+	ConnDowns int64
+	NodeDowns int64
+	NodeUps   int64
+}
+
+// Observe counts the event. It's ObserverFunc.
+func (c *EventCounts) Observe(e Event) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	switch e := Unwrap(e).(type) {
+	case SendEvent:
+		c.Sent++
+	case ReceiveEvent:
+		c.Received++
+	case DropEvent:
+		if c.Drops == nil {
+			c.Drops = make(map[DropReason]int64)
+		}
+		c.Drops[e.Reason]++
+	case ConnUpEvent:
+		c.ConnUps++
+	case ConnDownEvent:
+		c.ConnDowns++
+	case NodeDownEvent:
+		c.NodeDowns++
+	case NodeUpEvent:
+		c.NodeUps++
+	}
+}
+
+// String implements Stringer interface for EventCounts.
+func (c *EventCounts) String() string {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	reasons := make([]string, 0, len(c.Drops))
+	for reason := range c.Drops {
+		reasons = append(reasons, string(reason))
+	}
+	sort.Strings(reasons)
+	drops := make([]string, len(reasons))
+	var total int64
+	for i, reason := range reasons {
+		n := c.Drops[DropReason(reason)]
+		drops[i] = fmt.Sprintf("%s: %d", reason, n)
+		total += n
+	}
+	s := fmt.Sprintf("sent: %d, received: %d, dropped: %d", c.Sent, c.Received, total)
+	if len(drops) > 0 {
+		s += " (" + strings.Join(drops, ", ") + ")"
+	}
+	return s + fmt.Sprintf(", connections up/down: %d/%d, nodes down/up: %d/%d",
+		c.ConnUps, c.ConnDowns, c.NodeDowns, c.NodeUps)
+}
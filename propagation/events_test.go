@@ -0,0 +1,24 @@
+package propagation
+
+import "testing"
+
+func TestEventCounts(t *testing.T) {
+	var counts EventCounts
+	events := ReceiveEvents(counts.Observe)
+	events(LogEntry{Ts: 10, From: 0, To: 1})
+	events(LogEntry{Ts: 20, From: 1, To: 2})
+	counts.Observe(SendEvent{From: 0, To: 1})
+	counts.Observe(DropEvent{From: 1, To: 3, Reason: DropLoss})
+	counts.Observe(DropEvent{From: 2, To: 3, Reason: DropLoss})
+	counts.Observe(DropEvent{From: 1, To: 2, Reason: DropNodeDown})
+	counts.Observe(NodeDownEvent{Node: 3})
+	counts.Observe(ConnDownEvent{From: 0, To: 3})
+
+	if counts.Sent != 1 || counts.Received != 2 || counts.NodeDowns != 1 || counts.ConnDowns != 1 {
+		t.Fatalf("Unexpected event counts: %s", &counts)
+	}
+	want := "sent: 1, received: 2, dropped: 3 (down: 1, loss: 2), connections up/down: 0/1, nodes down/up: 1/0"
+	if got := counts.String(); got != want {
+		t.Fatalf("Expected '%s', got '%s'", want, got)
+	}
+}
@@ -0,0 +1,99 @@
+package propagation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Supported propagation log encoding formats.
+const (
+	FormatJSON    = "json"
+	FormatProto   = "pb"      // protobuf, see pb/log.proto for schema
+	FormatMsgpack = "msgpack" // MessagePack map with the same keys as JSON
+)
+
+// FormatFromPath guesses log format by the file extension, ignoring
+// compression extension like ".gz". It defaults to JSON.
+func FormatFromPath(path string) string {
+	path = strings.TrimSuffix(path, ".gz")
+	switch strings.TrimPrefix(filepath.Ext(path), ".") {
+	case FormatProto:
+		return FormatProto
+	case FormatMsgpack, "mp":
+		return FormatMsgpack
+	default:
+		return FormatJSON
+	}
+}
+
+// Encode writes log to w in the given format.
+func (l *Log) Encode(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON, "":
+		return json.NewEncoder(w).Encode(l)
+	case FormatProto:
+		data, err := l.marshalProto()
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case FormatMsgpack:
+		enc := msgpack.NewEncoder(w)
+		enc.SetCustomStructTag("json")
+		return enc.Encode(l)
+	default:
+		return fmt.Errorf("unknown log format '%s'", format)
+	}
+}
+
+// DecodeLog reads log in the given format from r.
+func DecodeLog(r io.Reader, format string) (*Log, error) {
+	l := &Log{}
+	switch format {
+	case FormatJSON, "":
+		if err := json.NewDecoder(r).Decode(&l); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatProto:
+		err = l.unmarshalProto(data)
+	case FormatMsgpack:
+		dec := msgpack.NewDecoder(bytes.NewReader(data))
+		dec.SetCustomStructTag("json")
+		if err = dec.Decode(l); err == nil {
+			err = l.check()
+		}
+	default:
+		err = fmt.Errorf("unknown log format '%s'", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// check validates that log fields hold the same number of steps.
+func (l *Log) check() error {
+	if len(l.Links) != len(l.Timestamps) || len(l.Nodes) != len(l.Timestamps) {
+		return errors.New("timestamps, links and nodes lengths mismatch")
+	}
+	if l.Messages != nil && len(l.Messages) != len(l.Timestamps) {
+		return errors.New("timestamps and messages lengths mismatch")
+	}
+	return nil
+}
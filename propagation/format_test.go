@@ -0,0 +1,119 @@
+package propagation
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	plog := NewLog(3)
+	plog.AddStep(0, []int{0, 1}, []int{0})
+	plog.AddStep(150, []int{1, 2, 1, 3}, []int{2, 3})
+	plog.AddStep(70000, []int{3, 40000}, []int{-1})
+	plog.Traffic = &Traffic{
+		PayloadMessages: 3,
+		PayloadBytes:    1200,
+		ControlMessages: 10,
+		ControlBytes:    320,
+	}
+	plog.Offline = &Offline{
+		DelayedMessages: 2,
+		DelayMs:         900,
+		MaxDelayMs:      500,
+	}
+	plog.Reliability = &Reliability{
+		Lost:            7,
+		Retransmissions: 6,
+		Failed:          1,
+		DelayMs:         300,
+		MaxDelayMs:      120,
+	}
+	plog.Mesh = &Mesh{
+		Grafts:           12,
+		Prunes:           9,
+		MeshDeliveries:   2,
+		GossipDeliveries: 1,
+	}
+	plog.Sampling = &Sampling{
+		Rate:     10,
+		Recorded: 3,
+		Skipped:  25,
+	}
+	plog.Meta = &Meta{
+		Version:       "v1.2.0",
+		Commit:        "5295082",
+		Params:        map[string]string{"algorithm": "gossip", "ttl": "10"},
+		GraphChecksum: "sha256:00ff",
+	}
+
+	for _, format := range []string{FormatJSON, FormatProto, FormatMsgpack} {
+		var buf bytes.Buffer
+		if err := plog.Encode(&buf, format); err != nil {
+			t.Fatalf("%s: encode: %v", format, err)
+		}
+		got, err := DecodeLog(&buf, format)
+		if err != nil {
+			t.Fatalf("%s: decode: %v", format, err)
+		}
+		if !reflect.DeepEqual(got, plog) {
+			t.Fatalf("%s: expected %v, got %v", format, plog, got)
+		}
+	}
+}
+
+func TestEncodeDecodeMessages(t *testing.T) {
+	plog := NewLog(2)
+	plog.AddStep(10, []int{0, 1, 0, 2}, []int{0, 1})
+	plog.AddStep(20, []int{2, 3}, []int{2})
+	plog.Messages = [][]string{{"a", "b"}, {"b"}}
+
+	for _, format := range []string{FormatJSON, FormatProto, FormatMsgpack} {
+		var buf bytes.Buffer
+		if err := plog.Encode(&buf, format); err != nil {
+			t.Fatalf("%s: encode: %v", format, err)
+		}
+		got, err := DecodeLog(&buf, format)
+		if err != nil {
+			t.Fatalf("%s: decode: %v", format, err)
+		}
+		if !reflect.DeepEqual(got, plog) {
+			t.Fatalf("%s: expected %v, got %v", format, plog, got)
+		}
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	plog := NewLog(1)
+	plog.AddStep(10, []int{0, 1}, []int{0})
+
+	for _, format := range []string{FormatProto, FormatMsgpack} {
+		var buf bytes.Buffer
+		plog.Encode(&buf, format)
+		data := buf.Bytes()
+		_, err := DecodeLog(bytes.NewReader(data[:len(data)-1]), format)
+		if err == nil {
+			t.Fatalf("%s: expected error for truncated data", format)
+		}
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	var tests = []struct {
+		path     string
+		expected string
+	}{
+		{"propagation.json", FormatJSON},
+		{"propagation.json.gz", FormatJSON},
+		{"propagation.pb", FormatProto},
+		{"propagation.pb.gz", FormatProto},
+		{"propagation.msgpack", FormatMsgpack},
+		{"-", FormatJSON},
+	}
+	for _, test := range tests {
+		got := FormatFromPath(test.path)
+		if got != test.expected {
+			t.Fatalf("%s: expected %s, got %s", test.path, test.expected, got)
+		}
+	}
+}
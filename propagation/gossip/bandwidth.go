@@ -0,0 +1,115 @@
+package gossip
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// Bandwidth describes node's connection bandwidth, in bytes per second.
+// Zero values mean unlimited bandwidth.
+type Bandwidth struct {
+	Up   float64
+	Down float64
+}
+
+// mbit converts Mbit/s into bytes per second.
+func mbit(n float64) float64 {
+	return n * 1000 * 1000 / 8
+}
+
+// BandwidthClasses defines known bandwidth classes by name.
+var BandwidthClasses = map[string]Bandwidth{
+	"mobile": {Up: mbit(2), Down: mbit(10)},
+	"dsl":    {Up: mbit(1), Down: mbit(20)},
+	"cable":  {Up: mbit(10), Down: mbit(100)},
+	"fiber":  {Up: mbit(500), Down: mbit(1000)},
+	"dc":     {Up: mbit(10000), Down: mbit(10000)},
+}
+
+// BandwidthClass represents share of nodes with the given bandwidth.
+type BandwidthClass struct {
+	Name      string
+	Bandwidth Bandwidth
+	Share     float64 // fraction of nodes, shares are normalized
+}
+
+// ParseBandwidthClasses parses classes mix in form of comma-separated
+// name=share pairs, i.e. "mobile=0.3,dsl=0.5,fiber=0.2". Names should
+// be from BandwidthClasses.
+func ParseBandwidthClasses(s string) ([]BandwidthClass, error) {
+	var (
+		classes []BandwidthClass
+		shares  []float64
+	)
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		bw, ok := BandwidthClasses[kv[0]]
+		if !ok {
+			return nil, fmt.Errorf("unknown bandwidth class '%s'", kv[0])
+		}
+		share := 1.0
+		if len(kv) == 2 {
+			var err error
+			share, err = strconv.ParseFloat(kv[1], 64)
+			if err != nil || share < 0 {
+				return nil, fmt.Errorf("wrong share for bandwidth class '%s'", kv[0])
+			}
+		}
+		classes = append(classes, BandwidthClass{Name: kv[0], Bandwidth: bw, Share: share})
+		shares = append(shares, share)
+	}
+	if err := propagation.CheckShares(shares); err != nil {
+		return nil, fmt.Errorf("wrong bandwidth classes '%s': %v", s, err)
+	}
+	return classes, nil
+}
+
+// WithBandwidth sets bandwidth for each node, so message transfer takes
+// size/bandwidth in addition to the propagation delay. Bandwidth of the
+// link is the minimum of sender's uplink and receiver's downlink.
+func WithBandwidth(fn func(node int) Bandwidth) Option {
+	return func(s *Simulator) {
+		s.bandwidth = make([]Bandwidth, len(s.nodes))
+		for i := range s.bandwidth {
+			s.bandwidth[i] = fn(i)
+		}
+	}
+}
+
+// WithBandwidthClasses randomly assigns bandwidth classes to nodes,
+// proportionally to their shares.
+func WithBandwidthClasses(classes ...BandwidthClass) Option {
+	return func(s *Simulator) {
+		if len(classes) == 0 {
+			return
+		}
+		shares := make([]float64, len(classes))
+		for i, c := range classes {
+			shares[i] = c.Share
+		}
+		class := propagation.SplitShares(s.rand.Perm(len(s.nodes)), shares)
+		s.bandwidth = make([]Bandwidth, len(s.nodes))
+		for idx, i := range class {
+			s.bandwidth[idx] = classes[i].Bandwidth
+		}
+	}
+}
+
+// transferTime returns time needed to transfer size bytes from node to its peer.
+func (s *Simulator) transferTime(from, to, size int) time.Duration {
+	if s.bandwidth == nil {
+		return 0
+	}
+	rate := s.bandwidth[from].Up
+	if down := s.bandwidth[to].Down; down > 0 && (rate == 0 || down < rate) {
+		rate = down
+	}
+	if rate == 0 {
+		return 0
+	}
+	return time.Duration(float64(size) / rate * float64(time.Second))
+}
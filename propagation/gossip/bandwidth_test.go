@@ -0,0 +1,18 @@
+package gossip
+
+import "testing"
+
+func TestParseBandwidthClasses(t *testing.T) {
+	classes, err := ParseBandwidthClasses("mobile=0.3,dsl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(classes) != 2 || classes[0].Share != 0.3 || classes[1].Share != 1 {
+		t.Fatalf("unexpected classes: %+v", classes)
+	}
+	for _, s := range []string{"mobile=0,dsl=0", "mobile=-1", "modem=1"} {
+		if _, err := ParseBandwidthClasses(s); err == nil {
+			t.Fatalf("expected error for '%s'", s)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package gossip
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Pause pauses the simulation until Resume is called: scheduled events,
+// including message deliveries, don't run while paused. Implements
+// propagation.Pauser.
+func (s *Simulator) Pause() {
+	s.pauseMx.Lock()
+	defer s.pauseMx.Unlock()
+	if s.pausing {
+		return
+	}
+	s.pausing = true
+	s.pausedAt = s.clock.now()
+	s.sched.pause()
+}
+
+// Resume resumes paused simulation. Implements propagation.Pauser.
+func (s *Simulator) Resume() {
+	s.pauseMx.Lock()
+	defer s.pauseMx.Unlock()
+	if !s.pausing {
+		return
+	}
+	s.paused += s.clock.since(s.pausedAt)
+	s.pausing = false
+	s.sched.resume()
+}
+
+// pausedTotal returns total time spent in pause, including current pause.
+func (s *Simulator) pausedTotal() time.Duration {
+	s.pauseMx.Lock()
+	defer s.pauseMx.Unlock()
+	if s.pausing {
+		return s.paused + s.clock.since(s.pausedAt)
+	}
+	return s.paused
+}
+
+// checkpoint represents serialized simulator state.
+type checkpoint struct {
+	Nodes int              `json:"nodes"`
+	Peers map[int][]int    `json:"peers"`
+	Down  []int            `json:"down"`
+	Seen  map[int][]string `json:"seen"` // hex-encoded contents of seen messages
+}
+
+// Checkpoint writes simulator state (current topology, stopped nodes and
+// messages seen by each node) in JSON format. Scheduled events, i.e.
+// deliveries in flight, are not part of the checkpoint: message run
+// interrupted by taking it is lost, and should be repeated after restore.
+// Implements propagation.Checkpointer.
+func (s *Simulator) Checkpoint(w io.Writer) error {
+	cp := checkpoint{
+		Nodes: s.nodeCount(),
+		Peers: make(map[int][]int),
+		Seen:  make(map[int][]string),
+	}
+
+	s.mx.RLock()
+	for idx, peers := range s.peers {
+		cp.Peers[idx] = append([]int(nil), peers...)
+	}
+	for idx, down := range s.down {
+		if down {
+			cp.Down = append(cp.Down, idx)
+		}
+	}
+	s.mx.RUnlock()
+
+	s.seenMx.Lock()
+	for idx, seen := range s.seen {
+		for content := range seen {
+			cp.Seen[idx] = append(cp.Seen[idx], hex.EncodeToString([]byte(content)))
+		}
+	}
+	s.seenMx.Unlock()
+
+	return json.NewEncoder(w).Encode(cp)
+}
+
+// Restore restores simulator state previously saved with Checkpoint.
+// Simulator should be created for the same network graph.
+// Implements propagation.Checkpointer.
+func (s *Simulator) Restore(r io.Reader) error {
+	var cp checkpoint
+	if err := json.NewDecoder(r).Decode(&cp); err != nil {
+		return fmt.Errorf("decode checkpoint: %v", err)
+	}
+	if n := s.nodeCount(); cp.Nodes != n {
+		return fmt.Errorf("checkpoint has %d nodes, but network has %d", cp.Nodes, n)
+	}
+
+	seen := make([]map[string]bool, cp.Nodes)
+	requested := make([]map[string]bool, cp.Nodes)
+	for idx := range seen {
+		seen[idx] = make(map[string]bool)
+		requested[idx] = make(map[string]bool)
+	}
+	for idx, contents := range cp.Seen {
+		if idx < 0 || idx >= cp.Nodes {
+			return fmt.Errorf("wrong node index %d in checkpoint", idx)
+		}
+		for _, str := range contents {
+			content, err := hex.DecodeString(str)
+			if err != nil {
+				return fmt.Errorf("decode message content: %v", err)
+			}
+			seen[idx][string(content)] = true
+		}
+	}
+
+	for idx, peers := range cp.Peers {
+		if idx < 0 || idx >= cp.Nodes {
+			return fmt.Errorf("wrong node index %d in checkpoint", idx)
+		}
+		for _, peer := range peers {
+			if peer < 0 || peer >= cp.Nodes {
+				return fmt.Errorf("wrong peer index %d of node %d in checkpoint", peer, idx)
+			}
+		}
+	}
+	down := make(map[int]bool)
+	for _, idx := range cp.Down {
+		if idx < 0 || idx >= cp.Nodes {
+			return fmt.Errorf("wrong stopped node index %d in checkpoint", idx)
+		}
+		down[idx] = true
+	}
+
+	s.mx.Lock()
+	s.peers = cp.Peers
+	s.down = down
+	s.mx.Unlock()
+
+	s.seenMx.Lock()
+	s.seen = seen
+	s.requested = requested
+	s.seenMx.Unlock()
+	return nil
+}
@@ -0,0 +1,90 @@
+package gossip
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+)
+
+// node implements string-only graph.Node
+type node string
+
+func (n node) ID() string { return string(n) }
+
+// line returns network of n nodes connected one after another.
+func line(n int) *graph.Graph {
+	g := graph.NewGraph()
+	for i := 0; i < n; i++ {
+		g.AddNode(node(strconv.Itoa(i)))
+	}
+	for i := 1; i < n; i++ {
+		g.AddLink(strconv.Itoa(i-1), strconv.Itoa(i))
+	}
+	return g
+}
+
+// reached returns set of nodes reached by the message, including sender.
+func reached(plog *propagation.Log) map[int]bool {
+	ret := make(map[int]bool)
+	for _, nodes := range plog.Nodes {
+		for _, n := range nodes {
+			ret[n] = true
+		}
+	}
+	return ret
+}
+
+func TestCheckpointRestore(t *testing.T) {
+	sim := NewSimulator(line(4), 4, 0)
+	defer sim.Stop()
+	if err := sim.DisconnectNodes(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.StopNode(3); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := sim.Checkpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewSimulator(line(4), 4, 0)
+	defer restored.Stop()
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := reached(restored.SendMessage(0, 10, 100)); len(got) != 2 {
+		t.Fatalf("expected message to reach nodes 0 and 1 only, got %v", got)
+	}
+	if !restored.isDown(3) {
+		t.Fatal("expected node 3 to stay stopped")
+	}
+}
+
+func TestRestoreWrongIndices(t *testing.T) {
+	var tests = []struct {
+		name       string
+		checkpoint string
+		err        string
+	}{
+		{"nodes", `{"nodes": 5}`, "checkpoint has 5 nodes"},
+		{"seen", `{"nodes": 4, "seen": {"4": ["00"]}}`, "wrong node index 4"},
+		{"peers", `{"nodes": 4, "peers": {"-1": [0]}}`, "wrong node index -1"},
+		{"peer", `{"nodes": 4, "peers": {"0": [1, 7]}}`, "wrong peer index 7 of node 0"},
+		{"down", `{"nodes": 4, "down": [4]}`, "wrong stopped node index 4"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sim := NewSimulator(line(4), 4, 0)
+			defer sim.Stop()
+			err := sim.Restore(strings.NewReader(test.checkpoint))
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package gossip
+
+import "sync"
+
+// ChokeParams defines Episub-style choking parameters. Nodes choke peers
+// that keep pushing duplicate payloads, so choked peers send only
+// announcements (IHAVE) and payload is pulled from them on demand. Choked
+// peer is unchoked when its announcements turn out to be the first ones.
+type ChokeParams struct {
+	DuplicatesThreshold int // choke peer after that many duplicates in a row
+	UnchokeThreshold    int // unchoke peer after that many first announcements
+	MinUnchoked         int // keep at least that many peers pushing eagerly
+}
+
+// DefaultChokeParams returns default choking parameters.
+func DefaultChokeParams() ChokeParams {
+	return ChokeParams{
+		DuplicatesThreshold: 2,
+		UnchokeThreshold:    1,
+		MinUnchoked:         2,
+	}
+}
+
+// link identifies direction between two nodes.
+type link struct {
+	from, to int
+}
+
+// choking keeps choking state of all nodes.
+type choking struct {
+	params ChokeParams
+
+	mx      sync.Mutex
+	choked  map[link]bool // sender's view: sender is choked by receiver
+	choking map[link]bool // receiver's view: receiver has choked the sender
+	dups    map[link]int  // duplicates in a row, by sender -> receiver
+	useful  map[link]int  // first announcements of choked sender
+	count   map[int]int   // number of peers choked by node
+}
+
+func newChoking(params ChokeParams) *choking {
+	return &choking{
+		params:  params,
+		choked:  make(map[link]bool),
+		choking: make(map[link]bool),
+		dups:    make(map[link]int),
+		useful:  make(map[link]int),
+		count:   make(map[int]int),
+	}
+}
+
+// isChoked reports whether sender should only announce messages to receiver.
+func (c *choking) isChoked(from, to int) bool {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.choked[link{from, to}]
+}
+
+// setChoked applies choke or unchoke message received by sender.
+func (c *choking) setChoked(from, to int, choked bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.choked[link{from, to}] = choked
+}
+
+// payload accounts for payload delivered to node by peer, and reports
+// whether node should choke the peer. peers is the number of node's peers.
+func (c *choking) payload(node, peer, peers int, first bool) bool {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	l := link{peer, node}
+	if first {
+		c.dups[l] = 0
+		return false
+	}
+	c.dups[l]++
+	if c.choking[l] || c.dups[l] < c.params.DuplicatesThreshold {
+		return false
+	}
+	if peers-c.count[node] <= c.params.MinUnchoked {
+		return false
+	}
+	c.choking[l] = true
+	c.count[node]++
+	return true
+}
+
+// announce accounts for the first announcement of the message received
+// by node from peer, and reports whether node should unchoke the peer.
+func (c *choking) announce(node, peer int) bool {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	l := link{peer, node}
+	if !c.choking[l] {
+		return false
+	}
+	c.useful[l]++
+	if c.useful[l] < c.params.UnchokeThreshold {
+		return false
+	}
+	c.choking[l] = false
+	c.useful[l] = 0
+	c.dups[l] = 0
+	c.count[node]--
+	return true
+}
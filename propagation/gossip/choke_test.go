@@ -0,0 +1,63 @@
+package gossip
+
+import "testing"
+
+func TestChokeDuplicates(t *testing.T) {
+	c := newChoking(DefaultChokeParams())
+
+	// duplicates run is broken by the first delivery
+	if c.payload(0, 1, 5, false) || c.payload(0, 1, 5, true) || c.payload(0, 1, 5, false) {
+		t.Fatal("expected no choke before duplicates threshold")
+	}
+	if !c.payload(0, 1, 5, false) {
+		t.Fatal("expected choke after a run of duplicates")
+	}
+	if c.payload(0, 1, 5, false) {
+		t.Fatal("expected no choke of already choked peer")
+	}
+
+	// node keeps at least MinUnchoked of its 5 peers unchoked
+	for _, peer := range []int{2, 3} {
+		c.payload(0, peer, 5, false)
+		if !c.payload(0, peer, 5, false) {
+			t.Fatalf("expected choke of peer %d", peer)
+		}
+	}
+	c.payload(0, 4, 5, false)
+	if c.payload(0, 4, 5, false) {
+		t.Fatal("expected no choke beyond MinUnchoked peers")
+	}
+}
+
+func TestChokeUnchoke(t *testing.T) {
+	c := newChoking(DefaultChokeParams())
+	if c.announce(0, 1) {
+		t.Fatal("expected no unchoke of not choked peer")
+	}
+
+	c.payload(0, 1, 3, false)
+	if !c.payload(0, 1, 3, false) {
+		t.Fatal("expected choke after a run of duplicates")
+	}
+	c.setChoked(1, 0, true)
+	if !c.isChoked(1, 0) || c.isChoked(0, 1) {
+		t.Fatal("expected only peer 1 to be choked by node 0")
+	}
+	if !c.announce(0, 1) {
+		t.Fatal("expected unchoke on a useful announcement")
+	}
+	c.setChoked(1, 0, false)
+	if c.isChoked(1, 0) {
+		t.Fatal("expected peer 1 to be unchoked")
+	}
+
+	// duplicates are counted from scratch after unchoke, and unchoked
+	// peer frees its slot for choking
+	if c.payload(0, 1, 3, false) {
+		t.Fatal("expected no choke right after unchoke")
+	}
+	c.payload(0, 2, 3, false)
+	if !c.payload(0, 2, 3, false) {
+		t.Fatal("expected choke of peer 2 after peer 1 is unchoked")
+	}
+}
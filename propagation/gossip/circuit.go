@@ -0,0 +1,55 @@
+package gossip
+
+// RelayedParams defines links going through libp2p circuit relays: both
+// nodes of relayed link are connected to the relay only, so messages over
+// the link traverse the relay, adding its latency and load.
+type RelayedParams struct {
+	Fraction float64 // fraction of links relayed through random relay nodes (0..1)
+	Relays   int     // number of random nodes acting as relays for Fraction
+
+	// Links maps links marked as relayed explicitly to their relay nodes,
+	// direction doesn't matter.
+	Links map[LinkIndex]int
+}
+
+// WithRelayedLinks makes links relayed, as marked in params.Links, and
+// random fraction of links relayed through random relay nodes, except
+// links of relay nodes themselves. Messages over relayed link take two
+// hops, so they pay latency and bandwidth of both of them, and contend
+// with other messages at relay uplink with WithQueueing.
+func WithRelayedLinks(params RelayedParams) Option {
+	return func(s *Simulator) {
+		circuits := make(map[LinkIndex]int)
+		add := func(from, to, relay int) {
+			if relay == from || relay == to {
+				return
+			}
+			circuits[LinkIndex{From: from, To: to}] = relay
+			circuits[LinkIndex{From: to, To: from}] = relay
+		}
+
+		n := len(s.nodes)
+		if params.Relays > n {
+			params.Relays = n
+		}
+		if params.Fraction > 0 && params.Relays > 0 {
+			relays := s.rand.Perm(n)[:params.Relays]
+			links := s.data.Links()
+			count := int(params.Fraction * float64(len(links)))
+			for _, idx := range s.rand.Perm(len(links))[:count] {
+				l := links[idx]
+				add(l.FromIdx(), l.ToIdx(), relays[s.rand.Intn(len(relays))])
+			}
+		}
+		for l, relay := range params.Links {
+			add(l.From, l.To, relay)
+		}
+		s.circuits = circuits
+	}
+}
+
+// RelayedLinks returns number of links going through relays, if set with
+// WithRelayedLinks.
+func (s *Simulator) RelayedLinks() int {
+	return len(s.circuits) / 2
+}
@@ -0,0 +1,47 @@
+package gossip
+
+import "time"
+
+// clock implements simulation time, which runs scale times faster than
+// real time, so all delays are shortened proportionally while timestamps
+// in the log stay the same.
+type clock struct {
+	epoch time.Time
+	scale float64
+}
+
+func newClock() *clock {
+	return &clock{
+		epoch: time.Now(),
+		scale: 1,
+	}
+}
+
+// WithTimeScale makes simulation run scale times faster than real time,
+// i.e. with scale 100, 100ms latency takes 1ms of real time. Timestamps
+// in the log are in simulation time, so they don't depend on scale.
+// Note that with high scales shortened delays become comparable with
+// scheduling overhead, which makes timings less precise.
+func WithTimeScale(scale float64) Option {
+	return func(s *Simulator) {
+		if scale > 0 {
+			s.clock.scale = scale
+		}
+	}
+}
+
+// now returns current simulation time.
+func (c *clock) now() time.Time {
+	elapsed := time.Since(c.epoch)
+	return c.epoch.Add(time.Duration(float64(elapsed) * c.scale))
+}
+
+// since returns simulation time elapsed since t.
+func (c *clock) since(t time.Time) time.Duration {
+	return c.now().Sub(t)
+}
+
+// real converts simulation duration into real time one.
+func (c *clock) real(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / c.scale)
+}
@@ -0,0 +1,82 @@
+package gossip
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CodingParams defines erasure-coded broadcast, where payload is encoded
+// into N fragments of size/K bytes each, propagated independently. Node
+// is able to decode the message once it holds any K fragments.
+type CodingParams struct {
+	K int // fragments needed to decode the message
+	N int // total fragments, N >= K
+}
+
+// ParseCoding parses erasure coding parameters in form of "k:n", i.e. "4:6".
+func ParseCoding(s string) (CodingParams, error) {
+	var params CodingParams
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return params, fmt.Errorf("wrong erasure coding '%s', expected k:n", s)
+	}
+	var err error
+	params.K, err = strconv.Atoi(parts[0])
+	if err != nil || params.K < 1 {
+		return params, fmt.Errorf("wrong number of fragments to decode '%s'", parts[0])
+	}
+	params.N, err = strconv.Atoi(parts[1])
+	if err != nil || params.N < params.K {
+		return params, fmt.Errorf("wrong total number of fragments '%s', should be at least %d", parts[1], params.K)
+	}
+	return params, nil
+}
+
+// WithErasureCoding enables erasure-coded broadcast with the given
+// parameters (see CodingParams). Log has one entry per node, with the
+// link delivering the K-th fragment, and traffic reflects the redundancy
+// of coding.
+func WithErasureCoding(params CodingParams) Option {
+	return func(s *Simulator) {
+		s.coding = &params
+	}
+}
+
+// fragmentSize returns the size of each fragment of the message of the
+// given size.
+func (p CodingParams) fragmentSize(size int) int {
+	return (size + p.K - 1) / p.K
+}
+
+// decoding tracks fragments received by nodes for a single message.
+type decoding struct {
+	k int
+
+	mx        sync.Mutex
+	fragments []map[int]bool // node -> fragments it holds
+}
+
+func newDecoding(k, nodes int) *decoding {
+	d := &decoding{
+		k:         k,
+		fragments: make([]map[int]bool, nodes),
+	}
+	for i := range d.fragments {
+		d.fragments[i] = make(map[int]bool)
+	}
+	return d
+}
+
+// deliver accounts for fragment delivered to node, and reports whether
+// node is able to decode the message with it for the first time.
+func (d *decoding) deliver(node, fragment int) bool {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if d.fragments[node][fragment] {
+		return false
+	}
+	d.fragments[node][fragment] = true
+	return len(d.fragments[node]) == d.k
+}
@@ -0,0 +1,63 @@
+package gossip
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// collector collects log entries of the single message run. Senders add
+// entries without waiting for them to be processed, so reporting overhead
+// doesn't affect message delivery and its timing.
+type collector struct {
+	expired int32 // set once max duration is reached
+
+	mx      sync.Mutex
+	entries propagation.LogEntries
+	notify  chan struct{} // signals new entries, never blocks senders
+}
+
+func newCollector() *collector {
+	return &collector{
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// add adds entry to the log, unless collector is expired.
+func (c *collector) add(entry propagation.LogEntry) {
+	c.mx.Lock()
+	if c.isExpired() {
+		c.mx.Unlock()
+		return
+	}
+	c.entries.Add(entry)
+	c.mx.Unlock()
+
+	select {
+	case c.notify <- struct{}{}:
+	default: // already signaled
+	}
+}
+
+// since returns copy of entries starting from the i-th one.
+func (c *collector) since(i int) []propagation.LogEntry {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	var ret []propagation.LogEntry
+	for ; i < c.entries.Len(); i++ {
+		ret = append(ret, *c.entries.At(i))
+	}
+	return ret
+}
+
+// expire makes collector drop entries added after that.
+func (c *collector) expire() {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	atomic.StoreInt32(&c.expired, 1)
+}
+
+func (c *collector) isExpired() bool {
+	return atomic.LoadInt32(&c.expired) == 1
+}
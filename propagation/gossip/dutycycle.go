@@ -0,0 +1,121 @@
+package gossip
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DutyCycleParams defines intermittent connectivity model, where a fraction
+// of nodes (i.e. mobile ones) periodically go offline and get back online.
+// Messages can't be delivered to or from offline node, so sender holds them
+// until both nodes are online.
+type DutyCycleParams struct {
+	Fraction float64       // fraction of nodes with duty cycle (0..1)
+	Online   time.Duration // online interval of each cycle
+	Offline  time.Duration // offline interval of each cycle
+}
+
+// ParseDutyCycle parses duty cycle in form of "fraction:online:offline",
+// i.e. "0.3:1s:500ms".
+func ParseDutyCycle(s string) (DutyCycleParams, error) {
+	var params DutyCycleParams
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return params, fmt.Errorf("wrong duty cycle '%s', expected fraction:online:offline", s)
+	}
+	var err error
+	params.Fraction, err = strconv.ParseFloat(parts[0], 64)
+	if err != nil || params.Fraction < 0 || params.Fraction > 1 {
+		return params, fmt.Errorf("wrong duty cycle fraction '%s'", parts[0])
+	}
+	params.Online, err = time.ParseDuration(parts[1])
+	if err != nil || params.Online <= 0 {
+		return params, fmt.Errorf("wrong duty cycle online interval '%s'", parts[1])
+	}
+	params.Offline, err = time.ParseDuration(parts[2])
+	if err != nil || params.Offline < 0 {
+		return params, fmt.Errorf("wrong duty cycle offline interval '%s'", parts[2])
+	}
+	return params, nil
+}
+
+// dutyCycle is the online/offline schedule of a single node.
+type dutyCycle struct {
+	online, offline time.Duration
+	phase           time.Duration // offset of the node's cycle start
+}
+
+// dutyCycles keeps schedules of nodes with duty cycle, relative
+// to the simulator start.
+type dutyCycles struct {
+	start time.Time
+	nodes map[int]dutyCycle
+}
+
+// WithDutyCycle makes random fraction of nodes go offline periodically,
+// with random phase of each node's cycle. Delays caused by offline nodes
+// are reported in the propagation log (see propagation.Offline).
+func WithDutyCycle(params DutyCycleParams) Option {
+	return func(s *Simulator) {
+		n := len(s.nodes)
+		count := int(params.Fraction * float64(n))
+		if count == 0 || params.Offline == 0 {
+			return
+		}
+		period := params.Online + params.Offline
+		s.dutyCycles = &dutyCycles{
+			start: s.clock.now(),
+			nodes: make(map[int]dutyCycle, count),
+		}
+		for _, idx := range s.rand.Perm(n)[:count] {
+			s.dutyCycles.nodes[idx] = dutyCycle{
+				online:  params.Online,
+				offline: params.Offline,
+				phase:   time.Duration(s.rand.Int63n(int64(period))),
+			}
+		}
+	}
+}
+
+// offlineFor returns time left until node gets back online, or zero
+// if node is online at t.
+func (d *dutyCycles) offlineFor(node int, t time.Time) time.Duration {
+	c, ok := d.nodes[node]
+	if !ok {
+		return 0
+	}
+	period := c.online + c.offline
+	pos := (t.Sub(d.start) + c.phase) % period
+	if pos < c.online {
+		return 0
+	}
+	return period - pos
+}
+
+// untilOnline returns time left until both nodes are online, and false
+// if they are never online at the same time.
+func (s *Simulator) untilOnline(from, to int) (time.Duration, bool) {
+	if s.dutyCycles == nil {
+		return 0, true
+	}
+	now := s.sched.now()
+	t := now
+	// nodes share cycle period, so they are either online together within
+	// a period, or never
+	for i := 0; i < maxOnlineWaits; i++ {
+		wait := s.dutyCycles.offlineFor(from, t)
+		if d := s.dutyCycles.offlineFor(to, t); d > wait {
+			wait = d
+		}
+		if wait == 0 {
+			return t.Sub(now), true
+		}
+		t = t.Add(wait)
+	}
+	return 0, false
+}
+
+// maxOnlineWaits bounds the number of offline intervals untilOnline skips.
+const maxOnlineWaits = 4
@@ -0,0 +1,35 @@
+package gossip
+
+import (
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// observe reports typed event of the payload message to observer,
+// timestamped relative to the message run start. Events of spam and
+// control messages are not reported.
+func (s *Simulator) observe(message Message, e propagation.Event) {
+	if message.kind != kindPayload || message.run.collector == nil {
+		return
+	}
+	ts := int64(s.runTime(message).Sub(message.run.start) / time.Millisecond)
+	id := message.run.id
+	switch e := e.(type) {
+	case propagation.SendEvent:
+		e.Ts, e.Msg = ts, id
+		s.observer(e)
+	case propagation.ReceiveEvent:
+		e.Ts, e.Msg = ts, id
+		s.observer(e)
+	case propagation.DropEvent:
+		e.Ts, e.Msg = ts, id
+		s.observer(e)
+	}
+}
+
+// sinceEpoch returns simulation time in milliseconds since simulator
+// creation, for timestamping events not tied to messages.
+func (s *Simulator) sinceEpoch() int64 {
+	return int64(s.clock.since(s.clock.epoch) / time.Millisecond)
+}
@@ -0,0 +1,116 @@
+package gossip
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// HandshakeParams defines connection setup cost of links established
+// lazily, on the first message sent over them, rather than kept open from
+// the start. Handshake takes RoundTrips link round trips before the first
+// message can go, and its messages count as control traffic.
+type HandshakeParams struct {
+	RoundTrips int           // round trips before connection is ready
+	Bytes      int           // total size of handshake messages, both directions
+	RTT        time.Duration // round trip of links without latency, see WithLatency
+}
+
+// DefaultHandshakeRTT is the round trip time of links without latency.
+const DefaultHandshakeRTT = 20 * time.Millisecond
+
+// handshakes lists known protocol stacks, with approximate sizes of their
+// handshakes (TLS one is dominated by certificates).
+var handshakes = map[string]HandshakeParams{
+	"tcp":    {RoundTrips: 1, Bytes: 180},
+	"tls":    {RoundTrips: 2, Bytes: 4500}, // TCP + TLS 1.3
+	"tls12":  {RoundTrips: 3, Bytes: 5000}, // TCP + TLS 1.2
+	"devp2p": {RoundTrips: 3, Bytes: 1200}, // TCP + RLPx auth/ack + Hello
+}
+
+// ParseHandshake returns handshake parameters of the protocol stack by
+// its name (tcp, tls, tls12 or devp2p).
+func ParseHandshake(name string) (HandshakeParams, error) {
+	params, ok := handshakes[name]
+	if !ok {
+		return params, fmt.Errorf("unknown handshake '%s', expected tcp, tls, tls12 or devp2p", name)
+	}
+	return params, nil
+}
+
+// connections keeps state of lazily established connections, which stay
+// open once established, including for the following messages.
+type connections struct {
+	params HandshakeParams
+
+	mx    sync.Mutex
+	ready map[link]time.Time // connection -> time it's ready to carry messages
+}
+
+// WithHandshake makes links established lazily, so the first message sent
+// over each link waits for connection handshake with the given parameters
+// (see HandshakeParams), or handshake of their transport, if nodes are
+// assigned ones with WithTransportClasses. Messages sent while handshake is
+// in progress wait for it to complete. By default all connections are
+// pre-established.
+func WithHandshake(params HandshakeParams) Option {
+	return func(s *Simulator) {
+		if params.RTT <= 0 {
+			params.RTT = DefaultHandshakeRTT
+		}
+		s.connections = &connections{
+			params: params,
+			ready:  make(map[link]time.Time),
+		}
+	}
+}
+
+// linkSetup returns time added to message transmission from node to its
+// peer by their transport and connection setup.
+func (s *Simulator) linkSetup(from, to int, message Message) time.Duration {
+	var d time.Duration
+	if t, ok := s.transport(from, to); ok {
+		d = t.Latency
+	}
+	return d + s.connect(from, to, message)
+}
+
+// connect returns time message from node to its peer has to wait for
+// the connection between them, starting handshake if it's not established
+// yet. Handshake traffic is accounted to the message starting it.
+func (s *Simulator) connect(from, to int, message Message) time.Duration {
+	c := s.connections
+	if c == nil {
+		return 0
+	}
+	params := c.params
+	if t, ok := s.transport(from, to); ok {
+		params.RoundTrips, params.Bytes = t.Handshake.RoundTrips, t.Handshake.Bytes
+	}
+	l := link{from: from, to: to}
+	if from > to {
+		l = link{from: to, to: from}
+	}
+	now := s.clock.now()
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if ready, ok := c.ready[l]; ok {
+		if ready.After(now) {
+			return ready.Sub(now)
+		}
+		return 0
+	}
+
+	rtt := params.RTT
+	if _, typed := s.linkTypes.profile(from, to); typed || s.latency != nil {
+		rtt = 2 * s.linkLatency(from, to)
+	}
+	wait := time.Duration(params.RoundTrips) * rtt
+	c.ready[l] = now.Add(wait)
+	// handshake messages go both ways, one per round trip in each direction
+	messages := 2 * params.RoundTrips
+	for i := 0; i < messages; i++ {
+		message.run.traffic.AddControl(params.Bytes / messages)
+	}
+	return wait
+}
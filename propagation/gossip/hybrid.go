@@ -0,0 +1,111 @@
+package gossip
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HybridParams defines push-pull hybrid gossip. Message is pushed as usual
+// for the first PushRounds hops only. After that, every Interval each node
+// still missing the message pulls it from Fanout random peers, for the rest
+// of the message TTL rounds. Push reaches most nodes quickly, while pull is
+// more efficient at reaching the last ones.
+type HybridParams struct {
+	PushRounds int           // hops the message is pushed for
+	Interval   time.Duration // interval between pull rounds
+	Fanout     int           // peers node pulls from in each round
+}
+
+// ParseHybrid parses hybrid gossip parameters in form of
+// "rounds:interval:fanout", i.e. "3:200ms:2".
+func ParseHybrid(s string) (HybridParams, error) {
+	var params HybridParams
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return params, fmt.Errorf("wrong hybrid gossip '%s', expected rounds:interval:fanout", s)
+	}
+	var err error
+	params.PushRounds, err = strconv.Atoi(parts[0])
+	if err != nil || params.PushRounds < 1 {
+		return params, fmt.Errorf("wrong push rounds number '%s'", parts[0])
+	}
+	params.Interval, err = time.ParseDuration(parts[1])
+	if err != nil || params.Interval <= 0 {
+		return params, fmt.Errorf("wrong pull interval '%s'", parts[1])
+	}
+	params.Fanout, err = strconv.Atoi(parts[2])
+	if err != nil || params.Fanout < 1 {
+		return params, fmt.Errorf("wrong pull fanout '%s'", parts[2])
+	}
+	return params, nil
+}
+
+// WithHybrid enables push-pull hybrid gossip with the given parameters
+// (see HybridParams). Pull requests count as control traffic.
+func WithHybrid(params HybridParams) Option {
+	return func(s *Simulator) {
+		s.hybrid = &params
+	}
+}
+
+// pushRounds returns TTL message is pushed with, limited by push rounds
+// in hybrid mode, and the number of pull rounds after that.
+func (s *Simulator) pushRounds(ttl int) (push, pull int) {
+	if s.hybrid == nil || ttl <= s.hybrid.PushRounds {
+		return ttl, 0
+	}
+	return s.hybrid.PushRounds, ttl - s.hybrid.PushRounds
+}
+
+// startPull starts pull rounds of the message published in hybrid mode.
+// Rounds stop early once all nodes having peers have the message.
+func (s *Simulator) startPull(message Message, rounds int) {
+	if rounds == 0 {
+		return
+	}
+	// pulled message isn't pushed further
+	message.TTL = 1
+	var round int
+	var pull func()
+	pull = func() {
+		if message.run.expired() || !s.pullRound(message) {
+			return
+		}
+		if round++; round < rounds {
+			s.after(s.hybrid.Interval, message.run, pull)
+		}
+	}
+	s.after(s.hybrid.Interval, message.run, pull)
+}
+
+// pullRound makes nodes missing the message request it from random peers,
+// and reports whether any node did.
+func (s *Simulator) pullRound(message Message) bool {
+	var pulled bool
+	for node := 0; node < s.nodeCount(); node++ {
+		if s.isSeen(node, message.Content) || s.isDown(node) {
+			continue
+		}
+		s.mx.RLock()
+		peers := s.peers[node]
+		order := s.rand.Perm(len(peers))
+		if len(order) > s.hybrid.Fanout {
+			order = order[:s.hybrid.Fanout]
+		}
+		for _, i := range order {
+			s.send(node, peers[i], message.withKind(kindPull))
+			pulled = true
+		}
+		s.mx.RUnlock()
+	}
+	return pulled
+}
+
+// isSeen reports whether node has seen message content.
+func (s *Simulator) isSeen(idx int, content []byte) bool {
+	s.seenMx.Lock()
+	defer s.seenMx.Unlock()
+	return s.seen[idx][string(content)]
+}
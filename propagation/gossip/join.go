@@ -0,0 +1,76 @@
+package gossip
+
+import (
+	"time"
+)
+
+// JoinParams defines network formation model, where nodes join the network
+// over time through bootstrap nodes, so messages are sent while network is
+// still forming. Link can carry messages only when both its nodes joined.
+type JoinParams struct {
+	Bootstrap int           // number of bootstrap nodes, joined from the start
+	Interval  time.Duration // interval between joins of other nodes
+	Random    bool          // join in random order, instead of by node index
+}
+
+// joins keeps join times of nodes, relative to simulator start.
+type joins struct {
+	start    time.Time
+	joinedAt []time.Duration
+}
+
+// WithJoinOrder makes nodes join the network one by one, starting with
+// bootstrap nodes. Bootstrap nodes are the first nodes by index (or random
+// ones, if params.Random is set), except node 0 is always a bootstrap one,
+// as messages are usually sent from it.
+func WithJoinOrder(params JoinParams) Option {
+	return func(s *Simulator) {
+		n := len(s.nodes)
+		order := make([]int, n)
+		for i := range order {
+			order[i] = i
+		}
+		if params.Random {
+			s.rand.Shuffle(n-1, func(i, j int) { order[i+1], order[j+1] = order[j+1], order[i+1] })
+		}
+		if params.Bootstrap < 1 {
+			params.Bootstrap = 1
+		}
+
+		s.joins = &joins{
+			start:    s.clock.now(),
+			joinedAt: make([]time.Duration, n),
+		}
+		for i, idx := range order {
+			if i < params.Bootstrap {
+				continue
+			}
+			s.joins.joinedAt[idx] = time.Duration(i-params.Bootstrap+1) * params.Interval
+		}
+	}
+}
+
+// JoinedNodes returns the number of nodes joined the network so far.
+func (s *Simulator) JoinedNodes() int {
+	if s.joins == nil {
+		return s.nodeCount()
+	}
+	elapsed := s.clock.since(s.joins.start)
+	var count int
+	for _, at := range s.joins.joinedAt {
+		if at <= elapsed {
+			count++
+		}
+	}
+	return count
+}
+
+// linkFormed reports whether the link between nodes is formed, i.e.
+// both nodes joined the network.
+func (s *Simulator) linkFormed(from, to int) bool {
+	if s.joins == nil {
+		return true
+	}
+	elapsed := s.clock.since(s.joins.start)
+	return s.joins.joinedAt[from] <= elapsed && s.joins.joinedAt[to] <= elapsed
+}
@@ -36,3 +36,16 @@ func PrecalculatePeers(data *graph.Graph) map[int][]int {
 	}
 	return ret
 }
+
+// PrecalculateDirectedPeers creates map with peers indexes, treating
+// links as directed, so peer is added only for the link source node.
+func PrecalculateDirectedPeers(data *graph.Graph) map[int][]int {
+	ret := make(map[int][]int)
+	for _, link := range data.Links() {
+		if link.From() == link.To() {
+			continue
+		}
+		ret[link.FromIdx()] = append(ret[link.FromIdx()], link.ToIdx())
+	}
+	return ret
+}
@@ -0,0 +1,160 @@
+package gossip
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LinkProfile describes characteristics of the link type, i.e. "wifi" or
+// "backbone", shared by all links of that type.
+type LinkProfile struct {
+	Latency time.Duration // added to each transmission over link
+	Loss    float64       // probability of losing each transmission
+}
+
+// LinkTypesParams defines typed links of the network, which model
+// heterogeneous setups, i.e. overlay over wireless and wired underlay.
+type LinkTypesParams struct {
+	Types    map[LinkIndex]string   // link -> its type, links are matched in both directions
+	Profiles map[string]LinkProfile // link type -> its profile
+	Prefer   []string               // link types payload is pushed over, all if empty
+}
+
+// ParseLinkProfiles parses link type profiles in form of comma-separated
+// type=latency:loss pairs, i.e. "wifi=50ms:0.05,backbone=5ms:0".
+func ParseLinkProfiles(s string) (map[string]LinkProfile, error) {
+	profiles := make(map[string]LinkProfile)
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("wrong link type '%s', expected type=latency:loss", pair)
+		}
+		parts := strings.Split(kv[1], ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("wrong profile of link type '%s', expected latency:loss", kv[0])
+		}
+		var (
+			p   LinkProfile
+			err error
+		)
+		p.Latency, err = time.ParseDuration(parts[0])
+		if err != nil || p.Latency < 0 {
+			return nil, fmt.Errorf("wrong latency of link type '%s'", kv[0])
+		}
+		p.Loss, err = strconv.ParseFloat(parts[1], 64)
+		if err != nil || p.Loss < 0 || p.Loss >= 1 {
+			return nil, fmt.Errorf("wrong loss of link type '%s'", kv[0])
+		}
+		profiles[kv[0]] = p
+	}
+	return profiles, nil
+}
+
+// linkTypes keeps types of links and their profiles.
+type linkTypes struct {
+	types    map[LinkIndex]string
+	profiles map[string]LinkProfile
+	prefer   map[string]bool // empty if all link types are preferred
+}
+
+// WithLinkTypes sets link types with their profiles (see LinkTypesParams).
+// Typed links use latency and loss of their type profile instead of ones
+// set with WithLatency and WithLoss, while untyped links keep using them.
+// With preferred link types, nodes push payload over links of those types,
+// and only announce messages (see LazyPush) over the rest of the links,
+// unless node has no preferred links at all.
+func WithLinkTypes(params LinkTypesParams) Option {
+	return func(s *Simulator) {
+		lt := &linkTypes{
+			types:    params.Types,
+			profiles: params.Profiles,
+			prefer:   make(map[string]bool, len(params.Prefer)),
+		}
+		for _, t := range params.Prefer {
+			lt.prefer[t] = true
+		}
+		s.linkTypes = lt
+	}
+}
+
+// typeOf returns type of the link between nodes, in either direction.
+func (lt *linkTypes) typeOf(from, to int) (string, bool) {
+	if t, ok := lt.types[LinkIndex{From: from, To: to}]; ok {
+		return t, true
+	}
+	t, ok := lt.types[LinkIndex{From: to, To: from}]
+	return t, ok
+}
+
+// profile returns profile of the link between nodes, if link is typed.
+func (lt *linkTypes) profile(from, to int) (LinkProfile, bool) {
+	if lt == nil {
+		return LinkProfile{}, false
+	}
+	t, ok := lt.typeOf(from, to)
+	if !ok {
+		return LinkProfile{}, false
+	}
+	p, ok := lt.profiles[t]
+	return p, ok
+}
+
+// preferred reports whether link between nodes is of preferred type.
+func (lt *linkTypes) preferred(from, to int) bool {
+	t, ok := lt.typeOf(from, to)
+	return ok && lt.prefer[t]
+}
+
+// announceOnly returns function reporting whether node should only
+// announce messages to the peer, as link to it isn't of preferred type,
+// while node has some preferred links. Returned function is nil, if node
+// pushes payload to all peers.
+func (lt *linkTypes) announceOnly(from int, peers []int) func(peer int) bool {
+	if lt == nil || len(lt.prefer) == 0 {
+		return nil
+	}
+	for _, peer := range peers {
+		if lt.preferred(from, peer) {
+			return func(peer int) bool {
+				return !lt.preferred(from, peer)
+			}
+		}
+	}
+	return nil
+}
+
+// lossy reports whether any link type loses messages.
+func (lt *linkTypes) lossy() bool {
+	if lt == nil {
+		return false
+	}
+	for _, p := range lt.profiles {
+		if p.Loss > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// linkLatency returns latency of the link, from its type profile for typed
+// links, or set with WithLatency otherwise.
+func (s *Simulator) linkLatency(from, to int) time.Duration {
+	if p, ok := s.linkTypes.profile(from, to); ok {
+		return p.Latency
+	}
+	if s.latency != nil {
+		return s.latency(from, to)
+	}
+	return 0
+}
+
+// linkLoss returns probability of losing transmission over the link, from
+// its type profile for typed links, or set with WithLoss otherwise.
+func (s *Simulator) linkLoss(from, to int) float64 {
+	if p, ok := s.linkTypes.profile(from, to); ok {
+		return p.Loss
+	}
+	return s.loss
+}
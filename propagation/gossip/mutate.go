@@ -0,0 +1,103 @@
+package gossip
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+)
+
+// AddNode adds new node with the given ID to the network and returns its
+// index. Node has no peers, connect it with AddLink. Nodes can be added
+// only between messages, and only if simulator doesn't keep per node
+// state of optional features, like scoring or bandwidth classes.
+// Implements propagation.NetworkMutator.
+func (s *Simulator) AddNode(id string) (int, error) {
+	if s.scoring != nil || s.bandwidth != nil || s.dutyCycles != nil || s.nat != nil ||
+		s.circuits != nil || s.waku != nil || s.joins != nil || s.transports != nil || s.topics != nil {
+		return 0, errors.New("adding nodes with per node state of optional features is not supported")
+	}
+	s.inflightMx.Lock()
+	defer s.inflightMx.Unlock()
+	if len(s.inflight) > 0 {
+		return 0, errors.New("nodes can't be added while messages propagate")
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	data, idx, err := propagation.GraphWithNode(s.data, id)
+	if err != nil {
+		return 0, err
+	}
+	s.data = data
+	s.nodes = append(s.nodes, &nodeState{})
+	s.seenMx.Lock()
+	s.seen = append(s.seen, make(map[string]bool))
+	s.requested = append(s.requested, make(map[string]bool))
+	s.seenMx.Unlock()
+	return idx, nil
+}
+
+// RemoveNode stops the node and removes its links. Node keeps its index,
+// so indices of other nodes don't change. Implements
+// propagation.NetworkMutator.
+func (s *Simulator) RemoveNode(idx int) error {
+	if idx < 0 || idx >= s.nodeCount() {
+		return fmt.Errorf("node with index %d not found", idx)
+	}
+	s.mx.Lock()
+	// with directed links, nodes sending to the node are not its peers
+	for from, peers := range s.peers {
+		s.peers[from] = removePeer(peers, idx)
+	}
+	delete(s.peers, idx)
+	s.data = propagation.CopyGraph(s.data, func(from, to int) bool {
+		return from == idx || to == idx
+	})
+	s.mx.Unlock()
+	return s.StopNode(idx)
+}
+
+// AddLink adds link between nodes, from node to its peer only if links are
+// directed (see WithDirected). Implements propagation.NetworkMutator.
+func (s *Simulator) AddLink(from, to int) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	data, err := propagation.GraphWithLink(s.data, from, to)
+	if err != nil {
+		return err
+	}
+	s.data = data
+	s.peers[from] = append(removePeer(s.peers[from], to), to)
+	if !s.directed {
+		s.peers[to] = append(removePeer(s.peers[to], from), from)
+	}
+	return nil
+}
+
+// RemoveLink removes links between nodes, in both directions, so nodes
+// don't send messages to each other anymore, and propagation logs don't
+// reference them. Implements propagation.NetworkMutator.
+func (s *Simulator) RemoveLink(from, to int) error {
+	s.mx.Lock()
+	if from < 0 || from >= len(s.nodes) || to < 0 || to >= len(s.nodes) {
+		s.mx.Unlock()
+		return fmt.Errorf("wrong link %d -> %d", from, to)
+	}
+	s.data = propagation.CopyGraph(s.data, func(a, b int) bool {
+		return (a == from && b == to) || (a == to && b == from)
+	})
+	s.peers[from] = removePeer(s.peers[from], to)
+	s.peers[to] = removePeer(s.peers[to], from)
+	s.mx.Unlock()
+	s.observer(propagation.ConnDownEvent{Ts: s.sinceEpoch(), From: from, To: to})
+	return nil
+}
+
+// graph returns network graph of the simulator.
+func (s *Simulator) graph() *graph.Graph {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.data
+}
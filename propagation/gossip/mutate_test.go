@@ -0,0 +1,46 @@
+package gossip
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestAddNode(t *testing.T) {
+	sim := NewSimulator(line(4), 4, 0, WithSeed(1))
+	defer sim.Stop()
+
+	// let node runners finish before mailboxes grow
+	if got := len(reached(sim.SendMessage(0, 10, 100))); got != 4 {
+		t.Fatalf("expected 4 nodes reached, got %d", got)
+	}
+	for i := 0; i < 20; i++ {
+		idx, err := sim.AddNode("new" + strconv.Itoa(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sim.AddLink(idx-1, idx); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(reached(sim.SendMessage(0, 100, 100))), idx+1; got != want {
+			t.Fatalf("expected %d nodes reached, got %d", want, got)
+		}
+	}
+}
+
+func TestRemoveLink(t *testing.T) {
+	sim := NewSimulator(line(4), 4, 0, WithSeed(1))
+	defer sim.Stop()
+
+	if err := sim.DisconnectNodes(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := sim.graph().NumLinks(); got != 2 {
+		t.Fatalf("expected disconnected link removed from graph, got %d links", got)
+	}
+	if got := len(reached(sim.SendMessage(0, 10, 100))); got != 2 {
+		t.Fatalf("expected 2 nodes reached, got %d", got)
+	}
+	if err := sim.RemoveLink(0, 4); err == nil {
+		t.Fatal("expected error for unknown node")
+	}
+}
@@ -0,0 +1,90 @@
+package gossip
+
+// NATParams defines NAT model, where a fraction of nodes is behind NAT and
+// can't accept inbound connections. Peers connect to NAT'd node only if they
+// can accept connection back, so links between two NAT'd nodes can't carry
+// messages, unless they go through relay nodes (like libp2p circuit relay).
+type NATParams struct {
+	Fraction float64 // fraction of nodes behind NAT (0..1)
+	Relays   int     // number of public nodes acting as relays
+}
+
+// nat keeps NAT state of nodes.
+type nat struct {
+	natted  map[int]bool
+	relays  []int
+	relayOf map[int]int // NAT'd node -> relay it's reachable through
+}
+
+// WithNAT puts random fraction of nodes behind NAT and picks relay nodes
+// out of the rest. Each NAT'd node is reachable through a random relay,
+// which forwards messages from other NAT'd nodes, adding an extra hop.
+// Without relays, links between NAT'd nodes are removed.
+//
+// It should go after options changing peers, like WithDirected.
+func WithNAT(params NATParams) Option {
+	return func(s *Simulator) {
+		n := len(s.nodes)
+		count := int(params.Fraction * float64(n))
+		if count == 0 {
+			return
+		}
+		order := s.rand.Perm(n)
+		s.nat = &nat{
+			natted:  make(map[int]bool, count),
+			relayOf: make(map[int]int, count),
+		}
+		for _, idx := range order[:count] {
+			s.nat.natted[idx] = true
+		}
+		public := order[count:]
+		if params.Relays > len(public) {
+			params.Relays = len(public)
+		}
+		s.nat.relays = public[:params.Relays]
+		if len(s.nat.relays) > 0 {
+			for idx := range s.nat.natted {
+				s.nat.relayOf[idx] = s.nat.relays[s.rand.Intn(len(s.nat.relays))]
+			}
+			return
+		}
+
+		for node, peers := range s.peers {
+			if !s.nat.natted[node] {
+				continue
+			}
+			var reachable []int
+			for _, peer := range peers {
+				if !s.nat.natted[peer] {
+					reachable = append(reachable, peer)
+				}
+			}
+			s.peers[node] = reachable
+		}
+	}
+}
+
+// NATNodes returns indices of nodes behind NAT, if enabled with WithNAT.
+func (s *Simulator) NATNodes() []int {
+	if s.nat == nil {
+		return nil
+	}
+	ret := make([]int, 0, len(s.nat.natted))
+	for idx := range s.nat.natted {
+		ret = append(ret, idx)
+	}
+	return ret
+}
+
+// relay returns relay node messages from node to its peer go through,
+// if link between them is relayed or both of them are behind NAT.
+func (s *Simulator) relay(from, to int) (int, bool) {
+	if relay, ok := s.circuits[LinkIndex{From: from, To: to}]; ok {
+		return relay, true
+	}
+	if s.nat == nil || !s.nat.natted[from] || !s.nat.natted[to] {
+		return 0, false
+	}
+	relay, ok := s.nat.relayOf[to]
+	return relay, ok
+}
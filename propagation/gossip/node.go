@@ -0,0 +1,80 @@
+package gossip
+
+import (
+	"sync"
+	"time"
+)
+
+// nodeState holds the processing state of a single node. Nodes don't run
+// goroutines of their own: they process messages one by one in scheduler
+// events, and messages delivered to the node busy with processing wait
+// until it's ready. So idle nodes take only a few bytes of memory, and
+// large networks don't need a goroutine and a channel per node.
+type nodeState struct {
+	mx    sync.Mutex
+	ready time.Time // time node finishes processing of messages taken so far
+}
+
+// newNodeStates returns states of n idle nodes.
+func newNodeStates(n int) []*nodeState {
+	nodes := make([]*nodeState, n)
+	for i := range nodes {
+		nodes[i] = &nodeState{}
+	}
+	return nodes
+}
+
+// node returns state of the node with the given index. States are kept by
+// pointer, so scheduled events aren't affected by AddNode growing the slice.
+func (s *Simulator) node(idx int) *nodeState {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.nodes[idx]
+}
+
+// nodeCount returns the number of nodes, including ones added with AddNode.
+func (s *Simulator) nodeCount() int {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return len(s.nodes)
+}
+
+// deliver schedules processing of the message by node once it's ready.
+// Messages are processed one by one in order of delivery.
+func (s *Simulator) deliver(idx int, message Message) {
+	s.after(s.untilReady(idx), message.run, func() {
+		// node may have taken message delivered earlier meanwhile
+		if s.untilReady(idx) > 0 {
+			s.deliver(idx, message)
+			return
+		}
+		s.processMessage(idx, message)
+	})
+}
+
+// untilReady returns time left until node finishes processing of messages
+// it has taken.
+func (s *Simulator) untilReady(idx int) time.Duration {
+	n := s.node(idx)
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	if wait := n.ready.Sub(s.sched.now()); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// process makes node spend simulator delay on processing the message,
+// and runs fn once node is done with it.
+func (s *Simulator) process(idx int, run *messageRun, fn func()) {
+	n := s.node(idx)
+	n.mx.Lock()
+	now := s.sched.now()
+	if n.ready.Before(now) {
+		n.ready = now
+	}
+	n.ready = n.ready.Add(s.delay)
+	wait := n.ready.Sub(now)
+	n.mx.Unlock()
+	s.after(wait, run, fn)
+}
@@ -0,0 +1,45 @@
+package gossip
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentSendMessage(t *testing.T) {
+	// ring, so nodes get the same messages from both sides
+	g := line(8)
+	g.AddLink("7", "0")
+	sim := NewSimulator(g, 4, 0, WithSeed(1))
+	defer sim.Stop()
+
+	const senders, messages = 8, 5
+	results := make(chan int, senders*messages)
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func(sender int) {
+			defer wg.Done()
+			for j := 0; j < messages; j++ {
+				results <- len(reached(sim.SendMessage(sender, 10, 100)))
+			}
+		}(i)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("concurrent runs didn't finish")
+	}
+
+	close(results)
+	for got := range results {
+		if got != 8 {
+			t.Fatalf("expected all 8 nodes reached, got %d", got)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package gossip
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// Option represents simulator option.
+type Option func(*Simulator)
+
+// Mode defines how nodes push messages to their peers.
+type Mode int
+
+const (
+	// EagerPush sends message payload to all peers right away.
+	EagerPush Mode = iota
+	// LazyPush announces message ID to peers first (IHAVE), and sends
+	// payload only to peers requesting it (IWANT). It trades latency of
+	// extra round trip for less duplicated payload traffic.
+	LazyPush
+)
+
+// ParseMode parses mode name ("eager" or "lazy").
+func ParseMode(name string) (Mode, error) {
+	switch name {
+	case "eager", "":
+		return EagerPush, nil
+	case "lazy":
+		return LazyPush, nil
+	default:
+		return EagerPush, fmt.Errorf("unknown gossip mode '%s'", name)
+	}
+}
+
+// WithMode sets push mode used by nodes. Default is EagerPush.
+func WithMode(mode Mode) Option {
+	return func(s *Simulator) {
+		s.mode = mode
+	}
+}
+
+// WithProgress sets the function to report simulation progress to.
+func WithProgress(fn propagation.ProgressFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.Progress) {}
+		}
+		s.progress = fn
+	}
+}
+
+// WithEvents sets the function to report each message sending to,
+// as it happens during simulation.
+func WithEvents(fn propagation.EventFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.LogEntry) {}
+		}
+		s.events = fn
+	}
+}
+
+// WithObserver sets the function to report typed events to, as they
+// happen during simulation: payload messages sent, received and dropped,
+// with the reason, timestamped relative to the message run start, and
+// nodes stopped and restarted and connections removed, timestamped
+// relative to simulator creation.
+func WithObserver(fn propagation.ObserverFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.Event) {}
+		}
+		s.observer = fn
+	}
+}
+
+// WithScoring enables GossipSub-style peer scoring and mesh maintenance
+// with the given parameters (see ScoreParams).
+func WithScoring(params ScoreParams) Option {
+	return func(s *Simulator) {
+		s.scoring = newScoring(params, len(s.nodes), s.clock, s.rand)
+	}
+}
+
+// WithMaliciousNodes sets nodes that tamper with messages they propagate,
+// so receivers fail to validate them and penalize the sender when scoring
+// is enabled.
+func WithMaliciousNodes(nodes ...int) Option {
+	return func(s *Simulator) {
+		for _, idx := range nodes {
+			s.malicious[idx] = true
+		}
+	}
+}
+
+// WithWithholdingNodes marks nodes that receive messages, but never relay
+// them to peers (or serve them on request), like free-riders or eclipse
+// attackers do. Messages sent by these nodes themselves are propagated.
+func WithWithholdingNodes(nodes ...int) Option {
+	return func(s *Simulator) {
+		for _, idx := range nodes {
+			s.withholding[idx] = true
+		}
+	}
+}
+
+// WithChoking enables Episub-style choking of peers pushing redundant
+// payloads with the given parameters (see ChokeParams).
+func WithChoking(params ChokeParams) Option {
+	return func(s *Simulator) {
+		s.choking = newChoking(params)
+	}
+}
+
+// WithQueueing enables transmission queueing model with the given
+// parameters (see QueueParams).
+func WithQueueing(params QueueParams) Option {
+	return func(s *Simulator) {
+		s.queues = newQueues(params, s.clock, s.rand)
+	}
+}
+
+// WithLatency sets per link latency function, which is added to each
+// transmission on top of the node's delay (see geo.Latency).
+func WithLatency(fn func(from, to int) time.Duration) Option {
+	return func(s *Simulator) {
+		s.latency = fn
+	}
+}
+
+// WithMaxDuration bounds propagation of each message sent with SendMessage
+// by the given duration. Messages still in flight are dropped once it's
+// reached, and log contains nodes reached so far.
+func WithMaxDuration(d time.Duration) Option {
+	return func(s *Simulator) {
+		s.maxDuration = d
+	}
+}
+
+// WithDirected makes simulator treat graph links as directed, so messages
+// are propagated only from link source to its target. Use separate links
+// for both directions to get bidirectional connection.
+func WithDirected() Option {
+	return func(s *Simulator) {
+		s.directed = true
+		s.peers = PrecalculateDirectedPeers(s.data)
+	}
+}
+
+// WithLinkLatencies sets per link latencies, which may differ for each
+// direction. Links not found in the map have zero latency.
+func WithLinkLatencies(latencies map[LinkIndex]time.Duration) Option {
+	return WithLatency(func(from, to int) time.Duration {
+		return latencies[LinkIndex{From: from, To: to}]
+	})
+}
@@ -0,0 +1,181 @@
+package gossip
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Priority defines message priority class. With rate limiting enabled,
+// messages of higher priority preempt lower priority ones waiting to be
+// sent over the same link.
+type Priority int
+
+// Message priority classes, higher value means higher priority.
+const (
+	PriorityBulk   Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+// String implements Stringer interface for Priority.
+func (p Priority) String() string {
+	switch p {
+	case PriorityBulk:
+		return "bulk"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	default:
+		return fmt.Sprintf("priority(%d)", int(p))
+	}
+}
+
+// ParsePriority parses priority name ("bulk", "normal" or "high").
+func ParsePriority(name string) (Priority, error) {
+	for _, p := range []Priority{PriorityBulk, PriorityNormal, PriorityHigh} {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return PriorityNormal, fmt.Errorf("unknown priority '%s'", name)
+}
+
+// WithRateLimit limits the number of messages each node sends to each
+// of its peers to rate messages per second. Messages exceeding the limit
+// wait, and higher priority messages are sent first.
+func WithRateLimit(rate float64) Option {
+	return func(s *Simulator) {
+		if rate <= 0 {
+			return
+		}
+		s.limiter = &limiter{
+			interval: time.Duration(float64(time.Second) / rate),
+			sched:    s.sched,
+			links:    make(map[link]*linkLimiter),
+		}
+	}
+}
+
+// limiter implements per link rate limiting with priorities.
+type limiter struct {
+	interval time.Duration // minimal interval between messages
+	sched    *scheduler
+
+	mx    sync.Mutex
+	links map[link]*linkLimiter
+}
+
+// linkLimiter is the rate limiting state of a single link.
+type linkLimiter struct {
+	next      time.Time                     // time the next message can be sent at
+	waiting   map[Priority][]waitingMessage // in order of arrival, by priority
+	scheduled bool                          // sending of the next message is scheduled
+}
+
+// waitingMessage is the message waiting for its turn to be sent.
+type waitingMessage struct {
+	run  *messageRun
+	send func()
+}
+
+// limit runs send once message can be sent from node to its peer without
+// exceeding the rate limit, if it's set.
+func (s *Simulator) limit(from, to int, message Message, send func()) {
+	if s.limiter == nil {
+		send()
+		return
+	}
+	s.limiter.wait(link{from, to}, message, send)
+}
+
+// wait queues message until it can be sent over the link. Waiting message
+// counts as in-flight work of its run.
+func (l *limiter) wait(lnk link, message Message, send func()) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	ll, ok := l.links[lnk]
+	if !ok {
+		ll = &linkLimiter{waiting: make(map[Priority][]waitingMessage)}
+		l.links[lnk] = ll
+	}
+	message.run.wg.Add(1)
+	ll.waiting[message.priority] = append(ll.waiting[message.priority], waitingMessage{message.run, send})
+	if !ll.scheduled {
+		l.scheduleNext(ll)
+	}
+}
+
+// scheduleNext schedules sending of the next waiting message over the
+// link, once the interval since the previous one passes. Caller should
+// hold the lock.
+func (l *limiter) scheduleNext(ll *linkLimiter) {
+	wait := ll.next.Sub(l.sched.now())
+	if wait < 0 {
+		wait = 0
+	}
+	ll.scheduled = l.sched.schedule(wait, event{
+		fn:   func() { l.sendNext(ll) },
+		drop: func() { l.drop(ll) },
+	})
+	if !ll.scheduled {
+		ll.release()
+	}
+}
+
+// sendNext sends waiting message of the highest priority.
+func (l *limiter) sendNext(ll *linkLimiter) {
+	l.mx.Lock()
+	m := ll.pop()
+	ll.next = l.sched.now().Add(l.interval)
+	ll.scheduled = false
+	if ll.waitingCount() > 0 {
+		l.scheduleNext(ll)
+	}
+	l.mx.Unlock()
+
+	m.send()
+	m.run.wg.Done()
+}
+
+// drop drops messages waiting on the link, as simulator is stopped.
+func (l *limiter) drop(ll *linkLimiter) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	ll.release()
+}
+
+// release drops waiting messages, finishing their in-flight work. Caller
+// should hold the lock.
+func (ll *linkLimiter) release() {
+	for p, waiting := range ll.waiting {
+		for _, m := range waiting {
+			m.run.wg.Done()
+		}
+		delete(ll.waiting, p)
+	}
+}
+
+// pop removes the earliest waiting message of the highest priority.
+func (ll *linkLimiter) pop() waitingMessage {
+	top, found := PriorityBulk, false
+	for p, waiting := range ll.waiting {
+		if len(waiting) > 0 && (!found || p > top) {
+			top, found = p, true
+		}
+	}
+	m := ll.waiting[top][0]
+	ll.waiting[top][0] = waitingMessage{}
+	ll.waiting[top] = ll.waiting[top][1:]
+	return m
+}
+
+// waitingCount returns the number of messages waiting on the link.
+func (ll *linkLimiter) waitingCount() int {
+	var n int
+	for _, waiting := range ll.waiting {
+		n += len(waiting)
+	}
+	return n
+}
@@ -0,0 +1,92 @@
+package gossip
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// QueueParams defines transmission queueing model. With queueing enabled,
+// concurrent transmissions from the same node (or over the same link)
+// contend and wait for each other, adding queueing delay under load.
+type QueueParams struct {
+	PerLink bool // queue per link, instead of per node uplink
+
+	// ServiceTime is the mean transmission time, used if bandwidth
+	// is not set (see WithBandwidth).
+	ServiceTime time.Duration
+
+	// Exponential draws service times from exponential distribution
+	// with the mean of transfer time, making it M/M/1-style queue.
+	Exponential bool
+}
+
+// DefaultQueueParams returns M/M/1-style per node queue parameters.
+func DefaultQueueParams() QueueParams {
+	return QueueParams{
+		ServiceTime: time.Millisecond,
+		Exponential: true,
+	}
+}
+
+// ParseQueueModel parses queue model name ("node" or "link") into
+// default queue parameters.
+func ParseQueueModel(name string) (QueueParams, error) {
+	params := DefaultQueueParams()
+	switch name {
+	case "node":
+	case "link":
+		params.PerLink = true
+	default:
+		return params, fmt.Errorf("unknown queue model '%s'", name)
+	}
+	return params, nil
+}
+
+// queues keeps transmission queues state as the time each queue
+// gets free at.
+type queues struct {
+	params QueueParams
+	clock  *clock
+	rand   *rand.Rand
+
+	mx   sync.Mutex
+	busy map[link]time.Time
+}
+
+func newQueues(params QueueParams, clock *clock, rand *rand.Rand) *queues {
+	return &queues{
+		params: params,
+		clock:  clock,
+		rand:   rand,
+		busy:   make(map[link]time.Time),
+	}
+}
+
+// reserve puts transmission with the given transfer time into the queue
+// and returns the time until transmission is over, including waiting.
+func (q *queues) reserve(from, to int, transfer time.Duration) time.Duration {
+	if transfer == 0 {
+		transfer = q.params.ServiceTime
+	}
+	if q.params.Exponential {
+		transfer = time.Duration(q.rand.ExpFloat64() * float64(transfer))
+	}
+
+	key := link{from, -1}
+	if q.params.PerLink {
+		key.to = to
+	}
+
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	now := q.clock.now()
+	start := q.busy[key]
+	if start.Before(now) {
+		start = now
+	}
+	end := start.Add(transfer)
+	q.busy[key] = end
+	return end.Sub(now)
+}
@@ -0,0 +1,64 @@
+package gossip
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// lockedSource is the rand.Source safe for concurrent use, as simulator
+// draws random numbers from many goroutines.
+type lockedSource struct {
+	mx  sync.Mutex
+	src rand.Source64
+}
+
+func newRand(seed int64) *rand.Rand {
+	return rand.New(&lockedSource{src: rand.NewSource(seed).(rand.Source64)})
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.src.Seed(seed)
+}
+
+// readRand fills b with random bytes from r. Unlike r.Read, it's safe for
+// concurrent use with lockedSource.
+func readRand(r *rand.Rand, b []byte) {
+	for i := 0; i < len(b); i += 8 {
+		v := r.Uint64()
+		for j := i; j < i+8 && j < len(b); j++ {
+			b[j] = byte(v)
+			v >>= 8
+		}
+	}
+}
+
+// WithSeed seeds simulator's own random source, so simulators running
+// concurrently don't share it, and random choices of the run (i.e. nodes
+// assignments and losses) are reproducible, as far as goroutines
+// scheduling allows. It should go before options assigning random
+// properties to nodes, like WithDutyCycle or WithNAT.
+func WithSeed(seed int64) Option {
+	return func(s *Simulator) {
+		s.rand.Seed(seed)
+	}
+}
+
+// defaultSeed returns seed for simulators created without WithSeed.
+func defaultSeed() int64 {
+	return time.Now().UnixNano()
+}
@@ -0,0 +1,143 @@
+package gossip
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryParams defines ACK and retransmission layer parameters. With
+// retries enabled, receiver acknowledges each message, and sender
+// retransmits it after ACK timeout, multiplying timeout by backoff factor
+// after each attempt.
+type RetryParams struct {
+	Timeout    time.Duration // ACK timeout of the first attempt
+	Backoff    float64       // timeout multiplier for the next attempt
+	MaxRetries int           // give up on message after that many retransmissions
+}
+
+// DefaultRetryParams returns default retransmission parameters.
+func DefaultRetryParams() RetryParams {
+	return RetryParams{
+		Timeout:    50 * time.Millisecond,
+		Backoff:    2,
+		MaxRetries: 5,
+	}
+}
+
+// ParseRetries parses retransmission parameters in form of
+// "timeout:backoff:retries", i.e. "50ms:2:5".
+func ParseRetries(s string) (RetryParams, error) {
+	var params RetryParams
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return params, fmt.Errorf("wrong retries '%s', expected timeout:backoff:retries", s)
+	}
+	var err error
+	params.Timeout, err = time.ParseDuration(parts[0])
+	if err != nil || params.Timeout <= 0 {
+		return params, fmt.Errorf("wrong ACK timeout '%s'", parts[0])
+	}
+	params.Backoff, err = strconv.ParseFloat(parts[1], 64)
+	if err != nil || params.Backoff < 1 {
+		return params, fmt.Errorf("wrong backoff factor '%s'", parts[1])
+	}
+	params.MaxRetries, err = strconv.Atoi(parts[2])
+	if err != nil || params.MaxRetries < 0 {
+		return params, fmt.Errorf("wrong retries number '%s'", parts[2])
+	}
+	return params, nil
+}
+
+// WithLoss makes links lose each transmitted message, including control
+// messages and ACKs, with the given probability. Lost messages are dropped,
+// unless retransmissions are enabled with WithRetries.
+func WithLoss(rate float64) Option {
+	return func(s *Simulator) {
+		s.loss = rate
+	}
+}
+
+// WithRetries enables ACK and retransmission layer with the given
+// parameters (see RetryParams). Retransmissions and latency they add are
+// reported in the propagation log (see propagation.Reliability).
+func WithRetries(params RetryParams) Option {
+	return func(s *Simulator) {
+		s.retries = &params
+	}
+}
+
+// lost reports whether a single transmission is lost by link with the
+// given loss rate.
+func (s *Simulator) lost(rate float64) bool {
+	return rate > 0 && s.rand.Float64() < rate
+}
+
+// transmit simulates message transmission over lossy link, which takes
+// transfer time per attempt. It returns time transmission takes and
+// whether message got through. Lost messages are retransmitted after ACK
+// timeout, if retries are enabled.
+func (s *Simulator) transmit(message Message, size int, transfer time.Duration, loss float64) (time.Duration, bool) {
+	if loss == 0 {
+		return transfer, true
+	}
+	rel := &message.run.reliability
+	var took, delay time.Duration
+	var timeout time.Duration
+	if s.retries != nil {
+		timeout = s.retries.Timeout
+	}
+	for attempt := 0; ; attempt++ {
+		took += transfer
+		if !s.lost(loss) {
+			break
+		}
+		rel.AddLost()
+		if s.retries == nil || attempt >= s.retries.MaxRetries {
+			rel.AddFailed()
+			return took, false
+		}
+		// sender waits for ACK until timeout, and sends message again
+		took += timeout
+		delay += timeout + transfer
+		timeout = time.Duration(float64(timeout) * s.retries.Backoff)
+		message.countTraffic(size)
+		rel.AddRetransmission()
+	}
+	if delay > 0 && message.kind == kindPayload {
+		rel.AddDelay(delay)
+	}
+	return took, true
+}
+
+// acknowledge simulates ACK of the delivered message. As sender doesn't
+// know whether message or ACK was lost, lost ACKs cause retransmissions of
+// the message already delivered, which receiver discards.
+func (s *Simulator) acknowledge(message Message, size int, loss float64) {
+	if s.retries == nil {
+		return
+	}
+	rel := &message.run.reliability
+	var retries int
+	for {
+		message.run.traffic.AddControl(controlMessageSize)
+		if !s.lost(loss) {
+			return
+		}
+		rel.AddLost()
+		// resend until the copy gets through, and acknowledge it again
+		for {
+			if retries >= s.retries.MaxRetries {
+				return
+			}
+			retries++
+			message.countTraffic(size)
+			rel.AddRetransmission()
+			if !s.lost(loss) {
+				break
+			}
+			rel.AddLost()
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package gossip
+
+import (
+	"fmt"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// RestartNode brings stopped node back online, reconnected to its peers.
+// Node keeps messages it has seen before failure, as if it persisted them.
+// With fetch, it requests messages in flight it missed while it was down
+// from all its peers, like nodes do in pull rounds (see WithHybrid), and
+// relays messages it gets further. Implements propagation.NodeRestarter.
+func (s *Simulator) RestartNode(idx int, fetch bool) error {
+	if idx < 0 || idx >= s.nodeCount() {
+		return fmt.Errorf("node with index %d not found", idx)
+	}
+	s.mx.Lock()
+	delete(s.down, idx)
+	s.mx.Unlock()
+	s.observer(propagation.NodeUpEvent{Ts: s.sinceEpoch(), Node: idx})
+	if !fetch {
+		return nil
+	}
+
+	s.inflightMx.Lock()
+	defer s.inflightMx.Unlock()
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	for _, message := range s.inflight {
+		if s.isSeen(idx, message.Content) {
+			continue
+		}
+		for _, peer := range s.peers[idx] {
+			s.send(idx, peer, message.withKind(kindPull))
+		}
+	}
+	return nil
+}
+
+// track registers message of the run in progress, so restarted nodes can
+// fetch it, until untrack is called.
+func (s *Simulator) track(message Message) {
+	s.inflightMx.Lock()
+	defer s.inflightMx.Unlock()
+	if s.inflight == nil {
+		s.inflight = make(map[string]Message)
+	}
+	s.inflight[string(message.Content)] = message
+}
+
+// untrack removes messages of the finished run.
+func (s *Simulator) untrack(run *messageRun) {
+	s.inflightMx.Lock()
+	defer s.inflightMx.Unlock()
+	for content, message := range s.inflight {
+		if message.run == run {
+			delete(s.inflight, content)
+		}
+	}
+}
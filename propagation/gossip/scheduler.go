@@ -0,0 +1,231 @@
+package gossip
+
+import (
+	"sync"
+	"time"
+)
+
+// event is a single action of the simulation, like message sending or
+// delivery, scheduled at the given simulation time.
+type event struct {
+	at   time.Time
+	seq  uint64      // order of scheduling, so events at the same time are ordered
+	run  *messageRun // run the event is in-flight work of, nil for background events
+	fn   func()
+	drop func() // called instead of fn if simulator is stopped, optional
+}
+
+// scheduler runs all events of the simulator in a single goroutine, in
+// order of their time, like propagation/core does. Unlike core, it runs on
+// simulation clock, sleeping until the next event is due, so events can be
+// scheduled concurrently by SendMessage and network mutations. Each event
+// scheduled for a run counts as its in-flight work until the event is done.
+type scheduler struct {
+	clock *clock
+	wake  chan struct{} // signals new earliest event or resume
+
+	mx       sync.Mutex
+	queue    []event // binary heap ordered by time and sequence number
+	seq      uint64
+	paused   bool
+	pausedAt time.Time
+	stopped  bool
+}
+
+func newScheduler(clock *clock) *scheduler {
+	return &scheduler{
+		clock: clock,
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+// now returns current simulation time of events. It stands still while
+// scheduler is paused.
+func (sc *scheduler) now() time.Time {
+	sc.mx.Lock()
+	defer sc.mx.Unlock()
+	return sc.nowLocked()
+}
+
+func (sc *scheduler) nowLocked() time.Time {
+	if sc.paused {
+		return sc.pausedAt
+	}
+	return sc.clock.now()
+}
+
+// schedule adds event to run after simulation duration d, and reports
+// whether it's added, i.e. scheduler is not stopped.
+func (sc *scheduler) schedule(d time.Duration, e event) bool {
+	sc.mx.Lock()
+	if sc.stopped {
+		sc.mx.Unlock()
+		return false
+	}
+	if e.run != nil {
+		e.run.wg.Add(1)
+	}
+	e.at = sc.nowLocked().Add(d)
+	sc.push(e)
+	first := sc.queue[0].seq == sc.seq-1
+	sc.mx.Unlock()
+
+	if first {
+		sc.signal()
+	}
+	return true
+}
+
+func (sc *scheduler) signal() {
+	select {
+	case sc.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pause stops running events until resume is called.
+func (sc *scheduler) pause() {
+	sc.mx.Lock()
+	defer sc.mx.Unlock()
+	if sc.paused {
+		return
+	}
+	sc.paused = true
+	sc.pausedAt = sc.clock.now()
+}
+
+// resume runs events again, shifting them by the time spent in pause, so
+// intervals between events stay the same. Events scheduled while paused
+// are relative to the pause start, so they're shifted as well.
+func (sc *scheduler) resume() {
+	sc.mx.Lock()
+	if !sc.paused {
+		sc.mx.Unlock()
+		return
+	}
+	d := sc.clock.since(sc.pausedAt)
+	for i := range sc.queue {
+		sc.queue[i].at = sc.queue[i].at.Add(d)
+	}
+	sc.paused = false
+	sc.mx.Unlock()
+	sc.signal()
+}
+
+// run runs events until quit is closed. Events pending at that moment
+// are dropped.
+func (sc *scheduler) run(quit <-chan struct{}) {
+	for {
+		select {
+		case <-quit:
+			sc.stop()
+			return
+		default:
+		}
+
+		sc.mx.Lock()
+		wait := time.Duration(-1) // until woken up
+		if !sc.paused && len(sc.queue) > 0 {
+			wait = sc.queue[0].at.Sub(sc.clock.now())
+			if wait <= 0 {
+				e := sc.pop()
+				sc.mx.Unlock()
+				e.fn()
+				if e.run != nil {
+					e.run.wg.Done()
+				}
+				continue
+			}
+		}
+		sc.mx.Unlock()
+
+		var timer *time.Timer
+		var due <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(sc.clock.real(wait))
+			due = timer.C
+		}
+		select {
+		case <-due:
+		case <-sc.wake:
+		case <-quit:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// stop drops pending events and makes scheduler reject new ones.
+func (sc *scheduler) stop() {
+	sc.mx.Lock()
+	sc.stopped = true
+	queue := sc.queue
+	sc.queue = nil
+	sc.mx.Unlock()
+
+	for _, e := range queue {
+		if e.drop != nil {
+			e.drop()
+		}
+		if e.run != nil {
+			e.run.wg.Done()
+		}
+	}
+}
+
+// push adds event to the queue. It's implemented without container/heap,
+// like in propagation/core, so events are not boxed into interfaces.
+// Caller should hold the lock.
+func (sc *scheduler) push(e event) {
+	e.seq = sc.seq
+	sc.seq++
+	sc.queue = append(sc.queue, e)
+	for i := len(sc.queue) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if !sc.less(i, parent) {
+			break
+		}
+		sc.queue[i], sc.queue[parent] = sc.queue[parent], sc.queue[i]
+		i = parent
+	}
+}
+
+// pop removes the earliest event from the queue. Caller should hold
+// the lock.
+func (sc *scheduler) pop() event {
+	top := sc.queue[0]
+	last := len(sc.queue) - 1
+	sc.queue[0] = sc.queue[last]
+	sc.queue[last] = event{} // let closures be garbage collected
+	sc.queue = sc.queue[:last]
+	for i := 0; ; {
+		min, left, right := i, 2*i+1, 2*i+2
+		if left < last && sc.less(left, min) {
+			min = left
+		}
+		if right < last && sc.less(right, min) {
+			min = right
+		}
+		if min == i {
+			break
+		}
+		sc.queue[i], sc.queue[min] = sc.queue[min], sc.queue[i]
+		i = min
+	}
+	return top
+}
+
+func (sc *scheduler) less(i, j int) bool {
+	a, b := sc.queue[i], sc.queue[j]
+	if !a.at.Equal(b.at) {
+		return a.at.Before(b.at)
+	}
+	return a.seq < b.seq
+}
+
+// after schedules fn to run after simulation duration d, as in-flight work
+// of the run, if it's given.
+func (s *Simulator) after(d time.Duration, run *messageRun, fn func()) {
+	s.sched.schedule(d, event{run: run, fn: fn})
+}
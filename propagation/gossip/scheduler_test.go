@@ -0,0 +1,59 @@
+package gossip
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSchedulerOrder(t *testing.T) {
+	sc := newScheduler(newClock())
+	quit := make(chan struct{})
+	defer close(quit)
+
+	run := &messageRun{}
+	got := make(chan int, 4)
+	for i, d := range []time.Duration{30, 10, 20, 10} {
+		i := i
+		sc.schedule(d*time.Millisecond, event{run: run, fn: func() { got <- i }})
+	}
+	go sc.run(quit)
+	run.wg.Wait()
+	close(got)
+
+	var order []int
+	for i := range got {
+		order = append(order, i)
+	}
+	// events at the same time run in order of scheduling
+	if expected := []int{1, 3, 2, 0}; !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected events order %v, got %v", expected, order)
+	}
+}
+
+func TestSchedulerStop(t *testing.T) {
+	sc := newScheduler(newClock())
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		sc.run(quit)
+		close(done)
+	}()
+
+	run := &messageRun{}
+	var dropped bool
+	sc.schedule(time.Hour, event{
+		run:  run,
+		fn:   func() { t.Error("expected pending event not to run") },
+		drop: func() { dropped = true },
+	})
+	close(quit)
+	<-done
+	run.wg.Wait()
+	if !dropped {
+		t.Fatal("expected pending event to be dropped")
+	}
+	if sc.schedule(0, event{run: run, fn: func() {}}) {
+		t.Fatal("expected stopped scheduler to reject events")
+	}
+}
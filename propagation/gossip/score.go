@@ -0,0 +1,286 @@
+package gossip
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ScoreParams defines GossipSub-style peer scoring and mesh maintenance
+// parameters. With scoring enabled, each node pushes payload only to its
+// mesh peers and announces messages (IHAVE) to the rest of peers. Mesh is
+// maintained on each heartbeat: peers with negative score are pruned, and
+// peers with the best score are grafted when mesh is too small.
+type ScoreParams struct {
+	D     int // target mesh degree
+	DLow  int // graft peers when mesh is smaller
+	DHigh int // prune peers when mesh is larger
+
+	Heartbeat    time.Duration // mesh maintenance interval
+	PruneBackoff time.Duration // pruned peer isn't grafted again for that long
+
+	// Churn is the probability of each mesh peer being pruned on
+	// heartbeat regardless of its score, modeling mesh instability caused
+	// by connection flaps and peers leaving.
+	Churn float64
+
+	TimeInMeshWeight  float64
+	TimeInMeshQuantum time.Duration
+	TimeInMeshCap     float64
+
+	FirstDeliveryWeight float64
+	FirstDeliveryDecay  float64 // applied on each heartbeat
+	FirstDeliveryCap    float64
+
+	InvalidWeight float64 // should be negative, applied to squared counter
+	InvalidDecay  float64 // applied on each heartbeat
+
+	// GraylistThreshold is the score below which messages from the peer
+	// are ignored completely.
+	GraylistThreshold float64
+}
+
+// DefaultScoreParams returns scoring parameters with values close to
+// GossipSub defaults, scaled to simulation timings.
+func DefaultScoreParams() ScoreParams {
+	return ScoreParams{
+		D:     6,
+		DLow:  4,
+		DHigh: 12,
+
+		Heartbeat:    100 * time.Millisecond,
+		PruneBackoff: time.Second,
+
+		TimeInMeshWeight:  0.01,
+		TimeInMeshQuantum: 10 * time.Millisecond,
+		TimeInMeshCap:     100,
+
+		FirstDeliveryWeight: 1,
+		FirstDeliveryDecay:  0.9,
+		FirstDeliveryCap:    50,
+
+		InvalidWeight: -10,
+		InvalidDecay:  0.99,
+
+		GraylistThreshold: -100,
+	}
+}
+
+// peerCounters holds scoring counters of a single peer, as seen by node.
+type peerCounters struct {
+	graftedAt       time.Time // zero if peer is not in mesh
+	prunedAt        time.Time // zero if peer has never been pruned
+	firstDeliveries float64
+	invalid         float64
+}
+
+// scoring implements peer scoring and mesh maintenance.
+type scoring struct {
+	params ScoreParams
+	clock  *clock
+	rand   *rand.Rand
+
+	mx     sync.Mutex
+	peers  []map[int]*peerCounters // node -> peer -> counters
+	grafts int64                   // total peers grafted to meshes
+	prunes int64                   // total peers pruned from meshes
+}
+
+func newScoring(params ScoreParams, nodes int, clock *clock, rand *rand.Rand) *scoring {
+	sc := &scoring{
+		params: params,
+		clock:  clock,
+		rand:   rand,
+		peers:  make([]map[int]*peerCounters, nodes),
+	}
+	for i := range sc.peers {
+		sc.peers[i] = make(map[int]*peerCounters)
+	}
+	return sc
+}
+
+// counters returns counters for the node's peer, creating them if needed.
+// Caller should hold the lock.
+func (sc *scoring) counters(node, peer int) *peerCounters {
+	c, ok := sc.peers[node][peer]
+	if !ok {
+		c = &peerCounters{}
+		sc.peers[node][peer] = c
+	}
+	return c
+}
+
+// score calculates node's score of the peer. Caller should hold the lock.
+func (sc *scoring) score(node, peer int, now time.Time) float64 {
+	c, ok := sc.peers[node][peer]
+	if !ok {
+		return 0
+	}
+	p := sc.params
+
+	var score float64
+	if !c.graftedAt.IsZero() {
+		inMesh := float64(now.Sub(c.graftedAt)) / float64(p.TimeInMeshQuantum)
+		score += p.TimeInMeshWeight * math.Min(inMesh, p.TimeInMeshCap)
+	}
+	score += p.FirstDeliveryWeight * math.Min(c.firstDeliveries, p.FirstDeliveryCap)
+	score += p.InvalidWeight * c.invalid * c.invalid
+	return score
+}
+
+// inMesh reports whether peer is in node's mesh.
+func (sc *scoring) inMesh(node, peer int) bool {
+	sc.mx.Lock()
+	defer sc.mx.Unlock()
+	c, ok := sc.peers[node][peer]
+	return ok && !c.graftedAt.IsZero()
+}
+
+// graylisted reports whether messages from peer should be ignored by node.
+func (sc *scoring) graylisted(node, peer int) bool {
+	sc.mx.Lock()
+	defer sc.mx.Unlock()
+	return sc.score(node, peer, sc.clock.now()) < sc.params.GraylistThreshold
+}
+
+// deliver accounts for the message delivered to node by peer.
+func (sc *scoring) deliver(node, peer int, first, valid bool) {
+	sc.mx.Lock()
+	defer sc.mx.Unlock()
+	c := sc.counters(node, peer)
+	switch {
+	case !valid:
+		c.invalid++
+	case first:
+		c.firstDeliveries++
+	}
+}
+
+// heartbeat decays counters and maintains node's mesh out of given
+// candidate peers.
+func (sc *scoring) heartbeat(node int, candidates []int, now time.Time) {
+	sc.mx.Lock()
+	defer sc.mx.Unlock()
+	p := sc.params
+
+	alive := make(map[int]bool, len(candidates))
+	for _, peer := range candidates {
+		alive[peer] = true
+	}
+
+	// peers are visited in order, so random churn is reproducible with
+	// the fixed seed
+	known := make([]int, 0, len(sc.peers[node]))
+	for peer := range sc.peers[node] {
+		known = append(known, peer)
+	}
+	sort.Ints(known)
+
+	var mesh, others []int
+	for _, peer := range known {
+		c := sc.peers[node][peer]
+		c.firstDeliveries *= p.FirstDeliveryDecay
+		c.invalid *= p.InvalidDecay
+		if c.graftedAt.IsZero() {
+			continue
+		}
+		// prune disconnected peers, peers with negative score and
+		// churned ones
+		if !alive[peer] || sc.score(node, peer, now) < 0 || (p.Churn > 0 && sc.rand.Float64() < p.Churn) {
+			sc.prune(c, now)
+			continue
+		}
+		mesh = append(mesh, peer)
+	}
+	for _, peer := range candidates {
+		c := sc.counters(node, peer)
+		backoff := !c.prunedAt.IsZero() && now.Sub(c.prunedAt) < p.PruneBackoff
+		if c.graftedAt.IsZero() && !backoff && sc.score(node, peer, now) >= 0 {
+			others = append(others, peer)
+		}
+	}
+
+	byScore := func(peers []int) {
+		sc.rand.Shuffle(len(peers), func(i, j int) { peers[i], peers[j] = peers[j], peers[i] })
+		sort.SliceStable(peers, func(i, j int) bool {
+			return sc.score(node, peers[i], now) > sc.score(node, peers[j], now)
+		})
+	}
+
+	switch {
+	case len(mesh) < p.DLow:
+		byScore(others)
+		for _, peer := range others {
+			if len(mesh) >= p.D {
+				break
+			}
+			sc.peers[node][peer].graftedAt = now
+			sc.grafts++
+			mesh = append(mesh, peer)
+		}
+	case len(mesh) > p.DHigh:
+		byScore(mesh)
+		for _, peer := range mesh[p.D:] {
+			sc.prune(sc.peers[node][peer], now)
+		}
+	}
+}
+
+// prune removes peer from the mesh. Caller should hold the lock.
+func (sc *scoring) prune(c *peerCounters, now time.Time) {
+	c.graftedAt = time.Time{}
+	c.prunedAt = now
+	sc.prunes++
+}
+
+// changes returns total numbers of grafted and pruned mesh peers.
+func (sc *scoring) changes() (grafts, prunes int64) {
+	sc.mx.Lock()
+	defer sc.mx.Unlock()
+	return sc.grafts, sc.prunes
+}
+
+// Scores returns node's current scores of its peers, if peer scoring is
+// enabled with WithScoring.
+func (s *Simulator) Scores(node int) map[int]float64 {
+	if s.scoring == nil || node < 0 || node >= s.nodeCount() {
+		return nil
+	}
+	sc := s.scoring
+	sc.mx.Lock()
+	defer sc.mx.Unlock()
+	now := s.clock.now()
+	ret := make(map[int]float64, len(sc.peers[node]))
+	for peer := range sc.peers[node] {
+		ret[peer] = sc.score(node, peer, now)
+	}
+	return ret
+}
+
+// runHeartbeat schedules heartbeats maintaining meshes of all nodes
+// periodically, until simulator is stopped.
+func (s *Simulator) runHeartbeat() {
+	s.after(s.scoring.params.Heartbeat, nil, func() {
+		s.heartbeat()
+		s.runHeartbeat()
+	})
+}
+
+func (s *Simulator) heartbeat() {
+	now := s.clock.now()
+	for node := 0; node < s.nodeCount(); node++ {
+		s.mx.RLock()
+		var candidates []int
+		if !s.down[node] {
+			for _, peer := range s.peers[node] {
+				if !s.down[peer] && s.linkFormed(node, peer) {
+					candidates = append(candidates, peer)
+				}
+			}
+		}
+		s.mx.RUnlock()
+		s.scoring.heartbeat(node, candidates, now)
+	}
+}
@@ -0,0 +1,178 @@
+package gossip
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestScoringHeartbeat(t *testing.T) {
+	params := DefaultScoreParams()
+	params.D, params.DLow, params.DHigh = 3, 2, 4
+
+	// graft sets peers in node 0's mesh since given time
+	graft := func(sc *scoring, since time.Time, peers ...int) {
+		for _, peer := range peers {
+			sc.counters(0, peer).graftedAt = since
+		}
+	}
+	var tests = []struct {
+		name       string
+		setup      func(sc *scoring, now time.Time)
+		candidates []int
+		size       int
+		mesh       []int // checked if set, for deterministic cases
+		graylisted []int
+	}{
+		{
+			name:       "graft up to D",
+			setup:      func(sc *scoring, now time.Time) {},
+			candidates: []int{1, 2, 3, 4, 5, 6},
+			size:       3,
+		},
+		{
+			name: "graft best scored",
+			setup: func(sc *scoring, now time.Time) {
+				graft(sc, now, 1)
+				sc.counters(0, 5).firstDeliveries = 10
+				sc.counters(0, 6).firstDeliveries = 5
+			},
+			candidates: []int{1, 2, 3, 4, 5, 6},
+			size:       3,
+			mesh:       []int{1, 5, 6},
+		},
+		{
+			name: "keep mesh within bounds",
+			setup: func(sc *scoring, now time.Time) {
+				graft(sc, now, 1, 2)
+			},
+			candidates: []int{1, 2, 3, 4, 5, 6},
+			size:       2,
+			mesh:       []int{1, 2},
+		},
+		{
+			name: "prune down to D",
+			setup: func(sc *scoring, now time.Time) {
+				graft(sc, now, 1, 2, 3, 4, 5, 6)
+				for _, peer := range []int{2, 4, 6} {
+					sc.counters(0, peer).firstDeliveries = 10
+				}
+			},
+			candidates: []int{1, 2, 3, 4, 5, 6},
+			size:       3,
+			mesh:       []int{2, 4, 6},
+		},
+		{
+			name: "prune disconnected",
+			setup: func(sc *scoring, now time.Time) {
+				graft(sc, now, 1, 2, 3)
+			},
+			candidates: []int{1, 2},
+			size:       2,
+			mesh:       []int{1, 2},
+		},
+		{
+			name: "prune negative score",
+			setup: func(sc *scoring, now time.Time) {
+				graft(sc, now, 1, 2, 3)
+				sc.counters(0, 2).invalid = 2
+			},
+			candidates: []int{1, 2, 3},
+			size:       2,
+			mesh:       []int{1, 3},
+		},
+		{
+			name: "prune backoff",
+			setup: func(sc *scoring, now time.Time) {
+				for _, peer := range []int{1, 2, 3, 4} {
+					sc.counters(0, peer).prunedAt = now.Add(-params.PruneBackoff / 2)
+				}
+			},
+			candidates: []int{1, 2, 3, 4, 5, 6},
+			size:       2,
+			mesh:       []int{5, 6},
+		},
+		{
+			name: "graft after backoff",
+			setup: func(sc *scoring, now time.Time) {
+				for _, peer := range []int{1, 2, 3} {
+					sc.counters(0, peer).prunedAt = now.Add(-2 * params.PruneBackoff)
+				}
+			},
+			candidates: []int{1, 2, 3},
+			size:       3,
+			mesh:       []int{1, 2, 3},
+		},
+		{
+			name: "graylist",
+			setup: func(sc *scoring, now time.Time) {
+				sc.counters(0, 1).invalid = 5
+				sc.counters(0, 2).invalid = 1
+			},
+			candidates: []int{1, 2, 3},
+			size:       1,
+			mesh:       []int{3},
+			graylisted: []int{1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sc := newScoring(params, 7, newClock(), rand.New(rand.NewSource(1)))
+			now := time.Now()
+			test.setup(sc, now)
+			sc.heartbeat(0, test.candidates, now)
+
+			mesh := meshOf(sc, 0)
+			if len(mesh) != test.size {
+				t.Fatalf("expected mesh of %d peers, got %v", test.size, mesh)
+			}
+			if test.mesh != nil && !reflect.DeepEqual(mesh, test.mesh) {
+				t.Fatalf("expected mesh %v, got %v", test.mesh, mesh)
+			}
+			var graylisted []int
+			for _, peer := range test.candidates {
+				if sc.graylisted(0, peer) {
+					graylisted = append(graylisted, peer)
+				}
+			}
+			if !reflect.DeepEqual(graylisted, test.graylisted) {
+				t.Fatalf("expected graylisted peers %v, got %v", test.graylisted, graylisted)
+			}
+		})
+	}
+}
+
+func TestScoringHeartbeatSeed(t *testing.T) {
+	params := DefaultScoreParams()
+	params.D, params.DLow, params.DHigh = 3, 2, 4
+	params.Churn = 0.5
+
+	run := func() [][]int {
+		sc := newScoring(params, 1, newClock(), rand.New(rand.NewSource(42)))
+		now := time.Now()
+		var meshes [][]int
+		for i := 0; i < 20; i++ {
+			now = now.Add(params.Heartbeat)
+			sc.heartbeat(0, []int{1, 2, 3, 4, 5, 6, 7, 8}, now)
+			meshes = append(meshes, meshOf(sc, 0))
+		}
+		return meshes
+	}
+	if first, second := run(), run(); !reflect.DeepEqual(first, second) {
+		t.Fatalf("expected the same meshes with the same seed, got %v and %v", first, second)
+	}
+}
+
+// meshOf returns sorted peers in node's mesh.
+func meshOf(sc *scoring, node int) []int {
+	var mesh []int
+	for peer := range sc.peers[node] {
+		if sc.inMesh(node, peer) {
+			mesh = append(mesh, peer)
+		}
+	}
+	sort.Ints(mesh)
+	return mesh
+}
@@ -1,7 +1,9 @@
 package gossip
 
 import (
-	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -11,114 +13,594 @@ import (
 
 // Simulator is responsible for running propagation simulation.
 type Simulator struct {
-	data            *graph.Graph
-	delay           time.Duration
-	peers           map[int][]int
-	nodesCh         []chan Message
-	reportCh        chan propagation.LogEntry
-	peersToSendTo   int // number of peers to propagate message
-	wg              *sync.WaitGroup
-	simulationStart time.Time
+	data          *graph.Graph // guarded by mx, changed by NetworkMutator methods
+	directed      bool         // links are directed, see WithDirected
+	delay         time.Duration
+	nodes         []*nodeState // processing states of nodes, guarded by mx, grown by AddNode
+	peersToSendTo int          // number of peers to propagate message
+	quit          chan struct{}
+	mode          Mode
+	scoring       *scoring                         // nil if peer scoring is disabled
+	choking       *choking                         // nil if choking is disabled
+	bandwidth     []Bandwidth                      // nil if bandwidth is unlimited
+	queues        *queues                          // nil if transmissions don't contend
+	latency       func(from, to int) time.Duration // per link latency, optional
+	dutyCycles    *dutyCycles                      // nil if nodes are always online
+	nat           *nat                             // nil if all nodes are public
+	circuits      map[LinkIndex]int                // relayed links -> relay node, both directions
+	waku          *waku                            // nil if all nodes are relay ones
+	joins         *joins                           // nil if all nodes joined from the start
+	malicious     map[int]bool                     // nodes propagating invalid messages
+	withholding   map[int]bool                     // nodes never relaying messages
+	spam          *propagation.SpamParams          // nil if there is no background spam
+	limiter       *limiter                         // nil if sending rate is unlimited
+	spamMx        sync.Mutex
+	spamUsers     int           // messages being sent, sharing spam generation
+	stopSpam      func()        // stops spam generation, if it's running
+	loss          float64       // probability of losing each transmission
+	linkTypes     *linkTypes    // nil if links are untyped
+	retries       *RetryParams  // nil if lost messages are not retransmitted
+	coding        *CodingParams // nil if payload is not erasure-coded
+	hybrid        *HybridParams // nil if messages are pushed only
+	connections   *connections  // nil if connections are pre-established
+	transports    *transports   // nil if transport doesn't matter
+	topics        *topics       // nil if messages have no topics
+	maxDuration   time.Duration // zero if message propagation is unbounded
+	clock         *clock        // simulation time, possibly scaled
+	sched         *scheduler    // runs all events of the simulation
+	rand          *rand.Rand    // simulator own random source, see WithSeed
+	progress      propagation.ProgressFunc
+	events        propagation.EventFunc
+	observer      propagation.ObserverFunc
+
+	mx    sync.RWMutex
+	peers map[int][]int
+	down  map[int]bool // stopped nodes
+
+	inflightMx sync.Mutex
+	inflight   map[string]Message // messages of runs in progress, by content
+
+	seenMx    sync.Mutex
+	seen      []map[string]bool // messages seen by each node
+	requested []map[string]bool // messages requested with IWANT by each node
+
+	pauseMx  sync.Mutex
+	pausing  bool          // true while paused
+	paused   time.Duration // total time spent in pause
+	pausedAt time.Time
 }
 
 // Message represents the message propagated in the simulation.
 type Message struct {
 	Content []byte
 	TTL     int
+
+	kind     messageKind
+	priority Priority
+	fragment int    // index of erasure-coded fragment
+	topic    string // empty if message has no topic
+	from     int    // sender of the message
+	invalid  bool   // message has been tampered with and fails validation
+	pulled   bool   // payload sent on request rather than pushed
+	run      *messageRun
+}
+
+// messageKind defines the type of message exchanged between nodes.
+type messageKind int
+
+const (
+	kindPayload messageKind = iota // message with payload
+	kindIHave                      // announcement of the message ID
+	kindIWant                      // request of the announced message
+	kindChoke                      // request to send announcements only
+	kindUnchoke                    // request to push payload again
+	kindPull                       // request of the message, if peer has it
+)
+
+// controlMessageSize is the size of IHAVE/IWANT/pull messages, which carry
+// message ID (hash) only.
+const controlMessageSize = 32
+
+// messageRun tracks propagation of the single message sent with SendMessage.
+type messageRun struct {
+	id          string // message identifier reported in log entries
+	start       time.Time
+	paused      time.Duration  // simulator pause time at start
+	wg          sync.WaitGroup // in-flight events, see scheduler
+	collector   *collector     // nil for spam messages
+	traffic     propagation.Traffic
+	offline     propagation.Offline
+	reliability propagation.Reliability
+	mesh        propagation.Mesh
+	grafts      int64     // total mesh grafts at start, with scoring
+	prunes      int64     // total mesh prunes at start, with scoring
+	decoding    *decoding // nil if payload is not erasure-coded
+}
+
+// expired reports whether max duration of the run is reached, so its
+// messages are not propagated anymore.
+func (r *messageRun) expired() bool {
+	return r.collector != nil && r.collector.isExpired()
 }
 
 // NewSimulator initializes new simulator for the given graph data.
-func NewSimulator(data *graph.Graph, N int, delay time.Duration) *Simulator {
+func NewSimulator(data *graph.Graph, N int, delay time.Duration, opts ...Option) *Simulator {
 	nodeCount := data.NumNodes()
+	clock := newClock()
 	sim := &Simulator{
 		data:          data,
 		delay:         delay,
 		peers:         PrecalculatePeers(data),
+		down:          make(map[int]bool),
 		peersToSendTo: N,
-		reportCh:      make(chan propagation.LogEntry),
-		nodesCh:       make([]chan Message, nodeCount), // one channel per node
-		wg:            new(sync.WaitGroup),
+		nodes:         newNodeStates(nodeCount),
+		seen:          make([]map[string]bool, nodeCount),
+		requested:     make([]map[string]bool, nodeCount),
+		quit:          make(chan struct{}),
+		malicious:     make(map[int]bool),
+		withholding:   make(map[int]bool),
+		clock:         clock,
+		sched:         newScheduler(clock),
+		rand:          newRand(defaultSeed()),
+		progress:      func(propagation.Progress) {},
+		events:        func(propagation.LogEntry) {},
+		observer:      func(propagation.Event) {},
+	}
+	for _, opt := range opts {
+		opt(sim)
 	}
-	sim.wg.Add(nodeCount)
 	for i := 0; i < nodeCount; i++ {
-		ch := sim.startNode(i)
-		sim.nodesCh[i] = ch // this channel will be used to talk to node by index
+		sim.seen[i] = make(map[string]bool)
+		sim.requested[i] = make(map[string]bool)
+	}
+	go sim.sched.run(sim.quit)
+	if sim.scoring != nil {
+		sim.heartbeat() // build initial meshes
+		sim.runHeartbeat()
 	}
 	return sim
 }
 
 // Stop stops simulator and frees all resources if any. Implements propagation.Simulator.
 func (s *Simulator) Stop() error {
+	close(s.quit)
 	return nil
 }
 
 // SendMessage sends single message and tracks propagation. Implements propagation.Simulator.
+// It's safe to call SendMessage multiple times, including concurrently.
 func (s *Simulator) SendMessage(startNodeIdx, ttl, size int) *propagation.Log {
-	message := s.generateMessage(ttl, size)
-	s.simulationStart = time.Now()
-	s.propagateMessage(startNodeIdx, message)
+	return s.SendMessageWithPriority(startNodeIdx, ttl, size, PriorityNormal)
+}
+
+// SendMessageWithPriority sends single message of the given priority and
+// tracks its propagation, see SendMessage and WithRateLimit.
+func (s *Simulator) SendMessageWithPriority(startNodeIdx, ttl, size int, priority Priority) *propagation.Log {
+	run := &messageRun{
+		id:        s.newMessageID(),
+		start:     s.clock.now(),
+		paused:    s.pausedTotal(),
+		collector: newCollector(),
+	}
+	if s.scoring != nil {
+		run.grafts, run.prunes = s.scoring.changes()
+	}
+	// erasure-coded fragments are propagated as independent messages
+	fragments := 1
+	if s.coding != nil {
+		fragments = s.coding.N
+		size = s.coding.fragmentSize(size)
+		run.decoding = newDecoding(s.coding.K, s.nodeCount())
+	}
+	stopSpam := s.startSpam()
+	defer stopSpam()
+	push, pull := s.pushRounds(ttl)
+	for i := 0; i < fragments; i++ {
+		message := s.generateMessage(push, size)
+		message.priority = priority
+		message.fragment = i
+		message.topic = s.topics.topic()
+		message.run = run
+		s.track(message)
+		s.publish(startNodeIdx, message)
+		s.startPull(message, pull)
+	}
 
 	done := make(chan bool)
 	go func() {
-		s.wg.Wait()
+		run.wg.Wait()
 		done <- true
 	}()
 
-	var ret []*propagation.LogEntry
+	// deadline fires once max duration is reached, if set
+	var deadline <-chan time.Time
+	if s.maxDuration > 0 {
+		timer := time.NewTimer(s.clock.real(s.maxDuration))
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	// entries are processed in batches, while senders keep going
+	var processed int
+	reached := make(map[int]bool)
+	process := func() {
+		entries := run.collector.since(processed)
+		processed += len(entries)
+		for _, entry := range entries {
+			s.events(entry)
+			if !reached[entry.To] {
+				reached[entry.To] = true
+				s.progress(propagation.Progress{
+					Phase: propagation.PhaseCollect,
+					Done:  len(reached),
+					Total: s.nodeCount(),
+				})
+			}
+		}
+	}
 	for {
 		select {
-		case val := <-s.reportCh:
-			ret = append(ret, &val)
+		case <-deadline:
+			// let in-flight messages finish, dropping their reports
+			run.collector.expire()
+			deadline = nil
+		case <-run.collector.notify:
+			process()
 		case <-done:
-			return propagation.LogEntries2Log(s.data, ret)
+			s.untrack(run)
+			process()
+			plog := run.collector.entries.Log(s.graph())
+			run.collector.entries.Release()
+			traffic := run.traffic
+			plog.Traffic = &traffic
+			if s.dutyCycles != nil {
+				offline := run.offline
+				plog.Offline = &offline
+			}
+			if s.loss > 0 || s.linkTypes.lossy() || s.retries != nil {
+				reliability := run.reliability
+				plog.Reliability = &reliability
+			}
+			if s.scoring != nil {
+				mesh := run.mesh
+				grafts, prunes := s.scoring.changes()
+				mesh.Grafts, mesh.Prunes = grafts-run.grafts, prunes-run.prunes
+				plog.Mesh = &mesh
+			}
+			return plog
 		}
 	}
 }
 
-func (s *Simulator) startNode(i int) chan Message {
-	ch := make(chan Message)
-	go s.runNode(i, ch)
-	return ch
+// StopNode marks node as stopped, so it doesn't receive or propagate messages
+// anymore. Implements propagation.NodeStopper.
+func (s *Simulator) StopNode(idx int) error {
+	if idx < 0 || idx >= s.nodeCount() {
+		return fmt.Errorf("node with index %d not found", idx)
+	}
+	s.mx.Lock()
+	s.down[idx] = true
+	s.mx.Unlock()
+	s.observer(propagation.NodeDownEvent{Ts: s.sinceEpoch(), Node: idx})
+	return nil
 }
 
-// runNode does actual node processing part
-func (s *Simulator) runNode(i int, ch chan Message) {
-	defer s.wg.Done()
-	t := time.NewTimer(10 * time.Second)
+// DisconnectNodes removes connection between two nodes (see RemoveLink).
+// Implements propagation.NodesDisconnector.
+func (s *Simulator) DisconnectNodes(from, to int) error {
+	return s.RemoveLink(from, to)
+}
 
-	cache := make(map[string]bool)
-	for {
-		select {
-		case message := <-ch:
-			if cache[string(message.Content)] {
-				continue
-			}
-			cache[string(message.Content)] = true
-			message.TTL--
-			if message.TTL == 0 {
-				return
+// processMessage handles single message received by node.
+func (s *Simulator) processMessage(i int, message Message) {
+	if s.scoring != nil && s.scoring.graylisted(i, message.from) {
+		return
+	}
+
+	switch message.kind {
+	case kindIHave:
+		if s.markRequested(i, message.Content) {
+			if s.choking != nil && s.choking.announce(i, message.from) {
+				s.send(i, message.from, message.withKind(kindUnchoke))
 			}
-			s.propagateMessage(i, message)
-		case <-t.C:
+			s.process(i, message.run, func() {
+				s.send(i, message.from, message.withKind(kindIWant))
+			})
+		}
+		return
+	case kindIWant:
+		if s.withholding[i] {
 			return
 		}
+		s.process(i, message.run, func() {
+			s.send(i, message.from, message.withKind(kindPayload).pull())
+		})
+		return
+	case kindPull:
+		if s.withholding[i] || !s.isSeen(i, message.Content) {
+			return
+		}
+		s.process(i, message.run, func() {
+			s.send(i, message.from, message.withKind(kindPayload).pull())
+		})
+		return
+	case kindChoke, kindUnchoke:
+		s.choking.setChoked(i, message.from, message.kind == kindChoke)
+		return
+	}
+
+	if message.invalid {
+		s.scoreDelivery(i, message.from, false, false)
+		// let node request the message from other peers
+		s.unmarkRequested(i, message.Content)
+		return
 	}
+	first := s.markSeen(i, message.Content)
+	s.scoreDelivery(i, message.from, first, true)
+	if s.choking != nil && s.choking.payload(i, message.from, s.peersCount(i), first) {
+		s.send(i, message.from, message.withKind(kindChoke))
+	}
+	if !first {
+		return
+	}
+	if s.scoring != nil {
+		message.run.mesh.AddDelivery(message.pulled)
+	}
+	message.pulled = false
+	s.serveLight(i, message)
+	message.TTL--
+	if message.TTL == 0 || s.withholding[i] || !s.waku.relays(i) || !s.topics.relays(i, message.topic) {
+		return
+	}
+	s.propagateMessage(i, message)
 }
 
-// propagateMessage simulates message sending from node to its peers.
+// propagateMessage simulates message sending from node to its peers, once
+// node processes the message.
 func (s *Simulator) propagateMessage(from int, message Message) {
-	time.Sleep(s.delay)
-	peers := s.peers[from]
-	for i := range peers {
-		go s.sendMessage(from, peers[i], message)
+	s.process(from, message.run, func() {
+		s.sendToPeers(from, message)
+	})
+}
+
+// sendToPeers sends message from node to its peers.
+func (s *Simulator) sendToPeers(from int, message Message) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	if s.down[from] {
+		return
+	}
+	kind := kindPayload
+	if s.mode == LazyPush {
+		kind = kindIHave
+	}
+	if s.malicious[from] {
+		message.invalid = true
+	}
+	// payload goes over preferred link types only, if node has them
+	announceOnly := s.linkTypes.announceOnly(from, s.peers[from])
+	for _, peer := range s.peers[from] {
+		if !s.topics.sendsTo(peer, message.topic) {
+			continue
+		}
+		// with scoring, payload is pushed to mesh peers only
+		k := kind
+		if s.scoring != nil {
+			k = kindIHave
+			if s.scoring.inMesh(from, peer) {
+				k = kindPayload
+			}
+		}
+		if s.choking != nil && s.choking.isChoked(from, peer) {
+			k = kindIHave
+		}
+		if announceOnly != nil && announceOnly(peer) {
+			k = kindIHave
+		}
+		s.send(from, peer, message.withKind(k))
+	}
+}
+
+// send schedules message sending from node to its peer. It doesn't
+// send right away, as callers may hold the simulator lock.
+func (s *Simulator) send(from, to int, message Message) {
+	if message.run.expired() {
+		return
 	}
+	message.from = from
+	s.after(0, message.run, func() {
+		s.sendMessage(from, to, message)
+	})
 }
 
-// sendMessage simulates message sending for given from and to indexes.
+// sendMessage simulates message sending for given from and to indexes,
+// scheduling its delivery once message is transmitted. Only payload
+// messages are reported to the log, but all of them count as traffic.
 func (s *Simulator) sendMessage(from, to int, message Message) {
-	s.nodesCh[to] <- message
-	entry := propagation.NewLogEntry(time.Now(), s.simulationStart, from, to)
-	s.reportCh <- *entry
+	size := controlMessageSize
+	if message.kind == kindPayload {
+		size = len(message.Content)
+	}
+	if t, ok := s.transport(from, to); ok {
+		size += t.Overhead
+	}
+	message.countTraffic(size)
+	s.observe(message, propagation.SendEvent{From: from, To: to})
+
+	// messages can't go over links not formed yet
+	if s.isDown(to) {
+		s.observe(message, propagation.DropEvent{From: from, To: to, Reason: propagation.DropNodeDown})
+		return
+	}
+	if !s.linkFormed(from, to) {
+		return
+	}
+	// messages over relayed links and between NAT'd nodes go through the relay
+	relay, relayed := s.relay(from, to)
+	if relayed {
+		if s.isDown(relay) {
+			s.observe(message, propagation.DropEvent{From: from, To: to, Reason: propagation.DropNodeDown})
+			return
+		}
+		message.countTraffic(size) // relay to receiver hop
+	}
+
+	// hold message until both nodes are online
+	wait, ok := s.untilOnline(from, to)
+	if !ok {
+		return
+	}
+	if wait > 0 && message.kind == kindPayload {
+		message.run.offline.AddDelay(wait)
+	}
+	s.after(wait, message.run, func() {
+		s.limit(from, to, message, func() {
+			transfer := s.sendTime(from, to, relay, relayed, size, message)
+			loss := s.linkLoss(from, to)
+			took, delivered := s.transmit(message, size, transfer, loss)
+			s.after(took, message.run, func() {
+				if !delivered {
+					s.observe(message, propagation.DropEvent{From: from, To: to, Reason: propagation.DropLoss})
+					return
+				}
+				s.receive(from, to, size, loss, message)
+			})
+		})
+	})
+}
+
+// sendTime returns time message takes to get from node to its peer,
+// possibly over the relay, including link setup.
+func (s *Simulator) sendTime(from, to, relay int, relayed bool, size int, message Message) time.Duration {
+	transfer := s.transferTime(from, to, size)
+	switch {
+	case relayed && s.queues != nil:
+		// relay uplink is loaded by all links it relays
+		transfer = s.queues.reserve(from, relay, s.transferTime(from, relay, size)) +
+			s.queues.reserve(relay, to, s.transferTime(relay, to, size))
+	case relayed:
+		transfer = s.transferTime(from, relay, size) + s.transferTime(relay, to, size)
+	case s.queues != nil:
+		transfer = s.queues.reserve(from, to, transfer)
+	}
+	if relayed {
+		transfer += s.linkLatency(from, relay) + s.linkLatency(relay, to)
+	} else {
+		transfer += s.linkLatency(from, to)
+	}
+	if relayed {
+		transfer += s.linkSetup(from, relay, message) + s.linkSetup(relay, to, message)
+	} else {
+		transfer += s.linkSetup(from, to, message)
+	}
+	return transfer
+}
+
+// receive delivers message transmitted from node to its peer and reports
+// it to the log.
+func (s *Simulator) receive(from, to, size int, loss float64, message Message) {
+	if message.run.expired() {
+		return
+	}
+	s.acknowledge(message, size, loss)
+	s.deliver(to, message)
+	// invalid messages don't propagate, so they're not reported
+	if message.kind != kindPayload || message.invalid || message.run.collector == nil {
+		return
+	}
+	// coded message is reported once node is able to decode it
+	if d := message.run.decoding; d != nil && !d.deliver(to, message.fragment) {
+		return
+	}
+	entry := propagation.NewLogEntry(s.runTime(message), message.run.start, from, to)
+	entry.Msg = message.run.id
+	message.run.collector.add(*entry)
+	s.observe(message, propagation.ReceiveEvent{From: from, To: to})
+}
+
+// runTime returns current time of the message run, excluding time spent
+// in pause since message sending.
+func (s *Simulator) runTime(message Message) time.Time {
+	return s.clock.now().Add(message.run.paused - s.pausedTotal())
+}
+
+// markRequested marks message content as requested by node and reports
+// whether it should be requested, i.e. it's neither seen nor requested yet.
+func (s *Simulator) markRequested(idx int, content []byte) bool {
+	s.seenMx.Lock()
+	defer s.seenMx.Unlock()
+	key := string(content)
+	if s.seen[idx][key] || s.requested[idx][key] {
+		return false
+	}
+	s.requested[idx][key] = true
+	return true
+}
+
+// unmarkRequested removes requested mark from message content for node.
+func (s *Simulator) unmarkRequested(idx int, content []byte) {
+	s.seenMx.Lock()
+	defer s.seenMx.Unlock()
+	delete(s.requested[idx], string(content))
+}
+
+// scoreDelivery accounts for payload delivery in peer scores, if enabled.
+func (s *Simulator) scoreDelivery(node, peer int, first, valid bool) {
+	if s.scoring != nil {
+		s.scoring.deliver(node, peer, first, valid)
+	}
+}
+
+// markSeen marks message content as seen by node and reports
+// whether it's seen for the first time.
+func (s *Simulator) markSeen(idx int, content []byte) bool {
+	s.seenMx.Lock()
+	defer s.seenMx.Unlock()
+	if s.seen[idx][string(content)] {
+		return false
+	}
+	s.seen[idx][string(content)] = true
+	return true
+}
+
+func (s *Simulator) peersCount(idx int) int {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return len(s.peers[idx])
+}
+
+func (s *Simulator) isDown(idx int) bool {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.down[idx]
+}
+
+// countTraffic accounts for the message sending of the given size.
+func (m Message) countTraffic(size int) {
+	if m.kind == kindPayload {
+		m.run.traffic.AddPayload(size)
+	} else {
+		m.run.traffic.AddControl(size)
+	}
+}
+
+// withKind returns copy of the message with the given kind.
+func (m Message) withKind(kind messageKind) Message {
+	m.kind = kind
+	return m
+}
+
+// pull returns copy of the message marked as sent on request.
+func (m Message) pull() Message {
+	m.pulled = true
+	return m
+}
+
+// newMessageID returns random identifier of the message.
+func (s *Simulator) newMessageID() string {
+	id := make([]byte, 8)
+	readRand(s.rand, id)
+	return hex.EncodeToString(id)
 }
 
 func (s *Simulator) generateMessage(ttl, size int) Message {
@@ -126,6 +608,17 @@ func (s *Simulator) generateMessage(ttl, size int) Message {
 		Content: make([]byte, size),
 		TTL:     ttl,
 	}
-	rand.Read(msg.Content)
+	readRand(s.rand, msg.Content)
 	return msg
 }
+
+// removePeer returns peers without the given one.
+func removePeer(peers []int, peer int) []int {
+	ret := make([]int, 0, len(peers))
+	for _, p := range peers {
+		if p != peer {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
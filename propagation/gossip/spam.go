@@ -0,0 +1,71 @@
+package gossip
+
+import (
+	"sync/atomic"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// WithSpam makes spamming nodes send background messages while the
+// measured message propagates (see SendMessage). Spam messages are
+// propagated as usual, contending for bandwidth and queues, but they are
+// not reported to the propagation log and don't count as its traffic.
+func WithSpam(params propagation.SpamParams) Option {
+	return func(s *Simulator) {
+		if params.TTL == 0 {
+			params.TTL = 10
+		}
+		s.spam = &params
+	}
+}
+
+// startSpam starts spam generation, unless it's already running for
+// messages sent concurrently, and returns function stopping it once all
+// of them are done.
+func (s *Simulator) startSpam() func() {
+	s.spamMx.Lock()
+	defer s.spamMx.Unlock()
+	s.spamUsers++
+	if s.spamUsers == 1 {
+		s.stopSpam = s.runSpam()
+	}
+	return func() {
+		s.spamMx.Lock()
+		defer s.spamMx.Unlock()
+		s.spamUsers--
+		if s.spamUsers == 0 {
+			s.stopSpam()
+		}
+	}
+}
+
+// runSpam starts spam generation by spamming nodes and returns function
+// stopping it.
+func (s *Simulator) runSpam() func() {
+	if s.spam == nil || len(s.spam.Nodes) == 0 {
+		return func() {}
+	}
+	var stopped int32
+	var spam func(node int)
+	spam = func(node int) {
+		s.after(s.spam.Interval(), nil, func() {
+			if atomic.LoadInt32(&stopped) == 1 {
+				return
+			}
+			message := s.generateMessage(s.spam.TTL, s.spam.Size)
+			message.run = &messageRun{
+				start:  s.clock.now(),
+				paused: s.pausedTotal(),
+			}
+			s.markSeen(node, message.Content)
+			s.propagateMessage(node, message)
+			spam(node)
+		})
+	}
+	for _, node := range s.spam.Nodes {
+		spam(node)
+	}
+	return func() {
+		atomic.StoreInt32(&stopped, 1)
+	}
+}
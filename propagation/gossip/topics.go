@@ -0,0 +1,100 @@
+package gossip
+
+import (
+	"fmt"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// RelayPolicy defines how nodes relay messages on topics.
+type RelayPolicy int
+
+const (
+	// RelayAll makes all nodes relay all messages, regardless of their
+	// subscriptions, like Whisper nodes with full bloom filter.
+	RelayAll RelayPolicy = iota
+	// RelayBloom makes nodes send messages only to peers whose topics
+	// bloom filter matches the message topic, like Whisper nodes with
+	// bloom filters of their subscriptions. Nodes receiving messages
+	// due to false positives relay them as well.
+	RelayBloom
+	// RelayMesh makes only subscribers relay messages, and only to peers
+	// subscribed to the topic, like GossipSub topic meshes. Publisher
+	// doesn't need to be subscribed.
+	RelayMesh
+)
+
+// ParseRelayPolicy parses relay policy name ("all", "bloom" or "mesh").
+func ParseRelayPolicy(name string) (RelayPolicy, error) {
+	switch name {
+	case "all", "":
+		return RelayAll, nil
+	case "bloom":
+		return RelayBloom, nil
+	case "mesh":
+		return RelayMesh, nil
+	default:
+		return RelayAll, fmt.Errorf("unknown relay policy '%s'", name)
+	}
+}
+
+// TopicParams defines topic-based pubsub model.
+type TopicParams struct {
+	Subscriptions propagation.Subscriptions
+	Relay         RelayPolicy
+	Topic         string // topic of messages sent with SendMessage
+}
+
+// topics keeps subscriptions state of all nodes.
+type topics struct {
+	params TopicParams
+	blooms []propagation.Bloom // bloom filter advertised by each node
+}
+
+// WithTopics enables topic-based pubsub with the given parameters (see
+// TopicParams), so messages sent with SendMessage are published on
+// params.Topic and relayed according to params.Relay.
+func WithTopics(params TopicParams) Option {
+	return func(s *Simulator) {
+		t := &topics{
+			params: params,
+			blooms: make([]propagation.Bloom, len(s.nodes)),
+		}
+		for i := range t.blooms {
+			t.blooms[i] = params.Subscriptions.Bloom(i)
+		}
+		s.topics = t
+	}
+}
+
+// relays reports whether node relays messages on topic further.
+// Messages without topic (i.e. spam) are relayed by all nodes.
+func (t *topics) relays(node int, topic string) bool {
+	if t == nil || topic == "" || t.params.Relay != RelayMesh {
+		return true
+	}
+	return t.params.Subscriptions.Subscribed(node, topic)
+}
+
+// sendsTo reports whether message on topic should be sent to peer.
+func (t *topics) sendsTo(peer int, topic string) bool {
+	if t == nil || topic == "" {
+		return true
+	}
+	switch t.params.Relay {
+	case RelayBloom:
+		return t.blooms[peer].Matches(propagation.TopicBloom(topic))
+	case RelayMesh:
+		return t.params.Subscriptions.Subscribed(peer, topic)
+	default:
+		return true
+	}
+}
+
+// topic returns topic of messages sent with SendMessage.
+func (t *topics) topic() string {
+	if t == nil {
+		return ""
+	}
+	return t.params.Topic
+}
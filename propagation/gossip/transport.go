@@ -0,0 +1,133 @@
+package gossip
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// Transport describes characteristics of the transport protocol nodes
+// connect with, which shape each transmission over link on top of link
+// latency and bandwidth.
+type Transport struct {
+	Latency   time.Duration   // added to each transmission, i.e. framing and head-of-line blocking
+	Overhead  int             // framing bytes added to each message
+	Handshake HandshakeParams // connection setup, if links are established lazily
+}
+
+// Transports defines known transport profiles by name. Numbers are rough
+// approximations: QUIC avoids head-of-line blocking and sets up encrypted
+// connection in one round trip, while WebSocket used by browsers adds
+// HTTP upgrade to TCP and TLS handshake, and extra framing.
+var Transports = map[string]Transport{
+	"tcp": {
+		Latency:   2 * time.Millisecond,
+		Overhead:  52, // TCP/IP headers and stream multiplexer framing
+		Handshake: handshakes["tls"],
+	},
+	"quic": {
+		Overhead:  60,
+		Handshake: HandshakeParams{RoundTrips: 1, Bytes: 4000},
+	},
+	"websocket": {
+		Latency:   5 * time.Millisecond,
+		Overhead:  66,
+		Handshake: HandshakeParams{RoundTrips: 3, Bytes: 5000},
+	},
+}
+
+// TransportClass represents share of nodes using the given transport.
+type TransportClass struct {
+	Name      string
+	Transport Transport
+	Share     float64 // fraction of nodes, shares are normalized
+}
+
+// ParseTransportClasses parses transports mix in form of comma-separated
+// name=share pairs, i.e. "tcp=0.7,quic=0.2,websocket=0.1". Names should
+// be from Transports.
+func ParseTransportClasses(s string) ([]TransportClass, error) {
+	var (
+		classes []TransportClass
+		shares  []float64
+	)
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		t, ok := Transports[kv[0]]
+		if !ok {
+			return nil, fmt.Errorf("unknown transport '%s'", kv[0])
+		}
+		share := 1.0
+		if len(kv) == 2 {
+			var err error
+			share, err = strconv.ParseFloat(kv[1], 64)
+			if err != nil || share < 0 {
+				return nil, fmt.Errorf("wrong share for transport '%s'", kv[0])
+			}
+		}
+		classes = append(classes, TransportClass{Name: kv[0], Transport: t, Share: share})
+		shares = append(shares, share)
+	}
+	if err := propagation.CheckShares(shares); err != nil {
+		return nil, fmt.Errorf("wrong transports mix '%s': %v", s, err)
+	}
+	return classes, nil
+}
+
+// transports keeps transport classes of nodes.
+type transports struct {
+	classes []TransportClass
+	class   []int // node -> index of its class
+}
+
+// WithTransportClasses randomly assigns transports to nodes, proportionally
+// to class shares. Link between nodes with different transports goes over
+// the slower one, i.e. browser node connects to all its peers over
+// WebSocket. With WithHandshake, lazily established links pay handshake
+// of their transport.
+func WithTransportClasses(classes ...TransportClass) Option {
+	return func(s *Simulator) {
+		if len(classes) == 0 {
+			return
+		}
+		shares := make([]float64, len(classes))
+		for i, c := range classes {
+			shares[i] = c.Share
+		}
+		t := &transports{
+			classes: classes,
+			class:   propagation.SplitShares(s.rand.Perm(len(s.nodes)), shares),
+		}
+		s.transports = t
+	}
+}
+
+// TransportNames returns transport name of each node, if assigned with
+// WithTransportClasses.
+func (s *Simulator) TransportNames() []string {
+	if s.transports == nil {
+		return nil
+	}
+	names := make([]string, len(s.transports.class))
+	for i, c := range s.transports.class {
+		names[i] = s.transports.classes[c].Name
+	}
+	return names
+}
+
+// transport returns transport of the link between two nodes, which is
+// the one of them with higher latency.
+func (s *Simulator) transport(from, to int) (Transport, bool) {
+	if s.transports == nil {
+		return Transport{}, false
+	}
+	a := s.transports.classes[s.transports.class[from]].Transport
+	b := s.transports.classes[s.transports.class[to]].Transport
+	if b.Latency > a.Latency {
+		return b, true
+	}
+	return a, true
+}
@@ -0,0 +1,18 @@
+package gossip
+
+import "testing"
+
+func TestParseTransportClasses(t *testing.T) {
+	classes, err := ParseTransportClasses("tcp=0.7,quic=0.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(classes) != 2 || classes[1].Name != "quic" || classes[1].Share != 0.3 {
+		t.Fatalf("unexpected classes: %+v", classes)
+	}
+	for _, s := range []string{"tcp=0,quic=0", "tcp=-0.5", "udp=1"} {
+		if _, err := ParseTransportClasses(s); err == nil {
+			t.Fatalf("expected error for '%s'", s)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package gossip
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// Roles is a set of Waku protocols run by the node.
+type Roles uint8
+
+// Service roles imply RoleRelay, as Waku service nodes are relay nodes.
+// Nodes without RoleRelay are light clients: they don't relay messages,
+// but use service nodes to receive (filter or store) and publish
+// (lightpush) messages.
+const (
+	RoleRelay     Roles = 1 << iota // relays messages to peers
+	RoleStore                       // stores messages for light clients to query
+	RoleFilter                      // pushes messages to subscribed light clients
+	RoleLightPush                   // publishes messages on behalf of light clients
+)
+
+// roleNames lists roles names in order they're formatted.
+var roleNames = []struct {
+	name string
+	role Roles
+}{
+	{"relay", RoleRelay},
+	{"store", RoleStore},
+	{"filter", RoleFilter},
+	{"lightpush", RoleLightPush},
+}
+
+// ParseRoles parses '+'-separated role names, i.e. "relay+filter+store".
+// Role "light" stands for light client, running no relay.
+func ParseRoles(s string) (Roles, error) {
+	var roles Roles
+	var light bool
+	for _, name := range strings.Split(s, "+") {
+		if name == "light" {
+			light = true
+			continue
+		}
+		var found bool
+		for _, r := range roleNames {
+			if r.name == name {
+				roles |= r.role
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown waku role '%s'", name)
+		}
+	}
+	if light && roles != 0 {
+		return 0, fmt.Errorf("light client can't run other roles, got '%s'", s)
+	}
+	if roles != 0 {
+		roles |= RoleRelay
+	}
+	return roles, nil
+}
+
+// Light reports whether roles describe light client.
+func (r Roles) Light() bool {
+	return r&RoleRelay == 0
+}
+
+// String implements Stringer interface for Roles.
+func (r Roles) String() string {
+	if r.Light() {
+		return "light"
+	}
+	var names []string
+	for _, rn := range roleNames {
+		if r&rn.role != 0 {
+			names = append(names, rn.name)
+		}
+	}
+	return strings.Join(names, "+")
+}
+
+// RoleClass represents share of nodes running the given roles.
+type RoleClass struct {
+	Roles Roles
+	Share float64 // fraction of nodes, shares are normalized
+}
+
+// ParseRoleClasses parses roles mix in form of comma-separated roles=share
+// pairs, i.e. "relay=0.6,relay+filter+lightpush+store=0.1,light=0.3".
+func ParseRoleClasses(s string) ([]RoleClass, error) {
+	var (
+		classes []RoleClass
+		shares  []float64
+	)
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		roles, err := ParseRoles(kv[0])
+		if err != nil {
+			return nil, err
+		}
+		share := 1.0
+		if len(kv) == 2 {
+			share, err = strconv.ParseFloat(kv[1], 64)
+			if err != nil || share < 0 {
+				return nil, fmt.Errorf("wrong share for waku roles '%s'", kv[0])
+			}
+		}
+		classes = append(classes, RoleClass{Roles: roles, Share: share})
+		shares = append(shares, share)
+	}
+	if err := propagation.CheckShares(shares); err != nil {
+		return nil, fmt.Errorf("wrong waku roles mix '%s': %v", s, err)
+	}
+	return classes, nil
+}
+
+// DefaultStorePoll is the default interval of light clients querying store
+// nodes for new messages.
+const DefaultStorePoll = time.Second
+
+// WakuParams defines Waku roles model, where only relay nodes form the
+// relay network, and light clients reach it through service nodes.
+type WakuParams struct {
+	Classes   []RoleClass
+	StorePoll time.Duration // interval of store queries, DefaultStorePoll if zero
+}
+
+// waku keeps roles of nodes and service nodes of light clients.
+type waku struct {
+	roles     []Roles
+	lightPush map[int]int     // light client -> its lightpush node
+	filter    map[int][]int   // filter node -> subscribed light clients
+	store     map[int][]int   // store node -> light clients querying it
+	poll      time.Duration   // store query interval
+	phase     []time.Duration // store query phase of each light client
+}
+
+// WithWaku randomly assigns Waku roles to nodes, proportionally to class
+// shares. Light clients are removed from the relay network and pick
+// service nodes among their peers. They subscribe to a filter node to
+// receive messages or, if no peer runs filter, query a store node every
+// StorePoll. Messages sent by light client are published through
+// lightpush node. Light clients without such peers are left unserved.
+//
+// It should go after options changing peers, like WithDirected.
+func WithWaku(params WakuParams) Option {
+	return func(s *Simulator) {
+		if len(params.Classes) == 0 {
+			return
+		}
+		if params.StorePoll <= 0 {
+			params.StorePoll = DefaultStorePoll
+		}
+		shares := make([]float64, len(params.Classes))
+		for i, c := range params.Classes {
+			shares[i] = c.Share
+		}
+		n := len(s.nodes)
+		class := propagation.SplitShares(s.rand.Perm(n), shares)
+		w := &waku{
+			roles:     make([]Roles, n),
+			lightPush: make(map[int]int),
+			filter:    make(map[int][]int),
+			store:     make(map[int][]int),
+			poll:      params.StorePoll,
+			phase:     make([]time.Duration, n),
+		}
+		for idx, i := range class {
+			w.roles[idx] = params.Classes[i].Roles
+		}
+
+		for node, roles := range w.roles {
+			if !roles.Light() {
+				continue
+			}
+			if lp, ok := w.service(s, node, RoleLightPush); ok {
+				w.lightPush[node] = lp
+			}
+			if f, ok := w.service(s, node, RoleFilter); ok {
+				w.filter[f] = append(w.filter[f], node)
+			} else if st, ok := w.service(s, node, RoleStore); ok {
+				w.store[st] = append(w.store[st], node)
+				w.phase[node] = time.Duration(s.rand.Int63n(int64(w.poll)))
+			}
+		}
+
+		// light clients don't take part in relay
+		for node, peers := range s.peers {
+			if w.roles[node].Light() {
+				s.peers[node] = nil
+				continue
+			}
+			var relays []int
+			for _, peer := range peers {
+				if !w.roles[peer].Light() {
+					relays = append(relays, peer)
+				}
+			}
+			s.peers[node] = relays
+		}
+		s.waku = w
+	}
+}
+
+// service picks random peer of the light client running role.
+func (w *waku) service(s *Simulator, node int, role Roles) (int, bool) {
+	var candidates []int
+	for _, peer := range s.peers[node] {
+		if w.roles[peer]&role != 0 {
+			candidates = append(candidates, peer)
+		}
+	}
+	if len(candidates) == 0 {
+		return 0, false
+	}
+	return candidates[s.rand.Intn(len(candidates))], true
+}
+
+// Roles returns Waku roles of nodes, if enabled with WithWaku.
+func (s *Simulator) Roles() []Roles {
+	if s.waku == nil {
+		return nil
+	}
+	return append([]Roles(nil), s.waku.roles...)
+}
+
+// relays reports whether node relays messages further.
+func (w *waku) relays(node int) bool {
+	return w == nil || !w.roles[node].Light()
+}
+
+// publish starts propagation of the message sent from node. Light clients
+// hand it over to their lightpush node instead.
+func (s *Simulator) publish(node int, message Message) {
+	s.markSeen(node, message.Content)
+	if s.waku != nil {
+		if lp, ok := s.waku.lightPush[node]; ok {
+			s.send(node, lp, message.withKind(kindPayload))
+			return
+		}
+	}
+	s.serveLight(node, message)
+	s.propagateMessage(node, message)
+}
+
+// serveLight delivers message seen by service node to its light clients:
+// filter subscribers get it right away, and store clients with their next
+// query.
+func (s *Simulator) serveLight(node int, message Message) {
+	if s.waku == nil {
+		return
+	}
+	message = message.withKind(kindPayload)
+	for _, client := range s.waku.filter[node] {
+		s.send(node, client, message)
+	}
+	for _, client := range s.waku.store[node] {
+		client := client
+		s.after(s.waku.untilQuery(client, s.sched.now()), message.run, func() {
+			s.send(node, client, message)
+		})
+	}
+}
+
+// untilQuery returns time left until the next store query of light client.
+func (w *waku) untilQuery(client int, now time.Time) time.Duration {
+	since := time.Duration(now.UnixNano()) % w.poll
+	wait := w.phase[client] - since
+	if wait < 0 {
+		wait += w.poll
+	}
+	return wait
+}
@@ -0,0 +1,18 @@
+package gossip
+
+import "testing"
+
+func TestParseRoleClasses(t *testing.T) {
+	classes, err := ParseRoleClasses("relay=0.6,relay+filter+store=0.1,light=0.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(classes) != 3 || !classes[2].Roles.Light() || classes[1].Share != 0.1 {
+		t.Fatalf("unexpected classes: %+v", classes)
+	}
+	for _, s := range []string{"relay=0,light=0", "relay=-1", "archive=1"} {
+		if _, err := ParseRoleClasses(s); err == nil {
+			t.Fatalf("expected error for '%s'", s)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package propagation
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kill describes node failure injected while simulation is running,
+// optionally followed by node recovery.
+type Kill struct {
+	Node    int
+	At      time.Duration // offset since simulation start
+	Recover time.Duration // downtime before node restarts, stays down if 0
+	Fetch   bool          // restarted node fetches messages it missed from peers
+}
+
+// ParseKill parses node failure in form of space- or comma-separated
+// key=value fields, i.e. "node=5 at=3s", or "node=5 at=3s recover=2s
+// fetch=true" for node restarting 2s later and fetching missed messages.
+// Failure without offset happens at the simulation start.
+func ParseKill(s string) (Kill, error) {
+	kill := Kill{Node: -1}
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' })
+	for _, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return kill, fmt.Errorf("wrong kill field '%s'", field)
+		}
+		switch kv[0] {
+		case "node":
+			node, err := strconv.Atoi(kv[1])
+			if err != nil || node < 0 {
+				return kill, fmt.Errorf("wrong kill node '%s'", kv[1])
+			}
+			kill.Node = node
+		case "at":
+			at, err := time.ParseDuration(kv[1])
+			if err != nil || at < 0 {
+				return kill, fmt.Errorf("wrong kill offset '%s'", kv[1])
+			}
+			kill.At = at
+		case "recover":
+			downtime, err := time.ParseDuration(kv[1])
+			if err != nil || downtime < 0 {
+				return kill, fmt.Errorf("wrong kill recovery delay '%s'", kv[1])
+			}
+			kill.Recover = downtime
+		case "fetch":
+			fetch, err := strconv.ParseBool(kv[1])
+			if err != nil {
+				return kill, fmt.Errorf("wrong kill fetch '%s'", kv[1])
+			}
+			kill.Fetch = fetch
+		default:
+			return kill, fmt.Errorf("unknown kill field '%s'", kv[0])
+		}
+	}
+	if kill.Node < 0 {
+		return kill, fmt.Errorf("kill '%s' has no node", s)
+	}
+	if kill.Fetch && kill.Recover == 0 {
+		return kill, fmt.Errorf("kill '%s' fetches messages, but doesn't recover", s)
+	}
+	return kill, nil
+}
+
+// String implements Stringer interface for Kill.
+func (k Kill) String() string {
+	s := fmt.Sprintf("node=%d at=%v", k.Node, k.At)
+	if k.Recover > 0 {
+		s += fmt.Sprintf(" recover=%v fetch=%v", k.Recover, k.Fetch)
+	}
+	return s
+}
+
+// ScheduleKills stops nodes at their offsets since now, while simulation
+// is running, and restarts recovering ones after their downtime, if sim
+// implements NodeRestarter. It returns function canceling kills and
+// restarts that haven't happened yet, which returns kills that did, in
+// order, with Recover reset for nodes that weren't restarted. Failing
+// kills are logged, as simulation goes on anyway.
+func ScheduleKills(sim NodeStopper, kills []Kill) (cancel func() []Kill) {
+	var (
+		mx       sync.Mutex
+		done     []Kill
+		timers   []*time.Timer
+		canceled bool
+	)
+	schedule := func(d time.Duration, fn func()) {
+		mx.Lock()
+		defer mx.Unlock()
+		if canceled {
+			return
+		}
+		timers = append(timers, time.AfterFunc(d, fn))
+	}
+	restarter, _ := sim.(NodeRestarter)
+	for _, kill := range kills {
+		kill := kill
+		schedule(kill.At, func() {
+			slog.Info("Killing node", "node", kill.Node, "at", kill.At)
+			if err := sim.StopNode(kill.Node); err != nil {
+				slog.Warn("Failed to kill node", "node", kill.Node, "err", err)
+				return
+			}
+			mx.Lock()
+			idx := len(done)
+			done = append(done, kill)
+			done[idx].Recover = 0
+			mx.Unlock()
+			if kill.Recover == 0 || restarter == nil {
+				return
+			}
+			schedule(kill.Recover, func() {
+				slog.Info("Restarting node", "node", kill.Node, "downtime", kill.Recover, "fetch", kill.Fetch)
+				if err := restarter.RestartNode(kill.Node, kill.Fetch); err != nil {
+					slog.Warn("Failed to restart node", "node", kill.Node, "err", err)
+					return
+				}
+				mx.Lock()
+				done[idx].Recover = kill.Recover
+				mx.Unlock()
+			})
+		})
+	}
+	return func() []Kill {
+		mx.Lock()
+		defer mx.Unlock()
+		canceled = true
+		for _, t := range timers {
+			t.Stop()
+		}
+		ret := append([]Kill(nil), done...)
+		sort.SliceStable(ret, func(i, j int) bool { return ret[i].At < ret[j].At })
+		return ret
+	}
+}
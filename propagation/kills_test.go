@@ -0,0 +1,33 @@
+package propagation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseKill(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected Kill
+	}{
+		{"node=5 at=3s", Kill{Node: 5, At: 3 * time.Second}},
+		{"at=100ms,node=2", Kill{Node: 2, At: 100 * time.Millisecond}},
+		{"node=0", Kill{Node: 0}},
+		{"node=1 at=1s recover=2s fetch=true", Kill{Node: 1, At: time.Second, Recover: 2 * time.Second, Fetch: true}},
+	}
+	for _, test := range tests {
+		kill, err := ParseKill(test.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kill != test.expected {
+			t.Fatalf("Expected %v for '%s', got %v", test.expected, test.s, kill)
+		}
+	}
+
+	for _, s := range []string{"", "at=1s", "node=x", "node=-1", "node=1 at=-1s", "node=1 when=1s", "node", "node=1 recover=x", "node=1 fetch=true"} {
+		if _, err := ParseKill(s); err == nil {
+			t.Fatalf("Expected error for '%s'", s)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package propagation
+
+import (
+	"sync"
+
+	"github.com/divan/graphx/graph"
+)
+
+// logEntriesChunk is the number of entries in a single LogEntries chunk.
+const logEntriesChunk = 4096
+
+// entriesChunks pools LogEntries chunks, so simulators sending many
+// messages reuse memory of previous logs.
+var entriesChunks = sync.Pool{
+	New: func() interface{} { return new([logEntriesChunk]LogEntry) },
+}
+
+// LogEntries collects log entries during simulation. Unlike slice of
+// pointers, it stores entries by value in fixed size chunks, which are
+// reused across simulations, so collecting large number of entries
+// doesn't allocate memory per entry nor copy entries as it grows.
+// Zero value is ready to use. It's not safe for concurrent use.
+type LogEntries struct {
+	chunks []*[logEntriesChunk]LogEntry
+	n      int
+}
+
+// Add appends entry to the collection.
+func (e *LogEntries) Add(entry LogEntry) {
+	chunk, i := e.n/logEntriesChunk, e.n%logEntriesChunk
+	if chunk == len(e.chunks) {
+		e.chunks = append(e.chunks, entriesChunks.Get().(*[logEntriesChunk]LogEntry))
+	}
+	e.chunks[chunk][i] = entry
+	e.n++
+}
+
+// Len returns the number of collected entries.
+func (e *LogEntries) Len() int {
+	return e.n
+}
+
+// At returns i-th collected entry.
+func (e *LogEntries) At(i int) *LogEntry {
+	return &e.chunks[i/logEntriesChunk][i%logEntriesChunk]
+}
+
+// Log converts collected entries to Log, see LogEntries2Log.
+func (e *LogEntries) Log(data *graph.Graph) *Log {
+	return entriesLog(data, e.n, e.At)
+}
+
+// Release returns memory of collected entries to the pool and resets the
+// collection. Entries returned by At must not be used after that.
+func (e *LogEntries) Release() {
+	for i, chunk := range e.chunks {
+		// drop message identifiers, so they can be garbage collected
+		used := e.n - i*logEntriesChunk
+		if used > logEntriesChunk {
+			used = logEntriesChunk
+		}
+		for j := 0; j < used; j++ {
+			chunk[j].Msg = ""
+		}
+		entriesChunks.Put(chunk)
+	}
+	e.chunks = nil
+	e.n = 0
+}
@@ -0,0 +1,102 @@
+package propagation
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/divan/graphx/graph"
+)
+
+// ringGraph returns ring network of n nodes.
+func ringGraph(n int) *graph.Graph {
+	data := graph.NewGraph()
+	for i := 0; i < n; i++ {
+		data.AddNode(&testNode{strconv.Itoa(i)})
+	}
+	for i := 0; i < n; i++ {
+		data.AddLink(strconv.Itoa(i), strconv.Itoa((i+1)%n))
+	}
+	return data
+}
+
+// ringEntry returns i-th entry of messages going around the ring.
+func ringEntry(i, n int) LogEntry {
+	return LogEntry{From: i % n, To: (i + 1) % n, Ts: int64(i / 100)}
+}
+
+func TestLogEntries(t *testing.T) {
+	n := 1000
+	data := ringGraph(n)
+	count := 3*logEntriesChunk + 10
+
+	var entries LogEntries
+	ptrs := make([]*LogEntry, 0, count)
+	for i := 0; i < count; i++ {
+		entry := ringEntry(i, n)
+		entries.Add(entry)
+		ptrs = append(ptrs, &entry)
+	}
+	if entries.Len() != count {
+		t.Fatalf("Expected %d entries, got %d", count, entries.Len())
+	}
+	if got := *entries.At(logEntriesChunk + 1); got != *ptrs[logEntriesChunk+1] {
+		t.Fatalf("Expected entry %v, got %v", *ptrs[logEntriesChunk+1], got)
+	}
+
+	plog := entries.Log(data)
+	expected := LogEntries2Log(data, ptrs)
+	if !reflect.DeepEqual(plog, expected) {
+		t.Fatal("Expected logs of entries and pointers to be equal")
+	}
+	for i := 1; i < plog.Len(); i++ {
+		if plog.Timestamps[i] <= plog.Timestamps[i-1] {
+			t.Fatalf("Expected steps sorted by timestamps, got %v", plog.Timestamps)
+		}
+	}
+
+	// appending to the step must not affect the next one
+	next := plog.Links[1][0]
+	plog.Links[0] = append(plog.Links[0], -1)
+	if plog.Links[1][0] != next {
+		t.Fatal("Expected steps not to share capacity")
+	}
+
+	entries.Release()
+	if entries.Len() != 0 {
+		t.Fatalf("Expected no entries after release, got %d", entries.Len())
+	}
+}
+
+const benchEntries = 100000
+
+// BenchmarkCollectPointers measures collecting entries with slice of
+// pointers, i.e. how simulators used to do it.
+func BenchmarkCollectPointers(b *testing.B) {
+	data := ringGraph(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var entries []*LogEntry
+		for j := 0; j < benchEntries; j++ {
+			entry := ringEntry(j, 1000)
+			entries = append(entries, &entry)
+		}
+		LogEntries2Log(data, entries)
+	}
+}
+
+// BenchmarkCollectLogEntries measures collecting entries with LogEntries.
+func BenchmarkCollectLogEntries(b *testing.B) {
+	data := ringGraph(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var entries LogEntries
+		for j := 0; j < benchEntries; j++ {
+			entries.Add(ringEntry(j, 1000))
+		}
+		entries.Log(data)
+		entries.Release()
+	}
+}
@@ -2,7 +2,8 @@ package propagation
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"sort"
 	"time"
 
 	"github.com/divan/graphx/graph"
@@ -14,6 +15,7 @@ type LogEntry struct {
 	From int
 	To   int
 	Ts   int64
+	Msg  string // message identifier, optional
 }
 
 // String implements Stringer interface for LogEntry.
@@ -34,37 +36,100 @@ func NewLogEntry(t, start time.Time, from, to int) *LogEntry {
 // LogEntries2Log converts raw slice of LogEntries to Log,
 // aggregating by timestamps and converting nodes indices to link indices.
 // We expect that timestamps already bucketed into Nms groups.
+//
+// For directed graphs with links in both directions, the link matching
+// the direction of sending is used.
+//
+// If entries belong to multiple messages (see LogEntry.Msg), resulting
+// log holds message identifiers of its links (see Log.Messages).
 func LogEntries2Log(data *graph.Graph, entries []*LogEntry) *Log {
-	tss := make(map[int64][]int)
-	tsnodes := make(map[int64][]int)
-	for _, entry := range entries {
-		idx, err := data.LinkByIndices(entry.From, entry.To)
-		if err != nil {
-			log.Println("[EE] Wrong link", entry)
-			continue
-		}
+	return entriesLog(data, len(entries), func(i int) *LogEntry { return entries[i] })
+}
 
-		// fill links map
-		if _, ok := tss[entry.Ts]; !ok {
-			tss[entry.Ts] = make([]int, 0)
+// entriesLog converts n entries returned by at into Log, see LogEntries2Log.
+// Log steps are sorted by timestamps, and links and nodes of all steps
+// share the same backing arrays, so conversion takes a few allocations
+// regardless of the number of entries.
+func entriesLog(data *graph.Graph, n int, at func(i int) *LogEntry) *Log {
+	links := make(map[[2]int]int, data.NumLinks())
+	for i, link := range data.Links() {
+		links[[2]int{link.FromIdx(), link.ToIdx()}] = i
+	}
+	linkIdx := func(from, to int) (int, bool) {
+		if idx, ok := links[[2]int{from, to}]; ok {
+			return idx, true
 		}
+		idx, ok := links[[2]int{to, from}]
+		return idx, ok
+	}
 
-		values := tss[entry.Ts]
-		values = append(values, idx)
-		tss[entry.Ts] = values
+	// count entries of each timestamp first, to allocate steps at once
+	counts := make(map[int64]int)
+	var (
+		total int
+		msg   string
+		multi bool // entries belong to multiple messages
+	)
+	for i := 0; i < n; i++ {
+		entry := at(i)
+		if _, ok := linkIdx(entry.From, entry.To); !ok {
+			slog.Warn("Wrong link", "entry", entry)
+			continue
+		}
+		counts[entry.Ts]++
+		if total == 0 {
+			msg = entry.Msg
+		} else if entry.Msg != msg {
+			multi = true
+		}
+		total++
+	}
+	tss := make([]int64, 0, len(counts))
+	for ts := range counts {
+		tss = append(tss, ts)
+	}
+	sort.Slice(tss, func(i, j int) bool { return tss[i] < tss[j] })
 
-		// fill tsnodes map
-		if _, ok := tsnodes[entry.Ts]; !ok {
-			tsnodes[entry.Ts] = make([]int, 0)
+	// offsets of each step in shared arrays
+	offsets := make(map[int64]int, len(tss))
+	var offset int
+	for _, ts := range tss {
+		offsets[ts] = offset
+		offset += counts[ts]
+	}
+	allLinks := make([]int, total)
+	allNodes := make([]int, 2*total)
+	var allMsgs []string
+	if multi {
+		allMsgs = make([]string, total)
+	}
+	fill := make(map[int64]int, len(tss)) // entries filled for each step
+	for i := 0; i < n; i++ {
+		entry := at(i)
+		idx, ok := linkIdx(entry.From, entry.To)
+		if !ok {
+			continue
+		}
+		pos := offsets[entry.Ts] + fill[entry.Ts]
+		fill[entry.Ts]++
+		allLinks[pos] = idx
+		allNodes[2*pos], allNodes[2*pos+1] = entry.From, entry.To
+		if multi {
+			allMsgs[pos] = entry.Msg
 		}
-		nnodes := tsnodes[entry.Ts]
-		nnodes = append(nnodes, entry.From, entry.To)
-		tsnodes[entry.Ts] = nnodes
 	}
 
 	plog := NewLog(len(tss))
-	for ts, links := range tss {
-		plog.AddStep(int(ts), tsnodes[ts], links)
+	for _, ts := range tss {
+		// limit capacity, so appending to the step doesn't overwrite the next one
+		start, end := offsets[ts], offsets[ts]+counts[ts]
+		links := allLinks[start:end:end]
+		nodes := allNodes[2*start : 2*end : 2*end]
+		if multi {
+			plog.addMessageStep(int(ts), nodes, links, allMsgs[start:end:end])
+			continue
+		}
+		plog.AddStep(int(ts), nodes, links)
 	}
 
 	return plog
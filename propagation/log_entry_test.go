@@ -0,0 +1,38 @@
+package propagation
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/divan/graphx/graph"
+)
+
+func TestLogEntries2LogDirected(t *testing.T) {
+	data := graph.NewGraph()
+	for _, id := range []string{"0", "1", "2"} {
+		data.AddNode(&testNode{id})
+	}
+	data.AddLink("0", "1")
+	data.AddLink("1", "0")
+	data.AddLink("1", "2")
+
+	entries := []*LogEntry{
+		{From: 1, To: 0, Ts: 10},
+		{From: 0, To: 1, Ts: 10},
+		{From: 2, To: 1, Ts: 20}, // reverse direction falls back to 1->2 link
+	}
+	plog := LogEntries2Log(data, entries)
+	sort.Sort(plog)
+
+	expected := [][]int{{1, 0}, {2}}
+	if !reflect.DeepEqual(plog.Links, expected) {
+		t.Fatalf("expected links %v, got %v", expected, plog.Links)
+	}
+}
+
+type testNode struct {
+	id string
+}
+
+func (n *testNode) ID() string { return n.id }
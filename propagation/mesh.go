@@ -0,0 +1,46 @@
+package propagation
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Mesh describes mesh maintenance during propagation, for simulators
+// pushing messages over GossipSub-style mesh. Peers pruned from the mesh
+// only get messages through gossip (IHAVE/IWANT), so the share of first
+// deliveries by gossip shows how much mesh instability slows delivery.
+type Mesh struct {
+	Grafts           int64 // peers grafted to meshes while message propagated
+	Prunes           int64 // peers pruned from meshes while message propagated
+	MeshDeliveries   int64 // first deliveries pushed by mesh peers
+	GossipDeliveries int64 // first deliveries pulled on request, missed by mesh
+}
+
+// AddDelivery accounts for the first delivery of the message to node,
+// either pushed by mesh peer or pulled with gossip. It's safe for
+// concurrent use.
+func (m *Mesh) AddDelivery(gossip bool) {
+	if gossip {
+		atomic.AddInt64(&m.GossipDeliveries, 1)
+		return
+	}
+	atomic.AddInt64(&m.MeshDeliveries, 1)
+}
+
+// Add adds mesh counters from other.
+func (m *Mesh) Add(other *Mesh) {
+	m.Grafts += other.Grafts
+	m.Prunes += other.Prunes
+	m.MeshDeliveries += other.MeshDeliveries
+	m.GossipDeliveries += other.GossipDeliveries
+}
+
+// String implements Stringer interface for Mesh.
+func (m *Mesh) String() string {
+	var share float64
+	if total := m.MeshDeliveries + m.GossipDeliveries; total > 0 {
+		share = 100 * float64(m.GossipDeliveries) / float64(total)
+	}
+	return fmt.Sprintf("grafts: %d, prunes: %d, first deliveries: %d by mesh, %d by gossip (%.1f%%)",
+		m.Grafts, m.Prunes, m.MeshDeliveries, m.GossipDeliveries, share)
+}
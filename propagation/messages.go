@@ -0,0 +1,84 @@
+package propagation
+
+import "sort"
+
+// MessageIDs returns sorted identifiers of messages in the log, if it
+// holds propagation of multiple messages (see Log.Messages).
+func (l *Log) MessageIDs() []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, msgs := range l.Messages {
+		for _, id := range msgs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Filter returns log of the message with the given id only. Traffic and
+// other counters are not split by messages, so they are not copied, while
+// metadata is kept.
+func (l *Log) Filter(id string) *Log {
+	ret := NewLog(0)
+	ret.Meta = l.Meta
+	for i, ts := range l.Timestamps {
+		msgs := l.messagesAt(i)
+		var nodes, links []int
+		for j, link := range l.Links[i] {
+			if msgs[j] != id {
+				continue
+			}
+			links = append(links, link)
+			// nodes hold from and to of each link
+			if 2*j+1 < len(l.Nodes[i]) {
+				nodes = append(nodes, l.Nodes[i][2*j], l.Nodes[i][2*j+1])
+			}
+		}
+		if len(links) > 0 {
+			ret.AddStep(ts, nodes, links)
+		}
+	}
+	return ret
+}
+
+// WithMessage returns copy of the log with all links attributed to the
+// message with the given id.
+func (l *Log) WithMessage(id string) *Log {
+	ret := *l
+	ret.Messages = make([][]string, len(l.Links))
+	for i, links := range l.Links {
+		ret.Messages[i] = make([]string, len(links))
+		for j := range links {
+			ret.Messages[i][j] = id
+		}
+	}
+	return &ret
+}
+
+// addMessageStep adds step with message ids of its links, see AddStep.
+func (l *Log) addMessageStep(ts int, nodes, links []int, msgs []string) {
+	l.initMessages()
+	l.AddStep(ts, nodes, links)
+	l.Messages = append(l.Messages, msgs)
+}
+
+// initMessages fills message ids of existing steps with empty ones,
+// if log doesn't hold them yet.
+func (l *Log) initMessages() {
+	for i := len(l.Messages); i < len(l.Links); i++ {
+		l.Messages = append(l.Messages, make([]string, len(l.Links[i])))
+	}
+}
+
+// messagesAt returns message ids of links of i-th step, which are empty
+// if log doesn't hold them.
+func (l *Log) messagesAt(i int) []string {
+	if i < len(l.Messages) && len(l.Messages[i]) == len(l.Links[i]) {
+		return l.Messages[i]
+	}
+	return make([]string, len(l.Links[i]))
+}
@@ -0,0 +1,69 @@
+package propagation
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/divan/graphx/graph"
+)
+
+func TestLogEntries2LogMessages(t *testing.T) {
+	data := graph.NewGraph()
+	for _, id := range []string{"0", "1", "2"} {
+		data.AddNode(&testNode{id})
+	}
+	data.AddLink("0", "1")
+	data.AddLink("1", "2")
+
+	single := LogEntries2Log(data, []*LogEntry{
+		{From: 0, To: 1, Ts: 10, Msg: "a"},
+		{From: 1, To: 2, Ts: 20, Msg: "a"},
+	})
+	if single.Messages != nil {
+		t.Fatalf("Expected no message ids for a single message, got %v", single.Messages)
+	}
+
+	plog := LogEntries2Log(data, []*LogEntry{
+		{From: 0, To: 1, Ts: 10, Msg: "a"},
+		{From: 2, To: 1, Ts: 10, Msg: "b"},
+		{From: 1, To: 2, Ts: 20, Msg: "a"},
+	})
+	sort.Sort(plog)
+	if ids := plog.MessageIDs(); !reflect.DeepEqual(ids, []string{"a", "b"}) {
+		t.Fatalf("Expected message ids [a b], got %v", ids)
+	}
+
+	a := plog.Filter("a")
+	if !reflect.DeepEqual(a.Timestamps, []int{10, 20}) || !reflect.DeepEqual(a.Links, [][]int{{0}, {1}}) {
+		t.Fatalf("Expected log of message a, got %v", a)
+	}
+	if !reflect.DeepEqual(a.Nodes, [][]int{{0, 1}, {1, 2}}) {
+		t.Fatalf("Expected nodes of message a, got %v", a.Nodes)
+	}
+	b := plog.Filter("b")
+	if !reflect.DeepEqual(b.Links, [][]int{{1}}) || !reflect.DeepEqual(b.Nodes, [][]int{{2, 1}}) {
+		t.Fatalf("Expected log of message b, got %v", b)
+	}
+}
+
+func TestMergeMessages(t *testing.T) {
+	a := NewLog(1)
+	a.AddStep(10, []int{0, 1}, []int{0})
+	b := NewLog(2)
+	b.AddStep(0, []int{1, 2}, []int{1})
+	b.AddStep(10, []int{2, 3}, []int{2})
+
+	plog := NewLog(0)
+	plog.Merge(a, 0)
+	plog.Merge(b.WithMessage("b"), 10)
+
+	// messages of the first log are unknown
+	expected := [][]string{{"", "b"}, {"b"}}
+	if !reflect.DeepEqual(plog.Messages, expected) {
+		t.Fatalf("Expected messages %v, got %v", expected, plog.Messages)
+	}
+	if !reflect.DeepEqual(plog.Filter("b").Links, [][]int{{1}, {2}}) {
+		t.Fatalf("Expected links of message b, got %v", plog.Filter("b").Links)
+	}
+}
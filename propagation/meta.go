@@ -0,0 +1,97 @@
+package propagation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/divan/graphx/graph"
+)
+
+// Version is the simulator version, set at build time with
+// -ldflags "-X github.com/divan/simulation/propagation.Version=v1.0.0".
+// Module version from the build info is used if it's not set.
+var Version string
+
+// Meta describes how the log was produced, so published results can be
+// traced back to the simulator build, parameters and network graph.
+type Meta struct {
+	Version       string
+	Commit        string            // git commit simulator was built from, "-dirty" suffix for modified tree
+	Params        map[string]string `json:",omitempty"` // simulation parameters, i.e. command line flags
+	GraphChecksum string            // see GraphChecksum
+}
+
+// NewMeta returns metadata of the log produced by this build of simulator
+// on the given graph.
+func NewMeta(g *graph.Graph, params map[string]string) *Meta {
+	version, commit := BuildInfo()
+	return &Meta{
+		Version:       version,
+		Commit:        commit,
+		Params:        params,
+		GraphChecksum: GraphChecksum(g),
+	}
+}
+
+// BuildInfo returns simulator version and git commit it was built from,
+// "dev" and empty commit if unknown.
+func BuildInfo() (version, commit string) {
+	version = Version
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		if version == "" {
+			version = "dev"
+		}
+		return version, ""
+	}
+	if version == "" {
+		version = info.Main.Version
+	}
+	if version == "" || version == "(devel)" {
+		version = "dev"
+	}
+	var modified bool
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			commit = s.Value
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if commit != "" && modified {
+		commit += "-dirty"
+	}
+	return version, commit
+}
+
+// GraphChecksum returns SHA-256 checksum of graph nodes and links, in
+// order, so logs can be matched to the graph they were produced on.
+func GraphChecksum(g *graph.Graph) string {
+	h := sha256.New()
+	for _, node := range g.Nodes() {
+		fmt.Fprintf(h, "%s\n", strconv.Quote(node.ID()))
+	}
+	for _, link := range g.Links() {
+		fmt.Fprintf(h, "%d %d\n", link.FromIdx(), link.ToIdx())
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// MatchesGraph reports whether log was produced on the given graph. Logs
+// without metadata are assumed to match.
+func (l *Log) MatchesGraph(g *graph.Graph) bool {
+	return l.Meta == nil || l.Meta.GraphChecksum == "" || l.Meta.GraphChecksum == GraphChecksum(g)
+}
+
+// String implements Stringer interface for Meta.
+func (m *Meta) String() string {
+	commit := m.Commit
+	if commit == "" {
+		commit = "unknown"
+	}
+	return fmt.Sprintf("version %s, commit %s, graph %s", m.Version, commit, m.GraphChecksum)
+}
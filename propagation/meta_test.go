@@ -0,0 +1,38 @@
+package propagation
+
+import (
+	"testing"
+
+	"github.com/divan/graphx/graph"
+)
+
+func TestGraphChecksum(t *testing.T) {
+	newGraph := func(links ...[2]string) *graph.Graph {
+		g := graph.NewGraph()
+		for _, id := range []string{"0", "1", "2"} {
+			g.AddNode(&testNode{id})
+		}
+		for _, l := range links {
+			g.AddLink(l[0], l[1])
+		}
+		return g
+	}
+	a := newGraph([2]string{"0", "1"}, [2]string{"1", "2"})
+	b := newGraph([2]string{"0", "1"}, [2]string{"0", "2"})
+
+	if GraphChecksum(a) != GraphChecksum(newGraph([2]string{"0", "1"}, [2]string{"1", "2"})) {
+		t.Fatal("Expected equal checksums of the same graphs")
+	}
+	if GraphChecksum(a) == GraphChecksum(b) {
+		t.Fatal("Expected different checksums of different graphs")
+	}
+
+	plog := NewLog(0)
+	if !plog.MatchesGraph(b) {
+		t.Fatal("Expected log without metadata to match any graph")
+	}
+	plog.Meta = NewMeta(a, nil)
+	if !plog.MatchesGraph(a) || plog.MatchesGraph(b) {
+		t.Fatal("Expected log to match only the graph it was produced on")
+	}
+}
@@ -0,0 +1,127 @@
+package propagation
+
+import "sync"
+
+// Middleware wraps ObserverFunc with event processing, i.e. deduplication,
+// enrichment or live metrics, so processors can be chained between
+// simulator and event consumers without modifying simulator code.
+// Middleware calls next for each event it passes further, possibly
+// modified, and drops events by not calling it. Like ObserverFunc,
+// resulting function may be called concurrently.
+type Middleware func(next ObserverFunc) ObserverFunc
+
+// Chain returns ObserverFunc passing events through middlewares, in the
+// given order, to fn. Nil fn is replaced with no-op, so middlewares can
+// be used for their side effects only.
+func Chain(fn ObserverFunc, mws ...Middleware) ObserverFunc {
+	if fn == nil {
+		fn = func(Event) {}
+	}
+	for i := len(mws) - 1; i >= 0; i-- {
+		fn = mws[i](fn)
+	}
+	return fn
+}
+
+// Tee returns middleware reporting each event to fns as well, before
+// passing it further, i.e. to publish events to sink or update metrics.
+func Tee(fns ...ObserverFunc) Middleware {
+	return func(next ObserverFunc) ObserverFunc {
+		return func(e Event) {
+			for _, fn := range fns {
+				fn(e)
+			}
+			next(e)
+		}
+	}
+}
+
+// Filter returns middleware passing only events keep returns true for.
+func Filter(keep func(Event) bool) Middleware {
+	return func(next ObserverFunc) ObserverFunc {
+		return func(e Event) {
+			if keep(e) {
+				next(e)
+			}
+		}
+	}
+}
+
+// Dedup returns middleware dropping duplicate receive events, passing
+// only the first arrival of each message to each node. Other events are
+// passed as is.
+func Dedup() Middleware {
+	type key struct {
+		msg  string
+		node int
+	}
+	var (
+		mx   sync.Mutex
+		seen = make(map[key]bool)
+	)
+	return Filter(func(e Event) bool {
+		recv, ok := Unwrap(e).(ReceiveEvent)
+		if !ok {
+			return true
+		}
+		k := key{recv.Msg, recv.To}
+		mx.Lock()
+		defer mx.Unlock()
+		if seen[k] {
+			return false
+		}
+		seen[k] = true
+		return true
+	})
+}
+
+// Enriched is event with attributes added by Enrich middleware, i.e.
+// geo info of nodes. Use Unwrap to get the original event.
+type Enriched struct {
+	Event
+	Attrs map[string]string
+}
+
+// Enrich returns middleware adding attributes returned by fn to each
+// event, merging them with attributes added before. Events fn returns
+// no attributes for are passed as is.
+func Enrich(fn func(Event) map[string]string) Middleware {
+	return func(next ObserverFunc) ObserverFunc {
+		return func(e Event) {
+			attrs := fn(Unwrap(e))
+			if len(attrs) == 0 {
+				next(e)
+				return
+			}
+			enriched := Enriched{Event: Unwrap(e), Attrs: attrs}
+			if prev, ok := e.(Enriched); ok {
+				enriched.Attrs = make(map[string]string, len(prev.Attrs)+len(attrs))
+				for k, v := range prev.Attrs {
+					enriched.Attrs[k] = v
+				}
+				for k, v := range attrs {
+					enriched.Attrs[k] = v
+				}
+			}
+			next(enriched)
+		}
+	}
+}
+
+// Unwrap returns the original event of enriched one, or event itself.
+func Unwrap(e Event) Event {
+	if enriched, ok := e.(Enriched); ok {
+		return enriched.Event
+	}
+	return e
+}
+
+// Deliveries returns ObserverFunc reporting receive events to fn as log
+// entries, so EventFunc consumers can be put at the end of the chain.
+func Deliveries(fn EventFunc) ObserverFunc {
+	return func(e Event) {
+		if recv, ok := Unwrap(e).(ReceiveEvent); ok {
+			fn(LogEntry{Ts: recv.Ts, From: recv.From, To: recv.To, Msg: recv.Msg})
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package propagation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	var (
+		counts  EventCounts
+		entries []LogEntry
+	)
+	fn := Chain(Deliveries(func(e LogEntry) { entries = append(entries, e) }),
+		Tee(counts.Observe),
+		Dedup(),
+		Filter(func(e Event) bool { return Unwrap(e).Timestamp() < 100 }),
+	)
+	fn(SendEvent{Ts: 5, From: 0, To: 1, Msg: "a"})
+	fn(ReceiveEvent{Ts: 10, From: 0, To: 1, Msg: "a"})
+	fn(ReceiveEvent{Ts: 20, From: 2, To: 1, Msg: "a"}) // duplicate
+	fn(ReceiveEvent{Ts: 20, From: 2, To: 1, Msg: "b"})
+	fn(ReceiveEvent{Ts: 150, From: 1, To: 3, Msg: "a"}) // filtered out
+
+	// Tee goes first, so it sees all events
+	if counts.Sent != 1 || counts.Received != 4 {
+		t.Fatalf("Unexpected event counts: %s", &counts)
+	}
+	want := []LogEntry{
+		{Ts: 10, From: 0, To: 1, Msg: "a"},
+		{Ts: 20, From: 2, To: 1, Msg: "b"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("Expected entries %v, got %v", want, entries)
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	var got Event
+	region := func(e Event) map[string]string {
+		if recv, ok := e.(ReceiveEvent); ok {
+			return map[string]string{"region": []string{"eu", "us"}[recv.To%2]}
+		}
+		return nil
+	}
+	hop := func(Event) map[string]string { return map[string]string{"hop": "1"} }
+	fn := Chain(func(e Event) { got = e }, Enrich(region), Enrich(hop))
+
+	fn(ReceiveEvent{Ts: 10, From: 0, To: 1})
+	want := Enriched{
+		Event: ReceiveEvent{Ts: 10, From: 0, To: 1},
+		Attrs: map[string]string{"region": "us", "hop": "1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	if got.Timestamp() != 10 {
+		t.Fatalf("Expected timestamp of the original event, got %d", got.Timestamp())
+	}
+
+	fn(NodeDownEvent{Ts: 20, Node: 1})
+	if attrs := got.(Enriched).Attrs; len(attrs) != 1 || attrs["hop"] != "1" {
+		t.Fatalf("Expected hop attribute only, got %v", attrs)
+	}
+}
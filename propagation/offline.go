@@ -0,0 +1,54 @@
+package propagation
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Offline describes delivery delays caused by nodes being offline during
+// simulation, for simulators with intermittent connectivity. Payload
+// messages to offline peers are held by sender until peer gets back online.
+type Offline struct {
+	DelayedMessages int64 // payload messages delayed by offline windows
+	DelayMs         int64 // total delay, in milliseconds
+	MaxDelayMs      int64 // maximum delay of a single message, in milliseconds
+}
+
+// AddDelay accounts for a single payload message delayed by d.
+// It's safe for concurrent use.
+func (o *Offline) AddDelay(d time.Duration) {
+	ms := int64(d / time.Millisecond)
+	atomic.AddInt64(&o.DelayedMessages, 1)
+	atomic.AddInt64(&o.DelayMs, ms)
+	for {
+		max := atomic.LoadInt64(&o.MaxDelayMs)
+		if ms <= max || atomic.CompareAndSwapInt64(&o.MaxDelayMs, max, ms) {
+			return
+		}
+	}
+}
+
+// Add adds delay counters from other.
+func (o *Offline) Add(other *Offline) {
+	o.DelayedMessages += other.DelayedMessages
+	o.DelayMs += other.DelayMs
+	if other.MaxDelayMs > o.MaxDelayMs {
+		o.MaxDelayMs = other.MaxDelayMs
+	}
+}
+
+// AvgDelay returns the average delay of delayed messages.
+func (o *Offline) AvgDelay() time.Duration {
+	if o.DelayedMessages == 0 {
+		return 0
+	}
+	return time.Duration(o.DelayMs/o.DelayedMessages) * time.Millisecond
+}
+
+// String implements Stringer interface for Offline.
+func (o *Offline) String() string {
+	return fmt.Sprintf("%d msgs delayed, avg: %v, max: %v, total: %v",
+		o.DelayedMessages, o.AvgDelay(), time.Duration(o.MaxDelayMs)*time.Millisecond,
+		time.Duration(o.DelayMs)*time.Millisecond)
+}
@@ -0,0 +1,711 @@
+// Protobuf schema for propagation log, encoded by propagation.Log.Encode
+// with "pb" format. Go code is generated with "go generate" in propagation
+// package.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: pb/log.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Log struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Steps         []*Step                `protobuf:"bytes,1,rep,name=steps,proto3" json:"steps,omitempty"`
+	Traffic       *Traffic               `protobuf:"bytes,2,opt,name=traffic,proto3" json:"traffic,omitempty"`         // optional, if tracked by simulator
+	Offline       *Offline               `protobuf:"bytes,3,opt,name=offline,proto3" json:"offline,omitempty"`         // optional, if nodes go offline
+	Reliability   *Reliability           `protobuf:"bytes,4,opt,name=reliability,proto3" json:"reliability,omitempty"` // optional, if links are lossy
+	Meta          *Meta                  `protobuf:"bytes,5,opt,name=meta,proto3" json:"meta,omitempty"`               // optional, how the log was produced
+	Mesh          *Mesh                  `protobuf:"bytes,6,opt,name=mesh,proto3" json:"mesh,omitempty"`               // optional, if messages are pushed over mesh
+	Sampling      *Sampling              `protobuf:"bytes,7,opt,name=sampling,proto3" json:"sampling,omitempty"`       // optional, if only subset of events is recorded
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Log) Reset() {
+	*x = Log{}
+	mi := &file_pb_log_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Log) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Log) ProtoMessage() {}
+
+func (x *Log) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_log_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Log.ProtoReflect.Descriptor instead.
+func (*Log) Descriptor() ([]byte, []int) {
+	return file_pb_log_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Log) GetSteps() []*Step {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+func (x *Log) GetTraffic() *Traffic {
+	if x != nil {
+		return x.Traffic
+	}
+	return nil
+}
+
+func (x *Log) GetOffline() *Offline {
+	if x != nil {
+		return x.Offline
+	}
+	return nil
+}
+
+func (x *Log) GetReliability() *Reliability {
+	if x != nil {
+		return x.Reliability
+	}
+	return nil
+}
+
+func (x *Log) GetMeta() *Meta {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+func (x *Log) GetMesh() *Mesh {
+	if x != nil {
+		return x.Mesh
+	}
+	return nil
+}
+
+func (x *Log) GetSampling() *Sampling {
+	if x != nil {
+		return x.Sampling
+	}
+	return nil
+}
+
+// Step holds nodes and links activated at the single timestamp.
+type Step struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // milliseconds starting from T0
+	Links         []int64                `protobuf:"varint,2,rep,packed,name=links,proto3" json:"links,omitempty"`  // links indices
+	Nodes         []int64                `protobuf:"varint,3,rep,packed,name=nodes,proto3" json:"nodes,omitempty"`  // nodes indices
+	Messages      []string               `protobuf:"bytes,4,rep,name=messages,proto3" json:"messages,omitempty"`    // message identifiers of links, optional
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Step) Reset() {
+	*x = Step{}
+	mi := &file_pb_log_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Step) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Step) ProtoMessage() {}
+
+func (x *Step) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_log_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Step.ProtoReflect.Descriptor instead.
+func (*Step) Descriptor() ([]byte, []int) {
+	return file_pb_log_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Step) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Step) GetLinks() []int64 {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+func (x *Step) GetNodes() []int64 {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *Step) GetMessages() []string {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+// Traffic describes amount of data sent between nodes.
+type Traffic struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	PayloadMessages int64                  `protobuf:"varint,1,opt,name=payload_messages,json=payloadMessages,proto3" json:"payload_messages,omitempty"`
+	PayloadBytes    int64                  `protobuf:"varint,2,opt,name=payload_bytes,json=payloadBytes,proto3" json:"payload_bytes,omitempty"`
+	ControlMessages int64                  `protobuf:"varint,3,opt,name=control_messages,json=controlMessages,proto3" json:"control_messages,omitempty"`
+	ControlBytes    int64                  `protobuf:"varint,4,opt,name=control_bytes,json=controlBytes,proto3" json:"control_bytes,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Traffic) Reset() {
+	*x = Traffic{}
+	mi := &file_pb_log_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Traffic) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Traffic) ProtoMessage() {}
+
+func (x *Traffic) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_log_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Traffic.ProtoReflect.Descriptor instead.
+func (*Traffic) Descriptor() ([]byte, []int) {
+	return file_pb_log_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Traffic) GetPayloadMessages() int64 {
+	if x != nil {
+		return x.PayloadMessages
+	}
+	return 0
+}
+
+func (x *Traffic) GetPayloadBytes() int64 {
+	if x != nil {
+		return x.PayloadBytes
+	}
+	return 0
+}
+
+func (x *Traffic) GetControlMessages() int64 {
+	if x != nil {
+		return x.ControlMessages
+	}
+	return 0
+}
+
+func (x *Traffic) GetControlBytes() int64 {
+	if x != nil {
+		return x.ControlBytes
+	}
+	return 0
+}
+
+// Offline describes delivery delays caused by offline nodes.
+type Offline struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	DelayedMessages int64                  `protobuf:"varint,1,opt,name=delayed_messages,json=delayedMessages,proto3" json:"delayed_messages,omitempty"`
+	DelayMs         int64                  `protobuf:"varint,2,opt,name=delay_ms,json=delayMs,proto3" json:"delay_ms,omitempty"`
+	MaxDelayMs      int64                  `protobuf:"varint,3,opt,name=max_delay_ms,json=maxDelayMs,proto3" json:"max_delay_ms,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Offline) Reset() {
+	*x = Offline{}
+	mi := &file_pb_log_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Offline) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Offline) ProtoMessage() {}
+
+func (x *Offline) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_log_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Offline.ProtoReflect.Descriptor instead.
+func (*Offline) Descriptor() ([]byte, []int) {
+	return file_pb_log_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Offline) GetDelayedMessages() int64 {
+	if x != nil {
+		return x.DelayedMessages
+	}
+	return 0
+}
+
+func (x *Offline) GetDelayMs() int64 {
+	if x != nil {
+		return x.DelayMs
+	}
+	return 0
+}
+
+func (x *Offline) GetMaxDelayMs() int64 {
+	if x != nil {
+		return x.MaxDelayMs
+	}
+	return 0
+}
+
+// Meta describes simulator build, parameters and graph the log was
+// produced with.
+type Meta struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Commit        string                 `protobuf:"bytes,2,opt,name=commit,proto3" json:"commit,omitempty"`
+	Params        map[string]string      `protobuf:"bytes,3,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	GraphChecksum string                 `protobuf:"bytes,4,opt,name=graph_checksum,json=graphChecksum,proto3" json:"graph_checksum,omitempty"` // see propagation.GraphChecksum
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Meta) Reset() {
+	*x = Meta{}
+	mi := &file_pb_log_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Meta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Meta) ProtoMessage() {}
+
+func (x *Meta) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_log_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Meta.ProtoReflect.Descriptor instead.
+func (*Meta) Descriptor() ([]byte, []int) {
+	return file_pb_log_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Meta) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *Meta) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+func (x *Meta) GetParams() map[string]string {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *Meta) GetGraphChecksum() string {
+	if x != nil {
+		return x.GraphChecksum
+	}
+	return ""
+}
+
+// Mesh describes mesh maintenance and deliveries it made.
+type Mesh struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Grafts           int64                  `protobuf:"varint,1,opt,name=grafts,proto3" json:"grafts,omitempty"`
+	Prunes           int64                  `protobuf:"varint,2,opt,name=prunes,proto3" json:"prunes,omitempty"`
+	MeshDeliveries   int64                  `protobuf:"varint,3,opt,name=mesh_deliveries,json=meshDeliveries,proto3" json:"mesh_deliveries,omitempty"`
+	GossipDeliveries int64                  `protobuf:"varint,4,opt,name=gossip_deliveries,json=gossipDeliveries,proto3" json:"gossip_deliveries,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Mesh) Reset() {
+	*x = Mesh{}
+	mi := &file_pb_log_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Mesh) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Mesh) ProtoMessage() {}
+
+func (x *Mesh) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_log_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Mesh.ProtoReflect.Descriptor instead.
+func (*Mesh) Descriptor() ([]byte, []int) {
+	return file_pb_log_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Mesh) GetGrafts() int64 {
+	if x != nil {
+		return x.Grafts
+	}
+	return 0
+}
+
+func (x *Mesh) GetPrunes() int64 {
+	if x != nil {
+		return x.Prunes
+	}
+	return 0
+}
+
+func (x *Mesh) GetMeshDeliveries() int64 {
+	if x != nil {
+		return x.MeshDeliveries
+	}
+	return 0
+}
+
+func (x *Mesh) GetGossipDeliveries() int64 {
+	if x != nil {
+		return x.GossipDeliveries
+	}
+	return 0
+}
+
+// Reliability describes message losses and retransmissions.
+type Reliability struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Lost            int64                  `protobuf:"varint,1,opt,name=lost,proto3" json:"lost,omitempty"`
+	Retransmissions int64                  `protobuf:"varint,2,opt,name=retransmissions,proto3" json:"retransmissions,omitempty"`
+	Failed          int64                  `protobuf:"varint,3,opt,name=failed,proto3" json:"failed,omitempty"`
+	DelayMs         int64                  `protobuf:"varint,4,opt,name=delay_ms,json=delayMs,proto3" json:"delay_ms,omitempty"`
+	MaxDelayMs      int64                  `protobuf:"varint,5,opt,name=max_delay_ms,json=maxDelayMs,proto3" json:"max_delay_ms,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Reliability) Reset() {
+	*x = Reliability{}
+	mi := &file_pb_log_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reliability) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reliability) ProtoMessage() {}
+
+func (x *Reliability) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_log_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reliability.ProtoReflect.Descriptor instead.
+func (*Reliability) Descriptor() ([]byte, []int) {
+	return file_pb_log_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Reliability) GetLost() int64 {
+	if x != nil {
+		return x.Lost
+	}
+	return 0
+}
+
+func (x *Reliability) GetRetransmissions() int64 {
+	if x != nil {
+		return x.Retransmissions
+	}
+	return 0
+}
+
+func (x *Reliability) GetFailed() int64 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *Reliability) GetDelayMs() int64 {
+	if x != nil {
+		return x.DelayMs
+	}
+	return 0
+}
+
+func (x *Reliability) GetMaxDelayMs() int64 {
+	if x != nil {
+		return x.MaxDelayMs
+	}
+	return 0
+}
+
+// Sampling describes subset of events recorded to the log.
+type Sampling struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rate          int64                  `protobuf:"varint,1,opt,name=rate,proto3" json:"rate,omitempty"` // 1-in-rate links recorded besides first arrivals, none if 0
+	Recorded      int64                  `protobuf:"varint,2,opt,name=recorded,proto3" json:"recorded,omitempty"`
+	Skipped       int64                  `protobuf:"varint,3,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Sampling) Reset() {
+	*x = Sampling{}
+	mi := &file_pb_log_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sampling) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sampling) ProtoMessage() {}
+
+func (x *Sampling) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_log_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sampling.ProtoReflect.Descriptor instead.
+func (*Sampling) Descriptor() ([]byte, []int) {
+	return file_pb_log_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Sampling) GetRate() int64 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+func (x *Sampling) GetRecorded() int64 {
+	if x != nil {
+		return x.Recorded
+	}
+	return 0
+}
+
+func (x *Sampling) GetSkipped() int64 {
+	if x != nil {
+		return x.Skipped
+	}
+	return 0
+}
+
+var File_pb_log_proto protoreflect.FileDescriptor
+
+const file_pb_log_proto_rawDesc = "" +
+	"\n" +
+	"\fpb/log.proto\x12\vpropagation\"\xcb\x02\n" +
+	"\x03Log\x12'\n" +
+	"\x05steps\x18\x01 \x03(\v2\x11.propagation.StepR\x05steps\x12.\n" +
+	"\atraffic\x18\x02 \x01(\v2\x14.propagation.TrafficR\atraffic\x12.\n" +
+	"\aoffline\x18\x03 \x01(\v2\x14.propagation.OfflineR\aoffline\x12:\n" +
+	"\vreliability\x18\x04 \x01(\v2\x18.propagation.ReliabilityR\vreliability\x12%\n" +
+	"\x04meta\x18\x05 \x01(\v2\x11.propagation.MetaR\x04meta\x12%\n" +
+	"\x04mesh\x18\x06 \x01(\v2\x11.propagation.MeshR\x04mesh\x121\n" +
+	"\bsampling\x18\a \x01(\v2\x15.propagation.SamplingR\bsampling\"l\n" +
+	"\x04Step\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\x12\x14\n" +
+	"\x05links\x18\x02 \x03(\x03R\x05links\x12\x14\n" +
+	"\x05nodes\x18\x03 \x03(\x03R\x05nodes\x12\x1a\n" +
+	"\bmessages\x18\x04 \x03(\tR\bmessages\"\xa9\x01\n" +
+	"\aTraffic\x12)\n" +
+	"\x10payload_messages\x18\x01 \x01(\x03R\x0fpayloadMessages\x12#\n" +
+	"\rpayload_bytes\x18\x02 \x01(\x03R\fpayloadBytes\x12)\n" +
+	"\x10control_messages\x18\x03 \x01(\x03R\x0fcontrolMessages\x12#\n" +
+	"\rcontrol_bytes\x18\x04 \x01(\x03R\fcontrolBytes\"q\n" +
+	"\aOffline\x12)\n" +
+	"\x10delayed_messages\x18\x01 \x01(\x03R\x0fdelayedMessages\x12\x19\n" +
+	"\bdelay_ms\x18\x02 \x01(\x03R\adelayMs\x12 \n" +
+	"\fmax_delay_ms\x18\x03 \x01(\x03R\n" +
+	"maxDelayMs\"\xd1\x01\n" +
+	"\x04Meta\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x16\n" +
+	"\x06commit\x18\x02 \x01(\tR\x06commit\x125\n" +
+	"\x06params\x18\x03 \x03(\v2\x1d.propagation.Meta.ParamsEntryR\x06params\x12%\n" +
+	"\x0egraph_checksum\x18\x04 \x01(\tR\rgraphChecksum\x1a9\n" +
+	"\vParamsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x8c\x01\n" +
+	"\x04Mesh\x12\x16\n" +
+	"\x06grafts\x18\x01 \x01(\x03R\x06grafts\x12\x16\n" +
+	"\x06prunes\x18\x02 \x01(\x03R\x06prunes\x12'\n" +
+	"\x0fmesh_deliveries\x18\x03 \x01(\x03R\x0emeshDeliveries\x12+\n" +
+	"\x11gossip_deliveries\x18\x04 \x01(\x03R\x10gossipDeliveries\"\xa0\x01\n" +
+	"\vReliability\x12\x12\n" +
+	"\x04lost\x18\x01 \x01(\x03R\x04lost\x12(\n" +
+	"\x0fretransmissions\x18\x02 \x01(\x03R\x0fretransmissions\x12\x16\n" +
+	"\x06failed\x18\x03 \x01(\x03R\x06failed\x12\x19\n" +
+	"\bdelay_ms\x18\x04 \x01(\x03R\adelayMs\x12 \n" +
+	"\fmax_delay_ms\x18\x05 \x01(\x03R\n" +
+	"maxDelayMs\"T\n" +
+	"\bSampling\x12\x12\n" +
+	"\x04rate\x18\x01 \x01(\x03R\x04rate\x12\x1a\n" +
+	"\brecorded\x18\x02 \x01(\x03R\brecorded\x12\x18\n" +
+	"\askipped\x18\x03 \x01(\x03R\askippedB,Z*github.com/divan/simulation/propagation/pbb\x06proto3"
+
+var (
+	file_pb_log_proto_rawDescOnce sync.Once
+	file_pb_log_proto_rawDescData []byte
+)
+
+func file_pb_log_proto_rawDescGZIP() []byte {
+	file_pb_log_proto_rawDescOnce.Do(func() {
+		file_pb_log_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pb_log_proto_rawDesc), len(file_pb_log_proto_rawDesc)))
+	})
+	return file_pb_log_proto_rawDescData
+}
+
+var file_pb_log_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_pb_log_proto_goTypes = []any{
+	(*Log)(nil),         // 0: propagation.Log
+	(*Step)(nil),        // 1: propagation.Step
+	(*Traffic)(nil),     // 2: propagation.Traffic
+	(*Offline)(nil),     // 3: propagation.Offline
+	(*Meta)(nil),        // 4: propagation.Meta
+	(*Mesh)(nil),        // 5: propagation.Mesh
+	(*Reliability)(nil), // 6: propagation.Reliability
+	(*Sampling)(nil),    // 7: propagation.Sampling
+	nil,                 // 8: propagation.Meta.ParamsEntry
+}
+var file_pb_log_proto_depIdxs = []int32{
+	1, // 0: propagation.Log.steps:type_name -> propagation.Step
+	2, // 1: propagation.Log.traffic:type_name -> propagation.Traffic
+	3, // 2: propagation.Log.offline:type_name -> propagation.Offline
+	6, // 3: propagation.Log.reliability:type_name -> propagation.Reliability
+	4, // 4: propagation.Log.meta:type_name -> propagation.Meta
+	5, // 5: propagation.Log.mesh:type_name -> propagation.Mesh
+	7, // 6: propagation.Log.sampling:type_name -> propagation.Sampling
+	8, // 7: propagation.Meta.params:type_name -> propagation.Meta.ParamsEntry
+	8, // [8:8] is the sub-list for method output_type
+	8, // [8:8] is the sub-list for method input_type
+	8, // [8:8] is the sub-list for extension type_name
+	8, // [8:8] is the sub-list for extension extendee
+	0, // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_pb_log_proto_init() }
+func file_pb_log_proto_init() {
+	if File_pb_log_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pb_log_proto_rawDesc), len(file_pb_log_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_pb_log_proto_goTypes,
+		DependencyIndexes: file_pb_log_proto_depIdxs,
+		MessageInfos:      file_pb_log_proto_msgTypes,
+	}.Build()
+	File_pb_log_proto = out.File
+	file_pb_log_proto_goTypes = nil
+	file_pb_log_proto_depIdxs = nil
+}
@@ -5,6 +5,19 @@ type Log struct {
 	Timestamps []int   // timestamps in milliseconds starting from T0
 	Links      [][]int // indices of links for each step, len should be equal to len of Timestamps
 	Nodes      [][]int // indices of nodes involved in each step, should match Timestamps
+
+	// Messages holds message identifiers of each link of each step, for
+	// logs of multiple messages propagation. It's nil for a single message.
+	Messages [][]string `json:",omitempty"`
+
+	Traffic *Traffic `json:",omitempty"` // optional, if tracked by simulator
+	Offline *Offline `json:",omitempty"` // optional, if nodes go offline
+
+	Reliability *Reliability `json:",omitempty"` // optional, if links are lossy
+	Mesh        *Mesh        `json:",omitempty"` // optional, if messages are pushed over mesh
+	Sampling    *Sampling    `json:",omitempty"` // optional, if only subset of events is recorded
+
+	Meta *Meta `json:",omitempty"` // optional, see NewMeta
 }
 
 // NewLog inits a new empty plog structure with known number of timestamps. It
@@ -36,9 +49,80 @@ func (l *Log) Swap(i, j int) {
 	l.Timestamps[i], l.Timestamps[j] = l.Timestamps[j], l.Timestamps[i]
 	l.Nodes[i], l.Nodes[j] = l.Nodes[j], l.Nodes[i]
 	l.Links[i], l.Links[j] = l.Links[j], l.Links[i]
+	if l.Messages != nil {
+		l.Messages[i], l.Messages[j] = l.Messages[j], l.Messages[i]
+	}
 }
 
 // Len implements sort.Interface.
 func (l *Log) Len() int {
 	return len(l.Timestamps)
 }
+
+// Merge adds all steps from other log, shifting their timestamps by
+// offset milliseconds. Steps with the same timestamp are combined,
+// and traffic, offline delay, reliability, mesh and sampling counters are
+// summed up.
+// Message identifiers are kept, if any of the logs holds them.
+func (l *Log) Merge(other *Log, offset int) {
+	if other.Traffic != nil {
+		if l.Traffic == nil {
+			l.Traffic = &Traffic{}
+		}
+		l.Traffic.Add(other.Traffic)
+	}
+	if other.Offline != nil {
+		if l.Offline == nil {
+			l.Offline = &Offline{}
+		}
+		l.Offline.Add(other.Offline)
+	}
+	if other.Reliability != nil {
+		if l.Reliability == nil {
+			l.Reliability = &Reliability{}
+		}
+		l.Reliability.Add(other.Reliability)
+	}
+	if other.Mesh != nil {
+		if l.Mesh == nil {
+			l.Mesh = &Mesh{}
+		}
+		l.Mesh.Add(other.Mesh)
+	}
+	if other.Sampling != nil {
+		if l.Sampling == nil {
+			l.Sampling = &Sampling{Rate: other.Sampling.Rate}
+		}
+		l.Sampling.Add(other.Sampling)
+	}
+
+	tagged := l.Messages != nil || other.Messages != nil
+	if tagged {
+		l.initMessages()
+	}
+
+	idx := make(map[int]int, len(l.Timestamps))
+	for i, ts := range l.Timestamps {
+		idx[ts] = i
+	}
+	for i, ts := range other.Timestamps {
+		ts += offset
+		if j, ok := idx[ts]; ok {
+			l.Nodes[j] = append(l.Nodes[j], other.Nodes[i]...)
+			l.Links[j] = append(l.Links[j], other.Links[i]...)
+			if tagged {
+				l.Messages[j] = append(l.Messages[j], other.messagesAt(i)...)
+			}
+			continue
+		}
+		idx[ts] = len(l.Timestamps)
+		nodes := append([]int(nil), other.Nodes[i]...)
+		links := append([]int(nil), other.Links[i]...)
+		if tagged {
+			msgs := append([]string(nil), other.messagesAt(i)...)
+			l.addMessageStep(ts, nodes, links, msgs)
+			continue
+		}
+		l.AddStep(ts, nodes, links)
+	}
+}
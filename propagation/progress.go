@@ -0,0 +1,51 @@
+package propagation
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// Simulation phases reported via ProgressFunc.
+const (
+	PhaseCreateNodes = "Creating nodes"
+	PhaseConnect     = "Connecting nodes"
+	PhaseCollect     = "Collecting events"
+)
+
+// Progress describes progress of a single simulation phase.
+type Progress struct {
+	Phase string
+	Done  int
+	Total int
+}
+
+// Percentage returns phase completion in percents.
+func (p Progress) Percentage() float64 {
+	if p.Total == 0 {
+		return 100
+	}
+	return 100.0 * float64(p.Done) / float64(p.Total)
+}
+
+// ProgressFunc is called by simulators to report progress of
+// long-running phases. It may be called concurrently.
+type ProgressFunc func(Progress)
+
+// LogProgress returns ProgressFunc that logs progress of each phase
+// every 10%.
+func LogProgress() ProgressFunc {
+	var (
+		mx   sync.Mutex
+		last = make(map[string]int) // last reported decile per phase
+	)
+	return func(p Progress) {
+		decile := int(p.Percentage() / 10)
+		mx.Lock()
+		defer mx.Unlock()
+		if prev, ok := last[p.Phase]; ok && decile <= prev {
+			return
+		}
+		last[p.Phase] = decile
+		slog.Info(p.Phase, "done", p.Done, "total", p.Total)
+	}
+}
@@ -1,7 +1,71 @@
 package propagation
 
+import (
+	"io"
+
+	"github.com/divan/graphx/graph"
+)
+
 // Simulator defines the simulators for message propagation within the graph.
 type Simulator interface {
 	SendMessage(idx, ttl, size int) *Log
 	Stop() error
 }
+
+// NodeStopper is implemented by simulators that support stopping
+// (killing) nodes while simulation is running.
+type NodeStopper interface {
+	StopNode(idx int) error
+}
+
+// NodeRestarter is implemented by simulators that support restarting
+// stopped nodes, which reconnect to their peers. With fetch, restarted
+// node requests messages it missed while it was down from its peers.
+type NodeRestarter interface {
+	RestartNode(idx int, fetch bool) error
+}
+
+// NodesDisconnector is implemented by simulators that support breaking
+// connection between two nodes while simulation is running.
+type NodesDisconnector interface {
+	DisconnectNodes(from, to int) error
+}
+
+// Pauser is implemented by simulators that can pause message propagation
+// and resume it later. Time spent in pause is not reflected in log timestamps.
+type Pauser interface {
+	Pause()
+	Resume()
+}
+
+// Checkpointer is implemented by simulators that can save their state
+// to disk and restore it later, so long experiments can survive restarts.
+type Checkpointer interface {
+	Checkpoint(w io.Writer) error
+	Restore(r io.Reader) error
+}
+
+// DirectSender is implemented by simulators that can send message to the
+// specific recipient node, i.e. encrypted to its asymmetric key, so
+// point-to-point delivery latency can be measured, not just broadcast.
+type DirectSender interface {
+	SendDirectMessage(from, to, ttl, size int) *Log
+}
+
+// TopologyUpdater is implemented by simulators that can apply changes of
+// the network graph links to the running network, connecting and
+// disconnecting nodes, without restart (see LinkChanges).
+type TopologyUpdater interface {
+	UpdateTopology(data *graph.Graph) error
+}
+
+// NetworkMutator is implemented by simulators whose network can be changed
+// after construction, between or during messages. Indices of nodes never
+// change: added nodes get the next index, and removed nodes stay in the
+// network, stopped and without links.
+type NetworkMutator interface {
+	AddNode(id string) (int, error)
+	RemoveNode(idx int) error
+	AddLink(from, to int) error
+	RemoveLink(from, to int) error
+}
@@ -0,0 +1,160 @@
+package propagation
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative pb/log.proto
+
+import (
+	"fmt"
+
+	"github.com/divan/simulation/propagation/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// marshalProto encodes log as Log message from pb/log.proto. Encoding is
+// deterministic, so equal logs produce equal output.
+func (l *Log) marshalProto() ([]byte, error) {
+	msg := &pb.Log{
+		Steps: make([]*pb.Step, 0, len(l.Timestamps)),
+	}
+	for i, ts := range l.Timestamps {
+		step := &pb.Step{
+			Timestamp: int64(ts),
+			Links:     toInt64s(l.Links[i]),
+			Nodes:     toInt64s(l.Nodes[i]),
+		}
+		if l.Messages != nil {
+			step.Messages = l.messagesAt(i)
+		}
+		msg.Steps = append(msg.Steps, step)
+	}
+	if t := l.Traffic; t != nil {
+		msg.Traffic = &pb.Traffic{
+			PayloadMessages: t.PayloadMessages,
+			PayloadBytes:    t.PayloadBytes,
+			ControlMessages: t.ControlMessages,
+			ControlBytes:    t.ControlBytes,
+		}
+	}
+	if o := l.Offline; o != nil {
+		msg.Offline = &pb.Offline{
+			DelayedMessages: o.DelayedMessages,
+			DelayMs:         o.DelayMs,
+			MaxDelayMs:      o.MaxDelayMs,
+		}
+	}
+	if r := l.Reliability; r != nil {
+		msg.Reliability = &pb.Reliability{
+			Lost:            r.Lost,
+			Retransmissions: r.Retransmissions,
+			Failed:          r.Failed,
+			DelayMs:         r.DelayMs,
+			MaxDelayMs:      r.MaxDelayMs,
+		}
+	}
+	if m := l.Mesh; m != nil {
+		msg.Mesh = &pb.Mesh{
+			Grafts:           m.Grafts,
+			Prunes:           m.Prunes,
+			MeshDeliveries:   m.MeshDeliveries,
+			GossipDeliveries: m.GossipDeliveries,
+		}
+	}
+	if s := l.Sampling; s != nil {
+		msg.Sampling = &pb.Sampling{
+			Rate:     s.Rate,
+			Recorded: s.Recorded,
+			Skipped:  s.Skipped,
+		}
+	}
+	if m := l.Meta; m != nil {
+		msg.Meta = &pb.Meta{
+			Version:       m.Version,
+			Commit:        m.Commit,
+			Params:        m.Params,
+			GraphChecksum: m.GraphChecksum,
+		}
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+}
+
+// unmarshalProto decodes Log message from pb/log.proto into l.
+func (l *Log) unmarshalProto(data []byte) error {
+	var msg pb.Log
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	for _, step := range msg.Steps {
+		ts, nodes, links := int(step.Timestamp), toInts(step.Nodes), toInts(step.Links)
+		if step.Messages == nil && l.Messages == nil {
+			l.AddStep(ts, nodes, links)
+			continue
+		}
+		if step.Messages != nil && len(step.Messages) != len(links) {
+			return fmt.Errorf("step %d: %d message ids for %d links", l.Len(), len(step.Messages), len(links))
+		}
+		l.addMessageStep(ts, nodes, links, step.Messages)
+	}
+	if t := msg.Traffic; t != nil {
+		l.Traffic = &Traffic{
+			PayloadMessages: t.PayloadMessages,
+			PayloadBytes:    t.PayloadBytes,
+			ControlMessages: t.ControlMessages,
+			ControlBytes:    t.ControlBytes,
+		}
+	}
+	if o := msg.Offline; o != nil {
+		l.Offline = &Offline{
+			DelayedMessages: o.DelayedMessages,
+			DelayMs:         o.DelayMs,
+			MaxDelayMs:      o.MaxDelayMs,
+		}
+	}
+	if r := msg.Reliability; r != nil {
+		l.Reliability = &Reliability{
+			Lost:            r.Lost,
+			Retransmissions: r.Retransmissions,
+			Failed:          r.Failed,
+			DelayMs:         r.DelayMs,
+			MaxDelayMs:      r.MaxDelayMs,
+		}
+	}
+	if m := msg.Mesh; m != nil {
+		l.Mesh = &Mesh{
+			Grafts:           m.Grafts,
+			Prunes:           m.Prunes,
+			MeshDeliveries:   m.MeshDeliveries,
+			GossipDeliveries: m.GossipDeliveries,
+		}
+	}
+	if s := msg.Sampling; s != nil {
+		l.Sampling = &Sampling{
+			Rate:     s.Rate,
+			Recorded: s.Recorded,
+			Skipped:  s.Skipped,
+		}
+	}
+	if m := msg.Meta; m != nil {
+		l.Meta = &Meta{
+			Version:       m.Version,
+			Commit:        m.Commit,
+			Params:        m.Params,
+			GraphChecksum: m.GraphChecksum,
+		}
+	}
+	return nil
+}
+
+func toInt64s(values []int) []int64 {
+	ret := make([]int64, len(values))
+	for i, v := range values {
+		ret[i] = int64(v)
+	}
+	return ret
+}
+
+func toInts(values []int64) []int {
+	ret := make([]int, len(values))
+	for i, v := range values {
+		ret[i] = int(v)
+	}
+	return ret
+}
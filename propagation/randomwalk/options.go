@@ -0,0 +1,70 @@
+package randomwalk
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// Option represents simulator option.
+type Option func(*Simulator)
+
+// Defaults for random walk dissemination.
+const (
+	DefaultWalkers = 4
+	DefaultLatency = 10 * time.Millisecond
+)
+
+// WithWalkers sets the number of message copies sprayed by the sender,
+// which is the number of walkers carrying the message eventually.
+func WithWalkers(k int) Option {
+	return func(s *Simulator) {
+		if k > 0 {
+			s.walkers = k
+		}
+	}
+}
+
+// WithLatency sets per link latency function, replacing DefaultLatency.
+func WithLatency(fn func(from, to int) time.Duration) Option {
+	return func(s *Simulator) {
+		s.latency = fn
+	}
+}
+
+// WithMaxDuration bounds propagation of each message sent with SendMessage
+// by the given duration, so walkers stop once it's reached.
+func WithMaxDuration(d time.Duration) Option {
+	return func(s *Simulator) {
+		s.maxDuration = d
+	}
+}
+
+// WithSeed seeds simulator's own random source used for walks, so runs
+// are reproducible.
+func WithSeed(seed int64) Option {
+	return func(s *Simulator) {
+		s.rand = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithProgress sets the function to report simulation progress to.
+func WithProgress(fn propagation.ProgressFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.Progress) {}
+		}
+		s.progress = fn
+	}
+}
+
+// WithEvents sets the function to report each walker hop to.
+func WithEvents(fn propagation.EventFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.LogEntry) {}
+		}
+		s.events = fn
+	}
+}
@@ -0,0 +1,143 @@
+// Package randomwalk implements random walk based dissemination simulator,
+// modeling Spray and Wait routing of delay tolerant networks. Sender holds
+// k copies of the message, carried by walkers moving to random peers hop
+// by hop. In spray phase walker carrying multiple copies hands half of
+// them to each node it reaches first, which starts walker of its own
+// (binary spray). Walker with a single copy keeps carrying it (wait phase),
+// until it runs out of hops. So the message takes time and a bounded
+// number of transmissions, trading coverage for traffic.
+//
+// Walks don't need real time, so simulation runs on virtual clock, and
+// results depend only on the seed.
+package randomwalk
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/propagation/gossip"
+)
+
+// Simulator is responsible for running random walk simulation.
+type Simulator struct {
+	data        *graph.Graph
+	peers       map[int][]int
+	walkers     int
+	latency     func(from, to int) time.Duration
+	maxDuration time.Duration // zero if walks are bounded by ttl only
+	progress    propagation.ProgressFunc
+	events      propagation.EventFunc
+
+	randMx sync.Mutex
+	rand   *rand.Rand
+}
+
+// NewSimulator initializes new simulator for the given graph data.
+func NewSimulator(data *graph.Graph, opts ...Option) *Simulator {
+	sim := &Simulator{
+		data:     data,
+		peers:    gossip.PrecalculatePeers(data),
+		walkers:  DefaultWalkers,
+		latency:  func(int, int) time.Duration { return DefaultLatency },
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		progress: func(propagation.Progress) {},
+		events:   func(propagation.LogEntry) {},
+	}
+	for _, opt := range opts {
+		opt(sim)
+	}
+	return sim
+}
+
+// Stop stops simulator. Implements propagation.Simulator.
+func (s *Simulator) Stop() error {
+	return nil
+}
+
+// SendMessage sprays copies of the message of the given size from node and
+// tracks walkers carrying them. Implements propagation.Simulator. Each
+// walker makes up to ttl hops, counting from the sender, and every hop is
+// reported to the log, so revisits show up as duplicates. It's safe to call
+// SendMessage multiple times, including concurrently.
+func (s *Simulator) SendMessage(startNodeIdx, ttl, size int) *propagation.Log {
+	s.randMx.Lock()
+	seed := s.rand.Int63()
+	s.randMx.Unlock()
+	rnd := rand.New(rand.NewSource(seed))
+	id := fmt.Sprintf("%016x", uint64(seed))
+
+	var (
+		start   = time.Now()
+		traffic propagation.Traffic
+		entries propagation.LogEntries
+		queue   = &walks{{node: startNodeIdx, copies: s.walkers, hops: ttl}}
+		reached = map[int]bool{startNodeIdx: true}
+	)
+	for queue.Len() > 0 {
+		w := heap.Pop(queue).(walker)
+		peers := s.peers[w.node]
+		if w.hops <= 0 || len(peers) == 0 {
+			continue
+		}
+		next := peers[rnd.Intn(len(peers))]
+		at := w.at + s.latency(w.node, next)
+		if s.maxDuration > 0 && at > s.maxDuration {
+			continue
+		}
+		traffic.AddPayload(size)
+		entry := propagation.NewLogEntry(start.Add(at), start, w.node, next)
+		entry.Msg = id
+		entries.Add(*entry)
+		s.events(*entry)
+
+		w.node, w.at, w.hops = next, at, w.hops-1
+		if !reached[next] {
+			reached[next] = true
+			s.progress(propagation.Progress{
+				Phase: propagation.PhaseCollect,
+				Done:  len(reached),
+				Total: s.data.NumNodes(),
+			})
+			// binary spray: leave half of copies with the new node
+			if w.copies > 1 {
+				spray := w
+				spray.copies = w.copies / 2
+				w.copies -= spray.copies
+				heap.Push(queue, spray)
+			}
+		}
+		heap.Push(queue, w)
+	}
+
+	plog := entries.Log(s.data)
+	entries.Release()
+	plog.Traffic = &traffic
+	return plog
+}
+
+// walker carries copies of the message through the network.
+type walker struct {
+	node   int
+	copies int
+	hops   int           // hops left
+	at     time.Duration // virtual time walker reached node at
+}
+
+// walks implements heap.Interface for walkers, ordered by virtual time.
+type walks []walker
+
+func (w walks) Len() int            { return len(w) }
+func (w walks) Less(i, j int) bool  { return w[i].at < w[j].at }
+func (w walks) Swap(i, j int)       { w[i], w[j] = w[j], w[i] }
+func (w *walks) Push(x interface{}) { *w = append(*w, x.(walker)) }
+func (w *walks) Pop() interface{} {
+	old := *w
+	last := old[len(old)-1]
+	*w = old[:len(old)-1]
+	return last
+}
@@ -0,0 +1,46 @@
+package randomwalk
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/internal/testgraph"
+)
+
+func TestSendMessage(t *testing.T) {
+	data := testgraph.Ring(20)
+	send := func() (int64, []int) {
+		sim := NewSimulator(data, WithWalkers(4), WithSeed(1))
+		defer sim.Stop()
+		plog := sim.SendMessage(0, 50, 100)
+		return plog.Traffic.PayloadMessages, plog.Timestamps
+	}
+
+	// sprayed walkers share hops budget of the sender
+	transmissions, timestamps := send()
+	if transmissions < 50 || transmissions > 4*50 {
+		t.Fatalf("Expected between 50 and 200 transmissions, got %d", transmissions)
+	}
+	if again, ts := send(); again != transmissions || !reflect.DeepEqual(ts, timestamps) {
+		t.Fatalf("Expected the same walks with the same seed, got %d vs %d transmissions", again, transmissions)
+	}
+}
+
+func TestMaxDuration(t *testing.T) {
+	sim := NewSimulator(testgraph.Ring(20),
+		WithLatency(func(int, int) time.Duration { return 20 * time.Millisecond }),
+		WithMaxDuration(100*time.Millisecond),
+	)
+	defer sim.Stop()
+
+	plog := sim.SendMessage(0, 50, 100)
+	for _, ts := range plog.Timestamps {
+		if ts > 100 {
+			t.Fatalf("Expected walks to stop at max duration, got hop at %dms", ts)
+		}
+	}
+	if got, want := plog.Traffic.PayloadMessages, int64(5*DefaultWalkers); got > want {
+		t.Fatalf("Expected at most %d transmissions in 5 hops, got %d", want, got)
+	}
+}
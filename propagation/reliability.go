@@ -0,0 +1,67 @@
+package propagation
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Reliability describes message losses and retransmissions during
+// simulation, for simulators with lossy links. Lost messages are either
+// dropped or retransmitted by sender until acknowledged by receiver.
+type Reliability struct {
+	Lost            int64 // transmissions lost by links, including retransmissions and ACKs
+	Retransmissions int64 // messages sent again after ACK timeout
+	Failed          int64 // messages given up on after all retries
+	DelayMs         int64 // total delay of payload messages caused by retransmissions, in milliseconds
+	MaxDelayMs      int64 // maximum delay of a single payload message, in milliseconds
+}
+
+// AddLost accounts for a single transmission lost by link.
+// It's safe for concurrent use.
+func (r *Reliability) AddLost() {
+	atomic.AddInt64(&r.Lost, 1)
+}
+
+// AddRetransmission accounts for a single message retransmission.
+// It's safe for concurrent use.
+func (r *Reliability) AddRetransmission() {
+	atomic.AddInt64(&r.Retransmissions, 1)
+}
+
+// AddFailed accounts for a single message given up on.
+// It's safe for concurrent use.
+func (r *Reliability) AddFailed() {
+	atomic.AddInt64(&r.Failed, 1)
+}
+
+// AddDelay accounts for a payload message delivered with delay d due to
+// retransmissions. It's safe for concurrent use.
+func (r *Reliability) AddDelay(d time.Duration) {
+	ms := int64(d / time.Millisecond)
+	atomic.AddInt64(&r.DelayMs, ms)
+	for {
+		max := atomic.LoadInt64(&r.MaxDelayMs)
+		if ms <= max || atomic.CompareAndSwapInt64(&r.MaxDelayMs, max, ms) {
+			return
+		}
+	}
+}
+
+// Add adds reliability counters from other.
+func (r *Reliability) Add(other *Reliability) {
+	r.Lost += other.Lost
+	r.Retransmissions += other.Retransmissions
+	r.Failed += other.Failed
+	r.DelayMs += other.DelayMs
+	if other.MaxDelayMs > r.MaxDelayMs {
+		r.MaxDelayMs = other.MaxDelayMs
+	}
+}
+
+// String implements Stringer interface for Reliability.
+func (r *Reliability) String() string {
+	return fmt.Sprintf("lost: %d, retransmissions: %d, failed: %d, added latency: %v (max %v)",
+		r.Lost, r.Retransmissions, r.Failed,
+		time.Duration(r.DelayMs)*time.Millisecond, time.Duration(r.MaxDelayMs)*time.Millisecond)
+}
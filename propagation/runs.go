@@ -0,0 +1,84 @@
+package propagation
+
+import (
+	"math/rand"
+	"runtime"
+	"sync"
+)
+
+// Run describes a single independent simulation run, see RunMany.
+type Run struct {
+	Seed int64 // seed of the run's simulator random source
+	Node int   // sender node index
+	TTL  int
+	Size int
+}
+
+// SeededRuns returns n runs of the message sent from node, with seeds
+// starting from the given one.
+func SeededRuns(n int, seed int64, node, ttl, size int) []Run {
+	runs := make([]Run, n)
+	for i := range runs {
+		runs[i] = Run{Seed: seed + int64(i), Node: node, TTL: ttl, Size: size}
+	}
+	return runs
+}
+
+// SampledRuns returns n runs with seeds starting from the given one, like
+// SeededRuns, but each sent from the start node sampled from the network
+// of the given number of nodes, so results don't depend on the choice of
+// the sender. Nodes are sampled without replacement using the first seed,
+// until all of them are used.
+func SampledRuns(n int, seed int64, nodes, ttl, size int) []Run {
+	rng := rand.New(rand.NewSource(seed))
+	var perm []int
+	runs := make([]Run, n)
+	for i := range runs {
+		if i%nodes == 0 {
+			perm = rng.Perm(nodes)
+		}
+		runs[i] = Run{Seed: seed + int64(i), Node: perm[i%nodes], TTL: ttl, Size: size}
+	}
+	return runs
+}
+
+// RunMany executes independent runs in parallel on the given number of
+// workers, or GOMAXPROCS if it's not positive. Each run gets its own
+// simulator created with newSim for the run's seed, which is stopped once
+// message propagation is over, so runs share neither random source nor
+// logs. Logs are returned in order of runs, along with the first error
+// of stopping simulators.
+func RunMany(runs []Run, workers int, newSim func(seed int64) Simulator) ([]*Log, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	logs := make([]*Log, len(runs))
+	errs := make([]error, len(runs))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				run := runs[idx]
+				sim := newSim(run.Seed)
+				logs[idx] = sim.SendMessage(run.Node, run.TTL, run.Size)
+				errs[idx] = sim.Stop()
+			}
+		}()
+	}
+	for i := range runs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return logs, err
+		}
+	}
+	return logs, nil
+}
@@ -0,0 +1,82 @@
+package propagation
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// seedSimulator reports a single step with the seed as timestamp.
+type seedSimulator struct {
+	seed    int64
+	running *int32
+	max     *int32
+}
+
+func (s *seedSimulator) SendMessage(idx, ttl, size int) *Log {
+	n := atomic.AddInt32(s.running, 1)
+	for {
+		max := atomic.LoadInt32(s.max)
+		if n <= max || atomic.CompareAndSwapInt32(s.max, max, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	atomic.AddInt32(s.running, -1)
+
+	plog := NewLog(1)
+	plog.AddStep(int(s.seed), []int{idx}, []int{idx})
+	return plog
+}
+
+func (s *seedSimulator) Stop() error {
+	if s.seed == 13 {
+		return errors.New("stop failed")
+	}
+	return nil
+}
+
+func TestRunMany(t *testing.T) {
+	var running, max int32
+	newSim := func(seed int64) Simulator {
+		return &seedSimulator{seed: seed, running: &running, max: &max}
+	}
+
+	runs := SeededRuns(8, 100, 1, 10, 400)
+	logs, err := RunMany(runs, 3, newSim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, plog := range logs {
+		if got, want := plog.Timestamps[0], 100+i; got != want {
+			t.Fatalf("Expected log of run %d to have seed %d, got %d", i, want, got)
+		}
+	}
+	if max > 3 {
+		t.Fatalf("Expected at most 3 concurrent runs, got %d", max)
+	}
+
+	if _, err := RunMany(SeededRuns(2, 12, 0, 10, 400), 0, newSim); err == nil {
+		t.Fatal("Expected stop error")
+	}
+}
+
+func TestSampledRuns(t *testing.T) {
+	runs := SampledRuns(6, 7, 4, 10, 400)
+	seen := make(map[int]bool)
+	for i, run := range runs[:4] {
+		if run.Seed != 7+int64(i) {
+			t.Fatalf("Expected run %d to have seed %d, got %d", i, 7+i, run.Seed)
+		}
+		if run.Node < 0 || run.Node >= 4 || seen[run.Node] {
+			t.Fatalf("Expected distinct start nodes of first runs, got %v", runs)
+		}
+		seen[run.Node] = true
+	}
+	for i, run := range SampledRuns(6, 7, 4, 10, 400) {
+		if run.Node != runs[i].Node {
+			t.Fatalf("Expected the same start nodes for the same seed, got %v", runs)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package propagation
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Sampling describes subset of events recorded to the log, for huge
+// simulations, i.e. whisper networks with 1M nodes, which would produce
+// unmanageable logs otherwise. First arrival of the message to each node
+// is always recorded, so coverage and time-to-node stats stay exact, but
+// other deliveries are recorded only for 1-in-Rate links, or not at all.
+// Skipped events are counted, so stats of deliveries can be estimated.
+type Sampling struct {
+	Rate     int64 // other deliveries are recorded for 1-in-Rate links, none if 0
+	Recorded int64 // events recorded to the log
+	Skipped  int64 // events not recorded
+}
+
+// ParseSampling parses sampling in form of "first" for recording first
+// arrivals only, or N for recording deliveries over 1-in-N links besides
+// them.
+func ParseSampling(s string) (*Sampling, error) {
+	if s == "first" {
+		return &Sampling{}, nil
+	}
+	rate, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || rate < 1 {
+		return nil, fmt.Errorf("wrong sampling '%s', should be 'first' or positive number", s)
+	}
+	return &Sampling{Rate: rate}, nil
+}
+
+// Add adds event counters from other.
+func (s *Sampling) Add(other *Sampling) {
+	s.Recorded += other.Recorded
+	s.Skipped += other.Skipped
+}
+
+// Scale returns the number of events each recorded one stands for, on
+// average.
+func (s *Sampling) Scale() float64 {
+	if s.Recorded == 0 {
+		return 1
+	}
+	return float64(s.Recorded+s.Skipped) / float64(s.Recorded)
+}
+
+// String implements Stringer interface for Sampling.
+func (s *Sampling) String() string {
+	mode := "first arrivals only"
+	if s.Rate > 0 {
+		mode = fmt.Sprintf("first arrivals and 1-in-%d links", s.Rate)
+	}
+	return fmt.Sprintf("%s, %d events recorded, %d skipped", mode, s.Recorded, s.Skipped)
+}
+
+// Sampler decides which events of a single message propagation are
+// recorded, counting them. It's not safe for concurrent use.
+type Sampler struct {
+	sampling Sampling
+	reached  map[int]bool
+}
+
+// NewSampler creates sampler of the message sent from start node.
+func NewSampler(params Sampling, start int) *Sampler {
+	params.Recorded, params.Skipped = 0, 0
+	return &Sampler{
+		sampling: params,
+		reached:  map[int]bool{start: true},
+	}
+}
+
+// Keep reports whether delivery of the message from node to its peer
+// should be recorded. Links are sampled by hash of their nodes, so all
+// deliveries over the sampled link, in both directions, are recorded.
+func (s *Sampler) Keep(from, to int) bool {
+	keep := !s.reached[to] || (s.sampling.Rate > 0 && linkHash(from, to)%uint64(s.sampling.Rate) == 0)
+	s.reached[to] = true
+	if keep {
+		s.sampling.Recorded++
+	} else {
+		s.sampling.Skipped++
+	}
+	return keep
+}
+
+// Sampling returns sampling of recorded events so far.
+func (s *Sampler) Sampling() *Sampling {
+	ret := s.sampling
+	return &ret
+}
+
+// linkHash returns hash of the link between nodes, regardless of direction.
+func linkHash(a, b int) uint64 {
+	if a > b {
+		a, b = b, a
+	}
+	// splitmix64 finalizer of the nodes pair
+	h := uint64(a)<<32 ^ uint64(uint32(b))
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
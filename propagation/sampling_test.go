@@ -0,0 +1,55 @@
+package propagation
+
+import "testing"
+
+func TestSampler(t *testing.T) {
+	first := NewSampler(Sampling{}, 0)
+	for _, test := range []struct {
+		from, to int
+		keep     bool
+	}{
+		{0, 1, true},
+		{0, 2, true},
+		{1, 2, false},
+		{2, 1, false},
+		{1, 0, false},
+	} {
+		if keep := first.Keep(test.from, test.to); keep != test.keep {
+			t.Fatalf("Expected %d -> %d to be kept: %v, got %v", test.from, test.to, test.keep, keep)
+		}
+	}
+	if s := first.Sampling(); s.Recorded != 2 || s.Skipped != 3 || s.Scale() != 2.5 {
+		t.Fatalf("Expected 2 events recorded and 3 skipped, got %v", s)
+	}
+
+	// links are sampled in both directions
+	all := NewSampler(Sampling{Rate: 1}, 0)
+	sampled := NewSampler(Sampling{Rate: 3}, 0)
+	for i := 1; i < 100; i++ {
+		all.Keep(0, i)
+		sampled.Keep(0, i)
+		if !all.Keep(i, 0) {
+			t.Fatalf("Expected all duplicates kept with rate 1")
+		}
+		if sampled.Keep(i, 0) != sampled.Keep(0, i) {
+			t.Fatalf("Expected link %d sampled in both directions", i)
+		}
+	}
+	if s := sampled.Sampling(); s.Skipped == 0 || s.Recorded <= 99 {
+		t.Fatalf("Expected some duplicates sampled with rate 3, got %v", s)
+	}
+}
+
+func TestParseSampling(t *testing.T) {
+	if s, err := ParseSampling("first"); err != nil || s.Rate != 0 {
+		t.Fatalf("Expected first arrivals sampling, got %v, %v", s, err)
+	}
+	if s, err := ParseSampling("10"); err != nil || s.Rate != 10 {
+		t.Fatalf("Expected 1-in-10 sampling, got %v, %v", s, err)
+	}
+	for _, s := range []string{"", "0", "-1", "all"} {
+		if _, err := ParseSampling(s); err == nil {
+			t.Fatalf("Expected error for '%s'", s)
+		}
+	}
+}
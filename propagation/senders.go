@@ -0,0 +1,78 @@
+package propagation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Send describes a single message sent in simulation with multiple
+// concurrent senders.
+type Send struct {
+	ID     string        // message identifier
+	Node   int           // sender node index
+	Offset time.Duration // delay of sending since simulation start
+}
+
+// ParseSends parses messages to send in form of comma-separated
+// node@offset pairs, i.e. "0@0s,15@100ms,42@250ms". Messages get IDs
+// "msg0", "msg1" and so on, by their order.
+func ParseSends(s string) ([]Send, error) {
+	var sends []Send
+	for i, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, "@", 2)
+		node, err := strconv.Atoi(parts[0])
+		if err != nil || node < 0 {
+			return nil, fmt.Errorf("wrong sender node '%s'", parts[0])
+		}
+		var offset time.Duration
+		if len(parts) == 2 {
+			offset, err = time.ParseDuration(parts[1])
+			if err != nil || offset < 0 {
+				return nil, fmt.Errorf("wrong sending offset '%s'", parts[1])
+			}
+		}
+		sends = append(sends, Send{ID: fmt.Sprintf("msg%d", i), Node: node, Offset: offset})
+	}
+	return sends, nil
+}
+
+// SendMessages sends distinct messages from the given nodes at their
+// offsets concurrently, and returns propagation logs keyed by message ID.
+// Timestamps of each log start at the sending of its message. Simulator
+// should support concurrent SendMessage calls.
+func SendMessages(sim Simulator, sends []Send, ttl, size int) map[string]*Log {
+	var (
+		wg   sync.WaitGroup
+		mx   sync.Mutex
+		logs = make(map[string]*Log, len(sends))
+	)
+	for _, send := range sends {
+		wg.Add(1)
+		go func(send Send) {
+			defer wg.Done()
+			time.Sleep(send.Offset)
+			plog := sim.SendMessage(send.Node, ttl, size)
+			mx.Lock()
+			logs[send.ID] = plog
+			mx.Unlock()
+		}(send)
+	}
+	wg.Wait()
+	return logs
+}
+
+// MergeSends merges logs of messages sent with SendMessages into the
+// single log, shifting timestamps of each by its offset. Links of the
+// merged log are attributed to messages by their IDs (see Log.Messages).
+func MergeSends(sends []Send, logs map[string]*Log) *Log {
+	ret := NewLog(0)
+	for _, send := range sends {
+		if plog, ok := logs[send.ID]; ok {
+			ret.Merge(plog.WithMessage(send.ID), int(send.Offset/time.Millisecond))
+		}
+	}
+	return ret
+}
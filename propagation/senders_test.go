@@ -0,0 +1,67 @@
+package propagation
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseSends(t *testing.T) {
+	sends, err := ParseSends("0,15@100ms,42@1s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Send{
+		{ID: "msg0", Node: 0},
+		{ID: "msg1", Node: 15, Offset: 100 * time.Millisecond},
+		{ID: "msg2", Node: 42, Offset: time.Second},
+	}
+	if len(sends) != len(expected) {
+		t.Fatalf("Expected %d sends, got %d", len(expected), len(sends))
+	}
+	for i := range expected {
+		if sends[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected[i], sends[i])
+		}
+	}
+
+	for _, s := range []string{"", "a@1s", "1@x", "1@-1s"} {
+		if _, err := ParseSends(s); err == nil {
+			t.Fatalf("Expected error for '%s'", s)
+		}
+	}
+}
+
+// nodeSimulator reports a single step with the sender node.
+type nodeSimulator struct {
+	mx    sync.Mutex
+	nodes []int
+}
+
+func (s *nodeSimulator) SendMessage(idx, ttl, size int) *Log {
+	s.mx.Lock()
+	s.nodes = append(s.nodes, idx)
+	s.mx.Unlock()
+	plog := NewLog(1)
+	plog.AddStep(10, []int{idx}, []int{idx})
+	return plog
+}
+
+func (s *nodeSimulator) Stop() error { return nil }
+
+func TestSendMessages(t *testing.T) {
+	sends := []Send{
+		{ID: "a", Node: 1},
+		{ID: "b", Node: 2, Offset: 20 * time.Millisecond},
+	}
+	sim := &nodeSimulator{}
+	logs := SendMessages(sim, sends, 10, 100)
+	if len(logs) != 2 || logs["a"].Nodes[0][0] != 1 || logs["b"].Nodes[0][0] != 2 {
+		t.Fatalf("Expected logs keyed by message ID, got %v", logs)
+	}
+
+	merged := MergeSends(sends, logs)
+	if len(merged.Timestamps) != 2 || merged.Timestamps[0] != 10 || merged.Timestamps[1] != 30 {
+		t.Fatalf("Expected timestamps shifted by offsets, got %v", merged.Timestamps)
+	}
+}
@@ -0,0 +1,64 @@
+package propagation
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrZeroShares is returned for shares summing up to zero, as nodes can't
+// be split between such classes.
+var ErrZeroShares = errors.New("shares sum up to zero")
+
+// CheckShares checks that shares of node classes are non-negative and sum
+// up to a positive number, so nodes can be split by them (see SplitShares).
+func CheckShares(shares []float64) error {
+	var total float64
+	for i, share := range shares {
+		if share < 0 {
+			return fmt.Errorf("negative share %v of class %d", share, i)
+		}
+		total += share
+	}
+	if total <= 0 {
+		return ErrZeroShares
+	}
+	return nil
+}
+
+// SplitShares splits nodes, taken in the given order (usually shuffled),
+// into consecutive chunks proportional to shares, and returns class index
+// of each node. Shares should pass CheckShares, otherwise they're treated
+// as equal.
+func SplitShares(order []int, shares []float64) []int {
+	class := make([]int, len(order))
+	if len(shares) == 0 {
+		return class
+	}
+	if CheckShares(shares) != nil {
+		equal := make([]float64, len(shares))
+		for i := range equal {
+			equal[i] = 1
+		}
+		shares = equal
+	}
+	var total float64
+	for _, share := range shares {
+		total += share
+	}
+
+	n := len(order)
+	var acc float64
+	var start int
+	for i, share := range shares {
+		acc += share
+		end := int(acc / total * float64(n))
+		if i == len(shares)-1 {
+			end = n
+		}
+		for _, idx := range order[start:end] {
+			class[idx] = i
+		}
+		start = end
+	}
+	return class
+}
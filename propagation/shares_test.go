@@ -0,0 +1,51 @@
+package propagation
+
+import "testing"
+
+func TestCheckShares(t *testing.T) {
+	var tests = []struct {
+		shares []float64
+		ok     bool
+	}{
+		{[]float64{0.3, 0.7}, true},
+		{[]float64{0, 1}, true},
+		{[]float64{0, 0}, false},
+		{[]float64{1, -0.5}, false},
+		{nil, false},
+	}
+	for _, test := range tests {
+		if err := CheckShares(test.shares); (err == nil) != test.ok {
+			t.Fatalf("shares %v: expected ok=%v, got error %v", test.shares, test.ok, err)
+		}
+	}
+}
+
+func TestSplitShares(t *testing.T) {
+	order := []int{9, 8, 7, 6, 5, 4, 3, 2, 1, 0}
+	var tests = []struct {
+		shares []float64
+		counts []int
+	}{
+		{[]float64{0.3, 0.7}, []int{3, 7}},
+		{[]float64{1, 1, 0}, []int{5, 5, 0}},
+		{[]float64{0, 0}, []int{5, 5}}, // treated as equal
+		{[]float64{0.001}, []int{10}},
+	}
+	for _, test := range tests {
+		class := SplitShares(order, test.shares)
+		counts := make([]int, len(test.shares))
+		for _, c := range class {
+			counts[c]++
+		}
+		for i := range counts {
+			if counts[i] != test.counts[i] {
+				t.Fatalf("shares %v: expected counts %v, got %v", test.shares, test.counts, counts)
+			}
+		}
+	}
+	// nodes are taken in order
+	class := SplitShares(order, []float64{0.2, 0.8})
+	if class[9] != 0 || class[8] != 0 || class[0] != 1 {
+		t.Fatalf("expected first nodes of order in the first class, got %v", class)
+	}
+}
@@ -0,0 +1,54 @@
+package propagation
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SpamParams defines background spam traffic generated by a subset of
+// nodes during propagation of the measured message, to quantify
+// propagation degradation under DoS conditions.
+type SpamParams struct {
+	Nodes []int   // spamming nodes
+	Rate  float64 // messages per second, per spamming node
+	Size  int     // payload size of spam messages
+	TTL   int
+}
+
+// DefaultSpamSize is the default payload size of spam messages.
+const DefaultSpamSize = 64
+
+// ParseSpam parses spam parameters in form of "fraction:rate[:size]", i.e.
+// "0.1:50" for 10% of nodes sending 50 msgs/s each. It returns fraction of
+// spamming nodes separately, as nodes should be picked by the caller.
+func ParseSpam(s string) (float64, SpamParams, error) {
+	params := SpamParams{Size: DefaultSpamSize}
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, params, fmt.Errorf("wrong spam '%s', expected fraction:rate[:size]", s)
+	}
+	fraction, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || fraction < 0 || fraction > 1 {
+		return 0, params, fmt.Errorf("wrong spam fraction '%s'", parts[0])
+	}
+	params.Rate, err = strconv.ParseFloat(parts[1], 64)
+	if err != nil || params.Rate <= 0 {
+		return 0, params, fmt.Errorf("wrong spam rate '%s'", parts[1])
+	}
+	if len(parts) == 3 {
+		params.Size, err = strconv.Atoi(parts[2])
+		if err != nil || params.Size <= 0 {
+			return 0, params, fmt.Errorf("wrong spam size '%s'", parts[2])
+		}
+	}
+	return fraction, params, nil
+}
+
+// Interval returns random interval until the next spam message, so
+// messages of each node form a Poisson process with the given rate.
+func (p SpamParams) Interval() time.Duration {
+	return time.Duration(rand.ExpFloat64() / p.Rate * float64(time.Second))
+}
@@ -0,0 +1,96 @@
+// Package statusgo runs status-go wrapped Waku and Whisper services in the
+// simulation network (see devp2p package), instead of upstream
+// go-ethereum whisper, so Status-specific behaviour, like per-peer rate
+// limits and mail servers storing envelopes for offline peers, is
+// captured in propagation.
+package statusgo
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation/devp2p"
+)
+
+// Protocol is the status-go service protocol.
+type Protocol string
+
+// Known protocols.
+const (
+	Waku    Protocol = "waku"
+	Whisper Protocol = "shh"
+)
+
+// ParseProtocol parses protocol name, "waku" or "whisper".
+func ParseProtocol(s string) (Protocol, error) {
+	switch s {
+	case "waku":
+		return Waku, nil
+	case "whisper":
+		return Whisper, nil
+	}
+	return "", fmt.Errorf("unknown status-go protocol '%s', should be waku or whisper", s)
+}
+
+// messagesCode is the code of packets carrying envelopes, the same for
+// both protocols.
+const messagesCode = 1
+
+// mailServerPassword is the password envelopes of mail servers are
+// requested with, the one Status clients use.
+const mailServerPassword = "status-offline-inbox"
+
+// Params defines status-go services of the simulation.
+type Params struct {
+	Protocol    Protocol
+	MinPoW      float64 // min PoW nodes accept, messages are sent with it, status-go default if 0
+	RateLimit   int64   // envelopes per second accepted from each peer, unlimited if 0
+	MailServers []int   // indices of nodes running mail server, storing envelopes
+	DataDir     string  // mail servers databases directory, temporary one if empty
+}
+
+// NewSimulator creates devp2p simulator running status-go service with
+// the given params on each node of the graph.
+func NewSimulator(data *graph.Graph, params Params, opts ...devp2p.Option) (*devp2p.Simulator, error) {
+	if len(params.MailServers) > 0 && params.DataDir == "" {
+		dir, err := os.MkdirTemp("", "mailservers")
+		if err != nil {
+			return nil, fmt.Errorf("create mail servers directory: %v", err)
+		}
+		params.DataDir = dir
+	}
+	mailServers := make(map[int]bool, len(params.MailServers))
+	for _, idx := range params.MailServers {
+		if idx < 0 || idx >= data.NumNodes() {
+			return nil, fmt.Errorf("mail server node %d not found", idx)
+		}
+		mailServers[idx] = true
+	}
+
+	var service devp2p.Service
+	switch params.Protocol {
+	case Waku:
+		service = wakuService(params, mailServers)
+	case Whisper:
+		service = whisperService(params, mailServers)
+	default:
+		return nil, fmt.Errorf("unknown status-go protocol '%s'", params.Protocol)
+	}
+	return devp2p.NewSimulator(data, service, opts...)
+}
+
+// randomKey returns random symmetric key messages are encrypted with.
+func randomKey() ([]byte, error) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	return key, err
+}
+
+// payload returns random payload of the given size.
+func payload(size int) ([]byte, error) {
+	data := make([]byte, size)
+	_, err := rand.Read(data)
+	return data, err
+}
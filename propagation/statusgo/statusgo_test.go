@@ -0,0 +1,58 @@
+package statusgo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/internal/testgraph"
+	"github.com/divan/simulation/propagation/devp2p"
+)
+
+func TestParseProtocol(t *testing.T) {
+	var tests = []struct {
+		name     string
+		expected Protocol
+		err      bool
+	}{
+		{"waku", Waku, false},
+		{"whisper", Whisper, false},
+		{"shh", "", true},
+	}
+	for _, test := range tests {
+		got, err := ParseProtocol(test.name)
+		if (err != nil) != test.err || got != test.expected {
+			t.Fatalf("%s: expected %q (error %v), got %q (%v)", test.name, test.expected, test.err, got, err)
+		}
+	}
+}
+
+func TestNewSimulatorErrors(t *testing.T) {
+	if _, err := NewSimulator(testgraph.Line(2), Params{Protocol: "les"}); err == nil {
+		t.Fatal("expected error for unknown protocol")
+	}
+	if _, err := NewSimulator(testgraph.Line(2), Params{Protocol: Waku, MailServers: []int{2}, DataDir: t.TempDir()}); err == nil {
+		t.Fatal("expected error for mail server node out of range")
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	for _, protocol := range []Protocol{Waku, Whisper} {
+		t.Run(string(protocol), func(t *testing.T) {
+			sim, err := NewSimulator(testgraph.Line(4), Params{Protocol: protocol}, devp2p.WithQuiescence(time.Second))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sim.Stop()
+
+			reached := map[int]bool{0: true}
+			for _, nodes := range sim.SendMessage(0, 10, 100).Nodes {
+				for _, n := range nodes {
+					reached[n] = true
+				}
+			}
+			if len(reached) != 4 {
+				t.Fatalf("expected message to reach all 4 nodes, got %v", reached)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package statusgo
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/divan/simulation/propagation/devp2p"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p/simulations"
+	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+	"github.com/status-im/status-go/mailserver"
+	"github.com/status-im/status-go/params"
+	"github.com/status-im/status-go/waku"
+	"github.com/status-im/status-go/waku/common"
+	"go.uber.org/zap"
+)
+
+// wakuService returns devp2p service running status-go Waku nodes.
+func wakuService(p Params, mailServers map[int]bool) devp2p.Service {
+	return devp2p.Service{
+		Name: string(Waku),
+		New: func(idx int, ctx *adapters.ServiceContext) (node.Service, error) {
+			cfg := waku.DefaultConfig
+			if p.MinPoW > 0 {
+				cfg.MinimumAcceptedPoW = p.MinPoW
+			}
+			w := waku.New(&cfg, zap.NewNop())
+			if p.RateLimit > 0 {
+				// peers of in-memory network share address, so they're
+				// limited by peer ID only
+				w.RegisterRateLimiter(common.NewPeerRateLimiter(&common.PeerRateLimiterConfig{
+					LimitPerSecPeerID: p.RateLimit,
+				}))
+			}
+			if mailServers[idx] {
+				server := &mailserver.WakuMailServer{}
+				err := server.Init(w, &params.WakuConfig{
+					Enabled:                 true,
+					EnableMailServer:        true,
+					DataDir:                 filepath.Join(p.DataDir, fmt.Sprintf("node%d", idx)),
+					MailServerPassword:      mailServerPassword,
+					MailServerDataRetention: 1,
+				})
+				if err != nil {
+					return nil, fmt.Errorf("init mail server of node %d: %v", idx, err)
+				}
+				w.RegisterMailServer(server)
+			}
+			return w, nil
+		},
+		Send: func(svc node.Service, ttl, size int) (string, error) {
+			w := svc.(*waku.Waku)
+			key, err := randomKey()
+			if err != nil {
+				return "", err
+			}
+			data, err := payload(size)
+			if err != nil {
+				return "", err
+			}
+			msgParams := &common.MessageParams{
+				TTL:      uint32(ttl),
+				KeySym:   key,
+				Topic:    common.BytesToTopic(key),
+				Payload:  data,
+				PoW:      w.MinPow(),
+				WorkTime: 1,
+			}
+			msg, err := common.NewSentMessage(msgParams)
+			if err != nil {
+				return "", err
+			}
+			env, err := msg.Wrap(msgParams, time.Now())
+			if err != nil {
+				return "", err
+			}
+			return env.Hash().Hex(), w.Send(env)
+		},
+		Match: func(msg string, sender node.Service, packet *simulations.Msg) bool {
+			if packet.Protocol != string(Waku) || packet.Code != messagesCode {
+				return false
+			}
+			// packets of nodes without the envelope carry only other envelopes
+			return sender != nil && sender.(*waku.Waku).GetEnvelope(gethcommon.HexToHash(msg)) != nil
+		},
+	}
+}
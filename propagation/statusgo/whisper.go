@@ -0,0 +1,88 @@
+package statusgo
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/divan/simulation/propagation/devp2p"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p/simulations"
+	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+	"github.com/status-im/status-go/mailserver"
+	"github.com/status-im/status-go/params"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// whisperService returns devp2p service running status-go Whisper fork
+// nodes.
+func whisperService(p Params, mailServers map[int]bool) devp2p.Service {
+	return devp2p.Service{
+		Name: string(Whisper),
+		New: func(idx int, ctx *adapters.ServiceContext) (node.Service, error) {
+			cfg := whisper.DefaultConfig
+			if p.MinPoW > 0 {
+				cfg.MinimumAcceptedPOW = p.MinPoW
+			}
+			w := whisper.New(&cfg)
+			if p.RateLimit > 0 {
+				// peers of in-memory network share address, so they're
+				// limited by peer ID only
+				w.SetRateLimiter(whisper.NewPeerRateLimiter(&whisper.PeerRateLimiterConfig{
+					LimitPerSecPeerID: p.RateLimit,
+				}))
+			}
+			if mailServers[idx] {
+				server := &mailserver.WhisperMailServer{}
+				err := server.Init(w, &params.WhisperConfig{
+					Enabled:                 true,
+					EnableMailServer:        true,
+					DataDir:                 filepath.Join(p.DataDir, fmt.Sprintf("node%d", idx)),
+					MailServerPassword:      mailServerPassword,
+					MailServerDataRetention: 1,
+				})
+				if err != nil {
+					return nil, fmt.Errorf("init mail server of node %d: %v", idx, err)
+				}
+				w.RegisterServer(server)
+			}
+			return w, nil
+		},
+		Send: func(svc node.Service, ttl, size int) (string, error) {
+			w := svc.(*whisper.Whisper)
+			key, err := randomKey()
+			if err != nil {
+				return "", err
+			}
+			data, err := payload(size)
+			if err != nil {
+				return "", err
+			}
+			msgParams := &whisper.MessageParams{
+				TTL:      uint32(ttl),
+				KeySym:   key,
+				Topic:    whisper.BytesToTopic(key),
+				Payload:  data,
+				PoW:      w.MinPow(),
+				WorkTime: 1,
+			}
+			msg, err := whisper.NewSentMessage(msgParams)
+			if err != nil {
+				return "", err
+			}
+			env, err := msg.Wrap(msgParams, time.Now())
+			if err != nil {
+				return "", err
+			}
+			return env.Hash().Hex(), w.Send(env)
+		},
+		Match: func(msg string, sender node.Service, packet *simulations.Msg) bool {
+			if packet.Protocol != string(Whisper) || packet.Code != messagesCode {
+				return false
+			}
+			// packets of nodes without the envelope carry only other envelopes
+			return sender != nil && sender.(*whisper.Whisper).GetEnvelope(common.HexToHash(msg)) != nil
+		},
+	}
+}
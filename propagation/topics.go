@@ -0,0 +1,116 @@
+package propagation
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/rand"
+)
+
+// Subscriptions holds topics each node is subscribed to, by node index.
+// Nodes care only about messages on their topics, but may still relay
+// other messages, depending on the protocol.
+type Subscriptions [][]string
+
+// TopicName returns the name of i-th generated topic.
+func TopicName(i int) string {
+	return fmt.Sprintf("topic%d", i)
+}
+
+// RandomSubscriptions subscribes each of nodes to each of topics
+// (see TopicName) with probability fraction.
+func RandomSubscriptions(nodes, topics int, fraction float64) Subscriptions {
+	subs := make(Subscriptions, nodes)
+	for i := range subs {
+		for j := 0; j < topics; j++ {
+			if rand.Float64() < fraction {
+				subs[i] = append(subs[i], TopicName(j))
+			}
+		}
+	}
+	return subs
+}
+
+// Subscribed reports whether node is subscribed to topic.
+func (s Subscriptions) Subscribed(node int, topic string) bool {
+	if node < 0 || node >= len(s) {
+		return false
+	}
+	for _, t := range s[node] {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribers returns indices of nodes subscribed to topic.
+func (s Subscriptions) Subscribers(topic string) []int {
+	var ret []int
+	for i := range s {
+		if s.Subscribed(i, topic) {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+// Bloom returns bloom filter of all topics node is subscribed to.
+func (s Subscriptions) Bloom(node int) Bloom {
+	var b Bloom
+	if node < 0 || node >= len(s) {
+		return b
+	}
+	for _, topic := range s[node] {
+		b.Add(TopicBloom(topic))
+	}
+	return b
+}
+
+// BloomSize is the size of topics bloom filter in bytes, as in Whisper v6.
+const BloomSize = 64
+
+// Bloom is the topics bloom filter, as nodes advertise it to their peers
+// in Whisper v6, so peers send them only matching messages.
+type Bloom [BloomSize]byte
+
+// TopicBytes returns 4 bytes topic for the topic name, as used for Whisper
+// envelopes.
+func TopicBytes(topic string) [4]byte {
+	var ret [4]byte
+	hash := sha256.Sum256([]byte(topic))
+	copy(ret[:], hash[:])
+	return ret
+}
+
+// TopicBloom returns bloom filter of a single topic, following Whisper v6
+// TopicToBloom, which sets 3 bits out of 512.
+func TopicBloom(topic string) Bloom {
+	var b Bloom
+	t := TopicBytes(topic)
+	for j := 0; j < 3; j++ {
+		index := int(t[j])
+		if t[3]&(1<<uint(j)) != 0 {
+			index += 256
+		}
+		b[BloomSize-1-index/8] |= 1 << uint(index%8)
+	}
+	return b
+}
+
+// Add adds all bits of other bloom filter to b.
+func (b *Bloom) Add(other Bloom) {
+	for i := range b {
+		b[i] |= other[i]
+	}
+}
+
+// Matches reports whether all bits of other bloom filter are set in b,
+// which may be a false positive.
+func (b Bloom) Matches(other Bloom) bool {
+	for i := range b {
+		if b[i]&other[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package propagation
+
+import "testing"
+
+func TestSubscriptions(t *testing.T) {
+	subs := Subscriptions{
+		{"a", "b"},
+		{"b"},
+		nil,
+		{"c"},
+	}
+	if got := subs.Subscribers("b"); len(got) != 2 || got[0] != 0 || got[1] != 1 {
+		t.Fatalf("Expected subscribers [0 1], got %v", got)
+	}
+	if subs.Subscribed(2, "a") || subs.Subscribed(10, "a") {
+		t.Fatalf("Expected node without subscriptions to be unsubscribed")
+	}
+
+	bloom := subs.Bloom(0)
+	for _, topic := range []string{"a", "b"} {
+		if !bloom.Matches(TopicBloom(topic)) {
+			t.Fatalf("Expected bloom of node 0 to match topic %s", topic)
+		}
+	}
+	if subs.Bloom(2).Matches(TopicBloom("a")) {
+		t.Fatalf("Expected empty bloom not to match any topic")
+	}
+}
+
+func TestTopicBloom(t *testing.T) {
+	bloom := TopicBloom("topic0")
+	var bits int
+	for _, b := range bloom {
+		for ; b > 0; b >>= 1 {
+			bits += int(b & 1)
+		}
+	}
+	if bits < 1 || bits > 3 {
+		t.Fatalf("Expected up to 3 bits set, got %d", bits)
+	}
+}
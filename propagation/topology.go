@@ -0,0 +1,106 @@
+package propagation
+
+import (
+	"fmt"
+
+	"github.com/divan/graphx/graph"
+)
+
+// LinkChanges returns links added to and removed from the network graph,
+// as pairs of nodes indices, ignoring direction and duplicates. Graphs
+// should have the same nodes, in the same order, as nodes can't be added
+// or removed this way.
+func LinkChanges(old, updated *graph.Graph) (added, removed [][2]int, err error) {
+	oldNodes, nodes := old.Nodes(), updated.Nodes()
+	if len(oldNodes) != len(nodes) {
+		return nil, nil, fmt.Errorf("graph has %d nodes, expected %d", len(nodes), len(oldNodes))
+	}
+	for i := range nodes {
+		if nodes[i].ID() != oldNodes[i].ID() {
+			return nil, nil, fmt.Errorf("node %d is '%s', expected '%s'", i, nodes[i].ID(), oldNodes[i].ID())
+		}
+	}
+
+	oldLinks, links := linkPairs(old), linkPairs(updated)
+	for _, p := range links.order {
+		if !oldLinks.set[p] {
+			added = append(added, p)
+		}
+	}
+	for _, p := range oldLinks.order {
+		if !links.set[p] {
+			removed = append(removed, p)
+		}
+	}
+	return added, removed, nil
+}
+
+// pairs holds unique links of the graph, in order of their first appearance.
+type pairs struct {
+	set   map[[2]int]bool
+	order [][2]int
+}
+
+// linkPairs returns unique links of the graph, with the lower node index first.
+func linkPairs(data *graph.Graph) pairs {
+	ret := pairs{set: make(map[[2]int]bool, data.NumLinks())}
+	for _, link := range data.Links() {
+		p := [2]int{link.FromIdx(), link.ToIdx()}
+		if p[0] > p[1] {
+			p[0], p[1] = p[1], p[0]
+		}
+		if p[0] == p[1] || ret.set[p] {
+			continue
+		}
+		ret.set[p] = true
+		ret.order = append(ret.order, p)
+	}
+	return ret
+}
+
+// graphNode is graph node added with GraphWithNode, identified by ID only.
+type graphNode string
+
+// ID implements graph.Node interface.
+func (n graphNode) ID() string { return string(n) }
+
+// GraphWithNode returns copy of the network graph with new node appended,
+// and index of the node.
+func GraphWithNode(data *graph.Graph, id string) (*graph.Graph, int, error) {
+	for _, node := range data.Nodes() {
+		if node.ID() == id {
+			return nil, 0, fmt.Errorf("node '%s' exists", id)
+		}
+	}
+	ret := CopyGraph(data, nil)
+	ret.AddNode(graphNode(id))
+	return ret, data.NumNodes(), nil
+}
+
+// GraphWithLink returns copy of the network graph with new link appended.
+func GraphWithLink(data *graph.Graph, from, to int) (*graph.Graph, error) {
+	nodes := data.Nodes()
+	if from < 0 || from >= len(nodes) || to < 0 || to >= len(nodes) || from == to {
+		return nil, fmt.Errorf("wrong link %d -> %d", from, to)
+	}
+	ret := CopyGraph(data, nil)
+	ret.AddLink(nodes[from].ID(), nodes[to].ID())
+	return ret, nil
+}
+
+// CopyGraph returns copy of the network graph, without links skip returns
+// true for, if it's not nil. Graph doesn't support removing links in
+// place, so it's the way to remove them.
+func CopyGraph(data *graph.Graph, skip func(from, to int) bool) *graph.Graph {
+	ret := graph.NewGraph()
+	for _, node := range data.Nodes() {
+		ret.AddNode(node)
+	}
+	for _, link := range data.Links() {
+		if skip != nil && skip(link.FromIdx(), link.ToIdx()) {
+			continue
+		}
+		ret.AddLink(link.From(), link.To())
+	}
+	return ret
+}
@@ -0,0 +1,61 @@
+package propagation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLinkChanges(t *testing.T) {
+	old := ringGraph(4)
+	updated := ringGraph(4)
+	updated.AddLink("2", "0")
+	updated.AddLink("0", "2") // duplicate, reversed
+
+	added, removed, err := LinkChanges(old, updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][2]int{{0, 2}}; !reflect.DeepEqual(added, want) || removed != nil {
+		t.Fatalf("Expected %v added and none removed, got %v and %v", want, added, removed)
+	}
+
+	added, removed, err = LinkChanges(updated, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][2]int{{0, 2}}; !reflect.DeepEqual(removed, want) || added != nil {
+		t.Fatalf("Expected %v removed and none added, got %v and %v", want, removed, added)
+	}
+
+	if _, _, err := LinkChanges(old, ringGraph(5)); err == nil {
+		t.Fatal("Expected error for graph with different nodes")
+	}
+}
+
+func TestGraphMutations(t *testing.T) {
+	data := ringGraph(3)
+	data, idx, err := GraphWithNode(data, "3")
+	if err != nil || idx != 3 || data.NumNodes() != 4 {
+		t.Fatalf("Expected node 3 added, got %d, %v", idx, err)
+	}
+	if _, _, err := GraphWithNode(data, "1"); err == nil {
+		t.Fatal("Expected error for existing node")
+	}
+
+	data, err = GraphWithLink(data, 3, 0)
+	if err != nil || data.NumLinks() != 4 {
+		t.Fatalf("Expected link added, got %d links, %v", data.NumLinks(), err)
+	}
+	if _, err := GraphWithLink(data, 3, 4); err == nil {
+		t.Fatal("Expected error for unknown node")
+	}
+
+	// remove all links of node 0
+	data = CopyGraph(data, func(from, to int) bool { return from == 0 || to == 0 })
+	if data.NumNodes() != 4 || data.NumLinks() != 1 {
+		t.Fatalf("Expected 4 nodes and 1 link, got %d and %d", data.NumNodes(), data.NumLinks())
+	}
+	if link := data.Links()[0]; link.FromIdx() != 1 || link.ToIdx() != 2 {
+		t.Fatalf("Expected link 1 -> 2 left, got %d -> %d", link.FromIdx(), link.ToIdx())
+	}
+}
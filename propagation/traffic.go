@@ -0,0 +1,49 @@
+package propagation
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Traffic describes amount of data sent between nodes during simulation,
+// for simulators that track it. Control messages are protocol messages that
+// don't carry payload, i.e. message IDs announcements in lazy push gossip.
+type Traffic struct {
+	PayloadMessages int64
+	PayloadBytes    int64
+	ControlMessages int64
+	ControlBytes    int64
+}
+
+// AddPayload accounts for a single payload message of the given size.
+// It's safe for concurrent use.
+func (t *Traffic) AddPayload(size int) {
+	atomic.AddInt64(&t.PayloadMessages, 1)
+	atomic.AddInt64(&t.PayloadBytes, int64(size))
+}
+
+// AddControl accounts for a single control message of the given size.
+// It's safe for concurrent use.
+func (t *Traffic) AddControl(size int) {
+	atomic.AddInt64(&t.ControlMessages, 1)
+	atomic.AddInt64(&t.ControlBytes, int64(size))
+}
+
+// Add adds traffic counters from other.
+func (t *Traffic) Add(other *Traffic) {
+	t.PayloadMessages += other.PayloadMessages
+	t.PayloadBytes += other.PayloadBytes
+	t.ControlMessages += other.ControlMessages
+	t.ControlBytes += other.ControlBytes
+}
+
+// TotalBytes returns the number of bytes sent, including control messages.
+func (t *Traffic) TotalBytes() int64 {
+	return t.PayloadBytes + t.ControlBytes
+}
+
+// String implements Stringer interface for Traffic.
+func (t *Traffic) String() string {
+	return fmt.Sprintf("payload: %d msgs (%d bytes), control: %d msgs (%d bytes), total: %d bytes",
+		t.PayloadMessages, t.PayloadBytes, t.ControlMessages, t.ControlBytes, t.TotalBytes())
+}
@@ -0,0 +1,60 @@
+package whisperv6
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MessageCode identifies devp2p packets of the protocol by message code.
+type MessageCode struct {
+	Protocol string
+	Code     uint64
+}
+
+// envelopesCode identifies whisper packets carrying envelopes.
+var envelopesCode = MessageCode{Protocol: "shh", Code: messagesCode}
+
+// String implements Stringer interface for MessageCode.
+func (c MessageCode) String() string {
+	return fmt.Sprintf("%s/%d", c.Protocol, c.Code)
+}
+
+// DefaultMessageCodes returns codes tracked by default, which are whisper
+// packets carrying envelopes only.
+func DefaultMessageCodes() []MessageCode {
+	return []MessageCode{envelopesCode}
+}
+
+// ParseMessageCodes parses comma-separated protocol/code items, i.e.
+// "shh/1,les/2".
+func ParseMessageCodes(s string) ([]MessageCode, error) {
+	var codes []MessageCode
+	for _, item := range strings.Split(s, ",") {
+		parts := strings.SplitN(item, "/", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("wrong message code '%s', should be protocol/code", item)
+		}
+		code, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("wrong message code '%s': %v", item, err)
+		}
+		codes = append(codes, MessageCode{Protocol: parts[0], Code: code})
+	}
+	return codes, nil
+}
+
+// WithMessageCodes sets devp2p packets tracked during simulation, instead
+// of DefaultMessageCodes, so other services running under the same
+// harness, i.e. les, eth or custom protocols, can be tracked. Log holds
+// deliveries of the whisper envelope only, if its packets are tracked,
+// while packets of other codes are reported to events and observer as
+// they're sent, with message identifier set to the code, i.e. "les/2".
+func WithMessageCodes(codes ...MessageCode) Option {
+	return func(s *Simulator) {
+		s.codes = make(map[MessageCode]bool, len(codes))
+		for _, code := range codes {
+			s.codes[code] = true
+		}
+	}
+}
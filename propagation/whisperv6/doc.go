@@ -5,8 +5,8 @@
 //
 // Quick link of whisperv6 implementation: https://github.com/ethereum/go-ethereum/tree/master/whisper/whisperv6
 //
-// This simulator utilizes geth's simulations (https://github.com/ethereum/go-ethereum/tree/master/p2p/simulations) package and starts in-memory whisper services with a given
-// network topology.
+// This simulator runs whisper as a service of propagation/devp2p simulator, which utilizes geth's simulations (https://github.com/ethereum/go-ethereum/tree/master/p2p/simulations)
+// package and starts in-memory whisper services with a given network topology.
 package whisperv6
 
 //go:generate autoreadme -f
@@ -0,0 +1,20 @@
+package whisperv6
+
+import (
+	"time"
+
+	"github.com/divan/simulation/propagation"
+	"github.com/ethereum/go-ethereum/p2p/simulations"
+)
+
+// typedEvent converts event of the simulation network into typed one,
+// timestamped relative to start, or returns nil for events which are not
+// tracked. Only packets of tracked codes sent are converted into
+// SendEvents (see devp2p.Simulator.TypedEvent), and it's up to the caller
+// to check whether whisper ones carry the envelope.
+func (s *Simulator) typedEvent(event *simulations.Event, start time.Time) propagation.Event {
+	if msg := event.Msg; event.Type == simulations.EventTypeMsg && !s.codes[MessageCode{Protocol: msg.Protocol, Code: msg.Code}] {
+		return nil
+	}
+	return s.TypedEvent(event, start)
+}
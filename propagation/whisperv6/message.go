@@ -3,6 +3,7 @@ package whisperv6
 import (
 	"math/rand"
 
+	"github.com/divan/simulation/propagation"
 	"github.com/ethereum/go-ethereum/whisper/whisperv6"
 )
 
@@ -11,12 +12,33 @@ const (
 	aesKeyLength = 32
 )
 
-func generateMessage(ttl int, symkeyID string, size int) *whisperv6.NewMessage {
-	// set all the parameters except p.Dst and p.Padding
+// MessageParams defines parameters of generated whisper messages, so the
+// cost of PoW computation and envelope size overhead can be varied.
+type MessageParams struct {
+	PowTarget float64 // min PoW of envelopes, nodes drop ones below their min PoW
+	PowTime   uint32  // max seconds spent on PoW computation
+	Topic     string  // topic name, see propagation.TopicBytes; random if empty
+	Padding   int     // padding bytes, whisper pads to 256 bytes blocks if 0
+}
+
+// DefaultMessageParams returns default parameters of generated messages.
+func DefaultMessageParams() MessageParams {
+	return MessageParams{
+		PowTarget: 0.01,
+		PowTime:   1,
+	}
+}
+
+func generateMessage(ttl int, symkeyID string, size int, params MessageParams) *whisperv6.NewMessage {
+	// set all the parameters except p.Dst
 	buf := make([]byte, 4)
 	rand.Read(buf)
+	topic := whisperv6.BytesToTopic(buf)
+	if params.Topic != "" {
+		topic = whisperv6.TopicType(propagation.TopicBytes(params.Topic))
+	}
 
-	var sz uint32
+	sz := uint32(size)
 	if size == 0 {
 		sz = whisperv6.DefaultMaxMessageSize
 	} else if uint32(size) > whisperv6.MaxMessageSize {
@@ -24,14 +46,29 @@ func generateMessage(ttl int, symkeyID string, size int) *whisperv6.NewMessage {
 	}
 
 	msg := &whisperv6.NewMessage{
-		PowTarget: 0.01,
-		PowTime:   1,
+		PowTarget: params.PowTarget,
+		PowTime:   params.PowTime,
 		Payload:   make([]byte, sz),
 		SymKeyID:  symkeyID,
-		Topic:     whisperv6.BytesToTopic(buf),
+		Topic:     topic,
 		TTL:       uint32(ttl),
 	}
 	rand.Read(msg.Payload)
+	if params.Padding > 0 {
+		msg.Padding = make([]byte, params.Padding)
+		rand.Read(msg.Padding)
+	}
+
+	return msg
+}
+
+// spamPowTarget is the PoW target of spam envelopes, as low as nodes accept
+// (see newWhisper), so spamming is cheap.
+const spamPowTarget = 0.001
 
+// generateSpamMessage generates low-PoW message used as background spam.
+func generateSpamMessage(ttl int, symkeyID string, size int) *whisperv6.NewMessage {
+	msg := generateMessage(ttl, symkeyID, size, DefaultMessageParams())
+	msg.PowTarget = spamPowTarget
 	return msg
 }
@@ -0,0 +1,121 @@
+package whisperv6
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/divan/simulation/propagation"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv6"
+)
+
+// DefaultNodeConfig returns whisper config used by nodes by default.
+func DefaultNodeConfig() whisper.Config {
+	return whisper.Config{
+		MaxMessageSize:     whisper.DefaultMaxMessageSize,
+		MinimumAcceptedPOW: 0.001,
+	}
+}
+
+// NodeClass represents share of nodes with the given whisper config.
+type NodeClass struct {
+	Config whisper.Config
+	Share  float64 // fraction of nodes, shares are normalized
+}
+
+// ParseNodeClasses parses classes mix in form of comma-separated
+// pow[/size]=share items, where pow is the minimum accepted PoW and size
+// is the max message size in bytes (default if omitted), i.e.
+// "0.001=0.7,0.2/65536=0.3".
+func ParseNodeClasses(s string) ([]NodeClass, error) {
+	var (
+		classes []NodeClass
+		shares  []float64
+	)
+	for _, item := range strings.Split(s, ",") {
+		kv := strings.SplitN(item, "=", 2)
+		cfg := DefaultNodeConfig()
+		parts := strings.SplitN(kv[0], "/", 2)
+		pow, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil || pow < 0 {
+			return nil, fmt.Errorf("wrong min PoW of node class '%s'", item)
+		}
+		cfg.MinimumAcceptedPOW = pow
+		if len(parts) == 2 {
+			size, err := strconv.ParseUint(parts[1], 10, 32)
+			if err != nil || size == 0 {
+				return nil, fmt.Errorf("wrong max message size of node class '%s'", item)
+			}
+			cfg.MaxMessageSize = uint32(size)
+		}
+		share := 1.0
+		if len(kv) == 2 {
+			share, err = strconv.ParseFloat(kv[1], 64)
+			if err != nil || share < 0 {
+				return nil, fmt.Errorf("wrong share of node class '%s'", item)
+			}
+		}
+		classes = append(classes, NodeClass{Config: cfg, Share: share})
+		shares = append(shares, share)
+	}
+	if err := propagation.CheckShares(shares); err != nil {
+		return nil, fmt.Errorf("wrong node classes '%s': %v", s, err)
+	}
+	return classes, nil
+}
+
+// WithNodeConfigs sets whisper config of each node, instead of the default
+// one. Nodes refuse envelopes below their min PoW or above their max
+// message size, so such envelopes are not relayed further.
+func WithNodeConfigs(fn func(node int) whisper.Config) Option {
+	return func(s *Simulator) {
+		s.configs = make([]whisper.Config, s.nodes)
+		for i := range s.configs {
+			s.configs[i] = fn(i)
+		}
+	}
+}
+
+// WithNodeClasses randomly assigns whisper configs of the classes to
+// nodes, proportionally to their shares (see WithNodeConfigs).
+func WithNodeClasses(classes ...NodeClass) Option {
+	return func(s *Simulator) {
+		if len(classes) == 0 {
+			return
+		}
+		shares := make([]float64, len(classes))
+		for i, c := range classes {
+			shares[i] = c.Share
+		}
+		n := s.nodes
+		class := propagation.SplitShares(rand.Perm(n), shares)
+		s.configs = make([]whisper.Config, n)
+		for idx, i := range class {
+			s.configs[idx] = classes[i].Config
+		}
+	}
+}
+
+// whisperConfig returns whisper config of the node.
+func (s *Simulator) whisperConfig(idx int) whisper.Config {
+	if s.configs == nil {
+		return DefaultNodeConfig()
+	}
+	return s.configs[idx]
+}
+
+// refuses reports whether node refuses envelope due to its whisper config.
+func (s *Simulator) refuses(node int, env *whisper.Envelope) bool {
+	if s.configs == nil {
+		return false
+	}
+	cfg := s.configs[node]
+	return env.PoW() < cfg.MinimumAcceptedPOW || envelopeSize(env) > cfg.MaxMessageSize
+}
+
+// envelopeSize returns size of the envelope the way whisper accounts for
+// it, which isn't exported by whisper package.
+func envelopeSize(env *whisper.Envelope) uint32 {
+	return uint32(whisper.EnvelopeHeaderLength + len(env.Data))
+}
@@ -0,0 +1,130 @@
+package whisperv6
+
+import (
+	"time"
+
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/propagation/devp2p"
+)
+
+// DefaultConnectWorkers is the default number of workers establishing
+// connections between nodes in parallel.
+const DefaultConnectWorkers = devp2p.DefaultConnectWorkers
+
+// Option represents simulator option.
+type Option func(*Simulator)
+
+// WithConnectWorkers sets the number of workers establishing connections
+// between nodes in parallel during network setup.
+func WithConnectWorkers(n int) Option {
+	return func(s *Simulator) {
+		if n > 0 {
+			s.connectWorkers = n
+		}
+	}
+}
+
+// WithProgress sets the function to report setup and simulation progress to.
+// Passing nil disables progress reporting.
+func WithProgress(fn propagation.ProgressFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.Progress) {}
+		}
+		s.progress = fn
+	}
+}
+
+// WithEvents sets the function to report each message sending to,
+// as it happens during simulation.
+func WithEvents(fn propagation.EventFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.LogEntry) {}
+		}
+		s.events = fn
+	}
+}
+
+// WithObserver sets the function to report typed events to, as they
+// happen during message propagation: whisper packets carrying the
+// envelope sent, accepted and dropped by receivers, with the reason, and
+// connections and nodes going up and down.
+func WithObserver(fn propagation.ObserverFunc) Option {
+	return func(s *Simulator) {
+		if fn == nil {
+			fn = func(propagation.Event) {}
+		}
+		s.observer = fn
+	}
+}
+
+// WithTopics makes each node advertise bloom filter of the topics it's
+// subscribed to, instead of the full bloom filter, and publishes messages
+// sent with SendMessage on topic. Peers send node only envelopes matching
+// its bloom filter, so nodes relay only messages on their topics (and
+// false positive ones).
+func WithTopics(subs propagation.Subscriptions, topic string) Option {
+	return func(s *Simulator) {
+		s.subscriptions = subs
+		s.topic = topic
+	}
+}
+
+// WithMessageParams sets parameters of messages sent with SendMessage and
+// SendDirectMessage. Topic set by WithTopics takes precedence.
+func WithMessageParams(params MessageParams) Option {
+	return func(s *Simulator) {
+		s.message = params
+	}
+}
+
+// WithStopOnCoverage makes SendMessage stop collecting events once the
+// given fraction of nodes (0..1) has received the message, instead of
+// waiting for message TTL to expire. Packets sent after that, mostly
+// duplicates, are not reported to the log.
+func WithStopOnCoverage(fraction float64) Option {
+	return func(s *Simulator) {
+		s.stopCoverage = fraction
+	}
+}
+
+// WithQuiescence makes SendMessage stop collecting events after the given
+// period with no message packets, instead of waiting for message TTL to
+// expire. Period should exceed the expected delay of a single hop.
+func WithQuiescence(d time.Duration) Option {
+	return func(s *Simulator) {
+		s.quiescence = d
+	}
+}
+
+// WithMaxDuration bounds the time SendMessage collects events, if it's
+// shorter than message TTL.
+func WithMaxDuration(d time.Duration) Option {
+	return func(s *Simulator) {
+		s.maxDuration = d
+	}
+}
+
+// WithSpam makes spamming nodes post real low-PoW envelopes while the
+// measured message propagates (see SendMessage). As whisper batches
+// envelopes, propagation log counts only packets sent by nodes that
+// already have the measured envelope.
+func WithSpam(params propagation.SpamParams) Option {
+	return func(s *Simulator) {
+		if params.TTL == 0 {
+			params.TTL = 10
+		}
+		s.spam = &params
+	}
+}
+
+// WithSampling makes SendMessage record only a subset of events to the
+// log, for huge networks: first arrival of the message to each node, and
+// other deliveries over sampled links only (see propagation.Sampling).
+// Events reported with WithEvents are sampled as well.
+func WithSampling(params propagation.Sampling) Option {
+	return func(s *Simulator) {
+		s.sampling = &params
+	}
+}
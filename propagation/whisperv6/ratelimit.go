@@ -0,0 +1,201 @@
+package whisperv6
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv6"
+)
+
+// RateLimitParams defines per-peer rate limiting, modeled after the peer
+// rate limiter of Status whisper fork, which geth whisper lacks. Each node
+// accepts up to Limit envelopes per second from each peer and drops the
+// rest, and peer exceeding the limit more than Tolerance times is
+// disconnected as a DoS source.
+type RateLimitParams struct {
+	Limit     int64 // envelopes per second accepted from each peer
+	Tolerance int64 // times peer may exceed the limit before it's dropped, never dropped if 0
+}
+
+// ParseRateLimit parses rate limit in form of limit[:tolerance], i.e.
+// "20:5" for 20 envelopes per second, disconnecting peers exceeding it
+// more than 5 times.
+func ParseRateLimit(s string) (RateLimitParams, error) {
+	var params RateLimitParams
+	parts := strings.SplitN(s, ":", 2)
+	limit, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || limit <= 0 {
+		return params, fmt.Errorf("wrong rate limit '%s'", s)
+	}
+	params.Limit = limit
+	if len(parts) == 2 {
+		tolerance, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || tolerance < 0 {
+			return params, fmt.Errorf("wrong rate limit tolerance '%s'", s)
+		}
+		params.Tolerance = tolerance
+	}
+	return params, nil
+}
+
+// WithRateLimit enables per-peer rate limiting with the given parameters
+// (see RateLimitParams). Together with WithSpam it shows how legitimate
+// messages suffer once limits kick in: envelopes dropped by the limiter
+// are not relayed, and peers dropped as spammers stop relaying anything.
+func WithRateLimit(params RateLimitParams) Option {
+	return func(s *Simulator) {
+		s.limiter = &rateLimiter{
+			params:  params,
+			buckets: make(map[link]*bucket),
+			dropped: make(map[link]map[common.Hash]bool),
+		}
+	}
+}
+
+// link identifies direction between two nodes.
+type link struct {
+	from, to int
+}
+
+// bucket counts envelopes node accepted from peer in the current second.
+type bucket struct {
+	start    time.Time
+	count    int64
+	exceeded int64 // times peer exceeded the limit
+}
+
+// rateLimiter keeps rate limiting state of all nodes. Limits are enforced
+// as envelopes are written to the peer, so the limiter's decisions are
+// known by the time send events are reported (see dropped).
+type rateLimiter struct {
+	params RateLimitParams
+
+	mx      sync.Mutex
+	buckets map[link]*bucket              // sender -> receiver
+	dropped map[link]map[common.Hash]bool // envelopes dropped by receiver, by link
+	limited int64                         // total envelopes dropped, accessed atomically
+	peers   int64                         // total peers disconnected, accessed atomically
+}
+
+// accept returns envelopes receiver accepts from sender out of envelopes,
+// and reports whether sender should be disconnected.
+func (l *rateLimiter) accept(from, to int, envelopes []*whisper.Envelope, now time.Time) ([]*whisper.Envelope, bool) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	key := link{from: from, to: to}
+	b, ok := l.buckets[key]
+	if !ok || now.Sub(b.start) >= time.Second {
+		if !ok {
+			b = &bucket{}
+			l.buckets[key] = b
+		}
+		b.start, b.count = now, 0
+	}
+
+	allowed := l.params.Limit - b.count
+	if allowed < 0 {
+		allowed = 0
+	}
+	if int64(len(envelopes)) <= allowed {
+		b.count += int64(len(envelopes))
+		return envelopes, false
+	}
+	b.count = l.params.Limit
+	b.exceeded++
+	if l.dropped[key] == nil {
+		l.dropped[key] = make(map[common.Hash]bool)
+	}
+	for _, env := range envelopes[allowed:] {
+		l.dropped[key][env.Hash()] = true
+	}
+	atomic.AddInt64(&l.limited, int64(len(envelopes))-allowed)
+	drop := l.params.Tolerance > 0 && b.exceeded > l.params.Tolerance
+	if drop {
+		atomic.AddInt64(&l.peers, 1)
+	}
+	return envelopes[:allowed], drop
+}
+
+// isDropped reports whether envelope sent from node to its peer has been
+// dropped by the limiter.
+func (l *rateLimiter) isDropped(from, to int, hash common.Hash) bool {
+	if l == nil {
+		return false
+	}
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	return l.dropped[link{from: from, to: to}][hash]
+}
+
+// totals returns total numbers of dropped envelopes and disconnected peers.
+func (l *rateLimiter) totals() (envelopes, peers int64) {
+	if l == nil {
+		return 0, 0
+	}
+	return atomic.LoadInt64(&l.limited), atomic.LoadInt64(&l.peers)
+}
+
+// limitedWhisper is whisper service of the node, whose packets are subject
+// to rate limits of its peers.
+type limitedWhisper struct {
+	node.Service
+	sim  *Simulator
+	node int
+}
+
+// Protocols wraps whisper protocols, limiting envelopes written to peers.
+func (w *limitedWhisper) Protocols() []p2p.Protocol {
+	protos := w.Service.Protocols()
+	for i := range protos {
+		run := protos[i].Run
+		protos[i].Run = func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+			return run(peer, &limitedReadWriter{MsgReadWriter: rw, w: w, peer: peer})
+		}
+	}
+	return protos
+}
+
+// limitedReadWriter drops envelopes of packets written to the peer over
+// the peer's rate limit.
+type limitedReadWriter struct {
+	p2p.MsgReadWriter
+	w    *limitedWhisper
+	peer *p2p.Peer
+}
+
+// WriteMsg implements p2p.MsgWriter.
+func (rw *limitedReadWriter) WriteMsg(msg p2p.Msg) error {
+	if msg.Code != messagesCode {
+		return rw.MsgReadWriter.WriteMsg(msg)
+	}
+	var envelopes []*whisper.Envelope
+	if err := msg.Decode(&envelopes); err != nil {
+		return err
+	}
+
+	sim := rw.w.sim
+	peer := sim.Index(rw.peer.ID())
+	accepted, drop := sim.limiter.accept(rw.w.node, peer, envelopes, time.Now())
+	if drop {
+		rw.peer.Disconnect(p2p.DiscUselessPeer)
+	}
+	if len(accepted) == 0 {
+		return nil
+	}
+	data, err := rlp.EncodeToBytes(accepted)
+	if err != nil {
+		return err
+	}
+	msg.Payload = bytes.NewReader(data)
+	msg.Size = uint32(len(data))
+	return rw.MsgReadWriter.WriteMsg(msg)
+}
@@ -1,199 +1,392 @@
 package whisperv6
 
 import (
-	"crypto/ecdsa"
-	"errors"
-	"fmt"
+	"context"
 	"log"
+	"log/slog"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/divan/graphx/graph"
 	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/propagation/devp2p"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/node"
-	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/simulations"
 	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+	"github.com/ethereum/go-ethereum/rpc"
 	whisper "github.com/ethereum/go-ethereum/whisper/whisperv6"
 )
 
 // Simulator simulates WhisperV6 message propagation through the
-// given p2p network. Implements Simulator interface.
+// given p2p network. It runs whisper as devp2p service (see
+// propagation/devp2p), which sets up the network, and adds whisper
+// specific settings and accounting on top. Implements Simulator interface.
 type Simulator struct {
-	data     *graph.Graph
-	network  *simulations.Network
-	whispers map[enode.ID]*whisper.Whisper
+	*devp2p.Simulator
+
+	nodes          int // number of nodes in the graph, for per node settings
+	connectWorkers int
+	spam           *propagation.SpamParams // nil if there is no background spam
+	spamMx         sync.Mutex
+	spamUsers      int                       // messages being sent, sharing spam generation
+	stopSpam       func()                    // stops spam generation, if it's running
+	subscriptions  propagation.Subscriptions // nil if nodes have full bloom filter
+	topic          string                    // topic of messages sent with SendMessage
+	message        MessageParams             // parameters of messages sent with SendMessage
+	stopCoverage   float64                   // stop once that fraction of nodes is reached, if set
+	quiescence     time.Duration             // stop after that long without events, if set
+	maxDuration    time.Duration             // stop collecting events after that long, if set
+	progress       propagation.ProgressFunc
+	events         propagation.EventFunc
+	observer       propagation.ObserverFunc
+	codes          map[MessageCode]bool  // devp2p packets tracked, see WithMessageCodes
+	skew           []time.Duration       // clock offset of each node, nil if clocks are in sync
+	configs        []whisper.Config      // whisper config of each node, nil if all nodes use default one
+	limiter        *rateLimiter          // per-peer rate limits, nil if peers aren't limited
+	sampling       *propagation.Sampling // nil if all events are recorded
 }
 
-var ErrLinkExists = errors.New("link exists")
+var ErrLinkExists = devp2p.ErrLinkExists
+
+// progressInterval defines how often events collection progress is reported.
+const progressInterval = 500 * time.Millisecond
 
 // NewSimulator intializes simulator for the given graph data.
 // It uses defaults for PoW settings.
-func NewSimulator(data *graph.Graph) *Simulator {
-	rand.Seed(time.Now().UnixNano())
-
-	cfg := &whisper.Config{
-		MaxMessageSize:     whisper.DefaultMaxMessageSize,
-		MinimumAcceptedPOW: 0.001,
+func NewSimulator(data *graph.Graph, opts ...Option) *Simulator {
+	sim, err := newSimulator(data, opts...)
+	if err != nil {
+		log.Fatal("[ERROR] Can't create simulator: ", err)
 	}
-
-	whispers := make(map[enode.ID]*whisper.Whisper, data.NumNodes())
-	services := map[string]adapters.ServiceFunc{
-		"shh": func(ctx *adapters.ServiceContext) (node.Service, error) {
-			return whispers[ctx.Config.ID], nil
-		},
+	if err := sim.CreateNetwork(); err != nil {
+		log.Fatal("[ERROR] Can't create network: ", err)
 	}
+	return sim
+}
 
-	adapter := adapters.NewSimAdapter(services)
-	network := simulations.NewNetwork(adapter, &simulations.NetworkConfig{
-		DefaultService: "shh",
-	})
+// newSimulator creates simulator with empty in-memory network,
+// running whisper service on each node.
+func newSimulator(data *graph.Graph, opts ...Option) (*Simulator, error) {
+	rand.Seed(time.Now().UnixNano())
 
 	sim := &Simulator{
-		data:    data,
-		network: network,
+		nodes:          data.NumNodes(),
+		connectWorkers: DefaultConnectWorkers,
+		message:        DefaultMessageParams(),
+		progress:       propagation.LogProgress(),
+		events:         func(propagation.LogEntry) {},
+		observer:       func(propagation.Event) {},
 	}
-
-	log.Println("Creating nodes...")
-	for i := 0; i < data.NumNodes(); i++ {
-		node, err := sim.network.NewNodeWithConfig(nodeConfig(i))
-		if err != nil {
-			log.Fatal("[ERROR] Can't start node: ", err)
-		}
-		// it's important to init whisper service here, as it
-		// be initialized for each peer
-		service := whisper.New(cfg)
-		whispers[node.ID()] = service
+	WithMessageCodes(DefaultMessageCodes()...)(sim)
+	for _, opt := range opts {
+		opt(sim)
 	}
 
-	log.Println("Starting nodes...")
-	if err := network.StartAll(); err != nil {
-		log.Fatal("[ERROR] Can't start nodes: ", err)
+	service := devp2p.Service{
+		Name:  "shh",
+		New:   sim.newService,
+		Send:  sim.send,
+		Match: sim.match,
+	}
+	p2p, err := devp2p.New(data, service,
+		devp2p.WithConnectWorkers(sim.connectWorkers),
+		devp2p.WithProgress(sim.progress))
+	if err != nil {
+		return nil, err
 	}
+	sim.Simulator = p2p
+	return sim, nil
+}
 
-	// subscribing to network events
-	events := make(chan *simulations.Event)
-	sub := sim.network.Events().Subscribe(events)
-	defer sub.Unsubscribe()
+// newService creates whisper service of the node, wrapped to follow
+// node's clock and peers' rate limits, if they're set.
+func (s *Simulator) newService(idx int, ctx *adapters.ServiceContext) (node.Service, error) {
+	w := s.newWhisper(idx)
+	var service node.Service = w
+	if s.skew != nil {
+		service = &skewedWhisper{Whisper: w, sim: s, node: idx}
+	}
+	if s.limiter != nil {
+		service = &limitedWhisper{Service: service, sim: s, node: idx}
+	}
+	return service, nil
+}
 
-	count := 0
-	connectingDone := make(chan struct{})
-	go func() {
-		log.Println("Connecting nodes...")
-		for _, link := range data.Links() {
-			err := sim.connectNodes(link.FromIdx(), link.ToIdx())
-			if err != nil && err != ErrLinkExists {
-				log.Fatalf("[ERROR] Can't connect nodes %s and %s: %s", link.From(), link.To(), err)
-			} else if err == nil {
-				count++
-			}
-		}
-		log.Println("Connected all nodes...")
-		close(connectingDone)
-	}()
-
-	// wait for all nodes to establish connections
-	var connected int
-	var subErr error
-	for connected < count && subErr == nil {
-		select {
-		case event := <-events:
-			if event.Type == simulations.EventTypeConn {
-				if event.Conn.Up {
-					connected++
-				}
-			}
-		case e := <-sub.Err():
-			subErr = e
-			log.Fatal("Failed to connect nodes", subErr)
+// newWhisper creates whisper service of the node with its settings (see
+// WithNodeConfigs). With topics, node advertises bloom filter of its
+// subscriptions instead of the full one, so peers send it only matching
+// envelopes.
+func (s *Simulator) newWhisper(idx int) *whisper.Whisper {
+	cfg := s.whisperConfig(idx)
+	w := whisper.New(&cfg)
+	if s.subscriptions != nil {
+		bloom := s.subscriptions.Bloom(idx)
+		if err := w.SetBloomFilter(bloom[:]); err != nil {
+			log.Fatal("[ERROR] Can't set bloom filter: ", err)
 		}
 	}
+	return w
+}
 
-	sub.Unsubscribe()
-	<-connectingDone
-	log.Println("All connections established")
+// send posts message of the given TTL and size, encrypted with new
+// symmetric key, through the whisper service, and returns envelope hash.
+func (s *Simulator) send(svc node.Service, ttl, size int) (string, error) {
+	api := whisper.NewPublicWhisperAPI(unwrap(svc))
+	symKey := make([]byte, aesKeyLength)
+	rand.Read(symKey)
+	symkeyID, err := api.AddSymKey(context.Background(), symKey)
+	if err != nil {
+		return "", err
+	}
+	return s.postMessage(api, generateMessage(ttl, symkeyID, size, s.message))
+}
 
-	return sim
+// postMessage posts message with the whisper API, on the topic set by
+// WithTopics, if any, and returns envelope hash.
+func (s *Simulator) postMessage(api *whisper.PublicWhisperAPI, msg *whisper.NewMessage) (string, error) {
+	if s.subscriptions != nil {
+		msg.Topic = whisper.TopicType(propagation.TopicBytes(s.topic))
+	}
+	hash, err := api.Post(context.Background(), *msg)
+	if err != nil {
+		return "", err
+	}
+	return common.BytesToHash(hash).Hex(), nil
+}
+
+// match reports whether whisper packet sent by the node carries the
+// envelope with the given hash. Packets of nodes without the envelope
+// carry only other envelopes, i.e. spam or messages sent concurrently.
+func (s *Simulator) match(envelope string, sender node.Service, packet *simulations.Msg) bool {
+	if (MessageCode{Protocol: packet.Protocol, Code: packet.Code}) != envelopesCode {
+		return false
+	}
+	return s.envelope(sender, common.HexToHash(envelope)) != nil
 }
 
-// Stop stops simulator and frees all resources if any.
-func (s *Simulator) Stop() error {
-	log.Println("Shutting down simulation nodes...")
-	s.network.Shutdown()
+// envelope returns envelope with the given hash from the pool of the
+// node's whisper service, or nil if node doesn't have it or is down.
+func (s *Simulator) envelope(svc node.Service, hash common.Hash) *whisper.Envelope {
+	w := unwrap(svc)
+	if w == nil {
+		return nil
+	}
+	return w.GetEnvelope(hash)
+}
 
+// unwrap returns whisper of the node's service, or nil if node is down.
+func unwrap(svc node.Service) *whisper.Whisper {
+	switch w := svc.(type) {
+	case *whisper.Whisper:
+		return w
+	case *skewedWhisper:
+		return w.Whisper
+	case *limitedWhisper:
+		return unwrap(w.Service)
+	}
 	return nil
 }
 
 // SendMessage sends single message and tracks propagation. Implements propagation.Simulator.
+// It's safe to call SendMessage concurrently.
 func (s *Simulator) SendMessage(startNodeIdx, ttl, size int) *propagation.Log {
-	node := s.network.Nodes[startNodeIdx]
+	slog.Info("Sending Whisper message", "ttl", ttl, "size", size, "from", s.Network().Nodes[startNodeIdx].ID().String())
+	return s.post(startNodeIdx, ttl, func() (string, error) {
+		return s.send(s.Service(startNodeIdx), ttl, size)
+	})
+}
 
-	// the easiest way to send a message through the node is
-	// by using its public RPC methods - ssh_post.
-	client, err := node.Client()
-	if err != nil {
-		log.Fatal("Failed getting client", err)
+// SendDirectMessage sends single message from node to the recipient node,
+// encrypted to the recipient's asymmetric key, and tracks propagation.
+// Message still floods the network, as whisper nodes can't tell the
+// recipient, so delivery latency is the time the recipient first got the
+// envelope (see stats.AnalyzeDelivery). Implements propagation.DirectSender.
+func (s *Simulator) SendDirectMessage(from, to, ttl, size int) *propagation.Log {
+	if to < 0 || to >= len(s.Network().Nodes) {
+		log.Fatalf("Recipient node with index %d not found", to)
 	}
+	recipient := s.client(to)
+	slog.Info("Sending direct Whisper message", "ttl", ttl, "size", size,
+		"from", s.Network().Nodes[from].ID().String(), "to", s.Network().Nodes[to].ID().String())
 
-	log.Printf(" Sending Whisper message (ttl: %d, size %d bytes) from %s...\n", ttl, size, node.ID().String())
+	var keyID string
+	if err := recipient.Call(&keyID, "shh_newKeyPair"); err != nil {
+		log.Fatal("Failed generating recipient key pair: ", err)
+	}
+	var pubkey hexutil.Bytes
+	if err := recipient.Call(&pubkey, "shh_getPublicKey", keyID); err != nil {
+		log.Fatal("Failed getting recipient public key: ", err)
+	}
+	var filterID string
+	if err := recipient.Call(&filterID, "shh_newMessageFilter", whisper.Criteria{PrivateKeyID: keyID}); err != nil {
+		log.Fatal("Failed subscribing recipient to messages: ", err)
+	}
 
-	var symkeyID string
-	symKey := make([]byte, aesKeyLength)
-	rand.Read(symKey)
+	msg := generateMessage(ttl, "", size, s.message)
+	msg.PublicKey = pubkey
+	plog := s.post(from, ttl, func() (string, error) {
+		return s.postMessage(whisper.NewPublicWhisperAPI(unwrap(s.Service(from))), msg)
+	})
+
+	// make sure recipient could decrypt the message, not only received it
+	var received []*whisper.Message
+	if err := recipient.Call(&received, "shh_getFilterMessages", filterID); err != nil {
+		log.Fatal("Failed getting recipient messages: ", err)
+	}
+	if len(received) == 0 {
+		slog.Warn("Message wasn't delivered to recipient", "to", to)
+	}
+	return plog
+}
 
-	err = client.Call(&symkeyID, "shh_addSymKey", hexutil.Bytes(symKey))
+// client returns RPC client of the node.
+func (s *Simulator) client(idx int) *rpc.Client {
+	// the easiest way to send a message through the node is
+	// by using its public RPC methods - ssh_post.
+	client, err := s.Network().Nodes[idx].Client()
 	if err != nil {
-		log.Fatal("Failed adding new symmetric key: ", err)
+		log.Fatal("Failed getting client", err)
 	}
+	return client
+}
 
+// post posts message through the node with send, which returns envelope
+// hash, and tracks its propagation until ttl (in seconds) passes.
+func (s *Simulator) post(startNodeIdx, ttl int, send func() (string, error)) *propagation.Log {
 	// subscribing to network events
 	events := make(chan *simulations.Event)
-	sub := s.network.Events().Subscribe(events)
+	sub := s.Network().Events().Subscribe(events)
 	defer sub.Unsubscribe()
 
-	msg := generateMessage(ttl, symkeyID, size)
-	var ignored string
-	err = client.Call(&ignored, "shh_post", msg)
+	limited, disconnected := s.limiter.totals()
+	stopSpam := s.startSpam()
+	defer stopSpam()
+
+	hash, err := send()
 	if err != nil {
 		log.Fatal("Failed sending new post message: ", err)
 	}
-
-	// pre-cache node indexes
-	var ncache = make(map[enode.ID]int)
-	for i := range s.network.Nodes {
-		ncache[s.network.Nodes[i].ID()] = i
-	}
+	envelope := common.HexToHash(hash)
 
 	start := time.Now() // mark simulation start
 
 	timeout := time.Duration(ttl)*time.Second + 200*time.Millisecond // add a bit in the end
+	if s.maxDuration > 0 && s.maxDuration < timeout {
+		timeout = s.maxDuration
+	}
 	timer := time.NewTimer(timeout)
 	defer timer.Stop()
+	// quiet fires after period of events silence, if enabled
+	var quiet <-chan time.Time
+	var quietTimer *time.Timer
+	if s.quiescence > 0 {
+		quietTimer = time.NewTimer(s.quiescence)
+		defer quietTimer.Stop()
+		quiet = quietTimer.C
+	}
+	reached := map[int]bool{startNodeIdx: true}
+	var sampler *propagation.Sampler
+	if s.sampling != nil {
+		sampler = propagation.NewSampler(*s.sampling, startNodeIdx)
+	}
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
 	var (
 		subErr          error
 		done, hasEvents bool
-		plog            []*propagation.LogEntry
+		entries         propagation.LogEntries
+		future, expired int // envelopes dropped due to clock skew
+		refused         int // envelopes refused due to receiver's config
+		dropped         int // envelopes dropped by receiver's rate limiter
 	)
 
 	for subErr == nil && !done {
 		select {
 		case event := <-events:
-			if event.Type == simulations.EventTypeMsg {
-				msg := event.Msg
-				if msg.Code == 1 && msg.Protocol == "shh" && msg.Received == false {
-					from := ncache[msg.One]
-					to := ncache[msg.Other]
-					t := event.Time
-					entry := propagation.NewLogEntry(t, start, from, to)
-					plog = append(plog, entry)
-
-					hasEvents = true
+			e := s.typedEvent(event, start)
+			send, ok := e.(propagation.SendEvent)
+			if !ok {
+				if e != nil {
+					s.observer(e)
 				}
+				continue
+			}
+			if code := (MessageCode{Protocol: event.Msg.Protocol, Code: event.Msg.Code}); code != envelopesCode {
+				// packets of other protocols are streamed as they're sent,
+				// log holds envelope deliveries only
+				send.Msg = code.String()
+				s.observer(send)
+				s.events(propagation.LogEntry{Ts: send.Ts, From: send.From, To: send.To, Msg: send.Msg})
+				continue
+			}
+			// packets of nodes without the envelope carry only other
+			// envelopes, i.e. spam or messages sent concurrently
+			env := s.envelope(s.Service(send.From), envelope)
+			if env == nil {
+				continue
 			}
+			from, to := send.From, send.To
+			t := event.Time
+			hasEvents = true
+			send.Msg = envelope.Hex()
+			s.observer(send)
+			drop := func(reason propagation.DropReason) {
+				s.observer(propagation.DropEvent{Ts: send.Ts, From: from, To: to, Msg: send.Msg, Reason: reason})
+			}
+			if s.limiter.isDropped(from, to, envelope) {
+				dropped++
+				drop(propagation.DropRateLimit)
+				continue
+			}
+			switch s.checkEnvelope(to, env, t) {
+			case envelopeFuture:
+				future++
+				drop(propagation.DropClockSkew)
+				continue
+			case envelopeExpired:
+				expired++
+				drop(propagation.DropClockSkew)
+				continue
+			}
+			if s.refuses(to, env) {
+				refused++
+				drop(propagation.DropRefused)
+				continue
+			}
+			s.observer(propagation.ReceiveEvent{Ts: send.Ts, From: from, To: to, Msg: send.Msg})
+			if sampler != nil && !sampler.Keep(from, to) {
+				continue
+			}
+			entry := propagation.NewLogEntry(t, start, from, to)
+			entry.Msg = send.Msg
+			entries.Add(*entry)
+			s.events(*entry)
+
+			reached[to] = true
+			if s.stopCoverage > 0 && float64(len(reached)) >= s.stopCoverage*float64(len(s.Network().Nodes)) {
+				slog.Debug("Coverage reached, stopping", "nodes", len(reached))
+				done = true
+			}
+			if quietTimer != nil {
+				if !quietTimer.Stop() {
+					<-quietTimer.C
+				}
+				quietTimer.Reset(s.quiescence)
+			}
+		case <-ticker.C:
+			s.progress(propagation.Progress{
+				Phase: propagation.PhaseCollect,
+				Done:  int(time.Since(start) / time.Millisecond),
+				Total: int(timeout / time.Millisecond),
+			})
 		case <-timer.C:
 			done = true
+		case <-quiet:
+			slog.Debug("No events, stopping", "silence", s.quiescence)
+			done = true
 		case e := <-sub.Err():
 			subErr = e
 		}
@@ -201,49 +394,35 @@ func (s *Simulator) SendMessage(startNodeIdx, ttl, size int) *propagation.Log {
 	if subErr != nil {
 		log.Fatal("[ERROR] Failed to collect propagation info", subErr)
 	}
+	if future > 0 || expired > 0 {
+		slog.Info("Envelopes dropped due to clock skew", "future", future, "expired", expired)
+	}
+	if refused > 0 {
+		slog.Info("Envelopes refused due to nodes configs", "count", refused)
+	}
+	if s.limiter != nil {
+		total, peers := s.limiter.totals()
+		slog.Info("Envelopes dropped by rate limiter", "message", dropped,
+			"total", total-limited, "peers disconnected", peers-disconnected)
+	}
 	if !hasEvents {
 		log.Fatal("[ERROR] Didn't get any events, something wrong with simulator.")
 	}
 
-	return propagation.LogEntries2Log(s.data, plog)
-}
-
-// nodeConfig generates config for simulated node with random key.
-func nodeConfig(idx int) *adapters.NodeConfig {
-	key, err := crypto.GenerateKey()
-	if err != nil {
-		log.Fatal("[ERROR] Can't generate key: ", err)
+	plog := entries.Log(s.Graph())
+	entries.Release()
+	if sampler != nil {
+		plog.Sampling = sampler.Sampling()
 	}
-	id := pubkeyToID(&key.PublicKey)
-	return &adapters.NodeConfig{
-		ID:              id,
-		PrivateKey:      key,
-		Name:            nodeIdxToName(idx),
-		EnableMsgEvents: true,
-	}
-}
-
-func pubkeyToID(key *ecdsa.PublicKey) enode.ID {
-	return enode.PubkeyToIDV4(key)
+	return plog
 }
 
-func nodeIdxToName(id int) string {
-	return fmt.Sprintf("Node %d", id)
-}
-
-func (sim *Simulator) connectNodes(from, to int) error {
-	// TODO(divan): check if we have IDs in from/to strings
-	node1 := sim.network.Nodes[from]
-	if node1 == nil {
-		return fmt.Errorf("node with ID '%v' not found", from)
-	}
-	node2 := sim.network.Nodes[to]
-	if node2 == nil {
-		return fmt.Errorf("node with ID '%v' not found", to)
-	}
-	// if connection already exists, skip it, as network.Connect will fail
-	if sim.network.GetConn(node1.ID(), node2.ID()) != nil {
-		return ErrLinkExists
-	}
-	return sim.network.Connect(node1.ID(), node2.ID())
+// RestartNode starts stopped node again, with fresh whisper service, as
+// whisper keeps envelopes in memory only, and reconnects it to its peers
+// from the network graph that are up. Whisper peers send all envelopes of
+// their pools to new connections, so restarted node gets envelopes it
+// missed that haven't expired yet regardless of fetch. Implements
+// propagation.NodeRestarter.
+func (sim *Simulator) RestartNode(idx int, fetch bool) error {
+	return sim.Simulator.RestartNode(idx, fetch)
 }
@@ -0,0 +1,33 @@
+package whisperv6
+
+import (
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/internal/testgraph"
+)
+
+func TestSendMessage(t *testing.T) {
+	sim := NewSimulator(testgraph.Line(4), WithQuiescence(time.Second), WithProgress(nil))
+	defer sim.Stop()
+
+	plog := sim.SendMessage(0, 10, 100)
+	reached := map[int]bool{0: true}
+	for _, nodes := range plog.Nodes {
+		for _, n := range nodes {
+			reached[n] = true
+		}
+	}
+	if len(reached) != 4 {
+		t.Fatalf("expected message to reach all 4 nodes, got %v", reached)
+	}
+}
+
+func TestAddNodeWithSettings(t *testing.T) {
+	sim := NewSimulator(testgraph.Line(2), WithClockSkew(func(int) time.Duration { return 0 }), WithProgress(nil))
+	defer sim.Stop()
+
+	if _, err := sim.AddNode("2"); err == nil {
+		t.Fatal("expected error adding node with per node settings")
+	}
+}
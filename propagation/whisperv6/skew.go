@@ -0,0 +1,117 @@
+package whisperv6
+
+import (
+	"bytes"
+	"math/rand"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv6"
+)
+
+// messagesCode is the code of whisper packets carrying envelopes.
+const messagesCode = 1
+
+// WithClockSkew sets clock offset of each node, so nodes check envelopes
+// received from peers against their own clocks, like whisper does:
+// envelopes sent more than whisper.DefaultSyncAllowance in the future or
+// already expired are dropped and not relayed further, leaving holes in
+// propagation. Envelopes are created with the real clock, so offsets are
+// relative to the clock of the message sender.
+func WithClockSkew(fn func(node int) time.Duration) Option {
+	return func(s *Simulator) {
+		s.skew = make([]time.Duration, s.nodes)
+		for i := range s.skew {
+			s.skew[i] = fn(i)
+		}
+	}
+}
+
+// UniformClockSkew returns offsets drawn uniformly from [-max, max] for
+// WithClockSkew, using seed for random source.
+func UniformClockSkew(max time.Duration, seed int64) func(node int) time.Duration {
+	r := rand.New(rand.NewSource(seed))
+	return func(int) time.Duration {
+		return time.Duration(r.Int63n(2*int64(max)+1)) - max
+	}
+}
+
+// envelopeCheck is the result of checking envelope against node's clock.
+type envelopeCheck int
+
+const (
+	envelopeAccepted envelopeCheck = iota
+	envelopeFuture                 // sent too far in the future
+	envelopeExpired
+)
+
+// checkEnvelope checks envelope received by node at the given time against
+// node's clock, following the rules of whisper.
+func (s *Simulator) checkEnvelope(node int, env *whisper.Envelope, at time.Time) envelopeCheck {
+	if s.skew == nil {
+		return envelopeAccepted
+	}
+	now := uint32(at.Add(s.skew[node]).Unix())
+	sent := env.Expiry - env.TTL
+	switch {
+	case sent > now+whisper.DefaultSyncAllowance:
+		return envelopeFuture
+	case env.Expiry < now:
+		return envelopeExpired
+	}
+	return envelopeAccepted
+}
+
+// skewedWhisper is whisper service of the node with clock offset, which
+// drops envelopes from peers not accepted by its clock.
+type skewedWhisper struct {
+	*whisper.Whisper
+	sim  *Simulator
+	node int
+}
+
+// Protocols wraps whisper protocols, filtering envelopes read from peers.
+func (w *skewedWhisper) Protocols() []p2p.Protocol {
+	protos := w.Whisper.Protocols()
+	for i := range protos {
+		run := protos[i].Run
+		protos[i].Run = func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+			return run(peer, &skewedReadWriter{MsgReadWriter: rw, w: w})
+		}
+	}
+	return protos
+}
+
+// skewedReadWriter filters envelopes of packets read from the peer.
+type skewedReadWriter struct {
+	p2p.MsgReadWriter
+	w *skewedWhisper
+}
+
+// ReadMsg implements p2p.MsgReader.
+func (rw *skewedReadWriter) ReadMsg() (p2p.Msg, error) {
+	msg, err := rw.MsgReadWriter.ReadMsg()
+	if err != nil || msg.Code != messagesCode {
+		return msg, err
+	}
+	var envelopes []*whisper.Envelope
+	if err := msg.Decode(&envelopes); err != nil {
+		return msg, err
+	}
+
+	now := time.Now()
+	accepted := envelopes[:0]
+	for _, env := range envelopes {
+		if rw.w.sim.checkEnvelope(rw.w.node, env, now) == envelopeAccepted {
+			accepted = append(accepted, env)
+		}
+	}
+	data, err := rlp.EncodeToBytes(accepted)
+	if err != nil {
+		return msg, err
+	}
+	msg.Payload = bytes.NewReader(data)
+	msg.Size = uint32(len(data))
+	return msg, nil
+}
@@ -0,0 +1,52 @@
+package whisperv6
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/divan/graphx/graph"
+	"github.com/ethereum/go-ethereum/p2p/simulations"
+)
+
+// SaveSnapshot saves the simulated network (nodes with their keys and
+// established connections) into the JSON file, so the expensive nodes
+// creation and connection phase can be skipped next time with
+// NewSimulatorFromSnapshot.
+func (s *Simulator) SaveSnapshot(path string) error {
+	snap, err := s.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %v", err)
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// NewSimulatorFromSnapshot initializes simulator for the given graph data
+// from the network snapshot saved by SaveSnapshot. Snapshot should be taken
+// from the simulator created for the same graph, as nodes are matched
+// with graph nodes by index.
+func NewSimulatorFromSnapshot(data *graph.Graph, path string, opts ...Option) (*Simulator, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot: %v", err)
+	}
+
+	var snap simulations.Snapshot
+	if err := json.Unmarshal(buf, &snap); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %v", err)
+	}
+	sim, err := newSimulator(data, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := sim.LoadSnapshot(&snap); err != nil {
+		sim.Network().Shutdown()
+		return nil, err
+	}
+	return sim, nil
+}
@@ -0,0 +1,77 @@
+package whisperv6
+
+import (
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// startSpam starts spam generation, unless it's already running for
+// messages sent concurrently, and returns function stopping it once all
+// of them are done.
+func (s *Simulator) startSpam() func() {
+	s.spamMx.Lock()
+	defer s.spamMx.Unlock()
+	s.spamUsers++
+	if s.spamUsers == 1 {
+		s.stopSpam = s.runSpam()
+	}
+	return func() {
+		s.spamMx.Lock()
+		defer s.spamMx.Unlock()
+		s.spamUsers--
+		if s.spamUsers == 0 {
+			s.stopSpam()
+		}
+	}
+}
+
+// runSpam starts posting spam envelopes from spamming nodes and returns
+// function stopping it.
+func (s *Simulator) runSpam() func() {
+	if s.spam == nil || len(s.spam.Nodes) == 0 {
+		return func() {}
+	}
+	slog.Info("Starting spam", "nodes", len(s.spam.Nodes), "rate", s.spam.Rate)
+
+	quit := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, idx := range s.spam.Nodes {
+		client, err := s.Network().Nodes[idx].Client()
+		if err != nil {
+			slog.Warn("Failed getting spammer client", "node", idx, "err", err)
+			continue
+		}
+		symKey := make([]byte, aesKeyLength)
+		rand.Read(symKey)
+		var symkeyID string
+		if err := client.Call(&symkeyID, "shh_addSymKey", hexutil.Bytes(symKey)); err != nil {
+			slog.Warn("Failed adding spammer symmetric key", "node", idx, "err", err)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-time.After(s.spam.Interval()):
+				case <-quit:
+					return
+				}
+				msg := generateSpamMessage(s.spam.TTL, symkeyID, s.spam.Size)
+				var ignored hexutil.Bytes
+				if err := client.Call(&ignored, "shh_post", msg); err != nil {
+					slog.Debug("Failed posting spam envelope", "err", err)
+				}
+			}
+		}()
+	}
+	return func() {
+		close(quit)
+		wg.Wait()
+	}
+}
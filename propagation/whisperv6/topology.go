@@ -0,0 +1,15 @@
+package whisperv6
+
+import "errors"
+
+// AddNode adds new node with the given ID to the network, running whisper
+// service with default config, and returns its index. Node has no links,
+// connect it with AddLink. Nodes can't be added if their settings differ
+// (see WithNodeConfigs, WithClockSkew and WithTopics). Implements
+// propagation.NetworkMutator.
+func (sim *Simulator) AddNode(id string) (int, error) {
+	if sim.configs != nil || sim.skew != nil || sim.subscriptions != nil {
+		return 0, errors.New("adding nodes with per node settings is not supported")
+	}
+	return sim.Simulator.AddNode(id)
+}
@@ -0,0 +1,303 @@
+// Package report implements rendering of self-contained HTML reports
+// for simulation results, suitable for sharing with people not using Go tooling.
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/analysis"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/stats"
+)
+
+// chart dimensions, in pixels
+const (
+	chartWidth  = 800
+	chartHeight = 240
+	treeHeight  = 400
+)
+
+// Param represents single row of the parameters table.
+type Param struct {
+	Name  string
+	Value string
+}
+
+// Report holds all the data needed to render the report.
+type Report struct {
+	Title  string
+	Params []Param
+
+	data   *graph.Graph
+	plog   *propagation.Log
+	stats  *stats.Stats
+	tree   *stats.Tree
+	models []model
+}
+
+// model is expected coverage curve of analytical model, overlaid with
+// the simulated one.
+type model struct {
+	name  string
+	curve analysis.Curve
+}
+
+// New creates a new report for the given network graph and propagation log.
+func New(title string, data *graph.Graph, plog *propagation.Log) *Report {
+	ss := stats.Analyze(plog, data.NumNodes(), data.NumLinks())
+	r := &Report{
+		Title: title,
+		data:  data,
+		plog:  plog,
+		stats: ss,
+		tree:  stats.NewTree(plog),
+	}
+	r.AddParam("Nodes", fmt.Sprint(data.NumNodes()))
+	r.AddParam("Links", fmt.Sprint(data.NumLinks()))
+	r.AddParam("Time elapsed", ss.Time.String())
+	r.AddParam("Nodes coverage", ss.NodeCoverage.String())
+	r.AddParam("Links coverage", ss.LinkCoverage.String())
+	return r
+}
+
+// AddParam adds a row to the parameters table.
+func (r *Report) AddParam(name, value string) {
+	r.Params = append(r.Params, Param{Name: name, Value: value})
+}
+
+// AddModel overlays expected coverage curve of the analytical model (see
+// analysis.Expected) with the simulated coverage, and adds its deviation
+// from the latter to the parameters table.
+func (r *Report) AddModel(name string, curve analysis.Curve) {
+	r.models = append(r.models, model{name: name, curve: curve})
+	sim := analysis.Simulated(r.plog, r.data.NumNodes())
+	r.AddParam("Deviation from "+name+" model", fmt.Sprintf("%.1f%%", 100*analysis.Deviation(sim, curve)))
+}
+
+// WriteHTML renders report as a single HTML page without external dependencies.
+func (r *Report) WriteHTML(w io.Writer) error {
+	data := struct {
+		Title     string
+		Params    []Param
+		Width     int
+		Height    int
+		Coverage  *polyline
+		Models    []*polyline
+		Histogram []bar
+		MaxTime   int
+		Tree      *treeLayout
+	}{
+		Title:     r.Title,
+		Params:    r.Params,
+		Width:     chartWidth,
+		Height:    chartHeight,
+		Coverage:  r.coverageLine(),
+		Models:    r.modelLines(),
+		Histogram: r.histogramBars(),
+		MaxTime:   int(r.stats.Time / time.Millisecond),
+		Tree:      r.treeLayout(),
+	}
+	return reportTmpl.Execute(w, data)
+}
+
+// polyline represents SVG polyline chart.
+type polyline struct {
+	Name   string
+	Points string
+	Final  float64 // final value, in percents
+}
+
+// coverageLine calculates nodes coverage over time chart.
+func (r *Report) coverageLine() *polyline {
+	total := r.data.NumNodes()
+	var times []int
+	for _, ts := range r.tree.Time {
+		times = append(times, ts)
+	}
+	sort.Ints(times)
+	if total == 0 || len(times) == 0 {
+		return &polyline{}
+	}
+
+	maxTime := times[len(times)-1]
+	if maxTime == 0 {
+		maxTime = 1
+	}
+	x := func(ts int) float64 { return float64(ts) / float64(maxTime) * chartWidth }
+	y := func(n int) float64 { return chartHeight - float64(n)/float64(total)*chartHeight }
+
+	points := fmt.Sprintf("0,%.1f", y(0))
+	for i, ts := range times {
+		// draw steps, so coverage doesn't look interpolated
+		points += fmt.Sprintf(" %.1f,%.1f %.1f,%.1f", x(ts), y(i), x(ts), y(i+1))
+	}
+	return &polyline{
+		Points: points,
+		Final:  100.0 * float64(len(times)) / float64(total),
+	}
+}
+
+// modelLines calculates expected coverage over time charts of models,
+// in the same scale as the simulated one, cut at the end of simulation.
+func (r *Report) modelLines() []*polyline {
+	maxTime := r.stats.Time
+	if maxTime == 0 {
+		maxTime = time.Millisecond
+	}
+	x := func(t time.Duration) float64 { return float64(t) / float64(maxTime) * chartWidth }
+	y := func(c float64) float64 { return chartHeight - c*chartHeight }
+
+	lines := make([]*polyline, 0, len(r.models))
+	for _, m := range r.models {
+		line := &polyline{Name: m.name}
+		for i, p := range m.curve {
+			if p.Time > maxTime {
+				if i > 0 {
+					line.Points += fmt.Sprintf(" %.1f,%.1f", x(maxTime), y(m.curve[i-1].Coverage))
+				}
+				break
+			}
+			if i > 0 {
+				line.Points += fmt.Sprintf(" %.1f,%.1f", x(p.Time), y(m.curve[i-1].Coverage))
+			}
+			line.Points += fmt.Sprintf(" %.1f,%.1f", x(p.Time), y(p.Coverage))
+			line.Final = 100 * p.Coverage
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// bar represents single bar of the SVG bar chart.
+type bar struct {
+	X, Y, Width, Height float64
+	Label               string
+	Count               float64
+}
+
+// histogramBars calculates bars for TimeToNode histogram.
+func (r *Report) histogramBars() []bar {
+	h := r.stats.TimeToNodeHistogram
+	counts, dividers := h.Counts(), h.Dividers()
+	if len(counts) == 0 {
+		return nil
+	}
+
+	var max float64
+	for _, c := range counts {
+		if c > max {
+			max = c
+		}
+	}
+
+	width := float64(chartWidth) / float64(len(counts))
+	bars := make([]bar, 0, len(counts))
+	for i, c := range counts {
+		height := c / max * chartHeight
+		bars = append(bars, bar{
+			X:      float64(i) * width,
+			Y:      chartHeight - height,
+			Width:  width - 1,
+			Height: height,
+			Label:  fmt.Sprintf("%.0f-%.0fms", dividers[i], dividers[i+1]),
+			Count:  c,
+		})
+	}
+	return bars
+}
+
+// treeLayout represents calculated SVG layout of propagation tree.
+type treeLayout struct {
+	Width, Height int
+	Nodes         []treeNode
+	Edges         []treeEdge
+}
+
+type treeNode struct {
+	X, Y  float64
+	Label string
+	Root  bool
+}
+
+type treeEdge struct {
+	X1, Y1, X2, Y2 float64
+}
+
+// treeLayout calculates layered layout of the propagation tree, where
+// vertical position is the number of hops from the sender, and leafs are
+// spread horizontally. Trees of multiple senders are placed side by side.
+func (r *Report) treeLayout() *treeLayout {
+	if r.tree.Root == -1 {
+		return nil
+	}
+	children := r.tree.Children()
+
+	var maxDepth int
+	for node := range r.tree.Time {
+		if d := r.tree.Depth(node); d > maxDepth {
+			maxDepth = d
+		}
+	}
+	rowHeight := float64(treeHeight) / float64(maxDepth+1)
+
+	// assign horizontal positions to leafs in DFS order,
+	// parents are placed in the middle of their children
+	var (
+		leafs int
+		pos   = make(map[int]float64)
+		walk  func(node int) float64
+	)
+	walk = func(node int) float64 {
+		kids := children[node]
+		if len(kids) == 0 {
+			leafs++
+			pos[node] = float64(leafs)
+			return pos[node]
+		}
+		var sum float64
+		for _, kid := range kids {
+			sum += walk(kid)
+		}
+		pos[node] = sum / float64(len(kids))
+		return pos[node]
+	}
+	roots := make(map[int]bool, len(r.tree.Roots))
+	for _, root := range r.tree.Roots {
+		roots[root] = true
+		walk(root)
+	}
+
+	step := float64(chartWidth) / float64(leafs+1)
+	x := func(node int) float64 { return pos[node] * step }
+	y := func(node int) float64 { return float64(r.tree.Depth(node))*rowHeight + rowHeight/2 }
+
+	nodes := make([]int, 0, len(pos))
+	for node := range pos {
+		nodes = append(nodes, node)
+	}
+	sort.Ints(nodes)
+
+	layout := &treeLayout{Width: chartWidth, Height: treeHeight}
+	for _, node := range nodes {
+		layout.Nodes = append(layout.Nodes, treeNode{
+			X:     x(node),
+			Y:     y(node),
+			Label: fmt.Sprintf("node %d: %dms", node, r.tree.Time[node]),
+			Root:  roots[node],
+		})
+		if parent, ok := r.tree.Parent[node]; ok {
+			layout.Edges = append(layout.Edges, treeEdge{
+				X1: x(parent), Y1: y(parent),
+				X2: x(node), Y2: y(node),
+			})
+		}
+	}
+	return layout
+}
+
+var reportTmpl = template.Must(template.New("report").Parse(reportHTML))
@@ -0,0 +1,52 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/analysis"
+	"github.com/divan/simulation/propagation"
+)
+
+// node implements string-only graph.Node
+type node string
+
+func (n node) ID() string { return string(n) }
+
+func TestWriteHTML(t *testing.T) {
+	g := graph.NewGraph()
+	g.AddNode(node("0"))
+	g.AddNode(node("1"))
+	g.AddNode(node("2"))
+	g.AddLink("0", "1")
+	g.AddLink("1", "2")
+
+	plog := &propagation.Log{
+		Timestamps: []int{10, 20},
+		Nodes: [][]int{
+			[]int{0, 1},
+			[]int{1, 2},
+		},
+		Links: [][]int{
+			[]int{0},
+			[]int{1},
+		},
+	}
+
+	var buf bytes.Buffer
+	r := New("Test report", g, plog)
+	r.AddModel("push", analysis.Expected(analysis.Push, analysis.Params{Nodes: 3, Fanout: 1, Round: 10 * time.Millisecond}))
+	if err := r.WriteHTML(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, expected := range []string{"<title>Test report</title>", "<polyline", "node 2: 20ms", "push model", "Deviation from push model"} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("Expected report to contain %q, but it doesn't:\n%s", expected, out)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package report
+
+const reportHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+	body { font-family: sans-serif; margin: 2em auto; max-width: 860px; color: #222; }
+	h1 { font-size: 1.6em; }
+	h2 { font-size: 1.2em; margin-top: 2em; }
+	table { border-collapse: collapse; }
+	td, th { border: 1px solid #ccc; padding: 4px 12px; text-align: left; }
+	svg { background: #fafafa; border: 1px solid #eee; }
+	.line { fill: none; stroke: #1f77b4; stroke-width: 2; }
+	.model { fill: none; stroke: #ff7f0e; stroke-width: 1.5; stroke-dasharray: 6 3; }
+	.bar { fill: #1f77b4; }
+	.edge { stroke: #999; stroke-width: 1; }
+	.node { fill: #1f77b4; }
+	.root { fill: #d62728; }
+	.axis { font-size: 11px; fill: #666; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+
+<h2>Parameters</h2>
+<table>
+{{- range .Params }}
+	<tr><th>{{ .Name }}</th><td>{{ .Value }}</td></tr>
+{{- end }}
+</table>
+
+<h2>Nodes coverage over time</h2>
+<svg width="{{ .Width }}" height="{{ .Height }}">
+	<polyline class="line" points="{{ .Coverage.Points }}"/>
+{{- range .Models }}
+	<polyline class="model" points="{{ .Points }}"><title>{{ .Name }} model</title></polyline>
+{{- end }}
+</svg>
+<div class="axis">0ms &ndash; {{ .MaxTime }}ms, final coverage {{ printf "%.1f" .Coverage.Final }}%</div>
+{{- range .Models }}
+<div class="axis">Dashed: expected coverage of {{ .Name }} model, {{ printf "%.1f" .Final }}% by the end of simulation</div>
+{{- end }}
+
+<h2>TimeToNode histogram</h2>
+<svg width="{{ .Width }}" height="{{ .Height }}">
+{{- range .Histogram }}
+	<rect class="bar" x="{{ .X }}" y="{{ .Y }}" width="{{ .Width }}" height="{{ .Height }}"><title>{{ .Label }}: {{ .Count }} nodes</title></rect>
+{{- end }}
+</svg>
+
+<h2>Propagation tree</h2>
+{{- with .Tree }}
+<svg width="{{ .Width }}" height="{{ .Height }}">
+{{- range .Edges }}
+	<line class="edge" x1="{{ .X1 }}" y1="{{ .Y1 }}" x2="{{ .X2 }}" y2="{{ .Y2 }}"/>
+{{- end }}
+{{- range .Nodes }}
+	<circle class="{{ if .Root }}root{{ else }}node{{ end }}" cx="{{ .X }}" cy="{{ .Y }}" r="3"><title>{{ .Label }}</title></circle>
+{{- end }}
+</svg>
+<div class="axis">Vertical position is the number of hops from the sender (red).</div>
+{{- else }}
+<p>No propagation data.</p>
+{{- end }}
+</body>
+</html>
+`
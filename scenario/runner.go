@@ -0,0 +1,166 @@
+package scenario
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+)
+
+// Runner executes scenarios against any propagation.Simulator. Actions
+// other than message sending require simulator to implement corresponding
+// optional interfaces (propagation.NodeStopper, propagation.NodesDisconnector,
+// propagation.NetworkMutator).
+//
+// Note that simulators that can't distinguish traffic of different messages
+// may report the same propagation events for overlapping message sendings.
+type Runner struct {
+	data *graph.Graph
+	sim  propagation.Simulator
+}
+
+// NewRunner creates new scenario runner for the given network and simulator.
+func NewRunner(data *graph.Graph, sim propagation.Simulator) *Runner {
+	return &Runner{
+		data: data,
+		sim:  sim,
+	}
+}
+
+// result holds propagation log of a single message sending.
+type result struct {
+	offset int // in milliseconds since scenario start
+	plog   *propagation.Log
+}
+
+// Run executes all scenario events in time and returns the combined propagation
+// log, with timestamps relative to the scenario start. It blocks until all
+// sent messages finish propagating. Scenario referencing nodes missing from
+// the network is refused before running any event.
+func (r *Runner) Run(s *Scenario) (*propagation.Log, error) {
+	if err := s.CheckNodes(r.data.NumNodes()); err != nil {
+		return nil, err
+	}
+	var (
+		wg      sync.WaitGroup
+		mx      sync.Mutex
+		results []result
+		err     error
+	)
+
+	start := time.Now()
+	for _, event := range s.Events {
+		time.Sleep(time.Until(start.Add(event.At)))
+		slog.Info("Scenario event", "event", event.String())
+
+		if event.Action == ActionSend {
+			offset := int(time.Since(start) / time.Millisecond)
+			wg.Add(1)
+			go func(event Event) {
+				defer wg.Done()
+				plog := r.sim.SendMessage(event.Node, event.TTL, event.Size)
+				mx.Lock()
+				results = append(results, result{offset: offset, plog: plog})
+				mx.Unlock()
+			}(event)
+			continue
+		}
+
+		if err = r.execute(event); err != nil {
+			err = fmt.Errorf("event %v: %v", event, err)
+			break
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].offset < results[j].offset })
+	ret := propagation.NewLog(0)
+	for _, res := range results {
+		ret.Merge(res.plog, res.offset)
+	}
+	return ret, err
+}
+
+// execute executes non-send scenario event.
+func (r *Runner) execute(event Event) error {
+	switch event.Action {
+	case ActionKill:
+		stopper, ok := r.sim.(propagation.NodeStopper)
+		if !ok {
+			return fmt.Errorf("simulator doesn't support stopping nodes")
+		}
+		return stopper.StopNode(event.Node)
+	case ActionPartition:
+		disconnector, ok := r.sim.(propagation.NodesDisconnector)
+		if !ok {
+			return fmt.Errorf("simulator doesn't support disconnecting nodes")
+		}
+		return r.partition(disconnector, event.Groups)
+	case ActionAddNode, ActionRemoveNode, ActionAddLink, ActionRemoveLink:
+		mutator, ok := r.sim.(propagation.NetworkMutator)
+		if !ok {
+			return fmt.Errorf("simulator doesn't support changing network")
+		}
+		return mutate(mutator, event)
+	}
+	return fmt.Errorf("unknown action '%s'", event.Action)
+}
+
+// partition disconnects all links between nodes of different groups.
+// Nodes not listed in any group stay intact.
+func (r *Runner) partition(d propagation.NodesDisconnector, groups [][]int) error {
+	group := make(map[int]int)
+	for i, nodes := range groups {
+		for _, node := range nodes {
+			group[node] = i
+		}
+	}
+
+	for _, link := range r.data.Links() {
+		from, to := link.FromIdx(), link.ToIdx()
+		g1, ok1 := group[from]
+		g2, ok2 := group[to]
+		if !ok1 || !ok2 || g1 == g2 {
+			continue
+		}
+		if err := d.DisconnectNodes(from, to); err != nil {
+			return fmt.Errorf("disconnect %d and %d: %v", from, to, err)
+		}
+	}
+	return nil
+}
+
+// mutate changes network according to the event.
+func mutate(m propagation.NetworkMutator, event Event) error {
+	switch event.Action {
+	case ActionAddNode:
+		idx, err := m.AddNode(event.ID)
+		if err != nil {
+			return err
+		}
+		for _, peer := range event.Peers {
+			if err := m.AddLink(idx, peer); err != nil {
+				return fmt.Errorf("link %d and %d: %v", idx, peer, err)
+			}
+		}
+		return nil
+	case ActionRemoveNode:
+		return m.RemoveNode(event.Node)
+	}
+	for _, peer := range event.Peers {
+		var err error
+		if event.Action == ActionAddLink {
+			err = m.AddLink(event.Node, peer)
+		} else {
+			err = m.RemoveLink(event.Node, peer)
+		}
+		if err != nil {
+			return fmt.Errorf("%s %d and %d: %v", event.Action, event.Node, peer, err)
+		}
+	}
+	return nil
+}
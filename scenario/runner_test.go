@@ -0,0 +1,229 @@
+package scenario
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/propagation/gossip"
+	"github.com/divan/simulation/stats"
+)
+
+// node implements string-only graph.Node
+type node string
+
+func (n node) ID() string { return string(n) }
+
+// testSimulator records actions and returns fixed single step log.
+type testSimulator struct {
+	mx           sync.Mutex
+	sent         []int
+	stopped      []int
+	disconnected [][2]int
+}
+
+func (s *testSimulator) SendMessage(idx, ttl, size int) *propagation.Log {
+	s.mx.Lock()
+	s.sent = append(s.sent, idx)
+	s.mx.Unlock()
+	plog := propagation.NewLog(1)
+	plog.AddStep(10, []int{idx, idx + 1}, []int{idx})
+	return plog
+}
+
+func (s *testSimulator) Stop() error { return nil }
+
+func (s *testSimulator) StopNode(idx int) error {
+	s.stopped = append(s.stopped, idx)
+	return nil
+}
+
+func (s *testSimulator) DisconnectNodes(from, to int) error {
+	s.disconnected = append(s.disconnected, [2]int{from, to})
+	return nil
+}
+
+// testGraph returns network of 4 nodes connected one after another.
+func testGraph() *graph.Graph {
+	g := graph.NewGraph()
+	g.AddNode(node("0"))
+	g.AddNode(node("1"))
+	g.AddNode(node("2"))
+	g.AddNode(node("3"))
+	g.AddLink("0", "1")
+	g.AddLink("1", "2")
+	g.AddLink("2", "3")
+	return g
+}
+
+func TestRunner(t *testing.T) {
+	g := testGraph()
+	s := &Scenario{
+		Events: []Event{
+			{At: 20 * time.Millisecond, Action: ActionSend, Node: 2},
+			{At: 0, Action: ActionSend, Node: 0},
+			{At: 10 * time.Millisecond, Action: ActionKill, Node: 3},
+			{At: 10 * time.Millisecond, Action: ActionPartition, Groups: [][]int{{0, 1}, {2, 3}}},
+		},
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	sim := &testSimulator{}
+	plog, err := NewRunner(g, sim).Run(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sim.sent) != 2 {
+		t.Fatalf("Expected 2 messages to be sent, but got %d", len(sim.sent))
+	}
+	if len(sim.stopped) != 1 || sim.stopped[0] != 3 {
+		t.Fatalf("Expected node 3 to be stopped, but got %v", sim.stopped)
+	}
+	if len(sim.disconnected) != 1 || sim.disconnected[0] != [2]int{1, 2} {
+		t.Fatalf("Expected only link 1-2 to be disconnected, but got %v", sim.disconnected)
+	}
+	if plog.Len() != 2 {
+		t.Fatalf("Expected combined log to have 2 steps, but got %d", plog.Len())
+	}
+	if plog.Timestamps[1] < 30 {
+		t.Fatalf("Expected second message step to be shifted by scenario offset, but got %dms", plog.Timestamps[1])
+	}
+}
+
+// mutableSimulator records network changes.
+type mutableSimulator struct {
+	testSimulator
+	nodes   []string
+	removed []int
+	links   [][2]int // added, removed ones negated
+}
+
+func (s *mutableSimulator) AddNode(id string) (int, error) {
+	s.nodes = append(s.nodes, id)
+	return 3 + len(s.nodes), nil
+}
+
+func (s *mutableSimulator) RemoveNode(idx int) error {
+	s.removed = append(s.removed, idx)
+	return nil
+}
+
+func (s *mutableSimulator) AddLink(from, to int) error {
+	s.links = append(s.links, [2]int{from, to})
+	return nil
+}
+
+func (s *mutableSimulator) RemoveLink(from, to int) error {
+	s.links = append(s.links, [2]int{-from, -to})
+	return nil
+}
+
+func TestRunnerMutations(t *testing.T) {
+	s := &Scenario{
+		Events: []Event{
+			{Action: ActionAddNode, ID: "new", Peers: []int{1, 2}},
+			{Action: ActionAddLink, Node: 0, Peers: []int{3}},
+			{Action: ActionRemoveLink, Node: 1, Peers: []int{2}},
+			{Action: ActionRemoveNode, Node: 3},
+		},
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	sim := &mutableSimulator{}
+	if _, err := NewRunner(testGraph(), sim).Run(s); err != nil {
+		t.Fatal(err)
+	}
+	if len(sim.nodes) != 1 || sim.nodes[0] != "new" || len(sim.removed) != 1 || sim.removed[0] != 3 {
+		t.Fatalf("Expected node 'new' added and node 3 removed, got %v and %v", sim.nodes, sim.removed)
+	}
+	want := [][2]int{{4, 1}, {4, 2}, {0, 3}, {-1, -2}}
+	if !reflect.DeepEqual(sim.links, want) {
+		t.Fatalf("Expected links %v, got %v", want, sim.links)
+	}
+
+	// simulators without mutations support fail
+	if _, err := NewRunner(testGraph(), &testSimulator{}).Run(s); err == nil {
+		t.Fatal("Expected error for simulator not supporting network changes")
+	}
+
+	if err := (&Scenario{Events: []Event{{Action: ActionAddLink, Node: 0}}}).Validate(); err == nil {
+		t.Fatal("Expected error for add-link without peers")
+	}
+}
+
+func TestRunnerWrongNodes(t *testing.T) {
+	var tests = []Event{
+		{Action: ActionSend, Node: 4},
+		{Action: ActionKill, Node: -1},
+		{Action: ActionPartition, Groups: [][]int{{0, 1}, {2, 7}}},
+		{Action: ActionAddLink, Node: 0, Peers: []int{5}},
+		{Action: ActionAddNode, ID: "new", Peers: []int{4}},
+	}
+	for _, event := range tests {
+		s := &Scenario{Events: []Event{event}}
+		if err := s.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		sim := &mutableSimulator{}
+		if _, err := NewRunner(testGraph(), sim).Run(s); err == nil {
+			t.Fatalf("Expected error for event %v", event)
+		}
+		if len(sim.sent) != 0 || len(sim.stopped) != 0 || len(sim.links) != 0 {
+			t.Fatalf("Expected no events executed for %v", event)
+		}
+	}
+
+	// nodes added by scenario can be referenced by later events
+	s := &Scenario{Events: []Event{
+		{Action: ActionAddNode, ID: "new", Peers: []int{3}},
+		{At: time.Millisecond, Action: ActionSend, Node: 4},
+	}}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRunner(testGraph(), &mutableSimulator{}).Run(s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunnerMultipleSenders(t *testing.T) {
+	// two separate pairs, with gossip echoing message back to senders
+	g := graph.NewGraph()
+	for _, id := range []string{"0", "1", "2", "3"} {
+		g.AddNode(node(id))
+	}
+	g.AddLink("0", "1")
+	g.AddLink("2", "3")
+	s := &Scenario{
+		Events: []Event{
+			{At: 0, Action: ActionSend, Node: 0},
+			{At: 0, Action: ActionSend, Node: 2},
+		},
+	}
+
+	sim := gossip.NewSimulator(g, 4, 10*time.Millisecond, gossip.WithSeed(1))
+	defer sim.Stop()
+	plog, err := NewRunner(g, sim).Run(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan *stats.Stats)
+	go func() { done <- stats.Analyze(plog, g.NumNodes(), g.NumLinks()) }()
+	select {
+	case ss := <-done:
+		if ss.NodeCoverage.Actual != 4 {
+			t.Fatalf("Expected all 4 nodes covered, got %v", ss.NodeCoverage)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stats analysis of multiple senders log didn't finish")
+	}
+}
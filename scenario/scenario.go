@@ -0,0 +1,191 @@
+// Package scenario implements scripted simulations, described as a timeline
+// of events (message sending, node failures, network partitions) in a YAML file.
+//
+// Example scenario:
+//
+//	events:
+//	  - at: 0s
+//	    action: send
+//	    node: 0
+//	    ttl: 10
+//	    size: 400
+//	  - at: 3s
+//	    action: kill
+//	    node: 5
+//	  - at: 5s
+//	    action: partition
+//	    groups: [[0, 1, 2], [3, 4]]
+//	  - at: 6s
+//	    action: send
+//	    node: 3
+//	  - at: 7s
+//	    action: add-node
+//	    id: newcomer
+//	    peers: [3, 4]
+//	  - at: 8s
+//	    action: remove-link
+//	    node: 0
+//	    peers: [1]
+package scenario
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Supported event actions.
+const (
+	ActionSend      = "send"      // send message from Node
+	ActionKill      = "kill"      // stop Node
+	ActionPartition = "partition" // disconnect nodes between different Groups
+
+	ActionAddNode    = "add-node"    // add node with ID, linked to Peers
+	ActionRemoveNode = "remove-node" // remove Node with its links
+	ActionAddLink    = "add-link"    // add links between Node and Peers
+	ActionRemoveLink = "remove-link" // remove links between Node and Peers
+)
+
+// Defaults for message sending events.
+const (
+	DefaultTTL  = 10
+	DefaultSize = 400
+)
+
+// Scenario describes timeline of events to be executed during simulation.
+type Scenario struct {
+	Events []Event `yaml:"events"`
+}
+
+// Event describes single scenario action.
+type Event struct {
+	At     time.Duration `yaml:"at"` // offset from the scenario start
+	Action string        `yaml:"action"`
+	Node   int           `yaml:"node"`
+	TTL    int           `yaml:"ttl"`
+	Size   int           `yaml:"size"`
+	Groups [][]int       `yaml:"groups"`
+	ID     string        `yaml:"id"`    // node ID, for add-node
+	Peers  []int         `yaml:"peers"` // for add-node, add-link and remove-link
+}
+
+// String implements Stringer interface for Event.
+func (e Event) String() string {
+	switch e.Action {
+	case ActionPartition:
+		return fmt.Sprintf("%v: %s %v", e.At, e.Action, e.Groups)
+	case ActionAddNode:
+		return fmt.Sprintf("%v: %s %s %v", e.At, e.Action, e.ID, e.Peers)
+	case ActionAddLink, ActionRemoveLink:
+		return fmt.Sprintf("%v: %s node %d %v", e.At, e.Action, e.Node, e.Peers)
+	default:
+		return fmt.Sprintf("%v: %s node %d", e.At, e.Action, e.Node)
+	}
+}
+
+// Load reads scenario from YAML file.
+func Load(path string) (*Scenario, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse parses scenario from YAML data, validates it and
+// sorts events by time.
+func Parse(data []byte) (*Scenario, error) {
+	var s Scenario
+	if err := yaml.UnmarshalStrict(data, &s); err != nil {
+		return nil, fmt.Errorf("parse scenario: %v", err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Validate checks events for correctness, fills default values and sorts
+// events by time.
+func (s *Scenario) Validate() error {
+	for i := range s.Events {
+		e := &s.Events[i]
+		if e.At < 0 {
+			return fmt.Errorf("event %d: negative time %v", i, e.At)
+		}
+		switch e.Action {
+		case ActionSend:
+			if e.TTL == 0 {
+				e.TTL = DefaultTTL
+			}
+			if e.Size == 0 {
+				e.Size = DefaultSize
+			}
+		case ActionKill, ActionRemoveNode:
+		case ActionAddNode:
+			if e.ID == "" {
+				return fmt.Errorf("event %d: add-node requires node id", i)
+			}
+		case ActionAddLink, ActionRemoveLink:
+			if len(e.Peers) == 0 {
+				return fmt.Errorf("event %d: %s requires peers", i, e.Action)
+			}
+		case ActionPartition:
+			if len(e.Groups) < 2 {
+				return fmt.Errorf("event %d: partition requires at least two groups", i)
+			}
+		default:
+			return fmt.Errorf("event %d: unknown action '%s'", i, e.Action)
+		}
+	}
+	sort.SliceStable(s.Events, func(i, j int) bool {
+		return s.Events[i].At < s.Events[j].At
+	})
+	return nil
+}
+
+// CheckNodes checks node indices of validated events against the network
+// of the given number of nodes, accounting for nodes added by add-node
+// events before them.
+func (s *Scenario) CheckNodes(nodes int) error {
+	check := func(i, idx int) error {
+		if idx < 0 || idx >= nodes {
+			return fmt.Errorf("event %d: node %d not found, network has %d nodes", i, idx, nodes)
+		}
+		return nil
+	}
+	for i, e := range s.Events {
+		switch e.Action {
+		case ActionSend, ActionKill, ActionRemoveNode:
+			if err := check(i, e.Node); err != nil {
+				return err
+			}
+		case ActionAddLink, ActionRemoveLink:
+			if err := check(i, e.Node); err != nil {
+				return err
+			}
+			fallthrough
+		case ActionAddNode:
+			for _, peer := range e.Peers {
+				if err := check(i, peer); err != nil {
+					return err
+				}
+			}
+		case ActionPartition:
+			for _, group := range e.Groups {
+				for _, idx := range group {
+					if err := check(i, idx); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		if e.Action == ActionAddNode {
+			nodes++
+		}
+	}
+	return nil
+}
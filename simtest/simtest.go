@@ -0,0 +1,137 @@
+// Package simtest provides helpers for regression tests of propagation
+// protocols: tests run deterministic simulation and compare its log with
+// the golden one checked into the repository, within tolerances for
+// timing jitter, so protocol changes affecting propagation don't go
+// unnoticed:
+//
+//	func TestPropagation(t *testing.T) {
+//		sim := gossip.NewSimulator(network, 4, 10*time.Millisecond, gossip.WithSeed(1))
+//		plog := simtest.Run(t, sim, 0, 10, 400)
+//		simtest.Golden(t, plog, "testdata/gossip.json", simtest.DefaultTolerance())
+//	}
+//
+// Golden files are (re)written by running tests with -simtest.update flag.
+package simtest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/stats"
+)
+
+var update = flag.Bool("simtest.update", false, "Write golden propagation logs instead of comparing with them")
+
+// Tolerance describes allowed difference between the log and the golden
+// one, as simulators run in real time, and their timings jitter.
+type Tolerance struct {
+	Timing     time.Duration // of time-to-node of each node
+	Percentile time.Duration // of each time-to-node percentile, see stats.Compare
+	Unreached  int           // number of nodes reached in only one of logs
+}
+
+// DefaultTolerance returns tolerance suitable for gossip simulator with
+// default settings on a lightly loaded machine.
+func DefaultTolerance() Tolerance {
+	return Tolerance{
+		Timing:     20 * time.Millisecond,
+		Percentile: 10 * time.Millisecond,
+	}
+}
+
+// Run sends message from node with sim, and stops it. Simulator should be
+// deterministic, i.e. seeded, for its log to be comparable.
+func Run(t testing.TB, sim propagation.Simulator, node, ttl, size int) *propagation.Log {
+	t.Helper()
+	plog := sim.SendMessage(node, ttl, size)
+	if err := sim.Stop(); err != nil {
+		t.Fatalf("Failed to stop simulator: %v", err)
+	}
+	return plog
+}
+
+// Golden compares the log with the golden one in file at path, in format
+// guessed by its extension, and fails the test if they differ beyond
+// tolerance. With -simtest.update flag, it writes the log into the file
+// instead.
+func Golden(t testing.TB, plog *propagation.Log, path string, tol Tolerance) {
+	t.Helper()
+	format := propagation.FormatFromPath(path)
+	if *update {
+		if err := writeLog(plog, path, format); err != nil {
+			t.Fatalf("Failed to write golden log: %v", err)
+		}
+		t.Logf("Written golden log %s", path)
+		return
+	}
+
+	fd, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open golden log (run with -simtest.update to create it): %v", err)
+	}
+	defer fd.Close()
+	golden, err := propagation.DecodeLog(fd, format)
+	if err != nil {
+		t.Fatalf("Failed to read golden log %s: %v", path, err)
+	}
+	if diffs := Diff(golden, plog, tol); len(diffs) > 0 {
+		t.Errorf("Propagation differs from golden log %s:\n  %s", path, strings.Join(diffs, "\n  "))
+	}
+}
+
+// Diff returns differences between the log and the golden one beyond
+// tolerance, one line each, or nil if there are none.
+func Diff(golden, plog *propagation.Log, tol Tolerance) []string {
+	var diffs []string
+	cmp := stats.Compare(golden, plog)
+	for _, p := range cmp.Percentiles {
+		if abs(p.Delta()) > tol.Percentile {
+			diffs = append(diffs, fmt.Sprintf("p%v: %v, golden %v", p.Percentile*100, p.B, p.A))
+		}
+	}
+
+	var unreached []string
+	for _, diff := range cmp.NodeDiffs {
+		switch {
+		case diff.A < 0:
+			unreached = append(unreached, fmt.Sprintf("node %d: reached, but not in golden", diff.Node))
+		case diff.B < 0:
+			unreached = append(unreached, fmt.Sprintf("node %d: not reached", diff.Node))
+		case abs(diff.Delta()) > tol.Timing:
+			diffs = append(diffs, fmt.Sprintf("node %d: reached at %v, golden %v", diff.Node, diff.B, diff.A))
+		}
+	}
+	if len(unreached) > tol.Unreached {
+		diffs = append(diffs, unreached...)
+	}
+	return diffs
+}
+
+// writeLog writes the log into file at path, creating its directory.
+func writeLog(plog *propagation.Log, path, format string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	fd, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := plog.Encode(fd, format); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
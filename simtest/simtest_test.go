@@ -0,0 +1,59 @@
+package simtest
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// logSimulator returns the same log for every message.
+type logSimulator struct {
+	plog    *propagation.Log
+	stopped bool
+}
+
+func (s *logSimulator) SendMessage(idx, ttl, size int) *propagation.Log { return s.plog }
+func (s *logSimulator) Stop() error                                     { s.stopped = true; return nil }
+
+func newLog(second int) *propagation.Log {
+	plog := propagation.NewLog(2)
+	plog.AddStep(10, []int{0, 1}, []int{0})
+	plog.AddStep(second, []int{1, 2}, []int{1})
+	return plog
+}
+
+func TestDiff(t *testing.T) {
+	tol := Tolerance{Timing: 5 * time.Millisecond, Percentile: 5 * time.Millisecond}
+	if diffs := Diff(newLog(20), newLog(24), tol); diffs != nil {
+		t.Fatalf("Expected no differences within tolerance, got %v", diffs)
+	}
+	if diffs := Diff(newLog(20), newLog(30), tol); len(diffs) == 0 {
+		t.Fatal("Expected timing difference beyond tolerance")
+	}
+
+	short := propagation.NewLog(1)
+	short.AddStep(10, []int{0, 1}, []int{0})
+	if diffs := Diff(newLog(20), short, tol); len(diffs) == 0 {
+		t.Fatal("Expected unreached node difference")
+	}
+	tol.Unreached, tol.Percentile = 1, time.Second
+	if diffs := Diff(newLog(20), short, tol); diffs != nil {
+		t.Fatalf("Expected unreached node within tolerance, got %v", diffs)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "golden.json")
+	sim := &logSimulator{plog: newLog(20)}
+	plog := Run(t, sim, 0, 10, 400)
+	if !sim.stopped {
+		t.Fatal("Expected simulator to be stopped")
+	}
+
+	*update = true
+	Golden(t, plog, path, DefaultTolerance())
+	*update = false
+	Golden(t, newLog(25), path, DefaultTolerance())
+}
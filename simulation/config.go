@@ -0,0 +1,410 @@
+package simulation
+
+import (
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/propagation/antientropy"
+	"github.com/divan/simulation/propagation/bitswap"
+	"github.com/divan/simulation/propagation/core"
+	"github.com/divan/simulation/propagation/devp2p"
+	"github.com/divan/simulation/propagation/eth"
+	"github.com/divan/simulation/propagation/gossip"
+	"github.com/divan/simulation/propagation/randomwalk"
+	"github.com/divan/simulation/propagation/statusgo"
+	"github.com/divan/simulation/propagation/whisperv6"
+	"github.com/divan/simulation/stats"
+)
+
+// Config holds optional simulation parameters.
+type Config struct {
+	Progress   propagation.ProgressFunc // if nil, simulator's default is used
+	Events     propagation.EventFunc    // optional, called for each message sending
+	Observer   propagation.ObserverFunc // optional, called for each typed event
+	Middleware []propagation.Middleware // event processors chained before Observer, optional
+	Spam       *propagation.SpamParams  // nil if there is no background spam
+
+	Subscriptions propagation.Subscriptions // nil if messages have no topics
+	Topic         string                    // topic messages are published on
+	Relay         gossip.RelayPolicy
+
+	StopCoverage float64            // whisperv6 stops once that fraction of nodes is reached, if set
+	Quiescence   time.Duration      // whisperv6 stops after that long without events, if set
+	MaxDuration  time.Duration      // bounds propagation of each message, if set
+	Kills        []propagation.Kill // nodes stopped during Run, simulator should implement propagation.NodeStopper
+	ClockSkew    time.Duration      // whisperv6 max clock offset of nodes, uniform in [-ClockSkew, ClockSkew], if set
+	TimeScale    float64            // gossip simulation speedup, 1 for real time
+	Seed         int64              // clock skew and all simulators except whisperv6 and bitswap random source seed, random if 0
+
+	GossipMode        gossip.Mode
+	GossipScoring     *gossip.ScoreParams     // nil disables peer scoring
+	GossipChoking     *gossip.ChokeParams     // nil disables choking
+	Hybrid            *gossip.HybridParams    // nil if messages are pushed only
+	Handshake         *gossip.HandshakeParams // nil if connections are pre-established
+	Bandwidth         []gossip.BandwidthClass
+	Transports        []gossip.TransportClass
+	Queue             *gossip.QueueParams // nil disables queueing
+	Latency           func(from, to int) time.Duration
+	LinkLatencies     map[gossip.LinkIndex]time.Duration // per link, overrides Latency
+	LinkTypes         *gossip.LinkTypesParams            // nil if links are untyped
+	Join              *gossip.JoinParams                 // nil if all nodes joined from the start
+	NAT               *gossip.NATParams                  // nil if all nodes are public
+	Relayed           *gossip.RelayedParams              // nil if no links go through relays
+	Waku              *gossip.WakuParams                 // nil if all nodes are relay ones
+	DutyCycle         *gossip.DutyCycleParams            // nil if nodes are always online
+	Coding            *gossip.CodingParams               // nil if payload is not erasure-coded
+	Loss              float64                            // probability of losing each message
+	Retries           *gossip.RetryParams                // nil if lost messages are not retransmitted
+	RateLimit         float64                            // messages per second per peer, unlimited if 0
+	Directed          bool                               // treat links as directed
+	GossipMalicious   []int                              // indices of malicious nodes
+	ChunkSize         int                                // bitswap chunk size, default if 0
+	WantListSize      int                                // bitswap outstanding requests per peer, default if 0
+	GossipWithholding []int                              // indices of free-rider nodes, never relaying messages
+	EthBlocks         bool                               // eth simulator propagates blocks instead of transactions
+	Walkers           int                                // random walk copies per message, default if 0
+	SyncInterval      time.Duration                      // anti-entropy sync interval, default if 0
+	SyncPushPull      bool                               // anti-entropy nodes push messages peers lack as well
+
+	WhisperMessage *whisperv6.MessageParams   // nil for default whisperv6 messages
+	WhisperNodes   []whisperv6.NodeClass      // nil if all whisperv6 nodes use default config
+	WhisperLimit   *whisperv6.RateLimitParams // nil if whisperv6 peers aren't rate limited
+	WhisperCodes   []whisperv6.MessageCode    // devp2p packets tracked by whisperv6, default if nil
+	StatusGo       statusgo.Params            // statusgo services, Waku if protocol is empty
+	Sampling       *propagation.Sampling      // nil if whisperv6 records all events
+	Velocity       stats.VelocityParams       // velocity stats parameters, defaults if zero
+}
+
+// WhisperOptions converts config into whisperv6 simulator options.
+func (c Config) WhisperOptions() []whisperv6.Option {
+	var opts []whisperv6.Option
+	if c.Progress != nil {
+		opts = append(opts, whisperv6.WithProgress(c.Progress))
+	}
+	if c.Events != nil {
+		opts = append(opts, whisperv6.WithEvents(c.Events))
+	}
+	if observer := c.observer(); observer != nil {
+		opts = append(opts, whisperv6.WithObserver(observer))
+	}
+	if c.WhisperCodes != nil {
+		opts = append(opts, whisperv6.WithMessageCodes(c.WhisperCodes...))
+	}
+	if c.Spam != nil {
+		opts = append(opts, whisperv6.WithSpam(*c.Spam))
+	}
+	if c.Subscriptions != nil {
+		opts = append(opts, whisperv6.WithTopics(c.Subscriptions, c.Topic))
+	}
+	if c.StopCoverage > 0 {
+		opts = append(opts, whisperv6.WithStopOnCoverage(c.StopCoverage))
+	}
+	if c.Quiescence > 0 {
+		opts = append(opts, whisperv6.WithQuiescence(c.Quiescence))
+	}
+	if c.MaxDuration > 0 {
+		opts = append(opts, whisperv6.WithMaxDuration(c.MaxDuration))
+	}
+	if c.WhisperMessage != nil {
+		opts = append(opts, whisperv6.WithMessageParams(*c.WhisperMessage))
+	}
+	if len(c.WhisperNodes) > 0 {
+		opts = append(opts, whisperv6.WithNodeClasses(c.WhisperNodes...))
+	}
+	if c.WhisperLimit != nil {
+		opts = append(opts, whisperv6.WithRateLimit(*c.WhisperLimit))
+	}
+	if c.Sampling != nil {
+		opts = append(opts, whisperv6.WithSampling(*c.Sampling))
+	}
+	if c.ClockSkew > 0 {
+		seed := c.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		opts = append(opts, whisperv6.WithClockSkew(whisperv6.UniformClockSkew(c.ClockSkew, seed)))
+	}
+	return opts
+}
+
+// GossipOptions converts config into gossip simulator options.
+func (c Config) GossipOptions() []gossip.Option {
+	var opts []gossip.Option
+	// seed goes first, as other options draw random numbers
+	if c.Seed != 0 {
+		opts = append(opts, gossip.WithSeed(c.Seed))
+	}
+	opts = append(opts,
+		gossip.WithMode(c.GossipMode),
+		gossip.WithMaliciousNodes(c.GossipMalicious...),
+		gossip.WithWithholdingNodes(c.GossipWithholding...),
+	)
+	if c.GossipScoring != nil {
+		opts = append(opts, gossip.WithScoring(*c.GossipScoring))
+	}
+	if c.MaxDuration > 0 {
+		opts = append(opts, gossip.WithMaxDuration(c.MaxDuration))
+	}
+	if c.TimeScale > 0 && c.TimeScale != 1 {
+		opts = append(opts, gossip.WithTimeScale(c.TimeScale))
+	}
+	if len(c.Bandwidth) > 0 {
+		opts = append(opts, gossip.WithBandwidthClasses(c.Bandwidth...))
+	}
+	if len(c.Transports) > 0 {
+		opts = append(opts, gossip.WithTransportClasses(c.Transports...))
+	}
+	if c.Directed {
+		opts = append(opts, gossip.WithDirected())
+	}
+	if c.Join != nil {
+		opts = append(opts, gossip.WithJoinOrder(*c.Join))
+	}
+	if c.NAT != nil {
+		opts = append(opts, gossip.WithNAT(*c.NAT))
+	}
+	if c.Relayed != nil {
+		opts = append(opts, gossip.WithRelayedLinks(*c.Relayed))
+	}
+	if c.Waku != nil {
+		opts = append(opts, gossip.WithWaku(*c.Waku))
+	}
+	if c.Latency != nil {
+		opts = append(opts, gossip.WithLatency(c.Latency))
+	}
+	if c.LinkLatencies != nil {
+		opts = append(opts, gossip.WithLinkLatencies(c.LinkLatencies))
+	}
+	if c.Queue != nil {
+		opts = append(opts, gossip.WithQueueing(*c.Queue))
+	}
+	if c.DutyCycle != nil {
+		opts = append(opts, gossip.WithDutyCycle(*c.DutyCycle))
+	}
+	if c.GossipChoking != nil {
+		opts = append(opts, gossip.WithChoking(*c.GossipChoking))
+	}
+	if c.Hybrid != nil {
+		opts = append(opts, gossip.WithHybrid(*c.Hybrid))
+	}
+	if c.Handshake != nil {
+		opts = append(opts, gossip.WithHandshake(*c.Handshake))
+	}
+	if c.Progress != nil {
+		opts = append(opts, gossip.WithProgress(c.Progress))
+	}
+	if c.Events != nil {
+		opts = append(opts, gossip.WithEvents(c.Events))
+	}
+	if observer := c.observer(); observer != nil {
+		opts = append(opts, gossip.WithObserver(observer))
+	}
+	if c.Spam != nil {
+		opts = append(opts, gossip.WithSpam(*c.Spam))
+	}
+	if c.Subscriptions != nil {
+		opts = append(opts, gossip.WithTopics(gossip.TopicParams{
+			Subscriptions: c.Subscriptions,
+			Relay:         c.Relay,
+			Topic:         c.Topic,
+		}))
+	}
+	if c.Coding != nil {
+		opts = append(opts, gossip.WithErasureCoding(*c.Coding))
+	}
+	if c.Loss > 0 {
+		opts = append(opts, gossip.WithLoss(c.Loss))
+	}
+	if c.LinkTypes != nil {
+		opts = append(opts, gossip.WithLinkTypes(*c.LinkTypes))
+	}
+	if c.Retries != nil {
+		opts = append(opts, gossip.WithRetries(*c.Retries))
+	}
+	if c.RateLimit > 0 {
+		opts = append(opts, gossip.WithRateLimit(c.RateLimit))
+	}
+	return opts
+}
+
+// BitswapOptions converts config into bitswap simulator options.
+func (c Config) BitswapOptions() []bitswap.Option {
+	opts := []bitswap.Option{
+		bitswap.WithChunkSize(c.ChunkSize),
+		bitswap.WithWantListSize(c.WantListSize),
+	}
+	if c.Latency != nil {
+		opts = append(opts, bitswap.WithLatency(c.Latency))
+	}
+	if c.MaxDuration > 0 {
+		opts = append(opts, bitswap.WithMaxDuration(c.MaxDuration))
+	}
+	if c.Progress != nil {
+		opts = append(opts, bitswap.WithProgress(c.Progress))
+	}
+	if events := c.deliveryEvents(); events != nil {
+		opts = append(opts, bitswap.WithEvents(events))
+	}
+	return opts
+}
+
+// EthOptions converts config into eth simulator options.
+func (c Config) EthOptions() []eth.Option {
+	var opts []eth.Option
+	if c.Seed != 0 {
+		opts = append(opts, eth.WithSeed(c.Seed))
+	}
+	if c.EthBlocks {
+		opts = append(opts, eth.WithBlocks(eth.DefaultImportTime))
+	}
+	if c.Latency != nil {
+		opts = append(opts, eth.WithLatency(c.Latency))
+	}
+	if c.MaxDuration > 0 {
+		opts = append(opts, eth.WithMaxDuration(c.MaxDuration))
+	}
+	if c.Progress != nil {
+		opts = append(opts, eth.WithProgress(c.Progress))
+	}
+	if events := c.deliveryEvents(); events != nil {
+		opts = append(opts, eth.WithEvents(events))
+	}
+	return opts
+}
+
+// RandomWalkOptions converts config into random walk simulator options.
+func (c Config) RandomWalkOptions() []randomwalk.Option {
+	opts := []randomwalk.Option{
+		randomwalk.WithWalkers(c.Walkers),
+	}
+	if c.Seed != 0 {
+		opts = append(opts, randomwalk.WithSeed(c.Seed))
+	}
+	if c.Latency != nil {
+		opts = append(opts, randomwalk.WithLatency(c.Latency))
+	}
+	if c.MaxDuration > 0 {
+		opts = append(opts, randomwalk.WithMaxDuration(c.MaxDuration))
+	}
+	if c.Progress != nil {
+		opts = append(opts, randomwalk.WithProgress(c.Progress))
+	}
+	if events := c.deliveryEvents(); events != nil {
+		opts = append(opts, randomwalk.WithEvents(events))
+	}
+	return opts
+}
+
+// AntiEntropyOptions converts config into anti-entropy simulator options.
+func (c Config) AntiEntropyOptions() []antientropy.Option {
+	opts := []antientropy.Option{
+		antientropy.WithInterval(c.SyncInterval),
+	}
+	if c.Seed != 0 {
+		opts = append(opts, antientropy.WithSeed(c.Seed))
+	}
+	if c.SyncPushPull {
+		opts = append(opts, antientropy.WithPushPull())
+	}
+	if c.Latency != nil {
+		opts = append(opts, antientropy.WithLatency(c.Latency))
+	}
+	if c.MaxDuration > 0 {
+		opts = append(opts, antientropy.WithMaxDuration(c.MaxDuration))
+	}
+	if c.Progress != nil {
+		opts = append(opts, antientropy.WithProgress(c.Progress))
+	}
+	if events := c.deliveryEvents(); events != nil {
+		opts = append(opts, antientropy.WithEvents(events))
+	}
+	return opts
+}
+
+// CoreParams converts config into core params for the network. Core
+// relays message to 4 random peers, like gossip simulator, over links with
+// Latency or LinkLatencies, rounded to milliseconds.
+func (c Config) CoreParams(network *graph.Graph) core.Params {
+	params := core.Params{
+		Fanout: 4,
+		Loss:   c.Loss,
+		Seed:   c.Seed,
+	}
+	if params.Seed == 0 {
+		params.Seed = time.Now().UnixNano()
+	}
+	if c.Latency == nil && c.LinkLatencies == nil {
+		return params
+	}
+	for _, link := range network.Links() {
+		from, to := link.FromIdx(), link.ToIdx()
+		d, ok := c.LinkLatencies[gossip.LinkIndex{From: from, To: to}]
+		switch {
+		case ok:
+		case c.Latency != nil:
+			d = c.Latency(from, to)
+		default:
+			d = core.DefaultLatency * time.Millisecond
+		}
+		params.Latency = append(params.Latency, int(d/time.Millisecond))
+	}
+	return params
+}
+
+// StatusGoParams returns params of statusgo services, with Waku protocol
+// by default, and peers rate limited by WhisperLimit, unless set.
+func (c Config) StatusGoParams() statusgo.Params {
+	params := c.StatusGo
+	if params.Protocol == "" {
+		params.Protocol = statusgo.Waku
+	}
+	if params.RateLimit == 0 && c.WhisperLimit != nil {
+		params.RateLimit = c.WhisperLimit.Limit
+	}
+	return params
+}
+
+// Devp2pOptions converts config into devp2p simulator options.
+func (c Config) Devp2pOptions() []devp2p.Option {
+	var opts []devp2p.Option
+	if c.Progress != nil {
+		opts = append(opts, devp2p.WithProgress(c.Progress))
+	}
+	if c.Events != nil {
+		opts = append(opts, devp2p.WithEvents(c.Events))
+	}
+	if observer := c.observer(); observer != nil {
+		opts = append(opts, devp2p.WithObserver(observer))
+	}
+	if c.Quiescence > 0 {
+		opts = append(opts, devp2p.WithQuiescence(c.Quiescence))
+	}
+	return opts
+}
+
+// observer returns Observer with Middleware chained before it, or nil
+// if neither is set.
+func (c Config) observer() propagation.ObserverFunc {
+	if c.Observer == nil && len(c.Middleware) == 0 {
+		return nil
+	}
+	return propagation.Chain(c.Observer, c.Middleware...)
+}
+
+// deliveryEvents returns Events function for simulators reporting
+// deliveries only, which reports them to observer as well, if set.
+func (c Config) deliveryEvents() propagation.EventFunc {
+	observer := c.observer()
+	if observer == nil {
+		return c.Events
+	}
+	observe := propagation.ReceiveEvents(observer)
+	if c.Events == nil {
+		return observe
+	}
+	return func(entry propagation.LogEntry) {
+		c.Events(entry)
+		observe(entry)
+	}
+}
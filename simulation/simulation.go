@@ -0,0 +1,282 @@
+// Package simulation implements the full simulation pipeline: it runs
+// propagation algorithm on the network graph, and analyzes resulting
+// propagation log. It's used by propagation_simulator, and can be
+// embedded into other programs:
+//
+//	res, err := simulation.Run(r, "gossip", simulation.Config{}, 10, 400)
+//	if err != nil {
+//		return err
+//	}
+//	res.Stats.FprintVerbose(os.Stdout)
+//	return res.Log.Encode(w, "json")
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/preflight"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/propagation/antientropy"
+	"github.com/divan/simulation/propagation/bitswap"
+	"github.com/divan/simulation/propagation/core"
+	"github.com/divan/simulation/propagation/eth"
+	"github.com/divan/simulation/propagation/gossip"
+	"github.com/divan/simulation/propagation/randomwalk"
+	"github.com/divan/simulation/propagation/statusgo"
+	"github.com/divan/simulation/propagation/whisperv6"
+	"github.com/divan/simulation/stats"
+)
+
+// Algorithms lists known propagation algorithms.
+var Algorithms = []string{"whisperv6", "gossip", "bitswap", "eth", "randomwalk", "antientropy", "statusgo", "core"}
+
+// ErrNotSupported is returned when simulator doesn't support requested operation.
+var ErrNotSupported = errors.New("not supported by simulator")
+
+// Simulation represents single simulation of the propagation algorithm
+// on the network.
+type Simulation struct {
+	network *graph.Graph
+	sim     propagation.Simulator
+	cfg     Config
+}
+
+// Results holds propagation log of the simulation and its stats.
+type Results struct {
+	Log   *propagation.Log
+	Logs  map[string]*propagation.Log // per message logs, with multiple senders
+	Stats *stats.Stats
+}
+
+// NewSimulation creates Simulation of the algorithm (see Algorithms) for
+// the given network.
+func NewSimulation(algo string, network *graph.Graph, cfg Config) (*Simulation, error) {
+	var sim propagation.Simulator
+	switch algo {
+	case "whisperv6":
+		sim = whisperv6.NewSimulator(network, cfg.WhisperOptions()...)
+	case "bitswap":
+		sim = bitswap.NewSimulator(network, cfg.BitswapOptions()...)
+	case "eth":
+		sim = eth.NewSimulator(network, cfg.EthOptions()...)
+	case "randomwalk":
+		sim = randomwalk.NewSimulator(network, cfg.RandomWalkOptions()...)
+	case "antientropy":
+		sim = antientropy.NewSimulator(network, cfg.AntiEntropyOptions()...)
+	case "gossip":
+		sim = gossip.NewSimulator(network, 4, 10, cfg.GossipOptions()...)
+	case "statusgo":
+		s, err := statusgo.NewSimulator(network, cfg.StatusGoParams(), cfg.Devp2pOptions()...)
+		if err != nil {
+			return nil, fmt.Errorf("create statusgo simulator: %v", err)
+		}
+		sim = s
+	case "core":
+		s, err := core.NewSimulator(network, cfg.CoreParams(network))
+		if err != nil {
+			return nil, fmt.Errorf("create core simulator: %v", err)
+		}
+		sim = s
+	default:
+		return nil, fmt.Errorf("unknown algorithm '%s'", algo)
+	}
+	if _, ok := sim.(propagation.NodeStopper); len(cfg.Kills) > 0 && !ok {
+		return nil, fmt.Errorf("algorithm '%s' doesn't support killing nodes", algo)
+	}
+	for _, kill := range cfg.Kills {
+		if _, ok := sim.(propagation.NodeRestarter); kill.Recover > 0 && !ok {
+			return nil, fmt.Errorf("algorithm '%s' doesn't support restarting nodes", algo)
+		}
+	}
+
+	return &Simulation{
+		network: network,
+		sim:     sim,
+		cfg:     cfg,
+	}, nil
+}
+
+// NewWhisperSimulationWithSnapshot creates whisperv6 Simulation for the given
+// network, reusing network snapshot from file if it exists, or creating it otherwise.
+func NewWhisperSimulationWithSnapshot(network *graph.Graph, path string, cfg Config) (*Simulation, error) {
+	var sim *whisperv6.Simulator
+	if _, err := os.Stat(path); err == nil {
+		sim, err = whisperv6.NewSimulatorFromSnapshot(network, path, cfg.WhisperOptions()...)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Loaded network snapshot", "file", path)
+	} else {
+		sim = whisperv6.NewSimulator(network, cfg.WhisperOptions()...)
+		if err := sim.SaveSnapshot(path); err != nil {
+			return nil, err
+		}
+		slog.Info("Saved network snapshot", "file", path)
+	}
+
+	return &Simulation{
+		network: network,
+		sim:     sim,
+		cfg:     cfg,
+	}, nil
+}
+
+// Simulator returns underlying propagation simulator.
+func (s *Simulation) Simulator() propagation.Simulator {
+	return s.sim
+}
+
+// Run sends message from node 0, or multiple messages concurrently from
+// the given senders, and analyzes their propagation. Propagation log of
+// multiple messages combines logs of all of them, shifted by their offsets.
+// Nodes are killed during the run, if configured (see Config.Kills).
+func (s *Simulation) Run(ttl, size int, sends ...propagation.Send) *Results {
+	res := &Results{}
+	var cancelKills func() []propagation.Kill
+	if stopper, ok := s.sim.(propagation.NodeStopper); ok && len(s.cfg.Kills) > 0 {
+		cancelKills = propagation.ScheduleKills(stopper, s.cfg.Kills)
+	}
+	senders := []int{0}
+	if len(sends) > 0 {
+		res.Logs = propagation.SendMessages(s.sim, sends, ttl, size)
+		res.Log = propagation.MergeSends(sends, res.Logs)
+		senders = senders[:0]
+		for _, send := range sends {
+			senders = append(senders, send.Node)
+		}
+	} else {
+		res.Log = s.sim.SendMessage(0, ttl, size)
+	}
+	res.Stats = s.analyze(res.Log, senders...)
+	if cancelKills != nil {
+		// kills scheduled after propagation is over don't affect it
+		res.Stats.Failures = stats.AnalyzeFailures(res.Log, s.network.NumNodes(), cancelKills())
+	}
+	return res
+}
+
+// RunDirect sends single message from node to the recipient node (see
+// propagation.DirectSender), and analyzes its propagation and delivery
+// to the recipient.
+func (s *Simulation) RunDirect(from, to, ttl, size int) (*Results, error) {
+	ds, ok := s.sim.(propagation.DirectSender)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	res := &Results{Log: ds.SendDirectMessage(from, to, ttl, size)}
+	res.Stats = s.analyze(res.Log, from)
+	res.Stats.Delivery = stats.AnalyzeDelivery(res.Log, to)
+	return res, nil
+}
+
+// analyze analyzes propagation log of messages sent by senders.
+func (s *Simulation) analyze(plog *propagation.Log, senders ...int) *stats.Stats {
+	params := s.cfg.Velocity
+	if params.Window == 0 {
+		params = stats.DefaultVelocityParams()
+	}
+	nodes, links := s.network.NumNodes(), s.network.NumLinks()
+	ss := stats.Analyze(plog, nodes, links)
+	ss.Velocity = stats.AnalyzeVelocity(plog, nodes, params)
+	ss.Reachability = stats.AnalyzeReachability(plog, s.network, s.cfg.Directed, senders...)
+	if s.cfg.Subscriptions != nil {
+		ss.Topic = stats.AnalyzeTopic(plog, s.cfg.Subscriptions, s.cfg.Topic)
+	}
+	if g, ok := s.sim.(*gossip.Simulator); ok {
+		if roles := g.Roles(); roles != nil {
+			names := make([]string, len(roles))
+			for i, r := range roles {
+				names[i] = r.String()
+			}
+			ss.Roles = stats.AnalyzeGroups(plog, names)
+		}
+		if names := g.TransportNames(); names != nil {
+			ss.Transports = stats.AnalyzeGroups(plog, names)
+		}
+	}
+	return ss
+}
+
+// Stop stops simulation and shuts down network.
+func (s *Simulation) Stop() error {
+	return s.sim.Stop()
+}
+
+// SaveCheckpoint writes simulator state into w, if simulator supports it.
+func (s *Simulation) SaveCheckpoint(w io.Writer) error {
+	c, ok := s.sim.(propagation.Checkpointer)
+	if !ok {
+		return ErrNotSupported
+	}
+	return c.Checkpoint(w)
+}
+
+// RestoreCheckpoint restores simulator state from r, if simulator supports it.
+func (s *Simulation) RestoreCheckpoint(r io.Reader) error {
+	c, ok := s.sim.(propagation.Checkpointer)
+	if !ok {
+		return ErrNotSupported
+	}
+	return c.Restore(r)
+}
+
+// UpdateTopology applies links of the network graph to the running
+// network, if simulator supports it (see propagation.TopologyUpdater),
+// and analyzes following runs with the updated graph.
+func (s *Simulation) UpdateTopology(network *graph.Graph) error {
+	u, ok := s.sim.(propagation.TopologyUpdater)
+	if !ok {
+		return ErrNotSupported
+	}
+	if err := u.UpdateTopology(network); err != nil {
+		return err
+	}
+	s.network = network
+	return nil
+}
+
+// Pause pauses simulation, if simulator supports it.
+func (s *Simulation) Pause() error {
+	p, ok := s.sim.(propagation.Pauser)
+	if !ok {
+		return ErrNotSupported
+	}
+	p.Pause()
+	return nil
+}
+
+// Resume resumes paused simulation, if simulator supports it.
+func (s *Simulation) Resume() error {
+	p, ok := s.sim.(propagation.Pauser)
+	if !ok {
+		return ErrNotSupported
+	}
+	p.Resume()
+	return nil
+}
+
+// Run runs the whole pipeline: reads network graph in D3 JSON format from
+// r, checks it (see preflight.Check), simulates propagation of the message
+// sent from node 0 with the given algorithm, and analyzes it.
+func Run(r io.Reader, algo string, cfg Config, ttl, size int) (*Results, error) {
+	network, err := formats.FromD3JSONReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("read network: %v", err)
+	}
+	if report := preflight.Check(network, cfg.Directed); !report.OK() {
+		return nil, fmt.Errorf("network graph check failed:\n%s", report)
+	}
+
+	sim, err := NewSimulation(algo, network, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer sim.Stop()
+	return sim.Run(ttl, size), nil
+}
@@ -0,0 +1,266 @@
+package simulation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/divan/graphx/formats"
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/propagation/gossip"
+	"github.com/divan/simulation/stats"
+)
+
+const testNetwork = `{
+  "nodes": [{"id": "0"}, {"id": "1"}, {"id": "2"}, {"id": "3"}],
+  "links": [
+    {"source": "0", "target": "1"},
+    {"source": "1", "target": "2"},
+    {"source": "2", "target": "3"}
+  ]
+}`
+
+func TestRun(t *testing.T) {
+	res, err := Run(strings.NewReader(testNetwork), "gossip", Config{}, 10, 400)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Stats.NodeCoverage.Actual; got != 4 {
+		t.Fatalf("Expected all 4 nodes covered, got %d", got)
+	}
+	if res.Stats.Reachability == nil {
+		t.Fatal("Expected reachability stats")
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	if _, err := Run(strings.NewReader(testNetwork), "unknown", Config{}, 10, 400); err == nil {
+		t.Fatal("Expected error for unknown algorithm")
+	}
+	disconnected := `{"nodes": [{"id": "0"}, {"id": "1"}, {"id": "2"}], "links": [{"source": "0", "target": "1"}]}`
+	if _, err := Run(strings.NewReader(disconnected), "gossip", Config{}, 10, 400); err == nil {
+		t.Fatal("Expected error for disconnected network")
+	}
+}
+
+func TestRunSends(t *testing.T) {
+	sim, err := NewSimulation("gossip", testGraph(t), Config{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Stop()
+
+	sends := []propagation.Send{{ID: "a", Node: 0}, {ID: "b", Node: 3}}
+	res := sim.Run(10, 400, sends...)
+	if len(res.Logs) != len(sends) {
+		t.Fatalf("Expected %d per message logs, got %d", len(sends), len(res.Logs))
+	}
+	if got := res.Stats.NodeCoverage.Actual; got != 4 {
+		t.Fatalf("Expected all 4 nodes covered, got %d", got)
+	}
+}
+
+func TestRunDirectNotSupported(t *testing.T) {
+	sim, err := NewSimulation("gossip", testGraph(t), Config{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Stop()
+
+	if _, err := sim.RunDirect(0, 3, 10, 400); err != ErrNotSupported {
+		t.Fatalf("Expected ErrNotSupported for gossip, got %v", err)
+	}
+}
+
+func TestRunCore(t *testing.T) {
+	sim, err := NewSimulation("core", testGraph(t), Config{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Stop()
+
+	res := sim.Run(10, 400)
+	if got := res.Stats.NodeCoverage.Actual; got != 4 {
+		t.Fatalf("Expected all 4 nodes covered, got %d", got)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	sim, err := NewSimulation("gossip", testGraph(t), Config{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Stop()
+
+	if err := sim.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan *Results)
+	go func() {
+		done <- sim.Run(10, 400)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Expected paused simulation not to finish")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := sim.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case res := <-done:
+		if got := res.Stats.NodeCoverage.Actual; got != 4 {
+			t.Fatalf("Expected all 4 nodes covered, got %d", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected resumed simulation to finish")
+	}
+}
+
+func TestRunWaku(t *testing.T) {
+	full := `{
+  "nodes": [{"id": "0"}, {"id": "1"}, {"id": "2"}, {"id": "3"}],
+  "links": [
+    {"source": "0", "target": "1"}, {"source": "0", "target": "2"}, {"source": "0", "target": "3"},
+    {"source": "1", "target": "2"}, {"source": "1", "target": "3"}, {"source": "2", "target": "3"}
+  ]
+}`
+	data, err := formats.FromD3JSONReader(strings.NewReader(full))
+	if err != nil {
+		t.Fatal(err)
+	}
+	classes, err := gossip.ParseRoleClasses("relay+filter+lightpush=0.5,light=0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim, err := NewSimulation("gossip", data, Config{Seed: 1, Waku: &gossip.WakuParams{Classes: classes}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Stop()
+
+	res := sim.Run(10, 400)
+	if got := res.Stats.NodeCoverage.Actual; got != 4 {
+		t.Fatalf("Expected all 4 nodes covered through service nodes, got %d", got)
+	}
+	if len(res.Stats.Roles) != 2 {
+		t.Fatalf("Expected stats for 2 role groups, got %v", res.Stats.Roles)
+	}
+	for _, g := range res.Stats.Roles {
+		if g.Coverage != stats.NewCoverage(2, 2) {
+			t.Fatalf("Expected both nodes of '%s' group covered, got %v", g.Name, g.Coverage)
+		}
+	}
+}
+
+func TestRunHybrid(t *testing.T) {
+	hybrid := &gossip.HybridParams{PushRounds: 1, Interval: 50 * time.Millisecond, Fanout: 2}
+	sim, err := NewSimulation("gossip", testGraph(t), Config{Seed: 1, Hybrid: hybrid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Stop()
+
+	res := sim.Run(10, 400)
+	if got := res.Stats.NodeCoverage.Actual; got != 4 {
+		t.Fatalf("Expected all 4 nodes covered by pulls, got %d", got)
+	}
+	if res.Stats.Traffic == nil || res.Stats.Traffic.ControlMessages == 0 {
+		t.Fatal("Expected pull requests counted as control traffic")
+	}
+}
+
+func TestRunMeshChurn(t *testing.T) {
+	params := gossip.DefaultScoreParams()
+	params.Heartbeat = 10 * time.Millisecond
+	params.Churn = 0.5
+	sim, err := NewSimulation("gossip", testGraph(t), Config{Seed: 1, GossipScoring: &params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Stop()
+
+	res := sim.Run(10, 400)
+	mesh := res.Stats.Mesh
+	if mesh == nil {
+		t.Fatal("Expected mesh stats with scoring")
+	}
+	if got := res.Stats.NodeCoverage.Actual; got != 4 {
+		t.Fatalf("Expected all 4 nodes covered despite churn, got %d", got)
+	}
+	if got := mesh.MeshDeliveries + mesh.GossipDeliveries; got != 3 {
+		t.Fatalf("Expected 3 first deliveries, got %d", got)
+	}
+}
+
+func TestRunHandshake(t *testing.T) {
+	handshake := &gossip.HandshakeParams{RoundTrips: 2, Bytes: 400, RTT: 25 * time.Millisecond}
+	sim, err := NewSimulation("gossip", testGraph(t), Config{Seed: 1, Handshake: handshake})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Stop()
+
+	res := sim.Run(10, 400)
+	if got := res.Stats.NodeCoverage.Actual; got != 4 {
+		t.Fatalf("Expected all 4 nodes covered, got %d", got)
+	}
+	// each of 3 hops waits for 2 round trips of its link
+	if res.Stats.Time < 150*time.Millisecond {
+		t.Fatalf("Expected handshakes to take at least 150ms, got %v", res.Stats.Time)
+	}
+	if res.Stats.Traffic == nil || res.Stats.Traffic.ControlBytes != 3*400 {
+		t.Fatalf("Expected handshakes of 3 links counted as control traffic, got %v", res.Stats.Traffic)
+	}
+}
+
+func TestRunTransports(t *testing.T) {
+	classes, err := gossip.ParseTransportClasses("tcp=0.5,websocket=0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim, err := NewSimulation("gossip", testGraph(t), Config{Seed: 1, Transports: classes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Stop()
+
+	res := sim.Run(10, 400)
+	if got := res.Stats.NodeCoverage.Actual; got != 4 {
+		t.Fatalf("Expected all 4 nodes covered, got %d", got)
+	}
+	if len(res.Stats.Transports) != 2 {
+		t.Fatalf("Expected stats for 2 transports, got %v", res.Stats.Transports)
+	}
+	// every message carries framing of its transport
+	if res.Stats.Traffic.PayloadBytes <= res.Stats.Traffic.PayloadMessages*400 {
+		t.Fatalf("Expected transport overhead in traffic, got %v", res.Stats.Traffic)
+	}
+}
+
+func TestRunRelayedLinks(t *testing.T) {
+	relayed := &gossip.RelayedParams{Links: map[gossip.LinkIndex]int{{From: 1, To: 2}: 0}}
+	sim, err := NewSimulation("gossip", testGraph(t), Config{Seed: 1, Relayed: relayed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Stop()
+
+	res := sim.Run(10, 400)
+	if got := res.Stats.NodeCoverage.Actual; got != 4 {
+		t.Fatalf("Expected all 4 nodes covered, got %d", got)
+	}
+	// 6 transmissions over 3 links, with 1-2 ones taking two hops
+	if got := res.Stats.Traffic.PayloadMessages; got != 8 {
+		t.Fatalf("Expected 8 payload transmissions, got %d", got)
+	}
+}
+
+func testGraph(t *testing.T) *graph.Graph {
+	data, err := formats.FromD3JSONReader(strings.NewReader(testNetwork))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,47 @@
+package sink
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka publishes events into Kafka topic.
+type Kafka struct {
+	w *kafka.Writer
+}
+
+// NewKafka creates Kafka writer for the given brokers. Writes are
+// asynchronous and batched, so publishing doesn't block simulation.
+func NewKafka(brokers []string, topic string) (*Kafka, error) {
+	w := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		Async:        true,
+		BatchTimeout: 50 * time.Millisecond,
+		Completion: func(messages []kafka.Message, err error) {
+			if err != nil {
+				slog.Warn("Writing events to Kafka failed", "count", len(messages), "err", err)
+			}
+		},
+	}
+	return &Kafka{w: w}, nil
+}
+
+// Publish implements Sink. Events are keyed by the receiving node,
+// so events for the same node are kept in order within partition.
+func (k *Kafka) Publish(e Event) error {
+	return k.w.WriteMessages(context.Background(), kafka.Message{
+		Key:   []byte(strconv.Itoa(e.To)),
+		Value: marshal(e),
+	})
+}
+
+// Close implements Sink. It flushes pending events.
+func (k *Kafka) Close() error {
+	return k.w.Close()
+}
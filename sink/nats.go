@@ -0,0 +1,37 @@
+package sink
+
+import (
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATS publishes events into NATS subject.
+type NATS struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// NewNATS connects to NATS server.
+func NewNATS(url, subject string) (*NATS, error) {
+	conn, err := nats.Connect(url, nats.Name("propagation_simulator"))
+	if err != nil {
+		return nil, fmt.Errorf("connect to NATS: %v", err)
+	}
+	return &NATS{
+		conn:    conn,
+		subject: subject,
+	}, nil
+}
+
+// Publish implements Sink. Publishing is buffered by the client,
+// so it doesn't block simulation.
+func (n *NATS) Publish(e Event) error {
+	return n.conn.Publish(n.subject, marshal(e))
+}
+
+// Close implements Sink. It flushes pending events before closing connection.
+func (n *NATS) Close() error {
+	defer n.conn.Close()
+	return n.conn.Flush()
+}
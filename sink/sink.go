@@ -0,0 +1,83 @@
+// Package sink implements pluggable event sinks, publishing propagation
+// events to message brokers while simulation is running, so they can be
+// consumed by downstream pipelines (stream processing, dashboards).
+//
+// Sinks are configured with URL, where scheme defines broker type and
+// path defines topic or subject:
+//
+//	nats://localhost:4222/propagation
+//	kafka://localhost:9092/propagation
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// Sink publishes propagation events.
+type Sink interface {
+	Publish(e Event) error
+	Close() error
+}
+
+// Event represents propagation event published to sinks.
+type Event struct {
+	Run  string `json:"run,omitempty"` // optional run identifier
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Ts   int64  `json:"ts"`            // milliseconds since message sending start
+	Msg  string `json:"msg,omitempty"` // optional message identifier
+}
+
+// New creates sink out of URL. Supported schemes are "nats" and "kafka".
+func New(rawurl string) (Sink, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf("parse sink URL: %v", err)
+	}
+	topic := strings.Trim(u.Path, "/")
+	if topic == "" {
+		return nil, fmt.Errorf("sink URL '%s' has no topic", rawurl)
+	}
+
+	switch u.Scheme {
+	case "nats":
+		return NewNATS("nats://"+u.Host, topic)
+	case "kafka":
+		return NewKafka(strings.Split(u.Host, ","), topic)
+	default:
+		return nil, fmt.Errorf("unsupported sink '%s'", u.Scheme)
+	}
+}
+
+// EventFunc returns propagation.EventFunc publishing events to sink,
+// tagged with run identifier. Publishing errors are logged once,
+// so they don't flood the output on large simulations.
+func EventFunc(s Sink, run string) propagation.EventFunc {
+	var once sync.Once
+	return func(e propagation.LogEntry) {
+		err := s.Publish(Event{
+			Run:  run,
+			From: e.From,
+			To:   e.To,
+			Ts:   e.Ts,
+			Msg:  e.Msg,
+		})
+		if err != nil {
+			once.Do(func() {
+				slog.Warn("Publishing event failed, further errors are suppressed", "err", err)
+			})
+		}
+	}
+}
+
+func marshal(e Event) []byte {
+	data, _ := json.Marshal(e) // can't fail for Event
+	return data
+}
@@ -0,0 +1,62 @@
+package sink
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/divan/simulation/propagation"
+)
+
+type fakeSink struct {
+	events []Event
+	err    error
+}
+
+func (f *fakeSink) Publish(e Event) error {
+	f.events = append(f.events, e)
+	return f.err
+}
+
+func (f *fakeSink) Close() error { return nil }
+
+func TestEventFunc(t *testing.T) {
+	s := &fakeSink{}
+	fn := EventFunc(s, "run1")
+	fn(propagation.LogEntry{From: 1, To: 2, Ts: 30})
+	fn(propagation.LogEntry{From: 2, To: 3, Ts: 40})
+
+	expected := []Event{
+		{Run: "run1", From: 1, To: 2, Ts: 30},
+		{Run: "run1", From: 2, To: 3, Ts: 40},
+	}
+	if len(s.events) != len(expected) {
+		t.Fatalf("expected %d events, got %d", len(expected), len(s.events))
+	}
+	for i := range expected {
+		if s.events[i] != expected[i] {
+			t.Fatalf("event %d: expected %v, got %v", i, expected[i], s.events[i])
+		}
+	}
+
+	// errors should not stop publishing
+	s.err = errors.New("broker is down")
+	fn(propagation.LogEntry{From: 3, To: 4, Ts: 50})
+	fn(propagation.LogEntry{From: 4, To: 5, Ts: 60})
+	if len(s.events) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(s.events))
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	var tests = []string{
+		"nats://localhost:4222",
+		"nats://localhost:4222/",
+		"amqp://localhost/propagation",
+		"://bad",
+	}
+	for _, url := range tests {
+		if _, err := New(url); err == nil {
+			t.Fatalf("%s: expected error", url)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// NetworkStats describes delivery of the message to nodes of a single
+// network joined with others by bridge nodes.
+type NetworkStats struct {
+	GroupStats
+	First time.Duration // time to reach the first node of the network
+	Delay time.Duration // median delay relative to the home network
+}
+
+// BridgeStats describes cross-network propagation of the message between
+// networks joined by bridge nodes.
+type BridgeStats struct {
+	Home     string // network of the sender
+	Bridges  int    // number of bridge nodes
+	Networks []NetworkStats
+}
+
+// AnalyzeBridges analyzes propagation of the message sent from the home
+// network into other networks. Networks are given as network names ordered
+// by node index, and bridge nodes, belonging to no network, are flagged
+// in bridges. Networks are sorted by name, with home network first.
+func AnalyzeBridges(plog *propagation.Log, networks []string, bridges []bool, home string) *BridgeStats {
+	members := make([]string, len(networks))
+	ret := &BridgeStats{Home: home}
+	for i, name := range networks {
+		if i < len(bridges) && bridges[i] {
+			ret.Bridges++
+			continue
+		}
+		members[i] = name
+	}
+
+	reached := timeToNode(plog)
+	first := make(map[string]int)
+	for node, name := range members {
+		if ts, ok := reached[node]; ok && name != "" {
+			if f, ok := first[name]; !ok || ts < f {
+				first[name] = ts
+			}
+		}
+	}
+
+	var homeMedian time.Duration
+	for _, g := range AnalyzeGroups(plog, members) {
+		ns := NetworkStats{GroupStats: g}
+		if ts, ok := first[g.Name]; ok {
+			ns.First = msToDuration(ts)
+		}
+		if g.Name == home {
+			homeMedian = g.Median
+		}
+		ret.Networks = append(ret.Networks, ns)
+	}
+	for i := range ret.Networks {
+		ret.Networks[i].Delay = ret.Networks[i].Median - homeMedian
+	}
+	sort.SliceStable(ret.Networks, func(i, j int) bool {
+		return ret.Networks[i].Name == home && ret.Networks[j].Name != home
+	})
+	return ret
+}
+
+// String implements Stringer interface for NetworkStats.
+func (n NetworkStats) String() string {
+	return fmt.Sprintf("%s: coverage %v, first %v, median %v, last %v, delay %v", n.Name, n.Coverage, n.First, n.Median, n.Last, n.Delay)
+}
+
+// String implements Stringer interface for BridgeStats.
+func (b *BridgeStats) String() string {
+	lines := []string{fmt.Sprintf("home network %s, %d bridge nodes", b.Home, b.Bridges)}
+	for _, n := range b.Networks {
+		lines = append(lines, "  "+n.String())
+	}
+	return strings.Join(lines, "\n")
+}
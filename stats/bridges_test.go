@@ -0,0 +1,29 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestAnalyzeBridges(t *testing.T) {
+	plog := propagation.NewLog(3)
+	plog.AddStep(10, []int{0, 1}, []int{0})
+	plog.AddStep(20, []int{2}, []int{1})
+	plog.AddStep(50, []int{3}, []int{2})
+	networks := []string{"a", "a", "", "b", "b"}
+	bridges := []bool{false, false, true, false, false}
+
+	b := AnalyzeBridges(plog, networks, bridges, "a")
+	if b.Bridges != 1 || len(b.Networks) != 2 {
+		t.Fatalf("Expected 1 bridge and 2 networks, got %v", b)
+	}
+	a, other := b.Networks[0], b.Networks[1]
+	if a.Name != "a" || a.Coverage != NewCoverage(2, 2) || a.Delay != 0 {
+		t.Fatalf("Unexpected stats for home network: %v", a)
+	}
+	if other.Name != "b" || other.Coverage != NewCoverage(1, 2) || other.First != 50*time.Millisecond || other.Delay != 40*time.Millisecond {
+		t.Fatalf("Unexpected stats for network 'b': %v", other)
+	}
+}
@@ -0,0 +1,159 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/community"
+	"github.com/divan/simulation/propagation"
+)
+
+// maxBridges is the number of the most used bridges printed in stats.
+const maxBridges = 10
+
+// CommunityStats describes propagation of the message across communities
+// of the graph, see community.Detect.
+type CommunityStats struct {
+	Modularity  float64
+	Communities []Community
+	Delays      []CommunityDelay // between communities linked by bridges
+	Bridges     []Bridge         // most used first
+}
+
+// Community describes delivery of the message to nodes of a single
+// community.
+type Community struct {
+	Size     int
+	Coverage Coverage
+	First    time.Duration // time message entered community, if reached
+	Last     time.Duration // time the last reached node got message
+}
+
+// CommunityDelay is time between message entering one community and
+// entering the other one, linked to the former by bridges.
+type CommunityDelay struct {
+	From, To int
+	Delay    time.Duration
+}
+
+// Bridge is the link between nodes of different communities.
+type Bridge struct {
+	Link     int // link index
+	From, To int // communities
+	Hits     int // times message was sent over the link
+	First    time.Duration
+}
+
+// AnalyzeCommunities calculates propagation stats across given communities
+// of the graph nodes, ordered by node index.
+func AnalyzeCommunities(plog *propagation.Log, g *graph.Graph, communities []int) *CommunityStats {
+	n := 0
+	for _, c := range communities {
+		if c+1 > n {
+			n = c + 1
+		}
+	}
+	cs := &CommunityStats{
+		Modularity:  community.Modularity(g, communities),
+		Communities: make([]Community, n),
+	}
+
+	reached := timeToNode(plog)
+	actual := make([]int, n)
+	for i, c := range communities {
+		cs.Communities[c].Size++
+		ts, ok := reached[i]
+		if !ok {
+			continue
+		}
+		d := msToDuration(ts)
+		comm := &cs.Communities[c]
+		if actual[c] == 0 || d < comm.First {
+			comm.First = d
+		}
+		if d > comm.Last {
+			comm.Last = d
+		}
+		actual[c]++
+	}
+	for c := range cs.Communities {
+		cs.Communities[c].Coverage = NewCoverage(actual[c], cs.Communities[c].Size)
+	}
+
+	hits := make(map[int]int)
+	first := make(map[int]int)
+	for i, links := range plog.Links {
+		ts := plog.Timestamps[i]
+		for _, j := range links {
+			if f, ok := first[j]; !ok || ts < f {
+				first[j] = ts
+			}
+			hits[j]++
+		}
+	}
+
+	linked := make(map[[2]int]bool)
+	for i, link := range g.Links() {
+		from, to := communities[link.FromIdx()], communities[link.ToIdx()]
+		if from == to {
+			continue
+		}
+		cs.Bridges = append(cs.Bridges, Bridge{
+			Link:  i,
+			From:  from,
+			To:    to,
+			Hits:  hits[i],
+			First: msToDuration(first[i]),
+		})
+		linked[[2]int{from, to}] = true
+		linked[[2]int{to, from}] = true
+	}
+	sort.SliceStable(cs.Bridges, func(i, j int) bool { return cs.Bridges[i].Hits > cs.Bridges[j].Hits })
+
+	for pair := range linked {
+		a, b := cs.Communities[pair[0]], cs.Communities[pair[1]]
+		if a.Coverage.Actual == 0 || b.Coverage.Actual == 0 || a.First > b.First {
+			continue
+		}
+		if a.First == b.First && pair[0] > pair[1] {
+			continue
+		}
+		cs.Delays = append(cs.Delays, CommunityDelay{From: pair[0], To: pair[1], Delay: b.First - a.First})
+	}
+	sort.Slice(cs.Delays, func(i, j int) bool {
+		if cs.Delays[i].From != cs.Delays[j].From {
+			return cs.Delays[i].From < cs.Delays[j].From
+		}
+		return cs.Delays[i].To < cs.Delays[j].To
+	})
+	return cs
+}
+
+// String implements Stringer interface for CommunityStats.
+func (cs *CommunityStats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d communities (modularity %.2f), %d bridge links", len(cs.Communities), cs.Modularity, len(cs.Bridges))
+	for i, c := range cs.Communities {
+		fmt.Fprintf(&b, "\n  #%d: %d nodes, coverage %v", i, c.Size, c.Coverage)
+		if c.Coverage.Actual > 0 {
+			fmt.Fprintf(&b, ", entered at %v, last node at %v", c.First, c.Last)
+		}
+	}
+	for _, d := range cs.Delays {
+		fmt.Fprintf(&b, "\n  #%d -> #%d: %v", d.From, d.To, d.Delay)
+	}
+	for i, br := range cs.Bridges {
+		if i == maxBridges {
+			fmt.Fprintf(&b, "\n  ... and %d more bridges", len(cs.Bridges)-maxBridges)
+			break
+		}
+		fmt.Fprintf(&b, "\n  bridge link %d (#%d - #%d): used %d times", br.Link, br.From, br.To, br.Hits)
+		if br.Hits > 0 {
+			fmt.Fprintf(&b, ", first at %v", br.First)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,44 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+)
+
+func TestAnalyzeCommunities(t *testing.T) {
+	// triangles 0-1-2 and 3-4-5, joined by 2-3 bridge (link 3)
+	g := graph.NewGraph()
+	for _, id := range []string{"0", "1", "2", "3", "4", "5"} {
+		g.AddNode(node(id))
+	}
+	g.AddLink("0", "1")
+	g.AddLink("1", "2")
+	g.AddLink("2", "0")
+	g.AddLink("2", "3")
+	g.AddLink("3", "4")
+	g.AddLink("4", "5")
+	g.AddLink("5", "3")
+
+	plog := propagation.NewLog(3)
+	plog.AddStep(10, []int{0, 1, 0, 2}, []int{0, 2})
+	plog.AddStep(50, []int{2, 3}, []int{3})
+	plog.AddStep(60, []int{3, 4}, []int{4})
+	communities := []int{0, 0, 0, 1, 1, 1}
+
+	cs := AnalyzeCommunities(plog, g, communities)
+	if len(cs.Communities) != 2 {
+		t.Fatalf("Expected 2 communities, got %d", len(cs.Communities))
+	}
+	if c := cs.Communities[1]; c.Coverage != NewCoverage(2, 3) || c.First != 50*time.Millisecond || c.Last != 60*time.Millisecond {
+		t.Fatalf("Unexpected second community stats: %+v", c)
+	}
+	if len(cs.Bridges) != 1 || cs.Bridges[0].Link != 3 || cs.Bridges[0].Hits != 1 {
+		t.Fatalf("Expected bridge link 3 used once, got %+v", cs.Bridges)
+	}
+	if len(cs.Delays) != 1 || cs.Delays[0] != (CommunityDelay{From: 0, To: 1, Delay: 40 * time.Millisecond}) {
+		t.Fatalf("Expected 40ms delay from first to second community, got %+v", cs.Delays)
+	}
+}
@@ -0,0 +1,195 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+	"github.com/gonum/stat"
+)
+
+// percentiles used for latency comparison.
+var comparePercentiles = []float64{0.5, 0.9, 0.95, 0.99, 1.0}
+
+// Comparison represents the difference between two propagation logs,
+// usually produced by different simulators on the same network graph.
+type Comparison struct {
+	NodesA, NodesB int // number of nodes covered
+	LinksA, LinksB int // number of links used
+	TimeA, TimeB   time.Duration
+	TrafficA       *propagation.Traffic // nil if not tracked by simulator
+	TrafficB       *propagation.Traffic
+	EnergyA        *Energy // nil if not analyzed, see AnalyzeEnergy
+	EnergyB        *Energy
+	Percentiles    []PercentileDelta
+	NodeDiffs      []NodeDiff // sorted by absolute delta, largest first
+}
+
+// PercentileDelta holds time-to-node latency for the given percentile in both logs.
+type PercentileDelta struct {
+	Percentile float64
+	A, B       time.Duration
+}
+
+// Delta returns latency difference between second and first logs.
+func (p PercentileDelta) Delta() time.Duration {
+	return p.B - p.A
+}
+
+// NodeDiff holds time-to-node for a single node in both logs.
+// Negative values mean node has not been reached.
+type NodeDiff struct {
+	Node int
+	A, B time.Duration
+}
+
+// Delta returns time-to-node difference between second and first logs.
+// It's meaningful only when node has been reached in both logs.
+func (n NodeDiff) Delta() time.Duration {
+	return n.B - n.A
+}
+
+// Reached reports whether node has been reached in both logs.
+func (n NodeDiff) Reached() bool {
+	return n.A >= 0 && n.B >= 0
+}
+
+// Compare compares two propagation logs and returns filled Comparison object.
+func Compare(a, b *propagation.Log) *Comparison {
+	hitsA, hitsB := timeToNode(a), timeToNode(b)
+
+	cmp := &Comparison{
+		NodesA: len(hitsA),
+		NodesB: len(hitsB),
+		LinksA: countLinks(a),
+		LinksB: countLinks(b),
+		TimeA:  analyzeTiming(a),
+		TimeB:  analyzeTiming(b),
+
+		TrafficA: a.Traffic,
+		TrafficB: b.Traffic,
+	}
+
+	latA, latB := sortedValues(hitsA), sortedValues(hitsB)
+	for _, p := range comparePercentiles {
+		cmp.Percentiles = append(cmp.Percentiles, PercentileDelta{
+			Percentile: p,
+			A:          quantile(p, latA),
+			B:          quantile(p, latB),
+		})
+	}
+
+	nodes := make(map[int]struct{})
+	for node := range hitsA {
+		nodes[node] = struct{}{}
+	}
+	for node := range hitsB {
+		nodes[node] = struct{}{}
+	}
+	for node := range nodes {
+		diff := NodeDiff{Node: node, A: -1, B: -1}
+		if ts, ok := hitsA[node]; ok {
+			diff.A = msToDuration(ts)
+		}
+		if ts, ok := hitsB[node]; ok {
+			diff.B = msToDuration(ts)
+		}
+		if diff.Reached() && diff.Delta() == 0 {
+			continue
+		}
+		cmp.NodeDiffs = append(cmp.NodeDiffs, diff)
+	}
+	sort.Slice(cmp.NodeDiffs, func(i, j int) bool {
+		di, dj := cmp.NodeDiffs[i], cmp.NodeDiffs[j]
+		// nodes reached only in one log go first
+		if di.Reached() != dj.Reached() {
+			return !di.Reached()
+		}
+		if abs(di.Delta()) != abs(dj.Delta()) {
+			return abs(di.Delta()) > abs(dj.Delta())
+		}
+		return di.Node < dj.Node
+	})
+
+	return cmp
+}
+
+// PrintVerbose prints detailed terminal-friendly comparison to
+// the console.
+func (c *Comparison) PrintVerbose() {
+	fmt.Println("Comparison (A -> B):")
+	fmt.Printf("Time elapsed: %v -> %v (%+v)\n", c.TimeA, c.TimeB, c.TimeB-c.TimeA)
+	fmt.Printf("Nodes covered: %d -> %d (%+d)\n", c.NodesA, c.NodesB, c.NodesB-c.NodesA)
+	fmt.Printf("Links used: %d -> %d (%+d)\n", c.LinksA, c.LinksB, c.LinksB-c.LinksA)
+	if c.TrafficA != nil && c.TrafficB != nil {
+		a, b := c.TrafficA.TotalBytes(), c.TrafficB.TotalBytes()
+		fmt.Printf("Traffic, bytes: %d -> %d (%+d)\n", a, b, b-a)
+	}
+	if c.EnergyA != nil && c.EnergyB != nil {
+		a, b := c.EnergyA, c.EnergyB
+		fmt.Printf("Energy, total: %.2f -> %.2f (%+.2f)\n", a.Total, b.Total, b.Total-a.Total)
+		fmt.Printf("Energy, max per node: %.2f -> %.2f (%+.2f)\n", a.Max, b.Max, b.Max-a.Max)
+	}
+	fmt.Println("TimeToNode percentiles:")
+	for _, p := range c.Percentiles {
+		fmt.Printf("  p%-5v %v -> %v (%+v)\n", p.Percentile*100, p.A, p.B, p.Delta())
+	}
+
+	const maxDiffs = 10
+	fmt.Printf("Per-node differences: %d nodes\n", len(c.NodeDiffs))
+	for i, diff := range c.NodeDiffs {
+		if i == maxDiffs {
+			fmt.Printf("  ...and %d more\n", len(c.NodeDiffs)-maxDiffs)
+			break
+		}
+		switch {
+		case diff.A < 0:
+			fmt.Printf("  node %d: reached only in B (%v)\n", diff.Node, diff.B)
+		case diff.B < 0:
+			fmt.Printf("  node %d: reached only in A (%v)\n", diff.Node, diff.A)
+		default:
+			fmt.Printf("  node %d: %v -> %v (%+v)\n", diff.Node, diff.A, diff.B, diff.Delta())
+		}
+	}
+}
+
+// countLinks returns number of distinct links used in log.
+func countLinks(plog *propagation.Log) int {
+	links := make(map[int]struct{})
+	for _, step := range plog.Links {
+		for _, j := range step {
+			links[j] = struct{}{}
+		}
+	}
+	return len(links)
+}
+
+// sortedValues returns map values as a sorted float64 slice.
+func sortedValues(m map[int]int) []float64 {
+	x := make([]float64, 0, len(m))
+	for _, v := range m {
+		x = append(x, float64(v))
+	}
+	sort.Float64s(x)
+	return x
+}
+
+// quantile returns the p quantile of sorted x in milliseconds as time.Duration.
+func quantile(p float64, x []float64) time.Duration {
+	if len(x) == 0 {
+		return 0
+	}
+	return msToDuration(int(stat.Quantile(p, stat.Empirical, x, nil)))
+}
+
+func msToDuration(ms int) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,52 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestCompare(t *testing.T) {
+	a := &propagation.Log{
+		Timestamps: []int{10, 20},
+		Nodes: [][]int{
+			[]int{0, 1},
+			[]int{1, 2},
+		},
+		Links: [][]int{
+			[]int{0},
+			[]int{1},
+		},
+	}
+	b := &propagation.Log{
+		Timestamps: []int{10, 40},
+		Nodes: [][]int{
+			[]int{0, 1},
+			[]int{1, 3},
+		},
+		Links: [][]int{
+			[]int{0},
+			[]int{3},
+		},
+	}
+
+	cmp := Compare(a, b)
+
+	if cmp.NodesA != 3 || cmp.NodesB != 3 {
+		t.Fatalf("Expected 3 nodes covered in both logs, but got %d and %d", cmp.NodesA, cmp.NodesB)
+	}
+	if cmp.TimeB-cmp.TimeA != 20*time.Millisecond {
+		t.Fatalf("Expected time delta 20ms, but got %v", cmp.TimeB-cmp.TimeA)
+	}
+
+	// node 2 reached only in A, node 3 only in B
+	if len(cmp.NodeDiffs) != 2 {
+		t.Fatalf("Expected 2 node differences, but got %d: %v", len(cmp.NodeDiffs), cmp.NodeDiffs)
+	}
+	for _, diff := range cmp.NodeDiffs {
+		if diff.Reached() {
+			t.Fatalf("Expected node %d to be reached only in one log, but got %v", diff.Node, diff)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// maxSlowestHops is the number of the slowest critical path hops printed
+// in stats.
+const maxSlowestHops = 3
+
+// CriticalPath is the chain of first-arrival deliveries from the sender
+// to the node reached last, which determined the worst-case delivery time.
+// Speeding up its hops is the only way to reduce that time, unless other
+// paths take over.
+type CriticalPath struct {
+	Hops []Hop // from sender to the last node
+	Time time.Duration
+}
+
+// Hop is a single delivery of the critical path.
+type Hop struct {
+	From, To int
+	Link     int           // link index
+	Delay    time.Duration // since the sender got the message
+}
+
+// AnalyzeCriticalPath returns critical path of the propagation log, based
+// on its first-arrival propagation tree. Of the nodes reached last, the one
+// with the smallest index is taken.
+func AnalyzeCriticalPath(plog *propagation.Log) *CriticalPath {
+	return criticalPath(NewTree(plog))
+}
+
+func criticalPath(t *Tree) *CriticalPath {
+	last := t.Root
+	for node, ts := range t.Time {
+		if ts > t.Time[last] || ts == t.Time[last] && node < last {
+			last = node
+		}
+	}
+
+	cp := &CriticalPath{}
+	if last == -1 {
+		return cp
+	}
+	cp.Time = msToDuration(t.Time[last])
+	path := t.PathTo(last)
+	for i := 1; i < len(path); i++ {
+		from, to := path[i-1], path[i]
+		cp.Hops = append(cp.Hops, Hop{
+			From:  from,
+			To:    to,
+			Link:  t.Link[to],
+			Delay: msToDuration(t.Time[to] - t.Time[from]),
+		})
+	}
+	return cp
+}
+
+// Slowest returns up to n hops of the path with the largest delays,
+// slowest first.
+func (cp *CriticalPath) Slowest(n int) []Hop {
+	hops := append([]Hop(nil), cp.Hops...)
+	sort.SliceStable(hops, func(i, j int) bool { return hops[i].Delay > hops[j].Delay })
+	if len(hops) > n {
+		hops = hops[:n]
+	}
+	return hops
+}
+
+// String implements Stringer interface for CriticalPath.
+func (cp *CriticalPath) String() string {
+	if len(cp.Hops) == 0 {
+		return "none"
+	}
+	nodes := make([]string, 0, len(cp.Hops)+1)
+	nodes = append(nodes, fmt.Sprint(cp.Hops[0].From))
+	for _, hop := range cp.Hops {
+		nodes = append(nodes, fmt.Sprint(hop.To))
+	}
+	slowest := make([]string, 0, maxSlowestHops)
+	for _, hop := range cp.Slowest(maxSlowestHops) {
+		slowest = append(slowest, fmt.Sprintf("link %d (%d -> %d) %v", hop.Link, hop.From, hop.To, hop.Delay))
+	}
+	return fmt.Sprintf("%d hops, %v: %s\n  slowest hops: %s",
+		len(cp.Hops), cp.Time, strings.Join(nodes, " -> "), strings.Join(slowest, ", "))
+}
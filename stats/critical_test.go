@@ -0,0 +1,62 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestAnalyzeCriticalPath(t *testing.T) {
+	plog := propagation.NewLog(3)
+	plog.AddStep(10, []int{0, 1, 0, 2}, []int{0, 1})
+	plog.AddStep(40, []int{2, 3, 1, 3}, []int{2, 3})
+	plog.AddStep(50, []int{1, 4}, []int{4})
+
+	cp := AnalyzeCriticalPath(plog)
+	if cp.Time != 50*time.Millisecond {
+		t.Fatalf("Expected critical path of 50ms, got %v", cp.Time)
+	}
+	expected := []Hop{
+		{From: 0, To: 1, Link: 0, Delay: 10 * time.Millisecond},
+		{From: 1, To: 4, Link: 4, Delay: 40 * time.Millisecond},
+	}
+	if !reflect.DeepEqual(cp.Hops, expected) {
+		t.Fatalf("Expected hops %v, got %v", expected, cp.Hops)
+	}
+	if slowest := cp.Slowest(1); len(slowest) != 1 || slowest[0].Link != 4 {
+		t.Fatalf("Expected link 4 to be the slowest, got %v", slowest)
+	}
+}
+
+func TestAnalyzeCriticalPathMultipleSenders(t *testing.T) {
+	// nodes 0 and 2 both send, and their peers echo message back
+	plog := propagation.NewLog(2)
+	plog.AddStep(10, []int{0, 1, 2, 3}, []int{0, 1})
+	plog.AddStep(20, []int{1, 0, 3, 2}, []int{0, 1})
+
+	tree := NewTree(plog)
+	if !reflect.DeepEqual(tree.Roots, []int{0, 2}) {
+		t.Fatalf("Expected senders 0 and 2 as roots, got %v", tree.Roots)
+	}
+	if expected := map[int]int{1: 0, 3: 2}; !reflect.DeepEqual(tree.Parent, expected) {
+		t.Fatalf("Expected parents %v, got %v", expected, tree.Parent)
+	}
+	if d := tree.Depth(3); d != 1 {
+		t.Fatalf("Expected depth 1 of node 3, got %d", d)
+	}
+
+	cp := Analyze(plog, 4, 2).CriticalPath
+	expected := []Hop{{From: 0, To: 1, Link: 0, Delay: 10 * time.Millisecond}}
+	if !reflect.DeepEqual(cp.Hops, expected) {
+		t.Fatalf("Expected hops %v, got %v", expected, cp.Hops)
+	}
+}
+
+func TestTreeDepthCycle(t *testing.T) {
+	tree := &Tree{Root: 0, Parent: map[int]int{0: 1, 1: 0, 3: 2}}
+	if d := tree.Depth(1); d != 1 {
+		t.Fatalf("Expected depth 1 within parents cycle, got %d", d)
+	}
+}
@@ -0,0 +1,35 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// Delivery describes delivery of the point-to-point message to its
+// recipient (see propagation.DirectSender).
+type Delivery struct {
+	Recipient int
+	Delivered bool
+	Latency   time.Duration // time the recipient first got the message
+}
+
+// AnalyzeDelivery analyzes delivery of the message to the recipient node
+// in the propagation log.
+func AnalyzeDelivery(plog *propagation.Log, recipient int) *Delivery {
+	d := &Delivery{Recipient: recipient}
+	if ts, ok := timeToNode(plog)[recipient]; ok {
+		d.Delivered = true
+		d.Latency = msToDuration(ts)
+	}
+	return d
+}
+
+// String implements Stringer interface for Delivery.
+func (d *Delivery) String() string {
+	if !d.Delivered {
+		return fmt.Sprintf("not delivered to node %d", d.Recipient)
+	}
+	return fmt.Sprintf("delivered to node %d in %v", d.Recipient, d.Latency)
+}
@@ -0,0 +1,33 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestAnalyzeDelivery(t *testing.T) {
+	plog := &propagation.Log{
+		Timestamps: []int{10, 20, 40},
+		Nodes: [][]int{
+			[]int{0, 1},
+			[]int{1, 2},
+			[]int{2, 3},
+		},
+		Links: [][]int{
+			[]int{0},
+			[]int{1},
+			[]int{2},
+		},
+	}
+
+	d := AnalyzeDelivery(plog, 2)
+	if !d.Delivered || d.Latency != 20*time.Millisecond {
+		t.Fatalf("Expected delivery in 20ms, but got %v", d)
+	}
+	d = AnalyzeDelivery(plog, 4)
+	if d.Delivered {
+		t.Fatalf("Expected node 4 not to be delivered, but got %v", d)
+	}
+}
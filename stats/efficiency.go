@@ -0,0 +1,83 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// Efficiency describes coverage vs transmissions trade-off of the
+// propagation, i.e. how many deliveries it took to reach the given
+// fraction of nodes. Thresholds not reached are -1.
+type Efficiency struct {
+	Transmissions int     // deliveries in the log, including duplicates
+	PerNode       float64 // transmissions per reached node
+	ToHalf        int     // transmissions until half of nodes are reached
+	To90          int     // transmissions until 90% of nodes are reached
+	ToAll         int     // transmissions until all nodes are reached
+}
+
+// AnalyzeEfficiency analyzes transmissions needed to cover nodeCount nodes
+// of the network. Each link in the log step represents a single delivery,
+// so it's meaningful for simulators reporting all deliveries, not just the
+// first ones. For sampled logs, transmissions are scaled up by the number
+// of events each recorded one stands for (see propagation.Sampling.Scale),
+// which makes thresholds estimates.
+func AnalyzeEfficiency(plog *propagation.Log, nodeCount int) Efficiency {
+	e := Efficiency{ToHalf: -1, To90: -1, ToAll: -1}
+	steps := make([]int, len(plog.Timestamps))
+	for i := range steps {
+		steps[i] = i
+	}
+	sort.SliceStable(steps, func(i, j int) bool {
+		return plog.Timestamps[steps[i]] < plog.Timestamps[steps[j]]
+	})
+
+	reached := make(map[int]bool)
+	mark := func(threshold *int, fraction float64) {
+		if *threshold == -1 && float64(len(reached)) >= fraction*float64(nodeCount) {
+			*threshold = e.Transmissions
+		}
+	}
+	for _, i := range steps {
+		if i < len(plog.Links) {
+			e.Transmissions += len(plog.Links[i])
+		}
+		if i < len(plog.Nodes) {
+			for _, node := range plog.Nodes[i] {
+				reached[node] = true
+			}
+		}
+		mark(&e.ToHalf, 0.5)
+		mark(&e.To90, 0.9)
+		mark(&e.ToAll, 1)
+	}
+	if s := plog.Sampling; s != nil {
+		scale := func(n *int) {
+			if *n > 0 {
+				*n = int(float64(*n)*s.Scale() + 0.5)
+			}
+		}
+		scale(&e.ToHalf)
+		scale(&e.To90)
+		scale(&e.ToAll)
+		e.Transmissions += int(s.Skipped)
+	}
+	if len(reached) > 0 {
+		e.PerNode = float64(e.Transmissions) / float64(len(reached))
+	}
+	return e
+}
+
+// String implements Stringer interface for Efficiency.
+func (e Efficiency) String() string {
+	threshold := func(n int) string {
+		if n == -1 {
+			return "never"
+		}
+		return fmt.Sprint(n)
+	}
+	return fmt.Sprintf("%d transmissions, %.2f per reached node, to reach 50%%: %s, 90%%: %s, 100%%: %s",
+		e.Transmissions, e.PerNode, threshold(e.ToHalf), threshold(e.To90), threshold(e.ToAll))
+}
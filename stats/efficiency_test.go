@@ -0,0 +1,34 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestAnalyzeEfficiency(t *testing.T) {
+	plog := propagation.NewLog(3)
+	plog.AddStep(10, []int{0, 1}, []int{0})
+	plog.AddStep(30, []int{1, 0}, []int{0}) // duplicate
+	plog.AddStep(20, []int{1, 2}, []int{1})
+
+	e := AnalyzeEfficiency(plog, 4)
+	if e.Transmissions != 3 || e.ToHalf != 1 || e.To90 != -1 || e.ToAll != -1 {
+		t.Fatalf("Unexpected efficiency: %v", e)
+	}
+	if e.PerNode != 1 {
+		t.Fatalf("Expected 1 transmission per reached node, got %v", e.PerNode)
+	}
+
+	e = AnalyzeEfficiency(plog, 3)
+	if e.To90 != 2 || e.ToAll != 2 {
+		t.Fatalf("Expected all nodes reached in 2 transmissions, got %v", e)
+	}
+
+	// the same log, with 3 more duplicates skipped by sampling
+	plog.Sampling = &propagation.Sampling{Recorded: 3, Skipped: 3}
+	e = AnalyzeEfficiency(plog, 3)
+	if e.Transmissions != 6 || e.ToAll != 4 {
+		t.Fatalf("Expected 6 transmissions, all nodes reached in 4, got %v", e)
+	}
+}
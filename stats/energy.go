@@ -0,0 +1,76 @@
+package stats
+
+import (
+	"fmt"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// EnergyModel defines energy cost of sending and receiving messages, in
+// arbitrary energy units. It lets compare protocols by battery impact on
+// mobile nodes, where radio usage dominates power consumption.
+type EnergyModel struct {
+	Send    float64 // per message sent
+	Receive float64 // per message received
+
+	SendByte    float64 // per payload byte sent
+	ReceiveByte float64 // per payload byte received
+}
+
+// DefaultEnergyModel returns energy model where receiving costs about
+// half of sending, roughly matching mobile radios.
+func DefaultEnergyModel() EnergyModel {
+	return EnergyModel{
+		Send:        1,
+		Receive:     0.5,
+		SendByte:    0.01,
+		ReceiveByte: 0.005,
+	}
+}
+
+// Energy represents energy spent by nodes on message propagation.
+type Energy struct {
+	PerNode map[int]float64 // node index -> energy units, for nodes involved
+	Total   float64
+	Mean    float64 // per node, over all network nodes
+	Max     float64
+	MaxNode int // index of the node spent the most energy, -1 if none
+}
+
+// AnalyzeEnergy calculates energy spent by each node on sending and
+// receiving payload messages of the given size, according to the model.
+// Only payload deliveries recorded in the log are accounted.
+//
+// It relies on the fact that nodes in each log step are stored in pairs
+// of sender and receiver (see propagation.LogEntries2Log).
+func AnalyzeEnergy(plog *propagation.Log, nodeCount, size int, model EnergyModel) *Energy {
+	send := model.Send + model.SendByte*float64(size)
+	receive := model.Receive + model.ReceiveByte*float64(size)
+
+	e := &Energy{
+		PerNode: make(map[int]float64),
+		MaxNode: -1,
+	}
+	for _, nodes := range plog.Nodes {
+		for k := 0; k+1 < len(nodes); k += 2 {
+			e.PerNode[nodes[k]] += send
+			e.PerNode[nodes[k+1]] += receive
+			e.Total += send + receive
+		}
+	}
+	for node, v := range e.PerNode {
+		if v > e.Max || (v == e.Max && node < e.MaxNode) {
+			e.Max, e.MaxNode = v, node
+		}
+	}
+	if nodeCount > 0 {
+		e.Mean = e.Total / float64(nodeCount)
+	}
+	return e
+}
+
+// String implements Stringer interface for Energy.
+func (e *Energy) String() string {
+	return fmt.Sprintf("total: %.2f, per node: %.2f, max: %.2f (node %d)",
+		e.Total, e.Mean, e.Max, e.MaxNode)
+}
@@ -0,0 +1,40 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestAnalyzeEnergy(t *testing.T) {
+	plog := &propagation.Log{
+		Timestamps: []int{10, 20},
+		Nodes: [][]int{
+			[]int{0, 1, 0, 2},
+			[]int{1, 2},
+		},
+		Links: [][]int{
+			[]int{0, 1},
+			[]int{2},
+		},
+	}
+	model := EnergyModel{Send: 1, Receive: 0.5, SendByte: 0.01, ReceiveByte: 0}
+
+	e := AnalyzeEnergy(plog, 4, 100, model)
+
+	expected := map[int]float64{0: 4, 1: 2.5, 2: 1}
+	for node, v := range expected {
+		if e.PerNode[node] != v {
+			t.Fatalf("Expected node %d to spend %v, but got %v", node, v, e.PerNode[node])
+		}
+	}
+	if e.Total != 7.5 {
+		t.Fatalf("Expected total 7.5, but got %v", e.Total)
+	}
+	if e.Mean != 7.5/4 {
+		t.Fatalf("Expected mean %v, but got %v", 7.5/4, e.Mean)
+	}
+	if e.MaxNode != 0 || e.Max != 4 {
+		t.Fatalf("Expected max 4 for node 0, but got %v for node %d", e.Max, e.MaxNode)
+	}
+}
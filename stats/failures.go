@@ -0,0 +1,102 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// KillImpact describes propagation state at the time of a single node
+// failure, and node recovery, if it was restarted.
+type KillImpact struct {
+	Node     int
+	At       time.Duration
+	Reached  bool          // node got the message before it was killed
+	Before   Coverage      // nodes coverage at the time of the kill
+	Recover  time.Duration // downtime, zero if node wasn't restarted
+	CaughtUp bool          // restarted node got the message it missed
+}
+
+// Failures describes impact of node failures injected during propagation
+// (see propagation.Kill) on its coverage.
+type Failures struct {
+	Kills     []KillImpact
+	After     Coverage // final nodes coverage
+	Survivors Coverage // final coverage of nodes that were up in the end
+}
+
+// AnalyzeFailures analyzes coverage of the propagation log before and
+// after each of node failures, for network of the given number of nodes.
+// Killed node counts as reached only if it got the message before its
+// failure, or after its recovery.
+func AnalyzeFailures(plog *propagation.Log, nodes int, kills []propagation.Kill) *Failures {
+	reached := timeToNode(plog)
+	killed := make(map[int]bool) // nodes that stayed down
+	f := &Failures{}
+	for _, kill := range kills {
+		at := int(kill.At / time.Millisecond)
+		var before int
+		for _, ts := range reached {
+			if ts <= at {
+				before++
+			}
+		}
+		ts, ok := reached[kill.Node]
+		impact := KillImpact{
+			Node:    kill.Node,
+			At:      kill.At,
+			Reached: ok && ts <= at,
+			Before:  NewCoverage(before, nodes),
+			Recover: kill.Recover,
+		}
+		if kill.Recover > 0 {
+			impact.CaughtUp = !impact.Reached && ok && ts >= int((kill.At+kill.Recover)/time.Millisecond)
+			delete(killed, kill.Node)
+		} else {
+			killed[kill.Node] = true
+		}
+		f.Kills = append(f.Kills, impact)
+	}
+
+	var survivors int
+	for node := range reached {
+		if !killed[node] {
+			survivors++
+		}
+	}
+	f.After = NewCoverage(len(reached), nodes)
+	f.Survivors = NewCoverage(survivors, nodes-len(killed))
+	return f
+}
+
+// String implements Stringer interface for KillImpact.
+func (k KillImpact) String() string {
+	state := "not reached"
+	if k.Reached {
+		state = "reached"
+	}
+	s := fmt.Sprintf("node %d killed at %v (%s), coverage before: %v", k.Node, k.At, state, k.Before)
+	if k.Recover > 0 {
+		recovery := "missed"
+		switch {
+		case k.Reached:
+			recovery = "had it"
+		case k.CaughtUp:
+			recovery = "caught up"
+		}
+		s += fmt.Sprintf(", restarted after %v (%s)", k.Recover, recovery)
+	}
+	return s
+}
+
+// String implements Stringer interface for Failures.
+func (f *Failures) String() string {
+	lines := make([]string, 0, len(f.Kills)+1)
+	for _, k := range f.Kills {
+		lines = append(lines, "  "+k.String())
+	}
+	lines = append(lines, fmt.Sprintf("  coverage after: %v, survivors: %v", f.After, f.Survivors))
+	return "\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,46 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestAnalyzeFailures(t *testing.T) {
+	plog := &propagation.Log{
+		Timestamps: []int{10, 20, 40},
+		Nodes: [][]int{
+			[]int{0, 1},
+			[]int{1, 2},
+			[]int{2, 3},
+		},
+		Links: [][]int{
+			[]int{0},
+			[]int{1},
+			[]int{2},
+		},
+	}
+	kills := []propagation.Kill{
+		{Node: 2, At: 30 * time.Millisecond},
+		{Node: 4, At: 0},
+		{Node: 3, At: 0, Recover: 30 * time.Millisecond},
+	}
+
+	f := AnalyzeFailures(plog, 5, kills)
+	if len(f.Kills) != 3 {
+		t.Fatalf("Expected 3 kills, got %d", len(f.Kills))
+	}
+	if k := f.Kills[0]; !k.Reached || k.Before.Actual != 3 {
+		t.Fatalf("Expected node 2 reached with 3 nodes before, got %v", k)
+	}
+	if k := f.Kills[1]; k.Reached || k.Before.Actual != 0 {
+		t.Fatalf("Expected node 4 not reached with no nodes before, got %v", k)
+	}
+	if k := f.Kills[2]; k.Reached || !k.CaughtUp {
+		t.Fatalf("Expected node 3 to catch up after restart, got %v", k)
+	}
+	if f.After.Actual != 4 || f.Survivors.Actual != 3 || f.Survivors.Total != 3 {
+		t.Fatalf("Expected 4 nodes reached, 3/3 survivors, got %v", f)
+	}
+}
@@ -0,0 +1,73 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// GroupStats describes delivery of the message to nodes of a single
+// group, i.e. country or client type.
+type GroupStats struct {
+	Name     string
+	Coverage Coverage      // nodes of the group reached
+	Median   time.Duration // time to reach half of the reached nodes
+	P95      time.Duration
+	Last     time.Duration // time to reach the last of the reached nodes
+}
+
+// Groups holds stats of all node groups.
+type Groups []GroupStats
+
+// AnalyzeGroups breaks down coverage and latency of the propagation log
+// by node groups, given as group names ordered by node index. Nodes with
+// empty group name are skipped. Groups are sorted by name.
+func AnalyzeGroups(plog *propagation.Log, groups []string) Groups {
+	reached := timeToNode(plog)
+	total := make(map[string]int)
+	latencies := make(map[string][]float64)
+	for node, name := range groups {
+		if name == "" {
+			continue
+		}
+		total[name]++
+		if ts, ok := reached[node]; ok {
+			latencies[name] = append(latencies[name], float64(ts))
+		}
+	}
+
+	ret := make(Groups, 0, len(total))
+	for name, n := range total {
+		x := latencies[name]
+		sort.Float64s(x)
+		g := GroupStats{
+			Name:     name,
+			Coverage: NewCoverage(len(x), n),
+			Median:   quantile(0.5, x),
+			P95:      quantile(0.95, x),
+		}
+		if len(x) > 0 {
+			g.Last = msToDuration(int(x[len(x)-1]))
+		}
+		ret = append(ret, g)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+// String implements Stringer interface for GroupStats.
+func (g GroupStats) String() string {
+	return fmt.Sprintf("%s: coverage %v, median %v, p95 %v, last %v", g.Name, g.Coverage, g.Median, g.P95, g.Last)
+}
+
+// String implements Stringer interface for Groups.
+func (gs Groups) String() string {
+	lines := make([]string, len(gs))
+	for i, g := range gs {
+		lines[i] = "  " + g.String()
+	}
+	return "\n" + strings.Join(lines, "\n")
+}
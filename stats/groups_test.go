@@ -0,0 +1,27 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestAnalyzeGroups(t *testing.T) {
+	plog := propagation.NewLog(2)
+	plog.AddStep(10, []int{0, 1}, []int{0})
+	plog.AddStep(30, []int{3}, []int{1})
+	groups := []string{"eu", "eu", "us", "us", ""}
+
+	gs := AnalyzeGroups(plog, groups)
+	if len(gs) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(gs))
+	}
+	eu, us := gs[0], gs[1]
+	if eu.Name != "eu" || eu.Coverage != NewCoverage(2, 2) || eu.Last != 10*time.Millisecond {
+		t.Fatalf("Unexpected stats for 'eu' group: %v", eu)
+	}
+	if us.Name != "us" || us.Coverage != NewCoverage(1, 2) || us.Median != 30*time.Millisecond {
+		t.Fatalf("Unexpected stats for 'us' group: %v", us)
+	}
+}
@@ -1,8 +1,12 @@
 package stats
 
 import (
+	"encoding/csv"
 	"fmt"
+	"io"
+	"math"
 	"sort"
+	"strconv"
 
 	"github.com/gonum/floats"
 	"github.com/gonum/stat"
@@ -11,7 +15,9 @@ import (
 
 // Histogram is a simple histogram data holding structure.
 type Histogram struct {
-	data []float64
+	data     []float64
+	dividers []float64
+	values   []float64 // sorted raw values, used for export
 }
 
 // NewHistogram creates and calculates a histogram from raw counts slice.
@@ -19,6 +25,10 @@ func NewHistogram(x []float64, nBins int) *Histogram {
 	// x should be sorted
 	sort.Slice(x, func(i, j int) bool { return x[i] < x[j] })
 
+	if len(x) == 0 {
+		return &Histogram{}
+	}
+
 	dividers := []float64{}
 
 	// automatically calculate dividers
@@ -29,7 +39,9 @@ func NewHistogram(x []float64, nBins int) *Histogram {
 	floats.Span(dividers, min, max)
 	data := stat.Histogram(nil, dividers, x, nil)
 	return &Histogram{
-		data: data,
+		data:     data,
+		dividers: dividers,
+		values:   x,
 	}
 }
 
@@ -37,3 +49,97 @@ func NewHistogram(x []float64, nBins int) *Histogram {
 func (h *Histogram) String() string {
 	return fmt.Sprintf("%v\n%v", h.data, spark.Line(h.data))
 }
+
+// WriteCSV writes histogram bins as CSV with 'from', 'to' and 'count'
+// columns, one row per bin.
+func (h *Histogram) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"from", "to", "count"}); err != nil {
+		return err
+	}
+	for i, count := range h.data {
+		record := []string{
+			formatFloat(h.dividers[i]),
+			formatFloat(h.dividers[i+1]),
+			formatFloat(count),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteHDR writes histogram values in the HdrHistogram percentile
+// distribution format, so it can be plotted and merged with HdrHistogram
+// tooling (i.e. http://hdrhistogram.github.io/HdrHistogram/plotFiles.html).
+func (h *Histogram) WriteHDR(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "%12s %14s %10s %14s\n\n", "Value", "Percentile", "TotalCount", "1/(1-Percentile)")
+	if err != nil {
+		return err
+	}
+
+	n := len(h.values)
+	if n == 0 {
+		return nil
+	}
+
+	// percentile levels are iterated the same way HdrHistogram does it:
+	// 5 ticks per each half of the remaining distance to 100%.
+	const ticksPerHalfDistance = 5
+	var level float64
+	for {
+		idx := int(math.Ceil(level/100*float64(n))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		value := h.values[idx]
+		count := sort.Search(n, func(i int) bool { return h.values[i] > value })
+		if count == n {
+			break
+		}
+		percentile := float64(count) / float64(n)
+		_, err := fmt.Fprintf(w, "%12.3f %2.12f %10d %14.2f\n", value, percentile, count, 1/(1-percentile))
+		if err != nil {
+			return err
+		}
+
+		ticks := ticksPerHalfDistance * math.Pow(2, math.Floor(math.Log2(100/(100-level)))+1)
+		level += 100 / ticks
+	}
+	_, err = fmt.Fprintf(w, "%12.3f %2.12f %10d\n", h.values[n-1], 1.0, n)
+	if err != nil {
+		return err
+	}
+
+	mean := stat.Mean(h.values, nil)
+	var stddev float64
+	if n > 1 {
+		stddev = stat.StdDev(h.values, nil)
+	}
+	_, err = fmt.Fprintf(w, "#[Mean    = %12.3f, StdDeviation   = %12.3f]\n", mean, stddev)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "#[Max     = %12.3f, Total count    = %12d]\n", h.values[n-1], n)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "#[Buckets = %12d, SubBuckets     = %12d]\n", len(h.data), n)
+	return err
+}
+
+// Counts returns the number of values in each histogram bin.
+func (h *Histogram) Counts() []float64 {
+	return h.data
+}
+
+// Dividers returns the bins boundaries. It has one element more than Counts.
+func (h *Histogram) Dividers() []float64 {
+	return h.dividers
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,38 @@
+package stats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHistogramCSV(t *testing.T) {
+	h := NewHistogram([]float64{3, 1, 2, 1}, 2)
+
+	var buf bytes.Buffer
+	if err := h.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "from,to,count\n1,2.5,3\n2.5,4,1\n"
+	if got := buf.String(); got != expected {
+		t.Fatalf("Expected CSV output %q, but got %q", expected, got)
+	}
+}
+
+func TestHistogramHDR(t *testing.T) {
+	h := NewHistogram([]float64{10, 20, 30, 40}, 2)
+
+	var buf bytes.Buffer
+	if err := h.WriteHDR(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "Total count    =            4") {
+		t.Fatalf("Expected HDR output to contain total count, got:\n%s", out)
+	}
+	if !strings.Contains(out, "40.000 1.000000000000          4\n") {
+		t.Fatalf("Expected HDR output to end with max value, got:\n%s", out)
+	}
+}
@@ -0,0 +1,81 @@
+package stats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// InfluxDB measurement names used by WriteInflux.
+const (
+	InfluxCoverage = "propagation_coverage"
+	InfluxLatency  = "propagation_latency"
+)
+
+// WriteInflux writes coverage-over-time and per-node latency series in
+// InfluxDB line protocol, so results can be imported with
+// 'influx write' and shown in Grafana alongside real network telemetry.
+//
+// Points are timestamped relative to start. Tags are added to every
+// point, i.e. to distinguish algorithms or networks.
+func WriteInflux(w io.Writer, plog *propagation.Log, nodeCount int, start time.Time, tags map[string]string) error {
+	bw := bufio.NewWriter(w)
+	tagSet := formatInfluxTags(tags)
+	tree := NewTree(plog)
+
+	// single point per arrival time, with cumulative coverage
+	nodes := make([]int, 0, len(tree.Time))
+	for node := range tree.Time {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if tree.Time[nodes[i]] != tree.Time[nodes[j]] {
+			return tree.Time[nodes[i]] < tree.Time[nodes[j]]
+		}
+		return nodes[i] < nodes[j]
+	})
+	for i, node := range nodes {
+		ts := tree.Time[node]
+		if i+1 < len(nodes) && tree.Time[nodes[i+1]] == ts {
+			continue
+		}
+		coverage := NewCoverage(i+1, nodeCount)
+		fmt.Fprintf(bw, "%s%s nodes=%di,percentage=%s %d\n",
+			InfluxCoverage, tagSet, coverage.Actual, formatFloat(coverage.Percentage), influxTime(start, ts))
+	}
+
+	for _, node := range nodes {
+		ts := tree.Time[node]
+		fmt.Fprintf(bw, "%s%s,node=%d latency_ms=%di,hops=%di %d\n",
+			InfluxLatency, tagSet, node, ts, tree.Depth(node), influxTime(start, ts))
+	}
+	return bw.Flush()
+}
+
+// influxTime returns timestamp in nanoseconds for ts milliseconds since start.
+func influxTime(start time.Time, ts int) int64 {
+	return start.Add(time.Duration(ts) * time.Millisecond).UnixNano()
+}
+
+// formatInfluxTags formats tags sorted by key, as recommended by InfluxDB.
+func formatInfluxTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&sb, ",%s=%s", influxEscaper.Replace(k), influxEscaper.Replace(tags[k]))
+	}
+	return sb.String()
+}
+
+// influxEscaper escapes special characters in tag keys and values.
+var influxEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
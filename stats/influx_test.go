@@ -0,0 +1,34 @@
+package stats
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestWriteInflux(t *testing.T) {
+	plog := propagation.NewLog(2)
+	plog.AddStep(10, []int{0, 1, 0, 2}, []int{0, 1})
+	plog.AddStep(30, []int{2, 3}, []int{2})
+
+	var buf bytes.Buffer
+	start := time.Unix(100, 0)
+	tags := map[string]string{"algo": "gossip", "network": "my net"}
+	if err := WriteInflux(&buf, plog, 5, start, tags); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `propagation_coverage,algo=gossip,network=my\ net nodes=1i,percentage=20 100000000000
+propagation_coverage,algo=gossip,network=my\ net nodes=3i,percentage=60 100010000000
+propagation_coverage,algo=gossip,network=my\ net nodes=4i,percentage=80 100030000000
+propagation_latency,algo=gossip,network=my\ net,node=0 latency_ms=0i,hops=0i 100000000000
+propagation_latency,algo=gossip,network=my\ net,node=1 latency_ms=10i,hops=1i 100010000000
+propagation_latency,algo=gossip,network=my\ net,node=2 latency_ms=10i,hops=1i 100010000000
+propagation_latency,algo=gossip,network=my\ net,node=3 latency_ms=30i,hops=2i 100030000000
+`
+	if got := buf.String(); got != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
@@ -0,0 +1,18 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// LatencyPercentiles returns time-to-node latencies for the given
+// percentiles (0..1) of nodes reached in the propagation log.
+func LatencyPercentiles(plog *propagation.Log, percentiles ...float64) []time.Duration {
+	latencies := sortedValues(timeToNode(plog))
+	ret := make([]time.Duration, len(percentiles))
+	for i, p := range percentiles {
+		ret[i] = quantile(p, latencies)
+	}
+	return ret
+}
@@ -0,0 +1,32 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestLatencyPercentiles(t *testing.T) {
+	plog := &propagation.Log{
+		Timestamps: []int{10, 20, 40},
+		Nodes: [][]int{
+			[]int{0, 1},
+			[]int{1, 2},
+			[]int{2, 3},
+		},
+		Links: [][]int{
+			[]int{0},
+			[]int{1},
+			[]int{2},
+		},
+	}
+
+	got := LatencyPercentiles(plog, 0.5, 1.0)
+	expected := []time.Duration{10 * time.Millisecond, 40 * time.Millisecond}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("Expected percentiles %v, but got %v", expected, got)
+		}
+	}
+}
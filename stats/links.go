@@ -0,0 +1,86 @@
+package stats
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+)
+
+// LinkUsage holds the number of times message was sent over the link.
+type LinkUsage struct {
+	Link           int // link index
+	Source, Target string
+	Hits           int
+}
+
+// LinkUsages holds usage of links, most used first.
+type LinkUsages []LinkUsage
+
+// AnalyzeLinkUsage returns usage of all graph links in the propagation log,
+// sorted by hits, most used first, and then by link index.
+func AnalyzeLinkUsage(plog *propagation.Log, g *graph.Graph) LinkUsages {
+	hits := linkHits(plog)
+	nodes := g.Nodes()
+	ret := make(LinkUsages, 0, g.NumLinks())
+	for i, link := range g.Links() {
+		ret = append(ret, LinkUsage{
+			Link:   i,
+			Source: nodes[link.FromIdx()].ID(),
+			Target: nodes[link.ToIdx()].ID(),
+			Hits:   hits[i],
+		})
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Hits > ret[j].Hits })
+	return ret
+}
+
+// linkHits returns number of times each link was used in log.
+func linkHits(plog *propagation.Log) map[int]int {
+	hits := make(map[int]int)
+	for _, links := range plog.Links {
+		for _, j := range links {
+			hits[j]++
+		}
+	}
+	return hits
+}
+
+// Top returns up to k most used links.
+func (lu LinkUsages) Top(k int) LinkUsages {
+	if len(lu) > k {
+		return lu[:k]
+	}
+	return lu
+}
+
+// WriteCSV writes links usage as CSV with 'link', 'source', 'target' and
+// 'hits' columns, one row per link.
+func (lu LinkUsages) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"link", "source", "target", "hits"}); err != nil {
+		return err
+	}
+	for _, u := range lu {
+		record := []string{strconv.Itoa(u.Link), u.Source, u.Target, strconv.Itoa(u.Hits)}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// String implements Stringer interface for LinkUsages.
+func (lu LinkUsages) String() string {
+	lines := make([]string, len(lu))
+	for i, u := range lu {
+		lines[i] = fmt.Sprintf("  link %d (%s - %s): %d hits", u.Link, u.Source, u.Target, u.Hits)
+	}
+	return "\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,36 @@
+package stats
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestAnalyzeLinkUsage(t *testing.T) {
+	g := testGraph()
+	plog := propagation.NewLog(2)
+	plog.AddStep(10, []int{0, 1, 0, 3}, []int{0, 3})
+	plog.AddStep(20, []int{1, 2, 3, 0}, []int{1, 3})
+
+	lu := AnalyzeLinkUsage(plog, g)
+	if len(lu) != 4 {
+		t.Fatalf("Expected all 4 links, got %d", len(lu))
+	}
+	top := lu.Top(2)
+	if top[0] != (LinkUsage{Link: 3, Source: "0", Target: "3", Hits: 2}) || top[1].Link != 0 {
+		t.Fatalf("Unexpected top links: %v", top)
+	}
+	if lu[3].Link != 2 || lu[3].Hits != 0 {
+		t.Fatalf("Expected unused link 2 to be the last, got %v", lu[3])
+	}
+
+	var buf bytes.Buffer
+	if err := top.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := "link,source,target,hits\n3,0,3,2\n0,0,1,1\n"
+	if buf.String() != expected {
+		t.Fatalf("Expected CSV %q, got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,18 @@
+package stats
+
+import "github.com/divan/simulation/propagation"
+
+// AnalyzeMessages analyzes propagation of each message in the log of
+// multiple messages (see propagation.Log.Messages), keyed by message
+// identifier. It returns nil for logs of a single message.
+func AnalyzeMessages(plog *propagation.Log, nodeCount, linkCount int) map[string]*Stats {
+	ids := plog.MessageIDs()
+	if len(ids) == 0 {
+		return nil
+	}
+	ret := make(map[string]*Stats, len(ids))
+	for _, id := range ids {
+		ret[id] = Analyze(plog.Filter(id), nodeCount, linkCount)
+	}
+	return ret
+}
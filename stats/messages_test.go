@@ -0,0 +1,29 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestAnalyzeMessages(t *testing.T) {
+	plog := propagation.NewLog(2)
+	plog.AddStep(10, []int{0, 1, 0, 2}, []int{0, 1})
+	plog.AddStep(30, []int{2, 3}, []int{2})
+	if AnalyzeMessages(plog, 4, 3) != nil {
+		t.Fatalf("Expected no per message stats for a single message")
+	}
+
+	plog.Messages = [][]string{{"a", "b"}, {"b"}}
+	ss := AnalyzeMessages(plog, 4, 3)
+	if len(ss) != 2 {
+		t.Fatalf("Expected stats of 2 messages, got %d", len(ss))
+	}
+	if ss["a"].NodeCoverage.Actual != 2 || ss["a"].Time != 10*time.Millisecond {
+		t.Fatalf("Expected message a to reach 2 nodes in 10ms, got %v in %v", ss["a"].NodeCoverage, ss["a"].Time)
+	}
+	if ss["b"].NodeCoverage.Actual != 3 || ss["b"].Time != 30*time.Millisecond {
+		t.Fatalf("Expected message b to reach 3 nodes in 30ms, got %v in %v", ss["b"].NodeCoverage, ss["b"].Time)
+	}
+}
@@ -0,0 +1,87 @@
+package stats
+
+import (
+	"fmt"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/preflight"
+	"github.com/divan/simulation/propagation"
+)
+
+// Reachability attributes nodes not covered by the message either to the
+// network topology, for nodes unreachable from senders over graph links,
+// or to the protocol, which failed to reach them.
+type Reachability struct {
+	Components       int // connected components of the graph
+	LargestComponent int // nodes in the largest component
+	Reachable        int // nodes reachable from senders, including senders
+	Unreachable      int // uncovered nodes unreachable by topology
+	NotReached       int // uncovered nodes, which protocol failed to reach
+}
+
+// AnalyzeReachability analyzes reachability of nodes from the given sender
+// nodes over the graph links, following their direction for directed
+// graphs, and attributes nodes not covered in the propagation log.
+func AnalyzeReachability(plog *propagation.Log, g *graph.Graph, directed bool, senders ...int) *Reachability {
+	comps := preflight.Components(g)
+	r := &Reachability{Components: len(comps)}
+	if len(comps) > 0 {
+		r.LargestComponent = len(comps[0])
+	}
+
+	reachable := reachableFrom(g, directed, senders)
+	covered := timeToNode(plog)
+	for _, sender := range senders {
+		covered[sender] = 0
+	}
+	r.Reachable = len(reachable)
+	for i := 0; i < g.NumNodes(); i++ {
+		if _, ok := covered[i]; ok {
+			continue
+		}
+		if reachable[i] {
+			r.NotReached++
+		} else {
+			r.Unreachable++
+		}
+	}
+	return r
+}
+
+// reachableFrom returns nodes reachable from senders with BFS.
+func reachableFrom(g *graph.Graph, directed bool, senders []int) map[int]bool {
+	peers := make(map[int][]int)
+	for _, link := range g.Links() {
+		from, to := link.FromIdx(), link.ToIdx()
+		peers[from] = append(peers[from], to)
+		if !directed {
+			peers[to] = append(peers[to], from)
+		}
+	}
+
+	reached := make(map[int]bool)
+	var queue []int
+	for _, sender := range senders {
+		if sender >= 0 && sender < g.NumNodes() && !reached[sender] {
+			reached[sender] = true
+			queue = append(queue, sender)
+		}
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, peer := range peers[node] {
+			if !reached[peer] {
+				reached[peer] = true
+				queue = append(queue, peer)
+			}
+		}
+	}
+	return reached
+}
+
+// String implements Stringer interface for Reachability.
+func (r *Reachability) String() string {
+	return fmt.Sprintf("%d components (largest %d nodes), %d nodes reachable, uncovered: %d unreachable by topology, %d not reached by protocol",
+		r.Components, r.LargestComponent, r.Reachable, r.Unreachable, r.NotReached)
+}
@@ -0,0 +1,48 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+)
+
+func TestAnalyzeReachability(t *testing.T) {
+	// triangle 0-1-2 with leaf 3, and separate pair 4-5
+	g := testGraph()
+	g.AddNode(node("4"))
+	g.AddNode(node("5"))
+	g.AddLink("4", "5")
+
+	plog := propagation.NewLog(1)
+	plog.AddStep(10, []int{0, 1}, []int{0})
+
+	r := AnalyzeReachability(plog, g, false, 0)
+	if r.Components != 2 || r.LargestComponent != 4 {
+		t.Fatalf("Expected 2 components with largest of 4 nodes, got %d and %d", r.Components, r.LargestComponent)
+	}
+	if r.Reachable != 4 {
+		t.Fatalf("Expected 4 reachable nodes, got %d", r.Reachable)
+	}
+	if r.NotReached != 2 || r.Unreachable != 2 {
+		t.Fatalf("Expected 2 not reached and 2 unreachable nodes, got %d and %d", r.NotReached, r.Unreachable)
+	}
+}
+
+func TestAnalyzeReachabilityDirected(t *testing.T) {
+	g := graph.NewGraph()
+	g.AddNode(node("0"))
+	g.AddNode(node("1"))
+	g.AddNode(node("2"))
+	g.AddLink("1", "0")
+	g.AddLink("1", "2")
+
+	r := AnalyzeReachability(propagation.NewLog(0), g, true, 0)
+	if r.Reachable != 1 || r.Unreachable != 2 {
+		t.Fatalf("Expected only sender reachable, got %d reachable, %d unreachable", r.Reachable, r.Unreachable)
+	}
+	r = AnalyzeReachability(propagation.NewLog(0), g, false, 0)
+	if r.Reachable != 3 || r.NotReached != 2 {
+		t.Fatalf("Expected all nodes reachable, got %d reachable, %d not reached", r.Reachable, r.NotReached)
+	}
+}
@@ -0,0 +1,88 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+	"github.com/gonum/stat"
+)
+
+// Spread describes distribution of a metric across runs.
+type Spread struct {
+	Min    float64
+	Median float64
+	P95    float64
+	Max    float64
+}
+
+// Starts describes distribution of coverage and latency across runs sent
+// from different start nodes, so results don't depend on the choice of
+// the sender.
+type Starts struct {
+	Runs     int
+	Coverage Spread // percentage of nodes reached
+	P50      Spread // median time-to-node, in milliseconds
+	P95      Spread // p95 time-to-node, in milliseconds
+	Worst    int    // start node of the run with the lowest coverage
+}
+
+// AnalyzeStarts analyzes propagation logs of runs sent from the given start
+// nodes, in order of logs, for network of the given number of nodes.
+func AnalyzeStarts(logs []*propagation.Log, starts []int, nodes int) *Starts {
+	s := &Starts{Runs: len(logs), Worst: -1}
+	var coverage, p50, p95 []float64
+	worst := -1.0
+	for i, plog := range logs {
+		c := NewCoverage(len(timeToNode(plog)), nodes).Percentage
+		if worst < 0 || c < worst {
+			worst, s.Worst = c, starts[i]
+		}
+		latencies := LatencyPercentiles(plog, 0.5, 0.95)
+		coverage = append(coverage, c)
+		p50 = append(p50, float64(latencies[0]/time.Millisecond))
+		p95 = append(p95, float64(latencies[1]/time.Millisecond))
+	}
+	s.Coverage = newSpread(coverage)
+	s.P50 = newSpread(p50)
+	s.P95 = newSpread(p95)
+	return s
+}
+
+// newSpread returns distribution of x.
+func newSpread(x []float64) Spread {
+	if len(x) == 0 {
+		return Spread{}
+	}
+	sort.Float64s(x)
+	return Spread{
+		Min:    x[0],
+		Median: stat.Quantile(0.5, stat.Empirical, x, nil),
+		P95:    stat.Quantile(0.95, stat.Empirical, x, nil),
+		Max:    x[len(x)-1],
+	}
+}
+
+// durations formats spread of latencies in milliseconds.
+func (s Spread) durations() string {
+	return fmt.Sprintf("min %v, median %v, p95 %v, max %v", ms(s.Min), ms(s.Median), ms(s.P95), ms(s.Max))
+}
+
+// String implements Stringer interface for Starts.
+func (s *Starts) String() string {
+	lines := []string{
+		fmt.Sprintf("  runs: %d", s.Runs),
+		fmt.Sprintf("  coverage: min %.0f%%, median %.0f%%, p95 %.0f%%, max %.0f%% (lowest from node %d)",
+			s.Coverage.Min, s.Coverage.Median, s.Coverage.P95, s.Coverage.Max, s.Worst),
+		"  p50: " + s.P50.durations(),
+		"  p95: " + s.P95.durations(),
+	}
+	return "\n" + strings.Join(lines, "\n")
+}
+
+// ms converts milliseconds into time.Duration.
+func ms(x float64) time.Duration {
+	return time.Duration(x * float64(time.Millisecond))
+}
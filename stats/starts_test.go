@@ -0,0 +1,26 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestAnalyzeStarts(t *testing.T) {
+	full := propagation.NewLog(2)
+	full.AddStep(10, []int{0, 1}, []int{0, 1})
+	full.AddStep(30, []int{2, 3}, []int{1, 2})
+	half := propagation.NewLog(1)
+	half.AddStep(20, []int{3, 2}, []int{3, 2})
+
+	s := AnalyzeStarts([]*propagation.Log{full, half}, []int{0, 3}, 4)
+	if s.Runs != 2 || s.Worst != 3 {
+		t.Fatalf("Expected 2 runs with the lowest coverage from node 3, got %v", s)
+	}
+	if s.Coverage.Min != 50 || s.Coverage.Max != 100 {
+		t.Fatalf("Expected coverage from 50%% to 100%%, got %v", s.Coverage)
+	}
+	if s.P50.Min != 10 || s.P50.Max != 20 {
+		t.Fatalf("Expected p50 from 10ms to 20ms, got %v", s.P50)
+	}
+}
@@ -2,6 +2,8 @@ package stats
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"time"
 
 	"github.com/divan/simulation/propagation"
@@ -10,27 +12,114 @@ import (
 // Stats represents stats data for given simulation log.
 type Stats struct {
 	NodeHits            map[int]int
+	LinkHits            map[int]int
 	NodeCoverage        Coverage
 	LinkCoverage        Coverage
 	NodeHistogram       *Histogram
 	LinkHistogram       *Histogram
 	TimeToNodeHistogram *Histogram
 	Time                time.Duration
+	Velocity            Velocity                 // see AnalyzeVelocity
+	CriticalPath        *CriticalPath            // see AnalyzeCriticalPath
+	Duplicates          int                      // payload deliveries to nodes that already had the message
+	Efficiency          Efficiency               // see AnalyzeEfficiency
+	Traffic             *propagation.Traffic     // nil if not tracked by simulator
+	Offline             *propagation.Offline     // nil if nodes are always online
+	Reliability         *propagation.Reliability // nil if links are lossless
+	Mesh                *propagation.Mesh        // nil if messages aren't pushed over mesh
+	Sampling            *propagation.Sampling    // nil if all events are recorded
+	Events              *propagation.EventCounts // nil if typed events aren't observed
+	Energy              *Energy                  // nil if not analyzed, see AnalyzeEnergy
+	Topic               *TopicStats              // nil if not analyzed, see AnalyzeTopic
+	Reachability        *Reachability            // nil if not analyzed, see AnalyzeReachability
+	Groups              Groups                   // nil if not analyzed, see AnalyzeGroups
+	Roles               Groups                   // nil if not analyzed, groups of nodes by Waku roles
+	Transports          Groups                   // nil if not analyzed, groups of nodes by transport
+	Communities         *CommunityStats          // nil if not analyzed, see AnalyzeCommunities
+	Links               LinkUsages               // nil if not analyzed, see AnalyzeLinkUsage
+	Delivery            *Delivery                // nil if message isn't point-to-point, see AnalyzeDelivery
+	Targets             Targets                  // nil if not analyzed, see AnalyzeTargets
+	Bridges             *BridgeStats             // nil if not analyzed, see AnalyzeBridges
+	Failures            *Failures                // nil if no nodes were killed, see AnalyzeFailures
 }
 
 // PrintVerbose prints detailed terminal-friendly stats to
 // the console.
 func (s *Stats) PrintVerbose() {
-	fmt.Println("Stats:")
-	fmt.Println("Time elapsed:", s.Time)
-	fmt.Println("Nodes coverage:", s.NodeCoverage)
-	fmt.Println("Links coverage:", s.LinkCoverage)
-	fmt.Println("Nodes histogram:", s.NodeHistogram)
-	fmt.Println("Links histogram:", s.LinkHistogram)
-	fmt.Println("TimeToNode histogram:", s.TimeToNodeHistogram)
+	s.FprintVerbose(os.Stdout)
+}
+
+// FprintVerbose prints detailed terminal-friendly stats to w.
+func (s *Stats) FprintVerbose(w io.Writer) {
+	fmt.Fprintln(w, "Stats:")
+	fmt.Fprintln(w, "Time elapsed:", s.Time)
+	fmt.Fprintln(w, "Nodes coverage:", s.NodeCoverage)
+	fmt.Fprintln(w, "Links coverage:", s.LinkCoverage)
+	fmt.Fprintln(w, "Nodes histogram:", s.NodeHistogram)
+	fmt.Fprintln(w, "Links histogram:", s.LinkHistogram)
+	fmt.Fprintln(w, "TimeToNode histogram:", s.TimeToNodeHistogram)
+	fmt.Fprintln(w, "Velocity:", s.Velocity)
+	fmt.Fprintln(w, "Critical path:", s.CriticalPath)
+	fmt.Fprintln(w, "Duplicates:", s.Duplicates)
+	fmt.Fprintln(w, "Efficiency:", s.Efficiency)
+	if s.Traffic != nil {
+		fmt.Fprintln(w, "Traffic:", s.Traffic)
+	}
+	if s.Offline != nil {
+		fmt.Fprintln(w, "Offline delays:", s.Offline)
+	}
+	if s.Reliability != nil {
+		fmt.Fprintln(w, "Reliability:", s.Reliability)
+	}
+	if s.Mesh != nil {
+		fmt.Fprintln(w, "Mesh:", s.Mesh)
+	}
+	if s.Sampling != nil {
+		fmt.Fprintln(w, "Sampling:", s.Sampling, "(duplicates and efficiency are estimated, links and nodes histograms count recorded events only)")
+	}
+	if s.Events != nil {
+		fmt.Fprintln(w, "Events:", s.Events)
+	}
+	if s.Energy != nil {
+		fmt.Fprintln(w, "Energy:", s.Energy)
+	}
+	if s.Topic != nil {
+		fmt.Fprintln(w, "Topic:", s.Topic)
+	}
+	if s.Reachability != nil {
+		fmt.Fprintln(w, "Reachability:", s.Reachability)
+	}
+	if s.Groups != nil {
+		fmt.Fprintln(w, "Groups:", s.Groups)
+	}
+	if s.Roles != nil {
+		fmt.Fprintln(w, "Roles:", s.Roles)
+	}
+	if s.Transports != nil {
+		fmt.Fprintln(w, "Transports:", s.Transports)
+	}
+	if s.Communities != nil {
+		fmt.Fprintln(w, "Communities:", s.Communities)
+	}
+	if s.Links != nil {
+		fmt.Fprintln(w, "Most used links:", s.Links)
+	}
+	if s.Delivery != nil {
+		fmt.Fprintln(w, "Delivery:", s.Delivery)
+	}
+	if s.Targets != nil {
+		fmt.Fprintln(w, "Targets:", s.Targets)
+	}
+	if s.Bridges != nil {
+		fmt.Fprintln(w, "Bridges:", s.Bridges)
+	}
+	if s.Failures != nil {
+		fmt.Fprintln(w, "Failures:", s.Failures)
+	}
 }
 
 // Analyze analyzes given propagation log and returns filled Stats object.
+// Velocity is analyzed with DefaultVelocityParams.
 func Analyze(plog *propagation.Log, nodeCount, linkCount int) *Stats {
 	t := analyzeTiming(plog)
 	nodeHits, nodeHistogram := analyzeNodeHits(plog)
@@ -40,12 +129,22 @@ func Analyze(plog *propagation.Log, nodeCount, linkCount int) *Stats {
 
 	return &Stats{
 		NodeHits:            nodeHits,
+		LinkHits:            linkHits(plog),
 		NodeCoverage:        nodeCoverage,
 		LinkCoverage:        linkCoverage,
 		NodeHistogram:       nodeHistogram,
 		LinkHistogram:       linkHistogram,
 		TimeToNodeHistogram: timeToNodeHistogram,
 		Time:                t,
+		Velocity:            AnalyzeVelocity(plog, nodeCount, DefaultVelocityParams()),
+		CriticalPath:        AnalyzeCriticalPath(plog),
+		Duplicates:          analyzeDuplicates(plog),
+		Efficiency:          AnalyzeEfficiency(plog, nodeCount),
+		Traffic:             plog.Traffic,
+		Offline:             plog.Offline,
+		Reliability:         plog.Reliability,
+		Mesh:                plog.Mesh,
+		Sampling:            plog.Sampling,
 	}
 }
 
@@ -101,19 +200,45 @@ func analyzeTiming(plog *propagation.Log) time.Duration {
 }
 
 func analyzeTimeToNode(plog *propagation.Log) *Histogram {
+	hits := timeToNode(plog)
+
+	x := make([]float64, 0, len(plog.Nodes))
+	for _, ts := range hits {
+		x = append(x, float64(ts))
+	}
+	return NewHistogram(x, 20)
+}
+
+// analyzeDuplicates returns the number of deliveries to nodes that
+// already received the message before. Each link in the log step
+// represents a single delivery. For sampled logs, deliveries skipped
+// during collection are duplicates as well, as first arrivals are always
+// recorded.
+func analyzeDuplicates(plog *propagation.Log) int {
+	var deliveries int
+	for _, links := range plog.Links {
+		deliveries += len(links)
+	}
+	if plog.Sampling != nil {
+		deliveries += int(plog.Sampling.Skipped)
+	}
+	reached := len(NewTree(plog).Parent)
+	if deliveries < reached {
+		return 0
+	}
+	return deliveries - reached
+}
+
+// timeToNode returns the timestamp of the first hit for each node in log.
+func timeToNode(plog *propagation.Log) map[int]int {
 	var hits = make(map[int]int)
 	for i, ts := range plog.Timestamps {
 		nodes := plog.Nodes[i]
 		for _, j := range nodes {
-			if _, ok := hits[j]; !ok {
+			if first, ok := hits[j]; !ok || ts < first {
 				hits[j] = ts
 			}
 		}
 	}
-
-	x := make([]float64, 0, len(plog.Nodes))
-	for _, ts := range hits {
-		x = append(x, float64(ts))
-	}
-	return NewHistogram(x, 20)
+	return hits
 }
@@ -81,3 +81,19 @@ func BenchmarkAnalyze(b *testing.B) {
 		Analyze(plog, g.NumNodes(), g.NumLinks())
 	}
 }
+
+func TestDuplicates(t *testing.T) {
+	plog := propagation.NewLog(2)
+	plog.AddStep(10, []int{0, 1, 0, 2}, []int{0, 2})
+	plog.AddStep(20, []int{1, 2}, []int{1})
+
+	stats := Analyze(plog, 4, 4)
+	if stats.Duplicates != 1 {
+		t.Fatalf("Expected 1 duplicate, but got %d", stats.Duplicates)
+	}
+
+	plog.Sampling = &propagation.Sampling{Rate: 10, Recorded: 3, Skipped: 5}
+	if stats = Analyze(plog, 4, 4); stats.Duplicates != 6 {
+		t.Fatalf("Expected 6 duplicates with skipped ones, but got %d", stats.Duplicates)
+	}
+}
@@ -0,0 +1,81 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// TargetStats describes delivery of the message to a single target node
+// across runs, for cases where delivery time to the specific nodes
+// matters rather than full coverage.
+type TargetStats struct {
+	Node      int
+	Runs      int
+	Delivered int           // runs in which the target was reached
+	Median    time.Duration // delivery time, among runs the target was reached
+	P95       time.Duration
+	Max       time.Duration
+}
+
+// Targets holds stats of all target nodes.
+type Targets []TargetStats
+
+// AnalyzeTargets analyzes delivery time to the target nodes in each of
+// propagation logs of independent runs. Targets are sorted by node index.
+func AnalyzeTargets(logs []*propagation.Log, targets ...int) Targets {
+	latencies := make(map[int][]float64, len(targets))
+	for _, plog := range logs {
+		reached := timeToNode(plog)
+		for _, node := range targets {
+			if ts, ok := reached[node]; ok {
+				latencies[node] = append(latencies[node], float64(ts))
+			}
+		}
+	}
+
+	ret := make(Targets, 0, len(targets))
+	for _, node := range targets {
+		x := latencies[node]
+		sort.Float64s(x)
+		t := TargetStats{
+			Node:      node,
+			Runs:      len(logs),
+			Delivered: len(x),
+			Median:    quantile(0.5, x),
+			P95:       quantile(0.95, x),
+		}
+		if len(x) > 0 {
+			t.Max = msToDuration(int(x[len(x)-1]))
+		}
+		ret = append(ret, t)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Node < ret[j].Node })
+	return ret
+}
+
+// Success returns fraction of runs in which the target was reached.
+func (t TargetStats) Success() float64 {
+	if t.Runs == 0 {
+		return 0
+	}
+	return float64(t.Delivered) / float64(t.Runs)
+}
+
+// String implements Stringer interface for TargetStats.
+func (t TargetStats) String() string {
+	return fmt.Sprintf("node %d: delivered %d/%d (%.0f%%), median %v, p95 %v, max %v",
+		t.Node, t.Delivered, t.Runs, 100*t.Success(), t.Median, t.P95, t.Max)
+}
+
+// String implements Stringer interface for Targets.
+func (ts Targets) String() string {
+	lines := make([]string, len(ts))
+	for i, t := range ts {
+		lines[i] = "  " + t.String()
+	}
+	return "\n" + strings.Join(lines, "\n")
+}
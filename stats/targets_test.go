@@ -0,0 +1,28 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestAnalyzeTargets(t *testing.T) {
+	first := propagation.NewLog(2)
+	first.AddStep(10, []int{0, 1}, []int{0})
+	first.AddStep(30, []int{2}, []int{1})
+	second := propagation.NewLog(1)
+	second.AddStep(20, []int{0, 1}, []int{0})
+
+	ts := AnalyzeTargets([]*propagation.Log{first, second}, 2, 1)
+	if len(ts) != 2 {
+		t.Fatalf("Expected 2 targets, got %d", len(ts))
+	}
+	one, two := ts[0], ts[1]
+	if one.Node != 1 || one.Delivered != 2 || one.Max != 20*time.Millisecond {
+		t.Fatalf("Unexpected stats for node 1: %v", one)
+	}
+	if two.Node != 2 || two.Delivered != 1 || two.Success() != 0.5 || two.Median != 30*time.Millisecond {
+		t.Fatalf("Unexpected stats for node 2: %v", two)
+	}
+}
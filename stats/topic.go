@@ -0,0 +1,38 @@
+package stats
+
+import (
+	"fmt"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// TopicStats describes delivery of the message published on topic to
+// nodes subscribed to it, and to other nodes relaying it.
+type TopicStats struct {
+	Topic       string
+	Subscribers Coverage // subscribers reached, out of all subscribers
+	Others      int      // reached nodes not subscribed to topic
+}
+
+// AnalyzeTopic calculates delivery stats of the message published on
+// topic from the propagation log.
+func AnalyzeTopic(plog *propagation.Log, subs propagation.Subscriptions, topic string) *TopicStats {
+	subscribers := subs.Subscribers(topic)
+	reached := timeToNode(plog)
+	var actual int
+	for _, node := range subscribers {
+		if _, ok := reached[node]; ok {
+			actual++
+		}
+	}
+	return &TopicStats{
+		Topic:       topic,
+		Subscribers: NewCoverage(actual, len(subscribers)),
+		Others:      len(reached) - actual,
+	}
+}
+
+// String implements Stringer interface for TopicStats.
+func (t *TopicStats) String() string {
+	return fmt.Sprintf("'%s' subscribers reached: %v, other nodes reached: %d", t.Topic, t.Subscribers, t.Others)
+}
@@ -0,0 +1,22 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestAnalyzeTopic(t *testing.T) {
+	plog := propagation.NewLog(2)
+	plog.AddStep(10, []int{0, 1, 0, 2}, []int{0, 1})
+	plog.AddStep(20, []int{2, 3}, []int{2})
+	subs := propagation.Subscriptions{{"a"}, {"a"}, nil, {"b"}, {"a"}}
+
+	ts := AnalyzeTopic(plog, subs, "a")
+	if ts.Subscribers != NewCoverage(2, 3) {
+		t.Fatalf("Expected 2 of 3 subscribers reached, got %v", ts.Subscribers)
+	}
+	if ts.Others != 2 {
+		t.Fatalf("Expected 2 other nodes reached, got %d", ts.Others)
+	}
+}
@@ -0,0 +1,112 @@
+package stats
+
+import (
+	"sort"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// Tree represents the first-arrival propagation tree, where each node
+// is linked to the peer it received the message from for the first time.
+// Logs of multiple senders make a forest, with a root per sender.
+type Tree struct {
+	Root   int         // the first sender, -1 if log is empty
+	Roots  []int       // all senders, in order of their first sending
+	Parent map[int]int // node index -> index of the parent node
+	Link   map[int]int // node index -> index of the link from the parent
+	Time   map[int]int // node index -> first arrival timestamp, in ms
+}
+
+// NewTree builds the propagation tree out of the propagation log.
+//
+// It relies on the fact that nodes in each log step are stored in pairs
+// of sender and receiver (see propagation.LogEntries2Log). Nodes sending
+// before being reached are senders, which are roots at time 0 and never
+// get a parent, even if the message is echoed back to them.
+func NewTree(plog *propagation.Log) *Tree {
+	t := &Tree{
+		Root:   -1,
+		Parent: make(map[int]int),
+		Link:   make(map[int]int),
+		Time:   make(map[int]int),
+	}
+
+	// log steps are not guaranteed to be sorted
+	steps := make([]int, len(plog.Timestamps))
+	for i := range steps {
+		steps[i] = i
+	}
+	sort.SliceStable(steps, func(i, j int) bool {
+		return plog.Timestamps[steps[i]] < plog.Timestamps[steps[j]]
+	})
+
+	for _, i := range steps {
+		ts, nodes := plog.Timestamps[i], plog.Nodes[i]
+		var links []int
+		if i < len(plog.Links) {
+			links = plog.Links[i]
+		}
+		for k := 0; k+1 < len(nodes); k += 2 {
+			from, to := nodes[k], nodes[k+1]
+			if _, ok := t.Time[from]; !ok {
+				if t.Root == -1 {
+					t.Root = from
+				}
+				t.Roots = append(t.Roots, from)
+				t.Time[from] = 0
+			}
+			if _, ok := t.Time[to]; ok {
+				continue
+			}
+			t.Parent[to] = from
+			t.Time[to] = ts
+			if k/2 < len(links) {
+				t.Link[to] = links[k/2]
+			}
+		}
+	}
+	return t
+}
+
+// Children returns the map of node's children, sorted by arrival time.
+func (t *Tree) Children() map[int][]int {
+	children := make(map[int][]int)
+	for node, parent := range t.Parent {
+		children[parent] = append(children[parent], node)
+	}
+	for _, nodes := range children {
+		sort.Slice(nodes, func(i, j int) bool {
+			if t.Time[nodes[i]] != t.Time[nodes[j]] {
+				return t.Time[nodes[i]] < t.Time[nodes[j]]
+			}
+			return nodes[i] < nodes[j]
+		})
+	}
+	return children
+}
+
+// Depth returns the number of hops from the node's root to the node.
+func (t *Tree) Depth(node int) int {
+	return len(t.PathTo(node)) - 1
+}
+
+// PathTo returns nodes on the way from the node's root to the node,
+// including both. It stops at the already visited node, so it never loops
+// even if parents form a cycle.
+func (t *Tree) PathTo(node int) []int {
+	path := []int{node}
+	visited := map[int]bool{node: true}
+	for {
+		parent, ok := t.Parent[node]
+		if !ok || visited[parent] {
+			break
+		}
+		visited[parent] = true
+		path = append(path, parent)
+		node = parent
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
@@ -0,0 +1,89 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+// VelocityParams defines how propagation velocity and area under the
+// coverage curve are measured.
+type VelocityParams struct {
+	// Window is the sliding window nodes reached are counted in to
+	// get velocity.
+	Window time.Duration
+
+	// Horizon is the time coverage curve is integrated up to. Use the
+	// same horizon to compare different logs, zero means time of the
+	// last log step.
+	Horizon time.Duration
+}
+
+// DefaultVelocityParams returns velocity parameters with 100ms window
+// and horizon of the log itself.
+func DefaultVelocityParams() VelocityParams {
+	return VelocityParams{
+		Window: 100 * time.Millisecond,
+	}
+}
+
+// Velocity describes how fast the message spreads over the network, in
+// single numbers suitable for comparing protocol variants.
+type Velocity struct {
+	Peak     float64       // max nodes reached per second, within the window
+	PeakTime time.Duration // start of the window with peak velocity
+	// AUC is the area under the coverage curve divided by horizon, i.e.
+	// mean fraction of nodes having the message up to the horizon. It's 1
+	// if all nodes got the message instantly.
+	AUC     float64
+	Horizon time.Duration
+}
+
+// AnalyzeVelocity calculates peak propagation velocity and area under the
+// coverage curve of the propagation log.
+func AnalyzeVelocity(plog *propagation.Log, nodeCount int, params VelocityParams) Velocity {
+	times := sortedValues(timeToNode(plog)) // in ms
+	v := Velocity{Horizon: params.Horizon}
+	if v.Horizon <= 0 {
+		v.Horizon = analyzeTiming(plog)
+	}
+
+	if params.Window > 0 {
+		window := float64(params.Window) / float64(time.Millisecond)
+		var j int
+		for i, ts := range times {
+			for j < len(times) && times[j] < ts+window {
+				j++
+			}
+			if rate := float64(j-i) / params.Window.Seconds(); rate > v.Peak {
+				v.Peak = rate
+				v.PeakTime = msToDuration(int(ts))
+			}
+		}
+	}
+
+	if nodeCount == 0 {
+		return v
+	}
+	horizon := float64(v.Horizon) / float64(time.Millisecond)
+	if horizon <= 0 {
+		// everything happened at once
+		v.AUC = float64(len(times)) / float64(nodeCount)
+		return v
+	}
+	// each node adds to the coverage from its arrival till horizon
+	var area float64
+	for _, ts := range times {
+		if ts < horizon {
+			area += horizon - ts
+		}
+	}
+	v.AUC = area / horizon / float64(nodeCount)
+	return v
+}
+
+// String implements Stringer interface for Velocity.
+func (v Velocity) String() string {
+	return fmt.Sprintf("peak %.1f nodes/s at %v, coverage AUC %.3f (horizon %v)", v.Peak, v.PeakTime, v.AUC, v.Horizon)
+}
@@ -0,0 +1,32 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+)
+
+func TestAnalyzeVelocity(t *testing.T) {
+	plog := propagation.NewLog(3)
+	plog.AddStep(0, []int{0, 1}, []int{0})
+	plog.AddStep(100, []int{1, 2, 1, 3}, []int{1, 2})
+	plog.AddStep(150, []int{3, 4}, []int{3})
+
+	params := VelocityParams{Window: 100 * time.Millisecond, Horizon: 200 * time.Millisecond}
+	v := AnalyzeVelocity(plog, 5, params)
+	if v.Peak != 30 || v.PeakTime != 100*time.Millisecond {
+		t.Fatalf("Expected peak of 30 nodes/s at 100ms, got %v", v)
+	}
+	// node arrivals at 0, 0, 100, 100, 150 out of 200ms
+	expected := (200 + 200 + 100 + 100 + 50) / 200.0 / 5
+	if math.Abs(v.AUC-expected) > 1e-9 {
+		t.Fatalf("Expected AUC %v, got %v", expected, v.AUC)
+	}
+
+	v = AnalyzeVelocity(plog, 5, VelocityParams{Window: time.Second})
+	if v.Horizon != 150*time.Millisecond {
+		t.Fatalf("Expected horizon of the log, got %v", v.Horizon)
+	}
+}
@@ -0,0 +1,201 @@
+// Package store implements SQLite results store, which keeps simulation
+// metadata, propagation logs and stats of many runs in a single file,
+// so they can be queried with SQL.
+//
+// Example query for average coverage by algorithm:
+//
+//	SELECT algorithm, AVG(node_coverage) FROM runs GROUP BY algorithm;
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/stats"
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	started_at    TIMESTAMP NOT NULL,
+	algorithm     TEXT NOT NULL,
+	network       TEXT NOT NULL,
+	nodes         INTEGER NOT NULL,
+	links         INTEGER NOT NULL,
+	ttl           INTEGER NOT NULL,
+	size          INTEGER NOT NULL,
+	duration_ms   INTEGER NOT NULL,
+	node_coverage REAL NOT NULL,
+	link_coverage REAL NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS steps (
+	run_id   INTEGER NOT NULL REFERENCES runs(id),
+	step     INTEGER NOT NULL,
+	ts       INTEGER NOT NULL,
+	nodes    TEXT NOT NULL,
+	links    TEXT NOT NULL,
+	messages TEXT, -- message ids of links, NULL if log isn't tagged
+	PRIMARY KEY (run_id, step)
+);
+
+CREATE TABLE IF NOT EXISTS arrivals (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	node   INTEGER NOT NULL,
+	parent INTEGER,
+	ts     INTEGER NOT NULL,
+	PRIMARY KEY (run_id, node)
+);
+`
+
+// Store represents SQLite results store.
+type Store struct {
+	db *sql.DB
+}
+
+// Run holds the results of a single simulation run.
+type Run struct {
+	Started   time.Time
+	Algorithm string
+	Network   string // network graph filename
+	Nodes     int
+	Links     int
+	TTL       int
+	Size      int
+	Log       *propagation.Log
+	Stats     *stats.Stats
+}
+
+// Open opens the SQLite database at path, creating it and the
+// schema if needed.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %v", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %v", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// SaveRun stores run metadata, propagation log steps and first arrival
+// times for each node in a single transaction. It returns the run ID.
+func (s *Store) SaveRun(r *Run) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	id, err := saveRun(tx, r)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func saveRun(tx *sql.Tx, r *Run) (int64, error) {
+	res, err := tx.Exec(`INSERT INTO runs
+		(started_at, algorithm, network, nodes, links, ttl, size, duration_ms, node_coverage, link_coverage)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Started, r.Algorithm, r.Network, r.Nodes, r.Links, r.TTL, r.Size,
+		int64(r.Stats.Time/time.Millisecond), r.Stats.NodeCoverage.Percentage, r.Stats.LinkCoverage.Percentage)
+	if err != nil {
+		return 0, fmt.Errorf("insert run: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO steps (run_id, step, ts, nodes, links, messages) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for i, ts := range r.Log.Timestamps {
+		nodes, _ := json.Marshal(r.Log.Nodes[i])
+		links, _ := json.Marshal(r.Log.Links[i])
+		var messages sql.NullString
+		if i < len(r.Log.Messages) {
+			data, _ := json.Marshal(r.Log.Messages[i])
+			messages = sql.NullString{String: string(data), Valid: true}
+		}
+		if _, err := stmt.Exec(id, i, ts, string(nodes), string(links), messages); err != nil {
+			return 0, fmt.Errorf("insert step %d: %v", i, err)
+		}
+	}
+
+	stmt, err = tx.Prepare(`INSERT INTO arrivals (run_id, node, parent, ts) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	tree := stats.NewTree(r.Log)
+	for node, ts := range tree.Time {
+		var parent sql.NullInt64
+		if p, ok := tree.Parent[node]; ok {
+			parent = sql.NullInt64{Int64: int64(p), Valid: true}
+		}
+		if _, err := stmt.Exec(id, node, parent, ts); err != nil {
+			return 0, fmt.Errorf("insert arrival for node %d: %v", node, err)
+		}
+	}
+	return id, nil
+}
+
+// LoadLog reads propagation log of the run with given ID, with message
+// ids of its links, if they were stored.
+func (s *Store) LoadLog(id int64) (*propagation.Log, error) {
+	rows, err := s.db.Query(`SELECT ts, nodes, links, messages FROM steps WHERE run_id = ? ORDER BY step`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		plog     = propagation.NewLog(0)
+		messages [][]string
+		tagged   bool
+	)
+	for rows.Next() {
+		var (
+			ts                   int
+			nodesJSON, linksJSON string
+			messagesJSON         sql.NullString
+			nodes, links         []int
+			msgs                 []string
+		)
+		if err := rows.Scan(&ts, &nodesJSON, &linksJSON, &messagesJSON); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(nodesJSON), &nodes); err != nil {
+			return nil, fmt.Errorf("parse nodes: %v", err)
+		}
+		if err := json.Unmarshal([]byte(linksJSON), &links); err != nil {
+			return nil, fmt.Errorf("parse links: %v", err)
+		}
+		if messagesJSON.Valid {
+			if err := json.Unmarshal([]byte(messagesJSON.String), &msgs); err != nil {
+				return nil, fmt.Errorf("parse messages: %v", err)
+			}
+			tagged = true
+		}
+		plog.AddStep(ts, nodes, links)
+		messages = append(messages, msgs)
+	}
+	if tagged {
+		plog.Messages = messages
+	}
+	return plog, rows.Err()
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/divan/simulation/propagation"
+	"github.com/divan/simulation/stats"
+)
+
+// testLog returns log of message sent by node 0 over the line 0-1-2,
+// with links 0 (0-1) and 1 (1-2).
+func testLog() *propagation.Log {
+	plog := propagation.NewLog(2)
+	plog.AddStep(10, []int{0, 1}, []int{0})
+	plog.AddStep(25, []int{1, 2}, []int{1})
+	return plog
+}
+
+func saveAndLoad(t *testing.T, plog *propagation.Log) (*Store, int64, *propagation.Log) {
+	s, err := Open(filepath.Join(t.TempDir(), "runs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	id, err := s.SaveRun(&Run{
+		Started:   time.Now(),
+		Algorithm: "gossip",
+		Network:   "network.json",
+		Nodes:     3,
+		Links:     2,
+		TTL:       10,
+		Size:      100,
+		Log:       plog,
+		Stats:     stats.Analyze(plog, 3, 2),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := s.LoadLog(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, id, loaded
+}
+
+func TestRoundTrip(t *testing.T) {
+	plog := testLog()
+	s, id, loaded := saveAndLoad(t, plog)
+	if !reflect.DeepEqual(loaded, plog) {
+		t.Fatalf("Expected loaded log %+v, got %+v", plog, loaded)
+	}
+
+	rows, err := s.db.Query(`SELECT node, parent, ts FROM arrivals WHERE run_id = ? ORDER BY node`, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	type arrival struct {
+		node   int
+		parent sql.NullInt64
+		ts     int
+	}
+	var got []arrival
+	for rows.Next() {
+		var a arrival
+		if err := rows.Scan(&a.node, &a.parent, &a.ts); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, a)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []arrival{
+		{0, sql.NullInt64{}, 0},
+		{1, sql.NullInt64{Int64: 0, Valid: true}, 10},
+		{2, sql.NullInt64{Int64: 1, Valid: true}, 25},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected arrivals %v, got %v", expected, got)
+	}
+}
+
+func TestRoundTripMessages(t *testing.T) {
+	plog := testLog().WithMessage("a")
+	_, _, loaded := saveAndLoad(t, plog)
+	if !reflect.DeepEqual(loaded.Messages, plog.Messages) {
+		t.Fatalf("Expected loaded messages %v, got %v", plog.Messages, loaded.Messages)
+	}
+}
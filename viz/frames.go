@@ -0,0 +1,75 @@
+package viz
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+)
+
+// Frames represents propagation data in the timestamped frames format
+// consumed by the WebGL p2p visualization frontend. Unlike propagation.Log,
+// it's self-contained: it includes node positions and links, so frontend
+// doesn't have to calculate layout on its own.
+type Frames struct {
+	Nodes  []FrameNode `json:"nodes"`
+	Links  []Link      `json:"links"`
+	Frames []Frame     `json:"frames"`
+}
+
+// FrameNode represents positioned network node.
+type FrameNode struct {
+	ID string `json:"id"`
+	Position
+}
+
+// Frame represents nodes and links active at the given timestamp.
+type Frame struct {
+	Ts    int   `json:"ts"` // timestamp in milliseconds starting from T0
+	Nodes []int `json:"nodes"`
+	Links []int `json:"links"`
+}
+
+// NewFrames converts propagation log into Frames, sorted by timestamp.
+// If positions is nil, layout is calculated using Layout, otherwise there
+// should be position for each node of the graph.
+func NewFrames(data *graph.Graph, plog *propagation.Log, positions []Position) (*Frames, error) {
+	if positions == nil {
+		positions = Layout(data, 300)
+	}
+
+	network := NewNetwork(data)
+	if len(positions) != len(network.Nodes) {
+		return nil, fmt.Errorf("got %d positions for %d nodes", len(positions), len(network.Nodes))
+	}
+	ret := &Frames{
+		Nodes:  make([]FrameNode, 0, len(network.Nodes)),
+		Links:  network.Links,
+		Frames: make([]Frame, 0, plog.Len()),
+	}
+	for i, node := range network.Nodes {
+		ret.Nodes = append(ret.Nodes, FrameNode{
+			ID:       node.ID,
+			Position: positions[i],
+		})
+	}
+	for i, ts := range plog.Timestamps {
+		ret.Frames = append(ret.Frames, Frame{
+			Ts:    ts,
+			Nodes: plog.Nodes[i],
+			Links: plog.Links[i],
+		})
+	}
+	sort.SliceStable(ret.Frames, func(i, j int) bool {
+		return ret.Frames[i].Ts < ret.Frames[j].Ts
+	})
+	return ret, nil
+}
+
+// WriteJSON writes frames in JSON format to the given io.Writer.
+func (f *Frames) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(f)
+}
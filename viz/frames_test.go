@@ -0,0 +1,69 @@
+package viz
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+)
+
+// node implements string-only graph.Node
+type node string
+
+func (n node) ID() string { return string(n) }
+
+func testGraph() *graph.Graph {
+	g := graph.NewGraph()
+	g.AddNode(node("0"))
+	g.AddNode(node("1"))
+	g.AddNode(node("2"))
+	g.AddLink("0", "1")
+	g.AddLink("1", "2")
+	return g
+}
+
+func TestFramesJSON(t *testing.T) {
+	// frames are sorted, even if log isn't
+	plog := &propagation.Log{
+		Timestamps: []int{20, 10},
+		Nodes: [][]int{
+			[]int{1, 2},
+			[]int{0, 1},
+		},
+		Links: [][]int{
+			[]int{1},
+			[]int{0},
+		},
+	}
+	positions := []Position{{X: 1}, {Y: 2}, {Z: 3}}
+
+	frames, err := NewFrames(testGraph(), plog, positions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := frames.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"nodes":[{"id":"0","x":1,"y":0,"z":0},{"id":"1","x":0,"y":2,"z":0},{"id":"2","x":0,"y":0,"z":3}],` +
+		`"links":[{"source":0,"target":1},{"source":1,"target":2}],` +
+		`"frames":[{"ts":10,"nodes":[0,1],"links":[0]},{"ts":20,"nodes":[1,2],"links":[1]}]}` + "\n"
+	if got := buf.String(); got != expected {
+		t.Fatalf("Expected frames\n%s, got\n%s", expected, got)
+	}
+}
+
+func TestFramesPositions(t *testing.T) {
+	if _, err := NewFrames(testGraph(), &propagation.Log{}, []Position{{}, {}}); err == nil {
+		t.Fatal("Expected error for fewer positions than nodes")
+	}
+	frames, err := NewFrames(testGraph(), &propagation.Log{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames.Nodes) != 3 {
+		t.Fatalf("Expected layout for 3 nodes, got %d", len(frames.Nodes))
+	}
+}
@@ -0,0 +1,174 @@
+package viz
+
+// indexHTML is a self-contained visualization page. It lays out the
+// graph using simple force-directed algorithm and replays the propagation
+// log on canvas.
+const indexHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Propagation visualization</title>
+<style>
+	body { margin: 0; background: #111; color: #ddd; font-family: sans-serif; overflow: hidden; }
+	#controls { position: absolute; top: 10px; left: 10px; }
+	#controls button { margin-right: 6px; }
+	canvas { display: block; }
+</style>
+</head>
+<body>
+<div id="controls">
+	<button id="play">Play</button>
+	<button id="reset">Reset</button>
+	speed <select id="speed"><option>0.25</option><option>0.5</option><option selected>1</option><option>2</option><option>5</option></select>
+	<span id="status"></span>
+</div>
+<canvas id="canvas"></canvas>
+<script>
+(function() {
+	var canvas = document.getElementById("canvas");
+	var ctx = canvas.getContext("2d");
+	var status = document.getElementById("status");
+	var network, plog, steps = [];
+	var pos = [], hitNodes = {}, activeLinks = [];
+	var playing = false, t0 = 0, elapsed = 0, stepIdx = 0;
+
+	function resize() {
+		canvas.width = window.innerWidth;
+		canvas.height = window.innerHeight;
+	}
+	window.addEventListener("resize", function() { resize(); draw(); });
+	resize();
+
+	// layout calculates node positions using naive force-directed algorithm.
+	function layout() {
+		var n = network.nodes.length;
+		for (var i = 0; i < n; i++) {
+			var a = 2 * Math.PI * i / n;
+			pos.push({x: Math.cos(a), y: Math.sin(a), dx: 0, dy: 0});
+		}
+		var k = Math.sqrt(4 / Math.max(n, 1));
+		for (var iter = 0; iter < 200; iter++) {
+			var temp = 0.1 * (1 - iter / 200);
+			for (var i = 0; i < n; i++) { pos[i].dx = 0; pos[i].dy = 0; }
+			for (var i = 0; i < n; i++) {
+				for (var j = i + 1; j < n; j++) {
+					var dx = pos[i].x - pos[j].x, dy = pos[i].y - pos[j].y;
+					var d2 = dx * dx + dy * dy + 1e-6;
+					var f = k * k / d2;
+					pos[i].dx += dx * f; pos[i].dy += dy * f;
+					pos[j].dx -= dx * f; pos[j].dy -= dy * f;
+				}
+			}
+			network.links.forEach(function(l) {
+				var a = pos[l.source], b = pos[l.target];
+				var dx = a.x - b.x, dy = a.y - b.y;
+				var d = Math.sqrt(dx * dx + dy * dy) + 1e-6;
+				var f = d / k;
+				a.dx -= dx * f; a.dy -= dy * f;
+				b.dx += dx * f; b.dy += dy * f;
+			});
+			for (var i = 0; i < n; i++) {
+				var d = Math.sqrt(pos[i].dx * pos[i].dx + pos[i].dy * pos[i].dy) + 1e-6;
+				pos[i].x += pos[i].dx / d * Math.min(d, temp);
+				pos[i].y += pos[i].dy / d * Math.min(d, temp);
+			}
+		}
+		var minX = Infinity, maxX = -Infinity, minY = Infinity, maxY = -Infinity;
+		pos.forEach(function(p) {
+			minX = Math.min(minX, p.x); maxX = Math.max(maxX, p.x);
+			minY = Math.min(minY, p.y); maxY = Math.max(maxY, p.y);
+		});
+		pos.forEach(function(p) {
+			p.x = (p.x - minX) / ((maxX - minX) || 1);
+			p.y = (p.y - minY) / ((maxY - minY) || 1);
+		});
+	}
+
+	function screen(p) {
+		var m = 40, w = canvas.width - 2 * m, h = canvas.height - 2 * m;
+		return {x: m + p.x * w, y: m + p.y * h};
+	}
+
+	function draw() {
+		if (!network) return;
+		ctx.clearRect(0, 0, canvas.width, canvas.height);
+		ctx.strokeStyle = "#333";
+		ctx.lineWidth = 1;
+		ctx.beginPath();
+		network.links.forEach(function(l) {
+			var a = screen(pos[l.source]), b = screen(pos[l.target]);
+			ctx.moveTo(a.x, a.y); ctx.lineTo(b.x, b.y);
+		});
+		ctx.stroke();
+
+		ctx.strokeStyle = "#ff9900";
+		ctx.lineWidth = 2;
+		ctx.beginPath();
+		activeLinks.forEach(function(idx) {
+			var l = network.links[idx];
+			if (!l) return;
+			var a = screen(pos[l.source]), b = screen(pos[l.target]);
+			ctx.moveTo(a.x, a.y); ctx.lineTo(b.x, b.y);
+		});
+		ctx.stroke();
+
+		network.nodes.forEach(function(n, i) {
+			var p = screen(pos[i]);
+			ctx.fillStyle = hitNodes[i] ? "#ff3300" : "#3399ff";
+			ctx.beginPath();
+			ctx.arc(p.x, p.y, 3, 0, 2 * Math.PI);
+			ctx.fill();
+		});
+		var covered = Object.keys(hitNodes).length;
+		status.textContent = elapsed.toFixed(0) + "ms, " + covered + "/" + network.nodes.length + " nodes";
+	}
+
+	function tick(now) {
+		if (!playing) return;
+		var speed = parseFloat(document.getElementById("speed").value);
+		elapsed += (now - t0) * speed;
+		t0 = now;
+		activeLinks = [];
+		while (stepIdx < steps.length && steps[stepIdx].ts <= elapsed) {
+			var s = steps[stepIdx];
+			s.nodes.forEach(function(n) { hitNodes[n] = true; });
+			activeLinks = activeLinks.concat(s.links);
+			stepIdx++;
+		}
+		draw();
+		if (stepIdx >= steps.length) {
+			playing = false;
+			return;
+		}
+		requestAnimationFrame(tick);
+	}
+
+	document.getElementById("play").onclick = function() {
+		if (playing) { playing = false; return; }
+		playing = true;
+		t0 = performance.now();
+		requestAnimationFrame(tick);
+	};
+	document.getElementById("reset").onclick = function() {
+		playing = false; elapsed = 0; stepIdx = 0; hitNodes = {}; activeLinks = [];
+		draw();
+	};
+
+	Promise.all([
+		fetch("network.json").then(function(r) { return r.json(); }),
+		fetch("propagation.json").then(function(r) { return r.json(); })
+	]).then(function(res) {
+		network = res[0];
+		plog = res[1];
+		for (var i = 0; i < plog.Timestamps.length; i++) {
+			steps.push({ts: plog.Timestamps[i], nodes: plog.Nodes[i] || [], links: plog.Links[i] || []});
+		}
+		steps.sort(function(a, b) { return a.ts - b.ts; });
+		layout();
+		draw();
+	});
+})();
+</script>
+</body>
+</html>
+`
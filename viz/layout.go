@@ -0,0 +1,97 @@
+package viz
+
+import (
+	"math"
+
+	"github.com/divan/graphx/graph"
+)
+
+// Position represents node position in 3D space.
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// Layout calculates 3D positions for graph nodes using naive
+// force-directed algorithm (Fruchterman-Reingold) with given number
+// of iterations. Resulting positions are centered around zero.
+// It's deterministic, so the same graph always gets the same layout.
+func Layout(data *graph.Graph, iterations int) []Position {
+	n := data.NumNodes()
+	pos := make([]Position, n)
+	if n == 0 {
+		return pos
+	}
+
+	// start with nodes spread on the sphere (Fibonacci lattice)
+	const size = 100.0
+	golden := math.Pi * (3 - math.Sqrt(5))
+	for i := range pos {
+		y := 1 - 2*(float64(i)+0.5)/float64(n)
+		r := math.Sqrt(1 - y*y)
+		a := golden * float64(i)
+		pos[i] = Position{X: size * r * math.Cos(a), Y: size * y, Z: size * r * math.Sin(a)}
+	}
+
+	k := size / math.Cbrt(float64(n))
+	disp := make([]Position, n)
+	for iter := 0; iter < iterations; iter++ {
+		temp := size / 10 * (1 - float64(iter)/float64(iterations))
+		for i := range disp {
+			disp[i] = Position{}
+		}
+
+		// repulsive forces between all nodes
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				dx, dy, dz := pos[i].X-pos[j].X, pos[i].Y-pos[j].Y, pos[i].Z-pos[j].Z
+				d2 := dx*dx + dy*dy + dz*dz + 1e-9
+				f := k * k / d2
+				disp[i].X += dx * f
+				disp[i].Y += dy * f
+				disp[i].Z += dz * f
+				disp[j].X -= dx * f
+				disp[j].Y -= dy * f
+				disp[j].Z -= dz * f
+			}
+		}
+
+		// attractive forces along links
+		for _, link := range data.Links() {
+			a, b := link.FromIdx(), link.ToIdx()
+			dx, dy, dz := pos[a].X-pos[b].X, pos[a].Y-pos[b].Y, pos[a].Z-pos[b].Z
+			d := math.Sqrt(dx*dx+dy*dy+dz*dz) + 1e-9
+			f := d / k
+			disp[a].X -= dx * f
+			disp[a].Y -= dy * f
+			disp[a].Z -= dz * f
+			disp[b].X += dx * f
+			disp[b].Y += dy * f
+			disp[b].Z += dz * f
+		}
+
+		for i := range pos {
+			d := math.Sqrt(disp[i].X*disp[i].X+disp[i].Y*disp[i].Y+disp[i].Z*disp[i].Z) + 1e-9
+			step := math.Min(d, temp)
+			pos[i].X += disp[i].X / d * step
+			pos[i].Y += disp[i].Y / d * step
+			pos[i].Z += disp[i].Z / d * step
+		}
+	}
+
+	// center layout
+	var c Position
+	for _, p := range pos {
+		c.X += p.X
+		c.Y += p.Y
+		c.Z += p.Z
+	}
+	c.X, c.Y, c.Z = c.X/float64(n), c.Y/float64(n), c.Z/float64(n)
+	for i := range pos {
+		pos[i].X -= c.X
+		pos[i].Y -= c.Y
+		pos[i].Z -= c.Z
+	}
+	return pos
+}
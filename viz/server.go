@@ -0,0 +1,96 @@
+// Package viz implements a small embedded web UI for animating message
+// propagation over the network graph in the browser.
+package viz
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/divan/graphx/graph"
+	"github.com/divan/simulation/propagation"
+)
+
+// Server serves visualization page along with network and propagation data.
+// Implements http.Handler.
+type Server struct {
+	data *graph.Graph
+	plog *propagation.Log
+	mux  *http.ServeMux
+}
+
+// NewServer creates new visualization server for the given network graph and propagation log.
+func NewServer(data *graph.Graph, plog *propagation.Log) *Server {
+	s := &Server{
+		data: data,
+		plog: plog,
+		mux:  http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/", s.indexHandler)
+	s.mux.HandleFunc("/network.json", s.networkHandler)
+	s.mux.HandleFunc("/propagation.json", s.propagationHandler)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) indexHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	io.WriteString(w, indexHTML)
+}
+
+func (s *Server) networkHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, NewNetwork(s.data))
+}
+
+func (s *Server) propagationHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.plog)
+}
+
+// Network represents network graph in the D3-compatible JSON format,
+// with links referring to nodes by their indices.
+type Network struct {
+	Nodes []Node `json:"nodes"`
+	Links []Link `json:"links"`
+}
+
+// Node represents single network node.
+type Node struct {
+	ID string `json:"id"`
+}
+
+// Link represents single network link.
+type Link struct {
+	Source int `json:"source"`
+	Target int `json:"target"`
+}
+
+// NewNetwork converts graph into Network.
+func NewNetwork(data *graph.Graph) *Network {
+	ret := &Network{
+		Nodes: make([]Node, 0, data.NumNodes()),
+		Links: make([]Link, 0, data.NumLinks()),
+	}
+	for _, node := range data.Nodes() {
+		ret.Nodes = append(ret.Nodes, Node{ID: node.ID()})
+	}
+	for _, link := range data.Links() {
+		ret.Links = append(ret.Links, Link{Source: link.FromIdx(), Target: link.ToIdx()})
+	}
+	return ret
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Can't encode JSON", "err", err)
+	}
+}